@@ -0,0 +1,154 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/goliatone/go-repository-bun/repositoryctx"
+	"github.com/uptrace/bun"
+)
+
+// AggregateStep writes part of an aggregate inside the shared transaction.
+// root is the root record as returned by its repository, so generated IDs
+// are already set. ctx also carries tx, see repositoryctx.Tx.
+type AggregateStep[Root any] func(ctx context.Context, tx bun.IDB, root Root) error
+
+// Aggregate coordinates the repositories of an aggregate, a root record and
+// its children, so every write runs in a single TransactionManager.RunInTx
+// call and any failure rolls back the whole aggregate:
+//
+//	orders := NewAggregate(db, orderRepo).
+//		OnCreate(CreateChildren(lineRepo, func(o *Order) []*Line { return o.Lines })).
+//		OnDelete(DeleteChildren(lineRepo, func(o *Order) []DeleteCriteria {
+//			return []DeleteCriteria{DeleteBy("order_id", "=", o.ID.String())}
+//		}))
+//	order, err := orders.Create(ctx, order)
+type Aggregate[Root any] struct {
+	tm        TransactionManager
+	root      Repository[Root]
+	txOptions *sql.TxOptions
+
+	onCreate []AggregateStep[Root]
+	onUpdate []AggregateStep[Root]
+	onDelete []AggregateStep[Root]
+}
+
+// NewAggregate returns an aggregate of root running its transactions on tm,
+// usually the *bun.DB, or a bun.Tx to nest the aggregate in a savepoint.
+func NewAggregate[Root any](tm TransactionManager, root Repository[Root]) *Aggregate[Root] {
+	return &Aggregate[Root]{tm: tm, root: root}
+}
+
+// WithTxOptions sets the options of the aggregate transactions.
+func (a *Aggregate[Root]) WithTxOptions(opts *sql.TxOptions) *Aggregate[Root] {
+	a.txOptions = opts
+	return a
+}
+
+// OnCreate adds steps run in order after the root is created.
+func (a *Aggregate[Root]) OnCreate(steps ...AggregateStep[Root]) *Aggregate[Root] {
+	a.onCreate = append(a.onCreate, steps...)
+	return a
+}
+
+// OnUpdate adds steps run in order after the root is updated.
+func (a *Aggregate[Root]) OnUpdate(steps ...AggregateStep[Root]) *Aggregate[Root] {
+	a.onUpdate = append(a.onUpdate, steps...)
+	return a
+}
+
+// OnDelete adds steps run in order before the root is deleted, so children
+// go first and foreign keys are satisfied.
+func (a *Aggregate[Root]) OnDelete(steps ...AggregateStep[Root]) *Aggregate[Root] {
+	a.onDelete = append(a.onDelete, steps...)
+	return a
+}
+
+// Create creates the root and runs the OnCreate steps.
+func (a *Aggregate[Root]) Create(ctx context.Context, root Root, criteria ...InsertCriteria) (Root, error) {
+	var created Root
+	err := a.tm.RunInTx(ctx, a.txOptions, func(ctx context.Context, tx bun.Tx) error {
+		record, err := a.root.CreateTx(ctx, tx, root, criteria...)
+		if err != nil {
+			return err
+		}
+		if err := runAggregateSteps(ctx, tx, record, a.onCreate); err != nil {
+			return err
+		}
+		created = record
+		return nil
+	})
+	return created, err
+}
+
+// Update updates the root and runs the OnUpdate steps.
+func (a *Aggregate[Root]) Update(ctx context.Context, root Root, criteria ...UpdateCriteria) (Root, error) {
+	var updated Root
+	err := a.tm.RunInTx(ctx, a.txOptions, func(ctx context.Context, tx bun.Tx) error {
+		record, err := a.root.UpdateTx(ctx, tx, root, criteria...)
+		if err != nil {
+			return err
+		}
+		if err := runAggregateSteps(ctx, tx, record, a.onUpdate); err != nil {
+			return err
+		}
+		updated = record
+		return nil
+	})
+	return updated, err
+}
+
+// Delete runs the OnDelete steps and deletes the root.
+func (a *Aggregate[Root]) Delete(ctx context.Context, root Root) error {
+	return a.tm.RunInTx(ctx, a.txOptions, func(ctx context.Context, tx bun.Tx) error {
+		if err := runAggregateSteps(ctx, tx, root, a.onDelete); err != nil {
+			return err
+		}
+		return a.root.DeleteTx(ctx, tx, root)
+	})
+}
+
+func runAggregateSteps[Root any](ctx context.Context, tx bun.IDB, root Root, steps []AggregateStep[Root]) error {
+	ctx = repositoryctx.WithTx(ctx, tx)
+	for _, step := range steps {
+		if step == nil {
+			continue
+		}
+		if err := step(ctx, tx, root); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// CreateChildren returns a step creating the records returned by children.
+func CreateChildren[Root, Child any](repo Repository[Child], children func(root Root) []Child, criteria ...InsertCriteria) AggregateStep[Root] {
+	return func(ctx context.Context, tx bun.IDB, root Root) error {
+		records := children(root)
+		if len(records) == 0 {
+			return nil
+		}
+		_, err := repo.CreateManyTx(ctx, tx, records, criteria...)
+		return err
+	}
+}
+
+// UpdateChildren returns a step updating the records returned by children.
+func UpdateChildren[Root, Child any](repo Repository[Child], children func(root Root) []Child, criteria ...UpdateCriteria) AggregateStep[Root] {
+	return func(ctx context.Context, tx bun.IDB, root Root) error {
+		records := children(root)
+		if len(records) == 0 {
+			return nil
+		}
+		_, err := repo.UpdateManyTx(ctx, tx, records, criteria...)
+		return err
+	}
+}
+
+// DeleteChildren returns a step deleting the child rows matched by criteria,
+// e.g. every row referencing the root.
+func DeleteChildren[Root, Child any](repo Repository[Child], criteria func(root Root) []DeleteCriteria) AggregateStep[Root] {
+	return func(ctx context.Context, tx bun.IDB, root Root) error {
+		return repo.DeleteWhereTx(ctx, tx, criteria(root)...)
+	}
+}
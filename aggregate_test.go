@@ -0,0 +1,73 @@
+package repository
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/uptrace/bun"
+)
+
+func TestAggregate(t *testing.T) {
+	setupTestData(t)
+	ctx := context.Background()
+
+	companies := newTestCompanyRepository(db)
+	users := newTestUserRepository(db)
+
+	emails := []string{"alice@example.com", "bob@example.com"}
+	members := func(company *TestCompany) []*TestUser {
+		records := make([]*TestUser, 0, len(emails))
+		for _, email := range emails {
+			records = append(records, &TestUser{Name: email, Email: email, CompanyID: company.ID})
+		}
+		return records
+	}
+	aggregate := NewAggregate[*TestCompany](db, companies).
+		OnCreate(CreateChildren(users, members)).
+		OnDelete(DeleteChildren[*TestCompany](users, func(company *TestCompany) []DeleteCriteria {
+			return []DeleteCriteria{DeleteBy("company_id", "=", company.ID.String())}
+		}))
+
+	company, err := aggregate.Create(ctx, &TestCompany{Name: "Acme", Identifier: "acme"})
+	require.NoError(t, err)
+	count, err := users.Count(ctx, SelectBy("company_id", "=", company.ID.String()))
+	require.NoError(t, err)
+	assert.Equal(t, 2, count)
+
+	// a failing child rolls back the root
+	_, err = aggregate.Create(ctx, &TestCompany{Name: "Globex", Identifier: "globex"})
+	require.Error(t, err)
+	assert.True(t, IsDuplicatedKey(err))
+	_, err = companies.GetByIdentifier(ctx, "globex")
+	assert.True(t, IsRecordNotFound(err))
+
+	require.NoError(t, aggregate.Delete(ctx, company))
+	count, err = users.Count(ctx)
+	require.NoError(t, err)
+	assert.Zero(t, count)
+	_, err = companies.GetByID(ctx, company.ID.String())
+	assert.True(t, IsRecordNotFound(err))
+}
+
+func TestAggregate_UpdateRollback(t *testing.T) {
+	setupTestData(t)
+	ctx := context.Background()
+
+	companies := newTestCompanyRepository(db)
+	company, err := companies.Create(ctx, &TestCompany{Name: "Acme", Identifier: "acme"})
+	require.NoError(t, err)
+
+	stepErr := assert.AnError
+	aggregate := NewAggregate[*TestCompany](db, companies).
+		OnUpdate(func(context.Context, bun.IDB, *TestCompany) error { return stepErr })
+
+	company.Name = "Renamed"
+	_, err = aggregate.Update(ctx, company)
+	assert.ErrorIs(t, err, stepErr)
+
+	found, err := companies.GetByID(ctx, company.ID.String())
+	require.NoError(t, err)
+	assert.Equal(t, "Acme", found.Name)
+}
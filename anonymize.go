@@ -0,0 +1,299 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/goliatone/go-errors"
+	"github.com/google/uuid"
+	"github.com/uptrace/bun"
+)
+
+const defaultAnonymizeBatchSize = 500
+
+// Anonymizer returns the SQL expression and arguments assigned to column when
+// a row is anonymized, e.g. ("NULL", nil) or ("?", []any{"[redacted]"}).
+// The expression is evaluated by the database, so the original value never
+// leaves it.
+type Anonymizer func(driver, column string) (string, []any, error)
+
+// AnonymizeNull clears the column.
+func AnonymizeNull() Anonymizer {
+	return func(string, string) (string, []any, error) {
+		return "NULL", nil, nil
+	}
+}
+
+// AnonymizeRedact overwrites the column with placeholder.
+func AnonymizeRedact(placeholder any) Anonymizer {
+	return func(string, string) (string, []any, error) {
+		return "?", []any{placeholder}, nil
+	}
+}
+
+// AnonymizePlaceholder overwrites the column with prefix followed by the
+// record ID, keeping values unique, e.g. for email columns with a unique index.
+func AnonymizePlaceholder(prefix string) Anonymizer {
+	return func(string, string) (string, []any, error) {
+		return "CONCAT(?, ?TableAlias.id)", []any{prefix}, nil
+	}
+}
+
+// AnonymizeHash replaces the column with a hex digest of its current value
+// (MD5 on Postgres, SHA-256 on MySQL and MSSQL), so equal inputs still match
+// after erasure. SQLite has no built-in hash function and is not supported.
+func AnonymizeHash() Anonymizer {
+	return func(driver, column string) (string, []any, error) {
+		ident := bun.Ident(column)
+		switch driver {
+		case "postgres":
+			return "md5(?TableAlias.?::text)", []any{ident}, nil
+		case "mysql":
+			return "SHA2(?TableAlias.?, 256)", []any{ident}, nil
+		case "mssql":
+			return "CONVERT(VARCHAR(64), HASHBYTES('SHA2_256', CAST(?TableAlias.? AS NVARCHAR(MAX))), 2)", []any{ident}, nil
+		default:
+			return "", nil, unsupportedDriverError("AnonymizeHash", driver)
+		}
+	}
+}
+
+// ErasureAudit records an Anonymize run: which columns of which table were
+// erased and how many rows were affected. It never stores erased values.
+type ErasureAudit struct {
+	bun.BaseModel `bun:"table:repository_erasure_audits,alias:rea"`
+
+	ID        string    `bun:"id,pk" json:"id"`
+	Scope     string    `bun:"scope,notnull" json:"scope"`
+	Columns   string    `bun:"columns,notnull" json:"columns"`
+	Rows      int64     `bun:"rows,notnull" json:"rows"`
+	CreatedAt time.Time `bun:"created_at,notnull" json:"created_at"`
+}
+
+// RecordAnonymizer is an optional capability for repositories that can erase
+// PII in place for right-to-be-forgotten workflows. The audit table must
+// exist, see CreateErasureAuditTable.
+type RecordAnonymizer interface {
+	Anonymize(ctx context.Context, criteria []UpdateCriteria, rules map[string]Anonymizer) (ErasureAudit, error)
+	AnonymizeTx(ctx context.Context, tx bun.IDB, criteria []UpdateCriteria, rules map[string]Anonymizer) (ErasureAudit, error)
+}
+
+// CreateErasureAuditTable creates the table backing Anonymize audit entries
+// if it does not exist yet.
+func CreateErasureAuditTable(ctx context.Context, db bun.IDB) error {
+	_, err := db.NewCreateTable().
+		Model((*ErasureAudit)(nil)).
+		IfNotExists().
+		Exec(ctx)
+	return err
+}
+
+// Anonymize overwrites the columns in rules for every row matched by criteria,
+// soft deleted rows included, and records an ErasureAudit entry. Rows are
+// updated in batches of WithAnonymizeBatchSize IDs; outside a transaction
+// every batch commits on its own, so an interrupted run can be resumed by
+// calling Anonymize again. Calls without criteria are blocked unless
+// WithAllowFullTableUpdate(true) is configured.
+func (r *repo[T]) Anonymize(ctx context.Context, criteria []UpdateCriteria, rules map[string]Anonymizer) (ErasureAudit, error) {
+	return r.AnonymizeTx(ctx, r.db, criteria, rules)
+}
+
+func (r *repo[T]) AnonymizeTx(ctx context.Context, tx bun.IDB, criteria []UpdateCriteria, rules map[string]Anonymizer) (ErasureAudit, error) {
+	ctx = r.withOperation(ctx, "Anonymize", len(criteria))
+	assignments, columns, err := r.anonymizeAssignments(rules)
+	if err != nil {
+		return ErasureAudit{}, err
+	}
+
+	_, hasWhere, err := r.probeUpdateCriteria(tx, criteria)
+	if err != nil {
+		return ErasureAudit{}, err
+	}
+	if !hasWhere && !r.allowFullTableUpdate {
+		return ErasureAudit{}, fullTableOperationBlockedError("update", "WithAllowFullTableUpdate")
+	}
+
+	batchSize := r.anonymizeBatchSize
+	if batchSize <= 0 {
+		batchSize = defaultAnonymizeBatchSize
+	}
+
+	var rows int64
+	last := ""
+	for {
+		ids, err := r.anonymizeBatchIDs(ctx, tx, last, batchSize)
+		if err != nil {
+			return ErasureAudit{}, err
+		}
+		if len(ids) == 0 {
+			break
+		}
+
+		affected, err := r.anonymizeBatch(ctx, tx, ids, criteria, assignments)
+		if err != nil {
+			return ErasureAudit{}, err
+		}
+		rows += affected
+
+		last = ids[len(ids)-1]
+		if len(ids) < batchSize {
+			break
+		}
+	}
+
+	audit := ErasureAudit{
+		ID:        uuid.NewString(),
+		Scope:     r.TableName(),
+		Columns:   strings.Join(columns, ","),
+		Rows:      rows,
+		CreatedAt: time.Now().UTC(),
+	}
+	if _, err := tx.NewInsert().Model(&audit).Exec(ctx); err != nil {
+		return ErasureAudit{}, r.mapError(err)
+	}
+	return audit, nil
+}
+
+type anonymizeAssignment struct {
+	column string
+	expr   string
+	args   []any
+}
+
+func (r *repo[T]) anonymizeAssignments(rules map[string]Anonymizer) ([]anonymizeAssignment, []string, error) {
+	if len(rules) == 0 {
+		return nil, nil, errors.NewValidation(
+			"repository: Anonymize requires rules",
+			errors.FieldError{Field: "rules", Message: "at least one column rule is required"},
+		)
+	}
+
+	columns := make([]string, 0, len(rules))
+	for column := range rules {
+		columns = append(columns, column)
+	}
+	sort.Strings(columns)
+
+	table := r.modelTable()
+	assignments := make([]anonymizeAssignment, 0, len(columns))
+	for _, column := range columns {
+		col, ok := normalizeSQLIdentifier(column)
+		if !ok {
+			return nil, nil, invalidColumnError("rules", column)
+		}
+		if table != nil {
+			field, ok := table.FieldMap[col]
+			if !ok {
+				return nil, nil, errors.NewValidation(
+					"repository: unknown column",
+					errors.FieldError{Field: "rules", Message: fmt.Sprintf("column %q does not exist on %s", col, table.Name)},
+				)
+			}
+			if field.IsPK {
+				return nil, nil, errors.NewValidation(
+					"repository: primary key cannot be anonymized",
+					errors.FieldError{Field: "rules", Message: fmt.Sprintf("column %q is a primary key", col)},
+				)
+			}
+		}
+
+		rule := rules[column]
+		if rule == nil {
+			return nil, nil, errors.NewValidation(
+				"repository: Anonymize rule is nil",
+				errors.FieldError{Field: "rules", Message: fmt.Sprintf("column %q has no anonymizer", col)},
+			)
+		}
+		expr, args, err := rule(r.driver, col)
+		if err != nil {
+			return nil, nil, err
+		}
+		assignments = append(assignments, anonymizeAssignment{column: col, expr: expr, args: args})
+	}
+	return assignments, columns, nil
+}
+
+func (r *repo[T]) anonymizeBatchIDs(ctx context.Context, tx bun.IDB, after string, limit int) ([]string, error) {
+	ids := []string{}
+	q := tx.NewSelect().
+		Model(r.handlers.NewRecord()).
+		Column("id").
+		OrderExpr("?TableAlias.id ASC").
+		Limit(limit)
+	if querySoftDeletes(q) {
+		q = q.WhereAllWithDeleted()
+	}
+	if after != "" {
+		q = q.Where("?TableAlias.id > ?", after)
+	}
+	if err := q.Scan(ctx, &ids); err != nil {
+		return nil, r.mapError(err)
+	}
+	return ids, nil
+}
+
+func (r *repo[T]) anonymizeBatch(ctx context.Context, tx bun.IDB, ids []string, criteria []UpdateCriteria, assignments []anonymizeAssignment) (int64, error) {
+	if r.hasSoftDelete() {
+		criteria = append([]UpdateCriteria{UpdateDeletedAlso()}, criteria...)
+	}
+	newQuery := func(tx bun.IDB) (*bun.UpdateQuery, error) {
+		q := tx.NewUpdate().Model(r.handlers.NewRecord())
+		if err := r.applyUpdateCriteria(q, criteria); err != nil {
+			return nil, err
+		}
+		for _, assignment := range assignments {
+			q = q.Set("? = "+assignment.expr, append([]any{bun.Ident(assignment.column)}, assignment.args...)...)
+		}
+		q = q.Where("?TableAlias.id IN (?)", bun.In(ids))
+		return r.applyUpdateScopes(ctx, q), nil
+	}
+
+	return r.execWithHistory(ctx, tx, EventUpdate,
+		func(ctx context.Context, tx bun.IDB, returning string, changed *[]uuid.UUID) error {
+			q, err := newQuery(tx)
+			if err != nil {
+				return err
+			}
+			_, err = q.Returning(returning).Exec(ctx, changed)
+			return err
+		},
+		func(ctx context.Context, tx bun.IDB, changed []uuid.UUID) (int64, error) {
+			q, err := newQuery(tx)
+			if err != nil {
+				return 0, err
+			}
+			if changed != nil {
+				if err := r.scrubHistory(ctx, tx, changed, assignments); err != nil {
+					return 0, err
+				}
+				query, args := r.whereInIDs(changed)
+				q.Where(query, args...)
+			}
+			return r.execUpdate(ctx, tx, q)
+		},
+	)
+}
+
+// scrubHistory overwrites the anonymized columns of every version of the rows
+// with ids in the history table, including the version just recorded, so
+// erased values do not survive in the history.
+func (r *repo[T]) scrubHistory(ctx context.Context, tx bun.IDB, ids []uuid.UUID, assignments []anonymizeAssignment) error {
+	table, err := r.historyModelTable()
+	if err != nil {
+		return err
+	}
+	q := tx.NewUpdate().
+		Model(r.handlers.NewRecord()).
+		ModelTableExpr("? AS ?", bun.Ident(r.HistoryTableName()), bun.Safe(table.SQLAlias))
+	if querySoftDeletes(q) {
+		q = q.WhereAllWithDeleted()
+	}
+	for _, assignment := range assignments {
+		q = q.Set("? = "+assignment.expr, append([]any{bun.Ident(assignment.column)}, assignment.args...)...)
+	}
+	_, err = q.Where("?TableAlias.? IN (?)", bun.Ident(table.PKs[0].Name), bun.In(ids)).Exec(ctx)
+	return r.mapError(err)
+}
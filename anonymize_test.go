@@ -0,0 +1,85 @@
+package repository
+
+import (
+	"context"
+	"testing"
+
+	goerrors "github.com/goliatone/go-errors"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRepository_Anonymize(t *testing.T) {
+	setupTestData(t)
+	ctx := context.Background()
+	require.NoError(t, CreateErasureAuditTable(ctx, db))
+
+	repo := newTestUserRepositoryWithConfig(db, nil, WithAnonymizeBatchSize(1))
+	erased, kept := uuid.New(), uuid.New()
+	users, err := repo.CreateMany(ctx, []*TestUser{
+		{Name: "Alice", Email: "alice@example.com", CompanyID: erased},
+		{Name: "Bob", Email: "bob@example.com", CompanyID: erased},
+		{Name: "Carol", Email: "carol@example.com", CompanyID: kept},
+	})
+	require.NoError(t, err)
+
+	anonymizer, ok := repo.(RecordAnonymizer)
+	require.True(t, ok)
+
+	audit, err := anonymizer.Anonymize(ctx,
+		[]UpdateCriteria{UpdateBy("company_id", "=", erased.String())},
+		map[string]Anonymizer{
+			"name":  AnonymizeRedact("[redacted]"),
+			"email": AnonymizePlaceholder("erased-"),
+		},
+	)
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), audit.Rows)
+	assert.Equal(t, "email,name", audit.Columns)
+	assert.Equal(t, "test_users", audit.Scope)
+
+	for _, user := range users {
+		got, err := repo.GetByID(ctx, user.ID.String())
+		require.NoError(t, err)
+		if user.CompanyID == kept {
+			assert.Equal(t, user.Name, got.Name)
+			assert.Equal(t, user.Email, got.Email)
+			continue
+		}
+		assert.Equal(t, "[redacted]", got.Name)
+		assert.Equal(t, "erased-"+user.ID.String(), got.Email)
+	}
+
+	stored := new(ErasureAudit)
+	require.NoError(t, db.NewSelect().Model(stored).Where("id = ?", audit.ID).Scan(ctx))
+	assert.Equal(t, int64(2), stored.Rows)
+}
+
+func TestRepository_Anonymize_Validation(t *testing.T) {
+	setupTestData(t)
+	ctx := context.Background()
+	anonymizer, ok := newTestUserRepository(db).(RecordAnonymizer)
+	require.True(t, ok)
+	criteria := []UpdateCriteria{UpdateBy("name", "=", "Alice")}
+
+	tests := []struct {
+		name     string
+		criteria []UpdateCriteria
+		rules    map[string]Anonymizer
+	}{
+		{name: "no rules", criteria: criteria},
+		{name: "unknown column", criteria: criteria, rules: map[string]Anonymizer{"ssn": AnonymizeNull()}},
+		{name: "primary key", criteria: criteria, rules: map[string]Anonymizer{"id": AnonymizeNull()}},
+		{name: "hash on sqlite", criteria: criteria, rules: map[string]Anonymizer{"name": AnonymizeHash()}},
+		{name: "no criteria", rules: map[string]Anonymizer{"name": AnonymizeNull()}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := anonymizer.Anonymize(ctx, tt.criteria, tt.rules)
+			require.Error(t, err)
+			assert.True(t, goerrors.IsValidation(err))
+		})
+	}
+}
@@ -0,0 +1,227 @@
+package repository
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	defaultBatchLoaderWindow   = 2 * time.Millisecond
+	defaultBatchLoaderMaxBatch = 500
+)
+
+// BatchLoaderOption configures NewBatchLoader.
+type BatchLoaderOption func(*batchLoaderConfig)
+
+type batchLoaderConfig struct {
+	window   time.Duration
+	maxBatch int
+}
+
+// WithWindow sets how long a batch collects IDs after its first Load before
+// running. Defaults to 2ms.
+func WithWindow(window time.Duration) BatchLoaderOption {
+	return func(cfg *batchLoaderConfig) {
+		if window > 0 {
+			cfg.window = window
+		}
+	}
+}
+
+// WithMaxBatch caps the distinct IDs of a batch; a full batch runs right
+// away. Defaults to 500.
+func WithMaxBatch(size int) BatchLoaderOption {
+	return func(cfg *batchLoaderConfig) {
+		if size > 0 {
+			cfg.maxBatch = size
+		}
+	}
+}
+
+// BatchLoader coalesces concurrent Load calls into a single GetByIDs query,
+// the dataloader pattern that removes N+1 queries from GraphQL resolvers:
+//
+//	loader := repository.NewBatchLoader(userRepo, repository.WithWindow(2*time.Millisecond))
+//
+//	// in each resolver, concurrently
+//	author, err := loader.Load(ctx, post.AuthorID.String())
+//
+// The batch runs with the context of the Load that started it, without its
+// cancellation; every caller still stops waiting when its own context is
+// done. Loaders keep no cache, so records are always fresh, but concurrent
+// callers asking for the same ID share the same record value.
+type BatchLoader[T any] struct {
+	repo Repository[T]
+	cfg  batchLoaderConfig
+
+	mu      sync.Mutex
+	pending *loaderBatch[T]
+}
+
+type loaderBatch[T any] struct {
+	ctx     context.Context
+	ids     []string
+	index   map[string]int
+	results []loaderResult[T]
+	timer   *time.Timer
+	done    chan struct{}
+}
+
+type loaderResult[T any] struct {
+	record T
+	err    error
+}
+
+// NewBatchLoader returns a loader batching the GetByID lookups of repo into
+// GetByIDs queries when repo is a BatchGetter.
+func NewBatchLoader[T any](repo Repository[T], opts ...BatchLoaderOption) *BatchLoader[T] {
+	cfg := batchLoaderConfig{window: defaultBatchLoaderWindow, maxBatch: defaultBatchLoaderMaxBatch}
+	for _, opt := range opts {
+		if opt != nil {
+			opt(&cfg)
+		}
+	}
+	return &BatchLoader[T]{repo: repo, cfg: cfg}
+}
+
+// Load returns the record with id, loaded together with the IDs requested
+// by concurrent calls. Missing records return a not found error.
+func (l *BatchLoader[T]) Load(ctx context.Context, id string) (T, error) {
+	batch, pos := l.enqueue(ctx, id)
+
+	select {
+	case <-batch.done:
+		result := batch.results[pos]
+		return result.record, result.err
+	case <-ctx.Done():
+		var zero T
+		return zero, ctx.Err()
+	}
+}
+
+// LoadMany loads ids through the loader and returns the records in input
+// order, with the error of each ID at the same position.
+func (l *BatchLoader[T]) LoadMany(ctx context.Context, ids []string) ([]T, []error) {
+	records := make([]T, len(ids))
+	errs := make([]error, len(ids))
+
+	var wg sync.WaitGroup
+	for i, id := range ids {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			records[i], errs[i] = l.Load(ctx, id)
+		}()
+	}
+	wg.Wait()
+	return records, errs
+}
+
+// enqueue adds id to the pending batch, starting a new one when needed, and
+// returns the batch with the position of the id result.
+func (l *BatchLoader[T]) enqueue(ctx context.Context, id string) (*loaderBatch[T], int) {
+	key := normalizeLoaderID(id)
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	batch := l.pending
+	if batch == nil {
+		batch = &loaderBatch[T]{
+			ctx:   context.WithoutCancel(ctx),
+			index: make(map[string]int),
+			done:  make(chan struct{}),
+		}
+		batch.timer = time.AfterFunc(l.cfg.window, func() { l.dispatch(batch) })
+		l.pending = batch
+	}
+
+	pos, ok := batch.index[key]
+	if !ok {
+		pos = len(batch.ids)
+		batch.index[key] = pos
+		batch.ids = append(batch.ids, key)
+	}
+
+	if len(batch.ids) >= l.cfg.maxBatch && batch.timer.Stop() {
+		l.pending = nil
+		go l.run(batch)
+	}
+	return batch, pos
+}
+
+// dispatch runs batch once its window elapsed.
+func (l *BatchLoader[T]) dispatch(batch *loaderBatch[T]) {
+	l.mu.Lock()
+	if l.pending == batch {
+		l.pending = nil
+	}
+	l.mu.Unlock()
+	l.run(batch)
+}
+
+func (l *BatchLoader[T]) run(batch *loaderBatch[T]) {
+	defer close(batch.done)
+
+	batch.results = make([]loaderResult[T], len(batch.ids))
+	records, err := l.getByIDs(batch.ctx, batch.ids)
+	if err != nil && !IsPartialResult(err) {
+		for i := range batch.results {
+			batch.results[i].err = err
+		}
+		return
+	}
+
+	getID := l.repo.Handlers().GetID
+	byID := make(map[string]T, len(records))
+	for _, record := range records {
+		byID[getID(record).String()] = record
+	}
+	for i, id := range batch.ids {
+		if record, ok := byID[id]; ok {
+			batch.results[i].record = record
+			continue
+		}
+		batch.results[i].err = NewRecordNotFound()
+	}
+}
+
+// getByIDs loads ids with GetByIDs, or with one GetByID per ID when the
+// repository is not a BatchGetter, e.g. a hand written implementation.
+func (l *BatchLoader[T]) getByIDs(ctx context.Context, ids []string) ([]T, error) {
+	if getter, ok := l.repo.(BatchGetter[T]); ok {
+		return getter.GetByIDs(ctx, ids)
+	}
+
+	records := make([]T, 0, len(ids))
+	var missing []string
+	for _, id := range ids {
+		record, err := l.repo.GetByID(ctx, id)
+		if IsRecordNotFound(err) {
+			missing = append(missing, id)
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		records = append(records, record)
+	}
+	if len(missing) > 0 {
+		return records, &PartialResultError{MissingIDs: missing}
+	}
+	return records, nil
+}
+
+// normalizeLoaderID formats UUIDs the way GetID renders them, so results
+// match IDs given in another case.
+func normalizeLoaderID(id string) string {
+	id = strings.TrimSpace(id)
+	if parsed, err := uuid.Parse(id); err == nil {
+		return parsed.String()
+	}
+	return id
+}
@@ -0,0 +1,126 @@
+package repository
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/uptrace/bun"
+)
+
+type batchCountingRepository struct {
+	Repository[*TestUser]
+	batches atomic.Int32
+	sizes   []int
+	mu      sync.Mutex
+}
+
+func (r *batchCountingRepository) GetByIDs(ctx context.Context, ids []string, criteria ...SelectCriteria) ([]*TestUser, error) {
+	r.batches.Add(1)
+	r.mu.Lock()
+	r.sizes = append(r.sizes, len(ids))
+	r.mu.Unlock()
+	return r.Repository.(BatchGetter[*TestUser]).GetByIDs(ctx, ids, criteria...)
+}
+
+func (r *batchCountingRepository) GetByIDsTx(ctx context.Context, tx bun.IDB, ids []string, criteria ...SelectCriteria) ([]*TestUser, error) {
+	return r.Repository.(BatchGetter[*TestUser]).GetByIDsTx(ctx, tx, ids, criteria...)
+}
+
+func seedBatchLoaderUsers(t *testing.T, n int) (*batchCountingRepository, []*TestUser) {
+	t.Helper()
+	setupTestData(t)
+	repo := &batchCountingRepository{Repository: newTestUserRepository(db)}
+
+	users := make([]*TestUser, 0, n)
+	for range n {
+		user, err := repo.Create(context.Background(), &TestUser{Name: "Loader", Email: uuid.NewString() + "@example.com"})
+		require.NoError(t, err)
+		users = append(users, user)
+	}
+	return repo, users
+}
+
+func TestBatchLoader_CoalescesConcurrentLoads(t *testing.T) {
+	ctx := context.Background()
+	repo, users := seedBatchLoaderUsers(t, 5)
+	loader := NewBatchLoader[*TestUser](repo, WithWindow(20*time.Millisecond))
+
+	ids := make([]string, 0, 8)
+	for _, user := range users {
+		ids = append(ids, user.ID.String())
+	}
+	ids = append(ids, strings.ToUpper(users[0].ID.String()), users[1].ID.String())
+	missing := uuid.NewString()
+	ids = append(ids, missing)
+
+	records, errs := loader.LoadMany(ctx, ids)
+	assert.Equal(t, int32(1), repo.batches.Load())
+	assert.Equal(t, []int{6}, repo.sizes, "duplicate IDs are loaded once")
+
+	for i, user := range users {
+		require.NoError(t, errs[i])
+		assert.Equal(t, user.ID, records[i].ID)
+	}
+	require.NoError(t, errs[5])
+	assert.Same(t, records[0], records[5], "callers of the same ID share the record")
+	assert.Equal(t, users[1].ID, records[6].ID)
+	assert.True(t, IsRecordNotFound(errs[7]))
+	assert.Nil(t, records[7])
+
+	// a new batch starts after the previous one ran
+	record, err := loader.Load(ctx, users[2].ID.String())
+	require.NoError(t, err)
+	assert.Equal(t, users[2].ID, record.ID)
+	assert.Equal(t, int32(2), repo.batches.Load())
+}
+
+func TestBatchLoader_MaxBatch(t *testing.T) {
+	ctx := context.Background()
+	repo, users := seedBatchLoaderUsers(t, 4)
+	loader := NewBatchLoader[*TestUser](repo, WithWindow(time.Hour), WithMaxBatch(2))
+
+	ids := []string{users[0].ID.String(), users[1].ID.String(), users[2].ID.String(), users[3].ID.String()}
+	records, errs := loader.LoadMany(ctx, ids)
+	for i := range ids {
+		require.NoError(t, errs[i])
+		assert.Equal(t, users[i].ID, records[i].ID)
+	}
+	assert.Equal(t, int32(2), repo.batches.Load(), "full batches run without waiting for the window")
+}
+
+func TestBatchLoader_CallerContext(t *testing.T) {
+	repo, users := seedBatchLoaderUsers(t, 1)
+	loader := NewBatchLoader[*TestUser](repo, WithWindow(50*time.Millisecond))
+
+	cancelled, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err := loader.Load(cancelled, users[0].ID.String())
+	assert.ErrorIs(t, err, context.Canceled)
+
+	record, err := loader.Load(context.Background(), users[0].ID.String())
+	require.NoError(t, err, "the batch started by a cancelled caller still runs")
+	assert.Equal(t, users[0].ID, record.ID)
+	assert.Equal(t, int32(1), repo.batches.Load())
+}
+
+func TestBatchLoader_WithoutBatchGetter(t *testing.T) {
+	ctx := context.Background()
+	repo, users := seedBatchLoaderUsers(t, 2)
+	loader := NewBatchLoader[*TestUser](struct{ Repository[*TestUser] }{repo.Repository}, WithWindow(20*time.Millisecond))
+
+	missing := uuid.NewString()
+	records, errs := loader.LoadMany(ctx, []string{users[1].ID.String(), missing, users[0].ID.String()})
+	require.NoError(t, errs[0])
+	assert.Equal(t, users[1].ID, records[0].ID)
+	assert.True(t, IsRecordNotFound(errs[1]))
+	require.NoError(t, errs[2])
+	assert.Equal(t, users[0].ID, records[2].ID)
+	assert.Zero(t, repo.batches.Load(), "repositories without GetByIDs load one ID at a time")
+}
@@ -0,0 +1,317 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"github.com/uptrace/bun"
+	"github.com/uptrace/bun/schema"
+)
+
+const defaultBulkLoadBatchSize = 1000
+
+// BulkLoadOption configures BulkLoad.
+type BulkLoadOption func(*bulkLoadConfig)
+
+type bulkLoadConfig struct {
+	batchSize int
+}
+
+// WithBulkBatchSize sets how many rows each multi-row INSERT writes.
+// Defaults to 1000. COPY loads stream every row regardless.
+func WithBulkBatchSize(size int) BulkLoadOption {
+	return func(cfg *bulkLoadConfig) {
+		if size > 0 {
+			cfg.batchSize = size
+		}
+	}
+}
+
+// BulkCopier streams rows into PostgreSQL with COPY FROM for drivers BulkLoad
+// cannot stream to itself. CopyFrom runs query, a COPY ... FROM STDIN
+// statement, on conn inside the transaction BulkLoad started there; rows hold
+// the driver values of the query columns. repositorypgdriver implements it
+// for bun's pgdriver.
+type BulkCopier interface {
+	CopyFrom(ctx context.Context, conn bun.Conn, query string, rows [][]any) error
+}
+
+// WithBulkCopier makes BulkLoad stream rows on PostgreSQL with copier, e.g.
+// repositorypgdriver.NewCopier() for bun's pgdriver. Copies run on a
+// connection of their own, so BulkLoadTx inside a caller transaction keeps
+// using lib/pq COPY or INSERTs.
+func WithBulkCopier(copier BulkCopier) RepoOption {
+	return func(cfg *repoConfig) {
+		if cfg == nil {
+			return
+		}
+		cfg.bulkCopier = copier
+	}
+}
+
+// BulkLoader is an optional capability for repositories that can load large
+// amounts of rows faster than CreateMany.
+type BulkLoader[T any] interface {
+	BulkLoad(ctx context.Context, records []T, opts ...BulkLoadOption) (int64, error)
+	BulkLoadTx(ctx context.Context, tx bun.IDB, records []T, opts ...BulkLoadOption) (int64, error)
+}
+
+// BulkLoad inserts records for ETL style loads and returns how many rows were
+// written. On PostgreSQL rows are streamed with COPY FROM, through the
+// WithBulkCopier copier or on lib/pq connections; elsewhere they are written
+// with multi-row INSERTs of WithBulkBatchSize rows. Zero nullzero columns get their
+// database default on both paths. Either way the load runs in one transaction,
+// without RETURNING, unique prechecks or domain events, and records are not
+// refreshed from the database; only missing IDs are set. COPY cannot apply
+// insert scopes, so loads with active insert scopes use INSERTs.
+func (r *repo[T]) BulkLoad(ctx context.Context, records []T, opts ...BulkLoadOption) (int64, error) {
+	return r.BulkLoadTx(ctx, r.db, records, opts...)
+}
+
+func (r *repo[T]) BulkLoadTx(ctx context.Context, tx bun.IDB, records []T, opts ...BulkLoadOption) (int64, error) {
+	ctx = r.withOperation(ctx, "BulkLoad", 0)
+	cfg := bulkLoadConfig{batchSize: defaultBulkLoadBatchSize}
+	for _, opt := range opts {
+		if opt != nil {
+			opt(&cfg)
+		}
+	}
+	if len(records) == 0 {
+		return 0, nil
+	}
+
+	for _, record := range records {
+		if r.handlers.GetID(record) == uuid.Nil {
+			r.handlers.SetID(record, uuid.New())
+		}
+	}
+
+	if err := r.stampIntegrity(records...); err != nil {
+		return 0, err
+	}
+	if err := r.encryptRecords(records...); err != nil {
+		return 0, err
+	}
+	// COPY bypasses the query hook decrypting the records
+	defer func() { _ = r.decryptRecords(records...) }()
+
+	if r.bulkCopier != nil && r.canCopy(ctx) {
+		if loaded, ok, err := r.copyWithCopier(ctx, tx, records); ok {
+			return loaded, err
+		}
+	}
+
+	var loaded int64
+	err := runInTx(ctx, tx, func(ctx context.Context, tx bun.IDB) error {
+		var err error
+		if r.canCopy(ctx) && r.pqDriver() {
+			loaded, err = r.copyRecords(ctx, tx, records)
+		} else {
+			loaded, err = r.insertBatches(ctx, tx, records, cfg.batchSize)
+		}
+		return err
+	})
+	if err != nil {
+		return 0, err
+	}
+	return loaded, nil
+}
+
+// canCopy reports whether records can be streamed with COPY FROM, by lib/pq
+// or by the WithBulkCopier copier. Other drivers need a copier: linking
+// pgdriver.CopyFrom here would link pgdriver into every consumer.
+func (r *repo[T]) canCopy(ctx context.Context) bool {
+	if r.driver != "postgres" || r.db == nil || r.modelTable() == nil {
+		return false
+	}
+	if r.bulkCopier == nil && !r.pqDriver() {
+		return false
+	}
+	return len(r.resolveInsertScopes(ctx)) == 0
+}
+
+func (r *repo[T]) pqDriver() bool {
+	_, ok := r.db.DB.Driver().(*pq.Driver)
+	return ok
+}
+
+// copyWithCopier streams records with the WithBulkCopier copier, in one
+// transaction on a connection of tx. ok is false when tx is a transaction,
+// whose connection the copier cannot reach.
+func (r *repo[T]) copyWithCopier(ctx context.Context, tx bun.IDB, records []T) (loaded int64, ok bool, err error) {
+	var conn bun.Conn
+	switch db := tx.(type) {
+	case *bun.DB:
+		if conn, err = db.Conn(ctx); err != nil {
+			return 0, true, r.mapError(err)
+		}
+		defer conn.Close()
+	case bun.Conn:
+		conn = db
+	default:
+		return 0, false, nil
+	}
+
+	table := r.modelTable()
+	batches, err := copyBatches(table.Fields, records)
+	if err != nil {
+		return 0, true, err
+	}
+	err = RunInTx(ctx, conn, nil, func(ctx context.Context, _ bun.Tx) error {
+		for _, batch := range batches {
+			columns := make([]string, len(batch.fields))
+			for i, field := range batch.fields {
+				columns[i] = string(field.SQLName)
+			}
+			query := fmt.Sprintf("COPY %s (%s) FROM STDIN", table.SQLName, strings.Join(columns, ", "))
+			if err := r.bulkCopier.CopyFrom(ctx, conn, query, batch.rows); err != nil {
+				return r.mapError(fmt.Errorf("bulk load error: %w", err))
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, true, err
+	}
+	return int64(len(records)), true, nil
+}
+
+func (r *repo[T]) insertBatches(ctx context.Context, tx bun.IDB, records []T, batchSize int) (int64, error) {
+	var loaded int64
+	for start := 0; start < len(records); start += batchSize {
+		batch := records[start:min(start+batchSize, len(records))]
+
+		q := tx.NewInsert().Model(&batch)
+		q = r.applyInsertScopes(ctx, q)
+
+		res, err := q.Exec(ctx)
+		if err != nil {
+			return loaded, r.mapError(fmt.Errorf("bulk load error: %w", err))
+		}
+		n, err := rowsAffected(res)
+		if err != nil {
+			return loaded, err
+		}
+		loaded += n
+	}
+	return loaded, nil
+}
+
+type stmtPreparer interface {
+	PrepareContext(ctx context.Context, query string) (*sql.Stmt, error)
+}
+
+func (r *repo[T]) copyRecords(ctx context.Context, tx bun.IDB, records []T) (int64, error) {
+	preparer, ok := tx.(stmtPreparer)
+	if !ok {
+		return r.insertBatches(ctx, tx, records, defaultBulkLoadBatchSize)
+	}
+
+	table := r.modelTable()
+	batches, err := copyBatches(table.Fields, records)
+	if err != nil {
+		return 0, err
+	}
+	for _, batch := range batches {
+		if err := r.copyBatch(ctx, preparer, table.Name, batch); err != nil {
+			return 0, err
+		}
+	}
+	return int64(len(records)), nil
+}
+
+func (r *repo[T]) copyBatch(ctx context.Context, preparer stmtPreparer, tableName string, batch *copyBatch) error {
+	columns := make([]string, len(batch.fields))
+	for i, field := range batch.fields {
+		columns[i] = field.Name
+	}
+
+	copyIn := pq.CopyIn(tableName, columns...)
+	if schemaName, name, ok := strings.Cut(tableName, "."); ok {
+		copyIn = pq.CopyInSchema(schemaName, name, columns...)
+	}
+	stmt, err := preparer.PrepareContext(ctx, copyIn)
+	if err != nil {
+		return r.mapError(err)
+	}
+	defer stmt.Close()
+
+	for _, row := range batch.rows {
+		if _, err := stmt.ExecContext(ctx, row...); err != nil {
+			return r.mapError(fmt.Errorf("bulk load error: %w", err))
+		}
+	}
+	if _, err := stmt.ExecContext(ctx); err != nil {
+		return r.mapError(fmt.Errorf("bulk load error: %w", err))
+	}
+	return nil
+}
+
+// copyBatch is a run of records COPY writes with the same column list.
+type copyBatch struct {
+	fields []*schema.Field
+	rows   [][]any
+}
+
+// copyBatches groups records by the columns COPY writes for them. Zero
+// nullzero fields are left out of the column list, so the database fills in
+// their default just like the DEFAULT bun writes for them in an INSERT.
+func copyBatches[T any](fields []*schema.Field, records []T) ([]*copyBatch, error) {
+	var batches []*copyBatch
+	byKey := map[string]*copyBatch{}
+	key := make([]byte, len(fields))
+	for _, record := range records {
+		value := reflect.Indirect(reflect.ValueOf(record))
+		included := make([]*schema.Field, 0, len(fields))
+		for i, field := range fields {
+			key[i] = '1'
+			if field.NullZero && field.HasZeroValue(value) {
+				key[i] = '0'
+				continue
+			}
+			included = append(included, field)
+		}
+
+		batch, ok := byKey[string(key)]
+		if !ok {
+			batch = &copyBatch{fields: included}
+			byKey[string(key)] = batch
+			batches = append(batches, batch)
+		}
+
+		row := make([]any, len(batch.fields))
+		for i, field := range batch.fields {
+			var err error
+			if row[i], err = copyValue(field, value); err != nil {
+				return nil, err
+			}
+		}
+		batch.rows = append(batch.rows, row)
+	}
+	return batches, nil
+}
+
+// copyValue returns the COPY value of field: driver values as is, nil for
+// nullzero zero values and JSON for values database/sql cannot encode, which
+// bun stores as JSON.
+func copyValue(field *schema.Field, structValue reflect.Value) (any, error) {
+	if field.NullZero && field.HasZeroValue(structValue) {
+		return nil, nil
+	}
+	value := field.Value(structValue).Interface()
+	if _, err := driver.DefaultParameterConverter.ConvertValue(value); err == nil {
+		return value, nil
+	}
+	encoded, err := json.Marshal(value)
+	if err != nil {
+		return nil, fmt.Errorf("repository: encode %s for copy: %w", field.Name, err)
+	}
+	return string(encoded), nil
+}
@@ -0,0 +1,176 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"os"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/uptrace/bun"
+	"github.com/uptrace/bun/dialect/pgdialect"
+	"github.com/uptrace/bun/schema"
+)
+
+func TestRepository_BulkLoad(t *testing.T) {
+	setupTestData(t)
+	ctx := context.Background()
+
+	publisher := &recordingPublisher{}
+	repo := newTestUserRepositoryWithConfig(db, nil, WithEventPublisher(publisher))
+	loader, ok := repo.(BulkLoader[*TestUser])
+	require.True(t, ok)
+
+	records := make([]*TestUser, 5)
+	for i := range records {
+		records[i] = &TestUser{Name: fmt.Sprintf("user-%d", i), Email: fmt.Sprintf("user-%d@example.com", i)}
+	}
+
+	loaded, err := loader.BulkLoad(ctx, records, WithBulkBatchSize(2))
+	require.NoError(t, err)
+	assert.EqualValues(t, 5, loaded)
+	for _, record := range records {
+		assert.NotEqual(t, uuid.Nil, record.ID)
+	}
+
+	count, err := repo.Count(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 5, count)
+	assert.Empty(t, publisher.recorded(), "bulk loads publish no events")
+
+	duplicate := []*TestUser{
+		{Name: "new", Email: "new@example.com"},
+		{Name: "dup", Email: "user-0@example.com"},
+	}
+	_, err = loader.BulkLoad(ctx, duplicate, WithBulkBatchSize(1))
+	assert.True(t, IsDuplicatedKey(err))
+
+	count, err = repo.Count(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 5, count, "a failed load is rolled back")
+}
+
+func TestCopyValue(t *testing.T) {
+	type copyModel struct {
+		ID       uuid.UUID      `bun:"id,pk"`
+		Deleted  *string        `bun:"deleted,nullzero"`
+		Metadata map[string]any `bun:"metadata"`
+	}
+	table := db.Table(reflect.TypeFor[copyModel]())
+	id := uuid.New()
+	value := reflect.ValueOf(copyModel{ID: id, Metadata: map[string]any{"a": 1}})
+
+	got, err := copyValue(table.FieldMap["id"], value)
+	require.NoError(t, err)
+	assert.Equal(t, id, got)
+
+	got, err = copyValue(table.FieldMap["deleted"], value)
+	require.NoError(t, err)
+	assert.Nil(t, got)
+
+	got, err = copyValue(table.FieldMap["metadata"], value)
+	require.NoError(t, err)
+	assert.Equal(t, `{"a":1}`, got)
+}
+
+type bulkCopyItem struct {
+	bun.BaseModel `bun:"table:bulk_copy_items,alias:bci"`
+
+	ID        uuid.UUID `bun:"id,pk,type:uuid"`
+	Name      string    `bun:"name,notnull"`
+	CreatedAt time.Time `bun:"created_at,nullzero,notnull,default:current_timestamp"`
+}
+
+func TestCopyBatches(t *testing.T) {
+	table := db.Table(reflect.TypeFor[bulkCopyItem]())
+	createdAt := time.Now().UTC()
+	records := []*bulkCopyItem{
+		{ID: uuid.New(), Name: "a"},
+		{ID: uuid.New(), Name: "b", CreatedAt: createdAt},
+		{ID: uuid.New(), Name: "c"},
+	}
+
+	batches, err := copyBatches(table.Fields, records)
+	require.NoError(t, err)
+	require.Len(t, batches, 2)
+
+	assert.Equal(t, []*schema.Field{table.FieldMap["id"], table.FieldMap["name"]}, batches[0].fields,
+		"zero nullzero columns are left to their default")
+	assert.Equal(t, [][]any{{records[0].ID, "a"}, {records[2].ID, "c"}}, batches[0].rows)
+
+	assert.Len(t, batches[1].fields, 3)
+	assert.Equal(t, [][]any{{records[1].ID, "b", createdAt}}, batches[1].rows)
+}
+
+// TestRepository_BulkLoad_Copy runs BulkLoad through COPY FROM against the
+// PostgreSQL database in REPOSITORY_TEST_POSTGRES_DSN.
+func TestRepository_BulkLoad_Copy(t *testing.T) {
+	dsn := os.Getenv("REPOSITORY_TEST_POSTGRES_DSN")
+	if dsn == "" {
+		t.Skip("REPOSITORY_TEST_POSTGRES_DSN is not set")
+	}
+	ctx := context.Background()
+
+	sqldb, err := sql.Open("postgres", dsn)
+	require.NoError(t, err)
+	pgDB := bun.NewDB(sqldb, pgdialect.New())
+	t.Cleanup(func() { _ = pgDB.Close() })
+
+	_, err = pgDB.NewDropTable().Model((*bulkCopyItem)(nil)).IfExists().Exec(ctx)
+	require.NoError(t, err)
+	_, err = pgDB.NewCreateTable().Model((*bulkCopyItem)(nil)).Exec(ctx)
+	require.NoError(t, err)
+
+	items := MustNewRepository[*bulkCopyItem](pgDB, testModelHandlers[*bulkCopyItem]())
+	require.True(t, items.(*repo[*bulkCopyItem]).canCopy(ctx))
+
+	loaded, err := items.(BulkLoader[*bulkCopyItem]).BulkLoad(ctx, []*bulkCopyItem{{Name: "a"}, {Name: "b"}})
+	require.NoError(t, err)
+	assert.EqualValues(t, 2, loaded)
+
+	stored, _, err := items.List(ctx)
+	require.NoError(t, err)
+	require.Len(t, stored, 2)
+	for _, item := range stored {
+		assert.False(t, item.CreatedAt.IsZero(), "created_at gets its default")
+	}
+}
+
+type recordingCopier struct {
+	queries []string
+	rows    [][][]any
+}
+
+func (c *recordingCopier) CopyFrom(_ context.Context, _ bun.Conn, query string, rows [][]any) error {
+	c.queries = append(c.queries, query)
+	c.rows = append(c.rows, rows)
+	return nil
+}
+
+func TestRepository_BulkLoad_Copier(t *testing.T) {
+	ctx := context.Background()
+	pgDB := newDialectTestDB(t, pgdialect.New())
+	copier := &recordingCopier{}
+	items := NewRepositoryWithConfig(pgDB, testModelHandlers[*bulkCopyItem](), nil, WithBulkCopier(copier))
+	require.True(t, items.(*repo[*bulkCopyItem]).canCopy(ctx))
+
+	createdAt := time.Now().UTC()
+	records := []*bulkCopyItem{{Name: "a"}, {Name: "b", CreatedAt: createdAt}}
+	loaded, err := items.(BulkLoader[*bulkCopyItem]).BulkLoad(ctx, records)
+	require.NoError(t, err)
+	assert.EqualValues(t, 2, loaded)
+
+	assert.Equal(t, []string{
+		`COPY "bulk_copy_items" ("id", "name") FROM STDIN`,
+		`COPY "bulk_copy_items" ("id", "name", "created_at") FROM STDIN`,
+	}, copier.queries)
+	assert.Equal(t, [][][]any{
+		{{records[0].ID, "a"}},
+		{{records[1].ID, "b", createdAt}},
+	}, copier.rows)
+}
@@ -0,0 +1,217 @@
+package repository
+
+import (
+	"context"
+	"crypto/md5"
+	"encoding/hex"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+
+	"github.com/goliatone/go-errors"
+	"github.com/uptrace/bun"
+)
+
+// RecordChecksummer is an optional capability for repositories that can
+// fingerprint their rows, e.g. to compare a primary with its replicas.
+type RecordChecksummer interface {
+	ChecksumList(ctx context.Context, columns []string, criteria ...SelectCriteria) (string, error)
+	ChecksumListTx(ctx context.Context, tx bun.IDB, columns []string, criteria ...SelectCriteria) (string, error)
+}
+
+const (
+	// checksumSeparator joins the column values of a row before hashing.
+	checksumSeparator = "|"
+	// checksumNull stands for NULL column values.
+	checksumNull = `\N`
+	// checksumHexDigits is how many leading MD5 hex digits are summed per
+	// row: 60 bits keep the value positive on every driver.
+	checksumHexDigits = 15
+)
+
+// checksumSumExpressions hash the row_text column of the rows subquery and
+// sum the leading checksumHexDigits of each MD5 as an unsigned integer.
+var checksumSumExpressions = map[string]string{
+	"postgres": "COALESCE(SUM(('x' || SUBSTR(MD5(row_text), 1, 15))::bit(60)::bigint), 0)",
+	"mysql":    "COALESCE(SUM(CAST(CONV(SUBSTRING(MD5(row_text), 1, 15), 16, 10) AS UNSIGNED)), 0)",
+	"mssql": "COALESCE(SUM(CAST(CONVERT(BIGINT, CONVERT(VARBINARY(8), '0' + SUBSTRING(" +
+		"CONVERT(VARCHAR(32), HASHBYTES('MD5', row_text), 2), 1, 15), 2)) AS DECIMAL(38, 0))), 0)",
+}
+
+// ChecksumList returns an order independent checksum of columns over the rows
+// matching criteria, or of every model column when columns is empty. Each row
+// is rendered as text, hashed with MD5 and the hashes are summed, so the
+// result only depends on the set of rows, duplicates included. Postgres,
+// MySQL and MSSQL compute it server side and only return the row count and
+// the sum; SQLite, which has no MD5 function, streams the rendered rows.
+//
+// The checksum has the form "<rows>:<sum>". Compare checksums taken with the
+// same driver: the text rendering of timestamps, booleans or floats differs
+// between databases.
+func (r *repo[T]) ChecksumList(ctx context.Context, columns []string, criteria ...SelectCriteria) (string, error) {
+	return r.ChecksumListTx(ctx, r.db, columns, criteria...)
+}
+
+func (r *repo[T]) ChecksumListTx(ctx context.Context, tx bun.IDB, columns []string, criteria ...SelectCriteria) (string, error) {
+	ctx = r.withOperation(ctx, "ChecksumList", len(criteria))
+	columns, err := r.checksumColumns(columns)
+	if err != nil {
+		return "", err
+	}
+
+	rows, err := r.checksumRowsQuery(ctx, tx, columns, criteria)
+	if err != nil {
+		return "", err
+	}
+
+	if r.driver == "sqlite" {
+		return r.checksumStream(ctx, rows)
+	}
+
+	q, err := r.checksumQuery(tx, rows)
+	if err != nil {
+		return "", err
+	}
+
+	var count int64
+	var sum string
+	if err := q.Scan(ctx, &count, &sum); err != nil {
+		return "", r.mapError(err)
+	}
+	return formatChecksum(count, sum), nil
+}
+
+// checksumColumns validates columns against the model, defaulting to every
+// model column.
+func (r *repo[T]) checksumColumns(columns []string) ([]string, error) {
+	table := r.modelTable()
+	if len(columns) == 0 {
+		if table == nil {
+			return nil, errors.NewValidation(
+				"repository: checksum columns required",
+				errors.FieldError{Field: "columns", Message: "at least one column is required"},
+			)
+		}
+		columns = make([]string, 0, len(table.Fields))
+		for _, field := range table.Fields {
+			columns = append(columns, field.Name)
+		}
+		return columns, nil
+	}
+
+	normalized := make([]string, 0, len(columns))
+	for _, column := range columns {
+		col, ok := normalizeSQLIdentifier(column)
+		if !ok {
+			return nil, invalidColumnError("columns", column)
+		}
+		if table != nil {
+			if _, ok := table.FieldMap[col]; !ok {
+				return nil, errors.NewValidation(
+					"repository: unknown column",
+					errors.FieldError{Field: "columns", Message: fmt.Sprintf("column %q does not exist on %s", col, table.Name)},
+				)
+			}
+		}
+		normalized = append(normalized, col)
+	}
+	return normalized, nil
+}
+
+// checksumRowsQuery selects the text rendering of each matching row as
+// row_text.
+func (r *repo[T]) checksumRowsQuery(ctx context.Context, tx bun.IDB, columns []string, criteria []SelectCriteria) (*bun.SelectQuery, error) {
+	textType := "TEXT"
+	switch r.driver {
+	case "mysql":
+		textType = "CHAR"
+	case "mssql":
+		textType = "NVARCHAR(MAX)"
+	}
+
+	parts := make([]string, 0, len(columns))
+	args := make([]any, 0, len(columns)*2)
+	for _, column := range columns {
+		parts = append(parts, "COALESCE(CAST(?TableAlias.? AS "+textType+"), ?)")
+		args = append(args, bun.Ident(column), checksumNull)
+	}
+
+	var expr string
+	switch r.driver {
+	case "mysql", "mssql":
+		expr = "CONCAT_WS(?, " + strings.Join(parts, ", ") + ")"
+		args = append([]any{checksumSeparator}, args...)
+	default:
+		expr = strings.Join(parts, " || ? || ")
+		joined := make([]any, 0, len(args)+len(columns))
+		for i := 0; i < len(args); i += 2 {
+			if i > 0 {
+				joined = append(joined, checksumSeparator)
+			}
+			joined = append(joined, args[i], args[i+1])
+		}
+		args = joined
+	}
+
+	q := tx.NewSelect().
+		Model(r.handlers.NewRecord()).
+		ColumnExpr(expr+" AS row_text", args...)
+	q = r.applySelectScopes(ctx, q)
+	if err := r.applySelectCriteria(q, criteria); err != nil {
+		return nil, err
+	}
+	return q, nil
+}
+
+// checksumQuery aggregates the rows query server side.
+func (r *repo[T]) checksumQuery(tx bun.IDB, rows *bun.SelectQuery) (*bun.SelectQuery, error) {
+	sumExpr, ok := checksumSumExpressions[r.driver]
+	if !ok {
+		return nil, unsupportedDriverError("ChecksumList", r.driver)
+	}
+	return tx.NewSelect().
+		TableExpr("(?) AS checksum_rows", rows).
+		ColumnExpr("COUNT(*)").
+		ColumnExpr(sumExpr), nil
+}
+
+// checksumStream computes the checksum client side, for drivers without an
+// MD5 function.
+func (r *repo[T]) checksumStream(ctx context.Context, q *bun.SelectQuery) (string, error) {
+	rows, err := q.Rows(ctx)
+	if err != nil {
+		return "", r.mapError(err)
+	}
+	defer rows.Close()
+
+	var count int64
+	sum := new(big.Int)
+	for rows.Next() {
+		var text string
+		if err := rows.Scan(&text); err != nil {
+			return "", r.mapError(err)
+		}
+		sum.Add(sum, new(big.Int).SetUint64(checksumRowValue(text)))
+		count++
+	}
+	if err := rows.Err(); err != nil {
+		return "", r.mapError(err)
+	}
+	return formatChecksum(count, sum.String()), nil
+}
+
+// checksumRowValue mirrors the server side expressions: the leading
+// checksumHexDigits of the row MD5 as an unsigned integer.
+func checksumRowValue(text string) uint64 {
+	digest := md5.Sum([]byte(text))
+	value, _ := strconv.ParseUint(hex.EncodeToString(digest[:])[:checksumHexDigits], 16, 64)
+	return value
+}
+
+func formatChecksum(count int64, sum string) string {
+	if sum = strings.TrimSpace(sum); sum == "" {
+		sum = "0"
+	}
+	return fmt.Sprintf("%d:%s", count, sum)
+}
@@ -0,0 +1,136 @@
+package repository
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"testing"
+
+	goerrors "github.com/goliatone/go-errors"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/uptrace/bun/dialect/mysqldialect"
+	"github.com/uptrace/bun/dialect/pgdialect"
+)
+
+func checksumTestItems() []*sampleTestItem {
+	return []*sampleTestItem{
+		{ID: uuid.MustParse("00000000-0000-0000-0000-000000000001"), Name: "alpha", Group: "a", Weight: sampleWeight(1.5)},
+		{ID: uuid.MustParse("00000000-0000-0000-0000-000000000002"), Name: "beta", Group: "a"},
+		{ID: uuid.MustParse("00000000-0000-0000-0000-000000000003"), Name: "gamma", Group: "b", Weight: sampleWeight(3)},
+	}
+}
+
+func TestRepository_ChecksumList(t *testing.T) {
+	ctx := context.Background()
+
+	items := checksumTestItems()
+	checksummer, ok := newSampleTestItemRepository(t, items...).(RecordChecksummer)
+	require.True(t, ok)
+
+	primary, err := checksummer.ChecksumList(ctx, nil)
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(primary, "3:"), primary)
+
+	// Same rows inserted in another order, as on a replica.
+	reversed := checksumTestItems()
+	slices.Reverse(reversed)
+	replica, ok := newSampleTestItemRepository(t, reversed...).(RecordChecksummer)
+	require.True(t, ok)
+
+	checksum, err := replica.ChecksumList(ctx, nil)
+	require.NoError(t, err)
+	assert.Equal(t, primary, checksum)
+
+	_, err = db.NewUpdate().Model((*sampleTestItem)(nil)).
+		Set("weight = ?", 2).
+		Where("name = ?", "beta").
+		Exec(ctx)
+	require.NoError(t, err)
+
+	checksum, err = replica.ChecksumList(ctx, nil)
+	require.NoError(t, err)
+	assert.NotEqual(t, primary, checksum, "NULL and a value differ")
+
+	checksum, err = replica.ChecksumList(ctx, []string{"id", "name"})
+	require.NoError(t, err)
+	assert.NotEqual(t, primary, checksum)
+
+	partial, err := replica.ChecksumList(ctx, []string{"id", "name"}, SelectBy("grp", "=", "a"))
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(partial, "2:"), partial)
+	assert.NotEqual(t, checksum, partial)
+
+	empty, err := replica.ChecksumList(ctx, []string{"id"}, SelectBy("grp", "=", "missing"))
+	require.NoError(t, err)
+	assert.Equal(t, "0:0", empty)
+}
+
+func TestRepository_ChecksumListInvalidColumns(t *testing.T) {
+	ctx := context.Background()
+	checksummer := newSampleTestItemRepository(t).(RecordChecksummer)
+
+	_, err := checksummer.ChecksumList(ctx, []string{"missing"})
+	require.Error(t, err)
+	assert.True(t, goerrors.IsValidation(err))
+
+	_, err = checksummer.ChecksumList(ctx, []string{"name; DROP TABLE x"})
+	require.Error(t, err)
+	assert.True(t, goerrors.IsValidation(err))
+}
+
+func TestRepository_ChecksumListServerSideSQL(t *testing.T) {
+	ctx := context.Background()
+	handlers := testModelHandlers[*sampleTestItem]()
+
+	tests := []struct {
+		name     string
+		db       func(t *testing.T) *repo[*sampleTestItem]
+		expected []string
+	}{
+		{
+			name: "postgres",
+			db: func(t *testing.T) *repo[*sampleTestItem] {
+				return NewRepositoryWithConfig(newDialectTestDB(t, pgdialect.New()), handlers, nil).(*repo[*sampleTestItem])
+			},
+			expected: []string{
+				`COALESCE(CAST("sti"."name" AS TEXT), '\N') || '|' || COALESCE(CAST("sti"."grp" AS TEXT), '\N') AS row_text`,
+				"SUBSTR(MD5(row_text), 1, 15))::bit(60)::bigint",
+				`WHERE ("sti".grp = 'a')`,
+			},
+		},
+		{
+			name: "mysql",
+			db: func(t *testing.T) *repo[*sampleTestItem] {
+				return NewRepositoryWithConfig(newDialectTestDB(t, mysqldialect.New()), handlers, nil).(*repo[*sampleTestItem])
+			},
+			expected: []string{
+				"CONCAT_WS('|', COALESCE(CAST(`sti`.`name` AS CHAR), ",
+				"CONV(SUBSTRING(MD5(row_text), 1, 15), 16, 10)",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := tt.db(t)
+			rows, err := r.checksumRowsQuery(ctx, r.db, []string{"name", "grp"}, []SelectCriteria{SelectBy("grp", "=", "a")})
+			require.NoError(t, err)
+			q, err := r.checksumQuery(r.db, rows)
+			require.NoError(t, err)
+
+			sql := q.String()
+			assert.Contains(t, sql, "COUNT(*)")
+			for _, fragment := range tt.expected {
+				assert.Contains(t, sql, fragment)
+			}
+		})
+	}
+}
+
+func TestChecksumRowValueFitsSixtyBits(t *testing.T) {
+	for _, text := range []string{"", "alpha|a", `\N|\N`} {
+		assert.Less(t, checksumRowValue(text), uint64(1)<<60)
+	}
+}
@@ -0,0 +1,159 @@
+package repository
+
+import (
+	"context"
+	stderrors "errors"
+	"sync"
+	"time"
+
+	"github.com/goliatone/go-errors"
+	"github.com/uptrace/bun"
+)
+
+// circuitOpenTextCode marks the errors returned while a circuit breaker is open.
+const circuitOpenTextCode = "CIRCUIT_OPEN"
+
+// WithCircuitBreaker stops the repository from querying a database that keeps
+// failing. After threshold consecutive connection errors, as classified by the
+// repository error mappers, the breaker opens and every operation fails fast
+// with a retryable CIRCUIT_OPEN error, see IsCircuitOpen, without touching the
+// database. Once cooldown has passed one operation goes through as a trial: a
+// query that reaches the database closes the breaker again, another connection
+// error keeps it open for a further cooldown. Each repository built with the
+// option has its own breaker.
+func WithCircuitBreaker(threshold int, cooldown time.Duration) RepoOption {
+	return func(cfg *repoConfig) {
+		if cfg == nil || threshold < 1 || cooldown <= 0 {
+			return
+		}
+		cfg.circuitBreakerThreshold = threshold
+		cfg.circuitBreakerCooldown = cooldown
+	}
+}
+
+// IsCircuitOpen reports whether err was returned by an open circuit breaker,
+// see WithCircuitBreaker.
+func IsCircuitOpen(err error) bool {
+	var retryableErr *errors.RetryableError
+	return errors.As(err, &retryableErr) && retryableErr.BaseError != nil &&
+		retryableErr.TextCode == circuitOpenTextCode
+}
+
+type circuitBreakerKey struct{}
+
+type circuitBreaker struct {
+	threshold int
+	cooldown  time.Duration
+	mapError  func(error) error
+	now       func() time.Time
+
+	mu       sync.Mutex
+	failures int
+	openedAt time.Time
+}
+
+func newCircuitBreaker(threshold int, cooldown time.Duration, mapError func(error) error) *circuitBreaker {
+	return &circuitBreaker{threshold: threshold, cooldown: cooldown, mapError: mapError, now: time.Now}
+}
+
+// guard returns the context operations run with: one failing every query
+// while the breaker is open, or one carrying the breaker so the query hook
+// reports outcomes to it. Calls nested in a guarded operation pass through.
+func (b *circuitBreaker) guard(ctx context.Context) context.Context {
+	if current, _ := ctx.Value(circuitBreakerKey{}).(*circuitBreaker); current == b {
+		return ctx
+	}
+	if retryIn, open := b.allow(); open {
+		return &circuitOpenContext{Context: ctx, err: circuitOpenError(retryIn)}
+	}
+	return context.WithValue(ctx, circuitBreakerKey{}, b)
+}
+
+// allow reports whether the breaker is open and, if so, how long until the
+// next trial. Letting a trial through restarts the cooldown, so concurrent
+// operations keep failing fast until the trial reports back.
+func (b *circuitBreaker) allow() (time.Duration, bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.failures < b.threshold {
+		return 0, false
+	}
+	now := b.now()
+	if elapsed := now.Sub(b.openedAt); elapsed < b.cooldown {
+		return b.cooldown - elapsed, true
+	}
+	b.openedAt = now
+	return 0, false
+}
+
+func (b *circuitBreaker) record(failed bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if !failed {
+		b.failures = 0
+		return
+	}
+	b.failures++
+	if b.failures >= b.threshold {
+		b.openedAt = b.now()
+	}
+}
+
+func circuitOpenError(retryIn time.Duration) error {
+	return errors.NewRetryable("Database circuit breaker is open", CategoryDatabaseConnection).
+		WithRetryDelay(retryIn).
+		WithCode(503).
+		WithTextCode(circuitOpenTextCode)
+}
+
+// circuitOpenContext is done from the start, so database/sql rejects its
+// queries, and transactions, before acquiring a connection and returns err.
+type circuitOpenContext struct {
+	context.Context
+	err error
+}
+
+var closedChannel = func() chan struct{} {
+	done := make(chan struct{})
+	close(done)
+	return done
+}()
+
+func (c *circuitOpenContext) Done() <-chan struct{} {
+	return closedChannel
+}
+
+func (c *circuitOpenContext) Err() error {
+	return c.err
+}
+
+// circuitBreakerHook reports the outcome of every query run by a guarded
+// operation to its breaker. Cancellations say nothing about the database and
+// are not counted.
+type circuitBreakerHook struct{}
+
+func (circuitBreakerHook) QueryHookKey() string {
+	return "circuit-breaker"
+}
+
+func (circuitBreakerHook) BeforeQuery(ctx context.Context, _ *bun.QueryEvent) context.Context {
+	return ctx
+}
+
+func (circuitBreakerHook) AfterQuery(ctx context.Context, event *bun.QueryEvent) {
+	recordCircuitOutcome(ctx, event.Err)
+}
+
+// recordCircuitOutcome reports the outcome of a query run with ctx to the
+// breaker guarding it, if any. Queries run outside bun, such as prepared
+// lookups, call it themselves.
+func recordCircuitOutcome(ctx context.Context, err error) {
+	breaker, _ := ctx.Value(circuitBreakerKey{}).(*circuitBreaker)
+	if breaker == nil || IsCircuitOpen(err) ||
+		stderrors.Is(err, context.Canceled) || stderrors.Is(err, context.DeadlineExceeded) {
+		return
+	}
+	breaker.record(err != nil && IsConnectionError(breaker.mapError(err)))
+}
@@ -0,0 +1,114 @@
+package repository
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/uptrace/bun"
+	"github.com/uptrace/bun/dialect/sqlitedialect"
+)
+
+func TestCircuitBreaker(t *testing.T) {
+	ctx := context.Background()
+	testDB := newDialectTestDB(t, sqlitedialect.New())
+
+	// a missing table stands in for an unreachable database
+	down := func(err error) error {
+		if strings.Contains(err.Error(), "no such table") {
+			return newRetryableDatabaseConnectionError("Database connection error")
+		}
+		return nil
+	}
+	users := newTestUserRepositoryWithConfig(testDB, nil, WithErrorMapper(down), WithCircuitBreaker(2, time.Minute))
+	now := time.Now()
+	users.(*repo[*TestUser]).circuitBreaker.now = func() time.Time { return now }
+
+	for range 2 {
+		_, err := users.GetByID(ctx, uuid.NewString())
+		require.True(t, IsConnectionError(err))
+		assert.False(t, IsCircuitOpen(err))
+	}
+
+	_, err := users.GetByID(ctx, uuid.NewString())
+	assert.True(t, IsCircuitOpen(err))
+	assert.True(t, IsConnectionError(err))
+	_, err = users.Create(ctx, &TestUser{Name: "Alice", Email: "alice@example.com"})
+	assert.True(t, IsCircuitOpen(err), "writes fail fast too")
+
+	now = now.Add(time.Minute)
+	_, err = users.GetByID(ctx, uuid.NewString())
+	assert.False(t, IsCircuitOpen(err), "a trial goes through after the cooldown")
+	_, err = users.GetByID(ctx, uuid.NewString())
+	assert.True(t, IsCircuitOpen(err), "a failed trial reopens the breaker")
+
+	_, err = testDB.NewCreateTable().Model((*TestUser)(nil)).Exec(ctx)
+	require.NoError(t, err)
+	now = now.Add(time.Minute)
+	_, err = users.GetByID(ctx, uuid.NewString())
+	assert.True(t, IsRecordNotFound(err))
+
+	_, err = users.Create(ctx, &TestUser{Name: "Alice", Email: "alice@example.com"})
+	require.NoError(t, err, "a successful trial closes the breaker")
+	count, err := users.Count(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, count)
+}
+
+func TestCircuitBreaker_PreparedStatements(t *testing.T) {
+	ctx := context.Background()
+	sqldb := newPreparedTestSQLDB(t)
+	testDB := bun.NewDB(sqldb, sqlitedialect.New())
+	alice := createPreparedTestUser(t, sqldb, "alice@example.com")
+
+	down := func(err error) error {
+		if strings.Contains(err.Error(), "no such table") {
+			return newRetryableDatabaseConnectionError("Database connection error")
+		}
+		return nil
+	}
+	users := newTestUserRepositoryWithConfig(testDB, nil,
+		WithErrorMapper(down), WithCircuitBreaker(2, time.Minute), WithPreparedStatements())
+	breaker := users.(*repo[*TestUser]).circuitBreaker
+	now := time.Now()
+	breaker.now = func() time.Time { return now }
+	checker := users.(RecordExistenceChecker)
+
+	_, err := users.GetByID(ctx, alice.ID.String())
+	require.NoError(t, err)
+	_, err = checker.ExistsByID(ctx, alice.ID.String())
+	require.NoError(t, err)
+	require.Len(t, users.(*repo[*TestUser]).preparedStatements.stmts, 2)
+
+	// a successful prepared trial closes the breaker
+	breaker.failures = 2
+	breaker.openedAt = now.Add(-time.Minute)
+	_, err = users.GetByID(ctx, alice.ID.String())
+	require.NoError(t, err)
+	_, err = users.GetByIdentifier(ctx, "alice@example.com")
+	require.NoError(t, err, "the breaker is closed again")
+
+	breaker.failures = 2
+	breaker.openedAt = now.Add(-time.Minute)
+	_, err = checker.ExistsByID(ctx, alice.ID.String())
+	require.NoError(t, err)
+	_, err = checker.ExistsByID(ctx, alice.ID.String())
+	require.NoError(t, err, "the breaker is closed again")
+
+	// failing prepared lookups open it
+	_, err = testDB.NewDropTable().Model((*TestUser)(nil)).Exec(ctx)
+	require.NoError(t, err)
+	_, err = users.GetByID(ctx, alice.ID.String())
+	require.True(t, IsConnectionError(err))
+	assert.False(t, IsCircuitOpen(err))
+	_, err = checker.ExistsByID(ctx, alice.ID.String())
+	require.True(t, IsConnectionError(err))
+	assert.False(t, IsCircuitOpen(err))
+
+	_, err = users.GetByID(ctx, alice.ID.String())
+	assert.True(t, IsCircuitOpen(err))
+}
@@ -0,0 +1,62 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/uptrace/bun"
+	"github.com/uptrace/bun/dialect"
+)
+
+// RecordClaimer is an optional capability for repositories that can claim
+// queue-like rows for background workers.
+type RecordClaimer[T any] interface {
+	ClaimOne(ctx context.Context, claimCriteria []SelectCriteria, markClaimed ...UpdateCriteria) (T, error)
+	ClaimOneTx(ctx context.Context, tx bun.IDB, claimCriteria []SelectCriteria, markClaimed ...UpdateCriteria) (T, error)
+}
+
+// ClaimOne selects the first row matched by claimCriteria, locks it and
+// updates it with markClaimed in one transaction, returning the claimed
+// record. Workers racing for the same rows each claim a different one:
+//
+//	job, err := repo.(RecordClaimer[*Job]).ClaimOne(ctx,
+//		[]SelectCriteria{SelectBy("status", "=", "pending"), OrderBy("created_at ASC")},
+//		UpdateSetColumn("status", "running"),
+//		UpdateSetColumn("claimed_at", time.Now()),
+//	)
+//
+// On PostgreSQL and MySQL the row is selected with FOR UPDATE SKIP LOCKED, so
+// rows locked by another worker are skipped instead of waited for. SQLite
+// serializes writers, so a concurrent claim fails with a busy error instead.
+// When no row matches, the not found error of Get is returned.
+func (r *repo[T]) ClaimOne(ctx context.Context, claimCriteria []SelectCriteria, markClaimed ...UpdateCriteria) (T, error) {
+	return r.ClaimOneTx(ctx, r.db, claimCriteria, markClaimed...)
+}
+
+func (r *repo[T]) ClaimOneTx(ctx context.Context, tx bun.IDB, claimCriteria []SelectCriteria, markClaimed ...UpdateCriteria) (T, error) {
+	ctx = r.withOperation(ctx, "ClaimOne", len(claimCriteria))
+	var claimed T
+	err := runInTx(ctx, tx, func(ctx context.Context, tx bun.IDB) error {
+		record := r.handlers.NewRecord()
+		q := tx.NewSelect().Model(record)
+		q = r.applySelectScopes(ctx, q)
+		if err := r.applySelectCriteria(q, claimCriteria); err != nil {
+			return err
+		}
+		switch tx.Dialect().Name() {
+		case dialect.PG, dialect.MySQL:
+			q = q.For("UPDATE SKIP LOCKED")
+		}
+		if err := r.scanRecords(ctx, q.Limit(1).Scan); err != nil {
+			return err
+		}
+
+		var err error
+		claimed, err = r.UpdateTx(ctx, tx, record, markClaimed...)
+		return err
+	})
+	if err != nil {
+		var zero T
+		return zero, err
+	}
+	return claimed, nil
+}
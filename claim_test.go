@@ -0,0 +1,45 @@
+package repository
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRepository_ClaimOne(t *testing.T) {
+	setupTestData(t)
+	ctx := context.Background()
+
+	repo := newTestUserRepository(db)
+	claimer, ok := repo.(RecordClaimer[*TestUser])
+	require.True(t, ok)
+
+	for _, user := range []*TestUser{
+		{Name: "pending", Email: "b@example.com"},
+		{Name: "pending", Email: "a@example.com"},
+		{Name: "done", Email: "c@example.com"},
+	} {
+		_, err := repo.Create(ctx, user)
+		require.NoError(t, err)
+	}
+
+	pending := []SelectCriteria{SelectBy("name", "=", "pending"), OrderBy("email ASC")}
+
+	first, err := claimer.ClaimOne(ctx, pending, UpdateSetColumn("name", "claimed"))
+	require.NoError(t, err)
+	assert.Equal(t, "a@example.com", first.Email)
+	assert.Equal(t, "claimed", first.Name)
+
+	second, err := claimer.ClaimOne(ctx, pending, UpdateSetColumn("name", "claimed"))
+	require.NoError(t, err)
+	assert.Equal(t, "b@example.com", second.Email)
+
+	stored, err := repo.GetByID(ctx, second.ID.String())
+	require.NoError(t, err)
+	assert.Equal(t, "claimed", stored.Name)
+
+	_, err = claimer.ClaimOne(ctx, pending, UpdateSetColumn("name", "claimed"))
+	assert.ErrorIs(t, err, ErrRecordNotFound)
+}
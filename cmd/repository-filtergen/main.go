@@ -0,0 +1,394 @@
+// Command repository-filtergen generates typed filter structs for bun models.
+//
+// For every requested model it emits a <Model>Filter struct with one optional
+// filter per column (repository.StringFilter for strings, repository.ValueFilter
+// for numbers, booleans, time.Time, uuid.UUID, ...) and a Criteria method that
+// compiles the set fields into repository.SelectCriteria:
+//
+//	//go:generate go run github.com/goliatone/go-repository-bun/cmd/repository-filtergen -type User,Company
+//
+//	users, total, err := userRepo.List(ctx, (&UserFilter{
+//		Email: &repository.StringFilter{Like: ptr("%@example.com")},
+//	}).Criteria()...)
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"log"
+	"os"
+	"path"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+const repositoryImportPath = "github.com/goliatone/go-repository-bun"
+
+var valueTypes = map[string]struct{}{
+	"bool": {}, "int": {}, "int8": {}, "int16": {}, "int32": {}, "int64": {},
+	"uint": {}, "uint8": {}, "uint16": {}, "uint32": {}, "uint64": {},
+	"float32": {}, "float64": {},
+}
+
+var selectorValueTypes = map[string]struct{}{
+	"time.Time":      {},
+	"uuid.UUID":      {},
+	"sql.NullString": {},
+	"sql.NullInt64":  {},
+	"sql.NullBool":   {},
+	"sql.NullTime":   {},
+}
+
+func main() {
+	typeNames := flag.String("type", "", "comma separated list of model type names (required)")
+	input := flag.String("file", os.Getenv("GOFILE"), "Go source file declaring the models")
+	output := flag.String("output", "", "output file (default <file>_filter.go)")
+	flag.Parse()
+
+	if *typeNames == "" || *input == "" {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	src, err := os.ReadFile(*input)
+	if err != nil {
+		log.Fatalf("repository-filtergen: %v", err)
+	}
+
+	code, err := generate(*input, src, strings.Split(*typeNames, ","))
+	if err != nil {
+		log.Fatalf("repository-filtergen: %v", err)
+	}
+
+	target := *output
+	if target == "" {
+		target = strings.TrimSuffix(*input, filepath.Ext(*input)) + "_filter.go"
+	}
+	if err := os.WriteFile(target, code, 0o600); err != nil {
+		log.Fatalf("repository-filtergen: %v", err)
+	}
+}
+
+type filterField struct {
+	Name   string
+	Column string
+	Type   string
+}
+
+type filterModel struct {
+	Name   string
+	Fields []filterField
+}
+
+// generate returns the formatted source of the filter structs for typeNames
+// declared in the Go file src.
+func generate(filename string, src []byte, typeNames []string) ([]byte, error) {
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, filename, src, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	structs := make(map[string]*ast.StructType)
+	ast.Inspect(file, func(n ast.Node) bool {
+		if spec, ok := n.(*ast.TypeSpec); ok {
+			if st, ok := spec.Type.(*ast.StructType); ok {
+				structs[spec.Name.Name] = st
+			}
+		}
+		return true
+	})
+
+	imports := fileImports(file)
+	used := make(map[string]struct{})
+
+	var models []filterModel
+	for _, name := range typeNames {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+		st, ok := structs[name]
+		if !ok {
+			return nil, fmt.Errorf("type %s not found in %s", name, filename)
+		}
+		models = append(models, filterModel{Name: name, Fields: structFilterFields(st, structs, used)})
+	}
+
+	return render(file.Name.Name, models, imports, used)
+}
+
+// structFilterFields returns the filter fields of st, including the fields
+// promoted from embedded structs declared in the same file. As in Go, a field
+// shadows promoted fields of the same name.
+func structFilterFields(st *ast.StructType, structs map[string]*ast.StructType, used map[string]struct{}) []filterField {
+	fields := collectFilterFields(st, structs, used, "", "", map[*ast.StructType]bool{})
+	depths := make(map[string]int, len(fields))
+	for _, f := range fields {
+		if depth, ok := depths[f.Name]; !ok || f.depth < depth {
+			depths[f.Name] = f.depth
+		}
+	}
+
+	result := make([]filterField, 0, len(fields))
+	seen := make(map[string]struct{}, len(fields))
+	for _, f := range fields {
+		if _, dup := seen[f.Name]; dup || f.depth != depths[f.Name] {
+			continue
+		}
+		seen[f.Name] = struct{}{}
+		result = append(result, f.filterField)
+	}
+	return result
+}
+
+// embeddedFilterField is a filter field found depth embedded structs deep.
+type embeddedFilterField struct {
+	filterField
+	depth int
+}
+
+// collectFilterFields walks st, prefixing field names with namePrefix and
+// columns with columnPrefix, as bun does for embed:<prefix> fields. visiting
+// guards against recursive embedding.
+func collectFilterFields(st *ast.StructType, structs map[string]*ast.StructType, used map[string]struct{}, namePrefix, columnPrefix string, visiting map[*ast.StructType]bool) []embeddedFilterField {
+	visiting[st] = true
+	defer delete(visiting, st)
+
+	var fields []embeddedFilterField
+	for _, field := range st.Fields.List {
+		if embedded, prefix, ok := embeddedStruct(field, structs); ok {
+			if visiting[embedded] {
+				continue
+			}
+			name := namePrefix
+			if len(field.Names) > 0 {
+				if !field.Names[0].IsExported() {
+					continue
+				}
+				name += field.Names[0].Name
+			}
+			for _, f := range collectFilterFields(embedded, structs, used, name, columnPrefix+prefix, visiting) {
+				if len(field.Names) == 0 {
+					f.depth++
+				}
+				fields = append(fields, f)
+			}
+			continue
+		}
+		if len(field.Names) == 0 {
+			continue
+		}
+		column, ok := bunColumn(field)
+		if !ok {
+			continue
+		}
+		filterType, pkg, ok := filterTypeFor(field.Type)
+		if !ok {
+			continue
+		}
+		if pkg != "" {
+			used[pkg] = struct{}{}
+		}
+		for _, ident := range field.Names {
+			if !ident.IsExported() {
+				continue
+			}
+			col := column
+			if col == "" {
+				col = snakeCase(ident.Name)
+			}
+			fields = append(fields, embeddedFilterField{
+				filterField: filterField{Name: namePrefix + ident.Name, Column: columnPrefix + col, Type: filterType},
+			})
+		}
+	}
+	return fields
+}
+
+// embeddedStruct returns the struct declared in the file that field embeds,
+// either anonymously or as a named field tagged bun:"embed:<prefix>", along
+// with the column prefix. Types from other packages cannot be resolved and
+// are skipped.
+func embeddedStruct(field *ast.Field, structs map[string]*ast.StructType) (*ast.StructType, string, bool) {
+	tag, hasTag := bunTag(field)
+	if hasTag && tag == "-" {
+		return nil, "", false
+	}
+	prefix, isEmbed := strings.CutPrefix(strings.Split(tag, ",")[0], "embed:")
+	if len(field.Names) > 0 && !isEmbed {
+		return nil, "", false
+	}
+
+	typ := field.Type
+	if star, ok := typ.(*ast.StarExpr); ok {
+		typ = star.X
+	}
+	ident, ok := typ.(*ast.Ident)
+	if !ok {
+		return nil, "", false
+	}
+	st, ok := structs[ident.Name]
+	return st, prefix, ok
+}
+
+func bunTag(field *ast.Field) (string, bool) {
+	if field.Tag == nil {
+		return "", false
+	}
+	raw, err := strconv.Unquote(field.Tag.Value)
+	if err != nil {
+		return "", false
+	}
+	return reflect.StructTag(raw).Lookup("bun")
+}
+
+// bunColumn returns the column name from the bun struct tag. Fields without a
+// bun tag, ignored fields and relations are skipped.
+func bunColumn(field *ast.Field) (string, bool) {
+	tag, ok := bunTag(field)
+	if !ok || tag == "-" {
+		return "", false
+	}
+	parts := strings.Split(tag, ",")
+	for _, opt := range parts[1:] {
+		if strings.HasPrefix(opt, "rel:") || strings.HasPrefix(opt, "m2m:") || opt == "scanonly" {
+			return "", false
+		}
+	}
+	if strings.HasPrefix(parts[0], "table:") || strings.Contains(parts[0], ":") {
+		return "", false
+	}
+	return parts[0], true
+}
+
+// filterTypeFor maps a field type to its filter type and the package the
+// filter type depends on, if any.
+func filterTypeFor(expr ast.Expr) (string, string, bool) {
+	switch t := expr.(type) {
+	case *ast.StarExpr:
+		return filterTypeFor(t.X)
+	case *ast.Ident:
+		if t.Name == "string" {
+			return "*repository.StringFilter", "", true
+		}
+		if _, ok := valueTypes[t.Name]; ok {
+			return fmt.Sprintf("*repository.ValueFilter[%s]", t.Name), "", true
+		}
+	case *ast.SelectorExpr:
+		pkg, ok := t.X.(*ast.Ident)
+		if !ok {
+			return "", "", false
+		}
+		name := pkg.Name + "." + t.Sel.Name
+		if _, ok := selectorValueTypes[name]; ok {
+			return fmt.Sprintf("*repository.ValueFilter[%s]", name), pkg.Name, true
+		}
+	}
+	return "", "", false
+}
+
+func fileImports(file *ast.File) map[string]string {
+	imports := make(map[string]string)
+	for _, spec := range file.Imports {
+		path, err := strconv.Unquote(spec.Path.Value)
+		if err != nil {
+			continue
+		}
+		name := filepath.Base(path)
+		if spec.Name != nil {
+			name = spec.Name.Name
+		}
+		imports[name] = path
+	}
+	return imports
+}
+
+func render(pkg string, models []filterModel, imports map[string]string, used map[string]struct{}) ([]byte, error) {
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "// Code generated by repository-filtergen. DO NOT EDIT.\n\npackage %s\n\n", pkg)
+
+	// imports are grouped like goimports does: standard library first, then
+	// everything else, each group sorted by path
+	type importSpec struct{ name, path string }
+	specs := []importSpec{{name: "repository", path: repositoryImportPath}}
+	for name := range used {
+		path, ok := imports[name]
+		if !ok {
+			return nil, fmt.Errorf("import for package %s not found", name)
+		}
+		specs = append(specs, importSpec{name: name, path: path})
+	}
+	sort.Slice(specs, func(i, j int) bool { return specs[i].path < specs[j].path })
+
+	var std, external []string
+	for _, spec := range specs {
+		line := strconv.Quote(spec.path)
+		if path.Base(spec.path) != spec.name {
+			line = spec.name + " " + line
+		}
+		if isStandardImport(spec.path) {
+			std = append(std, line)
+		} else {
+			external = append(external, line)
+		}
+	}
+	groups := make([]string, 0, 2)
+	for _, group := range [][]string{std, external} {
+		if len(group) > 0 {
+			groups = append(groups, strings.Join(group, "\n"))
+		}
+	}
+	fmt.Fprintf(&buf, "import (\n%s\n)\n", strings.Join(groups, "\n\n"))
+
+	for _, model := range models {
+		fmt.Fprintf(&buf, "\n// %sFilter is a typed filter for %s. Nil fields are ignored.\n", model.Name, model.Name)
+		fmt.Fprintf(&buf, "type %sFilter struct {\n", model.Name)
+		for _, f := range model.Fields {
+			fmt.Fprintf(&buf, "%s %s `json:\"%s,omitempty\"`\n", f.Name, f.Type, f.Column)
+		}
+		buf.WriteString("}\n")
+
+		fmt.Fprintf(&buf, "\n// Criteria compiles the set fields of f into select criteria.\n")
+		fmt.Fprintf(&buf, "func (f *%sFilter) Criteria() []repository.SelectCriteria {\n", model.Name)
+		buf.WriteString("if f == nil {\nreturn nil\n}\n\nvar criteria []repository.SelectCriteria\n")
+		for _, f := range model.Fields {
+			fmt.Fprintf(&buf, "criteria = append(criteria, f.%s.Criteria(%q)...)\n", f.Name, f.Column)
+		}
+		buf.WriteString("return criteria\n}\n")
+	}
+
+	return format.Source(buf.Bytes())
+}
+
+// isStandardImport reports whether path is a standard library package, that
+// is whether its first element has no dot.
+func isStandardImport(path string) bool {
+	first, _, _ := strings.Cut(path, "/")
+	return !strings.Contains(first, ".")
+}
+
+func snakeCase(name string) string {
+	runes := []rune(name)
+	var b strings.Builder
+	for i, r := range runes {
+		if unicode.IsUpper(r) {
+			if i > 0 && (unicode.IsLower(runes[i-1]) || (i+1 < len(runes) && unicode.IsLower(runes[i+1]))) {
+				b.WriteByte('_')
+			}
+			b.WriteRune(unicode.ToLower(r))
+			continue
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
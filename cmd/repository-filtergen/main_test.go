@@ -0,0 +1,107 @@
+package main
+
+import (
+	"go/format"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const modelSource = `package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/uptrace/bun"
+)
+
+type User struct {
+	bun.BaseModel ` + "`bun:\"table:users,alias:u\"`" + `
+
+	ID        uuid.UUID  ` + "`bun:\"id,pk,type:uuid\"`" + `
+	Email     string     ` + "`bun:\"email,notnull\"`" + `
+	Age       *int       ` + "`bun:\",nullzero\"`" + `
+	Tags      []string   ` + "`bun:\"tags,array\"`" + `
+	Company   *Company   ` + "`bun:\"rel:belongs-to\"`" + `
+	Secret    string     ` + "`bun:\"-\"`" + `
+	CreatedAt time.Time  ` + "`bun:\"created_at\"`" + `
+}
+
+type Company struct {
+	ID uuid.UUID ` + "`bun:\"id,pk\"`" + `
+}
+
+type Timestamps struct {
+	CreatedAt time.Time ` + "`bun:\"created_at\"`" + `
+	UpdatedAt time.Time ` + "`bun:\"updated_at\"`" + `
+}
+
+type Address struct {
+	City string ` + "`bun:\"city\"`" + `
+}
+
+type Account struct {
+	bun.BaseModel ` + "`bun:\"table:accounts\"`" + `
+	Timestamps
+
+	ID        uuid.UUID ` + "`bun:\"id,pk\"`" + `
+	UpdatedAt time.Time ` + "`bun:\"modified_at\"`" + `
+	Billing   Address   ` + "`bun:\"embed:billing_\"`" + `
+}
+`
+
+func TestGenerate(t *testing.T) {
+	code, err := generate("models.go", []byte(modelSource), []string{"User"})
+	require.NoError(t, err)
+
+	out := string(code)
+	assert.Contains(t, out, "// Code generated by repository-filtergen. DO NOT EDIT.")
+	assert.Contains(t, out, "package models")
+	assert.Contains(t, out, `"github.com/google/uuid"`)
+	assert.Contains(t, out, `"time"`)
+	assert.Contains(t, out, "type UserFilter struct")
+	assert.Regexp(t, `Email\s+\*repository\.StringFilter\s+`+"`json:\"email,omitempty\"`", out)
+	assert.Regexp(t, `Age\s+\*repository\.ValueFilter\[int\]\s+`+"`json:\"age,omitempty\"`", out)
+	assert.Regexp(t, `ID\s+\*repository\.ValueFilter\[uuid\.UUID\]\s+`+"`json:\"id,omitempty\"`", out)
+	assert.Contains(t, out, `f.CreatedAt.Criteria("created_at")...`)
+	assert.NotContains(t, out, "Tags")
+	assert.NotContains(t, out, "Company")
+	assert.NotContains(t, out, "Secret")
+	assert.NotContains(t, out, "BaseModel")
+	assert.Equal(t, 1, strings.Count(out, "func (f *UserFilter) Criteria()"))
+}
+
+func TestGenerate_EmbeddedStructs(t *testing.T) {
+	code, err := generate("models.go", []byte(modelSource), []string{"Account"})
+	require.NoError(t, err)
+
+	out := string(code)
+	assert.Contains(t, out, `f.CreatedAt.Criteria("created_at")...`, "promoted fields get filters")
+	assert.Contains(t, out, `f.UpdatedAt.Criteria("modified_at")...`, "outer fields shadow promoted ones")
+	assert.NotContains(t, out, `"updated_at"`)
+	assert.Contains(t, out, `f.BillingCity.Criteria("billing_city")...`)
+}
+
+func TestGenerate_FormattedImports(t *testing.T) {
+	code, err := generate("models.go", []byte(modelSource), []string{"User"})
+	require.NoError(t, err)
+
+	formatted, err := format.Source(code)
+	require.NoError(t, err)
+	assert.Equal(t, string(formatted), string(code))
+	assert.Contains(t, string(code), "import (\n\t\"time\"\n\n\trepository \"github.com/goliatone/go-repository-bun\"\n\t\"github.com/google/uuid\"\n)\n")
+}
+
+func TestGenerate_UnknownType(t *testing.T) {
+	_, err := generate("models.go", []byte(modelSource), []string{"Missing"})
+	require.Error(t, err)
+}
+
+func TestSnakeCase(t *testing.T) {
+	assert.Equal(t, "created_at", snakeCase("CreatedAt"))
+	assert.Equal(t, "user_id", snakeCase("UserID"))
+	assert.Equal(t, "http_status", snakeCase("HTTPStatus"))
+}
@@ -0,0 +1,202 @@
+package repository
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/goliatone/go-errors"
+	"github.com/uptrace/bun"
+	"github.com/uptrace/bun/schema"
+)
+
+// WithFilterableColumns limits the columns criteria built from user input may
+// filter on: SelectBy, SelectByValue, SelectOrBy and the StringFilter and
+// ValueFilter criteria fail the query with a validation error for any other
+// column. Trusted helpers such as SelectByID, SelectRawProcessor and scopes
+// are not restricted. Unknown columns are reported by Validate.
+//
+// The allowlist belongs to the repository: it applies to the criteria passed
+// to its methods, not to other repositories or bun queries of the model.
+func WithFilterableColumns(columns ...string) RepoOption {
+	return func(cfg *repoConfig) {
+		if cfg != nil {
+			cfg.filterableColumns = appendPolicyColumns(cfg.filterableColumns, columns)
+		}
+	}
+}
+
+// WithSortableColumns limits the columns OrderBySafe may order by, as
+// WithFilterableColumns does for filters. OrderBy is not restricted.
+func WithSortableColumns(columns ...string) RepoOption {
+	return func(cfg *repoConfig) {
+		if cfg != nil {
+			cfg.sortableColumns = appendPolicyColumns(cfg.sortableColumns, columns)
+		}
+	}
+}
+
+func appendPolicyColumns(list, columns []string) []string {
+	for _, column := range columns {
+		if column, ok := normalizeSQLIdentifier(column); ok && !containsString(list, column) {
+			list = append(list, column)
+		}
+	}
+	return list
+}
+
+// criteriaPolicy holds the criteria settings of a model, see
+// WithFilterableColumns, WithSortableColumns and WithStrictCriteria; a nil
+// list allows every column.
+type criteriaPolicy struct {
+	filterable map[string]struct{}
+	sortable   map[string]struct{}
+	strict     bool
+}
+
+func newCriteriaPolicy(filterable, sortable []string, strict bool) *criteriaPolicy {
+	if len(filterable) == 0 && len(sortable) == 0 && !strict {
+		return nil
+	}
+	return &criteriaPolicy{
+		filterable: policyColumnSet(filterable),
+		sortable:   policyColumnSet(sortable),
+		strict:     strict,
+	}
+}
+
+func policyColumnSet(columns []string) map[string]struct{} {
+	if len(columns) == 0 {
+		return nil
+	}
+	set := make(map[string]struct{}, len(columns))
+	for _, column := range columns {
+		set[column] = struct{}{}
+	}
+	return set
+}
+
+// criteriaQuery is implemented by bun select, update and delete queries.
+type criteriaQuery interface {
+	DB() *bun.DB
+	GetModel() bun.Model
+}
+
+// boundPolicies maps a query to the policy of the repository applying
+// criteria to it. Criteria only see the query, so applyCriteria binds the
+// policy for as long as they run and unbinds it before returning.
+var boundPolicies sync.Map
+
+func bindCriteriaPolicy(q any, policy *criteriaPolicy) (unbind func()) {
+	if policy == nil {
+		return func() {}
+	}
+	boundPolicies.Store(q, policy)
+	return func() { boundPolicies.Delete(q) }
+}
+
+// queryCriteriaPolicy returns the policy bound to q, nil outside of
+// applyCriteria or for repositories without one.
+func queryCriteriaPolicy(q criteriaQuery) *criteriaPolicy {
+	policy, _ := boundPolicies.Load(q)
+	p, _ := policy.(*criteriaPolicy)
+	return p
+}
+
+// requireFilterable fails the query when column is outside the filterable
+// allowlist of its model, or not a column of the model at all. Invalid
+// identifiers are left to criteria, which fail closed on them.
+func requireFilterable(column string, criteria SelectCriteria) SelectCriteria {
+	return func(q *bun.SelectQuery) *bun.SelectQuery {
+		col, ok := normalizeSQLIdentifier(column)
+		if !ok {
+			return criteria(q)
+		}
+		if policy := queryCriteriaPolicy(q); policy != nil && !policyAllows(policy.filterable, col) {
+			return q.Err(columnNotAllowedError("filter", col, "filterable"))
+		}
+		if err := unknownColumnError(q, col); err != nil {
+			return q.Err(err)
+		}
+		return criteria(q)
+	}
+}
+
+// unknownColumnError returns a CategoryCriteriaInvalid error when column is
+// not a field of the model of q, so criteria fail before the database sees
+// the query. Qualified columns, e.g. of joined relations, and queries without
+// a model table are left to the database.
+func unknownColumnError(q criteriaQuery, column string) error {
+	model, ok := q.GetModel().(bun.TableModel)
+	if !ok {
+		return nil
+	}
+	return unknownTableColumnError(model.Table(), column)
+}
+
+// unknownTableColumnError is unknownColumnError for callers holding the
+// model table rather than a query.
+func unknownTableColumnError(table *schema.Table, column string) error {
+	if table == nil || strings.Contains(column, ".") {
+		return nil
+	}
+	if _, ok := table.FieldMap[column]; ok {
+		return nil
+	}
+	return NewCriteriaInvalidError("Unknown column in query criteria").
+		WithMetadata(map[string]any{
+			"column": column,
+			"table":  table.Name,
+		})
+}
+
+func policyAllows(set map[string]struct{}, column string) bool {
+	if set == nil {
+		return true
+	}
+	_, ok := set[column]
+	return ok
+}
+
+func columnNotAllowedError(field, column, kind string) error {
+	return errors.NewValidation(
+		"repository: column not allowed",
+		errors.FieldError{
+			Field:   field,
+			Message: fmt.Sprintf("column %q is not %s", column, kind),
+			Value:   column,
+		},
+	)
+}
+
+func (r *repo[T]) validateColumnPolicy() error {
+	table := r.modelTable()
+	if table == nil {
+		return nil
+	}
+
+	var validationErrors errors.ValidationErrors
+	check := func(option string, columns []string) {
+		for _, column := range columns {
+			if _, ok := table.FieldMap[column]; !ok {
+				validationErrors = append(validationErrors, errors.FieldError{
+					Field:   option,
+					Message: fmt.Sprintf("unknown column %q on %s", column, table.Name),
+				})
+			}
+		}
+	}
+	check("repoOptions.WithFilterableColumns", r.filterableColumns)
+	check("repoOptions.WithSortableColumns", r.sortableColumns)
+
+	if len(validationErrors) > 0 {
+		return errors.NewValidation("repository configuration invalid", validationErrors...)
+	}
+	return nil
+}
+
+// sortColumn returns the column of a normalized order expression.
+func sortColumn(expr string) string {
+	column, _, _ := strings.Cut(expr, " ")
+	return column
+}
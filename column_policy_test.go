@@ -0,0 +1,79 @@
+package repository
+
+import (
+	"context"
+	"testing"
+
+	goerrors "github.com/goliatone/go-errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/uptrace/bun/dialect/sqlitedialect"
+)
+
+func TestColumnPolicy(t *testing.T) {
+	ctx := context.Background()
+	testDB := newDialectTestDB(t, sqlitedialect.New())
+	_, err := testDB.NewCreateTable().Model((*TestUser)(nil)).Exec(ctx)
+	require.NoError(t, err)
+	users := newTestUserRepositoryWithConfig(testDB, nil,
+		WithFilterableColumns("name", " email "),
+		WithSortableColumns("name"),
+	)
+	require.NoError(t, users.(Validator).Validate())
+
+	alice, err := users.Create(ctx, &TestUser{Name: "Alice", Email: "alice@example.com"})
+	require.NoError(t, err)
+	_, err = users.Create(ctx, &TestUser{Name: "Bob", Email: "bob@example.com"})
+	require.NoError(t, err)
+
+	found, _, err := users.List(ctx, SelectBy("name", "=", "Alice"), OrderBySafe("name DESC"))
+	require.NoError(t, err)
+	require.Len(t, found, 1)
+
+	like := "%@example.com"
+	_, total, err := users.List(ctx, (&StringFilter{Like: &like}).Criteria("email")...)
+	require.NoError(t, err)
+	assert.Equal(t, 2, total)
+
+	rejected := map[string]SelectCriteria{
+		"SelectBy":     SelectBy("company_id", "=", alice.CompanyID.String()),
+		"SelectOrBy":   SelectOrBy("id", "=", alice.ID.String()),
+		"StringFilter": (&StringFilter{Eq: &alice.Email}).Criteria("password_hash")[0],
+		"OrderBySafe":  OrderBySafe("name ASC, email DESC"),
+	}
+	for name, criteria := range rejected {
+		_, _, err = users.List(ctx, criteria)
+		require.Error(t, err, name)
+		assert.True(t, goerrors.IsValidation(err), name)
+	}
+
+	byID, err := users.GetByID(ctx, alice.ID.String())
+	require.NoError(t, err, "trusted lookups ignore the allowlist")
+	assert.Equal(t, "Alice", byID.Name)
+
+	other := newTestUserRepository(testDB)
+	_, total, err = other.List(ctx, SelectBy("company_id", "=", alice.CompanyID.String()))
+	require.NoError(t, err, "the allowlist is per repository")
+	assert.Equal(t, 2, total)
+	query := testDB.NewSelect().Model((*TestUser)(nil)).Apply(SelectBy("company_id", "=", "x"))
+	assert.Contains(t, query.String(), `"u".company_id = 'x'`, "plain bun queries are not restricted")
+
+	_, _, err = users.List(ctx, SelectBy("company_id", "=", alice.CompanyID.String()))
+	require.Error(t, err, "other repositories leave the allowlist in place")
+}
+
+func TestColumnPolicy_Validate(t *testing.T) {
+	repo := NewRepositoryWithConfig(newDialectTestDB(t, sqlitedialect.New()), testUserHandlers(), nil,
+		WithFilterableColumns("name", "missing"),
+		WithSortableColumns("unknown"),
+	)
+	err := repo.(Validator).Validate()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "repository configuration invalid")
+
+	var validationErr *goerrors.Error
+	require.True(t, goerrors.As(err, &validationErr))
+	require.Len(t, validationErr.ValidationErrors, 2)
+	assert.Equal(t, "repoOptions.WithFilterableColumns", validationErr.ValidationErrors[0].Field)
+	assert.Equal(t, "repoOptions.WithSortableColumns", validationErr.ValidationErrors[1].Field)
+}
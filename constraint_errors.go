@@ -0,0 +1,116 @@
+package repository
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/goliatone/go-errors"
+	"github.com/lib/pq"
+	"github.com/mattn/go-sqlite3"
+)
+
+// FieldErrorSpec is the field-level validation error reported in place of a
+// constraint violation, see WithConstraintMapping.
+type FieldErrorSpec struct {
+	Field   string
+	Message string
+}
+
+// WithConstraintMapping translates violations of the named constraints into
+// validation errors carrying spec.Field and spec.Message, so API consumers see
+// e.g. `email: is already taken` instead of raw constraint names:
+//
+//	WithConstraintMapping(map[string]FieldErrorSpec{
+//		"users_email_key": {Field: "email", Message: "is already taken"},
+//	})
+//
+// Keys are constraint or index names on Postgres, MySQL and MSSQL. SQLite does
+// not report constraint names, so unique violations are keyed by the failing
+// columns as SQLite reports them, e.g. "users.email" or "users.tenant_id, users.slug".
+// The mapped database error is kept as the source of the validation error.
+func WithConstraintMapping(mapping map[string]FieldErrorSpec) RepoOption {
+	return func(cfg *repoConfig) {
+		if cfg == nil {
+			return
+		}
+		if cfg.constraintMapping == nil {
+			cfg.constraintMapping = make(map[string]FieldErrorSpec, len(mapping))
+		}
+		for name, spec := range mapping {
+			name = strings.TrimSpace(name)
+			if name == "" {
+				continue
+			}
+			cfg.constraintMapping[name] = spec
+		}
+	}
+}
+
+var (
+	mysqlDuplicateKeyPattern   = regexp.MustCompile("for key '([^']+)'")
+	mysqlForeignKeyPattern     = regexp.MustCompile("CONSTRAINT `([^`]+)`")
+	mssqlConstraintNamePattern = regexp.MustCompile(`(?i)(?:constraint|unique index) '([^']+)'`)
+)
+
+// constraintName returns the name of the constraint violated by err, if the
+// driver reports it.
+func constraintName(err error) string {
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) {
+		return pqErr.Constraint
+	}
+
+	var mysqlErr *mysql.MySQLError
+	if errors.As(err, &mysqlErr) {
+		if match := mysqlDuplicateKeyPattern.FindStringSubmatch(mysqlErr.Message); match != nil {
+			// MySQL 8 prefixes the key with the table name
+			name := match[1]
+			if i := strings.LastIndex(name, "."); i >= 0 {
+				name = name[i+1:]
+			}
+			return name
+		}
+		if match := mysqlForeignKeyPattern.FindStringSubmatch(mysqlErr.Message); match != nil {
+			return match[1]
+		}
+		return ""
+	}
+
+	var sqliteErr sqlite3.Error
+	if errors.As(err, &sqliteErr) {
+		_, name, ok := strings.Cut(sqliteErr.Error(), " constraint failed: ")
+		if !ok {
+			return ""
+		}
+		return strings.TrimSpace(name)
+	}
+
+	if match := mssqlConstraintNamePattern.FindStringSubmatch(err.Error()); match != nil {
+		return match[1]
+	}
+	return ""
+}
+
+func (r *repo[T]) mapConstraintError(source, mapped error) error {
+	if len(r.constraintMapping) == 0 || !IsConstraintViolation(mapped) {
+		return mapped
+	}
+
+	name := constraintName(source)
+	spec, ok := r.constraintMapping[name]
+	if !ok {
+		return mapped
+	}
+
+	code := errors.CodeBadRequest
+	if IsDuplicatedKey(mapped) {
+		code = errors.CodeConflict
+	}
+	err := errors.NewValidation(spec.Message, errors.FieldError{Field: spec.Field, Message: spec.Message}).
+		WithCode(code).
+		WithTextCode("CONSTRAINT_VIOLATION").
+		WithMetadata(map[string]any{"constraint": name})
+	err.Source = mapped
+	return err
+}
@@ -0,0 +1,73 @@
+package repository
+
+import (
+	"context"
+	stderrors "errors"
+	"testing"
+
+	"github.com/go-sql-driver/mysql"
+	goerrors "github.com/goliatone/go-errors"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWithConstraintMapping(t *testing.T) {
+	setupTestData(t)
+	ctx := context.Background()
+
+	repo := newTestUserRepositoryWithConfig(db, nil, WithConstraintMapping(map[string]FieldErrorSpec{
+		"test_users.email": {Field: "email", Message: "is already taken"},
+	}))
+
+	_, err := repo.Create(ctx, &TestUser{Name: "Alice", Email: "alice@example.com"})
+	require.NoError(t, err)
+	_, err = repo.Create(ctx, &TestUser{Name: "Alice", Email: "alice@example.com"})
+	require.Error(t, err)
+
+	var validation *goerrors.Error
+	require.ErrorAs(t, err, &validation)
+	assert.True(t, goerrors.IsValidation(err))
+	assert.Equal(t, goerrors.CodeConflict, validation.Code)
+	require.Len(t, validation.ValidationErrors, 1)
+	assert.Equal(t, "email", validation.ValidationErrors[0].Field)
+	assert.Equal(t, "is already taken", validation.ValidationErrors[0].Message)
+	assert.Equal(t, "test_users.email", validation.Metadata["constraint"])
+
+	unmapped := newTestUserRepository(db)
+	_, err = unmapped.Create(ctx, &TestUser{Name: "Alice", Email: "alice@example.com"})
+	assert.True(t, IsDuplicatedKey(err))
+}
+
+func TestConstraintName(t *testing.T) {
+	tests := []struct {
+		name     string
+		err      error
+		expected string
+	}{
+		{name: "postgres", err: &pq.Error{Code: "23505", Constraint: "users_email_key"}, expected: "users_email_key"},
+		{
+			name:     "mysql duplicate",
+			err:      &mysql.MySQLError{Number: 1062, Message: "Duplicate entry 'a@example.com' for key 'users.users_email_key'"},
+			expected: "users_email_key",
+		},
+		{
+			name: "mysql foreign key",
+			err: &mysql.MySQLError{Number: 1452, Message: "Cannot add or update a child row: a foreign key constraint fails " +
+				"(`app`.`users`, CONSTRAINT `fk_users_company` FOREIGN KEY (`company_id`) REFERENCES `companies` (`id`))"},
+			expected: "fk_users_company",
+		},
+		{
+			name:     "mssql",
+			err:      stderrors.New("mssql: Violation of UNIQUE KEY constraint 'UQ_users_email'. Cannot insert duplicate key in object 'dbo.users'."),
+			expected: "UQ_users_email",
+		},
+		{name: "unknown", err: stderrors.New("boom"), expected: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, constraintName(tt.err))
+		})
+	}
+}
@@ -0,0 +1,50 @@
+package repository
+
+import (
+	"context"
+	"strings"
+
+	"github.com/uptrace/bun"
+)
+
+// DistinctCounter is an optional capability for repositories that can count
+// distinct column values, e.g. "active users this month" in reports.
+type DistinctCounter interface {
+	CountDistinct(ctx context.Context, column string, criteria ...SelectCriteria) (int, error)
+	CountDistinctTx(ctx context.Context, tx bun.IDB, column string, criteria ...SelectCriteria) (int, error)
+}
+
+// CountDistinct returns the number of distinct non NULL values of column
+// among the rows matching criteria, as COUNT(DISTINCT column). Plain columns
+// are qualified with the model table alias; qualified ones, like
+// "author.id", are used as given, for columns of joined tables.
+func (r *repo[T]) CountDistinct(ctx context.Context, column string, criteria ...SelectCriteria) (int, error) {
+	return r.CountDistinctTx(ctx, r.db, column, criteria...)
+}
+
+func (r *repo[T]) CountDistinctTx(ctx context.Context, tx bun.IDB, column string, criteria ...SelectCriteria) (int, error) {
+	ctx = r.withOperation(ctx, "CountDistinct", len(criteria))
+	col, ok := normalizeSQLIdentifier(column)
+	if !ok {
+		return 0, invalidColumnError("column", column)
+	}
+
+	q := tx.NewSelect().Model(r.handlers.NewRecord())
+	if strings.Contains(col, ".") {
+		q = q.ColumnExpr("COUNT(DISTINCT ?)", bun.Ident(col))
+	} else {
+		q = q.ColumnExpr("COUNT(DISTINCT ?TableAlias.?)", bun.Ident(col))
+	}
+
+	q = r.applySelectScopes(ctx, q)
+
+	if err := r.applySelectCriteria(q, criteria); err != nil {
+		return 0, err
+	}
+
+	var count int
+	if err := q.Scan(ctx, &count); err != nil {
+		return 0, r.mapError(err)
+	}
+	return count, nil
+}
@@ -0,0 +1,43 @@
+package repository
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRepository_CountDistinct(t *testing.T) {
+	setupTestData(t)
+
+	ctx := context.Background()
+	userRepo := newTestUserRepository(db)
+
+	companyA := uuid.New()
+	companyB := uuid.New()
+	users := []*TestUser{
+		{Name: "A1", Email: "a1@example.com", CompanyID: companyA},
+		{Name: "A2", Email: "a2@example.com", CompanyID: companyA},
+		{Name: "B1", Email: "b1@example.com", CompanyID: companyB},
+	}
+	for _, user := range users {
+		_, err := userRepo.Create(ctx, user)
+		require.NoError(t, err)
+	}
+
+	counter, ok := userRepo.(DistinctCounter)
+	require.True(t, ok)
+
+	companies, err := counter.CountDistinct(ctx, "company_id")
+	require.NoError(t, err)
+	assert.Equal(t, 2, companies)
+
+	companies, err = counter.CountDistinct(ctx, "company_id", SelectBy("name", "LIKE", "A%"))
+	require.NoError(t, err)
+	assert.Equal(t, 1, companies)
+
+	_, err = counter.CountDistinct(ctx, "company_id) FROM test_users; --")
+	require.Error(t, err)
+}
@@ -0,0 +1,84 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/uptrace/bun"
+)
+
+// countEstimateMinRows is the estimate below which CountEstimate counts
+// exactly: small tables are cheap to count and their statistics are the
+// least reliable.
+const countEstimateMinRows = 10000
+
+// CountEstimator is an optional capability for repositories that can return
+// approximate row counts, e.g. for "about 120M results" on huge tables.
+type CountEstimator interface {
+	CountEstimate(ctx context.Context, criteria ...SelectCriteria) (int, bool, error)
+	CountEstimateTx(ctx context.Context, tx bun.IDB, criteria ...SelectCriteria) (int, bool, error)
+}
+
+// CountEstimate returns the number of rows, reporting whether it is a planner
+// estimate instead of an exact count. Without criteria it reads the table
+// statistics: pg_class.reltuples on Postgres and information_schema
+// TABLE_ROWS on MySQL, or on Postgres the EXPLAIN row estimate when select
+// scopes or soft deletes filter the table. With criteria, on other drivers,
+// below 10000 estimated rows or when no estimate is available it falls back
+// to an exact Count. Estimates are only as fresh as the last ANALYZE.
+func (r *repo[T]) CountEstimate(ctx context.Context, criteria ...SelectCriteria) (int, bool, error) {
+	return r.CountEstimateTx(ctx, r.db, criteria...)
+}
+
+func (r *repo[T]) CountEstimateTx(ctx context.Context, tx bun.IDB, criteria ...SelectCriteria) (int, bool, error) {
+	ctx = r.withOperation(ctx, "CountEstimate", len(criteria))
+	if len(criteria) == 0 {
+		if estimate, ok := r.countEstimate(ctx, tx); ok && estimate >= countEstimateMinRows {
+			return int(estimate), true, nil
+		}
+	}
+	count, err := r.CountTx(ctx, tx, criteria...)
+	return count, false, err
+}
+
+// countEstimate returns the planner estimate of the rows Count would count
+// without criteria, if the driver provides one.
+func (r *repo[T]) countEstimate(ctx context.Context, tx bun.IDB) (float64, bool) {
+	q := tx.NewSelect().Model(r.handlers.NewRecord())
+	q = r.applySelectScopes(ctx, q)
+	filtered := selectHasWhere(q) || r.hasSoftDelete()
+
+	var estimate float64
+	switch {
+	case r.driver == "postgres" && !filtered:
+		err := tx.NewRaw("SELECT reltuples FROM pg_class WHERE oid = to_regclass(?)", r.TableName()).
+			Scan(ctx, &estimate)
+		return estimate, err == nil
+	case r.driver == "postgres":
+		var plan []byte
+		if err := tx.NewRaw("EXPLAIN (FORMAT JSON) ?", q).Scan(ctx, &plan); err != nil {
+			return 0, false
+		}
+		return postgresPlanRows(plan)
+	case r.driver == "mysql" && !filtered:
+		err := tx.NewRaw("SELECT COALESCE(TABLE_ROWS, 0) FROM information_schema.TABLES WHERE TABLE_SCHEMA = DATABASE() AND TABLE_NAME = ?", r.TableName()).
+			Scan(ctx, &estimate)
+		return estimate, err == nil
+	default:
+		return 0, false
+	}
+}
+
+// postgresPlanRows returns the row estimate of the top node of an EXPLAIN
+// (FORMAT JSON) plan.
+func postgresPlanRows(plan []byte) (float64, bool) {
+	var explained []struct {
+		Plan struct {
+			Rows *float64 `json:"Plan Rows"`
+		} `json:"Plan"`
+	}
+	if err := json.Unmarshal(plan, &explained); err != nil || len(explained) == 0 || explained[0].Plan.Rows == nil {
+		return 0, false
+	}
+	return *explained[0].Plan.Rows, true
+}
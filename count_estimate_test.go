@@ -0,0 +1,45 @@
+package repository
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRepository_CountEstimate(t *testing.T) {
+	setupTestData(t)
+	ctx := context.Background()
+	repo := newTestUserRepository(db)
+
+	for _, name := range []string{"Alice", "Bob"} {
+		_, err := repo.Create(ctx, &TestUser{Name: name, Email: name + "@example.com", CompanyID: uuid.New()})
+		require.NoError(t, err)
+	}
+
+	estimator, ok := repo.(CountEstimator)
+	require.True(t, ok)
+
+	count, estimated, err := estimator.CountEstimate(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 2, count)
+	assert.False(t, estimated, "SQLite has no planner estimate, so the count is exact")
+
+	count, estimated, err = estimator.CountEstimate(ctx, SelectBy("name", "=", "Alice"))
+	require.NoError(t, err)
+	assert.Equal(t, 1, count)
+	assert.False(t, estimated)
+}
+
+func TestPostgresPlanRows(t *testing.T) {
+	rows, ok := postgresPlanRows([]byte(`[{"Plan": {"Node Type": "Seq Scan", "Plan Rows": 120000000, "Plan Width": 64}}]`))
+	require.True(t, ok)
+	assert.Equal(t, float64(120000000), rows)
+
+	for _, plan := range []string{``, `[]`, `[{"Plan": {}}]`, `not json`} {
+		_, ok := postgresPlanRows([]byte(plan))
+		assert.False(t, ok, plan)
+	}
+}
@@ -0,0 +1,253 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strconv"
+	"time"
+
+	"github.com/goliatone/go-errors"
+	"github.com/uptrace/bun"
+	"github.com/uptrace/bun/dialect"
+	"github.com/uptrace/bun/schema"
+)
+
+// TimeBucket identifies the granularity used to group timestamps.
+type TimeBucket string
+
+const (
+	TimeBucketMinute TimeBucket = "minute"
+	TimeBucketHour   TimeBucket = "hour"
+	TimeBucketDay    TimeBucket = "day"
+	TimeBucketWeek   TimeBucket = "week"
+	TimeBucketMonth  TimeBucket = "month"
+	TimeBucketYear   TimeBucket = "year"
+)
+
+// GroupCounter is an optional capability for repositories that count rows
+// per column value or time bucket, e.g. for stats widgets.
+type GroupCounter interface {
+	CountBy(ctx context.Context, column string, criteria ...SelectCriteria) (map[string]int, error)
+	CountByTx(ctx context.Context, tx bun.IDB, column string, criteria ...SelectCriteria) (map[string]int, error)
+	CountByTimeBucket(ctx context.Context, column string, bucket TimeBucket, criteria ...SelectCriteria) (map[string]int, error)
+	CountByTimeBucketTx(ctx context.Context, tx bun.IDB, column string, bucket TimeBucket, criteria ...SelectCriteria) (map[string]int, error)
+}
+
+// CountBy returns the number of rows per distinct value of column.
+// Keys are normalized to strings: bool columns use "true"/"false" regardless of
+// how the dialect stores them, numeric columns use their decimal form, and NULL
+// values are reported under the empty string key.
+func (r *repo[T]) CountBy(ctx context.Context, column string, criteria ...SelectCriteria) (map[string]int, error) {
+	return r.CountByTx(ctx, r.db, column, criteria...)
+}
+
+func (r *repo[T]) CountByTx(ctx context.Context, tx bun.IDB, column string, criteria ...SelectCriteria) (map[string]int, error) {
+	ctx = r.withOperation(ctx, "CountBy", len(criteria))
+	col, ok := normalizeSQLIdentifier(column)
+	if !ok {
+		return nil, invalidColumnError("column", column)
+	}
+	if err := unknownTableColumnError(r.modelTable(), col); err != nil {
+		return nil, err
+	}
+
+	keyKind := reflect.Invalid
+	if field := r.modelField(col); field != nil {
+		keyKind = field.IndirectType.Kind()
+	}
+
+	expr := fmt.Sprintf("?TableAlias.%s", col)
+	return r.countGrouped(ctx, tx, expr, keyKind, criteria)
+}
+
+// CountByTimeBucket returns the number of rows per time bucket of column.
+// Keys are ISO-8601 timestamps (without zone) marking the start of each bucket,
+// e.g. "2024-03-01T00:00:00" for TimeBucketMonth. Weeks start on Monday.
+func (r *repo[T]) CountByTimeBucket(ctx context.Context, column string, bucket TimeBucket, criteria ...SelectCriteria) (map[string]int, error) {
+	return r.CountByTimeBucketTx(ctx, r.db, column, bucket, criteria...)
+}
+
+func (r *repo[T]) CountByTimeBucketTx(ctx context.Context, tx bun.IDB, column string, bucket TimeBucket, criteria ...SelectCriteria) (map[string]int, error) {
+	ctx = r.withOperation(ctx, "CountByTimeBucket", len(criteria))
+	col, ok := normalizeSQLIdentifier(column)
+	if !ok {
+		return nil, invalidColumnError("column", column)
+	}
+	if err := unknownTableColumnError(r.modelTable(), col); err != nil {
+		return nil, err
+	}
+
+	expr, ok := timeBucketLabelExpr(tx.Dialect().Name(), "?TableAlias."+col, bucket)
+	if !ok {
+		return nil, errors.NewValidation(
+			"repository: unsupported time bucket",
+			errors.FieldError{
+				Field:   "bucket",
+				Message: fmt.Sprintf("bucket %q is not supported for dialect %s", bucket, tx.Dialect().Name()),
+			},
+		)
+	}
+
+	return r.countGrouped(ctx, tx, expr, reflect.String, criteria)
+}
+
+func (r *repo[T]) countGrouped(ctx context.Context, tx bun.IDB, keyExpr string, keyKind reflect.Kind, criteria []SelectCriteria) (map[string]int, error) {
+	record := r.handlers.NewRecord()
+
+	q := tx.NewSelect().
+		Model(record).
+		ColumnExpr(keyExpr + " AS group_key").
+		ColumnExpr("COUNT(*) AS group_count")
+
+	q = r.applySelectScopes(ctx, q)
+
+	if err := r.applySelectCriteria(q, criteria); err != nil {
+		return nil, err
+	}
+
+	q = q.GroupExpr(keyExpr)
+
+	rows, err := q.Rows(ctx)
+	if err != nil {
+		return nil, r.mapError(err)
+	}
+	defer rows.Close()
+
+	counts := make(map[string]int)
+	for rows.Next() {
+		var key any
+		var count int
+		if err := rows.Scan(&key, &count); err != nil {
+			return nil, r.mapError(err)
+		}
+		counts[formatCountKey(key, keyKind)] += count
+	}
+	if err := rows.Err(); err != nil {
+		return nil, r.mapError(err)
+	}
+
+	return counts, nil
+}
+
+func (r *repo[T]) modelTable() *schema.Table {
+	if r.db == nil || r.handlers.NewRecord == nil {
+		return nil
+	}
+	record := r.handlers.NewRecord()
+	if isNilValue(any(record)) {
+		return nil
+	}
+	return r.db.Table(reflect.TypeOf(record))
+}
+
+func (r *repo[T]) modelField(column string) *schema.Field {
+	table := r.modelTable()
+	if table == nil {
+		return nil
+	}
+	return table.FieldMap[column]
+}
+
+func formatCountKey(value any, kind reflect.Kind) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case []byte:
+		return formatCountKey(string(v), kind)
+	case bool:
+		return strconv.FormatBool(v)
+	case int64:
+		if kind == reflect.Bool {
+			return strconv.FormatBool(v != 0)
+		}
+		return strconv.FormatInt(v, 10)
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case time.Time:
+		return v.Format(time.RFC3339Nano)
+	case string:
+		if kind == reflect.Bool {
+			if parsed, err := strconv.ParseBool(v); err == nil {
+				return strconv.FormatBool(parsed)
+			}
+		}
+		return v
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+func timeBucketLabelExpr(name dialect.Name, column string, bucket TimeBucket) (string, bool) {
+	switch name {
+	case dialect.PG:
+		unit, ok := postgresTimeBucketUnit(bucket)
+		if !ok {
+			return "", false
+		}
+		return fmt.Sprintf(`to_char(date_trunc('%s', %s), 'YYYY-MM-DD"T"HH24:MI:SS')`, unit, column), true
+	case dialect.SQLite:
+		return sqliteTimeBucketExpr(column, bucket)
+	case dialect.MySQL:
+		return mysqlTimeBucketExpr(column, bucket)
+	default:
+		return "", false
+	}
+}
+
+func postgresTimeBucketUnit(bucket TimeBucket) (string, bool) {
+	switch bucket {
+	case TimeBucketMinute, TimeBucketHour, TimeBucketDay, TimeBucketWeek, TimeBucketMonth, TimeBucketYear:
+		return string(bucket), true
+	default:
+		return "", false
+	}
+}
+
+func sqliteTimeBucketExpr(column string, bucket TimeBucket) (string, bool) {
+	switch bucket {
+	case TimeBucketMinute:
+		return fmt.Sprintf("strftime('%%Y-%%m-%%dT%%H:%%M:00', %s)", column), true
+	case TimeBucketHour:
+		return fmt.Sprintf("strftime('%%Y-%%m-%%dT%%H:00:00', %s)", column), true
+	case TimeBucketDay:
+		return fmt.Sprintf("strftime('%%Y-%%m-%%dT00:00:00', %s)", column), true
+	case TimeBucketWeek:
+		return fmt.Sprintf("strftime('%%Y-%%m-%%dT00:00:00', %s, 'weekday 0', '-6 days')", column), true
+	case TimeBucketMonth:
+		return fmt.Sprintf("strftime('%%Y-%%m-01T00:00:00', %s)", column), true
+	case TimeBucketYear:
+		return fmt.Sprintf("strftime('%%Y-01-01T00:00:00', %s)", column), true
+	default:
+		return "", false
+	}
+}
+
+func mysqlTimeBucketExpr(column string, bucket TimeBucket) (string, bool) {
+	switch bucket {
+	case TimeBucketMinute:
+		return fmt.Sprintf("DATE_FORMAT(%s, '%%Y-%%m-%%dT%%H:%%i:00')", column), true
+	case TimeBucketHour:
+		return fmt.Sprintf("DATE_FORMAT(%s, '%%Y-%%m-%%dT%%H:00:00')", column), true
+	case TimeBucketDay:
+		return fmt.Sprintf("DATE_FORMAT(%s, '%%Y-%%m-%%dT00:00:00')", column), true
+	case TimeBucketWeek:
+		return fmt.Sprintf("DATE_FORMAT(DATE_SUB(%s, INTERVAL WEEKDAY(%s) DAY), '%%Y-%%m-%%dT00:00:00')", column, column), true
+	case TimeBucketMonth:
+		return fmt.Sprintf("DATE_FORMAT(%s, '%%Y-%%m-01T00:00:00')", column), true
+	case TimeBucketYear:
+		return fmt.Sprintf("DATE_FORMAT(%s, '%%Y-01-01T00:00:00')", column), true
+	default:
+		return "", false
+	}
+}
+
+func invalidColumnError(field, column string) error {
+	return errors.NewValidation(
+		"repository: invalid column",
+		errors.FieldError{
+			Field:   field,
+			Message: fmt.Sprintf("column %q is not a valid SQL identifier", column),
+		},
+	)
+}
@@ -0,0 +1,103 @@
+package repository
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRepository_CountBy(t *testing.T) {
+	setupTestData(t)
+
+	ctx := context.Background()
+	userRepo := newTestUserRepository(db)
+
+	companyA := uuid.New()
+	companyB := uuid.New()
+	users := []*TestUser{
+		{ID: uuid.New(), Name: "A1", Email: "a1@example.com", CompanyID: companyA},
+		{ID: uuid.New(), Name: "A2", Email: "a2@example.com", CompanyID: companyA},
+		{ID: uuid.New(), Name: "B1", Email: "b1@example.com", CompanyID: companyB},
+	}
+	for _, user := range users {
+		_, err := userRepo.Create(ctx, user)
+		require.NoError(t, err)
+	}
+
+	counts, err := userRepo.(GroupCounter).CountBy(ctx, "company_id")
+	require.NoError(t, err)
+	assert.Len(t, counts, 2)
+
+	total := 0
+	for _, count := range counts {
+		total += count
+	}
+	assert.Equal(t, 3, total)
+
+	filtered, err := userRepo.(GroupCounter).CountBy(ctx, "name", SelectBy("company_id", "=", companyB.String()))
+	require.NoError(t, err)
+	assert.Equal(t, map[string]int{"B1": 1}, filtered)
+}
+
+func TestRepository_CountBy_InvalidColumn(t *testing.T) {
+	setupTestData(t)
+
+	userRepo := newTestUserRepository(db)
+
+	_, err := userRepo.(GroupCounter).CountBy(context.Background(), "name; DROP TABLE test_users")
+	require.Error(t, err)
+
+	_, err = userRepo.(GroupCounter).CountBy(context.Background(), "missing")
+	require.Error(t, err)
+	assert.True(t, IsCriteriaInvalid(err))
+
+	_, err = userRepo.(GroupCounter).CountByTimeBucket(context.Background(), "missing", TimeBucketDay)
+	require.Error(t, err)
+	assert.True(t, IsCriteriaInvalid(err))
+}
+
+func TestRepository_CountByTimeBucket(t *testing.T) {
+	setupTestData(t)
+
+	ctx := context.Background()
+	userRepo := newTestUserRepository(db)
+
+	march := time.Date(2024, time.March, 10, 12, 30, 0, 0, time.UTC)
+	april := time.Date(2024, time.April, 2, 8, 0, 0, 0, time.UTC)
+	users := []*TestUser{
+		{ID: uuid.New(), Name: "M1", Email: "m1@example.com", CompanyID: uuid.New(), CreatedAt: march},
+		{ID: uuid.New(), Name: "M2", Email: "m2@example.com", CompanyID: uuid.New(), CreatedAt: march.Add(-48 * time.Hour)},
+		{ID: uuid.New(), Name: "A1", Email: "a1@example.com", CompanyID: uuid.New(), CreatedAt: april},
+	}
+	for _, user := range users {
+		_, err := userRepo.Create(ctx, user)
+		require.NoError(t, err)
+	}
+
+	monthly, err := userRepo.(GroupCounter).CountByTimeBucket(ctx, "created_at", TimeBucketMonth)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]int{
+		"2024-03-01T00:00:00": 2,
+		"2024-04-01T00:00:00": 1,
+	}, monthly)
+
+	weekly, err := userRepo.(GroupCounter).CountByTimeBucket(ctx, "created_at", TimeBucketWeek)
+	require.NoError(t, err)
+	assert.Equal(t, 2, weekly["2024-03-04T00:00:00"])
+
+	_, err = userRepo.(GroupCounter).CountByTimeBucket(ctx, "created_at", TimeBucket("fortnight"))
+	require.Error(t, err)
+}
+
+func TestFormatCountKey_BoolColumns(t *testing.T) {
+	assert.Equal(t, "true", formatCountKey(int64(1), reflect.Bool))
+	assert.Equal(t, "false", formatCountKey(int64(0), reflect.Bool))
+	assert.Equal(t, "false", formatCountKey("f", reflect.Bool))
+	assert.Equal(t, "42", formatCountKey(int64(42), reflect.Int))
+	assert.Empty(t, formatCountKey(nil, reflect.Int))
+}
@@ -0,0 +1,52 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/uptrace/bun"
+)
+
+// TrashedCounter is an optional capability for repositories that can count
+// soft deleted rows, e.g. to show "N active / M archived".
+type TrashedCounter interface {
+	CountTrashed(ctx context.Context, criteria ...SelectCriteria) (int, error)
+	CountTrashedTx(ctx context.Context, tx bun.IDB, criteria ...SelectCriteria) (int, error)
+	CountWithTrashed(ctx context.Context, criteria ...SelectCriteria) (int, error)
+	CountWithTrashedTx(ctx context.Context, tx bun.IDB, criteria ...SelectCriteria) (int, error)
+}
+
+// CountTrashed returns the number of soft deleted rows matching criteria.
+// Models without a soft delete column have no trashed rows and return 0.
+func (r *repo[T]) CountTrashed(ctx context.Context, criteria ...SelectCriteria) (int, error) {
+	return r.CountTrashedTx(ctx, r.db, criteria...)
+}
+
+func (r *repo[T]) CountTrashedTx(ctx context.Context, tx bun.IDB, criteria ...SelectCriteria) (int, error) {
+	ctx = r.withOperation(ctx, "CountTrashed", len(criteria))
+	if !r.hasSoftDelete() {
+		return 0, nil
+	}
+	return r.CountTx(ctx, tx, withCriteria(criteria, SelectDeletedOnly())...)
+}
+
+// CountWithTrashed returns the number of rows matching criteria, soft deleted
+// or not. Models without a soft delete column count like Count.
+func (r *repo[T]) CountWithTrashed(ctx context.Context, criteria ...SelectCriteria) (int, error) {
+	return r.CountWithTrashedTx(ctx, r.db, criteria...)
+}
+
+func (r *repo[T]) CountWithTrashedTx(ctx context.Context, tx bun.IDB, criteria ...SelectCriteria) (int, error) {
+	ctx = r.withOperation(ctx, "CountWithTrashed", len(criteria))
+	if !r.hasSoftDelete() {
+		return r.CountTx(ctx, tx, criteria...)
+	}
+	return r.CountTx(ctx, tx, withCriteria(criteria, SelectDeletedAlso())...)
+}
+
+// withCriteria appends extra after criteria without aliasing the caller's
+// slice, so the appended criteria take precedence.
+func withCriteria(criteria []SelectCriteria, extra ...SelectCriteria) []SelectCriteria {
+	out := make([]SelectCriteria, 0, len(criteria)+len(extra))
+	out = append(out, criteria...)
+	return append(out, extra...)
+}
@@ -0,0 +1,63 @@
+package repository
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRepository_CountTrashed(t *testing.T) {
+	ctx := context.Background()
+	repo := newRetentionTestEventRepository(t)
+	seedRetentionTestEvents(t, repo, time.Hour, time.Hour, time.Hour)
+
+	events, _, err := repo.List(ctx, SelectBy("name", "<>", "event-0"))
+	require.NoError(t, err)
+	require.Len(t, events, 2)
+	require.NoError(t, repo.Delete(ctx, events[0]))
+
+	counter, ok := repo.(TrashedCounter)
+	require.True(t, ok)
+
+	active, err := repo.Count(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 2, active)
+
+	trashed, err := counter.CountTrashed(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, trashed)
+
+	all, err := counter.CountWithTrashed(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 3, all)
+
+	trashed, err = counter.CountTrashed(ctx, SelectBy("name", "=", "event-0"))
+	require.NoError(t, err)
+	assert.Equal(t, 0, trashed)
+
+	trashed, err = counter.CountTrashed(ctx, SelectDeletedAlso())
+	require.NoError(t, err)
+	assert.Equal(t, 1, trashed, "CountTrashed keeps counting trashed rows only")
+}
+
+func TestRepository_CountTrashed_WithoutSoftDelete(t *testing.T) {
+	setupTestData(t)
+	ctx := context.Background()
+	repo := newTestUserRepository(db)
+
+	counter, ok := repo.(TrashedCounter)
+	require.True(t, ok)
+
+	trashed, err := counter.CountTrashed(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 0, trashed)
+
+	expected, err := repo.Count(ctx)
+	require.NoError(t, err)
+	all, err := counter.CountWithTrashed(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, expected, all)
+}
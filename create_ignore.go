@@ -0,0 +1,83 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	stderrors "errors"
+
+	"github.com/google/uuid"
+	"github.com/uptrace/bun"
+)
+
+// DuplicateIgnoringCreator is an optional capability for repositories that
+// can insert a record unless it already exists.
+type DuplicateIgnoringCreator[T any] interface {
+	CreateIgnoreDuplicate(ctx context.Context, record T) (T, bool, error)
+	CreateIgnoreDuplicateTx(ctx context.Context, tx bun.IDB, record T) (T, bool, error)
+}
+
+// CreateIgnoreDuplicate inserts record ignoring unique conflicts and reports
+// whether it was created. When a row with the same primary key or identifier
+// already exists it is returned untouched with created false:
+//
+//	user, created, err := users.(DuplicateIgnoringCreator[*User]).CreateIgnoreDuplicate(ctx, user)
+//
+// This replaces catching IsDuplicatedKey and reselecting. The insert uses
+// ON CONFLICT DO NOTHING (INSERT IGNORE on MySQL, INSERT OR IGNORE on
+// SQLite). When the conflict is on another unique column, so no existing
+// record can be looked up, a not found error is returned. Unique prechecks
+// are skipped, since duplicates are expected.
+func (r *repo[T]) CreateIgnoreDuplicate(ctx context.Context, record T) (T, bool, error) {
+	return r.CreateIgnoreDuplicateTx(ctx, r.db, record)
+}
+
+func (r *repo[T]) CreateIgnoreDuplicateTx(ctx context.Context, tx bun.IDB, record T) (T, bool, error) {
+	ctx = r.withOperation(ctx, "CreateIgnoreDuplicate", 0)
+	var (
+		result  T
+		created bool
+	)
+	err := r.eventTx(ctx, tx, func(ctx context.Context, tx bun.IDB) error {
+		if r.handlers.GetID(record) == uuid.Nil {
+			r.handlers.SetID(record, uuid.New())
+		}
+		if err := r.assignSlugs(ctx, tx, record); err != nil {
+			return err
+		}
+
+		q := tx.NewInsert().Model(record).Ignore()
+		q = r.applyInsertScopes(ctx, q)
+		if err := r.stampIntegrity(record); err != nil {
+			return err
+		}
+		if err := r.encryptRecords(record); err != nil {
+			return err
+		}
+
+		res, err := q.Returning("*").Exec(ctx)
+		switch {
+		case err == nil:
+			if n, err := res.RowsAffected(); err == nil && n > 0 {
+				result, created = record, true
+				return r.publishEvents(ctx, tx, EventCreate, r.createdColumns(), record)
+			}
+		case !stderrors.Is(err, sql.ErrNoRows):
+			return r.mapError(err)
+		}
+
+		existing, found, err := r.findExistingRecord(ctx, tx, record)
+		if err != nil {
+			return r.mapError(err)
+		}
+		if !found {
+			return NewRecordNotFound()
+		}
+		result = existing
+		return nil
+	})
+	if err != nil {
+		var zero T
+		return zero, false, err
+	}
+	return result, created, nil
+}
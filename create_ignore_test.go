@@ -0,0 +1,44 @@
+package repository
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRepository_CreateIgnoreDuplicate(t *testing.T) {
+	setupTestData(t)
+	ctx := context.Background()
+
+	publisher := &recordingPublisher{}
+	repo := newTestUserRepositoryWithConfig(db, nil, WithEventPublisher(publisher))
+	creator, ok := repo.(DuplicateIgnoringCreator[*TestUser])
+	require.True(t, ok)
+
+	user, created, err := creator.CreateIgnoreDuplicate(ctx, &TestUser{Name: "Alice", Email: "alice@example.com"})
+	require.NoError(t, err)
+	assert.True(t, created)
+
+	byIdentifier, created, err := creator.CreateIgnoreDuplicate(ctx, &TestUser{Name: "Other", Email: "alice@example.com"})
+	require.NoError(t, err)
+	assert.False(t, created)
+	assert.Equal(t, user.ID, byIdentifier.ID)
+	assert.Equal(t, "Alice", byIdentifier.Name, "the existing record is returned untouched")
+
+	byID, created, err := creator.CreateIgnoreDuplicate(ctx, &TestUser{ID: user.ID, Name: "Other", Email: "other@example.com"})
+	require.NoError(t, err)
+	assert.False(t, created)
+	assert.Equal(t, "alice@example.com", byID.Email)
+
+	count, err := repo.Count(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, count)
+	assert.Len(t, publisher.recorded(), 1, "only the insert publishes an event")
+
+	_, created, err = creator.CreateIgnoreDuplicate(ctx, &TestUser{ID: uuid.New(), Name: "Bob", Email: "bob@example.com"})
+	require.NoError(t, err)
+	assert.True(t, created)
+}
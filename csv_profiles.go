@@ -0,0 +1,304 @@
+package repository
+
+import (
+	"context"
+	"encoding/csv"
+	stderrors "errors"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/goliatone/go-errors"
+	"github.com/uptrace/bun"
+)
+
+// ErrCSVProfileNotFound is returned for profile names never configured with
+// WithCSVProfiles.
+var ErrCSVProfileNotFound = stderrors.New("repository: csv profile not found")
+
+// CSVColumn maps a CSV header to a bun column.
+type CSVColumn struct {
+	Header string
+	Column string
+	// Parse converts a cell before it is mapped onto the record. Without it
+	// the cell is passed as a string and coerced like any MapToRecord value.
+	Parse func(value string) (any, error)
+	// Format renders the column value on export. Without it times are
+	// written as RFC 3339 and other values with fmt.Sprint.
+	Format func(value any) (string, error)
+}
+
+// CSVProfile is a named column mapping for a partner file format.
+type CSVProfile struct {
+	Name    string
+	Columns []CSVColumn
+	// Comma is the field delimiter. Defaults to ','.
+	Comma rune
+	// IgnoreUnknownHeaders skips CSV columns missing from Columns instead of
+	// failing the import.
+	IgnoreUnknownHeaders bool
+}
+
+// CSVTransfer is an optional capability for repositories configured with
+// WithCSVProfiles.
+type CSVTransfer interface {
+	ImportCSV(ctx context.Context, r io.Reader, profile string, opts ...ImportOption) (ImportReport, error)
+	ImportCSVTx(ctx context.Context, tx bun.IDB, r io.Reader, profile string, opts ...ImportOption) (ImportReport, error)
+	ExportCSV(ctx context.Context, w io.Writer, profile string, criteria ...SelectCriteria) (int, error)
+	ExportCSVTx(ctx context.Context, tx bun.IDB, w io.Writer, profile string, criteria ...SelectCriteria) (int, error)
+}
+
+// WithCSVProfiles registers named CSV profiles used by ImportCSV and
+// ExportCSV. Invalid profiles are reported by Validate.
+func WithCSVProfiles(profiles ...CSVProfile) RepoOption {
+	return func(cfg *repoConfig) {
+		if cfg == nil {
+			return
+		}
+		cfg.csvProfiles = append(cfg.csvProfiles, profiles...)
+	}
+}
+
+// ImportCSV reads a CSV file with a header row, maps its columns through the
+// named profile and imports the rows like Import. Rows whose cells fail to
+// parse are reported in the ImportReport; row numbers exclude the header.
+func (r *repo[T]) ImportCSV(ctx context.Context, reader io.Reader, profile string, opts ...ImportOption) (ImportReport, error) {
+	return r.ImportCSVTx(ctx, r.db, reader, profile, opts...)
+}
+
+func (r *repo[T]) ImportCSVTx(ctx context.Context, tx bun.IDB, reader io.Reader, profile string, opts ...ImportOption) (ImportReport, error) {
+	ctx = r.withOperation(ctx, "ImportCSV", 0)
+	p, err := r.csvProfile(profile)
+	if err != nil {
+		return ImportReport{}, err
+	}
+
+	cr := newCSVReader(reader, p)
+	header, err := cr.Read()
+	if err != nil {
+		return ImportReport{}, fmt.Errorf("repository: csv header: %w", err)
+	}
+	columns, err := csvImportColumns(p, header)
+	if err != nil {
+		return ImportReport{}, err
+	}
+
+	inputs := []importInput{}
+	for row := 1; ; row++ {
+		record, err := cr.Read()
+		if err == io.EOF {
+			break
+		}
+		input := importInput{row: row}
+		var parseErr *csv.ParseError
+		switch {
+		case stderrors.As(err, &parseErr):
+			input.line = parseErr.StartLine
+			input.err = err
+		case err != nil:
+			return ImportReport{}, err
+		default:
+			input.line, _ = cr.FieldPos(0)
+			input.values, input.err = csvRowValues(columns, record)
+		}
+		inputs = append(inputs, input)
+	}
+
+	return r.importInputs(ctx, tx, inputs, newImportConfig(opts))
+}
+
+// ExportCSV writes the records matched by criteria, with a header row, in
+// the named profile format and returns the number of records written.
+// Default list pagination does not apply.
+func (r *repo[T]) ExportCSV(ctx context.Context, writer io.Writer, profile string, criteria ...SelectCriteria) (int, error) {
+	return r.ExportCSVTx(ctx, r.db, writer, profile, criteria...)
+}
+
+func (r *repo[T]) ExportCSVTx(ctx context.Context, tx bun.IDB, writer io.Writer, profile string, criteria ...SelectCriteria) (int, error) {
+	ctx = r.withOperation(ctx, "ExportCSV", len(criteria))
+	p, err := r.csvProfile(profile)
+	if err != nil {
+		return 0, err
+	}
+
+	records := []T{}
+	q := tx.NewSelect().Model(&records)
+	q = r.applySelectScopes(ctx, q)
+	if err := r.applySelectCriteria(q, criteria); err != nil {
+		return 0, err
+	}
+	if len(r.defaultOrder) > 0 && !selectHasOrder(q) {
+		for _, expr := range r.defaultOrder {
+			q.OrderExpr(expr)
+		}
+	}
+	if err := r.scanRecords(ctx, q.Scan); err != nil {
+		return 0, err
+	}
+
+	cw := csv.NewWriter(writer)
+	if p.Comma != 0 {
+		cw.Comma = p.Comma
+	}
+	header := make([]string, 0, len(p.Columns))
+	for _, column := range p.Columns {
+		header = append(header, column.Header)
+	}
+	if err := cw.Write(header); err != nil {
+		return 0, err
+	}
+
+	line := make([]string, len(p.Columns))
+	for i, record := range records {
+		values, err := RecordToMap(record, WithProjectionKeyMode(MapKeyBun), WithProjectionRedaction(false))
+		if err != nil {
+			return i, err
+		}
+		for j, column := range p.Columns {
+			if line[j], err = formatCSVValue(column, values[column.Column]); err != nil {
+				return i, fmt.Errorf("repository: csv column %q: %w", column.Header, err)
+			}
+		}
+		if err := cw.Write(line); err != nil {
+			return i, err
+		}
+	}
+	cw.Flush()
+	return len(records), cw.Error()
+}
+
+func (r *repo[T]) csvProfile(name string) (CSVProfile, error) {
+	for _, profile := range r.csvProfiles {
+		if profile.Name == name {
+			return profile, nil
+		}
+	}
+	return CSVProfile{}, fmt.Errorf("%w: %q", ErrCSVProfileNotFound, name)
+}
+
+func newCSVReader(reader io.Reader, profile CSVProfile) *csv.Reader {
+	cr := csv.NewReader(reader)
+	if profile.Comma != 0 {
+		cr.Comma = profile.Comma
+	}
+	cr.TrimLeadingSpace = true
+	return cr
+}
+
+// csvImportColumns resolves the profile column of every header cell; nil
+// entries are ignored headers.
+func csvImportColumns(profile CSVProfile, header []string) ([]*CSVColumn, error) {
+	columns := make([]*CSVColumn, len(header))
+	for i, cell := range header {
+		cell = strings.TrimSpace(strings.TrimPrefix(cell, "\ufeff"))
+		for j := range profile.Columns {
+			if strings.EqualFold(profile.Columns[j].Header, cell) {
+				columns[i] = &profile.Columns[j]
+				break
+			}
+		}
+		if columns[i] == nil && !profile.IgnoreUnknownHeaders {
+			return nil, errors.NewValidation(
+				"repository: unknown csv header",
+				errors.FieldError{Field: "header", Message: fmt.Sprintf("header %q is not mapped by profile %q", cell, profile.Name)},
+			)
+		}
+	}
+	return columns, nil
+}
+
+func csvRowValues(columns []*CSVColumn, record []string) (map[string]any, error) {
+	values := make(map[string]any, len(columns))
+	for i, cell := range record {
+		if i >= len(columns) || columns[i] == nil {
+			continue
+		}
+		column := columns[i]
+		if column.Parse == nil {
+			values[column.Column] = cell
+			continue
+		}
+		value, err := column.Parse(cell)
+		if err != nil {
+			return nil, fmt.Errorf("column %q: %w", column.Header, err)
+		}
+		values[column.Column] = value
+	}
+	return values, nil
+}
+
+func formatCSVValue(column CSVColumn, value any) (string, error) {
+	if column.Format != nil {
+		return column.Format(value)
+	}
+	if value == nil {
+		return "", nil
+	}
+	rv := reflect.ValueOf(value)
+	for rv.Kind() == reflect.Pointer {
+		if rv.IsNil() {
+			return "", nil
+		}
+		rv = rv.Elem()
+	}
+	switch v := rv.Interface().(type) {
+	case time.Time:
+		return v.Format(time.RFC3339Nano), nil
+	case []byte:
+		return string(v), nil
+	default:
+		return fmt.Sprint(v), nil
+	}
+}
+
+func (r *repo[T]) validateCSVProfiles() error {
+	if len(r.csvProfiles) == 0 {
+		return nil
+	}
+
+	table := r.modelTable()
+	names := map[string]struct{}{}
+	var validationErrors errors.ValidationErrors
+	addErr := func(format string, args ...any) {
+		validationErrors = append(validationErrors, errors.FieldError{
+			Field:   "repoOptions.WithCSVProfiles",
+			Message: fmt.Sprintf(format, args...),
+		})
+	}
+
+	for _, profile := range r.csvProfiles {
+		if _, ok := names[profile.Name]; ok {
+			addErr("profile %q is registered twice", profile.Name)
+		}
+		names[profile.Name] = struct{}{}
+		if len(profile.Columns) == 0 {
+			addErr("profile %q has no columns", profile.Name)
+		}
+
+		headers := map[string]struct{}{}
+		for _, column := range profile.Columns {
+			header := strings.ToLower(strings.TrimSpace(column.Header))
+			if header == "" {
+				addErr("profile %q has a column without header", profile.Name)
+			} else if _, ok := headers[header]; ok {
+				addErr("profile %q maps header %q twice", profile.Name, column.Header)
+			}
+			headers[header] = struct{}{}
+
+			col, ok := normalizeSQLIdentifier(column.Column)
+			switch {
+			case !ok:
+				addErr("profile %q: column %q is not a valid SQL identifier", profile.Name, column.Column)
+			case table != nil && table.FieldMap[col] == nil:
+				addErr("profile %q: column %q does not exist on %s", profile.Name, col, table.Name)
+			}
+		}
+	}
+
+	if len(validationErrors) > 0 {
+		return errors.NewValidation("repository configuration invalid", validationErrors...)
+	}
+	return nil
+}
@@ -0,0 +1,97 @@
+package repository
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	goerrors "github.com/goliatone/go-errors"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func partnerCSVProfile() CSVProfile {
+	return CSVProfile{
+		Name:  "partner",
+		Comma: ';',
+		Columns: []CSVColumn{
+			{Header: "Full Name", Column: "name"},
+			{
+				Header: "E-Mail",
+				Column: "email",
+				Parse: func(value string) (any, error) {
+					return strings.ToLower(value), nil
+				},
+			},
+			{Header: "Company", Column: "company_id"},
+			{
+				Header: "Joined",
+				Column: "created_at",
+				Parse: func(value string) (any, error) {
+					return time.Parse("02/01/2006", value)
+				},
+				Format: func(value any) (string, error) {
+					return value.(time.Time).Format("02/01/2006"), nil
+				},
+			},
+		},
+	}
+}
+
+func TestRepository_CSVProfiles(t *testing.T) {
+	setupTestData(t)
+	ctx := context.Background()
+
+	repo := newTestUserRepositoryWithConfig(db, nil, WithCSVProfiles(partnerCSVProfile()))
+	transfer, ok := repo.(CSVTransfer)
+	require.True(t, ok)
+
+	company := uuid.New()
+	input := "Full Name;E-Mail;Company;Joined\n" +
+		"Alice;ALICE@example.com;" + company.String() + ";02/01/2024\n" +
+		"Bob;bob@example.com;" + company.String() + ";not-a-date\n" +
+		"Carol;carol@example.com;" + company.String() + ";15/03/2024\n"
+
+	report, err := transfer.ImportCSV(ctx, strings.NewReader(input), "partner")
+	require.NoError(t, err)
+	assert.Equal(t, 3, report.Rows)
+	assert.Equal(t, 2, report.Inserted)
+	assert.Equal(t, 1, report.Failed)
+	require.Len(t, report.Errors, 1)
+	assert.Equal(t, 2, report.Errors[0].Row)
+	assert.Contains(t, report.Errors[0].Error(), `column "Joined"`)
+
+	alice, err := repo.GetByIdentifier(ctx, "alice@example.com")
+	require.NoError(t, err)
+	assert.Equal(t, company, alice.CompanyID)
+	assert.Equal(t, time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC), alice.CreatedAt.UTC())
+
+	var out bytes.Buffer
+	written, err := transfer.ExportCSV(ctx, &out, "partner", OrderBy("name ASC"))
+	require.NoError(t, err)
+	assert.Equal(t, 2, written)
+	assert.Equal(t, "Full Name;E-Mail;Company;Joined\n"+
+		"Alice;alice@example.com;"+company.String()+";02/01/2024\n"+
+		"Carol;carol@example.com;"+company.String()+";15/03/2024\n", out.String())
+
+	_, err = transfer.ImportCSV(ctx, strings.NewReader("Full Name;Phone\nDan;123\n"), "partner")
+	assert.True(t, goerrors.IsValidation(err))
+
+	_, err = transfer.ExportCSV(ctx, &out, "missing")
+	assert.ErrorIs(t, err, ErrCSVProfileNotFound)
+}
+
+func TestRepository_CSVProfilesValidate(t *testing.T) {
+	profile := partnerCSVProfile()
+	profile.Columns = append(profile.Columns, CSVColumn{Header: "Phone", Column: "phone"})
+
+	repo := NewRepositoryWithConfig[*TestUser](db, testUserHandlers(), nil,
+		WithCSVProfiles(profile, CSVProfile{Name: "partner"}),
+	)
+	err := repo.(Validator).Validate()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "repository configuration invalid")
+}
@@ -0,0 +1,339 @@
+package repository
+
+import (
+	"bytes"
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	stderrors "errors"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/goliatone/go-errors"
+	"github.com/uptrace/bun"
+	"github.com/uptrace/bun/schema"
+)
+
+// encryptedValuePrefix marks stored values written by WithEncryptedColumns,
+// so plaintext rows written before encryption was enabled still read back.
+const encryptedValuePrefix = "enc:v1:"
+
+// Cipher encrypts and decrypts column values for WithEncryptedColumns.
+type Cipher interface {
+	Encrypt(plaintext []byte) ([]byte, error)
+	Decrypt(ciphertext []byte) ([]byte, error)
+}
+
+type aesGCMCipher struct {
+	aead cipher.AEAD
+}
+
+// NewAESGCMCipher returns a Cipher using AES-GCM with a random nonce per
+// value. key must be 16, 24 or 32 bytes long.
+func NewAESGCMCipher(key []byte) (Cipher, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	return &aesGCMCipher{aead: aead}, nil
+}
+
+func (c *aesGCMCipher) Encrypt(plaintext []byte) ([]byte, error) {
+	nonce := make([]byte, c.aead.NonceSize(), c.aead.NonceSize()+len(plaintext)+c.aead.Overhead())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	return c.aead.Seal(nonce, nonce, plaintext, nil), nil
+}
+
+func (c *aesGCMCipher) Decrypt(ciphertext []byte) ([]byte, error) {
+	size := c.aead.NonceSize()
+	if len(ciphertext) < size {
+		return nil, stderrors.New("repository: ciphertext too short")
+	}
+	return c.aead.Open(nil, ciphertext[:size], ciphertext[size:], nil)
+}
+
+type encryptionConfig struct {
+	cipher  Cipher
+	columns []string
+}
+
+// WithEncryptedColumns encrypts the given string or []byte columns at rest:
+// Create, CreateMany, Update, UpdateMany and the other writes of the
+// repository encrypt the values right before the statement runs, and a query
+// hook registered on the bun.DB decrypts them whenever the repository scans
+// records of the model. Each repository decrypts with its own cipher; queries
+// run outside the repository, relations included, read the stored values.
+// Stored values carry an "enc:v1:" prefix, so rows written before the option
+// was enabled keep reading back as they are; plaintext that happens to start
+// with the prefix is still encrypted, as only values that open with the
+// cipher count as encrypted. Ciphertexts differ on every write, so encrypted
+// columns cannot be filtered, sorted or used in unique checks. Reads return
+// values the cipher fails to open as errors; writes report them to
+// WithQueryHookErrorHandler.
+// Unknown or unsupported columns are reported by Validate.
+func WithEncryptedColumns(c Cipher, columns ...string) RepoOption {
+	return func(cfg *repoConfig) {
+		if cfg == nil || c == nil {
+			return
+		}
+		cfg.encryption = &encryptionConfig{cipher: c}
+		for _, column := range columns {
+			if column = strings.TrimSpace(column); column != "" && !containsString(cfg.encryption.columns, column) {
+				cfg.encryption.columns = append(cfg.encryption.columns, column)
+			}
+		}
+	}
+}
+
+// encryptedModel holds the encrypted fields of one model.
+type encryptedModel struct {
+	typ    reflect.Type
+	cipher Cipher
+	fields []*schema.Field
+}
+
+type encryptedModelKey struct{}
+
+// encryptionHook decrypts the encrypted columns of scanned records, with the
+// encryptedModel the repository running the query put on its context. The
+// hook holds no state, so repositories register it once per bun.DB and two
+// repositories of the same model never share a cipher.
+type encryptionHook struct{}
+
+func (encryptionHook) QueryHookKey() string {
+	return "encryption"
+}
+
+func (encryptionHook) BeforeQuery(ctx context.Context, _ *bun.QueryEvent) context.Context {
+	return ctx
+}
+
+func (encryptionHook) AfterQuery(ctx context.Context, event *bun.QueryEvent) {
+	model, ok := ctx.Value(encryptedModelKey{}).(*encryptedModel)
+	if !ok || event.Model == nil {
+		return
+	}
+	value := reflect.ValueOf(event.Model.Value())
+	for value.Kind() == reflect.Pointer || value.Kind() == reflect.Interface {
+		if value.IsNil() {
+			return
+		}
+		value = value.Elem()
+	}
+
+	var errs []error
+	switch value.Kind() {
+	case reflect.Struct:
+		errs = append(errs, model.decryptScanned(value))
+	case reflect.Slice:
+		for i := range value.Len() {
+			errs = append(errs, model.decryptScanned(reflect.Indirect(value.Index(i))))
+		}
+	}
+	if err := stderrors.Join(errs...); err != nil {
+		reportScanError(ctx, event.DB, encryptionHook{}, err)
+	}
+}
+
+// decryptScanned decrypts value when it is a record of the model.
+func (m *encryptedModel) decryptScanned(value reflect.Value) error {
+	if value.Kind() != reflect.Struct || value.Type() != m.typ {
+		return nil
+	}
+	return m.decrypt(value)
+}
+
+// registerEncryptedColumns resolves columns on the model of r and registers
+// the encryption hook on the DB.
+func (r *repo[T]) registerEncryptedColumns(cfg *encryptionConfig) {
+	table := r.modelTable()
+	if table == nil {
+		return
+	}
+	model := &encryptedModel{typ: table.Type, cipher: cfg.cipher}
+	for _, column := range cfg.columns {
+		if field, ok := table.FieldMap[column]; ok && encryptableField(field) {
+			model.fields = append(model.fields, field)
+		}
+	}
+	r.encryption = model
+	registerQueryHooks(r.db, encryptionHook{})
+}
+
+// withEncryption scopes the encrypted columns of r to ctx, so the encryption
+// hook decrypts the records the repository scans with it.
+func (r *repo[T]) withEncryption(ctx context.Context) context.Context {
+	if r.encryption == nil {
+		return ctx
+	}
+	if current, ok := ctx.Value(encryptedModelKey{}).(*encryptedModel); ok && current == r.encryption {
+		return ctx
+	}
+	return context.WithValue(ctx, encryptedModelKey{}, r.encryption)
+}
+
+func encryptableField(field *schema.Field) bool {
+	typ := field.IndirectType
+	return typ.Kind() == reflect.String || (typ.Kind() == reflect.Slice && typ.Elem().Kind() == reflect.Uint8)
+}
+
+// encryptRecords encrypts the encrypted columns of records in place. The
+// query hook decrypts them again once the write ran; writes and reads that do
+// not go through bun must call decryptRecords.
+func (r *repo[T]) encryptRecords(records ...T) error {
+	if r.encryption == nil {
+		return nil
+	}
+	for _, record := range records {
+		if err := r.encryption.encrypt(reflect.Indirect(reflect.ValueOf(record))); err != nil {
+			_ = r.decryptRecords(records...)
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *repo[T]) decryptRecords(records ...T) error {
+	if r.encryption == nil {
+		return nil
+	}
+	var errs []error
+	for _, record := range records {
+		errs = append(errs, r.encryption.decrypt(reflect.Indirect(reflect.ValueOf(record))))
+	}
+	return stderrors.Join(errs...)
+}
+
+func (m *encryptedModel) encrypt(value reflect.Value) error {
+	for _, field := range m.fields {
+		fv := reflect.Indirect(field.Value(value))
+		if !fv.IsValid() {
+			continue
+		}
+		switch fv.Kind() {
+		case reflect.String:
+			if plaintext := fv.String(); plaintext != "" && !m.sealedString(plaintext) {
+				ciphertext, err := m.cipher.Encrypt([]byte(plaintext))
+				if err != nil {
+					return fmt.Errorf("repository: encrypt %s: %w", field.Name, err)
+				}
+				fv.SetString(encryptedValuePrefix + base64.StdEncoding.EncodeToString(ciphertext))
+			}
+		case reflect.Slice:
+			if plaintext := fv.Bytes(); len(plaintext) > 0 && !m.sealedBytes(plaintext) {
+				ciphertext, err := m.cipher.Encrypt(plaintext)
+				if err != nil {
+					return fmt.Errorf("repository: encrypt %s: %w", field.Name, err)
+				}
+				fv.SetBytes(append([]byte(encryptedValuePrefix), ciphertext...))
+			}
+		}
+	}
+	return nil
+}
+
+// sealedString reports whether value is an envelope written by encrypt: it
+// must carry the prefix and open with the cipher. Plaintext that merely
+// starts with the prefix is encrypted like any other value.
+func (m *encryptedModel) sealedString(value string) bool {
+	encoded, ok := strings.CutPrefix(value, encryptedValuePrefix)
+	if !ok {
+		return false
+	}
+	ciphertext, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return false
+	}
+	_, err = m.cipher.Decrypt(ciphertext)
+	return err == nil
+}
+
+// sealedBytes is sealedString for []byte columns.
+func (m *encryptedModel) sealedBytes(value []byte) bool {
+	ciphertext, ok := bytes.CutPrefix(value, []byte(encryptedValuePrefix))
+	if !ok {
+		return false
+	}
+	_, err := m.cipher.Decrypt(ciphertext)
+	return err == nil
+}
+
+func (m *encryptedModel) decrypt(value reflect.Value) error {
+	var errs []error
+	for _, field := range m.fields {
+		fv := reflect.Indirect(field.Value(value))
+		if !fv.IsValid() {
+			continue
+		}
+		switch fv.Kind() {
+		case reflect.String:
+			encoded, ok := strings.CutPrefix(fv.String(), encryptedValuePrefix)
+			if !ok {
+				continue
+			}
+			ciphertext, err := base64.StdEncoding.DecodeString(encoded)
+			if err == nil {
+				var plaintext []byte
+				if plaintext, err = m.cipher.Decrypt(ciphertext); err == nil {
+					fv.SetString(string(plaintext))
+				}
+			}
+			if err != nil {
+				errs = append(errs, fmt.Errorf("repository: decrypt %s: %w", field.Name, err))
+			}
+		case reflect.Slice:
+			ciphertext, ok := bytes.CutPrefix(fv.Bytes(), []byte(encryptedValuePrefix))
+			if !ok {
+				continue
+			}
+			plaintext, err := m.cipher.Decrypt(ciphertext)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("repository: decrypt %s: %w", field.Name, err))
+				continue
+			}
+			fv.SetBytes(plaintext)
+		}
+	}
+	return stderrors.Join(errs...)
+}
+
+func (r *repo[T]) validateEncryptedColumns() error {
+	if r.encryptionConfig == nil {
+		return nil
+	}
+	table := r.modelTable()
+	if table == nil {
+		return nil
+	}
+
+	var validationErrors errors.ValidationErrors
+	for _, column := range r.encryptionConfig.columns {
+		field, ok := table.FieldMap[column]
+		switch {
+		case !ok:
+			validationErrors = append(validationErrors, errors.FieldError{
+				Field:   "repoOptions.WithEncryptedColumns",
+				Message: fmt.Sprintf("unknown column %q on %s", column, table.Name),
+			})
+		case !encryptableField(field):
+			validationErrors = append(validationErrors, errors.FieldError{
+				Field:   "repoOptions.WithEncryptedColumns",
+				Message: fmt.Sprintf("column %q on %s is not a string or []byte", column, table.Name),
+			})
+		}
+	}
+
+	if len(validationErrors) > 0 {
+		return errors.NewValidation("repository configuration invalid", validationErrors...)
+	}
+	return nil
+}
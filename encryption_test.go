@@ -0,0 +1,193 @@
+package repository
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/uptrace/bun"
+)
+
+type encryptionTestPatient struct {
+	bun.BaseModel `bun:"table:encryption_test_patients,alias:etp"`
+
+	ID    uuid.UUID `bun:"id,pk,notnull"`
+	Name  string    `bun:"name,notnull"`
+	SSN   string    `bun:"ssn"`
+	Notes []byte    `bun:"notes"`
+}
+
+func newEncryptionTestRepository(t *testing.T, opts ...RepoOption) Repository[*encryptionTestPatient] {
+	t.Helper()
+	return newTestRepository[*encryptionTestPatient](t, opts...)
+}
+
+func newTestCipher(t *testing.T) Cipher {
+	t.Helper()
+	c, err := NewAESGCMCipher(bytes.Repeat([]byte{7}, 32))
+	require.NoError(t, err)
+	return c
+}
+
+func TestAESGCMCipher(t *testing.T) {
+	c := newTestCipher(t)
+	first, err := c.Encrypt([]byte("secret"))
+	require.NoError(t, err)
+	second, err := c.Encrypt([]byte("secret"))
+	require.NoError(t, err)
+	assert.NotEqual(t, first, second)
+
+	plaintext, err := c.Decrypt(first)
+	require.NoError(t, err)
+	assert.Equal(t, "secret", string(plaintext))
+
+	_, err = NewAESGCMCipher([]byte("short"))
+	assert.Error(t, err)
+}
+
+func TestRepository_WithEncryptedColumns(t *testing.T) {
+	ctx := context.Background()
+	repo := newEncryptionTestRepository(t, WithEncryptedColumns(newTestCipher(t), "ssn", "notes"))
+	require.NoError(t, repo.(Validator).Validate())
+
+	created, err := repo.Create(ctx, &encryptionTestPatient{Name: "Ada", SSN: "123-45-6789", Notes: []byte("allergic")})
+	require.NoError(t, err)
+	assert.Equal(t, "123-45-6789", created.SSN)
+	assert.Equal(t, "allergic", string(created.Notes))
+
+	var raw struct {
+		SSN   string `bun:"ssn"`
+		Notes []byte `bun:"notes"`
+	}
+	require.NoError(t, db.NewRaw("SELECT ssn, notes FROM encryption_test_patients WHERE id = ?", created.ID).Scan(ctx, &raw))
+	assert.True(t, strings.HasPrefix(raw.SSN, "enc:v1:"))
+	assert.NotContains(t, raw.SSN, "123-45-6789")
+	assert.True(t, bytes.HasPrefix(raw.Notes, []byte("enc:v1:")))
+
+	found, err := repo.GetByID(ctx, created.ID.String())
+	require.NoError(t, err)
+	assert.Equal(t, "123-45-6789", found.SSN)
+	assert.Equal(t, "allergic", string(found.Notes))
+
+	found.SSN = "987-65-4321"
+	updated, err := repo.Update(ctx, found)
+	require.NoError(t, err)
+	assert.Equal(t, "987-65-4321", updated.SSN)
+
+	records, _, err := repo.List(ctx)
+	require.NoError(t, err)
+	require.Len(t, records, 1)
+	assert.Equal(t, "987-65-4321", records[0].SSN)
+	assert.Equal(t, "allergic", string(records[0].Notes))
+}
+
+func TestRepository_WithEncryptedColumns_ReadsPlaintextRows(t *testing.T) {
+	ctx := context.Background()
+	repo := newEncryptionTestRepository(t, WithEncryptedColumns(newTestCipher(t), "ssn"))
+
+	legacy := &encryptionTestPatient{ID: uuid.New(), Name: "Legacy", SSN: "plain"}
+	_, err := db.NewInsert().Model(legacy).Exec(ctx)
+	require.NoError(t, err)
+
+	found, err := repo.GetByID(ctx, legacy.ID.String())
+	require.NoError(t, err)
+	assert.Equal(t, "plain", found.SSN)
+}
+
+func TestRepository_WithEncryptedColumns_Validate(t *testing.T) {
+	repo := newEncryptionTestRepository(t, WithEncryptedColumns(newTestCipher(t), "missing"))
+	err := repo.(Validator).Validate()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "repository configuration invalid")
+}
+
+func TestRepository_WithEncryptedColumns_PrefixedPlaintext(t *testing.T) {
+	ctx := context.Background()
+	repo := newEncryptionTestRepository(t, WithEncryptedColumns(newTestCipher(t), "ssn"))
+
+	created, err := repo.Create(ctx, &encryptionTestPatient{Name: "Ada", SSN: "enc:v1:not-a-ciphertext"})
+	require.NoError(t, err)
+
+	var raw string
+	require.NoError(t, db.NewRaw("SELECT ssn FROM encryption_test_patients WHERE id = ?", created.ID).Scan(ctx, &raw))
+	assert.NotContains(t, raw, "not-a-ciphertext")
+
+	found, err := repo.GetByID(ctx, created.ID.String())
+	require.NoError(t, err)
+	assert.Equal(t, "enc:v1:not-a-ciphertext", found.SSN)
+}
+
+func TestRepository_WithEncryptedColumns_PreparedStatements(t *testing.T) {
+	ctx := context.Background()
+	repo := newEncryptionTestRepository(t, WithEncryptedColumns(newTestCipher(t), "ssn", "notes"), WithPreparedStatements())
+	defer repo.(PreparedStatementCache).ClosePreparedStatements()
+
+	created, err := repo.Create(ctx, &encryptionTestPatient{Name: "Ada", SSN: "123-45-6789", Notes: []byte("allergic")})
+	require.NoError(t, err)
+
+	for range 2 {
+		found, err := repo.GetByID(ctx, created.ID.String())
+		require.NoError(t, err)
+		assert.Equal(t, "123-45-6789", found.SSN)
+		assert.Equal(t, "allergic", string(found.Notes))
+	}
+}
+
+func TestRepository_WithEncryptedColumns_PerRepositoryCipher(t *testing.T) {
+	ctx := context.Background()
+	first := newEncryptionTestRepository(t, WithEncryptedColumns(newTestCipher(t), "ssn"))
+	otherCipher, err := NewAESGCMCipher(bytes.Repeat([]byte{9}, 32))
+	require.NoError(t, err)
+	second := NewRepositoryWithConfig(db, testModelHandlers[*encryptionTestPatient](), nil, WithEncryptedColumns(otherCipher, "ssn"))
+
+	created, err := first.Create(ctx, &encryptionTestPatient{Name: "Ada", SSN: "123-45-6789"})
+	require.NoError(t, err)
+
+	found, err := first.GetByID(ctx, created.ID.String())
+	require.NoError(t, err)
+	assert.Equal(t, "123-45-6789", found.SSN)
+
+	_, err = second.GetByID(ctx, created.ID.String())
+	assert.Error(t, err, "the second cipher cannot open the value")
+
+	stored := &encryptionTestPatient{}
+	require.NoError(t, db.NewSelect().Model(stored).Where("id = ?", created.ID).Scan(ctx))
+	assert.True(t, strings.HasPrefix(stored.SSN, "enc:v1:"))
+}
+
+func TestRepository_WithEncryptedColumns_DecryptionErrors(t *testing.T) {
+	ctx := context.Background()
+	writer := newEncryptionTestRepository(t, WithEncryptedColumns(newTestCipher(t), "ssn"))
+	created, err := writer.Create(ctx, &encryptionTestPatient{Name: "Ada", SSN: "123-45-6789"})
+	require.NoError(t, err)
+
+	otherCipher, err := NewAESGCMCipher(bytes.Repeat([]byte{9}, 32))
+	require.NoError(t, err)
+
+	var hookErrs []error
+	WithQueryHookErrorHandler(func(_ *bun.DB, _ bun.QueryHook, err error) { hookErrs = append(hookErrs, err) })(db)
+	defer WithQueryHookErrorHandler(LogQueryHookErrorHandler)(db)
+
+	reader := NewRepositoryWithConfig(db, testModelHandlers[*encryptionTestPatient](), nil, WithEncryptedColumns(otherCipher, "ssn"))
+	_, err = reader.GetByID(ctx, created.ID.String())
+	assert.Error(t, err)
+	_, _, err = reader.List(ctx)
+	assert.Error(t, err)
+
+	windowed := NewRepositoryWithConfig(db, testModelHandlers[*encryptionTestPatient](), nil,
+		WithEncryptedColumns(otherCipher, "ssn"), WithListWindowCount(true))
+	_, _, err = windowed.List(ctx)
+	assert.Error(t, err)
+
+	prepared := NewRepositoryWithConfig(db, testModelHandlers[*encryptionTestPatient](), nil,
+		WithEncryptedColumns(otherCipher, "ssn"), WithPreparedStatements())
+	defer prepared.(PreparedStatementCache).ClosePreparedStatements()
+	_, err = prepared.GetByID(ctx, created.ID.String())
+	assert.Error(t, err)
+
+	assert.Empty(t, hookErrs, "repository reads return decryption errors to the caller")
+}
@@ -3,10 +3,12 @@ package repository
 import (
 	"database/sql"
 	stderrors "errors"
+	"fmt"
 	"regexp"
 	"strings"
 	"time"
 
+	"github.com/go-sql-driver/mysql"
 	"github.com/goliatone/go-errors"
 	"github.com/lib/pq"
 	"github.com/mattn/go-sqlite3"
@@ -23,6 +25,10 @@ const (
 	CategoryDatabasePermission    = errors.Category("database_permission")
 	CategoryDatabaseSyntax        = errors.Category("database_syntax")
 	CategoryDatabaseExpectedCount = errors.Category("database_expected-count")
+	// CategoryCriteriaInvalid marks errors caused by query criteria supplied by
+	// the caller (unknown columns, unsupported operators) rather than by the
+	// database.
+	CategoryCriteriaInvalid = errors.Category("criteria_invalid")
 )
 
 // DatabaseErrorMapper maps database specific errors to standardized errors
@@ -36,6 +42,8 @@ func GetDatabaseErrorMappers(driver string) []DatabaseErrorMapper {
 		return []DatabaseErrorMapper{MapSQLiteErrors, MapCommonDatabaseErrors}
 	case "sqlserver", "mssql":
 		return []DatabaseErrorMapper{MapMSSQLErrors, MapCommonDatabaseErrors}
+	case "mysql":
+		return []DatabaseErrorMapper{MapMySQLErrors, MapCommonDatabaseErrors}
 	default:
 		return []DatabaseErrorMapper{MapCommonDatabaseErrors}
 	}
@@ -88,7 +96,8 @@ func isDatabaseCategory(cat errors.Category) bool {
 		CategoryDatabaseLock,
 		CategoryDatabasePermission,
 		CategoryDatabaseSyntax,
-		CategoryDatabaseExpectedCount:
+		CategoryDatabaseExpectedCount,
+		CategoryCriteriaInvalid:
 		return true
 	default:
 		return false
@@ -156,6 +165,18 @@ func MapPostgresErrors(err error) error {
 		return errors.NewNonRetryable("SQL syntax error", CategoryDatabaseSyntax).
 			WithCode(errors.CodeBadRequest).
 			WithTextCode("SYNTAX_ERROR")
+
+	case "42703": // undefined column
+		return NewCriteriaInvalidError("Unknown column in query criteria").
+			WithMetadata(map[string]any{
+				"detail": pqErr.Message,
+			})
+
+	case "42883": // undefined function or operator
+		return NewCriteriaInvalidError("Unsupported operator or function in query criteria").
+			WithMetadata(map[string]any{
+				"detail": pqErr.Message,
+			})
 	}
 
 	return nil
@@ -223,6 +244,14 @@ func MapSQLiteErrors(err error) error {
 			WithCode(errors.CodeConflict).
 			WithTextCode("TABLE_LOCKED")
 
+	case sqlite3.ErrError:
+		if column, ok := strings.CutPrefix(sqliteErr.Error(), "no such column: "); ok {
+			return NewCriteriaInvalidError("Unknown column in query criteria").
+				WithMetadata(map[string]any{
+					"column": column,
+				})
+		}
+
 	case sqlite3.ErrAuth:
 		return errors.NewNonRetryable("Authorization denied", CategoryDatabasePermission).
 			WithCode(errors.CodeForbidden).
@@ -255,6 +284,12 @@ func MapMSSQLErrors(err error) error {
 					WithTextCode("FOREIGN_KEY_VIOLATION")
 			},
 		},
+		{
+			pattern: regexp.MustCompile(`(?i)invalid column name`),
+			createError: func() error {
+				return NewCriteriaInvalidError("Unknown column in query criteria")
+			},
+		},
 		{
 			pattern: regexp.MustCompile(`(?i)deadlock|was deadlocked`),
 			createError: func() error {
@@ -290,6 +325,69 @@ func MapMSSQLErrors(err error) error {
 	return nil
 }
 
+func MapMySQLErrors(err error) error {
+	var mysqlErr *mysql.MySQLError
+	if !errors.As(err, &mysqlErr) {
+		return nil
+	}
+
+	switch mysqlErr.Number {
+	case 1062: // ER_DUP_ENTRY
+		return errors.NewNonRetryable("Duplicate key value violates unique constraint", CategoryDatabaseDuplicate).
+			WithCode(errors.CodeConflict).
+			WithTextCode("DUPLICATE_KEY").
+			WithMetadata(map[string]any{
+				"detail": mysqlErr.Message,
+			})
+
+	case 1451, 1452: // ER_ROW_IS_REFERENCED_2, ER_NO_REFERENCED_ROW_2
+		return errors.NewNonRetryable("Foreign key constraint violation", CategoryDatabaseConstraint).
+			WithCode(errors.CodeBadRequest).
+			WithTextCode("FOREIGN_KEY_VIOLATION").
+			WithMetadata(map[string]any{
+				"detail": mysqlErr.Message,
+			})
+
+	case 1048: // ER_BAD_NULL_ERROR
+		return errors.NewNonRetryable("Not null constraint violation", CategoryDatabaseConstraint).
+			WithCode(errors.CodeBadRequest).
+			WithTextCode("NOT_NULL_VIOLATION")
+
+	case 3819: // ER_CHECK_CONSTRAINT_VIOLATED
+		return errors.NewNonRetryable("Check constraint violation", CategoryDatabaseConstraint).
+			WithCode(errors.CodeBadRequest).
+			WithTextCode("CHECK_CONSTRAINT_VIOLATION")
+
+	case 1213: // ER_LOCK_DEADLOCK, we could retry
+		return errors.NewRetryableOperation("Deadlock detected", 500).
+			WithCode(errors.CodeConflict).
+			WithTextCode("DEADLOCK_DETECTED")
+
+	case 1205: // ER_LOCK_WAIT_TIMEOUT, we could retry
+		return errors.NewRetryableOperation("Lock wait timeout exceeded", 1000).
+			WithCode(errors.CodeConflict).
+			WithTextCode("LOCK_WAIT_TIMEOUT")
+
+	case 1044, 1045, 1142, 1143: // access denied for database, user, table or column
+		return errors.NewNonRetryable("Access denied", CategoryDatabasePermission).
+			WithCode(errors.CodeForbidden).
+			WithTextCode("ACCESS_DENIED")
+
+	case 1064: // ER_PARSE_ERROR
+		return errors.NewNonRetryable("SQL syntax error", CategoryDatabaseSyntax).
+			WithCode(errors.CodeBadRequest).
+			WithTextCode("SYNTAX_ERROR")
+
+	case 1054: // ER_BAD_FIELD_ERROR
+		return NewCriteriaInvalidError("Unknown column in query criteria").
+			WithMetadata(map[string]any{
+				"detail": mysqlErr.Message,
+			})
+	}
+
+	return nil
+}
+
 func IsDuplicatedKey(err error) bool {
 	return errors.IsCategory(err, CategoryDatabaseDuplicate)
 }
@@ -299,6 +397,31 @@ func IsConstraintViolation(err error) bool {
 		errors.IsCategory(err, CategoryDatabaseDuplicate)
 }
 
+// IsCriteriaInvalid reports whether err was caused by invalid query criteria.
+func IsCriteriaInvalid(err error) bool {
+	return errors.IsCategory(err, CategoryCriteriaInvalid)
+}
+
+// errorCategory returns the category of err after database error mapping,
+// or an empty category when err is nil or carries none.
+func errorCategory(err error) errors.Category {
+	if err == nil {
+		return ""
+	}
+
+	var e *errors.Error
+	if errors.As(err, &e) {
+		return e.Category
+	}
+
+	var retryableErr *errors.RetryableError
+	if errors.As(err, &retryableErr) && retryableErr.BaseError != nil {
+		return retryableErr.BaseError.Category
+	}
+
+	return ""
+}
+
 func IsConnectionError(err error) bool {
 	return errors.IsCategory(err, CategoryDatabaseConnection)
 }
@@ -307,6 +430,47 @@ func IsRetryableDatabase(err error) bool {
 	return errors.IsRetryableError(err)
 }
 
+func unsupportedDriverError(operation, driver string) error {
+	return errors.NewValidation(
+		fmt.Sprintf("repository: %s is not supported", operation),
+		errors.FieldError{Field: "driver", Message: fmt.Sprintf("%s is not supported for %s", operation, driver)},
+	)
+}
+
+// NewCriteriaInvalidError builds a non retryable CategoryCriteriaInvalid error
+// that maps to a 400 Bad Request.
+func NewCriteriaInvalidError(message string) *errors.RetryableError {
+	return errors.NewNonRetryable(message, CategoryCriteriaInvalid).
+		WithCode(errors.CodeBadRequest).
+		WithTextCode("CRITERIA_INVALID")
+}
+
+// applyCriteria applies criteria to q under policy, the criteria policy of the
+// calling repository, turning a panicking criteria function into an internal
+// error instead of crashing the caller. A panic is a bug in the criteria, not
+// bad input, so it maps to a 500; criteria reject bad input themselves.
+func applyCriteria[Q any, C ~func(Q) Q](q Q, criteria []C, policy *criteriaPolicy) (err error) {
+	defer bindCriteriaPolicy(q, policy)()
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			err = errors.NewNonRetryable("Query criteria failed to apply", errors.CategoryInternal).
+				WithCode(errors.CodeInternal).
+				WithTextCode("CRITERIA_PANIC").
+				WithMetadata(map[string]any{
+					"panic": fmt.Sprint(recovered),
+				})
+		}
+	}()
+
+	for _, c := range criteria {
+		if c == nil {
+			continue
+		}
+		c(q)
+	}
+	return nil
+}
+
 func NewRecordNotFound() *errors.RetryableError {
 	return newRecordNotFoundErrorWithSource(nil)
 }
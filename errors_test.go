@@ -1,16 +1,21 @@
 package repository
 
 import (
+	"context"
 	"database/sql"
 	stderrors "errors"
 	"fmt"
 	"regexp"
+	"strings"
 	"testing"
 
+	"github.com/go-sql-driver/mysql"
 	"github.com/goliatone/go-errors"
+	"github.com/google/uuid"
 	"github.com/lib/pq"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"github.com/uptrace/bun"
 )
 
 func TestMapDatabaseError_NilError(t *testing.T) {
@@ -181,6 +186,20 @@ func TestMapPostgresErrors(t *testing.T) {
 			expectedCategory: CategoryDatabaseConnection,
 			expectedCode:     502,
 		},
+		{
+			name: "undefined column",
+			pqError: &pq.Error{
+				Code:    "42703",
+				Message: `column "nope" does not exist`,
+			},
+			expectedCode:     errors.CodeBadRequest,
+			expectedText:     "CRITERIA_INVALID",
+			expectedRetry:    false,
+			expectedCategory: CategoryCriteriaInvalid,
+			expectedMeta: map[string]any{
+				"detail": `column "nope" does not exist`,
+			},
+		},
 	}
 
 	for _, tt := range tests {
@@ -215,6 +234,86 @@ func TestMapPostgresErrors(t *testing.T) {
 	}
 }
 
+func TestMapMySQLErrors(t *testing.T) {
+	tests := []struct {
+		name             string
+		mysqlError       *mysql.MySQLError
+		expectedCode     int
+		expectedText     string
+		expectedRetry    bool
+		expectedCategory errors.Category
+	}{
+		{
+			name:             "duplicate entry",
+			mysqlError:       &mysql.MySQLError{Number: 1062, Message: "Duplicate entry 'a@example.com' for key 'users.email'"},
+			expectedCode:     errors.CodeConflict,
+			expectedText:     "DUPLICATE_KEY",
+			expectedCategory: CategoryDatabaseDuplicate,
+		},
+		{
+			name:             "parent row referenced",
+			mysqlError:       &mysql.MySQLError{Number: 1451},
+			expectedCode:     errors.CodeBadRequest,
+			expectedText:     "FOREIGN_KEY_VIOLATION",
+			expectedCategory: CategoryDatabaseConstraint,
+		},
+		{
+			name:             "missing referenced row",
+			mysqlError:       &mysql.MySQLError{Number: 1452},
+			expectedCode:     errors.CodeBadRequest,
+			expectedText:     "FOREIGN_KEY_VIOLATION",
+			expectedCategory: CategoryDatabaseConstraint,
+		},
+		{
+			name:          "deadlock",
+			mysqlError:    &mysql.MySQLError{Number: 1213},
+			expectedCode:  errors.CodeConflict,
+			expectedText:  "DEADLOCK_DETECTED",
+			expectedRetry: true,
+		},
+		{
+			name:          "lock wait timeout",
+			mysqlError:    &mysql.MySQLError{Number: 1205},
+			expectedCode:  errors.CodeConflict,
+			expectedText:  "LOCK_WAIT_TIMEOUT",
+			expectedRetry: true,
+		},
+		{
+			name:             "access denied",
+			mysqlError:       &mysql.MySQLError{Number: 1045},
+			expectedCode:     errors.CodeForbidden,
+			expectedText:     "ACCESS_DENIED",
+			expectedCategory: CategoryDatabasePermission,
+		},
+		{
+			name:             "unknown column",
+			mysqlError:       &mysql.MySQLError{Number: 1054, Message: "Unknown column 'nope' in 'where clause'"},
+			expectedCode:     errors.CodeBadRequest,
+			expectedText:     "CRITERIA_INVALID",
+			expectedCategory: CategoryCriteriaInvalid,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := MapDatabaseError(fmt.Errorf("exec: %w", tt.mysqlError), "mysql")
+			require.Error(t, result)
+
+			var retryableErr *errors.RetryableError
+			require.True(t, errors.As(result, &retryableErr))
+			assert.Equal(t, tt.expectedRetry, retryableErr.IsRetryable())
+			assert.Equal(t, tt.expectedText, retryableErr.TextCode)
+			assert.Equal(t, tt.expectedCode, retryableErr.Code)
+
+			if tt.expectedCategory != "" {
+				assert.Truef(t, errors.IsCategory(result, tt.expectedCategory), "expected category %s", tt.expectedCategory)
+			}
+		})
+	}
+
+	assert.NoError(t, MapMySQLErrors(stderrors.New("not a mysql error")))
+}
+
 func TestMapMSSQLErrors_Working(t *testing.T) {
 	tests := []struct {
 		name          string
@@ -356,3 +455,79 @@ func TestIsRecordNotFound_Simple(t *testing.T) {
 		})
 	}
 }
+
+func TestRepository_CriteriaInvalidErrors(t *testing.T) {
+	setupTestData(t)
+
+	ctx := context.Background()
+	userRepo := newTestUserRepository(db)
+
+	_, _, err := userRepo.List(ctx, SelectRawProcessor(func(q *bun.SelectQuery) *bun.SelectQuery {
+		return q.Where("?TableAlias.nope = ?", 1)
+	}))
+	require.Error(t, err)
+	assert.True(t, IsCriteriaInvalid(err))
+
+	var retryableErr *errors.RetryableError
+	require.True(t, errors.As(err, &retryableErr))
+	assert.Equal(t, errors.CodeBadRequest, retryableErr.Code)
+	assert.Equal(t, "CRITERIA_INVALID", retryableErr.TextCode)
+
+	assert.NotPanics(t, func() {
+		_, err = userRepo.Get(ctx, func(q *bun.SelectQuery) *bun.SelectQuery {
+			panic("boom")
+		})
+	})
+	require.Error(t, err)
+	assert.False(t, IsCriteriaInvalid(err), "a panicking criteria is a bug, not bad input")
+	require.True(t, errors.As(err, &retryableErr))
+	assert.Equal(t, errors.CategoryInternal, retryableErr.Category)
+	assert.Equal(t, errors.CodeInternal, retryableErr.Code)
+	assert.Equal(t, "CRITERIA_PANIC", retryableErr.TextCode)
+
+	_, _, err = userRepo.List(ctx, SelectBy("nope", "=", "x"))
+	require.Error(t, err)
+	assert.True(t, IsCriteriaInvalid(err))
+	_, _, err = userRepo.List(ctx, OrderBySafe("name", "nope DESC"))
+	require.Error(t, err)
+	assert.True(t, IsCriteriaInvalid(err))
+	_, err = userRepo.(BulkUpdater).UpdateWhere(ctx, UpdateSetColumn("nope", "x"), UpdateBy("id", "=", uuid.New().String()))
+	require.Error(t, err)
+	assert.True(t, IsCriteriaInvalid(err))
+	_, err = userRepo.(CountingDeleter).DeleteWhereCount(ctx, DeleteBy("nope", "=", "x"))
+	require.Error(t, err)
+	assert.True(t, IsCriteriaInvalid(err))
+	assert.False(t, IsCriteriaInvalid(MapDatabaseError(sql.ErrNoRows, "sqlite3")))
+}
+
+func TestWithErrorMapper(t *testing.T) {
+	setupTestData(t)
+	ctx := context.Background()
+
+	errEmailTaken := stderrors.New("email taken")
+	var calls int
+	repo := newTestUserRepositoryWithConfig(db, nil,
+		WithErrorMapper(
+			func(err error) error {
+				calls++
+				return nil
+			},
+			func(err error) error {
+				if strings.Contains(err.Error(), "test_users.email") {
+					return errEmailTaken
+				}
+				return nil
+			},
+		),
+	)
+
+	_, err := repo.Create(ctx, &TestUser{Name: "Alice", Email: "alice@example.com"})
+	require.NoError(t, err)
+	_, err = repo.Create(ctx, &TestUser{Name: "Alice", Email: "alice@example.com"})
+	require.ErrorIs(t, err, errEmailTaken)
+	assert.Equal(t, 1, calls)
+
+	_, err = repo.Get(ctx, SelectBy("name", "=", "missing"))
+	assert.True(t, IsRecordNotFound(err), "unmatched errors fall through to the driver mappers")
+	assert.Equal(t, 2, calls)
+}
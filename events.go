@@ -0,0 +1,256 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"sort"
+	"time"
+
+	"github.com/goliatone/go-errors"
+	"github.com/goliatone/go-repository-bun/repositoryctx"
+	"github.com/uptrace/bun"
+)
+
+// EventOperation is the kind of write an Event describes.
+type EventOperation string
+
+const (
+	EventCreate      EventOperation = "create"
+	EventUpdate      EventOperation = "update"
+	EventDelete      EventOperation = "delete"
+	EventForceDelete EventOperation = "force_delete"
+)
+
+// Event describes a record written by a repository. Upserts publish the
+// create or update they resolved to.
+type Event struct {
+	// Entity is the table name of the record.
+	Entity    string
+	Operation EventOperation
+	ID        string
+	// Columns lists the written columns: every model column for creates,
+	// the updated columns for updates and none for deletes.
+	Columns []string
+	// Record is the record as written, the deleted record for deletes.
+	Record     any
+	Actor      string
+	OccurredAt time.Time
+}
+
+// EventPublisher receives the events of repository writes.
+type EventPublisher interface {
+	Publish(ctx context.Context, event Event) error
+}
+
+// EventPublisherFunc adapts a function to EventPublisher.
+type EventPublisherFunc func(ctx context.Context, event Event) error
+
+func (f EventPublisherFunc) Publish(ctx context.Context, event Event) error {
+	return f(ctx, event)
+}
+
+// TxEventPublisher is implemented by publishers that record events inside
+// the transaction of the write, e.g. into an outbox table, so an event is
+// stored if and only if the write commits. PublishTx errors fail the write.
+// Writes made outside a transaction run in one when a TxEventPublisher is
+// configured.
+type TxEventPublisher interface {
+	EventPublisher
+	PublishTx(ctx context.Context, tx bun.IDB, event Event) error
+}
+
+// EventErrorHandler receives the errors of publishers that run after the
+// write committed, when failing the write is no longer possible.
+type EventErrorHandler func(ctx context.Context, event Event, err error)
+
+// WithEventPublisher publishes an Event for every record created, updated,
+// upserted or deleted by the repository (DeleteWhere, UpdateWhere and
+// BulkLoad publish nothing). Can be repeated to add publishers.
+//
+// Publishers run after the write commits: right away for writes outside a
+// transaction, and after the commit for writes inside transactions started
+// with RunInTx or by the repository itself. Writes in other transactions,
+// e.g. bun's db.RunInTx, fail with ErrEventCommitUnobservable before touching
+// the database, since their commit cannot be observed and publishing early
+// would announce rows a rollback discards. TxEventPublisher implementations
+// instead run inside the transaction and work with any transaction.
+func WithEventPublisher(publisher EventPublisher) RepoOption {
+	return func(cfg *repoConfig) {
+		if cfg == nil || publisher == nil {
+			return
+		}
+		cfg.eventPublishers = append(cfg.eventPublishers, publisher)
+	}
+}
+
+// WithEventErrorHandler reports the errors of publishers running after the
+// commit. Without it those errors are dropped.
+func WithEventErrorHandler(handler EventErrorHandler) RepoOption {
+	return func(cfg *repoConfig) {
+		if cfg == nil {
+			return
+		}
+		cfg.eventErrorHandler = handler
+	}
+}
+
+// RunInTx runs fn in a transaction of tm and runs the commit hooks
+// registered during it, such as deferred event publishing, once it commits.
+// Nested calls join the hooks of the outermost call.
+func RunInTx(ctx context.Context, tm TransactionManager, opts *sql.TxOptions, fn func(ctx context.Context, tx bun.Tx) error) error {
+	ctx, hooks, owned := repositoryctx.WithCommitHooks(ctx)
+	if err := tm.RunInTx(ctx, opts, fn); err != nil {
+		return err
+	}
+	if owned {
+		hooks.Run(ctx)
+	}
+	return nil
+}
+
+// checkEventCommit fails writes in tx that would publish events after a
+// commit the repository cannot observe.
+func (r *repo[T]) checkEventCommit(ctx context.Context, tx bun.IDB) error {
+	if !isTransaction(tx) || repositoryctx.CollectsCommitHooks(ctx) {
+		return nil
+	}
+	for _, publisher := range r.eventPublishers {
+		if _, ok := publisher.(TxEventPublisher); !ok {
+			return eventCommitUnobservableError()
+		}
+	}
+	return nil
+}
+
+func eventCommitUnobservableError() error {
+	err := errors.New(
+		"repository: writes publishing events must run in repository.RunInTx, or use a TxEventPublisher",
+		errors.CategoryOperation,
+	)
+	err.Source = ErrEventCommitUnobservable
+	return err
+}
+
+// transactionalEvents reports whether a publisher must run inside the
+// transaction of the write.
+func (r *repo[T]) transactionalEvents() bool {
+	for _, publisher := range r.eventPublishers {
+		if _, ok := publisher.(TxEventPublisher); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// eventTx runs fn in a transaction when a TxEventPublisher must record the
+// events of the write atomically with it, WithHistory its previous rows or
+// WithIntegrityColumn the hashes of the rows it changes.
+func (r *repo[T]) eventTx(ctx context.Context, tx bun.IDB, fn func(ctx context.Context, tx bun.IDB) error) error {
+	if err := r.checkEventCommit(ctx, tx); err != nil {
+		return err
+	}
+	if !r.transactionalEvents() && r.historySuffix == "" && r.integrity == nil {
+		return fn(ctx, tx)
+	}
+	return runInTx(ctx, tx, fn)
+}
+
+// publishEvents publishes an event per record to the configured publishers.
+func (r *repo[T]) publishEvents(ctx context.Context, tx bun.IDB, op EventOperation, columns []string, records ...T) error {
+	if len(r.eventPublishers) == 0 || len(records) == 0 {
+		return nil
+	}
+
+	entity := r.TableName()
+	actor, _ := repositoryctx.Actor(ctx)
+	now := time.Now().UTC()
+	events := make([]Event, len(records))
+	for i, record := range records {
+		events[i] = Event{
+			Entity:     entity,
+			Operation:  op,
+			ID:         r.handlers.GetID(record).String(),
+			Columns:    columns,
+			Record:     record,
+			Actor:      actor,
+			OccurredAt: now,
+		}
+	}
+
+	var deferred []EventPublisher
+	for _, publisher := range r.eventPublishers {
+		txPublisher, ok := publisher.(TxEventPublisher)
+		if !ok {
+			deferred = append(deferred, publisher)
+			continue
+		}
+		for _, event := range events {
+			if err := txPublisher.PublishTx(ctx, tx, event); err != nil {
+				return err
+			}
+		}
+	}
+	if len(deferred) == 0 {
+		return nil
+	}
+
+	publish := func(ctx context.Context) {
+		for _, publisher := range deferred {
+			for _, event := range events {
+				if err := publisher.Publish(ctx, event); err != nil && r.eventErrorHandler != nil {
+					r.eventErrorHandler(ctx, event, err)
+				}
+			}
+		}
+	}
+	if !isTransaction(tx) {
+		publish(ctx)
+		return nil
+	}
+	if !repositoryctx.OnCommit(ctx, publish) {
+		return eventCommitUnobservableError()
+	}
+	return nil
+}
+
+// createdColumns lists the model columns written by creates.
+func (r *repo[T]) createdColumns() []string {
+	if len(r.eventPublishers) == 0 {
+		return nil
+	}
+	table := r.modelTable()
+	if table == nil {
+		return nil
+	}
+	columns := make([]string, 0, len(table.Fields))
+	for _, field := range table.Fields {
+		columns = append(columns, field.Name)
+	}
+	return columns
+}
+
+// updatedColumns lists the columns the update q writes, once its criteria
+// are applied.
+func (r *repo[T]) updatedColumns(q *bun.UpdateQuery) []string {
+	if len(r.eventPublishers) == 0 {
+		return nil
+	}
+	selected := queryColumnNames(q)
+	if selected == nil {
+		table := r.modelTable()
+		if table == nil {
+			return nil
+		}
+		columns := make([]string, 0, len(table.DataFields))
+		for _, field := range table.DataFields {
+			columns = append(columns, field.Name)
+		}
+		return columns
+	}
+	columns := make([]string, 0, len(selected))
+	for column := range selected {
+		columns = append(columns, column)
+	}
+	sort.Strings(columns)
+	return columns
+}
@@ -0,0 +1,212 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/goliatone/go-repository-bun/repositoryctx"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/uptrace/bun"
+)
+
+type recordingPublisher struct {
+	mu     sync.Mutex
+	events []Event
+	err    error
+}
+
+func (p *recordingPublisher) Publish(_ context.Context, event Event) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.events = append(p.events, event)
+	return p.err
+}
+
+func (p *recordingPublisher) recorded() []Event {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return append([]Event(nil), p.events...)
+}
+
+type recordingTxPublisher struct {
+	recordingPublisher
+	txErr error
+	inTx  []bool
+}
+
+func (p *recordingTxPublisher) PublishTx(ctx context.Context, tx bun.IDB, event Event) error {
+	p.inTx = append(p.inTx, isTransaction(tx))
+	if p.txErr != nil {
+		return p.txErr
+	}
+	return p.Publish(ctx, event)
+}
+
+func TestEventPublisher_Writes(t *testing.T) {
+	setupTestData(t)
+	ctx := repositoryctx.WithActor(context.Background(), "admin")
+	publisher := &recordingPublisher{}
+	repo := newTestUserRepositoryWithConfig(db, nil, WithEventPublisher(publisher))
+
+	user, err := repo.Create(ctx, &TestUser{ID: uuid.New(), Name: "Alice", Email: "alice@example.com"})
+	require.NoError(t, err)
+
+	user.Name = "Alicia"
+	_, err = repo.Update(ctx, user, UpdateColumns("name"))
+	require.NoError(t, err)
+
+	require.NoError(t, repo.Delete(ctx, user))
+
+	events := publisher.recorded()
+	require.Len(t, events, 3)
+
+	assert.Equal(t, EventCreate, events[0].Operation)
+	assert.Equal(t, "test_users", events[0].Entity)
+	assert.Equal(t, user.ID.String(), events[0].ID)
+	assert.Equal(t, "admin", events[0].Actor)
+	assert.Contains(t, events[0].Columns, "email")
+	assert.False(t, events[0].OccurredAt.IsZero())
+
+	assert.Equal(t, EventUpdate, events[1].Operation)
+	assert.Equal(t, []string{"name"}, events[1].Columns)
+	assert.Equal(t, "Alicia", events[1].Record.(*TestUser).Name)
+
+	assert.Equal(t, EventDelete, events[2].Operation)
+	assert.Empty(t, events[2].Columns)
+}
+
+func TestEventPublisher_UpdateColumnsFromExecutedQuery(t *testing.T) {
+	setupTestData(t)
+	ctx := context.Background()
+	publisher := &recordingPublisher{}
+	repo := newTestUserRepositoryWithConfig(db, nil, WithEventPublisher(publisher))
+
+	user, err := repo.Create(ctx, &TestUser{ID: uuid.New(), Name: "Alice", Email: "alice@example.com"})
+	require.NoError(t, err)
+
+	// criteria are applied once, and the columns come from that application
+	calls := 0
+	columns := func(q *bun.UpdateQuery) *bun.UpdateQuery {
+		calls++
+		if calls == 1 {
+			return q.Column("name")
+		}
+		return q.Column("email")
+	}
+	_, err = repo.Update(ctx, user, columns)
+	require.NoError(t, err)
+	_, err = repo.UpdateMany(ctx, []*TestUser{user}, UpdateColumns("email"))
+	require.NoError(t, err)
+
+	events := publisher.recorded()
+	require.Len(t, events, 3)
+	assert.Equal(t, 1, calls)
+	assert.Equal(t, []string{"name"}, events[1].Columns)
+	assert.Equal(t, []string{"email"}, events[2].Columns)
+}
+
+func TestEventPublisher_Upsert(t *testing.T) {
+	setupTestData(t)
+	ctx := context.Background()
+	publisher := &recordingPublisher{}
+	repo := newTestUserRepositoryWithConfig(db, nil, WithEventPublisher(publisher))
+
+	_, err := repo.Upsert(ctx, &TestUser{ID: uuid.New(), Name: "Bob", Email: "bob@example.com"})
+	require.NoError(t, err)
+	_, err = repo.Upsert(ctx, &TestUser{Name: "Bobby", Email: "bob@example.com"})
+	require.NoError(t, err)
+
+	events := publisher.recorded()
+	require.Len(t, events, 2)
+	assert.Equal(t, EventCreate, events[0].Operation)
+	assert.Equal(t, EventUpdate, events[1].Operation)
+	assert.Equal(t, events[0].ID, events[1].ID)
+}
+
+func TestEventPublisher_DeferredUntilCommit(t *testing.T) {
+	setupTestData(t)
+	ctx := context.Background()
+	publisher := &recordingPublisher{}
+	repo := newTestUserRepositoryWithConfig(db, nil, WithEventPublisher(publisher))
+
+	err := RunInTx(ctx, db, nil, func(ctx context.Context, tx bun.Tx) error {
+		_, err := repo.CreateManyTx(ctx, tx, []*TestUser{
+			{ID: uuid.New(), Name: "Carol", Email: "carol@example.com"},
+			{ID: uuid.New(), Name: "Dave", Email: "dave@example.com"},
+		})
+		require.NoError(t, err)
+		assert.Empty(t, publisher.recorded(), "events wait for the commit")
+		return nil
+	})
+	require.NoError(t, err)
+	assert.Len(t, publisher.recorded(), 2)
+
+	rollback := errors.New("rollback")
+	err = RunInTx(ctx, db, nil, func(ctx context.Context, tx bun.Tx) error {
+		_, err := repo.CreateTx(ctx, tx, &TestUser{ID: uuid.New(), Name: "Eve", Email: "eve@example.com"})
+		require.NoError(t, err)
+		return rollback
+	})
+	assert.ErrorIs(t, err, rollback)
+	assert.Len(t, publisher.recorded(), 2, "rolled back writes publish nothing")
+}
+
+func TestEventPublisher_UnobservableCommit(t *testing.T) {
+	setupTestData(t)
+	ctx := context.Background()
+	publisher := &recordingPublisher{}
+	repo := newTestUserRepositoryWithConfig(db, nil, WithEventPublisher(publisher))
+
+	err := db.RunInTx(ctx, nil, func(ctx context.Context, tx bun.Tx) error {
+		_, err := repo.CreateTx(ctx, tx, &TestUser{ID: uuid.New(), Name: "Frank", Email: "frank@example.com"})
+		return err
+	})
+	require.ErrorIs(t, err, ErrEventCommitUnobservable)
+	assert.Empty(t, publisher.recorded(), "nothing is published before a commit")
+
+	count, err := repo.Count(ctx)
+	require.NoError(t, err)
+	assert.Zero(t, count, "the write fails before touching the database")
+}
+
+func TestEventPublisher_Transactional(t *testing.T) {
+	setupTestData(t)
+	ctx := context.Background()
+	publisher := &recordingTxPublisher{}
+	repo := newTestUserRepositoryWithConfig(db, nil, WithEventPublisher(publisher))
+
+	_, err := repo.Create(ctx, &TestUser{ID: uuid.New(), Name: "Frank", Email: "frank@example.com"})
+	require.NoError(t, err)
+	assert.Equal(t, []bool{true}, publisher.inTx, "writes outside a transaction run in one")
+
+	publisher.txErr = errors.New("outbox unavailable")
+	_, err = repo.Create(ctx, &TestUser{ID: uuid.New(), Name: "Grace", Email: "grace@example.com"})
+	assert.ErrorIs(t, err, publisher.txErr)
+
+	count, err := repo.Count(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, count, "a failed publish rolls the write back")
+}
+
+func TestEventPublisher_ErrorHandler(t *testing.T) {
+	setupTestData(t)
+	ctx := context.Background()
+	publisher := &recordingPublisher{err: errors.New("broker down")}
+
+	var handled []error
+	repo := newTestUserRepositoryWithConfig(db, nil,
+		WithEventPublisher(publisher),
+		WithEventErrorHandler(func(_ context.Context, event Event, err error) {
+			assert.Equal(t, EventCreate, event.Operation)
+			handled = append(handled, err)
+		}),
+	)
+
+	_, err := repo.Create(ctx, &TestUser{ID: uuid.New(), Name: "Heidi", Email: "heidi@example.com"})
+	require.NoError(t, err, "publish errors after the write do not fail it")
+	assert.Equal(t, []error{publisher.err}, handled)
+}
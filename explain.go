@@ -0,0 +1,113 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+
+	"github.com/uptrace/bun"
+)
+
+// QueryExplainer is an optional capability for repositories that can return
+// the database plan of the query List would run, for debugging slow endpoints.
+type QueryExplainer interface {
+	Explain(ctx context.Context, criteria ...SelectCriteria) (string, error)
+	ExplainTx(ctx context.Context, tx bun.IDB, criteria ...SelectCriteria) (string, error)
+	// ExplainAnalyze executes the query to report actual timings (Postgres only).
+	ExplainAnalyze(ctx context.Context, criteria ...SelectCriteria) (string, error)
+	ExplainAnalyzeTx(ctx context.Context, tx bun.IDB, criteria ...SelectCriteria) (string, error)
+}
+
+// Explain returns the plan for the query List would run with criteria.
+// Plan rows are returned one per line with columns separated by tabs.
+func (r *repo[T]) Explain(ctx context.Context, criteria ...SelectCriteria) (string, error) {
+	return r.ExplainTx(ctx, r.db, criteria...)
+}
+
+func (r *repo[T]) ExplainTx(ctx context.Context, tx bun.IDB, criteria ...SelectCriteria) (string, error) {
+	ctx = r.withOperation(ctx, "Explain", len(criteria))
+	var prefix string
+	switch r.driver {
+	case "postgres", "mysql":
+		prefix = "EXPLAIN "
+	case "sqlite":
+		prefix = "EXPLAIN QUERY PLAN "
+	default:
+		return "", unsupportedDriverError("Explain", r.driver)
+	}
+	return r.explain(ctx, tx, prefix, criteria)
+}
+
+// ExplainAnalyze returns the Postgres EXPLAIN ANALYZE output for the query List
+// would run with criteria. The query is executed, so use it with care on
+// expensive queries.
+func (r *repo[T]) ExplainAnalyze(ctx context.Context, criteria ...SelectCriteria) (string, error) {
+	return r.ExplainAnalyzeTx(ctx, r.db, criteria...)
+}
+
+func (r *repo[T]) ExplainAnalyzeTx(ctx context.Context, tx bun.IDB, criteria ...SelectCriteria) (string, error) {
+	ctx = r.withOperation(ctx, "ExplainAnalyze", len(criteria))
+	if r.driver != "postgres" {
+		return "", unsupportedDriverError("ExplainAnalyze", r.driver)
+	}
+	return r.explain(ctx, tx, "EXPLAIN ANALYZE ", criteria)
+}
+
+func (r *repo[T]) explain(ctx context.Context, tx bun.IDB, prefix string, criteria []SelectCriteria) (string, error) {
+	records := []T{}
+	q, err := r.listQuery(ctx, tx, &records, criteria)
+	if err != nil {
+		return "", err
+	}
+
+	query, err := q.AppendQuery(r.db.Formatter(), nil)
+	if err != nil {
+		return "", NewCriteriaInvalidError("Query criteria failed to render").WithMetadata(map[string]any{
+			"error": err.Error(),
+		})
+	}
+
+	rows, err := tx.QueryContext(ctx, prefix+string(query))
+	if err != nil {
+		return "", r.mapError(err)
+	}
+	defer rows.Close()
+
+	plan, err := formatPlanRows(rows)
+	if err != nil {
+		return "", r.mapError(err)
+	}
+	return plan, nil
+}
+
+func formatPlanRows(rows *sql.Rows) (string, error) {
+	columns, err := rows.Columns()
+	if err != nil {
+		return "", err
+	}
+
+	values := make([]sql.NullString, len(columns))
+	dest := make([]any, len(columns))
+	for i := range values {
+		dest[i] = &values[i]
+	}
+
+	var lines []string
+	for rows.Next() {
+		if err := rows.Scan(dest...); err != nil {
+			return "", err
+		}
+		fields := make([]string, len(values))
+		for i, value := range values {
+			fields[i] = "NULL"
+			if value.Valid {
+				fields[i] = value.String
+			}
+		}
+		lines = append(lines, strings.Join(fields, "\t"))
+	}
+	if err := rows.Err(); err != nil {
+		return "", err
+	}
+	return strings.Join(lines, "\n"), nil
+}
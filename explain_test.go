@@ -0,0 +1,34 @@
+package repository
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRepository_Explain(t *testing.T) {
+	setupTestData(t)
+	ctx := context.Background()
+
+	explainer, ok := newTestUserRepository(db).(QueryExplainer)
+	require.True(t, ok)
+
+	plan, err := explainer.Explain(ctx, SelectBy("email", "=", "alice@example.com"))
+	require.NoError(t, err)
+	assert.Contains(t, plan, "test_users")
+
+	_, err = explainer.Explain(ctx, SelectBy("missing_column", "=", "x"))
+	require.Error(t, err)
+	assert.True(t, IsCriteriaInvalid(err))
+}
+
+func TestRepository_ExplainAnalyze_PostgresOnly(t *testing.T) {
+	explainer, ok := newTestUserRepository(db).(QueryExplainer)
+	require.True(t, ok)
+
+	_, err := explainer.ExplainAnalyze(context.Background())
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "ExplainAnalyze is not supported")
+}
@@ -0,0 +1,258 @@
+package repository
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+
+	"github.com/goliatone/go-errors"
+	"github.com/uptrace/bun"
+)
+
+// ExportEncoding is the file encoding written by Export.
+type ExportEncoding string
+
+const (
+	// ExportEncodingCSV writes CSV with a header row.
+	ExportEncodingCSV ExportEncoding = "csv"
+	// ExportEncodingNDJSON writes one JSON object per line.
+	ExportEncodingNDJSON ExportEncoding = "ndjson"
+)
+
+const defaultExportChunkSize = 1000
+
+// ExportFormat describes the file written by Export.
+type ExportFormat struct {
+	Encoding ExportEncoding
+	// KeyMode names the CSV headers and JSON keys. Defaults to MapKeyBun.
+	KeyMode MapKeyMode
+	// Columns selects and orders the exported keys, named in KeyMode.
+	// Defaults to every projected field in declaration order.
+	Columns []string
+	// ChunkSize is the number of records fetched per query. Defaults to 1000.
+	ChunkSize int
+	// Comma is the CSV field delimiter. Defaults to ','.
+	Comma rune
+}
+
+// RecordExporter is an optional capability for repositories that can export
+// query results as CSV or NDJSON files.
+type RecordExporter interface {
+	Export(ctx context.Context, w io.Writer, format ExportFormat, criteria ...SelectCriteria) error
+	ExportTx(ctx context.Context, tx bun.IDB, w io.Writer, format ExportFormat, criteria ...SelectCriteria) error
+}
+
+// Export streams the records matched by criteria to w, projected like
+// RecordToMap with JSON compatible values:
+//
+//	err := repo.(RecordExporter).Export(ctx, w, ExportFormat{
+//		Encoding: ExportEncodingCSV,
+//		KeyMode:  MapKeyJSON,
+//		Columns:  []string{"id", "email"},
+//	}, SelectBy("status", "=", "active"))
+//
+// Records are fetched in chunks of ChunkSize, ordered by the criteria order,
+// the default order or the primary key, so criteria must not paginate.
+// Nested values are written as JSON in CSV cells. Relations are not loaded.
+func (r *repo[T]) Export(ctx context.Context, w io.Writer, format ExportFormat, criteria ...SelectCriteria) error {
+	return r.ExportTx(ctx, r.db, w, format, criteria...)
+}
+
+func (r *repo[T]) ExportTx(ctx context.Context, tx bun.IDB, w io.Writer, format ExportFormat, criteria ...SelectCriteria) error {
+	ctx = r.withOperation(ctx, "Export", len(criteria))
+	if format.Encoding != ExportEncodingCSV && format.Encoding != ExportEncodingNDJSON {
+		return fmt.Errorf("repository: unsupported export encoding %q", format.Encoding)
+	}
+	keys, fields, err := r.exportFields(format)
+	if err != nil {
+		return err
+	}
+	chunkSize := format.ChunkSize
+	if chunkSize <= 0 {
+		chunkSize = defaultExportChunkSize
+	}
+
+	writer := newExportWriter(w, format, keys)
+	if err := writer.header(); err != nil {
+		return err
+	}
+
+	values := make([]any, len(fields))
+	for offset := 0; ; offset += chunkSize {
+		records, err := r.exportChunk(ctx, tx, criteria, chunkSize, offset)
+		if err != nil {
+			return err
+		}
+		for _, record := range records {
+			structValue, err := readStructValue(record)
+			if err != nil {
+				return err
+			}
+			for i, field := range fields {
+				values[i] = nil
+				fv, ok := fieldByIndexForRead(structValue, field.index)
+				if !ok {
+					continue
+				}
+				value, _ := projectedFieldValue(fv, true)
+				if values[i], err = JSONCompatibleValue(keys[i], value); err != nil {
+					return err
+				}
+			}
+			if err := writer.write(values); err != nil {
+				return err
+			}
+		}
+		if err := writer.flush(); err != nil {
+			return err
+		}
+		if len(records) < chunkSize {
+			return nil
+		}
+	}
+}
+
+// exportFields resolves the exported keys and their fields.
+func (r *repo[T]) exportFields(format ExportFormat) ([]string, []mapFieldBinding, error) {
+	typ := reflect.TypeFor[T]()
+	for typ.Kind() == reflect.Pointer {
+		typ = typ.Elem()
+	}
+	desc, err := resolveMapModelDescriptor(r.db, typ)
+	if err != nil {
+		return nil, nil, err
+	}
+	mode := format.KeyMode
+	if mode == "" {
+		mode = MapKeyBun
+	}
+
+	if len(format.Columns) == 0 {
+		var keys []string
+		var fields []mapFieldBinding
+		for _, field := range desc.fields {
+			if key := field.key(mode); key != "" {
+				keys = append(keys, key)
+				fields = append(fields, field)
+			}
+		}
+		return keys, fields, nil
+	}
+
+	lookup, err := descriptorLookupByMode(desc, mode)
+	if err != nil {
+		return nil, nil, err
+	}
+	fields := make([]mapFieldBinding, len(format.Columns))
+	for i, column := range format.Columns {
+		field, ok := lookup[column]
+		if !ok {
+			return nil, nil, errors.NewValidation(
+				"repository: unknown export column",
+				errors.FieldError{Field: "columns", Message: fmt.Sprintf("column %q does not exist on %s", column, r.TableName())},
+			)
+		}
+		fields[i] = field
+	}
+	return format.Columns, fields, nil
+}
+
+func (r *repo[T]) exportChunk(ctx context.Context, tx bun.IDB, criteria []SelectCriteria, limit, offset int) ([]T, error) {
+	records := []T{}
+	q := tx.NewSelect().Model(&records)
+	q = r.applySelectScopes(ctx, q)
+	if err := r.applySelectCriteria(q, criteria); err != nil {
+		return nil, err
+	}
+	if !selectHasOrder(q) {
+		for _, expr := range r.defaultOrder {
+			q.OrderExpr(expr)
+		}
+	}
+	if !selectHasOrder(q) {
+		if table := r.modelTable(); table != nil {
+			for _, pk := range table.PKs {
+				q.OrderExpr("?TableAlias.? ASC", bun.Ident(pk.Name))
+			}
+		}
+	}
+	if err := r.scanRecords(ctx, q.Limit(limit).Offset(offset).Scan); err != nil {
+		return nil, err
+	}
+	return records, nil
+}
+
+// exportWriter encodes exported rows.
+type exportWriter struct {
+	keys  []string
+	csv   *csv.Writer
+	json  *json.Encoder
+	cells []string
+}
+
+func newExportWriter(w io.Writer, format ExportFormat, keys []string) *exportWriter {
+	writer := &exportWriter{keys: keys}
+	if format.Encoding == ExportEncodingCSV {
+		writer.csv = csv.NewWriter(w)
+		if format.Comma != 0 {
+			writer.csv.Comma = format.Comma
+		}
+		writer.cells = make([]string, len(keys))
+	} else {
+		writer.json = json.NewEncoder(w)
+	}
+	return writer
+}
+
+func (w *exportWriter) header() error {
+	if w.csv == nil {
+		return nil
+	}
+	return w.csv.Write(w.keys)
+}
+
+func (w *exportWriter) write(values []any) error {
+	if w.csv == nil {
+		row := make(map[string]any, len(values))
+		for i, value := range values {
+			row[w.keys[i]] = value
+		}
+		return w.json.Encode(row)
+	}
+	for i, value := range values {
+		cell, err := exportCSVCell(value)
+		if err != nil {
+			return fmt.Errorf("repository: csv column %q: %w", w.keys[i], err)
+		}
+		w.cells[i] = cell
+	}
+	return w.csv.Write(w.cells)
+}
+
+func (w *exportWriter) flush() error {
+	if w.csv == nil {
+		return nil
+	}
+	w.csv.Flush()
+	return w.csv.Error()
+}
+
+// exportCSVCell renders a JSON compatible value as a CSV cell.
+func exportCSVCell(value any) (string, error) {
+	switch v := value.(type) {
+	case nil:
+		return "", nil
+	case string:
+		return v, nil
+	case []byte:
+		return string(v), nil
+	case map[string]any, []any:
+		encoded, err := json.Marshal(v)
+		return string(encoded), err
+	default:
+		return fmt.Sprint(v), nil
+	}
+}
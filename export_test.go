@@ -0,0 +1,87 @@
+package repository
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+
+	goerrors "github.com/goliatone/go-errors"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func seedExportUsers(t *testing.T, repo Repository[*TestUser], n int) {
+	t.Helper()
+	for i := range n {
+		_, err := repo.Create(context.Background(), &TestUser{
+			Name:  fmt.Sprintf("user-%d", i),
+			Email: fmt.Sprintf("user-%d@example.com", i),
+		})
+		require.NoError(t, err)
+	}
+}
+
+func TestRepository_Export_CSV(t *testing.T) {
+	setupTestData(t)
+	ctx := context.Background()
+	repo := newTestUserRepository(db)
+	seedExportUsers(t, repo, 5)
+
+	exporter, ok := repo.(RecordExporter)
+	require.True(t, ok)
+
+	var out bytes.Buffer
+	err := exporter.Export(ctx, &out, ExportFormat{
+		Encoding:  ExportEncodingCSV,
+		Columns:   []string{"name", "email"},
+		ChunkSize: 2,
+		Comma:     ';',
+	}, OrderBy("name DESC"), SelectBy("name", "!=", "user-0"))
+	require.NoError(t, err)
+
+	assert.Equal(t, strings.Join([]string{
+		"name;email",
+		"user-4;user-4@example.com",
+		"user-3;user-3@example.com",
+		"user-2;user-2@example.com",
+		"user-1;user-1@example.com",
+		"",
+	}, "\n"), out.String())
+
+	err = exporter.Export(ctx, &out, ExportFormat{Encoding: ExportEncodingCSV, Columns: []string{"missing"}})
+	assert.True(t, goerrors.IsValidation(err))
+}
+
+func TestRepository_Export_NDJSON(t *testing.T) {
+	setupTestData(t)
+	ctx := context.Background()
+	repo := newTestUserRepository(db)
+	seedExportUsers(t, repo, 3)
+
+	var out bytes.Buffer
+	err := repo.(RecordExporter).Export(ctx, &out, ExportFormat{Encoding: ExportEncodingNDJSON, KeyMode: MapKeyStruct})
+	require.NoError(t, err)
+
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	require.Len(t, lines, 3)
+
+	var row map[string]any
+	require.NoError(t, json.Unmarshal([]byte(lines[0]), &row))
+	assert.ElementsMatch(t, []string{"ID", "Name", "Email", "CompanyID", "CreatedAt", "UpdatedAt"}, keysOf(row))
+	assert.Equal(t, uuid.Nil.String(), row["CompanyID"])
+
+	err = repo.(RecordExporter).Export(ctx, &out, ExportFormat{Encoding: "xml"})
+	assert.Error(t, err)
+}
+
+func keysOf(m map[string]any) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	return keys
+}
@@ -0,0 +1,142 @@
+package repository
+
+import (
+	"fmt"
+
+	"github.com/uptrace/bun"
+)
+
+// StringFilter describes the common filters for a text column. Set fields are
+// combined with AND; a nil filter produces no criteria.
+type StringFilter struct {
+	Eq     *string  `json:"eq,omitempty"`
+	Ne     *string  `json:"ne,omitempty"`
+	Like   *string  `json:"like,omitempty"`
+	ILike  *string  `json:"ilike,omitempty"`
+	In     []string `json:"in,omitempty"`
+	NotIn  []string `json:"not_in,omitempty"`
+	IsNull *bool    `json:"is_null,omitempty"`
+}
+
+// Criteria compiles the filter into select criteria for column, which must
+// be allowed by WithFilterableColumns when the model has an allowlist.
+// ILike is case insensitive on every dialect (LOWER(column) LIKE LOWER(?)).
+func (f *StringFilter) Criteria(column string) []SelectCriteria {
+	if f == nil {
+		return nil
+	}
+
+	var criteria []SelectCriteria
+	criteria = appendValueCriteria(criteria, column, "=", f.Eq)
+	criteria = appendValueCriteria(criteria, column, "<>", f.Ne)
+	criteria = appendValueCriteria(criteria, column, "LIKE", f.Like)
+	if f.ILike != nil {
+		criteria = append(criteria, selectLowerLike(column, *f.ILike))
+	}
+	criteria = appendInCriteria(criteria, column, f.In, f.NotIn)
+	return requireFilterableAll(column, appendNullCriteria(criteria, column, f.IsNull))
+}
+
+// ValueFilter describes the common filters for a column of type V, e.g.
+// numbers, booleans, time.Time or uuid.UUID. Set fields are combined with
+// AND; a nil filter produces no criteria.
+type ValueFilter[V any] struct {
+	Eq     *V    `json:"eq,omitempty"`
+	Ne     *V    `json:"ne,omitempty"`
+	Gt     *V    `json:"gt,omitempty"`
+	Gte    *V    `json:"gte,omitempty"`
+	Lt     *V    `json:"lt,omitempty"`
+	Lte    *V    `json:"lte,omitempty"`
+	In     []V   `json:"in,omitempty"`
+	NotIn  []V   `json:"not_in,omitempty"`
+	IsNull *bool `json:"is_null,omitempty"`
+}
+
+// Criteria compiles the filter into select criteria for column, which must
+// be allowed by WithFilterableColumns when the model has an allowlist.
+func (f *ValueFilter[V]) Criteria(column string) []SelectCriteria {
+	if f == nil {
+		return nil
+	}
+
+	var criteria []SelectCriteria
+	criteria = appendValueCriteria(criteria, column, "=", f.Eq)
+	criteria = appendValueCriteria(criteria, column, "<>", f.Ne)
+	criteria = appendValueCriteria(criteria, column, ">", f.Gt)
+	criteria = appendValueCriteria(criteria, column, ">=", f.Gte)
+	criteria = appendValueCriteria(criteria, column, "<", f.Lt)
+	criteria = appendValueCriteria(criteria, column, "<=", f.Lte)
+	criteria = appendInCriteria(criteria, column, f.In, f.NotIn)
+	return requireFilterableAll(column, appendNullCriteria(criteria, column, f.IsNull))
+}
+
+func requireFilterableAll(column string, criteria []SelectCriteria) []SelectCriteria {
+	for i, c := range criteria {
+		criteria[i] = requireFilterable(column, c)
+	}
+	return criteria
+}
+
+func appendValueCriteria[V any](criteria []SelectCriteria, column, operator string, value *V) []SelectCriteria {
+	if value == nil {
+		return criteria
+	}
+	return append(criteria, selectCompare(column, operator, *value))
+}
+
+func appendInCriteria[V any](criteria []SelectCriteria, column string, in, notIn []V) []SelectCriteria {
+	if in != nil {
+		criteria = append(criteria, selectIn(column, in))
+	}
+	if len(notIn) > 0 {
+		criteria = append(criteria, SelectColumnNotIn(column, notIn))
+	}
+	return criteria
+}
+
+func appendNullCriteria(criteria []SelectCriteria, column string, isNull *bool) []SelectCriteria {
+	if isNull == nil {
+		return criteria
+	}
+	if *isNull {
+		return append(criteria, SelectIsNull(column))
+	}
+	return append(criteria, SelectNotNull(column))
+}
+
+// selectCompare binds value with its Go type instead of as a string.
+func selectCompare(column, operator string, value any) SelectCriteria {
+	return func(q *bun.SelectQuery) *bun.SelectQuery {
+		col, colOK := normalizeSQLIdentifier(column)
+		op, opOK := normalizeComparisonOperator(operator)
+		if !colOK || !opOK {
+			return rejectSelect(q, criteriaInput{"column", column, colOK}, criteriaInput{"operator", operator, opOK})
+		}
+		return q.Where(fmt.Sprintf("?TableAlias.%s %s ?", col, op), value)
+	}
+}
+
+// selectIn differs from SelectColumnIn in that an explicitly empty list
+// matches nothing instead of being ignored.
+func selectIn[V any](column string, values []V) SelectCriteria {
+	return func(q *bun.SelectQuery) *bun.SelectQuery {
+		col, ok := normalizeSQLIdentifier(column)
+		if !ok {
+			return rejectSelect(q, criteriaInput{"column", column, ok})
+		}
+		if len(values) == 0 {
+			return q.Where("1=0")
+		}
+		return q.Where(fmt.Sprintf("?TableAlias.%s IN (?)", col), bun.In(values))
+	}
+}
+
+func selectLowerLike(column, pattern string) SelectCriteria {
+	return func(q *bun.SelectQuery) *bun.SelectQuery {
+		col, ok := normalizeSQLIdentifier(column)
+		if !ok {
+			return rejectSelect(q, criteriaInput{"column", column, ok})
+		}
+		return q.Where(fmt.Sprintf("LOWER(?TableAlias.%s) LIKE LOWER(?)", col), pattern)
+	}
+}
@@ -0,0 +1,87 @@
+package repository
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStringFilter_Criteria(t *testing.T) {
+	setupTestData(t)
+	ctx := context.Background()
+	repo := newTestUserRepository(db)
+
+	_, err := repo.CreateMany(ctx, []*TestUser{
+		{Name: "Alice", Email: "alice@example.com"},
+		{Name: "Bob", Email: "bob@example.com"},
+		{Name: "Carol", Email: "carol@other.com"},
+	})
+	require.NoError(t, err)
+
+	like := "%@example.com"
+	ne := "bob@example.com"
+	users, total, err := repo.List(ctx, (&StringFilter{Like: &like, Ne: &ne}).Criteria("email")...)
+	require.NoError(t, err)
+	assert.Equal(t, 1, total)
+	require.Len(t, users, 1)
+	assert.Equal(t, "Alice", users[0].Name)
+
+	ilike := "%CAROL%"
+	_, total, err = repo.List(ctx, (&StringFilter{ILike: &ilike}).Criteria("email")...)
+	require.NoError(t, err)
+	assert.Equal(t, 1, total)
+
+	_, total, err = repo.List(ctx, (&StringFilter{In: []string{"Alice", "Bob"}}).Criteria("name")...)
+	require.NoError(t, err)
+	assert.Equal(t, 2, total)
+
+	_, total, err = repo.List(ctx, (&StringFilter{In: []string{}}).Criteria("name")...)
+	require.NoError(t, err)
+	assert.Equal(t, 0, total)
+
+	var nilFilter *StringFilter
+	assert.Empty(t, nilFilter.Criteria("name"))
+	_, total, err = repo.List(ctx, nilFilter.Criteria("name")...)
+	require.NoError(t, err)
+	assert.Equal(t, 3, total)
+}
+
+func TestValueFilter_Criteria(t *testing.T) {
+	setupTestData(t)
+	ctx := context.Background()
+	repo := newTestUserRepository(db)
+
+	companyID := uuid.New()
+	_, err := repo.CreateMany(ctx, []*TestUser{
+		{Name: "Alice", Email: "alice@example.com", CompanyID: companyID},
+		{Name: "Bob", Email: "bob@example.com", CompanyID: companyID},
+		{Name: "Carol", Email: "carol@example.com", CompanyID: uuid.New()},
+	})
+	require.NoError(t, err)
+
+	_, total, err := repo.List(ctx, (&ValueFilter[uuid.UUID]{Eq: &companyID}).Criteria("company_id")...)
+	require.NoError(t, err)
+	assert.Equal(t, 2, total)
+
+	_, total, err = repo.List(ctx, (&ValueFilter[uuid.UUID]{NotIn: []uuid.UUID{companyID}}).Criteria("company_id")...)
+	require.NoError(t, err)
+	assert.Equal(t, 1, total)
+
+	future := time.Now().Add(time.Hour)
+	_, total, err = repo.List(ctx, (&ValueFilter[time.Time]{Lt: &future}).Criteria("created_at")...)
+	require.NoError(t, err)
+	assert.Equal(t, 3, total)
+
+	_, total, err = repo.List(ctx, (&ValueFilter[time.Time]{Gte: &future}).Criteria("created_at")...)
+	require.NoError(t, err)
+	assert.Equal(t, 0, total)
+
+	isNull := false
+	_, total, err = repo.List(ctx, (&ValueFilter[time.Time]{IsNull: &isNull}).Criteria("created_at")...)
+	require.NoError(t, err)
+	assert.Equal(t, 3, total)
+}
@@ -0,0 +1,104 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	stderrors "errors"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/uptrace/bun"
+)
+
+// GetOrCreateMutator is an optional capability for repositories that can set
+// defaults on a record only when GetOrCreate has to insert it.
+type GetOrCreateMutator[T any] interface {
+	GetOrCreateWith(ctx context.Context, record T, onCreate func(T) T) (T, error)
+	GetOrCreateWithTx(ctx context.Context, tx bun.IDB, record T, onCreate func(T) T) (T, error)
+}
+
+// GetOrCreateWith behaves like GetOrCreate, but when no existing record is
+// found it passes record through onCreate and inserts the result. Existing
+// records are returned untouched:
+//
+//	user, err := users.(GetOrCreateMutator[*User]).GetOrCreateWith(ctx, &User{Email: email},
+//		func(u *User) *User { u.Role = "member"; return u })
+//
+// A nil onCreate makes it equivalent to GetOrCreate.
+func (r *repo[T]) GetOrCreateWith(ctx context.Context, record T, onCreate func(T) T) (T, error) {
+	return r.GetOrCreateWithTx(ctx, r.db, record, onCreate)
+}
+
+func (r *repo[T]) GetOrCreateWithTx(ctx context.Context, tx bun.IDB, record T, onCreate func(T) T) (T, error) {
+	ctx = r.withOperation(ctx, "GetOrCreateWith", 0)
+	return r.getOrCreate(ctx, tx, record, onCreate)
+}
+
+var atomicGetOrCreateDrivers = map[string]bool{
+	"postgres": true,
+	"mysql":    true,
+}
+
+// atomicGetOrCreateColumn returns the identifier column used by
+// WithAtomicGetOrCreate, if the atomic strategy applies to record.
+func (r *repo[T]) atomicGetOrCreateColumn(ctx context.Context, record T) (string, bool) {
+	if !r.atomicGetOrCreate || !atomicGetOrCreateDrivers[r.driver] ||
+		r.handlers.GetIdentifier == nil || r.handlers.GetIdentifierValue == nil {
+		return "", false
+	}
+	if _, ok := IdempotencyKeyFromContext(ctx); ok {
+		return "", false
+	}
+	if strings.TrimSpace(r.handlers.GetIdentifierValue(record)) == "" {
+		return "", false
+	}
+	return normalizeSQLIdentifier(strings.TrimSpace(r.handlers.GetIdentifier()))
+}
+
+// getOrCreateAtomic inserts record ignoring conflicts on column and reselects
+// the existing record by identifier when no row was inserted.
+func (r *repo[T]) getOrCreateAtomic(ctx context.Context, tx bun.IDB, column string, record T, onCreate func(T) T) (T, error) {
+	var zero T
+	if onCreate != nil {
+		record = onCreate(record)
+	}
+	if r.handlers.GetID(record) == uuid.Nil {
+		r.handlers.SetID(record, uuid.New())
+	}
+	if err := r.assignSlugs(ctx, tx, record); err != nil {
+		return zero, err
+	}
+
+	q := tx.NewInsert().Model(record)
+	q = r.applyInsertScopes(ctx, q)
+	if r.driver == "mysql" {
+		q = q.On("DUPLICATE KEY UPDATE ? = ?", bun.Ident(column), bun.Ident(column))
+	} else {
+		q = q.On("CONFLICT (?) DO NOTHING", bun.Ident(column))
+	}
+	if err := r.stampIntegrity(record); err != nil {
+		return zero, err
+	}
+	if err := r.encryptRecords(record); err != nil {
+		return zero, err
+	}
+
+	res, err := q.Returning("*").Exec(ctx)
+	switch {
+	case err == nil:
+		if n, err := res.RowsAffected(); err == nil && n > 0 {
+			return record, nil
+		}
+	case !stderrors.Is(err, sql.ErrNoRows):
+		return zero, r.mapError(err)
+	}
+
+	existing, found, err := r.findExistingByIdentifier(ctx, tx, record)
+	if err != nil {
+		return zero, r.mapError(err)
+	}
+	if !found {
+		return zero, NewRecordNotFound()
+	}
+	return existing, nil
+}
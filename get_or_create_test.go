@@ -0,0 +1,98 @@
+package repository
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/uptrace/bun/dialect/pgdialect"
+	"github.com/uptrace/bun/dialect/sqlitedialect"
+)
+
+func TestRepository_GetOrCreateWith(t *testing.T) {
+	setupTestData(t)
+	ctx := context.Background()
+	repo := newTestUserRepository(db)
+
+	mutator, ok := repo.(GetOrCreateMutator[*TestUser])
+	require.True(t, ok)
+
+	calls := 0
+	onCreate := func(user *TestUser) *TestUser {
+		calls++
+		user.Name = "Defaulted"
+		return user
+	}
+
+	created, err := mutator.GetOrCreateWith(ctx, &TestUser{Email: "new@example.com", CompanyID: uuid.New()}, onCreate)
+	require.NoError(t, err)
+	assert.Equal(t, 1, calls)
+	assert.Equal(t, "Defaulted", created.Name)
+
+	stored, err := repo.GetByID(ctx, created.ID.String())
+	require.NoError(t, err)
+	assert.Equal(t, "Defaulted", stored.Name)
+
+	existing, err := mutator.GetOrCreateWith(ctx, &TestUser{Email: "new@example.com", Name: "Other"}, onCreate)
+	require.NoError(t, err)
+	assert.Equal(t, 1, calls, "onCreate must not run for existing records")
+	assert.Equal(t, created.ID, existing.ID)
+	assert.Equal(t, "Defaulted", existing.Name)
+}
+
+func TestRepository_GetOrCreateWith_NilMutator(t *testing.T) {
+	setupTestData(t)
+	ctx := context.Background()
+	repo := newTestUserRepository(db)
+
+	created, err := repo.(GetOrCreateMutator[*TestUser]).GetOrCreateWithTx(ctx, db, &TestUser{Name: "Plain", Email: "plain@example.com", CompanyID: uuid.New()}, nil)
+	require.NoError(t, err)
+	assert.Equal(t, "Plain", created.Name)
+}
+
+func TestRepository_WithAtomicGetOrCreate(t *testing.T) {
+	ctx := context.Background()
+	// Postgres SQL runs on SQLite, which supports ON CONFLICT DO NOTHING.
+	testDB := newDialectTestDB(t, pgdialect.New())
+	_, err := testDB.NewCreateTable().Model((*TestUser)(nil)).Exec(ctx)
+	require.NoError(t, err)
+
+	recorder := &queryLogRecorder{}
+	repo := newTestUserRepositoryWithConfig(testDB, []Option{WithQueryLogging(recorder.log)}, WithAtomicGetOrCreate())
+
+	created, err := repo.GetOrCreate(ctx, &TestUser{Name: "Alice", Email: "alice@example.com", CompanyID: uuid.New()})
+	require.NoError(t, err)
+	require.Len(t, recorder.entries, 1, "inserts without a prior select")
+	assert.Contains(t, recorder.entries[0].Query, `ON CONFLICT ("email") DO NOTHING`)
+
+	existing, err := repo.GetOrCreate(ctx, &TestUser{Name: "Other", Email: "alice@example.com", CompanyID: uuid.New()})
+	require.NoError(t, err)
+	assert.Equal(t, created.ID, existing.ID)
+	assert.Equal(t, "Alice", existing.Name)
+	require.Len(t, recorder.entries, 3, "a skipped insert reselects by identifier")
+	assert.Equal(t, "SELECT", recorder.entries[2].Operation)
+
+	total, err := repo.Count(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, total)
+}
+
+func TestRepository_WithAtomicGetOrCreate_SQLiteFallback(t *testing.T) {
+	ctx := context.Background()
+	testDB := newDialectTestDB(t, sqlitedialect.New())
+	_, err := testDB.NewCreateTable().Model((*TestUser)(nil)).Exec(ctx)
+	require.NoError(t, err)
+
+	recorder := &queryLogRecorder{}
+	repo := newTestUserRepositoryWithConfig(testDB, []Option{WithQueryLogging(recorder.log)}, WithAtomicGetOrCreate())
+
+	_, err = repo.GetOrCreate(ctx, &TestUser{Name: "Alice", Email: "alice@example.com", CompanyID: uuid.New()})
+	require.NoError(t, err)
+	require.NotEmpty(t, recorder.entries)
+	assert.Equal(t, "SELECT", recorder.entries[0].Operation, "SQLite keeps select-then-insert")
+	for _, entry := range recorder.entries {
+		assert.NotContains(t, entry.Query, "ON CONFLICT")
+	}
+}
@@ -0,0 +1,471 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/goliatone/go-errors"
+	"github.com/google/uuid"
+	"github.com/uptrace/bun"
+	"github.com/uptrace/bun/dialect"
+	"github.com/uptrace/bun/dialect/feature"
+	"github.com/uptrace/bun/schema"
+)
+
+// DefaultHistoryTableSuffix names the history table of WithHistory when no
+// suffix is given: users -> users_history.
+const DefaultHistoryTableSuffix = "_history"
+
+// Columns added to the model columns in history tables.
+const (
+	historyVersionColumn   = "history_version"
+	historyOperationColumn = "history_operation"
+	historyValidToColumn   = "history_valid_to"
+)
+
+// historyTimestampTypes are the history_valid_to column types.
+var historyTimestampTypes = map[dialect.Name]string{
+	dialect.PG:     "TIMESTAMPTZ",
+	dialect.MySQL:  "DATETIME(6)",
+	dialect.MSSQL:  "DATETIME2",
+	dialect.SQLite: "TIMESTAMP",
+}
+
+// Version is a previous version of a record kept in its history table.
+type Version[T any] struct {
+	// Version numbers the versions of a record from 1, oldest first.
+	Version int
+	// Operation is the write that replaced the version: EventUpdate,
+	// EventDelete or EventForceDelete.
+	Operation EventOperation
+	// ValidTo is when the version was replaced.
+	ValidTo time.Time
+	Record  T
+}
+
+// WithHistory copies the current row of every record into a history table
+// before a write changes it, in the same transaction: Update, UpdateMany,
+// Delete, ForceDelete, criteria writes such as UpdateWhere and DeleteWhere,
+// Reorder and the rows replaced by UpsertOnConflict. Criteria writes find
+// the rows they change with a RETURNING clause, so they are rejected on
+// dialects without one, such as MySQL. Anonymize also scrubs the history of
+// the rows it erases. The history table is named after the repository table
+// plus suffix, DefaultHistoryTableSuffix when empty, and must exist, see
+// HistoryRepository.CreateHistoryTable.
+func WithHistory(suffix string) RepoOption {
+	return func(cfg *repoConfig) {
+		if cfg == nil {
+			return
+		}
+		if suffix = strings.TrimSpace(suffix); suffix == "" {
+			suffix = DefaultHistoryTableSuffix
+		}
+		cfg.historySuffix = suffix
+	}
+}
+
+// HistoryRepository is an optional capability for repositories configured
+// with WithHistory.
+type HistoryRepository[T any] interface {
+	HistoryTableName() string
+	CreateHistoryTable(ctx context.Context) error
+	CreateHistoryTableTx(ctx context.Context, tx bun.IDB) error
+	History(ctx context.Context, id string) ([]Version[T], error)
+	HistoryTx(ctx context.Context, tx bun.IDB, id string) ([]Version[T], error)
+	AsOf(ctx context.Context, id string, t time.Time) (T, error)
+	AsOfTx(ctx context.Context, tx bun.IDB, id string, t time.Time) (T, error)
+	RestoreVersion(ctx context.Context, id string, version int) (T, PatchDiff, error)
+	RestoreVersionTx(ctx context.Context, tx bun.IDB, id string, version int) (T, PatchDiff, error)
+}
+
+// HistoryTableName returns the name of the history table, or an empty string
+// when WithHistory is not configured.
+func (r *repo[T]) HistoryTableName() string {
+	if r.historySuffix == "" {
+		return ""
+	}
+	return r.TableName() + r.historySuffix
+}
+
+// CreateHistoryTable creates the history table if it does not exist yet: the
+// model columns without their constraints, plus history_version,
+// history_operation and history_valid_to, keyed by the primary key and the
+// version.
+func (r *repo[T]) CreateHistoryTable(ctx context.Context) error {
+	return r.CreateHistoryTableTx(ctx, r.db)
+}
+
+func (r *repo[T]) CreateHistoryTableTx(ctx context.Context, tx bun.IDB) error {
+	ctx = r.withOperation(ctx, "CreateHistoryTable", 0)
+	table, err := r.historyModelTable()
+	if err != nil {
+		return err
+	}
+	name := tx.Dialect().Name()
+	timestampType, ok := historyTimestampTypes[name]
+	if !ok {
+		return unsupportedDriverError("history", name.String())
+	}
+
+	columns := make([]string, 0, len(table.Fields)+3)
+	for _, field := range table.Fields {
+		columns = append(columns, string(field.SQLName)+" "+field.CreateTableSQLType)
+	}
+	columns = append(columns,
+		historyVersionColumn+" INTEGER NOT NULL",
+		historyOperationColumn+" VARCHAR(16) NOT NULL",
+		historyValidToColumn+" "+timestampType+" NOT NULL",
+		fmt.Sprintf("PRIMARY KEY (%s, %s)", table.PKs[0].SQLName, historyVersionColumn),
+	)
+
+	query := "CREATE TABLE IF NOT EXISTS ? (" + strings.Join(columns, ", ") + ")"
+	if name == dialect.MSSQL {
+		query = "IF OBJECT_ID(?, 'U') IS NULL CREATE TABLE ? (" + strings.Join(columns, ", ") + ")"
+		_, err = tx.ExecContext(ctx, query, r.HistoryTableName(), bun.Ident(r.HistoryTableName()))
+	} else {
+		_, err = tx.ExecContext(ctx, query, bun.Ident(r.HistoryTableName()))
+	}
+	return r.mapError(err)
+}
+
+// History returns the previous versions of the record with id, oldest first.
+// The current version is the record itself.
+func (r *repo[T]) History(ctx context.Context, id string) ([]Version[T], error) {
+	return r.HistoryTx(ctx, r.db, id)
+}
+
+func (r *repo[T]) HistoryTx(ctx context.Context, tx bun.IDB, id string) ([]Version[T], error) {
+	ctx = r.withOperation(ctx, "History", 0)
+	return r.historyVersions(ctx, tx, id, 0)
+}
+
+// historyVersions reads the history of the record with id, or only version
+// when it is not 0.
+func (r *repo[T]) historyVersions(ctx context.Context, tx bun.IDB, id string, version int) ([]Version[T], error) {
+	table, err := r.historyModelTable()
+	if err != nil {
+		return nil, err
+	}
+	recordID, err := uuid.Parse(id)
+	if err != nil {
+		return nil, errors.NewValidation("repository: invalid record id",
+			errors.FieldError{Field: "id", Message: err.Error()})
+	}
+
+	columns := make([]string, 0, len(table.Fields)+3)
+	for _, field := range table.Fields {
+		columns = append(columns, string(field.SQLName))
+	}
+	columns = append(columns, historyVersionColumn, historyOperationColumn, historyValidToColumn)
+
+	query := "SELECT " + strings.Join(columns, ", ") + " FROM ? WHERE ? = ?"
+	args := []any{bun.Ident(r.HistoryTableName()), bun.Safe(table.PKs[0].SQLName), recordID}
+	if version != 0 {
+		query += " AND " + historyVersionColumn + " = ?"
+		args = append(args, version)
+	}
+	rows, err := tx.QueryContext(ctx, query+" ORDER BY "+historyVersionColumn, args...)
+	if err != nil {
+		return nil, r.mapError(err)
+	}
+	defer rows.Close()
+
+	var versions []Version[T]
+	raw := make([]any, len(table.Fields))
+	for rows.Next() {
+		var (
+			version   Version[T]
+			operation string
+		)
+		dest := make([]any, 0, len(raw)+3)
+		for i := range raw {
+			dest = append(dest, &raw[i])
+		}
+		dest = append(dest, &version.Version, &operation, &version.ValidTo)
+		if err := rows.Scan(dest...); err != nil {
+			return nil, r.mapError(err)
+		}
+
+		version.Record = r.handlers.NewRecord()
+		strct := reflect.Indirect(reflect.ValueOf(version.Record))
+		for i, field := range table.Fields {
+			if err := field.ScanValue(strct, raw[i]); err != nil {
+				return nil, fmt.Errorf("repository: scan history column %s: %w", field.Name, err)
+			}
+		}
+		if err := r.decryptRecords(version.Record); err != nil {
+			return nil, err
+		}
+		version.Operation = EventOperation(operation)
+		versions = append(versions, version)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, r.mapError(err)
+	}
+	return versions, nil
+}
+
+// AsOf returns the record with id as it was at t: the oldest version replaced
+// after t, or the current record when no version was replaced after t. Times
+// before the first write of the record return its first version; records
+// deleted before t are not found.
+func (r *repo[T]) AsOf(ctx context.Context, id string, t time.Time) (T, error) {
+	return r.AsOfTx(ctx, r.db, id, t)
+}
+
+func (r *repo[T]) AsOfTx(ctx context.Context, tx bun.IDB, id string, t time.Time) (T, error) {
+	ctx = r.withOperation(ctx, "AsOf", 0)
+	versions, err := r.HistoryTx(ctx, tx, id)
+	if err != nil {
+		var zero T
+		return zero, err
+	}
+	for _, version := range versions {
+		if version.ValidTo.After(t) {
+			return version.Record, nil
+		}
+	}
+	return r.GetByIDTx(ctx, tx, id)
+}
+
+// RestoreVersion updates the record with id to the values of a version from
+// History and returns the updated record with the restored columns, their
+// current value as Old and the restored value as New. Primary keys and
+// read-only columns are never written, and the replaced row is recorded as a
+// new version like any update. Deleted records cannot be restored: the record
+// must exist.
+func (r *repo[T]) RestoreVersion(ctx context.Context, id string, version int) (T, PatchDiff, error) {
+	return r.RestoreVersionTx(ctx, r.db, id, version)
+}
+
+func (r *repo[T]) RestoreVersionTx(ctx context.Context, tx bun.IDB, id string, version int) (T, PatchDiff, error) {
+	ctx = r.withOperation(ctx, "RestoreVersion", 0)
+	var (
+		result T
+		diff   PatchDiff
+	)
+	err := runInTx(ctx, tx, func(ctx context.Context, tx bun.IDB) error {
+		if version < 1 {
+			return errors.NewValidation("repository: invalid history version",
+				errors.FieldError{Field: "version", Message: "versions start at 1"})
+		}
+		versions, err := r.historyVersions(ctx, tx, id, version)
+		if err != nil {
+			return err
+		}
+		if len(versions) == 0 {
+			return NewRecordNotFound()
+		}
+
+		current, err := r.GetByIDTx(WithoutRelations(ctx), tx, id)
+		if err != nil {
+			return err
+		}
+
+		table := r.modelTable()
+		ignored := copyStrings(r.readOnlyColumns)
+		for _, pk := range table.PKs {
+			ignored = append(ignored, pk.Name)
+		}
+		changes, columns := DiffRecords(current, versions[0].Record,
+			WithDiffSchema(r.db),
+			WithDiffIgnoreColumns(ignored...),
+		)
+		diff = PatchDiff{Changes: changes, Columns: columns}
+		if len(columns) == 0 {
+			result = current
+			return nil
+		}
+
+		target := reflect.Indirect(reflect.ValueOf(current))
+		source := reflect.Indirect(reflect.ValueOf(versions[0].Record))
+		for _, column := range columns {
+			if field, ok := table.FieldMap[column]; ok {
+				field.Value(target).Set(field.Value(source))
+			}
+		}
+		result, err = r.UpdateTx(ctx, tx, current, UpdateColumns(columns...))
+		return err
+	})
+	if err != nil {
+		var zero T
+		return zero, PatchDiff{}, err
+	}
+	return result, diff, nil
+}
+
+// recordHistory copies the current rows of ids into the history table,
+// numbering them after the latest version of each record.
+func (r *repo[T]) recordHistory(ctx context.Context, tx bun.IDB, op EventOperation, ids ...uuid.UUID) error {
+	if r.historySuffix == "" || len(ids) == 0 {
+		return nil
+	}
+	return r.copyHistory(ctx, tx, op, bun.In(ids))
+}
+
+// recordHistoryOf copies the current rows selected by source, a history
+// source, into the history table like recordHistory.
+func (r *repo[T]) recordHistoryOf(ctx context.Context, tx bun.IDB, op EventOperation, source *bun.SelectQuery) error {
+	if r.historySuffix == "" || source == nil {
+		return nil
+	}
+	return r.copyHistory(ctx, tx, op, source)
+}
+
+// copyHistory copies the rows whose primary key is in ids, a list or a
+// subquery, into the history table.
+func (r *repo[T]) copyHistory(ctx context.Context, tx bun.IDB, op EventOperation, ids any) error {
+	table, err := r.historyModelTable()
+	if err != nil {
+		return err
+	}
+
+	columns := make([]string, 0, len(table.Fields))
+	values := make([]string, 0, len(table.Fields))
+	for _, field := range table.Fields {
+		columns = append(columns, string(field.SQLName))
+		values = append(values, "t."+string(field.SQLName))
+	}
+	pk := string(table.PKs[0].SQLName)
+
+	query := "INSERT INTO ?0 (" + strings.Join(columns, ", ") + ", " +
+		historyVersionColumn + ", " + historyOperationColumn + ", " + historyValidToColumn + ") " +
+		"SELECT " + strings.Join(values, ", ") + ", " +
+		"(SELECT COALESCE(MAX(h." + historyVersionColumn + "), 0) + 1 FROM ?0 AS h WHERE h." + pk + " = t." + pk + "), ?1, ?2 " +
+		"FROM ?3 AS t WHERE t." + pk + " IN (?4)"
+	_, err = tx.ExecContext(ctx, query,
+		bun.Ident(r.HistoryTableName()), string(op), time.Now().UTC(), bun.Ident(r.TableName()), ids,
+	)
+	return r.mapError(err)
+}
+
+// newHistorySource returns a select of the primary keys of the model rows,
+// nil without WithHistory, for recordHistoryOf.
+func (r *repo[T]) newHistorySource(tx bun.IDB) *bun.SelectQuery {
+	table, err := r.historyModelTable()
+	if err != nil {
+		return nil
+	}
+	return tx.NewSelect().Model(r.handlers.NewRecord()).Column(table.PKs[0].Name)
+}
+
+// execWithHistory runs a criteria write. With WithHistory probe first runs
+// the write in a savepoint that is rolled back, scanning the primary keys of
+// the rows it changes with the returning expression; those rows are copied
+// into the history table and exec runs the write restricted to them, in the
+// same transaction. Without WithHistory exec just runs on tx with no IDs.
+func (r *repo[T]) execWithHistory(
+	ctx context.Context,
+	tx bun.IDB,
+	op EventOperation,
+	probe func(ctx context.Context, tx bun.IDB, returning string, ids *[]uuid.UUID) error,
+	exec func(ctx context.Context, tx bun.IDB, ids []uuid.UUID) (int64, error),
+) (int64, error) {
+	if r.historySuffix == "" {
+		return exec(ctx, tx, nil)
+	}
+	returning, err := r.historyReturning(tx)
+	if err != nil {
+		return 0, err
+	}
+
+	var affected int64
+	err = runInTx(ctx, tx, func(ctx context.Context, tx bun.IDB) error {
+		var ids []uuid.UUID
+		err := rollbackSavepoint(ctx, tx, func(ctx context.Context, tx bun.IDB) error {
+			return probe(ctx, tx, returning, &ids)
+		})
+		if err != nil || len(ids) == 0 {
+			return r.mapError(err)
+		}
+		if err := r.recordHistory(ctx, tx, op, ids...); err != nil {
+			return err
+		}
+		affected, err = exec(ctx, tx, ids)
+		return err
+	})
+	return affected, err
+}
+
+// historyReturning returns the RETURNING expression listing the primary keys
+// of the rows a criteria write changes. Dialects without RETURNING or OUTPUT
+// clauses cannot list them, so such writes are rejected there.
+func (r *repo[T]) historyReturning(tx bun.IDB) (string, error) {
+	table, err := r.historyModelTable()
+	if err != nil {
+		return "", err
+	}
+	pk := string(table.PKs[0].SQLName)
+	features := tx.Dialect().Features()
+	switch {
+	case features.Has(feature.Output):
+		return "DELETED." + pk, nil
+	case features.Has(feature.Returning):
+		return pk, nil
+	}
+	return "", errors.NewValidation(
+		"repository: unsupported write with history",
+		errors.FieldError{
+			Field:   "repoOptions.WithHistory",
+			Message: fmt.Sprintf("%s cannot record the history of writes by criteria", tx.Dialect().Name()),
+		},
+	)
+}
+
+// rollbackSavepoint runs fn in a savepoint of tx, a transaction, and rolls
+// the savepoint back, so fn can try a write without applying it.
+func rollbackSavepoint(ctx context.Context, tx bun.IDB, fn func(ctx context.Context, tx bun.IDB) error) error {
+	var (
+		savepoint bun.Tx
+		err       error
+	)
+	switch tx := tx.(type) {
+	case bun.Tx:
+		savepoint, err = tx.BeginTx(ctx, nil)
+	case *bun.Tx:
+		savepoint, err = tx.BeginTx(ctx, nil)
+	default:
+		return fmt.Errorf("repository: savepoints require a transaction, got %T", tx)
+	}
+	if err != nil {
+		return err
+	}
+	err = fn(ctx, savepoint)
+	if rollbackErr := savepoint.Rollback(); err == nil {
+		err = rollbackErr
+	}
+	return err
+}
+
+// whereInIDs restricts a write of the model to the rows with ids.
+func (r *repo[T]) whereInIDs(ids []uuid.UUID) (string, []any) {
+	return "?TableAlias.? IN (?)", []any{bun.Ident(r.modelTable().PKs[0].Name), bun.In(ids)}
+}
+
+func (r *repo[T]) historyModelTable() (*schema.Table, error) {
+	table := r.modelTable()
+	if r.historySuffix == "" {
+		return nil, fmt.Errorf("repository: %s has no history, see WithHistory", r.TableName())
+	}
+	if table == nil || len(table.PKs) != 1 {
+		return nil, fmt.Errorf("repository: history requires a model with a single primary key")
+	}
+	return table, nil
+}
+
+func (r *repo[T]) validateHistory() error {
+	if r.historySuffix == "" {
+		return nil
+	}
+	table := r.modelTable()
+	if table == nil || len(table.PKs) == 1 {
+		return nil
+	}
+	return errors.NewValidation("repository configuration invalid", errors.FieldError{
+		Field:   "repoOptions.WithHistory",
+		Message: fmt.Sprintf("%s must have a single primary key column", table.Name),
+	})
+}
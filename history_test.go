@@ -0,0 +1,335 @@
+package repository
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/uptrace/bun"
+)
+
+func newHistoryTestRepository(t *testing.T, opts ...RepoOption) (Repository[*TestUser], HistoryRepository[*TestUser]) {
+	t.Helper()
+	setupTestData(t)
+	repo := newTestUserRepositoryWithConfig(db, nil, append([]RepoOption{WithHistory("")}, opts...)...)
+
+	history, ok := repo.(HistoryRepository[*TestUser])
+	require.True(t, ok)
+	assert.Equal(t, "test_users_history", history.HistoryTableName())
+
+	ctx := context.Background()
+	_, err := db.NewDropTable().Table(history.HistoryTableName()).IfExists().Exec(ctx)
+	require.NoError(t, err)
+	require.NoError(t, history.CreateHistoryTable(ctx))
+	require.NoError(t, history.CreateHistoryTable(ctx), "creating the table is idempotent")
+	return repo, history
+}
+
+func TestHistoryRecordsPreviousVersions(t *testing.T) {
+	ctx := context.Background()
+	repo, history := newHistoryTestRepository(t)
+	require.NoError(t, repo.(Validator).Validate())
+
+	user, err := repo.Create(ctx, &TestUser{
+		Name: "Ada", Email: "ada@example.com", CompanyID: uuid.New(),
+		CreatedAt: time.Now(), UpdatedAt: time.Now(),
+	})
+	require.NoError(t, err)
+	id := user.ID.String()
+
+	versions, err := history.History(ctx, id)
+	require.NoError(t, err)
+	assert.Empty(t, versions)
+
+	user.Name = "Ada Lovelace"
+	_, err = repo.Update(ctx, user)
+	require.NoError(t, err)
+	user.Name = "Countess Lovelace"
+	_, err = repo.UpdateMany(ctx, []*TestUser{user})
+	require.NoError(t, err)
+
+	versions, err = history.History(ctx, id)
+	require.NoError(t, err)
+	require.Len(t, versions, 2)
+	assert.Equal(t, 1, versions[0].Version)
+	assert.Equal(t, EventUpdate, versions[0].Operation)
+	assert.Equal(t, "Ada", versions[0].Record.Name)
+	assert.Equal(t, user.ID, versions[0].Record.ID)
+	assert.Equal(t, 2, versions[1].Version)
+	assert.Equal(t, "Ada Lovelace", versions[1].Record.Name)
+	assert.False(t, versions[1].ValidTo.Before(versions[0].ValidTo))
+
+	require.NoError(t, repo.Delete(ctx, user))
+	versions, err = history.History(ctx, id)
+	require.NoError(t, err)
+	require.Len(t, versions, 3)
+	assert.Equal(t, EventDelete, versions[2].Operation)
+	assert.Equal(t, "Countess Lovelace", versions[2].Record.Name)
+}
+
+func TestHistoryAsOf(t *testing.T) {
+	ctx := context.Background()
+	repo, history := newHistoryTestRepository(t)
+
+	user, err := repo.Create(ctx, &TestUser{
+		Name: "v1", Email: "asof@example.com", CompanyID: uuid.New(),
+		CreatedAt: time.Now(), UpdatedAt: time.Now(),
+	})
+	require.NoError(t, err)
+	id := user.ID.String()
+
+	atV1 := time.Now()
+	time.Sleep(5 * time.Millisecond)
+	user.Name = "v2"
+	_, err = repo.Update(ctx, user)
+	require.NoError(t, err)
+	time.Sleep(5 * time.Millisecond)
+	atV2 := time.Now()
+
+	got, err := history.AsOf(ctx, id, atV1)
+	require.NoError(t, err)
+	assert.Equal(t, "v1", got.Name)
+
+	got, err = history.AsOf(ctx, id, atV2)
+	require.NoError(t, err)
+	assert.Equal(t, "v2", got.Name)
+
+	time.Sleep(5 * time.Millisecond)
+	require.NoError(t, repo.Delete(ctx, user))
+
+	got, err = history.AsOf(ctx, id, atV2)
+	require.NoError(t, err)
+	assert.Equal(t, "v2", got.Name)
+
+	_, err = history.AsOf(ctx, id, time.Now())
+	assert.True(t, IsRecordNotFound(err))
+}
+
+func TestHistoryRollsBackWithTheWrite(t *testing.T) {
+	ctx := context.Background()
+	repo, history := newHistoryTestRepository(t)
+
+	user, err := repo.Create(ctx, &TestUser{
+		Name: "Ada", Email: "rollback@example.com", CompanyID: uuid.New(),
+		CreatedAt: time.Now(), UpdatedAt: time.Now(),
+	})
+	require.NoError(t, err)
+	other, err := repo.Create(ctx, &TestUser{
+		Name: "Grace", Email: "grace@example.com", CompanyID: uuid.New(),
+		CreatedAt: time.Now(), UpdatedAt: time.Now(),
+	})
+	require.NoError(t, err)
+
+	user.Email = other.Email
+	_, err = repo.Update(ctx, user)
+	require.Error(t, err)
+
+	versions, err := history.History(ctx, user.ID.String())
+	require.NoError(t, err)
+	assert.Empty(t, versions)
+}
+
+func TestHistoryRequiresOption(t *testing.T) {
+	setupTestData(t)
+	repo := newTestUserRepository(db)
+	history := repo.(HistoryRepository[*TestUser])
+	assert.Empty(t, history.HistoryTableName())
+	_, err := history.History(context.Background(), uuid.NewString())
+	assert.Error(t, err)
+}
+
+func TestHistoryRestoreVersion(t *testing.T) {
+	ctx := context.Background()
+	repo, history := newHistoryTestRepository(t)
+
+	user, err := repo.Create(ctx, &TestUser{
+		Name: "Ada", Email: "restore@example.com", CompanyID: uuid.New(),
+		CreatedAt: time.Now(), UpdatedAt: time.Now(),
+	})
+	require.NoError(t, err)
+	id := user.ID.String()
+
+	user.Name, user.Email = "Ada Lovelace", "ada.lovelace@example.com"
+	_, err = repo.Update(ctx, user)
+	require.NoError(t, err)
+
+	restored, diff, err := history.RestoreVersion(ctx, id, 1)
+	require.NoError(t, err)
+	assert.Equal(t, "Ada", restored.Name)
+	assert.Equal(t, "restore@example.com", restored.Email)
+	assert.ElementsMatch(t, []string{"name", "email"}, diff.Columns)
+	assert.Equal(t, FieldChange{Old: "Ada Lovelace", New: "Ada"}, diff.Changes["name"])
+
+	stored, err := repo.GetByID(ctx, id)
+	require.NoError(t, err)
+	assert.Equal(t, "Ada", stored.Name)
+
+	versions, err := history.History(ctx, id)
+	require.NoError(t, err)
+	require.Len(t, versions, 2, "the restore records the replaced row")
+	assert.Equal(t, "Ada Lovelace", versions[1].Record.Name)
+
+	restored, diff, err = history.RestoreVersion(ctx, id, 2)
+	require.NoError(t, err)
+	assert.Equal(t, "Ada Lovelace", restored.Name)
+	assert.ElementsMatch(t, []string{"name", "email"}, diff.Columns)
+
+	_, _, err = history.RestoreVersion(ctx, id, 10)
+	assert.True(t, IsRecordNotFound(err))
+	_, _, err = history.RestoreVersion(ctx, id, 0)
+	assert.Error(t, err)
+}
+
+func TestHistoryRecordsCriteriaWrites(t *testing.T) {
+	ctx := context.Background()
+	repo, history := newHistoryTestRepository(t)
+
+	company := uuid.New()
+	users := map[string]*TestUser{}
+	for _, name := range []string{"Ada", "Grace", "Linus"} {
+		companyID := company
+		if name == "Linus" {
+			companyID = uuid.New()
+		}
+		user, err := repo.Create(ctx, &TestUser{
+			Name: name, Email: name + "@criteria.example.com", CompanyID: companyID,
+			CreatedAt: time.Now(), UpdatedAt: time.Now(),
+		})
+		require.NoError(t, err)
+		users[name] = user
+	}
+	versions := func(name string) []Version[*TestUser] {
+		t.Helper()
+		versions, err := history.History(ctx, users[name].ID.String())
+		require.NoError(t, err)
+		return versions
+	}
+
+	updated, err := repo.(BulkUpdater).UpdateWhere(ctx,
+		UpdateSetColumn("name", "renamed"), UpdateBy("company_id", "=", company.String()))
+	require.NoError(t, err)
+	assert.EqualValues(t, 2, updated)
+	require.Len(t, versions("Ada"), 1)
+	assert.Equal(t, "Ada", versions("Ada")[0].Record.Name)
+	assert.Equal(t, EventUpdate, versions("Ada")[0].Operation)
+	require.Len(t, versions("Grace"), 1)
+	assert.Equal(t, "Grace", versions("Grace")[0].Record.Name)
+	assert.Empty(t, versions("Linus"))
+
+	require.NoError(t, repo.DeleteWhere(ctx, DeleteBy("email", "=", users["Grace"].Email)))
+	require.Len(t, versions("Grace"), 2)
+	assert.Equal(t, EventDelete, versions("Grace")[1].Operation)
+	assert.Equal(t, "renamed", versions("Grace")[1].Record.Name)
+
+	require.NoError(t, repo.DeleteWhere(ctx, DeleteByID(users["Ada"].ID.String()), DeleteForReal()))
+	require.Len(t, versions("Ada"), 2)
+	assert.Equal(t, EventForceDelete, versions("Ada")[1].Operation)
+
+	_, err = repo.(RecordClaimer[*TestUser]).ClaimOne(ctx,
+		[]SelectCriteria{SelectBy("name", "=", "Linus")}, UpdateSetColumn("name", "claimed"))
+	require.NoError(t, err)
+	require.Len(t, versions("Linus"), 1)
+	assert.Equal(t, "Linus", versions("Linus")[0].Record.Name)
+
+	_, err = repo.(ConflictUpserter[*TestUser]).UpsertOnConflict(ctx, &TestUser{
+		Name: "upserted", Email: users["Linus"].Email, CompanyID: uuid.New(),
+		CreatedAt: time.Now(), UpdatedAt: time.Now(),
+	}, []string{"email"}, []string{"name"})
+	require.NoError(t, err)
+	require.Len(t, versions("Linus"), 2)
+	assert.Equal(t, "claimed", versions("Linus")[1].Record.Name)
+}
+
+func TestHistoryRecordsRawCriteriaWrites(t *testing.T) {
+	ctx := context.Background()
+	repo, history := newHistoryTestRepository(t)
+
+	user, err := repo.Create(ctx, &TestUser{
+		Name: "Ada", Email: "raw@example.com", CompanyID: uuid.New(),
+		CreatedAt: time.Now(), UpdatedAt: time.Now(),
+	})
+	require.NoError(t, err)
+	other, err := repo.Create(ctx, &TestUser{
+		Name: "Grace", Email: "raw-other@example.com", CompanyID: uuid.New(),
+		CreatedAt: time.Now(), UpdatedAt: time.Now(),
+	})
+	require.NoError(t, err)
+
+	updated, err := repo.(BulkUpdater).UpdateWhere(ctx, UpdateSetColumn("name", "renamed"),
+		UpdateRawProcessor(func(q *bun.UpdateQuery) *bun.UpdateQuery {
+			return q.Where("?TableAlias.name = ?", "Ada")
+		}))
+	require.NoError(t, err)
+	assert.EqualValues(t, 1, updated)
+
+	versions, err := history.History(ctx, user.ID.String())
+	require.NoError(t, err)
+	require.Len(t, versions, 1)
+	assert.Equal(t, "Ada", versions[0].Record.Name)
+	assert.Equal(t, EventUpdate, versions[0].Operation)
+
+	deleted, err := repo.(CountingDeleter).DeleteWhereCount(ctx, func(q *bun.DeleteQuery) *bun.DeleteQuery {
+		return q.Where("?TableAlias.name = ?", "renamed")
+	})
+	require.NoError(t, err)
+	assert.EqualValues(t, 1, deleted)
+
+	versions, err = history.History(ctx, user.ID.String())
+	require.NoError(t, err)
+	require.Len(t, versions, 2)
+	assert.Equal(t, "renamed", versions[1].Record.Name)
+	assert.Equal(t, EventDelete, versions[1].Operation)
+
+	versions, err = history.History(ctx, other.ID.String())
+	require.NoError(t, err)
+	assert.Empty(t, versions)
+	_, err = repo.GetByID(ctx, other.ID.String())
+	require.NoError(t, err)
+}
+
+func TestHistoryAnonymizeScrubsVersions(t *testing.T) {
+	ctx := context.Background()
+	repo, history := newHistoryTestRepository(t)
+	require.NoError(t, CreateErasureAuditTable(ctx, db))
+
+	user, err := repo.Create(ctx, &TestUser{
+		Name: "Ada", Email: "scrub@example.com", CompanyID: uuid.New(),
+		CreatedAt: time.Now(), UpdatedAt: time.Now(),
+	})
+	require.NoError(t, err)
+	user.Name = "Ada Lovelace"
+	_, err = repo.Update(ctx, user)
+	require.NoError(t, err)
+
+	_, err = repo.(RecordAnonymizer).Anonymize(ctx,
+		[]UpdateCriteria{UpdateBy("email", "=", user.Email)},
+		map[string]Anonymizer{"name": AnonymizeRedact("[erased]")})
+	require.NoError(t, err)
+
+	versions, err := history.History(ctx, user.ID.String())
+	require.NoError(t, err)
+	require.Len(t, versions, 2)
+	for _, version := range versions {
+		assert.Equal(t, "[erased]", version.Record.Name)
+		assert.Equal(t, user.Email, version.Record.Email)
+	}
+}
+
+func TestHistoryRecordsReorder(t *testing.T) {
+	ctx := context.Background()
+	_, ids := newOrderingTestRepository(t)
+	repo := NewRepositoryWithConfig(db, testModelHandlers[*orderingTestCard](), nil, WithPositionColumn("sort_at"), WithHistory(""))
+	history := repo.(HistoryRepository[*orderingTestCard])
+	_, err := db.NewDropTable().Table(history.HistoryTableName()).IfExists().Exec(ctx)
+	require.NoError(t, err)
+	require.NoError(t, history.CreateHistoryTable(ctx))
+
+	require.NoError(t, repo.(PositionReorderer).Reorder(ctx, []uuid.UUID{ids["b"], ids["a"]}))
+	versions, err := history.History(ctx, ids["a"].String())
+	require.NoError(t, err)
+	require.Len(t, versions, 1)
+	assert.Equal(t, 0, versions[0].Record.SortAt)
+}
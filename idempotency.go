@@ -0,0 +1,136 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/goliatone/go-repository-bun/repositoryctx"
+	"github.com/uptrace/bun"
+)
+
+// IdempotencyKey records the record created by a Create call issued with
+// WithIdempotencyKey. Keys are scoped per repository table.
+type IdempotencyKey struct {
+	bun.BaseModel `bun:"table:repository_idempotency_keys,alias:rik"`
+
+	Scope     string    `bun:"scope,pk" json:"scope"`
+	Key       string    `bun:"idempotency_key,pk" json:"idempotency_key"`
+	RecordID  string    `bun:"record_id,notnull" json:"record_id"`
+	CreatedAt time.Time `bun:"created_at,notnull" json:"created_at"`
+}
+
+// WithIdempotencyKey returns a context that makes Create/CreateTx idempotent
+// for key: the first call stores the key alongside the new record in the same
+// transaction, and replays with the same key return the originally created
+// record instead of inserting a new one. An empty key leaves ctx unchanged.
+//
+// The idempotency table must exist, see CreateIdempotencyKeyTable.
+func WithIdempotencyKey(ctx context.Context, key string) context.Context {
+	return repositoryctx.WithIdempotencyKey(ctx, key)
+}
+
+// IdempotencyKeyFromContext returns the idempotency key stored in ctx, if any.
+func IdempotencyKeyFromContext(ctx context.Context) (string, bool) {
+	return repositoryctx.IdempotencyKey(ctx)
+}
+
+// CreateIdempotencyKeyTable creates the table backing WithIdempotencyKey
+// if it does not exist yet.
+func CreateIdempotencyKeyTable(ctx context.Context, db bun.IDB) error {
+	_, err := db.NewCreateTable().
+		Model((*IdempotencyKey)(nil)).
+		IfNotExists().
+		Exec(ctx)
+	return err
+}
+
+func (r *repo[T]) createIdempotentTx(ctx context.Context, tx bun.IDB, key string, record T, criteria []InsertCriteria) (T, error) {
+	scope := r.TableName()
+
+	var result T
+	err := runInTx(ctx, tx, func(ctx context.Context, tx bun.IDB) error {
+		existing, found, err := r.findIdempotentRecord(ctx, tx, scope, key)
+		if err != nil {
+			return err
+		}
+		if found {
+			result = existing
+			return nil
+		}
+
+		created, err := r.createTx(ctx, tx, record, criteria)
+		if err != nil {
+			return err
+		}
+
+		entry := &IdempotencyKey{
+			Scope:     scope,
+			Key:       key,
+			RecordID:  r.handlers.GetID(created).String(),
+			CreatedAt: time.Now().UTC(),
+		}
+		if _, err := tx.NewInsert().Model(entry).Exec(ctx); err != nil {
+			return r.mapError(err)
+		}
+
+		result = created
+		return nil
+	})
+	if err == nil {
+		return result, nil
+	}
+
+	// A concurrent call with the same key committed first, our transaction
+	// was rolled back so we can safely return the winner's record.
+	if IsDuplicatedKey(err) && !isTransaction(tx) {
+		if existing, found, lookupErr := r.findIdempotentRecord(ctx, tx, scope, key); lookupErr == nil && found {
+			return existing, nil
+		}
+	}
+
+	var zero T
+	return zero, err
+}
+
+func (r *repo[T]) findIdempotentRecord(ctx context.Context, tx bun.IDB, scope, key string) (T, bool, error) {
+	var zero T
+
+	entry := new(IdempotencyKey)
+	err := tx.NewSelect().
+		Model(entry).
+		Where("?TableAlias.scope = ?", scope).
+		Where("?TableAlias.idempotency_key = ?", key).
+		Scan(ctx)
+	if err != nil {
+		if IsRecordNotFound(err) {
+			return zero, false, nil
+		}
+		return zero, false, r.mapError(err)
+	}
+
+	existing, err := r.GetByIDTx(ctx, tx, entry.RecordID)
+	if err != nil {
+		return zero, false, err
+	}
+	return existing, true, nil
+}
+
+// runInTx runs fn inside a transaction, reusing tx when it already is one.
+// Transactions it starts run their commit hooks, see RunInTx.
+func runInTx(ctx context.Context, db bun.IDB, fn func(ctx context.Context, tx bun.IDB) error) error {
+	if isTransaction(db) {
+		return fn(ctx, db)
+	}
+	return RunInTx(ctx, db, nil, func(ctx context.Context, tx bun.Tx) error {
+		return fn(ctx, tx)
+	})
+}
+
+func isTransaction(db bun.IDB) bool {
+	switch db.(type) {
+	case bun.Tx, *bun.Tx:
+		return true
+	default:
+		return false
+	}
+}
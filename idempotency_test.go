@@ -0,0 +1,105 @@
+package repository
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func setupIdempotencyKeys(t *testing.T) {
+	t.Helper()
+	dropTestTable(t, (*IdempotencyKey)(nil))
+	require.NoError(t, CreateIdempotencyKeyTable(context.Background(), db))
+}
+
+func TestRepository_CreateWithIdempotencyKey(t *testing.T) {
+	setupTestData(t)
+	setupIdempotencyKeys(t)
+
+	repo := newTestUserRepository(db)
+	ctx := WithIdempotencyKey(context.Background(), "req-1")
+
+	first, err := repo.Create(ctx, &TestUser{
+		Name:      "Alice",
+		Email:     "alice@example.com",
+		CompanyID: uuid.New(),
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+	})
+	require.NoError(t, err)
+
+	replay, err := repo.Create(ctx, &TestUser{
+		Name:      "Alice (retry)",
+		Email:     "alice-retry@example.com",
+		CompanyID: uuid.New(),
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+	})
+	require.NoError(t, err)
+	assert.Equal(t, first.ID, replay.ID)
+	assert.Equal(t, "Alice", replay.Name)
+
+	count, err := repo.Count(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 1, count)
+
+	other, err := repo.Create(WithIdempotencyKey(context.Background(), "req-2"), &TestUser{
+		Name:      "Bob",
+		Email:     "bob@example.com",
+		CompanyID: uuid.New(),
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+	})
+	require.NoError(t, err)
+	assert.NotEqual(t, first.ID, other.ID)
+}
+
+func TestRepository_CreateWithIdempotencyKey_RollsBackKeyWithRecord(t *testing.T) {
+	setupTestData(t)
+	setupIdempotencyKeys(t)
+
+	repo := newTestUserRepository(db)
+	ctx := WithIdempotencyKey(context.Background(), "req-1")
+
+	tx, err := db.BeginTx(ctx, nil)
+	require.NoError(t, err)
+	_, err = repo.CreateTx(ctx, tx, &TestUser{
+		Name:      "Alice",
+		Email:     "alice@example.com",
+		CompanyID: uuid.New(),
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+	})
+	require.NoError(t, err)
+	rollbackTx(t, tx)
+
+	keys, err := db.NewSelect().Model((*IdempotencyKey)(nil)).Count(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 0, keys)
+
+	created, err := repo.Create(ctx, &TestUser{
+		Name:      "Alice",
+		Email:     "alice@example.com",
+		CompanyID: uuid.New(),
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "Alice", created.Name)
+}
+
+func TestIdempotencyKeyFromContext(t *testing.T) {
+	_, ok := IdempotencyKeyFromContext(context.Background())
+	assert.False(t, ok)
+
+	_, ok = IdempotencyKeyFromContext(WithIdempotencyKey(context.Background(), "  "))
+	assert.False(t, ok)
+
+	key, ok := IdempotencyKeyFromContext(WithIdempotencyKey(context.Background(), " abc "))
+	assert.True(t, ok)
+	assert.Equal(t, "abc", key)
+}
@@ -0,0 +1,68 @@
+package repository
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newMultiIdentifierUserRepository(columns ...string) Repository[*TestUser] {
+	handlers := testUserHandlers()
+	handlers.GetIdentifiers = func() []string { return columns }
+	return NewRepositoryWithConfig(db, handlers, nil)
+}
+
+func TestRepository_GetIdentifiers_GetByIdentifier(t *testing.T) {
+	setupTestData(t)
+	ctx := context.Background()
+	repo := newMultiIdentifierUserRepository("email", "name")
+	require.NoError(t, repo.(Validator).Validate())
+
+	created, err := repo.Create(ctx, &TestUser{Name: "alice", Email: "alice@example.com", CompanyID: uuid.New()})
+	require.NoError(t, err)
+
+	byEmail, err := repo.GetByIdentifier(ctx, "alice@example.com")
+	require.NoError(t, err)
+	assert.Equal(t, created.ID, byEmail.ID)
+
+	byName, err := repo.GetByIdentifier(ctx, "alice")
+	require.NoError(t, err)
+	assert.Equal(t, created.ID, byName.ID)
+
+	_, err = repo.GetByIdentifier(ctx, "bob")
+	require.Error(t, err)
+	assert.True(t, IsRecordNotFound(err))
+}
+
+func TestRepository_GetIdentifiers_GetOrCreateMatchesAnyKey(t *testing.T) {
+	setupTestData(t)
+	ctx := context.Background()
+	repo := newMultiIdentifierUserRepository("email", "name")
+
+	created, err := repo.Create(ctx, &TestUser{Name: "alice", Email: "alice@example.com", CompanyID: uuid.New()})
+	require.NoError(t, err)
+
+	existing, err := repo.GetOrCreate(ctx, &TestUser{Name: "alice", Email: "alice@work.example.com", CompanyID: uuid.New()})
+	require.NoError(t, err)
+	assert.Equal(t, created.ID, existing.ID, "matched on name")
+
+	other, err := repo.GetOrCreate(ctx, &TestUser{Name: "bob", Email: "bob@example.com", CompanyID: uuid.New()})
+	require.NoError(t, err)
+	assert.NotEqual(t, created.ID, other.ID)
+
+	total, err := repo.Count(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 2, total)
+}
+
+func TestRepository_GetIdentifiers_Validation(t *testing.T) {
+	err := newMultiIdentifierUserRepository("email", "name; DROP").(Validator).Validate()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "repository configuration invalid")
+
+	err = newMultiIdentifierUserRepository().(Validator).Validate()
+	require.Error(t, err)
+}
@@ -0,0 +1,326 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/uptrace/bun"
+)
+
+// ImportConflictStrategy decides what Import does with a row whose insert
+// fails with a duplicate key.
+type ImportConflictStrategy int
+
+const (
+	// ImportFail stops the run at the first duplicate. It is the default.
+	ImportFail ImportConflictStrategy = iota
+	// ImportSkip keeps the existing record untouched.
+	ImportSkip
+	// ImportOverwrite replaces the existing record with the imported one.
+	ImportOverwrite
+	// ImportMerge applies the imported row onto the existing record with
+	// ApplyMapPatch, so only the provided keys change.
+	ImportMerge
+)
+
+func (s ImportConflictStrategy) String() string {
+	switch s {
+	case ImportFail:
+		return "fail"
+	case ImportSkip:
+		return "skip"
+	case ImportOverwrite:
+		return "overwrite"
+	case ImportMerge:
+		return "merge"
+	default:
+		return fmt.Sprintf("ImportConflictStrategy(%d)", int(s))
+	}
+}
+
+// ImportRowError is the error of a single imported row. Row is 1-based.
+// Line is the line of the row in the source file, or 0 for decoded rows.
+type ImportRowError struct {
+	Row  int
+	Line int
+	Err  error
+}
+
+func (e ImportRowError) Error() string {
+	if e.Line > 0 {
+		return fmt.Sprintf("row %d (line %d): %v", e.Row, e.Line, e.Err)
+	}
+	return fmt.Sprintf("row %d: %v", e.Row, e.Err)
+}
+
+func (e ImportRowError) Unwrap() error {
+	return e.Err
+}
+
+// ImportReport counts the outcome of every imported row.
+type ImportReport struct {
+	Rows        int
+	Inserted    int
+	Skipped     int
+	Overwritten int
+	Merged      int
+	Failed      int
+	Errors      []ImportRowError
+}
+
+// ImportOption configures an Import run.
+type ImportOption func(*importConfig)
+
+type importConfig struct {
+	conflict     ImportConflictStrategy
+	patchOptions []MapPatchOption
+	progress     ProgressReporter
+	batchSize    int
+	comma        rune
+}
+
+// WithImportConflictStrategy sets how rows hitting a duplicate key are handled.
+func WithImportConflictStrategy(strategy ImportConflictStrategy) ImportOption {
+	return func(cfg *importConfig) {
+		cfg.conflict = strategy
+	}
+}
+
+// WithImportPatchOptions sets the options used to map rows into records and,
+// for ImportMerge, to patch existing records (key mode, allowed fields, ...).
+func WithImportPatchOptions(opts ...MapPatchOption) ImportOption {
+	return func(cfg *importConfig) {
+		cfg.patchOptions = append(cfg.patchOptions, opts...)
+	}
+}
+
+// WithImportProgress reports the progress of the run to reporter, in place of
+// the repository WithProgressReporter.
+func WithImportProgress(reporter ProgressReporter) ImportOption {
+	return func(cfg *importConfig) {
+		cfg.progress = reporter
+	}
+}
+
+// WithImportBatchSize inserts rows in batches of size with a single
+// CreateMany. A batch failing for any reason, e.g. a duplicate key or a row
+// that does not map, is imported again row by row, so errors and conflict
+// strategies still apply per row. Defaults to 1, one insert per row.
+func WithImportBatchSize(size int) ImportOption {
+	return func(cfg *importConfig) {
+		if size > 0 {
+			cfg.batchSize = size
+		}
+	}
+}
+
+// RecordImporter is an optional capability for repositories that can import
+// rows decoded from external files.
+type RecordImporter interface {
+	Import(ctx context.Context, rows []map[string]any, opts ...ImportOption) (ImportReport, error)
+	ImportTx(ctx context.Context, tx bun.IDB, rows []map[string]any, opts ...ImportOption) (ImportReport, error)
+}
+
+// Import maps every row with MapToRecord and inserts it. Rows failing with a
+// duplicate key are resolved with the configured ImportConflictStrategy; the
+// existing record is found the same way Upsert finds it (ID, identifier,
+// WithRecordLookupResolver). Every insert runs in its own transaction, or
+// savepoint inside ImportTx, so failed rows are recorded in the report and do
+// not abort the run. With ImportFail the run stops at the first duplicate and
+// the partial report is returned with the error.
+func (r *repo[T]) Import(ctx context.Context, rows []map[string]any, opts ...ImportOption) (ImportReport, error) {
+	return r.ImportTx(ctx, r.db, rows, opts...)
+}
+
+func (r *repo[T]) ImportTx(ctx context.Context, tx bun.IDB, rows []map[string]any, opts ...ImportOption) (ImportReport, error) {
+	ctx = r.withOperation(ctx, "Import", 0)
+	inputs := make([]importInput, 0, len(rows))
+	for i, row := range rows {
+		inputs = append(inputs, importInput{row: i + 1, values: row})
+	}
+	return r.importInputs(ctx, tx, inputs, newImportConfig(opts))
+}
+
+// importInput is a decoded row. err is set when decoding failed, so the row
+// is reported as failed without being inserted.
+type importInput struct {
+	row    int
+	line   int
+	values map[string]any
+	err    error
+}
+
+// importSource yields decoded rows until it returns false. Errors abort the
+// run, e.g. a failing reader.
+type importSource func() (importInput, bool, error)
+
+func sliceImportSource(inputs []importInput) importSource {
+	return func() (importInput, bool, error) {
+		if len(inputs) == 0 {
+			return importInput{}, false, nil
+		}
+		input := inputs[0]
+		inputs = inputs[1:]
+		return input, true, nil
+	}
+}
+
+func newImportConfig(opts []ImportOption) importConfig {
+	cfg := importConfig{}
+	for _, opt := range opts {
+		if opt != nil {
+			opt(&cfg)
+		}
+	}
+	return cfg
+}
+
+func (r *repo[T]) importInputs(ctx context.Context, tx bun.IDB, inputs []importInput, cfg importConfig) (ImportReport, error) {
+	return r.importRows(ctx, tx, sliceImportSource(inputs), int64(len(inputs)), cfg)
+}
+
+// importRows imports the rows of next, in batches when configured. total is
+// reported as the progress total, 0 when unknown.
+func (r *repo[T]) importRows(ctx context.Context, tx bun.IDB, next importSource, total int64, cfg importConfig) (ImportReport, error) {
+	reporter := cfg.progress
+	if reporter == nil {
+		reporter = r.progressReporter
+	}
+	progress := newProgressTracker(reporter, "import", r.TableName(), total)
+	defer progress.done(ctx)
+
+	batchSize := max(cfg.batchSize, 1)
+	batch := make([]importInput, 0, batchSize)
+	report := ImportReport{}
+	for {
+		input, ok, err := next()
+		if err != nil {
+			return report, err
+		}
+		if ok {
+			batch = append(batch, input)
+		}
+		if len(batch) == batchSize || (!ok && len(batch) > 0) {
+			if err := r.importBatch(ctx, tx, batch, cfg, &report, progress); err != nil {
+				return report, err
+			}
+			batch = batch[:0]
+		}
+		if !ok {
+			return report, nil
+		}
+	}
+}
+
+// importBatch inserts batch with a single CreateMany, or row by row when it
+// holds one row or the batch insert fails.
+func (r *repo[T]) importBatch(ctx context.Context, tx bun.IDB, batch []importInput, cfg importConfig, report *ImportReport, progress *progressTracker) error {
+	if len(batch) > 1 && r.importAll(ctx, tx, batch, cfg) {
+		report.Rows += len(batch)
+		report.Inserted += len(batch)
+		progress.add(ctx, int64(len(batch)))
+		return nil
+	}
+
+	for _, input := range batch {
+		report.Rows++
+		err := input.err
+		outcome := ImportFail
+		if err == nil {
+			outcome, err = r.importRow(ctx, tx, input.values, cfg)
+		}
+		progress.add(ctx, 1)
+		if err != nil {
+			rowErr := ImportRowError{Row: input.row, Line: input.line, Err: err}
+			report.Failed++
+			report.Errors = append(report.Errors, rowErr)
+			if cfg.conflict == ImportFail && isUniqueConflict(err) {
+				return rowErr
+			}
+			continue
+		}
+		switch outcome {
+		case ImportSkip:
+			report.Skipped++
+		case ImportOverwrite:
+			report.Overwritten++
+		case ImportMerge:
+			report.Merged++
+		default:
+			report.Inserted++
+		}
+	}
+	return nil
+}
+
+// importAll reports whether every row of batch mapped and was inserted with
+// a single CreateMany, in its own (nested) transaction.
+func (r *repo[T]) importAll(ctx context.Context, tx bun.IDB, batch []importInput, cfg importConfig) bool {
+	records := make([]T, 0, len(batch))
+	for _, input := range batch {
+		if input.err != nil {
+			return false
+		}
+		record, err := MapToRecord[T](input.values, cfg.patchOptions...)
+		if err != nil {
+			return false
+		}
+		records = append(records, record)
+	}
+	err := tx.RunInTx(ctx, nil, func(ctx context.Context, tx bun.Tx) error {
+		_, err := r.CreateManyTx(ctx, tx, records)
+		return err
+	})
+	return err == nil
+}
+
+// importRow returns the conflict strategy applied to the row, or ImportFail
+// when the row was inserted without conflict.
+func (r *repo[T]) importRow(ctx context.Context, tx bun.IDB, row map[string]any, cfg importConfig) (ImportConflictStrategy, error) {
+	record, err := MapToRecord[T](row, cfg.patchOptions...)
+	if err != nil {
+		return ImportFail, err
+	}
+
+	// the insert runs in its own (nested) transaction so a duplicate key does
+	// not abort the surrounding one
+	err = tx.RunInTx(ctx, nil, func(ctx context.Context, tx bun.Tx) error {
+		_, err := r.CreateTx(ctx, tx, record)
+		return err
+	})
+	if err == nil || !isUniqueConflict(err) {
+		return ImportFail, err
+	}
+
+	switch cfg.conflict {
+	case ImportSkip:
+		return ImportSkip, nil
+	case ImportOverwrite, ImportMerge:
+		return cfg.conflict, r.resolveImportConflict(ctx, tx, record, row, cfg, err)
+	default:
+		return ImportFail, err
+	}
+}
+
+func (r *repo[T]) resolveImportConflict(ctx context.Context, tx bun.IDB, record T, row map[string]any, cfg importConfig, conflict error) error {
+	existing, found, err := r.findExistingRecord(ctx, tx, record)
+	if err != nil {
+		return r.mapError(err)
+	}
+	if !found {
+		return conflict
+	}
+
+	if cfg.conflict == ImportOverwrite {
+		r.handlers.SetID(record, r.handlers.GetID(existing))
+		_, err = r.UpdateTx(ctx, tx, record)
+		return err
+	}
+
+	patched, columns, err := ApplyMapPatch(existing, row, cfg.patchOptions...)
+	if err != nil || len(columns) == 0 {
+		return err
+	}
+	_, err = r.UpdateTx(ctx, tx, patched, UpdateColumns(columns...))
+	return err
+}
@@ -0,0 +1,154 @@
+package repository
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	stderrors "errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/uptrace/bun"
+)
+
+// ImportFormat is the file format read by ImportStream.
+type ImportFormat string
+
+const (
+	// ImportFormatCSV reads CSV with a header row naming the payload keys.
+	ImportFormatCSV ImportFormat = "csv"
+	// ImportFormatNDJSON reads one JSON object per line.
+	ImportFormatNDJSON ImportFormat = "ndjson"
+)
+
+const defaultImportStreamBatchSize = 500
+
+// WithImportComma sets the CSV field delimiter of ImportStream. Defaults to
+// ','.
+func WithImportComma(comma rune) ImportOption {
+	return func(cfg *importConfig) {
+		cfg.comma = comma
+	}
+}
+
+// StreamImporter is an optional capability for repositories that can import
+// rows straight from CSV or NDJSON files.
+type StreamImporter interface {
+	ImportStream(ctx context.Context, r io.Reader, format ImportFormat, opts ...ImportOption) (ImportReport, error)
+	ImportStreamTx(ctx context.Context, tx bun.IDB, r io.Reader, format ImportFormat, opts ...ImportOption) (ImportReport, error)
+}
+
+// ImportStream reads CSV or NDJSON rows from r and imports them like Import,
+// mapping each row with MapToRecord and the WithImportPatchOptions key mode
+// and allowlist. CSV headers and NDJSON object keys are the payload keys;
+// CSV cells are passed as strings and coerced to the field types. Rows are
+// read as they are imported and inserted in batches of 500 unless
+// WithImportBatchSize says otherwise. Row errors, including malformed CSV
+// records and JSON lines, carry the line number of the row.
+func (r *repo[T]) ImportStream(ctx context.Context, reader io.Reader, format ImportFormat, opts ...ImportOption) (ImportReport, error) {
+	return r.ImportStreamTx(ctx, r.db, reader, format, opts...)
+}
+
+func (r *repo[T]) ImportStreamTx(ctx context.Context, tx bun.IDB, reader io.Reader, format ImportFormat, opts ...ImportOption) (ImportReport, error) {
+	ctx = r.withOperation(ctx, "ImportStream", 0)
+	cfg := newImportConfig(append([]ImportOption{WithImportBatchSize(defaultImportStreamBatchSize)}, opts...))
+
+	var (
+		next importSource
+		err  error
+	)
+	switch format {
+	case ImportFormatCSV:
+		next, err = csvImportSource(reader, cfg.comma)
+	case ImportFormatNDJSON:
+		next = ndjsonImportSource(reader)
+	default:
+		err = fmt.Errorf("repository: unsupported import format %q", format)
+	}
+	if err != nil {
+		return ImportReport{}, err
+	}
+	return r.importRows(ctx, tx, next, 0, cfg)
+}
+
+func csvImportSource(reader io.Reader, comma rune) (importSource, error) {
+	cr := csv.NewReader(reader)
+	if comma != 0 {
+		cr.Comma = comma
+	}
+	cr.TrimLeadingSpace = true
+	cr.FieldsPerRecord = -1
+
+	header, err := cr.Read()
+	if err != nil {
+		return nil, fmt.Errorf("repository: csv header: %w", err)
+	}
+	keys := make([]string, len(header))
+	for i, cell := range header {
+		keys[i] = strings.TrimSpace(strings.TrimPrefix(cell, "\ufeff"))
+	}
+
+	row := 0
+	return func() (importInput, bool, error) {
+		record, err := cr.Read()
+		if err == io.EOF {
+			return importInput{}, false, nil
+		}
+		row++
+		input := importInput{row: row}
+		var parseErr *csv.ParseError
+		switch {
+		case stderrors.As(err, &parseErr):
+			input.line = parseErr.StartLine
+			input.err = err
+		case err != nil:
+			return importInput{}, false, err
+		case len(record) != len(keys):
+			input.line, _ = cr.FieldPos(0)
+			input.err = fmt.Errorf("expected %d fields, got %d", len(keys), len(record))
+		default:
+			input.line, _ = cr.FieldPos(0)
+			input.values = make(map[string]any, len(keys))
+			for i, key := range keys {
+				input.values[key] = record[i]
+			}
+		}
+		return input, true, nil
+	}, nil
+}
+
+func ndjsonImportSource(reader io.Reader) importSource {
+	br := bufio.NewReader(reader)
+	row, line := 0, 0
+	return func() (importInput, bool, error) {
+		for {
+			data, err := br.ReadBytes('\n')
+			if err != nil && err != io.EOF {
+				return importInput{}, false, err
+			}
+			if len(data) == 0 && err == io.EOF {
+				return importInput{}, false, nil
+			}
+			line++
+			data = bytes.TrimSpace(data)
+			if len(data) == 0 {
+				if err == io.EOF {
+					return importInput{}, false, nil
+				}
+				continue
+			}
+
+			row++
+			input := importInput{row: row, line: line}
+			if decodeErr := json.Unmarshal(data, &input.values); decodeErr != nil {
+				input.err = fmt.Errorf("invalid json: %w", decodeErr)
+			} else if input.values == nil {
+				input.err = fmt.Errorf("invalid json: expected an object")
+			}
+			return input, true, nil
+		}
+	}
+}
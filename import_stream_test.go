@@ -0,0 +1,77 @@
+package repository
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRepository_ImportStream_CSV(t *testing.T) {
+	setupTestData(t)
+	ctx := context.Background()
+	repo := newTestUserRepository(db)
+	importer, ok := repo.(StreamImporter)
+	require.True(t, ok)
+
+	input := strings.Join([]string{
+		"name;email",
+		"Alice;alice@example.com",
+		"Bob;bob@example.com",
+		"Broken",
+		"Carol;carol@example.com",
+		"Dave;dave@example.com",
+	}, "\n")
+
+	report, err := importer.ImportStream(ctx, strings.NewReader(input), ImportFormatCSV,
+		WithImportComma(';'), WithImportBatchSize(2))
+	require.NoError(t, err)
+	assert.Equal(t, 5, report.Rows)
+	assert.Equal(t, 4, report.Inserted)
+	require.Len(t, report.Errors, 1)
+	assert.Equal(t, 3, report.Errors[0].Row)
+	assert.Equal(t, 4, report.Errors[0].Line)
+	assert.Contains(t, report.Errors[0].Error(), "row 3 (line 4)")
+
+	count, err := repo.Count(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 4, count)
+}
+
+func TestRepository_ImportStream_NDJSON(t *testing.T) {
+	setupTestData(t)
+	ctx := context.Background()
+	repo := newTestUserRepository(db)
+	_, err := repo.Create(ctx, &TestUser{Name: "Alice", Email: "alice@example.com"})
+	require.NoError(t, err)
+
+	input := strings.Join([]string{
+		`{"name": "Bob", "email": "bob@example.com"}`,
+		``,
+		`{"name": "Alice Imported", "email": "alice@example.com"}`,
+		`{"name": "Broken",`,
+		`{"name": "Carol", "email": "carol@example.com", "company_id": "not allowed"}`,
+		`{"name": "Dave", "email": "dave@example.com"}`,
+	}, "\n")
+
+	report, err := repo.(StreamImporter).ImportStream(ctx, strings.NewReader(input), ImportFormatNDJSON,
+		WithImportConflictStrategy(ImportMerge),
+		WithImportPatchOptions(WithPatchAllowedFields("name", "email")),
+	)
+	require.NoError(t, err)
+	assert.Equal(t, ImportReport{Rows: 5, Inserted: 2, Merged: 1, Failed: 2, Errors: report.Errors}, report)
+	require.Len(t, report.Errors, 2)
+	assert.Equal(t, 4, report.Errors[0].Line)
+	assert.Contains(t, report.Errors[0].Error(), "invalid json")
+	assert.Equal(t, 5, report.Errors[1].Line)
+	assert.ErrorIs(t, report.Errors[1], ErrPatchFieldNotAllowed)
+
+	alice, err := repo.GetByIdentifier(ctx, "alice@example.com")
+	require.NoError(t, err)
+	assert.Equal(t, "Alice Imported", alice.Name)
+
+	_, err = repo.(StreamImporter).ImportStream(ctx, strings.NewReader(""), "xml")
+	assert.Error(t, err)
+}
@@ -0,0 +1,101 @@
+package repository
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRepository_Import_ConflictStrategies(t *testing.T) {
+	ctx := context.Background()
+	companyID := uuid.New()
+
+	rows := func() []map[string]any {
+		return []map[string]any{
+			{"name": "Alice Imported", "email": "alice@example.com"},
+			{"name": "Dave", "email": "dave@example.com", "company_id": companyID},
+		}
+	}
+
+	tests := []struct {
+		name     string
+		strategy ImportConflictStrategy
+		expected ImportReport
+		alice    TestUser
+	}{
+		{
+			name:     "skip",
+			strategy: ImportSkip,
+			expected: ImportReport{Rows: 2, Inserted: 1, Skipped: 1},
+			alice:    TestUser{Name: "Alice", CompanyID: companyID},
+		},
+		{
+			name:     "overwrite",
+			strategy: ImportOverwrite,
+			expected: ImportReport{Rows: 2, Inserted: 1, Overwritten: 1},
+			alice:    TestUser{Name: "Alice Imported", CompanyID: uuid.Nil},
+		},
+		{
+			name:     "merge",
+			strategy: ImportMerge,
+			expected: ImportReport{Rows: 2, Inserted: 1, Merged: 1},
+			alice:    TestUser{Name: "Alice Imported", CompanyID: companyID},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setupTestData(t)
+			repo := newTestUserRepository(db)
+			_, err := repo.Create(ctx, &TestUser{Name: "Alice", Email: "alice@example.com", CompanyID: companyID})
+			require.NoError(t, err)
+
+			importer, ok := repo.(RecordImporter)
+			require.True(t, ok)
+			report, err := importer.Import(ctx, rows(), WithImportConflictStrategy(tt.strategy))
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, report)
+
+			alice, err := repo.GetByIdentifier(ctx, "alice@example.com")
+			require.NoError(t, err)
+			assert.Equal(t, tt.alice.Name, alice.Name)
+			assert.Equal(t, tt.alice.CompanyID, alice.CompanyID)
+
+			_, err = repo.GetByIdentifier(ctx, "dave@example.com")
+			require.NoError(t, err)
+		})
+	}
+}
+
+func TestRepository_Import_FailStopsAtDuplicate(t *testing.T) {
+	setupTestData(t)
+	ctx := context.Background()
+	repo := newTestUserRepository(db)
+	_, err := repo.Create(ctx, &TestUser{Name: "Alice", Email: "alice@example.com"})
+	require.NoError(t, err)
+
+	report, err := repo.(RecordImporter).Import(ctx, []map[string]any{
+		{"name": "Bob", "email": "bob@example.com"},
+		{"name": "Broken", "email": "broken@example.com", "unknown": true},
+		{"name": "Alice", "email": "alice@example.com"},
+		{"name": "Carol", "email": "carol@example.com"},
+	})
+	require.Error(t, err)
+	assert.True(t, IsDuplicatedKey(err))
+
+	var rowErr ImportRowError
+	require.ErrorAs(t, err, &rowErr)
+	assert.Equal(t, 3, rowErr.Row)
+
+	assert.Equal(t, 3, report.Rows)
+	assert.Equal(t, 1, report.Inserted)
+	assert.Equal(t, 2, report.Failed)
+	require.Len(t, report.Errors, 2)
+	assert.Equal(t, 2, report.Errors[0].Row)
+
+	_, err = repo.GetByIdentifier(ctx, "carol@example.com")
+	assert.True(t, IsRecordNotFound(err))
+}
@@ -0,0 +1,552 @@
+package repository
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	stderrors "errors"
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/goliatone/go-errors"
+	"github.com/google/uuid"
+	"github.com/uptrace/bun"
+	"github.com/uptrace/bun/dialect/feature"
+	"github.com/uptrace/bun/schema"
+)
+
+// ErrIntegrityViolation reports a row whose integrity column does not match
+// the hash of its values, i.e. a row modified outside the repository.
+var ErrIntegrityViolation = stderrors.New("repository: record integrity hash mismatch")
+
+// RecordHashOption configures ComputeRecordHash and WithIntegrityColumn.
+type RecordHashOption func(*recordHashConfig)
+
+type recordHashConfig struct {
+	fields  []string
+	exclude string
+}
+
+// WithHashFields limits the hash to the given Bun columns. By default every
+// column of the record is hashed.
+func WithHashFields(fields ...string) RecordHashOption {
+	return func(cfg *recordHashConfig) {
+		for _, field := range fields {
+			if field = strings.TrimSpace(field); field != "" && !containsString(cfg.fields, field) {
+				cfg.fields = append(cfg.fields, field)
+			}
+		}
+	}
+}
+
+func newRecordHashConfig(opts []RecordHashOption) recordHashConfig {
+	var cfg recordHashConfig
+	for _, opt := range opts {
+		if opt != nil {
+			opt(&cfg)
+		}
+	}
+	return cfg
+}
+
+// ComputeRecordHash returns the hex encoded SHA-256 of the columns of record.
+// Values are projected like RecordToMap with JSON compatible values, keyed by
+// their Bun column names and serialized as JSON with sorted keys. Timestamps
+// are hashed in UTC with microsecond precision, the precision every supported
+// database stores, so the hash of a record read back matches the hash of the
+// record written.
+func ComputeRecordHash(record any, opts ...RecordHashOption) (string, error) {
+	return computeRecordHash(record, newRecordHashConfig(opts))
+}
+
+func computeRecordHash(record any, cfg recordHashConfig) (string, error) {
+	values, err := EntityToMap(record,
+		WithProjectionRedaction(false),
+		WithProjectionValueEncoder(recordHashValue),
+	)
+	if err != nil {
+		return "", err
+	}
+
+	if len(cfg.fields) > 0 {
+		selected := make(map[string]any, len(cfg.fields))
+		for _, field := range cfg.fields {
+			value, ok := values[field]
+			if !ok {
+				return "", fmt.Errorf("repository: unknown hash field %q", field)
+			}
+			selected[field] = value
+		}
+		values = selected
+	}
+	delete(values, cfg.exclude)
+
+	encoded, err := json.Marshal(values)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(encoded)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// recordHashValue normalizes timestamps before the JSON compatible encoding.
+func recordHashValue(field string, value any) (any, error) {
+	switch v := value.(type) {
+	case time.Time:
+		value = v.UTC().Truncate(time.Microsecond)
+	case *time.Time:
+		if v != nil {
+			value = v.UTC().Truncate(time.Microsecond)
+		}
+	}
+	return JSONCompatibleValue(field, value)
+}
+
+type integrityConfig struct {
+	column string
+	hash   recordHashConfig
+}
+
+// WithIntegrityColumn stores ComputeRecordHash of every record in column, a
+// string column, when the repository writes it, and verifies the hash when
+// records of the model are read, to detect rows modified out of band. The
+// column itself is never hashed; WithHashFields limits the hashed columns,
+// e.g. to leave out columns filled by the database or by model hooks.
+//
+// Inserts hash the record as passed. Other writes of the repository, such as
+// updates, UpdateWhere, Reorder, Anonymize or soft deletes, rehash the rows
+// they change from their stored values in the same transaction; set based
+// writes need RETURNING or OUTPUT clauses for that and fail on MySQL. Raw
+// SQL leaves stale hashes behind. A query hook registered on the bun.DB
+// verifies the rows of every repository select returning all hashed columns,
+// with the column of that repository. Reads fail with an error wrapping
+// ErrIntegrityViolation on a mismatch; writes report it to the handler set
+// with WithQueryHookErrorHandler. IntegrityVerifier checks stored rows on
+// demand.
+// Unknown or non string columns are reported by Validate.
+func WithIntegrityColumn(column string, opts ...RecordHashOption) RepoOption {
+	return func(cfg *repoConfig) {
+		if cfg == nil {
+			return
+		}
+		if column = strings.TrimSpace(column); column == "" {
+			return
+		}
+		cfg.integrity = &integrityConfig{column: column, hash: newRecordHashConfig(opts)}
+		cfg.integrity.hash.exclude = column
+	}
+}
+
+// IntegrityVerifier is an optional capability for repositories configured
+// with WithIntegrityColumn.
+type IntegrityVerifier interface {
+	VerifyIntegrity(ctx context.Context, criteria ...SelectCriteria) ([]uuid.UUID, error)
+	VerifyIntegrityTx(ctx context.Context, tx bun.IDB, criteria ...SelectCriteria) ([]uuid.UUID, error)
+}
+
+// integrityModel holds the integrity column of one model.
+type integrityModel struct {
+	table   *schema.Table
+	field   *schema.Field
+	hash    recordHashConfig
+	decrypt func(value reflect.Value) error
+}
+
+type integrityModelKey struct{}
+
+// integrityHook verifies the integrity column of scanned records, with the
+// integrityModel the repository running the query put on its context. Like
+// encryptionHook it holds no state and is registered once per bun.DB.
+type integrityHook struct{}
+
+type integrityVerifyKey struct{}
+
+func (integrityHook) QueryHookKey() string {
+	return "integrity"
+}
+
+func (integrityHook) BeforeQuery(ctx context.Context, _ *bun.QueryEvent) context.Context {
+	return ctx
+}
+
+func (integrityHook) AfterQuery(ctx context.Context, event *bun.QueryEvent) {
+	if event.Model == nil || event.Err != nil || event.Result == nil {
+		return
+	}
+	// VerifyIntegrity reports mismatches itself
+	if ctx.Value(integrityVerifyKey{}) != nil {
+		return
+	}
+	model, ok := ctx.Value(integrityModelKey{}).(*integrityModel)
+	if !ok {
+		return
+	}
+	q, ok := event.IQuery.(*bun.SelectQuery)
+	if !ok || !model.selectedBy(q) {
+		return
+	}
+	if n, err := event.Result.RowsAffected(); err != nil || n == 0 {
+		return
+	}
+
+	value := reflect.ValueOf(event.Model.Value())
+	for value.Kind() == reflect.Pointer || value.Kind() == reflect.Interface {
+		if value.IsNil() {
+			return
+		}
+		value = value.Elem()
+	}
+
+	var errs []error
+	switch value.Kind() {
+	case reflect.Struct:
+		errs = append(errs, model.verifyScanned(value))
+	case reflect.Slice:
+		for i := range value.Len() {
+			errs = append(errs, model.verifyScanned(reflect.Indirect(value.Index(i))))
+		}
+	}
+	for _, err := range errs {
+		if err != nil {
+			reportScanError(ctx, event.DB, integrityHook{}, err)
+		}
+	}
+}
+
+// verifyScanned verifies value when it is a record of the model.
+func (m *integrityModel) verifyScanned(value reflect.Value) error {
+	if value.Kind() != reflect.Struct || value.Type() != m.table.Type {
+		return nil
+	}
+	return m.verify(value)
+}
+
+// selectedBy reports whether q reads the integrity column and every hashed
+// column, so partial selects are not mistaken for tampered rows.
+func (m *integrityModel) selectedBy(q *bun.SelectQuery) bool {
+	selected := queryColumnNames(q)
+	if selected == nil {
+		return true
+	}
+	if _, ok := selected[m.field.Name]; !ok {
+		return false
+	}
+	for _, field := range m.table.Fields {
+		if field == m.field {
+			continue
+		}
+		if len(m.hash.fields) > 0 && !containsString(m.hash.fields, field.Name) {
+			continue
+		}
+		if _, ok := selected[field.Name]; !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// verify compares the stored hash of value with the hash of its columns.
+// Encrypted columns are decrypted first: hashes cover plaintext values.
+func (m *integrityModel) verify(value reflect.Value) error {
+	if m.decrypt != nil {
+		if err := m.decrypt(value); err != nil {
+			return err
+		}
+	}
+	expected, err := computeRecordHash(value.Addr().Interface(), m.hash)
+	if err != nil {
+		return err
+	}
+	if stored := reflect.Indirect(m.field.Value(value)); !stored.IsValid() || stored.String() != expected {
+		keys := make([]string, len(m.table.PKs))
+		for i, pk := range m.table.PKs {
+			keys[i] = fmt.Sprint(pk.Value(value).Interface())
+		}
+		return fmt.Errorf("%w: %s %s", ErrIntegrityViolation, m.table.Name, strings.Join(keys, ", "))
+	}
+	return nil
+}
+
+// registerIntegrityColumn resolves the integrity column on the model of r and
+// registers the integrity hook on the DB.
+func (r *repo[T]) registerIntegrityColumn(cfg *integrityConfig) {
+	table := r.modelTable()
+	if table == nil {
+		return
+	}
+	field, ok := table.FieldMap[cfg.column]
+	if !ok || field.IndirectType.Kind() != reflect.String {
+		return
+	}
+	model := &integrityModel{table: table, field: field, hash: cfg.hash}
+	if encryption := r.encryption; encryption != nil {
+		model.decrypt = encryption.decrypt
+	}
+	r.integrity = model
+	registerQueryHooks(r.db, integrityHook{})
+}
+
+// withIntegrity scopes the integrity column of r to ctx, so the integrity
+// hook verifies the records the repository scans with it.
+func (r *repo[T]) withIntegrity(ctx context.Context) context.Context {
+	if r.integrity == nil {
+		return ctx
+	}
+	if current, ok := ctx.Value(integrityModelKey{}).(*integrityModel); ok && current == r.integrity {
+		return ctx
+	}
+	return context.WithValue(ctx, integrityModelKey{}, r.integrity)
+}
+
+// verifyRecords decrypts and verifies records scanned without the query
+// hooks, e.g. by prepared lookups.
+func (r *repo[T]) verifyRecords(records ...T) error {
+	if r.integrity == nil {
+		return r.decryptRecords(records...)
+	}
+	var errs []error
+	for _, record := range records {
+		errs = append(errs, r.integrity.verify(reflect.Indirect(reflect.ValueOf(record))))
+	}
+	return stderrors.Join(errs...)
+}
+
+// stampIntegrity stores the hash of records in their integrity column. It
+// runs before encryptRecords: hashes cover plaintext values.
+func (r *repo[T]) stampIntegrity(records ...T) error {
+	if r.integrity == nil {
+		return nil
+	}
+	for _, record := range records {
+		hash, err := computeRecordHash(record, r.integrity.hash)
+		if err != nil {
+			return err
+		}
+		if err := r.integrity.field.ScanValue(reflect.Indirect(reflect.ValueOf(record)), hash); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// rehashIntegrity recomputes the integrity column of the rows with ids from
+// their stored values and returns the new hashes by ID. Writes that change
+// rows without the whole record at hand, such as updates, UpdateWhere,
+// Reorder, Anonymize and soft deletes, call it in their transaction once the
+// rows are written.
+func (r *repo[T]) rehashIntegrity(ctx context.Context, tx bun.IDB, ids []uuid.UUID) (map[uuid.UUID]string, error) {
+	if r.integrity == nil || len(ids) == 0 {
+		return nil, nil
+	}
+	ctx = context.WithValue(ctx, integrityVerifyKey{}, true)
+
+	hashes := make(map[uuid.UUID]string, len(ids))
+	for chunk := range slices.Chunk(ids, defaultExportChunkSize) {
+		var records []T
+		q := tx.NewSelect().Model(&records).Where("?TableAlias.id IN (?)", bun.In(chunk))
+		if querySoftDeletes(q) {
+			q = q.WhereAllWithDeleted()
+		}
+		if err := q.Scan(ctx); err != nil {
+			return nil, r.mapError(err)
+		}
+		if len(records) == 0 {
+			continue
+		}
+
+		var expr strings.Builder
+		args := make([]any, 0, 2*len(records)+1)
+		expr.WriteString("? = CASE ?TableAlias.id")
+		args = append(args, bun.Ident(r.integrity.field.Name))
+		rowIDs := make([]uuid.UUID, len(records))
+		for i, record := range records {
+			if r.integrity.decrypt != nil {
+				if err := r.integrity.decrypt(reflect.Indirect(reflect.ValueOf(record))); err != nil {
+					return nil, err
+				}
+			}
+			hash, err := computeRecordHash(record, r.integrity.hash)
+			if err != nil {
+				return nil, err
+			}
+			rowIDs[i] = r.handlers.GetID(record)
+			hashes[rowIDs[i]] = hash
+			expr.WriteString(" WHEN ? THEN ?")
+			args = append(args, rowIDs[i], hash)
+		}
+		expr.WriteString(" END")
+
+		uq := tx.NewUpdate().
+			Model(r.handlers.NewRecord()).
+			Set(expr.String(), args...).
+			Where("?TableAlias.id IN (?)", bun.In(rowIDs))
+		if querySoftDeletes(uq) {
+			uq = uq.WhereAllWithDeleted()
+		}
+		if _, err := uq.Exec(ctx); err != nil {
+			return nil, r.mapError(err)
+		}
+	}
+	return hashes, nil
+}
+
+// rehashRecords is rehashIntegrity for the rows of records, and stores the
+// new hashes in their integrity column.
+func (r *repo[T]) rehashRecords(ctx context.Context, tx bun.IDB, records ...T) error {
+	if r.integrity == nil {
+		return nil
+	}
+	ids := make([]uuid.UUID, len(records))
+	for i, record := range records {
+		ids[i] = r.handlers.GetID(record)
+	}
+	hashes, err := r.rehashIntegrity(ctx, tx, ids)
+	if err != nil {
+		return err
+	}
+	for _, record := range records {
+		if hash, ok := hashes[r.handlers.GetID(record)]; ok {
+			if err := r.integrity.field.ScanValue(reflect.Indirect(reflect.ValueOf(record)), hash); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// execUpdate runs q, a set based update of the model built on tx, and
+// returns the number of affected rows. With an integrity column the rows it
+// changes are rehashed in the same transaction.
+func (r *repo[T]) execUpdate(ctx context.Context, tx bun.IDB, q *bun.UpdateQuery) (int64, error) {
+	if r.integrity == nil {
+		res, err := q.Exec(ctx)
+		if err != nil {
+			return 0, r.mapError(err)
+		}
+		return rowsAffected(res)
+	}
+
+	return r.execRehashed(ctx, tx, func(ctx context.Context, tx bun.IDB, returning string, ids *[]uuid.UUID) error {
+		_, err := q.Conn(tx).Returning(returning).Exec(ctx, ids)
+		return err
+	})
+}
+
+// execRehashed runs exec, a set based write scanning the IDs of the rows it
+// changes with the returning expression, in a transaction with the rehash of
+// those rows, and returns the number of changed rows.
+func (r *repo[T]) execRehashed(ctx context.Context, tx bun.IDB, exec func(ctx context.Context, tx bun.IDB, returning string, ids *[]uuid.UUID) error) (int64, error) {
+	returning, err := r.integrityReturning(tx)
+	if err != nil {
+		return 0, err
+	}
+	var ids []uuid.UUID
+	err = runInTx(ctx, tx, func(ctx context.Context, tx bun.IDB) error {
+		if err := exec(ctx, tx, returning, &ids); err != nil {
+			return r.mapError(err)
+		}
+		_, err := r.rehashIntegrity(ctx, tx, ids)
+		return err
+	})
+	if err != nil {
+		return 0, err
+	}
+	return int64(len(ids)), nil
+}
+
+// integrityReturning returns the RETURNING expression listing the IDs of the
+// rows a set based write changes, to rehash them. Dialects without RETURNING
+// or OUTPUT clauses cannot list them, so such writes are rejected there.
+func (r *repo[T]) integrityReturning(tx bun.IDB) (string, error) {
+	features := tx.Dialect().Features()
+	switch {
+	case features.Has(feature.Output):
+		return "INSERTED.id", nil
+	case features.Has(feature.Returning):
+		return "id", nil
+	}
+	return "", errors.NewValidation(
+		"repository: unsupported write with integrity column",
+		errors.FieldError{
+			Field:   "repoOptions.WithIntegrityColumn",
+			Message: fmt.Sprintf("%s cannot return the rows changed by set based writes to rehash them", tx.Dialect().Name()),
+		},
+	)
+}
+
+// VerifyIntegrity recomputes the hash of every row matched by criteria and
+// returns the IDs of the rows whose integrity column does not match, in
+// primary key order unless criteria order the rows.
+func (r *repo[T]) VerifyIntegrity(ctx context.Context, criteria ...SelectCriteria) ([]uuid.UUID, error) {
+	return r.VerifyIntegrityTx(ctx, r.db, criteria...)
+}
+
+func (r *repo[T]) VerifyIntegrityTx(ctx context.Context, tx bun.IDB, criteria ...SelectCriteria) ([]uuid.UUID, error) {
+	ctx = r.withOperation(ctx, "VerifyIntegrity", len(criteria))
+	if r.integrity == nil {
+		return nil, fmt.Errorf("repository: %s has no integrity column", r.TableName())
+	}
+	ctx = context.WithValue(ctx, integrityVerifyKey{}, true)
+
+	var tampered []uuid.UUID
+	for offset := 0; ; offset += defaultExportChunkSize {
+		records, err := r.exportChunk(ctx, tx, criteria, defaultExportChunkSize, offset)
+		if err != nil {
+			return tampered, err
+		}
+		for _, record := range records {
+			err := r.integrity.verify(reflect.Indirect(reflect.ValueOf(record)))
+			switch {
+			case stderrors.Is(err, ErrIntegrityViolation):
+				tampered = append(tampered, r.handlers.GetID(record))
+			case err != nil:
+				return tampered, err
+			}
+		}
+		if len(records) < defaultExportChunkSize {
+			return tampered, nil
+		}
+	}
+}
+
+func (r *repo[T]) validateIntegrityColumn() error {
+	if r.integrityConfig == nil {
+		return nil
+	}
+	table := r.modelTable()
+	if table == nil {
+		return nil
+	}
+
+	var validationErrors errors.ValidationErrors
+	field, ok := table.FieldMap[r.integrityConfig.column]
+	switch {
+	case !ok:
+		validationErrors = append(validationErrors, errors.FieldError{
+			Field:   "repoOptions.WithIntegrityColumn",
+			Message: fmt.Sprintf("unknown column %q on %s", r.integrityConfig.column, table.Name),
+		})
+	case field.IndirectType.Kind() != reflect.String:
+		validationErrors = append(validationErrors, errors.FieldError{
+			Field:   "repoOptions.WithIntegrityColumn",
+			Message: fmt.Sprintf("column %q on %s is not a string", r.integrityConfig.column, table.Name),
+		})
+	}
+	for _, column := range r.integrityConfig.hash.fields {
+		if _, ok := table.FieldMap[column]; !ok {
+			validationErrors = append(validationErrors, errors.FieldError{
+				Field:   "repoOptions.WithHashFields",
+				Message: fmt.Sprintf("unknown column %q on %s", column, table.Name),
+			})
+		}
+	}
+
+	if len(validationErrors) > 0 {
+		return errors.NewValidation("repository configuration invalid", validationErrors...)
+	}
+	return nil
+}
@@ -0,0 +1,315 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	stderrors "errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/uptrace/bun"
+	"github.com/uptrace/bun/dialect/sqlitedialect"
+)
+
+type integrityTestLedger struct {
+	bun.BaseModel `bun:"table:integrity_test_ledgers,alias:itl"`
+
+	ID        uuid.UUID `bun:"id,pk,notnull"`
+	Account   string    `bun:"account,notnull"`
+	Amount    int64     `bun:"amount,notnull"`
+	Memo      string    `bun:"memo"`
+	RowHash   string    `bun:"row_hash"`
+	CreatedAt time.Time `bun:"created_at,notnull"`
+}
+
+// newIntegrityTestRepository uses its own DB so the collected hook errors do
+// not leak into other tests.
+func newIntegrityTestRepository(t *testing.T, opts ...RepoOption) (Repository[*integrityTestLedger], *bun.DB, func() []error) {
+	t.Helper()
+	bunDB, hookErrors := newIntegrityTestDB(t, (*integrityTestLedger)(nil))
+	repo := NewRepositoryWithConfig(bunDB, testModelHandlers[*integrityTestLedger](), nil, opts...)
+	return repo, bunDB, hookErrors
+}
+
+func newIntegrityTestDB(t *testing.T, models ...any) (*bun.DB, func() []error) {
+	t.Helper()
+	sqldb, err := sql.Open("sqlite3", ":memory:")
+	require.NoError(t, err)
+	sqldb.SetMaxOpenConns(1)
+	t.Cleanup(func() { _ = sqldb.Close() })
+
+	bunDB := bun.NewDB(sqldb, sqlitedialect.New())
+	var (
+		mu     sync.Mutex
+		errs   []error
+		record = func(_ *bun.DB, _ bun.QueryHook, err error) {
+			mu.Lock()
+			defer mu.Unlock()
+			errs = append(errs, err)
+		}
+	)
+	WithQueryHookErrorHandler(record)(bunDB)
+
+	for _, model := range models {
+		_, err = bunDB.NewCreateTable().Model(model).Exec(context.Background())
+		require.NoError(t, err)
+	}
+	return bunDB, func() []error {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]error(nil), errs...)
+	}
+}
+
+func TestComputeRecordHash(t *testing.T) {
+	at := time.Date(2024, 3, 1, 10, 0, 0, 123456789, time.UTC)
+	ledger := &integrityTestLedger{ID: uuid.New(), Account: "acme", Amount: 100, CreatedAt: at}
+
+	hash, err := ComputeRecordHash(ledger)
+	require.NoError(t, err)
+	assert.Len(t, hash, 64)
+
+	again, err := ComputeRecordHash(&integrityTestLedger{
+		ID:        ledger.ID,
+		Account:   "acme",
+		Amount:    100,
+		CreatedAt: at.Truncate(time.Microsecond).In(time.FixedZone("CET", 3600)),
+	})
+	require.NoError(t, err)
+	assert.Equal(t, hash, again, "timestamps are hashed in UTC with microsecond precision")
+
+	ledger.Amount = 101
+	changed, err := ComputeRecordHash(ledger)
+	require.NoError(t, err)
+	assert.NotEqual(t, hash, changed)
+
+	first, err := ComputeRecordHash(ledger, WithHashFields("id", "account"))
+	require.NoError(t, err)
+	ledger.Amount, ledger.Memo = 5, "ignored"
+	second, err := ComputeRecordHash(ledger, WithHashFields("id", "account"))
+	require.NoError(t, err)
+	assert.Equal(t, first, second)
+
+	_, err = ComputeRecordHash(ledger, WithHashFields("missing"))
+	assert.Error(t, err)
+}
+
+func TestIntegrityColumnStampsAndVerifiesRows(t *testing.T) {
+	ctx := context.Background()
+	repo, bunDB, hookErrors := newIntegrityTestRepository(t, WithIntegrityColumn("row_hash"))
+	require.NoError(t, repo.(Validator).Validate())
+
+	created, err := repo.Create(ctx, &integrityTestLedger{Account: "acme", Amount: 100, CreatedAt: time.Now()})
+	require.NoError(t, err)
+	expected, err := ComputeRecordHash(created, WithHashFields("id", "account", "amount", "memo", "created_at"))
+	require.NoError(t, err)
+	assert.Equal(t, expected, created.RowHash)
+
+	created.Memo = "settled"
+	updated, err := repo.Update(ctx, created)
+	require.NoError(t, err)
+	assert.NotEqual(t, expected, updated.RowHash)
+
+	other, err := repo.Create(ctx, &integrityTestLedger{Account: "globex", Amount: 7, CreatedAt: time.Now()})
+	require.NoError(t, err)
+
+	_, err = repo.GetByID(ctx, created.ID.String())
+	require.NoError(t, err)
+	_, _, err = repo.List(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, hookErrors())
+
+	verifier, ok := repo.(IntegrityVerifier)
+	require.True(t, ok)
+	tampered, err := verifier.VerifyIntegrity(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, tampered)
+
+	// modify the row out of band
+	_, err = bunDB.NewUpdate().Table("integrity_test_ledgers").
+		Set("amount = ?", 1_000_000).
+		Where("id = ?", other.ID).
+		Exec(ctx)
+	require.NoError(t, err)
+
+	tampered, err = verifier.VerifyIntegrity(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, []uuid.UUID{other.ID}, tampered)
+	assert.Empty(t, hookErrors(), "VerifyIntegrity does not report through the hook")
+
+	_, err = repo.GetByID(ctx, other.ID.String())
+	assert.True(t, stderrors.Is(err, ErrIntegrityViolation))
+	assert.Contains(t, err.Error(), other.ID.String())
+	_, _, err = repo.List(ctx)
+	assert.ErrorIs(t, err, ErrIntegrityViolation)
+	assert.Empty(t, hookErrors(), "reads return mismatches to the caller")
+
+	// partial selects are not verified
+	_, _, err = repo.List(ctx, SelectColumns("id", "account"))
+	require.NoError(t, err)
+}
+
+type integrityTestTask struct {
+	bun.BaseModel `bun:"table:integrity_test_tasks,alias:itt"`
+
+	ID        uuid.UUID `bun:"id,pk,notnull"`
+	Title     string    `bun:"title,notnull"`
+	Owner     string    `bun:"owner"`
+	Status    string    `bun:"status,notnull"`
+	Position  int       `bun:"position,notnull"`
+	RowHash   string    `bun:"row_hash"`
+	TouchedAt time.Time `bun:"touched_at"`
+	DeletedAt time.Time `bun:"deleted_at,soft_delete,nullzero"`
+}
+
+func TestIntegrityColumnRehashesEveryWriter(t *testing.T) {
+	ctx := context.Background()
+	bunDB, hookErrors := newIntegrityTestDB(t, (*integrityTestTask)(nil), (*ErasureAudit)(nil))
+	repo := NewRepositoryWithConfig(bunDB, testModelHandlers[*integrityTestTask](), nil, WithIntegrityColumn("row_hash"), WithAllowFullTableUpdate(true))
+	require.NoError(t, repo.(Validator).Validate())
+
+	var tasks []*integrityTestTask
+	for i, title := range []string{"plan", "build", "ship", "review"} {
+		task, err := repo.Create(ctx, &integrityTestTask{Title: title, Owner: "ada", Status: "open", Position: i})
+		require.NoError(t, err)
+		tasks = append(tasks, task)
+	}
+	ids := func(order ...int) []uuid.UUID {
+		out := make([]uuid.UUID, len(order))
+		for i, index := range order {
+			out[i] = tasks[index].ID
+		}
+		return out
+	}
+
+	tasks[0].Title = "plan v2"
+	_, err := repo.Update(ctx, tasks[0])
+	require.NoError(t, err)
+	_, err = repo.Update(ctx, &integrityTestTask{ID: tasks[1].ID, Owner: "grace"}, UpdateColumns("owner"))
+	require.NoError(t, err, "column restricted update with a partial record")
+	_, err = repo.Update(ctx, tasks[2], UpdateSetColumn("status", "blocked"))
+	require.NoError(t, err)
+	_, err = repo.(BulkUpdater).UpdateWhere(ctx, UpdateSetColumn("owner", "linus"), UpdateBy("status", "=", "open"))
+	require.NoError(t, err)
+	_, err = repo.(RecordToucher).TouchWhere(ctx, "touched_at", UpdateBy("owner", "=", "linus"))
+	require.NoError(t, err)
+
+	require.NoError(t, repo.(PositionReorderer).Reorder(ctx, ids(3, 2, 1, 0)))
+	require.NoError(t, repo.(PositionReorderer).MoveBefore(ctx, tasks[0].ID, tasks[3].ID))
+	require.NoError(t, repo.(PositionReorderer).MoveAfter(ctx, tasks[2].ID, tasks[1].ID))
+
+	_, err = repo.(RecordClaimer[*integrityTestTask]).ClaimOne(ctx,
+		[]SelectCriteria{SelectBy("status", "=", "open")}, UpdateSetColumn("status", "claimed"))
+	require.NoError(t, err)
+	_, err = repo.(RecordAnonymizer).Anonymize(ctx, []UpdateCriteria{UpdateByID(tasks[3].ID.String())},
+		map[string]Anonymizer{"owner": AnonymizeRedact("redacted")})
+	require.NoError(t, err)
+	_, err = repo.(ConflictUpserter[*integrityTestTask]).UpsertOnConflict(ctx,
+		&integrityTestTask{ID: tasks[3].ID, Title: "review v2"}, nil, []string{"title"})
+	require.NoError(t, err)
+
+	require.NoError(t, repo.Delete(ctx, tasks[0]))
+	require.NoError(t, repo.DeleteWhere(ctx, DeleteBy("title", "=", "ship")))
+
+	tampered, err := repo.(IntegrityVerifier).VerifyIntegrity(ctx, SelectDeletedAlso())
+	require.NoError(t, err)
+	assert.Empty(t, tampered)
+	_, _, err = repo.List(ctx, SelectDeletedAlso())
+	require.NoError(t, err)
+	assert.Empty(t, hookErrors())
+}
+
+func TestIntegrityColumnHashFields(t *testing.T) {
+	ctx := context.Background()
+	repo, bunDB, hookErrors := newIntegrityTestRepository(t,
+		WithIntegrityColumn("row_hash", WithHashFields("id", "account", "amount")),
+	)
+
+	created, err := repo.Create(ctx, &integrityTestLedger{Account: "acme", Amount: 100, CreatedAt: time.Now()})
+	require.NoError(t, err)
+
+	_, err = bunDB.NewUpdate().Table("integrity_test_ledgers").
+		Set("memo = ?", "not hashed").
+		Where("id = ?", created.ID).
+		Exec(ctx)
+	require.NoError(t, err)
+
+	tampered, err := repo.(IntegrityVerifier).VerifyIntegrity(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, tampered)
+
+	updated, err := repo.Update(ctx, &integrityTestLedger{ID: created.ID, Account: "acme", Amount: 200}, UpdateColumns("amount"))
+	require.NoError(t, err)
+	stored, err := repo.GetByID(ctx, created.ID.String())
+	require.NoError(t, err)
+	assert.Equal(t, updated.RowHash, stored.RowHash, "column restricted updates store the hash")
+	assert.Empty(t, hookErrors())
+}
+
+func TestIntegrityColumnValidate(t *testing.T) {
+	repo, _, _ := newIntegrityTestRepository(t, WithIntegrityColumn("missing"))
+	err := repo.(Validator).Validate()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "repository configuration invalid")
+
+	repo, _, _ = newIntegrityTestRepository(t, WithIntegrityColumn("amount"))
+	assert.Error(t, repo.(Validator).Validate())
+
+	repo, _, _ = newIntegrityTestRepository(t, WithIntegrityColumn("row_hash", WithHashFields("nope")))
+	assert.Error(t, repo.(Validator).Validate())
+}
+
+func TestIntegrityColumnVerifiesRepositoryReadsOnly(t *testing.T) {
+	ctx := context.Background()
+	repo, bunDB, hookErrors := newIntegrityTestRepository(t, WithIntegrityColumn("row_hash"))
+
+	created, err := repo.Create(ctx, &integrityTestLedger{Account: "acme", Amount: 100, CreatedAt: time.Now()})
+	require.NoError(t, err)
+	_, err = bunDB.NewUpdate().Table("integrity_test_ledgers").
+		Set("amount = ?", 1).
+		Where("id = ?", created.ID).
+		Exec(ctx)
+	require.NoError(t, err)
+
+	stored := &integrityTestLedger{}
+	require.NoError(t, bunDB.NewSelect().Model(stored).Where("id = ?", created.ID).Scan(ctx))
+	assert.Empty(t, hookErrors(), "queries outside the repository are not verified")
+
+	_, err = repo.GetByID(ctx, created.ID.String())
+	assert.ErrorIs(t, err, ErrIntegrityViolation)
+	assert.Empty(t, hookErrors())
+}
+
+func TestIntegrityColumnVerifiesUnhookedReads(t *testing.T) {
+	ctx := context.Background()
+	repo, bunDB, hookErrors := newIntegrityTestRepository(t, WithIntegrityColumn("row_hash"))
+
+	created, err := repo.Create(ctx, &integrityTestLedger{Account: "acme", Amount: 100, CreatedAt: time.Now()})
+	require.NoError(t, err)
+	_, err = bunDB.NewUpdate().Table("integrity_test_ledgers").
+		Set("amount = ?", 1).
+		Where("id = ?", created.ID).
+		Exec(ctx)
+	require.NoError(t, err)
+
+	prepared := NewRepositoryWithConfig(bunDB, testModelHandlers[*integrityTestLedger](), nil,
+		WithIntegrityColumn("row_hash"), WithPreparedStatements())
+	defer prepared.(PreparedStatementCache).ClosePreparedStatements()
+	for range 2 {
+		_, err = prepared.GetByID(ctx, created.ID.String())
+		assert.ErrorIs(t, err, ErrIntegrityViolation)
+	}
+
+	windowed := NewRepositoryWithConfig(bunDB, testModelHandlers[*integrityTestLedger](), nil,
+		WithIntegrityColumn("row_hash"), WithListWindowCount(true))
+	_, _, err = windowed.List(ctx)
+	assert.ErrorIs(t, err, ErrIntegrityViolation)
+	_, _, err = windowed.List(ctx, SelectColumns("id", "account"))
+	require.NoError(t, err)
+
+	assert.Empty(t, hookErrors())
+}
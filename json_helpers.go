@@ -1,18 +1,25 @@
 package repository
 
 import (
+	"encoding/json"
 	"fmt"
+	"regexp"
+	"sort"
+	"strings"
 
 	"github.com/uptrace/bun"
+	"github.com/uptrace/bun/dialect"
 )
 
+var jsonPathSegmentPattern = regexp.MustCompile(`^[A-Za-z0-9_\-]+$`)
+
 // WhereJSONContains applies a JSON containment check on the provided expression.
 // expr should be a JSON expression (e.g., metadata->'key' or json_extract(...)).
 func WhereJSONContains(expr string, value any) SelectCriteria {
 	return func(q *bun.SelectQuery) *bun.SelectQuery {
 		safeExpr, ok := normalizeJSONExpression(expr)
 		if !ok {
-			return q.Where("1=0")
+			return rejectSelect(q, criteriaInput{"expression", expr, ok})
 		}
 		return q.Where(fmt.Sprintf("%s @> ?", safeExpr), value)
 	}
@@ -20,16 +27,256 @@ func WhereJSONContains(expr string, value any) SelectCriteria {
 
 // OrderByJSONText orders by a text-extracting JSON expression.
 // expr should return text (e.g., metadata->>'key' or JSON_UNQUOTE(JSON_EXTRACT(...))).
+// An invalid direction falls back to ASC, or fails the query under
+// WithStrictCriteria.
 func OrderByJSONText(expr, direction string) SelectCriteria {
-	safeDirection, ok := normalizeOrderDirection(direction)
-	if !ok {
+	safeDirection, directionOK := normalizeOrderDirection(direction)
+	if !directionOK {
 		safeDirection = "ASC"
+		directionOK = strings.TrimSpace(direction) == ""
 	}
 	return func(q *bun.SelectQuery) *bun.SelectQuery {
 		safeExpr, ok := normalizeJSONExpression(expr)
 		if !ok {
-			return q
+			return skipSelect(q, criteriaInput{"expression", expr, ok}, criteriaInput{"direction", direction, directionOK})
+		}
+		if !directionOK && strictCriteria(q) {
+			return q.Err(invalidCriteriaError([]criteriaInput{{"direction", direction, directionOK}}))
 		}
 		return q.Order(fmt.Sprintf("%s %s", safeExpr, safeDirection))
 	}
 }
+
+// WhereJSONKeyEquals matches rows where the value at path inside the JSON column
+// equals value. path uses dot notation ("settings.theme", "tags.0"); numeric
+// segments address array elements. The predicate is rendered for the query
+// dialect: #>> on Postgres, json_extract on SQLite and JSON_EXTRACT on MySQL.
+// Postgres and MySQL compare the extracted text against the textual form of value;
+// a nil value matches missing or null keys.
+func WhereJSONKeyEquals(column, path string, value any) SelectCriteria {
+	return func(q *bun.SelectQuery) *bun.SelectQuery {
+		col, colOK := normalizeSQLIdentifier(column)
+		segments, pathOK := parseJSONPath(path)
+		if !colOK || !pathOK {
+			return rejectSelect(q, criteriaInput{"column", column, colOK}, criteriaInput{"path", path, pathOK})
+		}
+
+		name := q.Dialect().Name()
+		expr, ok := jsonTextExpr(name, "?TableAlias."+col, segments)
+		if !ok {
+			return rejectSelect(q, criteriaInput{"dialect", name.String(), ok})
+		}
+		if value == nil {
+			return q.Where(fmt.Sprintf("%s IS NULL", expr))
+		}
+		if name == dialect.SQLite {
+			return q.Where(fmt.Sprintf("%s = ?", expr), jsonSQLiteValue(value))
+		}
+		return q.Where(fmt.Sprintf("%s = ?", expr), jsonTextValue(value))
+	}
+}
+
+// WhereJSONHasKey matches rows where path exists inside the JSON column.
+func WhereJSONHasKey(column, path string) SelectCriteria {
+	return func(q *bun.SelectQuery) *bun.SelectQuery {
+		col, colOK := normalizeSQLIdentifier(column)
+		segments, pathOK := parseJSONPath(path)
+		if !colOK || !pathOK {
+			return rejectSelect(q, criteriaInput{"column", column, colOK}, criteriaInput{"path", path, pathOK})
+		}
+
+		target := "?TableAlias." + col
+		switch name := q.Dialect().Name(); name {
+		case dialect.PG:
+			return q.Where(fmt.Sprintf("%s #> '%s' IS NOT NULL", target, postgresJSONPath(segments)))
+		case dialect.SQLite:
+			return q.Where(fmt.Sprintf("json_type(%s, '%s') IS NOT NULL", target, standardJSONPath(segments)))
+		case dialect.MySQL:
+			return q.Where(fmt.Sprintf("JSON_CONTAINS_PATH(%s, 'one', '%s')", target, standardJSONPath(segments)))
+		default:
+			return rejectSelect(q, criteriaInput{"dialect", name.String(), false})
+		}
+	}
+}
+
+// OrderByJSONKey orders by the text value at path inside the JSON column.
+func OrderByJSONKey(column, path, direction string) SelectCriteria {
+	safeDirection, directionOK := normalizeOrderDirection(direction)
+	if !directionOK {
+		safeDirection = "ASC"
+		directionOK = strings.TrimSpace(direction) == ""
+	}
+	return func(q *bun.SelectQuery) *bun.SelectQuery {
+		col, colOK := normalizeSQLIdentifier(column)
+		segments, pathOK := parseJSONPath(path)
+		if !colOK || !pathOK || !directionOK {
+			return skipSelect(q,
+				criteriaInput{"column", column, colOK},
+				criteriaInput{"path", path, pathOK},
+				criteriaInput{"direction", direction, directionOK},
+			)
+		}
+		name := q.Dialect().Name()
+		expr, ok := jsonTextExpr(name, "?TableAlias."+col, segments)
+		if !ok {
+			return skipSelect(q, criteriaInput{"dialect", name.String(), ok})
+		}
+		return q.OrderExpr(fmt.Sprintf("%s %s", expr, safeDirection))
+	}
+}
+
+func jsonContainsCriteria(q *bun.SelectQuery, column string, value any) *bun.SelectQuery {
+	target := "?TableAlias." + column
+	invalidValue := criteriaInput{"value", fmt.Sprint(value), false}
+	switch q.Dialect().Name() {
+	case dialect.MySQL:
+		encoded, err := json.Marshal(value)
+		if err != nil {
+			return rejectSelect(q, invalidValue)
+		}
+		return q.Where(fmt.Sprintf("JSON_CONTAINS(%s, ?)", target), string(encoded))
+	case dialect.SQLite:
+		normalized, err := normalizeJSONValue(value)
+		if err != nil {
+			return rejectSelect(q, invalidValue)
+		}
+		expr, args, ok := sqliteJSONContainsExpr(target, nil, normalized)
+		if !ok {
+			return rejectSelect(q, invalidValue)
+		}
+		return q.Where(expr, args...)
+	default:
+		return q.Where(fmt.Sprintf("%s @> ?", target), value)
+	}
+}
+
+// sqliteJSONContainsExpr emulates Postgres' @> on SQLite: objects match when
+// every key is contained, arrays when every element is present, and scalars
+// by equality.
+func sqliteJSONContainsExpr(target string, segments []string, value any) (string, []any, bool) {
+	switch v := value.(type) {
+	case map[string]any:
+		keys := make([]string, 0, len(v))
+		for key := range v {
+			if !jsonPathSegmentPattern.MatchString(key) {
+				return "", nil, false
+			}
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+
+		parts := []string{fmt.Sprintf("json_type(%s, '%s') = 'object'", target, standardJSONPath(segments))}
+		var args []any
+		for _, key := range keys {
+			expr, nested, ok := sqliteJSONContainsExpr(target, append(append([]string{}, segments...), key), v[key])
+			if !ok {
+				return "", nil, false
+			}
+			parts = append(parts, expr)
+			args = append(args, nested...)
+		}
+		return "(" + strings.Join(parts, " AND ") + ")", args, true
+	case []any:
+		path := standardJSONPath(segments)
+		parts := []string{fmt.Sprintf("json_type(%s, '%s') = 'array'", target, path)}
+		var args []any
+		for _, item := range v {
+			switch item.(type) {
+			case map[string]any, []any:
+				encoded, err := json.Marshal(item)
+				if err != nil {
+					return "", nil, false
+				}
+				parts = append(parts, fmt.Sprintf("EXISTS (SELECT 1 FROM json_each(%s, '%s') AS je WHERE je.value = json(?))", target, path))
+				args = append(args, string(encoded))
+			default:
+				parts = append(parts, fmt.Sprintf("EXISTS (SELECT 1 FROM json_each(%s, '%s') AS je WHERE je.value = ?)", target, path))
+				args = append(args, jsonSQLiteValue(item))
+			}
+		}
+		return "(" + strings.Join(parts, " AND ") + ")", args, true
+	case nil:
+		return fmt.Sprintf("json_type(%s, '%s') = 'null'", target, standardJSONPath(segments)), nil, true
+	default:
+		return fmt.Sprintf("json_extract(%s, '%s') = ?", target, standardJSONPath(segments)), []any{jsonSQLiteValue(v)}, true
+	}
+}
+
+func jsonTextExpr(name dialect.Name, target string, segments []string) (string, bool) {
+	switch name {
+	case dialect.PG:
+		return fmt.Sprintf("%s #>> '%s'", target, postgresJSONPath(segments)), true
+	case dialect.SQLite:
+		return fmt.Sprintf("json_extract(%s, '%s')", target, standardJSONPath(segments)), true
+	case dialect.MySQL:
+		return fmt.Sprintf("JSON_UNQUOTE(JSON_EXTRACT(%s, '%s'))", target, standardJSONPath(segments)), true
+	default:
+		return "", false
+	}
+}
+
+func parseJSONPath(path string) ([]string, bool) {
+	path = strings.TrimSpace(path)
+	if path == "" {
+		return nil, false
+	}
+	segments := strings.Split(path, ".")
+	for _, segment := range segments {
+		if !jsonPathSegmentPattern.MatchString(segment) {
+			return nil, false
+		}
+	}
+	return segments, true
+}
+
+func postgresJSONPath(segments []string) string {
+	return "{" + strings.Join(segments, ",") + "}"
+}
+
+func standardJSONPath(segments []string) string {
+	var b strings.Builder
+	b.WriteString("$")
+	for _, segment := range segments {
+		if isJSONArrayIndex(segment) {
+			b.WriteString("[" + segment + "]")
+			continue
+		}
+		b.WriteString(`."` + segment + `"`)
+	}
+	return b.String()
+}
+
+func isJSONArrayIndex(segment string) bool {
+	for _, r := range segment {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return segment != ""
+}
+
+func normalizeJSONValue(value any) (any, error) {
+	encoded, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+	var normalized any
+	if err := json.Unmarshal(encoded, &normalized); err != nil {
+		return nil, err
+	}
+	return normalized, nil
+}
+
+func jsonSQLiteValue(value any) any {
+	if b, ok := value.(bool); ok {
+		if b {
+			return 1
+		}
+		return 0
+	}
+	return value
+}
+
+func jsonTextValue(value any) string {
+	return fmt.Sprint(value)
+}
@@ -0,0 +1,123 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/uptrace/bun"
+	"github.com/uptrace/bun/dialect/mysqldialect"
+	"github.com/uptrace/bun/dialect/pgdialect"
+	"github.com/uptrace/bun/schema"
+)
+
+type jsonHelperDoc struct {
+	bun.BaseModel `bun:"table:json_helper_docs,alias:jd"`
+
+	ID       int64          `bun:"id,pk,autoincrement"`
+	Metadata map[string]any `bun:"metadata,type:json"`
+}
+
+func newDialectTestDB(t *testing.T, d schema.Dialect) *bun.DB {
+	t.Helper()
+	sqldb, err := sql.Open("sqlite3", ":memory:")
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		require.NoError(t, sqldb.Close())
+	})
+	return bun.NewDB(sqldb, d)
+}
+
+func setupJSONHelperDocs(t *testing.T) {
+	t.Helper()
+	recreateTestTable(t, (*jsonHelperDoc)(nil))
+
+	docs := []*jsonHelperDoc{
+		{Metadata: map[string]any{"settings": map[string]any{"theme": "dark"}, "tags": []any{"a", "b"}, "active": true}},
+		{Metadata: map[string]any{"settings": map[string]any{"theme": "light"}, "tags": []any{"b"}, "active": false}},
+	}
+	_, err := db.NewInsert().Model(&docs).Exec(context.Background())
+	require.NoError(t, err)
+}
+
+func TestWhereJSONKeyEquals_SQLite(t *testing.T) {
+	setupJSONHelperDocs(t)
+
+	var docs []jsonHelperDoc
+	err := db.NewSelect().Model(&docs).
+		Apply(WhereJSONKeyEquals("metadata", "settings.theme", "dark")).
+		Scan(context.Background())
+	require.NoError(t, err)
+	require.Len(t, docs, 1)
+	assert.Equal(t, "dark", docs[0].Metadata["settings"].(map[string]any)["theme"])
+
+	docs = nil
+	err = db.NewSelect().Model(&docs).
+		Apply(WhereJSONKeyEquals("metadata", "active", false)).
+		Scan(context.Background())
+	require.NoError(t, err)
+	assert.Len(t, docs, 1)
+}
+
+func TestSelectJSONContains_SQLite(t *testing.T) {
+	setupJSONHelperDocs(t)
+
+	var docs []jsonHelperDoc
+	err := db.NewSelect().Model(&docs).
+		Apply(SelectJSONContains("metadata", map[string]any{"tags": []string{"a"}})).
+		Scan(context.Background())
+	require.NoError(t, err)
+	assert.Len(t, docs, 1)
+
+	docs = nil
+	err = db.NewSelect().Model(&docs).
+		Apply(SelectJSONContains("metadata", map[string]any{"tags": []string{"b"}, "settings": map[string]any{"theme": "light"}})).
+		Scan(context.Background())
+	require.NoError(t, err)
+	assert.Len(t, docs, 1)
+}
+
+func TestWhereJSONHasKey_SQLite(t *testing.T) {
+	setupJSONHelperDocs(t)
+
+	count, err := db.NewSelect().Model((*jsonHelperDoc)(nil)).
+		Apply(WhereJSONHasKey("metadata", "settings.theme")).
+		Count(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 2, count)
+
+	count, err = db.NewSelect().Model((*jsonHelperDoc)(nil)).
+		Apply(WhereJSONHasKey("metadata", "settings.missing")).
+		Count(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 0, count)
+}
+
+func TestJSONHelpers_DialectRendering(t *testing.T) {
+	pgDB := newDialectTestDB(t, pgdialect.New())
+	mysqlDB := newDialectTestDB(t, mysqldialect.New())
+
+	pgSQL := pgDB.NewSelect().Model((*jsonHelperDoc)(nil)).
+		Apply(WhereJSONKeyEquals("metadata", "settings.theme", "dark")).
+		Apply(SelectJSONContains("metadata", map[string]any{"active": true})).
+		String()
+	assert.Contains(t, pgSQL, `"jd".metadata #>> '{settings,theme}' = 'dark'`)
+	assert.Contains(t, pgSQL, `"jd".metadata @> `)
+
+	mysqlSQL := mysqlDB.NewSelect().Model((*jsonHelperDoc)(nil)).
+		Apply(WhereJSONKeyEquals("metadata", "tags.0", "a")).
+		Apply(SelectJSONContains("metadata", map[string]any{"active": true})).
+		String()
+	assert.Contains(t, mysqlSQL, "JSON_UNQUOTE(JSON_EXTRACT(`jd`.metadata, '$.\"tags\"[0]')) = 'a'")
+	assert.Contains(t, mysqlSQL, "JSON_CONTAINS(`jd`.metadata, '{\"active\":true}')")
+}
+
+func TestJSONHelpers_InvalidPathFailsClosed(t *testing.T) {
+	sql := db.NewSelect().Model((*jsonHelperDoc)(nil)).
+		Apply(WhereJSONKeyEquals("metadata", "settings'); DROP TABLE x; --", "dark")).
+		String()
+	assert.Contains(t, sql, "1=0")
+}
@@ -0,0 +1,71 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/uptrace/bun"
+)
+
+// defaultListAllBatchSize is how many rows ListAll fetches per query unless
+// configured with WithListAllBatchSize.
+const defaultListAllBatchSize = 1000
+
+// AllLister is an optional capability for repositories that can return every
+// record matching criteria, e.g. for batch jobs.
+type AllLister[T any] interface {
+	ListAll(ctx context.Context, criteria ...SelectCriteria) ([]T, error)
+	ListAllTx(ctx context.Context, tx bun.IDB, criteria ...SelectCriteria) ([]T, error)
+}
+
+// WithListAllBatchSize sets how many rows ListAll fetches per query.
+// Defaults to 1000.
+func WithListAllBatchSize(size int) RepoOption {
+	return func(cfg *repoConfig) {
+		if cfg == nil || size <= 0 {
+			return
+		}
+		cfg.listAllBatchSize = size
+	}
+}
+
+// ListAll returns every record matching criteria, paging through them in
+// batches of WithListAllBatchSize rows instead of stopping at the List
+// default limit. Pages are built like List pages, with scopes, default
+// relations and default order, and are ordered by primary key when neither
+// criteria nor the default order sort them; pagination criteria are
+// overridden. Run it in a transaction, with ListAllTx, to read a consistent
+// snapshot while rows are being written.
+func (r *repo[T]) ListAll(ctx context.Context, criteria ...SelectCriteria) ([]T, error) {
+	return r.ListAllTx(ctx, r.db, criteria...)
+}
+
+func (r *repo[T]) ListAllTx(ctx context.Context, tx bun.IDB, criteria ...SelectCriteria) ([]T, error) {
+	ctx = r.withOperation(ctx, "ListAll", len(criteria))
+	batchSize := r.listAllBatchSize
+	if batchSize <= 0 {
+		batchSize = defaultListAllBatchSize
+	}
+
+	all := []T{}
+	for offset := 0; ; offset += batchSize {
+		records := []T{}
+		q, err := r.listQuery(ctx, tx, &records, criteria)
+		if err != nil {
+			return nil, err
+		}
+		if !selectHasOrder(q) {
+			if table := r.modelTable(); table != nil {
+				for _, pk := range table.PKs {
+					q.OrderExpr("?TableAlias.? ASC", bun.Ident(pk.Name))
+				}
+			}
+		}
+		if err := r.scanRecords(ctx, q.Limit(batchSize).Offset(offset).Scan); err != nil {
+			return nil, err
+		}
+		all = append(all, records...)
+		if len(records) < batchSize {
+			return all, nil
+		}
+	}
+}
@@ -0,0 +1,51 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRepository_ListAll(t *testing.T) {
+	setupTestData(t)
+	ctx := context.Background()
+
+	users := make([]*TestUser, 0, 30)
+	for i := range 30 {
+		users = append(users, &TestUser{
+			Name:      fmt.Sprintf("user-%02d", i),
+			Email:     fmt.Sprintf("user-%02d@example.com", i),
+			CompanyID: uuid.New(),
+		})
+	}
+	legacy := newTestUserRepository(db)
+	_, err := legacy.CreateMany(ctx, users)
+	require.NoError(t, err)
+
+	listed, _, err := legacy.List(ctx)
+	require.NoError(t, err)
+	require.Len(t, listed, 25, "List stops at the legacy default limit")
+
+	all, err := legacy.(AllLister[*TestUser]).ListAll(ctx)
+	require.NoError(t, err)
+	assert.Len(t, all, 30)
+
+	batched := newTestUserRepositoryWithConfig(db, nil, WithListAllBatchSize(4))
+	request := WithStatsRecorder(ctx)
+	all, err = batched.(AllLister[*TestUser]).ListAll(request,
+		SelectBy("name", "LIKE", "user-1%"),
+		OrderBy("name DESC"),
+		SelectPaginate(1, 0),
+	)
+	require.NoError(t, err)
+	require.Len(t, all, 10)
+	assert.Equal(t, "user-19", all[0].Name)
+	assert.Equal(t, "user-10", all[9].Name)
+
+	stats, _ := StatsFromContext(request)
+	assert.Equal(t, 3, stats.Queries, "10 rows in batches of 4")
+}
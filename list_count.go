@@ -0,0 +1,81 @@
+package repository
+
+import (
+	"context"
+	"sync"
+
+	"github.com/uptrace/bun"
+)
+
+// NoCountLister is an optional capability for repositories that can list a
+// page without counting every matching row, e.g. for infinite scrolling.
+type NoCountLister[T any] interface {
+	ListNoCount(ctx context.Context, criteria ...SelectCriteria) ([]T, error)
+	ListNoCountTx(ctx context.Context, tx bun.IDB, criteria ...SelectCriteria) ([]T, error)
+}
+
+// WithParallelCount makes List run its count query concurrently with the
+// page query when listing in a transaction or on a bun.Conn, where both
+// would otherwise run one after the other on the single connection. The
+// count runs on a second pooled connection of the repository bun.DB, outside
+// the transaction, so it does not see the uncommitted writes of tx, and
+// needs a pool of at least two connections. Lists on the bun.DB itself
+// already count concurrently.
+func WithParallelCount() RepoOption {
+	return func(cfg *repoConfig) {
+		if cfg != nil {
+			cfg.parallelCount = true
+		}
+	}
+}
+
+// ListNoCount returns the records List would return, skipping the count query.
+func (r *repo[T]) ListNoCount(ctx context.Context, criteria ...SelectCriteria) ([]T, error) {
+	return r.ListNoCountTx(ctx, r.db, criteria...)
+}
+
+func (r *repo[T]) ListNoCountTx(ctx context.Context, tx bun.IDB, criteria ...SelectCriteria) ([]T, error) {
+	ctx = r.withOperation(ctx, "ListNoCount", len(criteria))
+	records := []T{}
+
+	q, err := r.listQuery(ctx, tx, &records, criteria)
+	if err != nil {
+		return nil, err
+	}
+	if err := r.scanRecords(ctx, q.Scan); err != nil {
+		return nil, err
+	}
+	return records, nil
+}
+
+func (r *repo[T]) useParallelCount(tx bun.IDB) bool {
+	if !r.parallelCount || r.db == nil {
+		return false
+	}
+	_, onDB := tx.(*bun.DB)
+	return !onDB
+}
+
+// scanAndCountParallel is q.ScanAndCount with the count query run on the
+// repository bun.DB, concurrently with the scan on the connection of q.
+func (r *repo[T]) scanAndCountParallel(ctx context.Context, q *bun.SelectQuery) (int, error) {
+	countQuery := q.Clone().Conn(r.db)
+
+	var (
+		wg       sync.WaitGroup
+		total    int
+		countErr error
+	)
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		total, countErr = countQuery.Count(ctx)
+	}()
+	scanErr := q.Scan(ctx)
+	wg.Wait()
+
+	if scanErr != nil {
+		return total, scanErr
+	}
+	return total, countErr
+}
@@ -0,0 +1,66 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/uptrace/bun"
+	"github.com/uptrace/bun/dialect/sqlitedialect"
+)
+
+func TestRepository_ListNoCount(t *testing.T) {
+	setupTestData(t)
+	ctx := context.Background()
+	repo := newTestUserRepositoryWithConfig(db, nil, WithDefaultListPagination(2, 0))
+
+	for _, name := range []string{"Alice", "Bob", "Carol"} {
+		_, err := repo.Create(ctx, &TestUser{Name: name, Email: name + "@example.com", CompanyID: uuid.New()})
+		require.NoError(t, err)
+	}
+
+	request := WithStatsRecorder(ctx)
+	users, err := repo.(NoCountLister[*TestUser]).ListNoCount(request, OrderBy("name DESC"))
+	require.NoError(t, err)
+	require.Len(t, users, 2)
+	assert.Equal(t, "Carol", users[0].Name)
+
+	stats, _ := StatsFromContext(request)
+	assert.Equal(t, 1, stats.Queries, "no count query")
+}
+
+func TestRepository_WithParallelCount(t *testing.T) {
+	ctx := context.Background()
+	sqldb, err := sql.Open("sqlite3", filepath.Join(t.TempDir(), "parallel.db"))
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = sqldb.Close() })
+	testDB := bun.NewDB(sqldb, sqlitedialect.New())
+	_, err = testDB.NewCreateTable().Model((*TestUser)(nil)).Exec(ctx)
+	require.NoError(t, err)
+
+	repo := newTestUserRepositoryWithConfig(testDB, nil, WithParallelCount(), WithDefaultListPagination(1, 0))
+	for _, name := range []string{"Alice", "Bob", "Carol"} {
+		_, err := repo.Create(ctx, &TestUser{Name: name, Email: name + "@example.com", CompanyID: uuid.New()})
+		require.NoError(t, err)
+	}
+
+	tx, err := testDB.BeginTx(ctx, nil)
+	require.NoError(t, err)
+	defer func() { _ = tx.Rollback() }()
+
+	users, total, err := repo.ListTx(ctx, tx, OrderBy("name ASC"))
+	require.NoError(t, err)
+	require.Len(t, users, 1)
+	assert.Equal(t, "Alice", users[0].Name)
+	assert.Equal(t, 3, total)
+
+	_, err = repo.CreateTx(ctx, tx, &TestUser{Name: "Dave", Email: "dave@example.com", CompanyID: uuid.New()})
+	require.NoError(t, err)
+	_, total, err = repo.ListTx(ctx, tx)
+	require.NoError(t, err)
+	assert.Equal(t, 3, total, "the count runs outside the transaction")
+}
@@ -0,0 +1,149 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"reflect"
+
+	"github.com/uptrace/bun"
+	"github.com/uptrace/bun/schema"
+)
+
+// listWindowTotalColumn holds the COUNT(*) OVER() total. bun discards scanned
+// columns starting with an underscore, so it never reaches the model.
+const listWindowTotalColumn = "_repository_total"
+
+var listWindowCountDrivers = map[string]bool{
+	"postgres": true,
+	"sqlite":   true,
+	"mssql":    true,
+	"mysql":    true,
+}
+
+// rowScanner is implemented by bun struct models.
+type rowScanner interface {
+	ScanRow(ctx context.Context, rows *sql.Rows) error
+}
+
+func (r *repo[T]) useListWindowCount(q *bun.SelectQuery) bool {
+	return r.listWindowCount && listWindowCountDrivers[r.driver] && !hasToManyJoins(q.GetModel())
+}
+
+// listWithWindowCount runs q with a COUNT(*) OVER() column and scans every row
+// twice: once for the total and once into a record. Records are scanned by a
+// struct model built like q, so inline relations are loaded the same way.
+func (r *repo[T]) listWithWindowCount(ctx context.Context, tx bun.IDB, q *bun.SelectQuery, records []T, criteria []SelectCriteria) ([]T, int, error) {
+	verify := r.integrity != nil && r.integrity.selectedBy(q)
+	if !selectHasColumns(q) {
+		q.ColumnExpr("?TableColumns")
+	}
+	q.ColumnExpr("COUNT(*) OVER() AS ?", bun.Ident(listWindowTotalColumn))
+
+	rows, err := q.Rows(ctx)
+	if err != nil {
+		return nil, 0, r.mapError(err)
+	}
+	defer rows.Close()
+
+	columns, err := rows.Columns()
+	if err != nil {
+		return nil, 0, r.mapError(err)
+	}
+
+	total := -1
+	for rows.Next() {
+		if total < 0 {
+			if total, err = scanListWindowTotal(rows, columns); err != nil {
+				return nil, 0, r.mapError(err)
+			}
+		}
+
+		record := r.handlers.NewRecord()
+		rq, err := r.listQuery(ctx, tx, record, criteria)
+		if err != nil {
+			return nil, 0, err
+		}
+		scanner, ok := rq.GetModel().(rowScanner)
+		if !ok {
+			return nil, 0, fmt.Errorf("repository: %T cannot scan rows", rq.GetModel())
+		}
+		if err := scanner.ScanRow(ctx, rows); err != nil {
+			return nil, 0, r.mapError(err)
+		}
+		records = append(records, record)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, r.mapError(err)
+	}
+	// ScanRow bypasses the query hooks, so the records are still encrypted
+	// and unverified
+	if verify {
+		err = r.verifyRecords(records...)
+	} else {
+		err = r.decryptRecords(records...)
+	}
+	if err != nil {
+		return nil, 0, err
+	}
+
+	if total < 0 {
+		// an empty page carries no total, e.g. an offset past the end
+		if total, err = q.Count(ctx); err != nil {
+			return nil, 0, r.mapError(err)
+		}
+	}
+
+	return records, total, nil
+}
+
+func scanListWindowTotal(rows *sql.Rows, columns []string) (int, error) {
+	var total int64
+	dest := make([]any, len(columns))
+	for i, column := range columns {
+		if column == listWindowTotalColumn {
+			dest[i] = &total
+			continue
+		}
+		dest[i] = new(any)
+	}
+	if err := rows.Scan(dest...); err != nil {
+		return 0, err
+	}
+	return int(total), nil
+}
+
+// hasToManyJoins reports whether model loads has-many or many-to-many
+// relations. Those run as extra queries after the main scan, which the single
+// statement window count path does not do.
+func hasToManyJoins(model any) bool {
+	value := reflect.ValueOf(model)
+	for value.Kind() == reflect.Interface || value.Kind() == reflect.Pointer {
+		if value.IsNil() {
+			return false
+		}
+		value = value.Elem()
+	}
+	if value.Kind() != reflect.Struct {
+		return false
+	}
+
+	joins := value.FieldByName("joins")
+	if !joins.IsValid() || joins.Kind() != reflect.Slice {
+		return false
+	}
+	for i := 0; i < joins.Len(); i++ {
+		join := joins.Index(i)
+		relation := join.FieldByName("Relation")
+		if relation.IsValid() && relation.Kind() == reflect.Pointer && !relation.IsNil() {
+			switch relation.Elem().FieldByName("Type").Int() {
+			case schema.HasManyRelation, schema.ManyToManyRelation:
+				return true
+			}
+		}
+		if hasToManyJoins(join.FieldByName("JoinModel")) {
+			return true
+		}
+	}
+	return false
+}
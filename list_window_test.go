@@ -0,0 +1,102 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/uptrace/bun"
+	"github.com/uptrace/bun/dialect/sqlitedialect"
+)
+
+type windowTestCompany struct {
+	bun.BaseModel `bun:"table:test_companies,alias:c"`
+
+	ID         uuid.UUID   `bun:"id,pk,notnull"`
+	Name       string      `bun:"name,notnull"`
+	Identifier string      `bun:"identifier,notnull"`
+	Users      []*TestUser `bun:"rel:has-many,join:id=company_id"`
+
+	CreatedAt time.Time `bun:"created_at,notnull"`
+	UpdatedAt time.Time `bun:"updated_at,notnull"`
+}
+
+func TestRepository_ListWindowCount(t *testing.T) {
+	ctx := context.Background()
+	testDB := newDialectTestDB(t, sqlitedialect.New())
+	_, err := testDB.NewCreateTable().Model((*TestUser)(nil)).Exec(ctx)
+	require.NoError(t, err)
+
+	hook := &countHook{}
+	repo := newTestUserRepositoryWithConfig(testDB, []Option{WithQueryHooks(hook)}, WithListWindowCount(true))
+	plain := newTestUserRepository(testDB)
+
+	for i := 1; i <= 12; i++ {
+		_, err := plain.Create(ctx, &TestUser{Name: fmt.Sprintf("User %02d", i), Email: fmt.Sprintf("user%02d@example.com", i)})
+		require.NoError(t, err)
+	}
+	before := hook.before
+
+	users, total, err := repo.List(ctx, SelectPaginate(5, 5), OrderBy("name ASC"))
+	require.NoError(t, err)
+	assert.Equal(t, 12, total)
+	require.Len(t, users, 5)
+	assert.Equal(t, "User 06", users[0].Name)
+	assert.Equal(t, "user10@example.com", users[4].Email)
+	assert.NotEqual(t, uuid.Nil, users[0].ID)
+	assert.EqualValues(t, 1, hook.before-before, "rows and total come from a single statement")
+
+	expected, expectedTotal, err := plain.List(ctx, SelectPaginate(5, 5), OrderBy("name ASC"))
+	require.NoError(t, err)
+	assert.Equal(t, expectedTotal, total)
+	assert.Equal(t, expected, users)
+
+	users, total, err = repo.List(ctx, SelectColumns("id", "name"), SelectBy("name", "LIKE", "User 1%"))
+	require.NoError(t, err)
+	assert.Equal(t, 3, total)
+	require.Len(t, users, 3)
+	assert.Empty(t, users[0].Email)
+
+	// empty pages fall back to a count query
+	users, total, err = repo.List(ctx, SelectPaginate(5, 50))
+	require.NoError(t, err)
+	assert.Empty(t, users)
+	assert.Equal(t, 12, total)
+}
+
+func TestHasToManyJoins(t *testing.T) {
+	q := db.NewSelect().Model(&[]*windowTestCompany{})
+	assert.False(t, hasToManyJoins(q.GetModel()))
+
+	q = q.Relation("Users")
+	assert.True(t, hasToManyJoins(q.GetModel()))
+
+	q = db.NewSelect().Model(&[]*relationTestUser{}).Relation("Company")
+	assert.False(t, hasToManyJoins(q.GetModel()))
+}
+
+func TestRepository_ListWindowCountWithRelation(t *testing.T) {
+	setupTestData(t)
+	ctx := context.Background()
+
+	company, err := newTestCompanyRepository(db).Create(ctx, &TestCompany{Name: "Acme", Identifier: "acme"})
+	require.NoError(t, err)
+
+	repo := newRelationTestUserRepository(db, WithDefaultRelations("Company"), WithListWindowCount(true))
+	for _, name := range []string{"alice", "bob"} {
+		_, err := repo.Create(ctx, &relationTestUser{Name: name, Email: name + "@example.com", CompanyID: company.ID})
+		require.NoError(t, err)
+	}
+
+	records, total, err := repo.List(ctx, SelectPaginate(1, 0), OrderBy("u.name ASC"))
+	require.NoError(t, err)
+	assert.Equal(t, 2, total)
+	require.Len(t, records, 1)
+	assert.Equal(t, "alice", records[0].Name)
+	require.NotNil(t, records[0].Company)
+	assert.Equal(t, "Acme", records[0].Company.Name)
+}
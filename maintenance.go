@@ -0,0 +1,94 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/goliatone/go-errors"
+	"github.com/uptrace/bun"
+)
+
+// MaintenanceOp is a table maintenance operation run by Maintain.
+type MaintenanceOp string
+
+const (
+	// MaintenanceAnalyze refreshes planner statistics
+	// (ANALYZE on Postgres and SQLite, ANALYZE TABLE on MySQL, UPDATE STATISTICS on MSSQL).
+	MaintenanceAnalyze MaintenanceOp = "analyze"
+	// MaintenanceVacuum reclaims storage (VACUUM on Postgres; SQLite vacuums the whole database).
+	MaintenanceVacuum MaintenanceOp = "vacuum"
+	// MaintenanceReindex rebuilds the table indexes
+	// (REINDEX on Postgres and SQLite, ALTER INDEX ALL ... REBUILD on MSSQL).
+	MaintenanceReindex MaintenanceOp = "reindex"
+	// MaintenanceOptimize defragments the table (OPTIMIZE TABLE on MySQL, PRAGMA optimize on SQLite).
+	MaintenanceOptimize MaintenanceOp = "optimize"
+)
+
+// TableMaintainer is an optional capability for repositories that can run
+// maintenance operations against their table.
+type TableMaintainer interface {
+	Maintain(ctx context.Context, ops ...MaintenanceOp) error
+}
+
+var maintenanceStatements = map[string]map[MaintenanceOp]string{
+	"postgres": {
+		MaintenanceAnalyze: "ANALYZE ?",
+		MaintenanceVacuum:  "VACUUM ?",
+		MaintenanceReindex: "REINDEX TABLE ?",
+	},
+	"mysql": {
+		MaintenanceAnalyze:  "ANALYZE TABLE ?",
+		MaintenanceOptimize: "OPTIMIZE TABLE ?",
+	},
+	"sqlite": {
+		MaintenanceAnalyze:  "ANALYZE ?",
+		MaintenanceVacuum:   "VACUUM",
+		MaintenanceReindex:  "REINDEX ?",
+		MaintenanceOptimize: "PRAGMA optimize",
+	},
+	"mssql": {
+		MaintenanceAnalyze: "UPDATE STATISTICS ?",
+		MaintenanceReindex: "ALTER INDEX ALL ON ? REBUILD",
+	},
+}
+
+// Maintain runs ops, in order, against the repository table. It defaults to
+// MaintenanceAnalyze when no op is given. Operations the database does not
+// support fail with a validation error before anything runs.
+// Maintain always uses the repository connection since VACUUM cannot run
+// inside a transaction.
+func (r *repo[T]) Maintain(ctx context.Context, ops ...MaintenanceOp) error {
+	if len(ops) == 0 {
+		ops = []MaintenanceOp{MaintenanceAnalyze}
+	}
+
+	statements := make([]string, 0, len(ops))
+	var validationErrors errors.ValidationErrors
+	for _, op := range ops {
+		statement, ok := maintenanceStatements[r.driver][op]
+		if !ok {
+			validationErrors = append(validationErrors, errors.FieldError{
+				Field:   "ops",
+				Message: fmt.Sprintf("maintenance operation %q is not supported for %s", op, r.driver),
+			})
+			continue
+		}
+		statements = append(statements, statement)
+	}
+	if len(validationErrors) > 0 {
+		return errors.NewValidation("repository: unsupported maintenance operation", validationErrors...)
+	}
+
+	table := bun.Ident(r.TableName())
+	for _, statement := range statements {
+		var args []any
+		if strings.Contains(statement, "?") {
+			args = append(args, table)
+		}
+		if _, err := r.db.NewRaw(statement, args...).Exec(ctx); err != nil {
+			return r.mapError(err)
+		}
+	}
+	return nil
+}
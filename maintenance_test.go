@@ -0,0 +1,46 @@
+package repository
+
+import (
+	"context"
+	"testing"
+
+	goerrors "github.com/goliatone/go-errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRepository_Maintain(t *testing.T) {
+	setupTestData(t)
+	ctx := context.Background()
+
+	maintainer, ok := newTestUserRepository(db).(TableMaintainer)
+	require.True(t, ok)
+
+	t.Run("defaults to analyze", func(t *testing.T) {
+		require.NoError(t, maintainer.Maintain(ctx))
+	})
+
+	t.Run("runs every sqlite operation", func(t *testing.T) {
+		err := maintainer.Maintain(ctx,
+			MaintenanceAnalyze,
+			MaintenanceVacuum,
+			MaintenanceReindex,
+			MaintenanceOptimize,
+		)
+		require.NoError(t, err)
+	})
+
+	t.Run("rejects unknown operations", func(t *testing.T) {
+		err := maintainer.Maintain(ctx, MaintenanceAnalyze, MaintenanceOp("shrink"))
+		require.Error(t, err)
+		assert.True(t, goerrors.IsValidation(err))
+		assert.Contains(t, err.Error(), "unsupported maintenance operation")
+	})
+}
+
+func TestMaintenanceStatements(t *testing.T) {
+	assert.Equal(t, "REINDEX TABLE ?", maintenanceStatements["postgres"][MaintenanceReindex])
+	assert.Equal(t, "OPTIMIZE TABLE ?", maintenanceStatements["mysql"][MaintenanceOptimize])
+	assert.NotContains(t, maintenanceStatements["mysql"], MaintenanceVacuum)
+	assert.NotContains(t, maintenanceStatements["postgres"], MaintenanceOptimize)
+}
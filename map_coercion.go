@@ -0,0 +1,106 @@
+package repository
+
+import (
+	"database/sql"
+	"encoding"
+	"encoding/json"
+	"fmt"
+	"reflect"
+)
+
+var (
+	textUnmarshalerType = reflect.TypeFor[encoding.TextUnmarshaler]()
+	jsonUnmarshalerType = reflect.TypeFor[json.Unmarshaler]()
+)
+
+// assignUnmarshaledValue decodes src into types that know how to parse
+// themselves: string and []byte sources go through
+// encoding.TextUnmarshaler (decimal.Decimal, net.IP, validated enums), and
+// decoded JSON documents (maps, slices, structs) through json.Unmarshaler
+// (json.RawMessage, JSON column types).
+func assignUnmarshaledValue(dst reflect.Value, src any) (bool, error) {
+	if !dst.CanAddr() {
+		return false, nil
+	}
+	target := dst.Addr()
+
+	switch typed := src.(type) {
+	case string:
+		if target.Type().Implements(textUnmarshalerType) {
+			return true, target.Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(typed))
+		}
+		return false, nil
+	case []byte:
+		if target.Type().Implements(textUnmarshalerType) {
+			return true, target.Interface().(encoding.TextUnmarshaler).UnmarshalText(typed)
+		}
+		return false, nil
+	}
+
+	if !target.Type().Implements(jsonUnmarshalerType) || !isJSONDocumentValue(reflect.ValueOf(src)) {
+		return false, nil
+	}
+	raw, err := json.Marshal(src)
+	if err != nil {
+		return true, err
+	}
+	return true, target.Interface().(json.Unmarshaler).UnmarshalJSON(raw)
+}
+
+func isJSONDocumentValue(value reflect.Value) bool {
+	switch value.Kind() {
+	case reflect.Map, reflect.Struct:
+		return true
+	case reflect.Slice, reflect.Array:
+		return value.Type().Elem().Kind() != reflect.Uint8
+	default:
+		return false
+	}
+}
+
+// assignElements converts src slices and arrays element by element, so
+// []any payloads fill []int fields and []string fills pq.StringArray.
+func assignElements(dst reflect.Value, src reflect.Value) (bool, error) {
+	if src.Kind() != reflect.Slice && src.Kind() != reflect.Array {
+		return false, nil
+	}
+
+	var out reflect.Value
+	switch dst.Kind() {
+	case reflect.Slice:
+		if src.Kind() == reflect.Slice && src.IsNil() {
+			setNilOrZero(dst)
+			return true, nil
+		}
+		out = reflect.MakeSlice(dst.Type(), src.Len(), src.Len())
+	case reflect.Array:
+		if src.Len() != dst.Len() {
+			return true, fmt.Errorf("cannot assign %d elements to %s", src.Len(), dst.Type())
+		}
+		out = reflect.New(dst.Type()).Elem()
+	default:
+		return false, nil
+	}
+
+	for i := range src.Len() {
+		if err := assignValue(out.Index(i), src.Index(i).Interface()); err != nil {
+			return true, fmt.Errorf("element %d: %w", i, err)
+		}
+	}
+	dst.Set(out)
+	return true, nil
+}
+
+// assignScannedValue is the last resort for types implementing sql.Scanner,
+// which accept the driver values payloads usually carry (int64, float64,
+// string, []byte, time.Time).
+func assignScannedValue(dst reflect.Value, src any) (bool, error) {
+	if !dst.CanAddr() {
+		return false, nil
+	}
+	scanner, ok := dst.Addr().Interface().(sql.Scanner)
+	if !ok {
+		return false, nil
+	}
+	return true, scanner.Scan(src)
+}
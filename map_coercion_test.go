@@ -0,0 +1,127 @@
+package repository
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// coercionCents is a minimal decimal type implementing the interfaces of
+// types such as decimal.Decimal.
+type coercionCents struct {
+	cents int64
+}
+
+func (c *coercionCents) UnmarshalText(text []byte) error {
+	whole, frac, _ := strings.Cut(string(text), ".")
+	units, err := strconv.ParseInt(whole+(frac + "00")[:2], 10, 64)
+	if err != nil {
+		return fmt.Errorf("invalid amount %q", text)
+	}
+	c.cents = units
+	return nil
+}
+
+func (c *coercionCents) Scan(src any) error {
+	switch v := src.(type) {
+	case float64:
+		c.cents = int64(v * 100)
+		return nil
+	case int64:
+		c.cents = v * 100
+		return nil
+	default:
+		return fmt.Errorf("unsupported amount source %T", src)
+	}
+}
+
+func (c coercionCents) Value() (driver.Value, error) {
+	return float64(c.cents) / 100, nil
+}
+
+type coercionStatus string
+
+func (s *coercionStatus) UnmarshalText(text []byte) error {
+	switch string(text) {
+	case "active", "archived":
+		*s = coercionStatus(text)
+		return nil
+	default:
+		return fmt.Errorf("invalid status %q", text)
+	}
+}
+
+type coercionModel struct {
+	Tags     pq.StringArray  `bun:"tags,array"`
+	Scores   []int           `bun:"scores"`
+	Pair     [2]float64      `bun:"pair"`
+	Levels   []*int64        `bun:"levels"`
+	Settings json.RawMessage `bun:"settings"`
+	Amount   coercionCents   `bun:"amount"`
+	Nickname sql.NullString  `bun:"nickname"`
+	Status   coercionStatus  `bun:"status"`
+	IP       net.IP          `bun:"ip"`
+}
+
+func TestApplyMapPatch_SliceAndScalarCoercion(t *testing.T) {
+	patched, _, err := ApplyMapPatch(&coercionModel{}, map[string]any{
+		"tags":     []string{"a", "b"},
+		"scores":   []any{1, "2", 3.0},
+		"pair":     []any{1, 2.5},
+		"levels":   []any{int64(4), nil},
+		"settings": map[string]any{"theme": "dark"},
+		"amount":   "12.5",
+		"nickname": "al",
+		"status":   "active",
+		"ip":       "10.0.0.1",
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, pq.StringArray{"a", "b"}, patched.Tags)
+	assert.Equal(t, []int{1, 2, 3}, patched.Scores)
+	assert.Equal(t, [2]float64{1, 2.5}, patched.Pair)
+	require.Len(t, patched.Levels, 2)
+	assert.Equal(t, int64(4), *patched.Levels[0])
+	assert.Nil(t, patched.Levels[1])
+	assert.JSONEq(t, `{"theme":"dark"}`, string(patched.Settings))
+	assert.Equal(t, int64(1250), patched.Amount.cents)
+	assert.Equal(t, sql.NullString{String: "al", Valid: true}, patched.Nickname)
+	assert.Equal(t, coercionStatus("active"), patched.Status)
+	assert.Equal(t, "10.0.0.1", patched.IP.String())
+
+	patched, _, err = ApplyMapPatch(patched, map[string]any{
+		"tags":     []any{"c"},
+		"settings": `{"raw":true}`,
+		"amount":   float64(3),
+	})
+	require.NoError(t, err)
+	assert.Equal(t, pq.StringArray{"c"}, patched.Tags)
+	assert.JSONEq(t, `{"raw":true}`, string(patched.Settings))
+	assert.Equal(t, int64(300), patched.Amount.cents, "sql.Scanner fallback")
+}
+
+func TestApplyMapPatch_CoercionErrors(t *testing.T) {
+	cases := map[string]struct {
+		value any
+		want  string
+	}{
+		"scores": {value: []any{1, "x"}, want: "element 1"},
+		"pair":   {value: []any{1, 2, 3}, want: "cannot assign 3 elements"},
+		"status": {value: "deleted", want: `invalid status "deleted"`},
+		"amount": {value: true, want: "unsupported amount source bool"},
+	}
+	for field, tc := range cases {
+		_, _, err := ApplyMapPatch(&coercionModel{}, map[string]any{field: tc.value})
+		require.Error(t, err, field)
+		assert.Contains(t, err.Error(), tc.want, field)
+	}
+}
@@ -36,11 +36,32 @@ var (
 	ErrPatchFieldNotAllowed = stderrors.New("repository: patch field not allowed")
 	// ErrPatchPrimaryKeyNotAllowed indicates a patch payload attempted to update a primary key field.
 	ErrPatchPrimaryKeyNotAllowed = stderrors.New("repository: patch primary key not allowed")
+	// ErrPatchReadOnlyField indicates a patch payload attempted to update a scanonly or generated column.
+	ErrPatchReadOnlyField = stderrors.New("repository: patch read-only field")
+)
+
+// MapReadOnlyMode controls how patches treat read-only fields: `bun:",scanonly"`
+// fields and generated columns (`bun:"type:... GENERATED ALWAYS AS (...)"`).
+type MapReadOnlyMode string
+
+const (
+	// MapReadOnlyReject fails the patch with ErrPatchReadOnlyField. Default for patches.
+	MapReadOnlyReject MapReadOnlyMode = "reject"
+	// MapReadOnlySkip silently drops read-only fields from the patch.
+	MapReadOnlySkip MapReadOnlyMode = "skip"
+	// MapReadOnlyAllow assigns read-only fields like any other field. Default for MapToRecord.
+	MapReadOnlyAllow MapReadOnlyMode = "allow"
 )
 
 type mapProjectionConfig struct {
 	keyMode            MapKeyMode
 	includeNilPointers bool
+	schemaDB           *bun.DB
+	valueEncoders      []MapValueEncoder
+	redactFields       map[string]struct{}
+	redactMask         any
+	maskRedacted       bool
+	redactionDisabled  bool
 }
 
 func defaultMapProjectionConfig() mapProjectionConfig {
@@ -60,6 +81,14 @@ func WithProjectionKeyMode(mode MapKeyMode) MapProjectionOption {
 	}
 }
 
+// WithProjectionSchema sources column names and primary key flags from the Bun
+// table schema registered on db instead of parsing struct tags.
+func WithProjectionSchema(db *bun.DB) MapProjectionOption {
+	return func(cfg *mapProjectionConfig) {
+		cfg.schemaDB = db
+	}
+}
+
 // WithProjectionIncludeNilPointers controls whether nil pointers are included as map entries.
 func WithProjectionIncludeNilPointers(include bool) MapProjectionOption {
 	return func(cfg *mapProjectionConfig) {
@@ -68,11 +97,19 @@ func WithProjectionIncludeNilPointers(include bool) MapProjectionOption {
 }
 
 type mapPatchConfig struct {
-	keyMode        MapKeyMode
-	allowedFields  map[string]struct{}
-	ignoreUnknown  bool
-	ignoreNil      bool
-	denyPrimaryKey bool
+	keyMode         MapKeyMode
+	allowedFields   map[string]struct{}
+	ignoreUnknown   bool
+	ignoreNil       bool
+	denyPrimaryKey  bool
+	readOnlyMode    MapReadOnlyMode
+	modelType       reflect.Type
+	schemaDB        *bun.DB
+	payloadModel    string
+	payloadVersion  int
+	readOnlyColumns map[string]struct{}
+	jsonMergeFields map[string]struct{}
+	validators      []PatchValidator
 }
 
 func defaultMapPatchConfig() mapPatchConfig {
@@ -80,6 +117,7 @@ func defaultMapPatchConfig() mapPatchConfig {
 		keyMode:       MapKeyBun,
 		ignoreUnknown: false,
 		ignoreNil:     false,
+		readOnlyMode:  MapReadOnlyReject,
 	}
 }
 
@@ -93,6 +131,14 @@ func WithPatchKeyMode(mode MapKeyMode) MapPatchOption {
 	}
 }
 
+// WithPatchSchema sources column names and primary key flags from the Bun
+// table schema registered on db instead of parsing struct tags.
+func WithPatchSchema(db *bun.DB) MapPatchOption {
+	return func(cfg *mapPatchConfig) {
+		cfg.schemaDB = db
+	}
+}
+
 // WithPatchAllowedFields allowlists patchable fields. Values can be Bun names, JSON names, or struct field names.
 func WithPatchAllowedFields(fields ...string) MapPatchOption {
 	return func(cfg *mapPatchConfig) {
@@ -123,6 +169,25 @@ func WithPatchIgnoreNil(ignore bool) MapPatchOption {
 	}
 }
 
+// WithPatchReadOnlyMode selects how read-only fields in a patch are handled.
+func WithPatchReadOnlyMode(mode MapReadOnlyMode) MapPatchOption {
+	return func(cfg *mapPatchConfig) {
+		cfg.readOnlyMode = normalizeMapReadOnlyMode(mode)
+	}
+}
+
+// WithPatchModel lets UpdateCriteriaForMapPatch resolve primary key and
+// read-only columns from model instead of treating every key as a plain column.
+func WithPatchModel(model any) MapPatchOption {
+	return func(cfg *mapPatchConfig) {
+		typ := reflect.TypeOf(model)
+		for typ != nil && typ.Kind() == reflect.Pointer {
+			typ = typ.Elem()
+		}
+		cfg.modelType = typ
+	}
+}
+
 // WithPatchDenyPrimaryKey rejects updates to primary key fields.
 func WithPatchDenyPrimaryKey() MapPatchOption {
 	return func(cfg *mapPatchConfig) {
@@ -149,7 +214,7 @@ func EntityToMap(entity any, opts ...MapProjectionOption) (map[string]any, error
 		return nil, err
 	}
 
-	desc, err := getMapModelDescriptor(structValue.Type())
+	desc, err := resolveMapModelDescriptor(cfg.schemaDB, structValue.Type())
 	if err != nil {
 		return nil, err
 	}
@@ -161,6 +226,12 @@ func EntityToMap(entity any, opts ...MapProjectionOption) (map[string]any, error
 		if key == "" {
 			continue
 		}
+		if cfg.redacts(field) {
+			if cfg.maskRedacted {
+				out[key] = cfg.redactMask
+			}
+			continue
+		}
 
 		fv, ok := fieldByIndexForRead(structValue, field.index)
 		if !ok {
@@ -174,16 +245,58 @@ func EntityToMap(entity any, opts ...MapProjectionOption) (map[string]any, error
 		if !include {
 			continue
 		}
+		value, err = cfg.encodeValue(key, value)
+		if err != nil {
+			return nil, err
+		}
 		out[key] = value
 	}
 
 	return out, nil
 }
 
+// ReadOnlyMapKeys returns the projected keys of entity that are read-only
+// (scanonly fields and generated columns), so adapters can mark them as such.
+// Keys follow the projection key mode.
+func ReadOnlyMapKeys(entity any, opts ...MapProjectionOption) ([]string, error) {
+	cfg := defaultMapProjectionConfig()
+	for _, opt := range opts {
+		if opt != nil {
+			opt(&cfg)
+		}
+	}
+
+	typ := reflect.TypeOf(entity)
+	for typ != nil && typ.Kind() == reflect.Pointer {
+		typ = typ.Elem()
+	}
+	if typ == nil {
+		return nil, fmt.Errorf("repository: expected struct type, got %T", entity)
+	}
+
+	desc, err := resolveMapModelDescriptor(cfg.schemaDB, typ)
+	if err != nil {
+		return nil, err
+	}
+
+	var keys []string
+	for _, field := range desc.fields {
+		if !field.readOnly {
+			continue
+		}
+		if key := field.key(cfg.keyMode); key != "" {
+			keys = append(keys, key)
+		}
+	}
+	return keys, nil
+}
+
 // MapToRecord maps a payload into a record using map-native reflection (no JSON roundtrip).
+// Read-only fields are assigned unless a different MapReadOnlyMode is configured.
 func MapToRecord[T any](payload map[string]any, opts ...MapPatchOption) (T, error) {
 	var zero T
-	record, _, err := ApplyMapPatch(zero, payload, opts...)
+	effectiveOpts := append([]MapPatchOption{WithPatchReadOnlyMode(MapReadOnlyAllow)}, opts...)
+	record, _, err := ApplyMapPatch(zero, payload, effectiveOpts...)
 	if err != nil {
 		return zero, err
 	}
@@ -202,6 +315,10 @@ func ApplyMapPatch[T any](record T, patch map[string]any, opts ...MapPatchOption
 		}
 	}
 
+	patch, err := cfg.migratePayload(patch)
+	if err != nil {
+		return zero, nil, err
+	}
 	if len(patch) == 0 {
 		return record, nil, nil
 	}
@@ -211,34 +328,64 @@ func ApplyMapPatch[T any](record T, patch map[string]any, opts ...MapPatchOption
 		return zero, nil, err
 	}
 
-	desc, err := getMapModelDescriptor(structValue.Type())
+	plan, err := planMapPatch(structValue.Type(), patch, cfg)
 	if err != nil {
 		return zero, nil, err
 	}
 
-	plan, err := buildPatchPlan(desc, patch, cfg)
+	columns, err := applyPatchPlan(structValue, plan)
 	if err != nil {
 		return zero, nil, err
 	}
+	return finalize(), columns, nil
+}
+
+// planMapPatch resolves and validates the patch plan for a model type.
+func planMapPatch(typ reflect.Type, patch map[string]any, cfg mapPatchConfig) ([]patchPlanItem, error) {
+	desc, err := resolveMapModelDescriptor(cfg.schemaDB, typ)
+	if err != nil {
+		return nil, err
+	}
 
+	plan, err := buildPatchPlan(desc, patch, cfg)
+	if err != nil {
+		return nil, err
+	}
+	if err := validatePatchPlan(plan, cfg); err != nil {
+		return nil, err
+	}
+	return plan, nil
+}
+
+// applyPatchPlan assigns plan onto structValue and returns the patched
+// columns.
+func applyPatchPlan(structValue reflect.Value, plan []patchPlanItem) ([]string, error) {
 	columns := make([]string, 0, len(plan))
 	seenColumns := make(map[string]struct{}, len(plan))
+	merges := newJSONMergeSet()
 
 	for _, item := range plan {
 		fieldValue, err := fieldByIndexForWrite(structValue, item.field.index)
 		if err != nil {
-			return zero, nil, err
+			return nil, err
 		}
-		if err := assignValue(fieldValue, item.value); err != nil {
-			return zero, nil, fmt.Errorf("repository: patch field %q (%s): %w", item.inputKey, item.field.bunName, err)
+		if item.jsonMerge {
+			err = merges.apply(fieldValue, item)
+		} else {
+			err = assignValue(fieldValue, item.value)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("repository: patch field %q (%s): %w", item.inputKey, item.field.bunName, err)
 		}
 		if _, exists := seenColumns[item.field.bunName]; !exists {
 			seenColumns[item.field.bunName] = struct{}{}
 			columns = append(columns, item.field.bunName)
 		}
 	}
-
-	return finalize(), columns, nil
+	if err := merges.flush(); err != nil {
+		return nil, err
+	}
+	return columns, nil
 }
 
 // UpdateCriteriaForMapPatch builds UpdateCriteria for direct query patch updates.
@@ -251,6 +398,10 @@ func UpdateCriteriaForMapPatch(patch map[string]any, opts ...MapPatchOption) ([]
 		}
 	}
 
+	patch, err := cfg.migratePayload(patch)
+	if err != nil {
+		return nil, err
+	}
 	if len(patch) == 0 {
 		return nil, nil
 	}
@@ -259,6 +410,9 @@ func UpdateCriteriaForMapPatch(patch map[string]any, opts ...MapPatchOption) ([]
 	if err != nil {
 		return nil, err
 	}
+	if err := validatePatchPlan(plan, cfg); err != nil {
+		return nil, err
+	}
 
 	if len(plan) == 0 {
 		return nil, nil
@@ -293,7 +447,7 @@ func UpdateByIDWithMapPatch[T any](
 		return zero, err
 	}
 
-	effectiveOpts := append([]MapPatchOption{}, opts...)
+	effectiveOpts := append(readOnlyPatchOptions(repo), opts...)
 	effectiveOpts = append(effectiveOpts, WithPatchDenyPrimaryKey())
 
 	patched, columns, err := ApplyMapPatch(current, patch, effectiveOpts...)
@@ -328,7 +482,7 @@ func UpdateByIDWithMapPatchTx[T any](
 		return zero, err
 	}
 
-	effectiveOpts := append([]MapPatchOption{}, opts...)
+	effectiveOpts := append(readOnlyPatchOptions(repo), opts...)
 	effectiveOpts = append(effectiveOpts, WithPatchDenyPrimaryKey())
 
 	patched, columns, err := ApplyMapPatch(current, patch, effectiveOpts...)
@@ -378,10 +532,12 @@ func NewMapRecordMapper[T any](cfg MapRecordMapperConfig) MapRecordMapper[T] {
 }
 
 type patchPlanItem struct {
-	inputKey string
-	column   string
-	value    any
-	field    mapFieldBinding
+	inputKey  string
+	column    string
+	value     any
+	field     mapFieldBinding
+	jsonMerge bool
+	path      []string
 }
 
 type mapModelDescriptor struct {
@@ -398,6 +554,20 @@ type mapFieldBinding struct {
 	jsonName    string
 	jsonIgnored bool
 	isPrimary   bool
+	readOnly    bool
+	redacted    bool
+	nested      reflect.Type
+	embedPrefix string
+	// columnIndex locates the value stored in the column when it differs
+	// from index, e.g. the enclosing JSONB struct of a nested patch key.
+	columnIndex []int
+}
+
+func (f mapFieldBinding) columnPath() []int {
+	if f.columnIndex != nil {
+		return f.columnIndex
+	}
+	return f.index
 }
 
 func (f mapFieldBinding) key(mode MapKeyMode) string {
@@ -436,18 +606,21 @@ func getMapModelDescriptor(typ reflect.Type) (*mapModelDescriptor, error) {
 }
 
 func buildMapModelDescriptor(typ reflect.Type) (*mapModelDescriptor, error) {
+	fields, err := collectMapFieldBindings(typ, nil)
+	if err != nil {
+		return nil, err
+	}
+	return newMapModelDescriptor(fields)
+}
+
+func newMapModelDescriptor(fields []mapFieldBinding) (*mapModelDescriptor, error) {
 	desc := &mapModelDescriptor{
+		fields:   fields,
 		byBun:    make(map[string]mapFieldBinding),
 		byJSON:   make(map[string]mapFieldBinding),
 		byStruct: make(map[string]mapFieldBinding),
 	}
 
-	fields, err := collectMapFieldBindings(typ, nil)
-	if err != nil {
-		return nil, err
-	}
-	desc.fields = fields
-
 	for _, field := range fields {
 		if err := descriptorAddField(desc.byStruct, field.structName, field); err != nil {
 			return nil, err
@@ -513,6 +686,7 @@ func collectMapFieldBindings(typ reflect.Type, prefix []int) ([]mapFieldBinding,
 		if bunSkip {
 			continue
 		}
+		readOnly := isReadOnlyBunTag(field.Tag.Get("bun"))
 
 		jsonName, jsonIgnored := parseJSONFieldName(field)
 		result = append(result, mapFieldBinding{
@@ -522,6 +696,10 @@ func collectMapFieldBindings(typ reflect.Type, prefix []int) ([]mapFieldBinding,
 			jsonName:    jsonName,
 			jsonIgnored: jsonIgnored,
 			isPrimary:   isPrimary,
+			readOnly:    readOnly,
+			redacted:    hasRedactTag(field),
+			nested:      nestedPatchType(field),
+			embedPrefix: bunEmbedPrefix(field.Tag.Get("bun")),
 		})
 	}
 
@@ -543,7 +721,20 @@ func buildPatchPlan(desc *mapModelDescriptor, patch map[string]any, cfg mapPatch
 			continue
 		}
 
+		fieldKey := key
+		var path []string
+		var parents []nestedPatchParent
 		field, ok := keyLookup[key]
+		if !ok {
+			if root, rest, dotted := strings.Cut(key, "."); dotted {
+				if rootField, found := keyLookup[root]; found && cfg.isJSONMergeField(root, rootField) {
+					fieldKey, path, field, ok = root, strings.Split(rest, "."), rootField, true
+				}
+			}
+		}
+		if !ok {
+			field, parents, ok = resolveNestedPatchField(keyLookup, key, cfg.keyMode)
+		}
 		if !ok {
 			if cfg.ignoreUnknown {
 				continue
@@ -555,15 +746,27 @@ func buildPatchPlan(desc *mapModelDescriptor, patch map[string]any, cfg mapPatch
 			return nil, fmt.Errorf("%w: %s", ErrPatchPrimaryKeyNotAllowed, key)
 		}
 
-		if !fieldAllowed(cfg.allowedFields, key, field) {
+		if field.readOnly || cfg.isReadOnlyColumn(field.bunName) {
+			skip, err := readOnlyPatchField(cfg.readOnlyMode, key)
+			if err != nil {
+				return nil, err
+			}
+			if skip {
+				continue
+			}
+		}
+
+		if !fieldAllowed(cfg.allowedFields, fieldKey, field) && !parentAllowed(cfg.allowedFields, parents) {
 			return nil, fmt.Errorf("%w: %s", ErrPatchFieldNotAllowed, key)
 		}
 
 		plan = append(plan, patchPlanItem{
-			inputKey: key,
-			column:   field.bunName,
-			value:    value,
-			field:    field,
+			inputKey:  key,
+			column:    field.bunName,
+			value:     value,
+			field:     field,
+			jsonMerge: cfg.isJSONMergeField(fieldKey, field),
+			path:      path,
 		})
 	}
 
@@ -579,6 +782,14 @@ func buildRawPatchPlan(patch map[string]any, cfg mapPatchConfig) ([]patchPlanIte
 		)
 	}
 
+	var desc *mapModelDescriptor
+	if cfg.modelType != nil {
+		var err error
+		if desc, err = resolveMapModelDescriptor(cfg.schemaDB, cfg.modelType); err != nil {
+			return nil, err
+		}
+	}
+
 	keys := sortedMapKeys(patch)
 	plan := make([]patchPlanItem, 0, len(keys))
 	for _, key := range keys {
@@ -587,9 +798,23 @@ func buildRawPatchPlan(patch map[string]any, cfg mapPatchConfig) ([]patchPlanIte
 			continue
 		}
 
-		if cfg.denyPrimaryKey && strings.EqualFold(key, "id") {
+		field, known := rawPatchField(desc, key)
+		isPrimary := strings.EqualFold(key, "id")
+		if known {
+			isPrimary = field.isPrimary
+		}
+		if cfg.denyPrimaryKey && isPrimary {
 			return nil, fmt.Errorf("%w: %s", ErrPatchPrimaryKeyNotAllowed, key)
 		}
+		if (known && field.readOnly) || cfg.isReadOnlyColumn(key) {
+			skip, err := readOnlyPatchField(cfg.readOnlyMode, key)
+			if err != nil {
+				return nil, err
+			}
+			if skip {
+				continue
+			}
+		}
 		if !fieldAllowedRaw(cfg.allowedFields, key) {
 			return nil, fmt.Errorf("%w: %s", ErrPatchFieldNotAllowed, key)
 		}
@@ -603,6 +828,27 @@ func buildRawPatchPlan(patch map[string]any, cfg mapPatchConfig) ([]patchPlanIte
 	return plan, nil
 }
 
+func rawPatchField(desc *mapModelDescriptor, column string) (mapFieldBinding, bool) {
+	if desc == nil {
+		return mapFieldBinding{}, false
+	}
+	field, ok := desc.byBun[column]
+	return field, ok
+}
+
+// readOnlyPatchField reports whether a read-only patch key is skipped, or
+// returns ErrPatchReadOnlyField when the mode rejects it.
+func readOnlyPatchField(mode MapReadOnlyMode, key string) (bool, error) {
+	switch normalizeMapReadOnlyMode(mode) {
+	case MapReadOnlyAllow:
+		return false, nil
+	case MapReadOnlySkip:
+		return true, nil
+	default:
+		return false, fmt.Errorf("%w: %s", ErrPatchReadOnlyField, key)
+	}
+}
+
 func descriptorLookupByMode(desc *mapModelDescriptor, mode MapKeyMode) (map[string]mapFieldBinding, error) {
 	switch normalizeMapKeyMode(mode) {
 	case MapKeyJSON:
@@ -772,6 +1018,14 @@ func assignKnownValue(dst reflect.Value, src any) (bool, error) {
 
 func assignByKind(dst reflect.Value, src any) error {
 	srcValue := reflect.ValueOf(src)
+	if srcValue.Type().AssignableTo(dst.Type()) {
+		dst.Set(srcValue)
+		return nil
+	}
+	if handled, err := assignUnmarshaledValue(dst, src); handled {
+		return err
+	}
+
 	switch dst.Kind() {
 	case reflect.String:
 		dst.SetString(fmt.Sprint(src))
@@ -807,17 +1061,18 @@ func assignByKind(dst reflect.Value, src any) error {
 	case reflect.Interface:
 		dst.Set(reflect.ValueOf(src))
 		return nil
+	case reflect.Slice, reflect.Array:
+		if handled, err := assignElements(dst, srcValue); handled {
+			return err
+		}
 	}
 
-	if srcValue.IsValid() {
-		if srcValue.Type().AssignableTo(dst.Type()) {
-			dst.Set(srcValue)
-			return nil
-		}
-		if srcValue.Type().ConvertibleTo(dst.Type()) {
-			dst.Set(srcValue.Convert(dst.Type()))
-			return nil
-		}
+	if srcValue.Type().ConvertibleTo(dst.Type()) {
+		dst.Set(srcValue.Convert(dst.Type()))
+		return nil
+	}
+	if handled, err := assignScannedValue(dst, src); handled {
+		return err
 	}
 
 	return fmt.Errorf("cannot assign %T to %s", src, dst.Type())
@@ -921,6 +1176,25 @@ func parseJSONFieldName(field reflect.StructField) (string, bool) {
 	}
 }
 
+// isReadOnlyBunTag reports whether a bun tag marks a scanonly field or a
+// generated column.
+func isReadOnlyBunTag(tag string) bool {
+	for i, part := range strings.Split(tag, ",") {
+		part = strings.TrimSpace(part)
+		if part == "scanonly" && i > 0 {
+			return true
+		}
+		if sqlType, ok := strings.CutPrefix(part, "type:"); ok && isGeneratedSQLType(sqlType) {
+			return true
+		}
+	}
+	return false
+}
+
+func isGeneratedSQLType(sqlType string) bool {
+	return strings.Contains(strings.ToUpper(sqlType), "GENERATED ")
+}
+
 func isBunTagOption(part string) bool {
 	switch part {
 	case "pk", "autoincrement", "notnull", "nullzero", "unique", "scanonly", "soft_delete", "skipupdate", "skipinsert":
@@ -1009,6 +1283,17 @@ func normalizeMapKeyMode(mode MapKeyMode) MapKeyMode {
 	}
 }
 
+func normalizeMapReadOnlyMode(mode MapReadOnlyMode) MapReadOnlyMode {
+	switch mode {
+	case MapReadOnlySkip:
+		return MapReadOnlySkip
+	case MapReadOnlyAllow:
+		return MapReadOnlyAllow
+	default:
+		return MapReadOnlyReject
+	}
+}
+
 func toSnakeCase(value string) string {
 	if value == "" {
 		return value
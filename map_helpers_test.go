@@ -237,3 +237,111 @@ func TestApplyMapPatch_RejectsOverflowAndUnderflow(t *testing.T) {
 	require.Error(t, err)
 	assert.Contains(t, err.Error(), "underflows uint64")
 }
+
+type mapSchemaAddress struct {
+	Street string `bun:"street" json:"street"`
+	City   string `bun:"city" json:"city"`
+}
+
+type mapSchemaModel struct {
+	ID       uuid.UUID        `bun:"id,pk" json:"id"`
+	Home     mapSchemaAddress `bun:"embed:home_" json:"home"`
+	Work     mapSchemaAddress `bun:"embed:work_" json:"work"`
+	Nickname string           `bun:",nullzero" json:"nickname"`
+}
+
+func TestRecordToMap_WithProjectionSchemaUsesBunColumns(t *testing.T) {
+	id := uuid.New()
+	model := mapSchemaModel{
+		ID:       id,
+		Home:     mapSchemaAddress{Street: "Main St", City: "Springfield"},
+		Work:     mapSchemaAddress{Street: "Elm St", City: "Shelbyville"},
+		Nickname: "bart",
+	}
+
+	out, err := RecordToMap(model, WithProjectionSchema(db))
+	require.NoError(t, err)
+	assert.Equal(t, map[string]any{
+		"id":          id,
+		"home_street": "Main St",
+		"home_city":   "Springfield",
+		"work_street": "Elm St",
+		"work_city":   "Shelbyville",
+		"nickname":    "bart",
+	}, out)
+
+	out, err = RecordToMap(model, WithProjectionSchema(db), WithProjectionKeyMode(MapKeyStruct))
+	require.NoError(t, err)
+	assert.Equal(t, "Elm St", out["Work.Street"])
+}
+
+func TestApplyMapPatch_WithPatchSchema(t *testing.T) {
+	record := &mapSchemaModel{ID: uuid.New()}
+
+	patched, columns, err := ApplyMapPatch(record, map[string]any{
+		"work_city": "Capital City",
+		"nickname":  "lisa",
+	}, WithPatchSchema(db))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"nickname", "work_city"}, columns)
+	assert.Equal(t, "Capital City", patched.Work.City)
+	assert.Equal(t, "lisa", patched.Nickname)
+
+	_, _, err = ApplyMapPatch(record, map[string]any{"id": uuid.New()}, WithPatchSchema(db), WithPatchDenyPrimaryKey())
+	require.ErrorIs(t, err, ErrPatchPrimaryKeyNotAllowed)
+
+	_, _, err = ApplyMapPatch(record, map[string]any{"work": "x"}, WithPatchSchema(db))
+	require.ErrorIs(t, err, ErrUnknownPatchField)
+}
+
+type mapReadOnlyModel struct {
+	ID       uuid.UUID `bun:"id,pk" json:"id"`
+	Price    int       `bun:"price" json:"price"`
+	Total    int       `bun:"total,type:'integer GENERATED ALWAYS AS (price * 2) STORED'" json:"total"`
+	RowCount int       `bun:"row_count,scanonly" json:"row_count"`
+}
+
+func TestApplyMapPatch_ReadOnlyFields(t *testing.T) {
+	record := &mapReadOnlyModel{ID: uuid.New()}
+
+	_, _, err := ApplyMapPatch(record, map[string]any{"price": 10, "total": 20})
+	require.ErrorIs(t, err, ErrPatchReadOnlyField)
+
+	_, _, err = ApplyMapPatch(record, map[string]any{"row_count": 3}, WithPatchSchema(db))
+	require.ErrorIs(t, err, ErrPatchReadOnlyField)
+
+	patched, columns, err := ApplyMapPatch(record, map[string]any{"price": 10, "total": 20, "row_count": 3},
+		WithPatchReadOnlyMode(MapReadOnlySkip))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"price"}, columns)
+	assert.Equal(t, 10, patched.Price)
+	assert.Zero(t, patched.Total)
+
+	built, err := MapToRecord[mapReadOnlyModel](map[string]any{"price": 10, "row_count": 3})
+	require.NoError(t, err)
+	assert.Equal(t, 3, built.RowCount)
+}
+
+func TestUpdateCriteriaForMapPatch_ReadOnlyFieldsWithModel(t *testing.T) {
+	criteria, err := UpdateCriteriaForMapPatch(map[string]any{"total": 20})
+	require.NoError(t, err)
+	assert.Len(t, criteria, 2)
+
+	_, err = UpdateCriteriaForMapPatch(map[string]any{"total": 20}, WithPatchModel(&mapReadOnlyModel{}))
+	require.ErrorIs(t, err, ErrPatchReadOnlyField)
+
+	criteria, err = UpdateCriteriaForMapPatch(map[string]any{"price": 10, "total": 20},
+		WithPatchModel(mapReadOnlyModel{}), WithPatchReadOnlyMode(MapReadOnlySkip))
+	require.NoError(t, err)
+	assert.Len(t, criteria, 2)
+}
+
+func TestReadOnlyMapKeys(t *testing.T) {
+	keys, err := ReadOnlyMapKeys(&mapReadOnlyModel{})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"total", "row_count"}, keys)
+
+	keys, err = ReadOnlyMapKeys(mapReadOnlyModel{}, WithProjectionKeyMode(MapKeyStruct), WithProjectionSchema(db))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Total", "RowCount"}, keys)
+}
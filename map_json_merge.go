@@ -0,0 +1,197 @@
+package repository
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// WithPatchJSONMerge patches the given map or JSON backed struct fields
+// (e.g. JSONB columns) with JSON merge patch semantics (RFC 7386) instead of
+// replacing them. Fields can be Bun names, JSON names, or struct field names.
+//
+// A map value is merged key by key into the current value, recursively, and
+// a nil value removes the key. Dotted payload keys address nested keys
+// directly:
+//
+//	ApplyMapPatch(record, map[string]any{
+//		"metadata.settings.theme": "dark", // set a nested key
+//		"metadata.legacy":         nil,    // remove a key
+//	}, WithPatchJSONMerge("metadata"))
+//
+// Struct fields are merged through their JSON encoding.
+func WithPatchJSONMerge(fields ...string) MapPatchOption {
+	return func(cfg *mapPatchConfig) {
+		if cfg.jsonMergeFields == nil {
+			cfg.jsonMergeFields = make(map[string]struct{}, len(fields))
+		}
+		for _, field := range fields {
+			field = strings.TrimSpace(field)
+			if field == "" {
+				continue
+			}
+			cfg.jsonMergeFields[field] = struct{}{}
+		}
+	}
+}
+
+func (cfg mapPatchConfig) isJSONMergeField(key string, field mapFieldBinding) bool {
+	return len(cfg.jsonMergeFields) > 0 && fieldAllowed(cfg.jsonMergeFields, key, field)
+}
+
+// jsonMergeSet collects the merged documents of the fields of one patch, so
+// several keys targeting the same field build on each other.
+type jsonMergeSet struct {
+	docs  map[string]*jsonMergeDoc
+	order []string
+}
+
+type jsonMergeDoc struct {
+	field    reflect.Value
+	inputKey string
+	column   string
+	value    any
+}
+
+func newJSONMergeSet() *jsonMergeSet {
+	return &jsonMergeSet{docs: make(map[string]*jsonMergeDoc)}
+}
+
+// apply merges item into the document of its field, loading the document
+// from the current field value on first use.
+func (s *jsonMergeSet) apply(field reflect.Value, item patchPlanItem) error {
+	doc, ok := s.docs[item.column]
+	if !ok {
+		current, err := jsonMergeDocument(field)
+		if err != nil {
+			return err
+		}
+		doc = &jsonMergeDoc{field: field, inputKey: item.inputKey, column: item.column, value: current}
+		s.docs[item.column] = doc
+		s.order = append(s.order, item.column)
+	}
+
+	if len(item.path) == 0 {
+		doc.value = jsonMergePatch(doc.value, item.value)
+		return nil
+	}
+
+	target, ok := doc.value.(map[string]any)
+	if !ok {
+		target = make(map[string]any)
+		doc.value = target
+	}
+	for _, key := range item.path[:len(item.path)-1] {
+		next, ok := target[key].(map[string]any)
+		if !ok {
+			next = make(map[string]any)
+			target[key] = next
+		}
+		target = next
+	}
+	leaf := item.path[len(item.path)-1]
+	if item.value == nil {
+		delete(target, leaf)
+		return nil
+	}
+	target[leaf] = jsonMergePatch(target[leaf], item.value)
+	return nil
+}
+
+// flush writes the merged documents back into their fields.
+func (s *jsonMergeSet) flush() error {
+	for _, column := range s.order {
+		doc := s.docs[column]
+		if err := assignJSONMergeDocument(doc.field, doc.value); err != nil {
+			return fmt.Errorf("repository: patch field %q (%s): %w", doc.inputKey, doc.column, err)
+		}
+	}
+	return nil
+}
+
+// jsonMergeDocument returns a copy of the field value as a JSON document.
+func jsonMergeDocument(field reflect.Value) (any, error) {
+	if (field.Kind() == reflect.Pointer || field.Kind() == reflect.Map || field.Kind() == reflect.Interface) && field.IsNil() {
+		return map[string]any{}, nil
+	}
+	if current, ok := field.Interface().(map[string]any); ok {
+		return jsonMergeCopy(current), nil
+	}
+
+	raw, err := json.Marshal(field.Interface())
+	if err != nil {
+		return nil, err
+	}
+	var doc any
+	if err := json.Unmarshal(raw, &doc); err != nil {
+		return nil, err
+	}
+	if doc == nil {
+		return map[string]any{}, nil
+	}
+	return doc, nil
+}
+
+func assignJSONMergeDocument(field reflect.Value, doc any) error {
+	if doc == nil {
+		setNilOrZero(field)
+		return nil
+	}
+	if value := reflect.ValueOf(doc); value.Type().AssignableTo(field.Type()) {
+		field.Set(value)
+		return nil
+	}
+
+	raw, err := json.Marshal(doc)
+	if err != nil {
+		return err
+	}
+	decoded := reflect.New(field.Type())
+	if err := json.Unmarshal(raw, decoded.Interface()); err != nil {
+		return err
+	}
+	field.Set(decoded.Elem())
+	return nil
+}
+
+// jsonMergePatch applies patch to target as described by RFC 7386.
+func jsonMergePatch(target, patch any) any {
+	patchMap, ok := patch.(map[string]any)
+	if !ok {
+		return jsonMergeCopy(patch)
+	}
+	targetMap, ok := target.(map[string]any)
+	if !ok {
+		targetMap = make(map[string]any, len(patchMap))
+	}
+	for key, value := range patchMap {
+		if value == nil {
+			delete(targetMap, key)
+			continue
+		}
+		targetMap[key] = jsonMergePatch(targetMap[key], value)
+	}
+	return targetMap
+}
+
+// jsonMergeCopy deep copies nested maps and slices, so merged documents
+// share no state with the record or the payload.
+func jsonMergeCopy(value any) any {
+	switch typed := value.(type) {
+	case map[string]any:
+		out := make(map[string]any, len(typed))
+		for key, item := range typed {
+			out[key] = jsonMergeCopy(item)
+		}
+		return out
+	case []any:
+		out := make([]any, len(typed))
+		for i, item := range typed {
+			out[i] = jsonMergeCopy(item)
+		}
+		return out
+	default:
+		return value
+	}
+}
@@ -0,0 +1,116 @@
+package repository
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type jsonMergePreferences struct {
+	Theme    string            `json:"theme"`
+	Language string            `json:"language,omitempty"`
+	Flags    map[string]bool   `json:"flags,omitempty"`
+	Limits   map[string]string `json:"limits,omitempty"`
+}
+
+type jsonMergeModel struct {
+	ID          uuid.UUID             `bun:"id,pk" json:"id"`
+	Name        string                `bun:"name" json:"name"`
+	Metadata    map[string]any        `bun:"metadata,type:jsonb" json:"metadata"`
+	Preferences *jsonMergePreferences `bun:"preferences,type:jsonb" json:"prefs"`
+}
+
+func TestApplyMapPatch_JSONMergeMap(t *testing.T) {
+	record := &jsonMergeModel{
+		ID:   uuid.New(),
+		Name: "Alice",
+		Metadata: map[string]any{
+			"settings": map[string]any{"theme": "light", "density": "compact"},
+			"legacy":   true,
+			"tags":     []any{"a"},
+		},
+	}
+	previous := record.Metadata
+
+	patched, columns, err := ApplyMapPatch(record, map[string]any{
+		"metadata": map[string]any{
+			"settings": map[string]any{"density": nil, "font": "serif"},
+			"tags":     []any{"b"},
+		},
+		"metadata.settings.theme": "dark",
+		"metadata.legacy":         nil,
+		"metadata.owner.email":    "alice@example.com",
+		"name":                    "Alicia",
+	}, WithPatchJSONMerge("metadata"))
+	require.NoError(t, err)
+
+	assert.ElementsMatch(t, []string{"metadata", "name"}, columns)
+	assert.Equal(t, "Alicia", patched.Name)
+	assert.Equal(t, map[string]any{
+		"settings": map[string]any{"theme": "dark", "font": "serif"},
+		"tags":     []any{"b"},
+		"owner":    map[string]any{"email": "alice@example.com"},
+	}, patched.Metadata)
+	assert.Equal(t, map[string]any{
+		"settings": map[string]any{"theme": "light", "density": "compact"},
+		"legacy":   true,
+		"tags":     []any{"a"},
+	}, previous, "the previous map is not mutated")
+
+	patched, _, err = ApplyMapPatch(&jsonMergeModel{}, map[string]any{"metadata.a.b": 1}, WithPatchJSONMerge("metadata"))
+	require.NoError(t, err)
+	assert.Equal(t, map[string]any{"a": map[string]any{"b": 1}}, patched.Metadata)
+
+	patched, _, err = ApplyMapPatch(record, map[string]any{"metadata": nil}, WithPatchJSONMerge("metadata"))
+	require.NoError(t, err)
+	assert.Nil(t, patched.Metadata, "a nil value still clears the whole field")
+}
+
+func TestApplyMapPatch_JSONMergeStruct(t *testing.T) {
+	record := jsonMergeModel{
+		Preferences: &jsonMergePreferences{
+			Theme:    "light",
+			Language: "en",
+			Flags:    map[string]bool{"beta": true, "ads": true},
+		},
+	}
+
+	patched, columns, err := ApplyMapPatch(record, map[string]any{
+		"prefs":           map[string]any{"flags": map[string]any{"ads": nil}},
+		"prefs.theme":     "dark",
+		"prefs.language":  nil,
+		"prefs.limits.rp": "10",
+	}, WithPatchKeyMode(MapKeyJSON), WithPatchJSONMerge("Preferences"))
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"preferences"}, columns)
+	assert.Equal(t, &jsonMergePreferences{
+		Theme:  "dark",
+		Flags:  map[string]bool{"beta": true},
+		Limits: map[string]string{"rp": "10"},
+	}, patched.Preferences)
+	assert.Equal(t, "light", record.Preferences.Theme, "value records are copied")
+
+	_, _, err = ApplyMapPatch(record, map[string]any{"prefs.flags.beta": "yes"},
+		WithPatchKeyMode(MapKeyJSON), WithPatchJSONMerge("prefs"))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "prefs.flags.beta")
+}
+
+func TestApplyMapPatch_JSONMergeRules(t *testing.T) {
+	record := &jsonMergeModel{Metadata: map[string]any{"a": 1}}
+
+	_, _, err := ApplyMapPatch(record, map[string]any{"metadata.a": 2})
+	assert.ErrorIs(t, err, ErrUnknownPatchField, "dotted keys need WithPatchJSONMerge")
+
+	_, _, err = ApplyMapPatch(record, map[string]any{"metadata.a": 2},
+		WithPatchJSONMerge("metadata"), WithPatchAllowedFields("name"))
+	assert.ErrorIs(t, err, ErrPatchFieldNotAllowed)
+
+	patched, _, err := ApplyMapPatch(record, map[string]any{"metadata.b": 2},
+		WithPatchJSONMerge("metadata"), WithPatchAllowedFields("metadata"))
+	require.NoError(t, err)
+	assert.Equal(t, map[string]any{"a": 1, "b": 2}, patched.Metadata)
+}
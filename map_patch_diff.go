@@ -0,0 +1,206 @@
+package repository
+
+import (
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/uptrace/bun"
+)
+
+// FieldChange is the value of a column before and after a change. Pointers
+// are dereferenced; nil pointers are nil.
+type FieldChange struct {
+	Old any
+	New any
+}
+
+// PatchDiff describes what a map patch would change.
+type PatchDiff struct {
+	// Changes holds the old and new value of every changed column, keyed by
+	// Bun column name. Nested patch keys report their whole column, e.g. the
+	// enclosing JSONB value.
+	Changes map[string]FieldChange
+	// Columns lists the changed columns in payload key order.
+	Columns []string
+}
+
+// HasChanges reports whether the patch would change anything.
+func (d PatchDiff) HasChanges() bool {
+	return len(d.Columns) > 0
+}
+
+// DiffMapPatch is a dry run of ApplyMapPatch: it resolves and validates patch
+// with the same options and returns the columns it would change, with their
+// old and new values, without mutating record. Columns whose value the patch
+// leaves unchanged are not reported.
+func DiffMapPatch[T any](record T, patch map[string]any, opts ...MapPatchOption) (PatchDiff, error) {
+	cfg := defaultMapPatchConfig()
+	for _, opt := range opts {
+		if opt != nil {
+			opt(&cfg)
+		}
+	}
+
+	patch, err := cfg.migratePayload(patch)
+	if err != nil {
+		return PatchDiff{}, err
+	}
+
+	original, err := readStructValue(record)
+	if err != nil {
+		return PatchDiff{}, err
+	}
+	if len(patch) == 0 {
+		return PatchDiff{Changes: map[string]FieldChange{}}, nil
+	}
+
+	plan, err := planMapPatch(original.Type(), patch, cfg)
+	if err != nil {
+		return PatchDiff{}, err
+	}
+
+	patched := reflect.New(original.Type()).Elem()
+	patched.Set(original)
+	for _, item := range plan {
+		detachPatchPath(patched, item.field.index)
+	}
+	if _, err := applyPatchPlan(patched, plan); err != nil {
+		return PatchDiff{}, err
+	}
+
+	diff := PatchDiff{Changes: make(map[string]FieldChange)}
+	for _, item := range plan {
+		column := item.field.bunName
+		if _, seen := diff.Changes[column]; seen {
+			continue
+		}
+		path := item.field.columnPath()
+		before := diffFieldValue(original, path)
+		after := diffFieldValue(patched, path)
+		if diffValuesEqual(before, after) {
+			continue
+		}
+		diff.Changes[column] = FieldChange{Old: before, New: after}
+		diff.Columns = append(diff.Columns, column)
+	}
+	return diff, nil
+}
+
+// detachPatchPath replaces the non-nil pointers along index in the shallow
+// copy structValue with copies of their targets, so writing the field does
+// not reach the original record.
+func detachPatchPath(structValue reflect.Value, index []int) {
+	current := structValue
+	for _, idx := range index {
+		if current.Kind() == reflect.Pointer {
+			if current.IsNil() {
+				return
+			}
+			current = current.Elem()
+		}
+		if current.Kind() != reflect.Struct {
+			return
+		}
+		current = current.Field(idx)
+		if current.Kind() == reflect.Pointer && !current.IsNil() && current.CanSet() {
+			clone := reflect.New(current.Type().Elem())
+			clone.Elem().Set(current.Elem())
+			current.Set(clone)
+		}
+	}
+}
+
+func diffFieldValue(structValue reflect.Value, index []int) any {
+	field, ok := fieldByIndexForRead(structValue, index)
+	if !ok {
+		return nil
+	}
+	value, _ := projectedFieldValue(field, true)
+	return value
+}
+
+// RecordDiffOption configures DiffRecords.
+type RecordDiffOption func(*recordDiffConfig)
+
+type recordDiffConfig struct {
+	schemaDB *bun.DB
+	ignored  map[string]struct{}
+}
+
+// WithDiffSchema sources column names from the Bun table schema registered on
+// db instead of parsing struct tags.
+func WithDiffSchema(db *bun.DB) RecordDiffOption {
+	return func(cfg *recordDiffConfig) {
+		cfg.schemaDB = db
+	}
+}
+
+// WithDiffIgnoreColumns leaves columns out of the diff, e.g. updated_at.
+func WithDiffIgnoreColumns(columns ...string) RecordDiffOption {
+	return func(cfg *recordDiffConfig) {
+		if cfg.ignored == nil {
+			cfg.ignored = make(map[string]struct{}, len(columns))
+		}
+		for _, column := range columns {
+			cfg.ignored[strings.TrimSpace(column)] = struct{}{}
+		}
+	}
+}
+
+// DiffRecords compares two versions of a record column by column and returns
+// the changes keyed by Bun column name, plus the changed columns in
+// declaration order. A nil record reads as all nil columns, so a created or
+// deleted record reports every non-nil column. Times are compared with
+// time.Time.Equal, so values reloaded from the database in another location
+// do not count as changes. T must be a struct or struct pointer type;
+// otherwise nothing is reported.
+func DiffRecords[T any](before, after T, opts ...RecordDiffOption) (map[string]FieldChange, []string) {
+	cfg := recordDiffConfig{}
+	for _, opt := range opts {
+		if opt != nil {
+			opt(&cfg)
+		}
+	}
+
+	typ := reflect.TypeFor[T]()
+	for typ.Kind() == reflect.Pointer {
+		typ = typ.Elem()
+	}
+	if typ.Kind() != reflect.Struct {
+		return nil, nil
+	}
+	desc, err := resolveMapModelDescriptor(cfg.schemaDB, typ)
+	if err != nil {
+		return nil, nil
+	}
+
+	// nil records leave the value invalid, which reads as nil columns
+	beforeValue, _ := readStructValue(before)
+	afterValue, _ := readStructValue(after)
+
+	changes := make(map[string]FieldChange)
+	var columns []string
+	for _, field := range desc.fields {
+		if _, skip := cfg.ignored[field.bunName]; skip {
+			continue
+		}
+		old := diffFieldValue(beforeValue, field.index)
+		current := diffFieldValue(afterValue, field.index)
+		if diffValuesEqual(old, current) {
+			continue
+		}
+		changes[field.bunName] = FieldChange{Old: old, New: current}
+		columns = append(columns, field.bunName)
+	}
+	return changes, columns
+}
+
+func diffValuesEqual(a, b any) bool {
+	if at, ok := a.(time.Time); ok {
+		if bt, ok := b.(time.Time); ok {
+			return at.Equal(bt)
+		}
+	}
+	return reflect.DeepEqual(a, b)
+}
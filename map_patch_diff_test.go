@@ -0,0 +1,97 @@
+package repository
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDiffMapPatch(t *testing.T) {
+	enabled := true
+	record := &mapHelperModel{ID: uuid.New(), Name: "Alice", Count: 2, Enabled: &enabled}
+
+	diff, err := DiffMapPatch(record, map[string]any{
+		"name":    "Alicia",
+		"count":   2,
+		"enabled": false,
+	})
+	require.NoError(t, err)
+
+	assert.True(t, diff.HasChanges())
+	assert.Equal(t, []string{"enabled", "name"}, diff.Columns, "unchanged values are not reported")
+	assert.Equal(t, map[string]FieldChange{
+		"enabled": {Old: true, New: false},
+		"name":    {Old: "Alice", New: "Alicia"},
+	}, diff.Changes)
+
+	assert.Equal(t, "Alice", record.Name, "the record is not mutated")
+	assert.True(t, *record.Enabled, "pointer targets are not mutated")
+
+	diff, err = DiffMapPatch(record, map[string]any{"name": "Alice"})
+	require.NoError(t, err)
+	assert.False(t, diff.HasChanges())
+
+	_, err = DiffMapPatch(record, map[string]any{"missing": 1})
+	assert.ErrorIs(t, err, ErrUnknownPatchField)
+}
+
+func TestDiffMapPatch_NestedFields(t *testing.T) {
+	record := patchPathModel{
+		ID:      uuid.New(),
+		Profile: &patchPathProfile{FirstName: "Ada", LastName: "Byron"},
+	}
+
+	diff, err := DiffMapPatch(record, map[string]any{
+		"profile.last_name": "Lovelace",
+		"home.city":         "London",
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"home_city", "profile"}, diff.Columns)
+	assert.Equal(t, FieldChange{Old: "", New: "London"}, diff.Changes["home_city"])
+	assert.Equal(t, FieldChange{
+		Old: patchPathProfile{FirstName: "Ada", LastName: "Byron"},
+		New: patchPathProfile{FirstName: "Ada", LastName: "Lovelace"},
+	}, diff.Changes["profile"])
+	assert.Equal(t, "Byron", record.Profile.LastName)
+
+	merged, err := DiffMapPatch(&jsonMergeModel{Metadata: map[string]any{"a": 1}},
+		map[string]any{"metadata.b": 2}, WithPatchJSONMerge("metadata"))
+	require.NoError(t, err)
+	assert.Equal(t, FieldChange{
+		Old: map[string]any{"a": 1},
+		New: map[string]any{"a": 1, "b": 2},
+	}, merged.Changes["metadata"])
+}
+
+func TestDiffRecords(t *testing.T) {
+	now := time.Now()
+	before := &TestUser{ID: uuid.New(), Name: "Alice", Email: "alice@example.com", CreatedAt: now, UpdatedAt: now}
+	after := *before
+	after.Name = "Alicia"
+	after.CreatedAt = now.In(time.FixedZone("CET", 3600))
+	after.UpdatedAt = now.Add(time.Minute)
+
+	changes, columns := DiffRecords(before, &after)
+	assert.Equal(t, []string{"name", "updated_at"}, columns, "equal times in another location are unchanged")
+	assert.Equal(t, FieldChange{Old: "Alice", New: "Alicia"}, changes["name"])
+
+	changes, columns = DiffRecords(before, &after, WithDiffIgnoreColumns("updated_at"))
+	assert.Equal(t, []string{"name"}, columns)
+	assert.Len(t, changes, 1)
+
+	_, columns = DiffRecords(before, before)
+	assert.Empty(t, columns)
+
+	changes, columns = DiffRecords(nil, before)
+	assert.Equal(t, []string{"id", "name", "email", "company_id", "created_at", "updated_at"}, columns)
+	assert.Equal(t, FieldChange{Old: nil, New: before.ID}, changes["id"])
+
+	changes, columns = DiffRecords(mapSchemaModel{Home: mapSchemaAddress{City: "A"}},
+		mapSchemaModel{Home: mapSchemaAddress{City: "B"}}, WithDiffSchema(db))
+	assert.Equal(t, []string{"home_city"}, columns)
+	assert.Equal(t, FieldChange{Old: "A", New: "B"}, changes["home_city"])
+}
@@ -0,0 +1,125 @@
+package repository
+
+import (
+	"database/sql"
+	"reflect"
+	"slices"
+	"strings"
+	"time"
+)
+
+// nestedPatchParent is a struct field traversed by a dotted patch key.
+type nestedPatchParent struct {
+	key   string
+	field mapFieldBinding
+}
+
+var sqlScannerType = reflect.TypeFor[sql.Scanner]()
+
+// nestedPatchType returns the struct type dotted patch keys may traverse
+// into through field, or nil when the field is a plain value. Relations are
+// not traversed since they are not columns of the model.
+func nestedPatchType(field reflect.StructField) reflect.Type {
+	typ := field.Type
+	if typ.Kind() == reflect.Pointer {
+		typ = typ.Elem()
+	}
+	if typ.Kind() != reflect.Struct || typ == reflect.TypeFor[time.Time]() {
+		return nil
+	}
+	if typ.Implements(driverValuerType) || reflect.PointerTo(typ).Implements(sqlScannerType) {
+		return nil
+	}
+	for _, part := range strings.Split(field.Tag.Get("bun"), ",") {
+		part = strings.TrimSpace(part)
+		if strings.HasPrefix(part, "rel:") || strings.HasPrefix(part, "m2m:") {
+			return nil
+		}
+	}
+	return typ
+}
+
+// bunEmbedPrefix returns the column prefix of a `bun:"embed:prefix_"` field.
+func bunEmbedPrefix(tag string) string {
+	for _, part := range strings.Split(tag, ",") {
+		if prefix, ok := strings.CutPrefix(strings.TrimSpace(part), "embed:"); ok {
+			return prefix
+		}
+	}
+	return ""
+}
+
+// resolveNestedPatchField resolves a dotted patch key such as
+// "profile.first_name" through nested struct fields, one segment at a time.
+// The returned binding indexes the nested field from the model root and
+// names the column that stores it: the prefixed column of `embed:` fields,
+// or the column of the enclosing struct otherwise (e.g. a JSONB struct).
+func resolveNestedPatchField(lookup map[string]mapFieldBinding, key string, mode MapKeyMode) (mapFieldBinding, []nestedPatchParent, bool) {
+	root, rest, dotted := strings.Cut(key, ".")
+	if !dotted {
+		return mapFieldBinding{}, nil, false
+	}
+	parent, ok := lookup[root]
+	if !ok || parent.nested == nil {
+		return mapFieldBinding{}, nil, false
+	}
+
+	desc, err := getMapModelDescriptor(parent.nested)
+	if err != nil {
+		return mapFieldBinding{}, nil, false
+	}
+	nestedLookup, err := descriptorLookupByMode(desc, mode)
+	if err != nil {
+		return mapFieldBinding{}, nil, false
+	}
+
+	parents := []nestedPatchParent{{key: root, field: parent}}
+	child, ok := nestedLookup[rest]
+	if !ok {
+		var nestedParents []nestedPatchParent
+		if child, nestedParents, ok = resolveNestedPatchField(nestedLookup, rest, mode); !ok {
+			return mapFieldBinding{}, nil, false
+		}
+		for _, nestedParent := range nestedParents {
+			nestedParent.key = root + "." + nestedParent.key
+			parents = append(parents, nestedParent)
+		}
+	}
+	return parent.nestedBinding(child), parents, true
+}
+
+// nestedBinding returns child addressed from the parent of f.
+func (f mapFieldBinding) nestedBinding(child mapFieldBinding) mapFieldBinding {
+	binding := mapFieldBinding{
+		index:       append(slices.Clone(f.index), child.index...),
+		structName:  f.structName + "." + child.structName,
+		bunName:     f.bunName,
+		jsonName:    f.jsonName + "." + child.jsonName,
+		jsonIgnored: f.jsonIgnored || child.jsonIgnored,
+		isPrimary:   f.isPrimary,
+		readOnly:    f.readOnly || child.readOnly,
+		nested:      child.nested,
+	}
+	if f.embedPrefix != "" {
+		binding.bunName = f.embedPrefix + child.bunName
+		binding.isPrimary = child.isPrimary
+		if child.embedPrefix != "" {
+			binding.embedPrefix = f.embedPrefix + child.embedPrefix
+		}
+		if child.columnIndex != nil {
+			binding.columnIndex = append(slices.Clone(f.index), child.columnIndex...)
+		}
+		return binding
+	}
+	binding.columnIndex = f.columnPath()
+	return binding
+}
+
+func parentAllowed(allowlist map[string]struct{}, parents []nestedPatchParent) bool {
+	for _, parent := range parents {
+		if len(allowlist) > 0 && fieldAllowed(allowlist, parent.key, parent.field) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,75 @@
+package repository
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type patchPathContact struct {
+	Phone string `bun:"phone" json:"phone"`
+}
+
+type patchPathProfile struct {
+	FirstName string           `bun:"first_name" json:"firstName"`
+	LastName  string           `bun:"last_name" json:"lastName"`
+	Contact   patchPathContact `bun:"contact" json:"contact"`
+}
+
+type patchPathModel struct {
+	ID      uuid.UUID         `bun:"id,pk" json:"id"`
+	Profile *patchPathProfile `bun:"profile,type:jsonb" json:"profile"`
+	Home    mapSchemaAddress  `bun:"embed:home_" json:"home"`
+	Owner   *TestUser         `bun:"rel:belongs-to,join:owner_id=id" json:"owner"`
+}
+
+func TestApplyMapPatch_DottedPaths(t *testing.T) {
+	record := &patchPathModel{ID: uuid.New()}
+
+	patched, columns, err := ApplyMapPatch(record, map[string]any{
+		"profile.first_name":    "Ada",
+		"profile.contact.phone": "555",
+		"home.city":             "Springfield",
+		"home.street":           "Main St",
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"home_city", "home_street", "profile"}, columns)
+	require.NotNil(t, patched.Profile, "nil pointers are allocated")
+	assert.Equal(t, "Ada", patched.Profile.FirstName)
+	assert.Equal(t, "555", patched.Profile.Contact.Phone)
+	assert.Equal(t, mapSchemaAddress{Street: "Main St", City: "Springfield"}, patched.Home)
+
+	patched, columns, err = ApplyMapPatch(record, map[string]any{"profile.lastName": "Lovelace"},
+		WithPatchKeyMode(MapKeyJSON))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"profile"}, columns)
+	assert.Equal(t, "Ada", patched.Profile.FirstName, "sibling fields are kept")
+	assert.Equal(t, "Lovelace", patched.Profile.LastName)
+
+	patched, _, err = ApplyMapPatch(record, map[string]any{"Home.Street": "Elm St"}, WithPatchKeyMode(MapKeyStruct))
+	require.NoError(t, err)
+	assert.Equal(t, "Elm St", patched.Home.Street)
+}
+
+func TestApplyMapPatch_DottedPathRules(t *testing.T) {
+	record := &patchPathModel{ID: uuid.New()}
+
+	for _, key := range []string{"profile.missing", "owner.name", "id.x"} {
+		_, _, err := ApplyMapPatch(record, map[string]any{key: "x"})
+		assert.ErrorIs(t, err, ErrUnknownPatchField, key)
+	}
+
+	_, _, err := ApplyMapPatch(record, map[string]any{"profile.first_name": "Ada"}, WithPatchAllowedFields("home"))
+	assert.ErrorIs(t, err, ErrPatchFieldNotAllowed)
+
+	for _, allowed := range []string{"profile", "profile.first_name", "Profile"} {
+		_, _, err = ApplyMapPatch(record, map[string]any{"profile.first_name": "Ada"}, WithPatchAllowedFields(allowed))
+		assert.NoError(t, err, allowed)
+	}
+
+	_, _, err = ApplyMapPatch(record, map[string]any{"home.city": "x"}, WithPatchAllowedFields("home_city"))
+	assert.NoError(t, err, "embedded fields can be allowed by column")
+}
@@ -0,0 +1,90 @@
+package repository
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/goliatone/go-errors"
+)
+
+// PatchValidator checks a single patch value. field is the payload key and
+// value the payload value, before it is converted to the field type.
+type PatchValidator func(field string, value any) error
+
+// WithPatchValidator runs validator on every patched field before the patch
+// is applied. All fields are validated and the failures are returned
+// together as a *PatchValidationError. Validators run in the order they are
+// configured.
+func WithPatchValidator(validator PatchValidator) MapPatchOption {
+	return func(cfg *mapPatchConfig) {
+		if validator != nil {
+			cfg.validators = append(cfg.validators, validator)
+		}
+	}
+}
+
+// PatchFieldError is the error of a single patched field.
+type PatchFieldError struct {
+	Field string
+	Err   error
+}
+
+func (e PatchFieldError) Error() string {
+	return fmt.Sprintf("%s: %v", e.Field, e.Err)
+}
+
+func (e PatchFieldError) Unwrap() error {
+	return e.Err
+}
+
+// PatchValidationError aggregates the fields rejected by patch validators,
+// in payload key order.
+type PatchValidationError struct {
+	Errors []PatchFieldError
+}
+
+func (e *PatchValidationError) Error() string {
+	messages := make([]string, len(e.Errors))
+	for i, fieldErr := range e.Errors {
+		messages[i] = fieldErr.Error()
+	}
+	return "repository: invalid patch: " + strings.Join(messages, "; ")
+}
+
+func (e *PatchValidationError) Unwrap() []error {
+	errs := make([]error, len(e.Errors))
+	for i, fieldErr := range e.Errors {
+		errs[i] = fieldErr
+	}
+	return errs
+}
+
+// ValidationError converts e into a validation error carrying one
+// FieldError per failure, ready to be rendered as a 422 response.
+func (e *PatchValidationError) ValidationError() *errors.Error {
+	fieldErrors := make(errors.ValidationErrors, len(e.Errors))
+	for i, fieldErr := range e.Errors {
+		fieldErrors[i] = errors.FieldError{Field: fieldErr.Field, Message: fieldErr.Err.Error()}
+	}
+	return errors.NewValidation("repository: invalid patch", fieldErrors...)
+}
+
+// validatePatchPlan runs the configured validators over plan.
+func validatePatchPlan(plan []patchPlanItem, cfg mapPatchConfig) error {
+	if len(cfg.validators) == 0 {
+		return nil
+	}
+
+	var failures []PatchFieldError
+	for _, item := range plan {
+		for _, validator := range cfg.validators {
+			if err := validator(item.inputKey, item.value); err != nil {
+				failures = append(failures, PatchFieldError{Field: item.inputKey, Err: err})
+			}
+		}
+	}
+	if len(failures) == 0 {
+		return nil
+	}
+	return &PatchValidationError{Errors: failures}
+}
@@ -0,0 +1,77 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	goerrors "github.com/goliatone/go-errors"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var errPatchTooLong = errors.New("too long")
+
+func maxLengthValidator(limit int) PatchValidator {
+	return func(field string, value any) error {
+		if text, ok := value.(string); ok && len(text) > limit {
+			return fmt.Errorf("%w: at most %d characters", errPatchTooLong, limit)
+		}
+		return nil
+	}
+}
+
+func TestApplyMapPatch_Validator(t *testing.T) {
+	record := &mapHelperModel{ID: uuid.New(), Name: "Alice"}
+	positive := func(field string, value any) error {
+		if field == "count" && value.(int) < 0 {
+			return errors.New("must be positive")
+		}
+		return nil
+	}
+
+	_, _, err := ApplyMapPatch(record, map[string]any{
+		"name":   "Alexandria",
+		"count":  -1,
+		"hidden": "ok",
+	}, WithPatchValidator(maxLengthValidator(5)), WithPatchValidator(positive))
+	require.Error(t, err)
+
+	var validation *PatchValidationError
+	require.ErrorAs(t, err, &validation)
+	require.Len(t, validation.Errors, 2)
+	assert.Equal(t, "count", validation.Errors[0].Field)
+	assert.Equal(t, "name", validation.Errors[1].Field)
+	assert.ErrorIs(t, err, errPatchTooLong)
+	assert.Equal(t, "repository: invalid patch: count: must be positive; name: too long: at most 5 characters", err.Error())
+	assert.Equal(t, "Alice", record.Name, "nothing is applied")
+
+	converted := validation.ValidationError()
+	assert.True(t, goerrors.IsValidation(converted))
+	assert.Equal(t, map[string]string{
+		"count": "must be positive",
+		"name":  "too long: at most 5 characters",
+	}, converted.ValidationMap())
+
+	patched, _, err := ApplyMapPatch(record, map[string]any{"name": "Ally"}, WithPatchValidator(maxLengthValidator(5)))
+	require.NoError(t, err)
+	assert.Equal(t, "Ally", patched.Name)
+}
+
+func TestUpdateByIDWithMapPatch_Validator(t *testing.T) {
+	ctx := context.Background()
+	setupTestData(t)
+	users := newTestUserRepository(db)
+	alice, err := users.Create(ctx, &TestUser{Name: "Alice", Email: "alice@example.com"})
+	require.NoError(t, err)
+
+	_, err = UpdateByIDWithMapPatch(ctx, users, alice.ID.String(), map[string]any{"name": "Alexandria"}, nil,
+		WithPatchValidator(maxLengthValidator(5)))
+	var validation *PatchValidationError
+	require.ErrorAs(t, err, &validation)
+
+	_, err = UpdateCriteriaForMapPatch(map[string]any{"name": "Alexandria"}, WithPatchValidator(maxLengthValidator(5)))
+	require.ErrorAs(t, err, &validation)
+}
@@ -0,0 +1,67 @@
+package repository
+
+import (
+	"reflect"
+	"strings"
+)
+
+// WithProjectionRedactFields redacts the given fields, named by their Bun
+// column, JSON key or struct field name, in addition to the fields tagged
+// `repository:"redact"`. Redacted fields are omitted from the map unless
+// WithProjectionRedactMask is set.
+func WithProjectionRedactFields(fields ...string) MapProjectionOption {
+	return func(cfg *mapProjectionConfig) {
+		for _, field := range fields {
+			if field = strings.TrimSpace(field); field != "" {
+				if cfg.redactFields == nil {
+					cfg.redactFields = make(map[string]struct{})
+				}
+				cfg.redactFields[field] = struct{}{}
+			}
+		}
+	}
+}
+
+// WithProjectionRedactMask keeps redacted keys in the map with mask as their
+// value, e.g. "[REDACTED]", instead of omitting them.
+func WithProjectionRedactMask(mask any) MapProjectionOption {
+	return func(cfg *mapProjectionConfig) {
+		cfg.redactMask = mask
+		cfg.maskRedacted = true
+	}
+}
+
+// WithProjectionRedaction turns redaction on (the default) or off. Disable it
+// only for trusted internal copies, such as backups, that must keep every
+// field.
+func WithProjectionRedaction(enabled bool) MapProjectionOption {
+	return func(cfg *mapProjectionConfig) {
+		cfg.redactionDisabled = !enabled
+	}
+}
+
+// redacts reports whether field must be redacted from the projection.
+func (cfg mapProjectionConfig) redacts(field mapFieldBinding) bool {
+	if cfg.redactionDisabled {
+		return false
+	}
+	if field.redacted {
+		return true
+	}
+	for _, name := range []string{field.bunName, field.jsonName, field.structName} {
+		if _, ok := cfg.redactFields[name]; ok && name != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// hasRedactTag reports whether field is tagged `repository:"redact"`.
+func hasRedactTag(field reflect.StructField) bool {
+	for _, option := range strings.Split(field.Tag.Get("repository"), ",") {
+		if strings.TrimSpace(option) == "redact" {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,42 @@
+package repository
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/uptrace/bun"
+)
+
+type redactionTestAccount struct {
+	bun.BaseModel `bun:"table:redaction_test_accounts,alias:rta"`
+
+	ID           uuid.UUID `bun:"id,pk,notnull" json:"id"`
+	Email        string    `bun:"email" json:"email"`
+	PasswordHash string    `bun:"password_hash" json:"-" repository:"redact"`
+	SSN          string    `bun:"ssn" json:"ssn"`
+}
+
+func TestEntityToMap_Redaction(t *testing.T) {
+	account := &redactionTestAccount{ID: uuid.New(), Email: "a@example.com", PasswordHash: "hash", SSN: "123"}
+
+	values, err := RecordToMap(account)
+	require.NoError(t, err)
+	assert.NotContains(t, values, "password_hash")
+	assert.Equal(t, "123", values["ssn"])
+
+	values, err = RecordToMap(account, WithProjectionRedactFields("SSN"), WithProjectionKeyMode(MapKeyJSON))
+	require.NoError(t, err)
+	assert.Equal(t, map[string]any{"id": account.ID, "email": "a@example.com"}, values)
+
+	values, err = EntityToMap(account, WithProjectionSchema(db), WithProjectionRedactFields("ssn"), WithProjectionRedactMask("[REDACTED]"))
+	require.NoError(t, err)
+	assert.Equal(t, "[REDACTED]", values["password_hash"])
+	assert.Equal(t, "[REDACTED]", values["ssn"])
+
+	values, err = RecordToMap(account, WithProjectionRedactFields("ssn"), WithProjectionRedaction(false))
+	require.NoError(t, err)
+	assert.Equal(t, "hash", values["password_hash"])
+	assert.Equal(t, "123", values["ssn"])
+}
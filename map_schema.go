@@ -0,0 +1,103 @@
+package repository
+
+import (
+	"reflect"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/uptrace/bun"
+	"github.com/uptrace/bun/schema"
+)
+
+type mapSchemaDescriptorKey struct {
+	db  *bun.DB
+	typ reflect.Type
+}
+
+var mapSchemaDescriptorCache sync.Map // map[mapSchemaDescriptorKey]*mapModelDescriptor
+
+// resolveMapModelDescriptor returns the Bun schema backed descriptor when db is
+// set and falls back to struct tag parsing otherwise.
+func resolveMapModelDescriptor(db *bun.DB, typ reflect.Type) (*mapModelDescriptor, error) {
+	if db == nil {
+		return getMapModelDescriptor(typ)
+	}
+	return getSchemaMapModelDescriptor(db, typ)
+}
+
+func getSchemaMapModelDescriptor(db *bun.DB, typ reflect.Type) (*mapModelDescriptor, error) {
+	// validate the type the same way the tag based descriptor does
+	if _, err := getMapModelDescriptor(typ); err != nil {
+		return nil, err
+	}
+
+	key := mapSchemaDescriptorKey{db: db, typ: typ}
+	if cached, ok := mapSchemaDescriptorCache.Load(key); ok {
+		if desc, ok := cached.(*mapModelDescriptor); ok {
+			return desc, nil
+		}
+	}
+
+	desc, err := newMapModelDescriptor(schemaMapFieldBindings(typ, db.Table(typ)))
+	if err != nil {
+		return nil, err
+	}
+	mapSchemaDescriptorCache.Store(key, desc)
+	return desc, nil
+}
+
+// schemaMapFieldBindings lists the table fields, including scanonly fields, in
+// struct declaration order. Relations are not part of the table fields.
+// Fields of `bun:"embed:prefix_"` structs are keyed by their dotted struct and
+// JSON paths (e.g. Address.Street) so repeated embeds do not collide.
+func schemaMapFieldBindings(typ reflect.Type, table *schema.Table) []mapFieldBinding {
+	seen := make(map[*schema.Field]struct{}, len(table.FieldMap))
+	fields := make([]*schema.Field, 0, len(table.FieldMap))
+	for _, field := range table.FieldMap {
+		if _, ok := seen[field]; ok {
+			continue
+		}
+		seen[field] = struct{}{}
+		fields = append(fields, field)
+	}
+	slices.SortFunc(fields, func(a, b *schema.Field) int {
+		return slices.Compare(a.Index, b.Index)
+	})
+
+	bindings := make([]mapFieldBinding, 0, len(fields))
+	for _, field := range fields {
+		structName, jsonName, jsonIgnored := schemaFieldPath(typ, field.Index)
+		bindings = append(bindings, mapFieldBinding{
+			index:       field.Index,
+			structName:  structName,
+			bunName:     field.Name,
+			jsonName:    jsonName,
+			jsonIgnored: jsonIgnored,
+			isPrimary:   field.IsPK,
+			readOnly:    field.Tag.HasOption("scanonly") || isGeneratedSQLType(field.UserSQLType),
+			redacted:    hasRedactTag(field.StructField),
+			nested:      nestedPatchType(field.StructField),
+		})
+	}
+	return bindings
+}
+
+func schemaFieldPath(typ reflect.Type, index []int) (string, string, bool) {
+	var structPath, jsonPath []string
+	jsonIgnored := false
+	for i, idx := range index {
+		sf := typ.Field(idx)
+		if i == len(index)-1 || !sf.Anonymous {
+			name, ignored := parseJSONFieldName(sf)
+			structPath = append(structPath, sf.Name)
+			jsonPath = append(jsonPath, name)
+			jsonIgnored = jsonIgnored || ignored
+		}
+		typ = sf.Type
+		if typ.Kind() == reflect.Pointer {
+			typ = typ.Elem()
+		}
+	}
+	return strings.Join(structPath, "."), strings.Join(jsonPath, "."), jsonIgnored
+}
@@ -0,0 +1,179 @@
+package repository
+
+import (
+	"database/sql/driver"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// MapValueEncoder converts a projected field value before it is stored in
+// the map. field is the projected key.
+type MapValueEncoder func(field string, value any) (any, error)
+
+// WithProjectionValueEncoder converts every projected value with encoder.
+// Encoders run in the order they are configured, each receiving the output
+// of the previous one.
+func WithProjectionValueEncoder(encoder MapValueEncoder) MapProjectionOption {
+	return func(cfg *mapProjectionConfig) {
+		if encoder != nil {
+			cfg.valueEncoders = append(cfg.valueEncoders, encoder)
+		}
+	}
+}
+
+// WithProjectionJSONCompatibleValues projects values as JSON consumers
+// expect them: uuid.UUID becomes its string form, time.Time an RFC 3339
+// string, driver.Valuer types (sql.NullString, ...) their driver value, and
+// nested structs maps keyed by their JSON names. Slices and maps are
+// converted element by element.
+func WithProjectionJSONCompatibleValues() MapProjectionOption {
+	return WithProjectionValueEncoder(JSONCompatibleValue)
+}
+
+// JSONCompatibleValue is the MapValueEncoder of
+// WithProjectionJSONCompatibleValues.
+func JSONCompatibleValue(field string, value any) (any, error) {
+	encoded, err := jsonCompatibleValue(reflect.ValueOf(value))
+	if err != nil {
+		return nil, fmt.Errorf("repository: encode projection field %q: %w", field, err)
+	}
+	return encoded, nil
+}
+
+func (cfg mapProjectionConfig) encodeValue(field string, value any) (any, error) {
+	var err error
+	for _, encoder := range cfg.valueEncoders {
+		value, err = encoder(field, value)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return value, nil
+}
+
+var (
+	uuidType         = reflect.TypeFor[uuid.UUID]()
+	timeType         = reflect.TypeFor[time.Time]()
+	driverValuerType = reflect.TypeFor[driver.Valuer]()
+)
+
+func jsonCompatibleValue(value reflect.Value) (any, error) {
+	if !value.IsValid() {
+		return nil, nil
+	}
+	for value.Kind() == reflect.Pointer || value.Kind() == reflect.Interface {
+		if value.IsNil() {
+			return nil, nil
+		}
+		value = value.Elem()
+	}
+
+	switch value.Type() {
+	case uuidType:
+		return value.Interface().(uuid.UUID).String(), nil
+	case timeType:
+		return value.Interface().(time.Time).Format(time.RFC3339Nano), nil
+	}
+	if value.Type().Implements(driverValuerType) {
+		raw, err := value.Interface().(driver.Valuer).Value()
+		if err != nil {
+			return nil, err
+		}
+		if raw == nil {
+			return nil, nil
+		}
+		return jsonCompatibleValue(reflect.ValueOf(raw))
+	}
+
+	switch value.Kind() {
+	case reflect.Struct:
+		out := make(map[string]any, value.NumField())
+		if err := collectJSONCompatibleFields(value, out); err != nil {
+			return nil, err
+		}
+		return out, nil
+	case reflect.Slice:
+		if value.IsNil() {
+			return nil, nil
+		}
+		if value.Type().Elem().Kind() == reflect.Uint8 {
+			return value.Interface(), nil
+		}
+		fallthrough
+	case reflect.Array:
+		out := make([]any, value.Len())
+		for i := range out {
+			item, err := jsonCompatibleValue(value.Index(i))
+			if err != nil {
+				return nil, err
+			}
+			out[i] = item
+		}
+		return out, nil
+	case reflect.Map:
+		if value.IsNil() {
+			return nil, nil
+		}
+		out := make(map[string]any, value.Len())
+		iter := value.MapRange()
+		for iter.Next() {
+			item, err := jsonCompatibleValue(iter.Value())
+			if err != nil {
+				return nil, err
+			}
+			out[fmt.Sprint(iter.Key().Interface())] = item
+		}
+		return out, nil
+	default:
+		return value.Interface(), nil
+	}
+}
+
+// collectJSONCompatibleFields adds the exported fields of value to out using
+// encoding/json naming: json tag names, "-" skipped and embedded structs
+// inlined.
+func collectJSONCompatibleFields(value reflect.Value, out map[string]any) error {
+	typ := value.Type()
+	for i := range typ.NumField() {
+		field := typ.Field(i)
+		tag := field.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, _, _ := strings.Cut(tag, ",")
+
+		fieldValue := value.Field(i)
+		if field.Anonymous && name == "" {
+			embedded := fieldValue
+			for embedded.Kind() == reflect.Pointer {
+				if embedded.IsNil() {
+					break
+				}
+				embedded = embedded.Elem()
+			}
+			if embedded.Kind() == reflect.Struct && embedded.Type() != timeType && embedded.Type() != uuidType {
+				if err := collectJSONCompatibleFields(embedded, out); err != nil {
+					return err
+				}
+				continue
+			}
+		}
+		if !field.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+
+		encoded, err := jsonCompatibleValue(fieldValue)
+		if err != nil {
+			return err
+		}
+		out[name] = encoded
+	}
+	return nil
+}
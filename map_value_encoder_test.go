@@ -0,0 +1,113 @@
+package repository
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type mapEncoderAudit struct {
+	By uuid.UUID `json:"by"`
+	At time.Time `json:"at"`
+}
+
+type mapEncoderSettings struct {
+	mapEncoderAudit
+	Theme    string            `json:"theme"`
+	Secret   string            `json:"-"`
+	Labels   map[string]string `json:"labels"`
+	Internal string
+}
+
+type mapEncoderModel struct {
+	ID        uuid.UUID          `bun:"id,pk"`
+	Name      string             `bun:"name"`
+	Nickname  sql.NullString     `bun:"nickname"`
+	CreatedAt time.Time          `bun:"created_at"`
+	DeletedAt *time.Time         `bun:"deleted_at"`
+	Settings  mapEncoderSettings `bun:"settings,type:jsonb"`
+	Owners    []uuid.UUID        `bun:"owners,array"`
+	Payload   []byte             `bun:"payload"`
+}
+
+func TestRecordToMap_JSONCompatibleValues(t *testing.T) {
+	id := uuid.New()
+	by := uuid.New()
+	createdAt := time.Date(2024, 3, 1, 10, 30, 0, 500, time.UTC)
+	model := mapEncoderModel{
+		ID:        id,
+		Name:      "Alice",
+		Nickname:  sql.NullString{String: "al", Valid: true},
+		CreatedAt: createdAt,
+		Settings: mapEncoderSettings{
+			mapEncoderAudit: mapEncoderAudit{By: by, At: createdAt},
+			Theme:           "dark",
+			Secret:          "hidden",
+			Labels:          map[string]string{"tier": "gold"},
+			Internal:        "x",
+		},
+		Owners:  []uuid.UUID{by},
+		Payload: []byte("raw"),
+	}
+
+	projected, err := RecordToMap(model, WithProjectionJSONCompatibleValues())
+	require.NoError(t, err)
+
+	assert.Equal(t, map[string]any{
+		"id":         id.String(),
+		"name":       "Alice",
+		"nickname":   "al",
+		"created_at": "2024-03-01T10:30:00.0000005Z",
+		"deleted_at": nil,
+		"settings": map[string]any{
+			"by":       by.String(),
+			"at":       "2024-03-01T10:30:00.0000005Z",
+			"theme":    "dark",
+			"labels":   map[string]any{"tier": "gold"},
+			"Internal": "x",
+		},
+		"owners":  []any{by.String()},
+		"payload": []byte("raw"),
+	}, projected)
+
+	_, err = json.Marshal(projected)
+	require.NoError(t, err)
+
+	model.Nickname = sql.NullString{}
+	projected, err = RecordToMap(model, WithProjectionJSONCompatibleValues())
+	require.NoError(t, err)
+	assert.Nil(t, projected["nickname"])
+}
+
+func TestRecordToMap_ProjectionValueEncoder(t *testing.T) {
+	model := mapHelperModel{ID: uuid.New(), Name: "Alice", Count: 3}
+
+	projected, err := RecordToMap(model,
+		WithProjectionJSONCompatibleValues(),
+		WithProjectionValueEncoder(func(field string, value any) (any, error) {
+			if field == "id" {
+				return strings.ToUpper(value.(string)), nil
+			}
+			return value, nil
+		}),
+	)
+	require.NoError(t, err)
+	assert.Equal(t, strings.ToUpper(model.ID.String()), projected["id"], "encoders run in order")
+	assert.Equal(t, 3, projected["count"])
+
+	errRejected := errors.New("rejected")
+	_, err = RecordToMap(model, WithProjectionValueEncoder(func(field string, value any) (any, error) {
+		if field == "name" {
+			return nil, errRejected
+		}
+		return value, nil
+	}))
+	assert.ErrorIs(t, err, errRejected)
+}
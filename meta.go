@@ -57,6 +57,12 @@ type FieldMeta struct {
 	Description  string   `json:"description"`
 	DefaultValue string   `json:"default_value"`
 	Validations  []string `json:"validations,omitempty"`
+	// Column is the Bun column name, empty for relations and `bun:"-"`
+	// fields.
+	Column string `json:"column,omitempty"`
+	// SQLType is the `bun:"type:..."` override, if any.
+	SQLType   string `json:"sql_type,omitempty"`
+	IsNotNull bool   `json:"is_not_null,omitempty"`
 }
 
 // GenerateModelMeta generates metadata from a model using reflection
@@ -98,6 +104,7 @@ func GenerateModelMeta(model any) ModelMeta {
 			StructName: field.Name,
 			Name:       getJSONName(jsonTag, field.Name),
 			Type:       getFieldType(field.Type),
+			Column:     toSnakeCase(field.Name),
 		}
 
 		// Parse bun tags
@@ -162,7 +169,17 @@ func getFieldType(t reflect.Type) string {
 
 func parseBunTag(field *FieldMeta, tag string) {
 	parts := strings.Split(tag, ",")
-	for _, part := range parts {
+	for i := 0; i < len(parts); i++ {
+		part := parts[i]
+		// the first part is the column name unless it is an option
+		if i == 0 && !strings.Contains(part, ":") {
+			if part == "-" {
+				field.Column = ""
+			} else if part != "" {
+				field.Column = part
+			}
+			continue
+		}
 		switch {
 		case part == "unique":
 			field.IsUnique = true
@@ -171,8 +188,19 @@ func parseBunTag(field *FieldMeta, tag string) {
 			field.IsUnique = true
 		case part == "null":
 			field.IsNullable = true
+		case part == "notnull":
+			field.IsNotNull = true
 		case strings.HasPrefix(part, "default:"):
 			field.DefaultValue = strings.TrimPrefix(part, "default:")
+		case strings.HasPrefix(part, "type:"):
+			// types like decimal(10,2) contain the tag separator
+			for strings.Count(part, "(") > strings.Count(part, ")") && i+1 < len(parts) {
+				i++
+				part += "," + parts[i]
+			}
+			field.SQLType = strings.TrimPrefix(part, "type:")
+		case strings.HasPrefix(part, "rel:"), strings.HasPrefix(part, "m2m:"):
+			field.Column = ""
 		}
 	}
 }
@@ -32,3 +32,32 @@ func TestGenerateModelMeta_UsesTableNameFromBaseModel(t *testing.T) {
 	assert.Equal(t, "count", fieldNames["Count"].Name)
 	assert.Empty(t, fieldNames["Hidden"].Name)
 }
+
+type metaColumnTestModel struct {
+	bun.BaseModel `bun:"table:meta_columns,alias:mc"`
+
+	ID        int      `bun:"id,pk"`
+	Price     float64  `bun:"amount,notnull,type:decimal(10,2)"`
+	CreatedAt string   `bun:",nullzero"`
+	Skipped   string   `bun:"-"`
+	Parent    *int     `bun:"rel:belongs-to"`
+	Labels    []string `bun:"labels,type:jsonb"`
+}
+
+func TestGenerateModelMeta_Columns(t *testing.T) {
+	meta := GenerateModelMeta(metaColumnTestModel{})
+
+	fields := map[string]FieldMeta{}
+	for _, f := range meta.Fields {
+		fields[f.StructName] = f
+	}
+
+	assert.Equal(t, "id", fields["ID"].Column)
+	assert.Equal(t, "amount", fields["Price"].Column)
+	assert.Equal(t, "decimal(10,2)", fields["Price"].SQLType)
+	assert.True(t, fields["Price"].IsNotNull)
+	assert.Equal(t, "created_at", fields["CreatedAt"].Column)
+	assert.Empty(t, fields["Skipped"].Column)
+	assert.Empty(t, fields["Parent"].Column)
+	assert.Equal(t, "jsonb", fields["Labels"].SQLType)
+}
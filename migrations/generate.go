@@ -0,0 +1,230 @@
+// Package migrations bridges repository models and bun migrations: it
+// generates SQL migration files from repository.GenerateModelMeta output and
+// wraps migrate.Migrator with Up, Down and Status.
+//
+// Generated files follow the bun naming scheme, <version>_<name>.up.sql and
+// <version>_<name>.down.sql, so they load with migrate.Migrations.Discover.
+package migrations
+
+import (
+	stderrors "errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	repository "github.com/goliatone/go-repository-bun"
+	"github.com/uptrace/bun/dialect"
+)
+
+// ErrNoChanges is returned by AddColumnsMigration when the current model has
+// no column the previous one lacks.
+var ErrNoChanges = stderrors.New("migrations: no changes")
+
+// statementSeparator splits the statements of bun SQL migration files.
+const statementSeparator = "\n--bun:split\n\n"
+
+// Migration is a generated SQL migration.
+type Migration struct {
+	// Name is the file name suffix, e.g. create_users.
+	Name string
+	Up   string
+	Down string
+}
+
+// CreateTableMigration returns a migration creating the table of meta, with
+// its primary key and unique columns, and dropping it on down.
+func CreateTableMigration(d dialect.Name, meta repository.ModelMeta) (Migration, error) {
+	if meta.TableName == "" {
+		return Migration{}, fmt.Errorf("migrations: model has no table name")
+	}
+
+	var (
+		lines []string
+		pks   []string
+	)
+	for _, field := range meta.Fields {
+		if field.Column == "" {
+			continue
+		}
+		definition, err := columnDefinition(d, field)
+		if err != nil {
+			return Migration{}, err
+		}
+		lines = append(lines, definition)
+		if field.IsPK {
+			pks = append(pks, quoteIdent(d, field.Column))
+		}
+	}
+	if len(lines) == 0 {
+		return Migration{}, fmt.Errorf("migrations: %s has no columns", meta.TableName)
+	}
+	if len(pks) > 0 {
+		lines = append(lines, "PRIMARY KEY ("+strings.Join(pks, ", ")+")")
+	}
+	for _, field := range meta.Fields {
+		if field.Column != "" && field.IsUnique && !field.IsPK {
+			lines = append(lines, "UNIQUE ("+quoteIdent(d, field.Column)+")")
+		}
+	}
+
+	table := quoteIdent(d, meta.TableName)
+	return Migration{
+		Name: "create_" + meta.TableName,
+		Up:   "CREATE TABLE " + table + " (\n\t" + strings.Join(lines, ",\n\t") + "\n);\n",
+		Down: "DROP TABLE " + table + ";\n",
+	}, nil
+}
+
+// AddColumnsMigration returns a migration adding the columns of current
+// missing from previous, two metas of the same model, and dropping them on
+// down. Removed or changed columns are not migrated: dropping data is left to
+// hand written migrations. It returns ErrNoChanges when nothing was added.
+func AddColumnsMigration(d dialect.Name, previous, current repository.ModelMeta) (Migration, error) {
+	existing := make(map[string]struct{}, len(previous.Fields))
+	for _, field := range previous.Fields {
+		existing[field.Column] = struct{}{}
+	}
+
+	table := quoteIdent(d, current.TableName)
+	var (
+		up, down []string
+		added    []string
+	)
+	for _, field := range current.Fields {
+		if field.Column == "" {
+			continue
+		}
+		if _, ok := existing[field.Column]; ok {
+			continue
+		}
+		definition, err := columnDefinition(d, field)
+		if err != nil {
+			return Migration{}, err
+		}
+		add := "ADD COLUMN "
+		if d == dialect.MSSQL {
+			add = "ADD "
+		}
+		up = append(up, "ALTER TABLE "+table+" "+add+definition+";\n")
+		down = append([]string{"ALTER TABLE " + table + " DROP COLUMN " + quoteIdent(d, field.Column) + ";\n"}, down...)
+		added = append(added, field.Column)
+	}
+	if len(added) == 0 {
+		return Migration{}, ErrNoChanges
+	}
+
+	return Migration{
+		Name: "add_" + strings.Join(added, "_") + "_to_" + current.TableName,
+		Up:   strings.Join(up, statementSeparator),
+		Down: strings.Join(down, statementSeparator),
+	}, nil
+}
+
+// WriteFiles writes the up and down files of m to dir, versioned with at in
+// the bun format (20060102150405), and returns their paths.
+func (m Migration) WriteFiles(dir string, at time.Time) ([]string, error) {
+	prefix := filepath.Join(dir, at.UTC().Format("20060102150405")+"_"+m.Name)
+	paths := []string{prefix + ".up.sql", prefix + ".down.sql"}
+	for i, content := range []string{m.Up, m.Down} {
+		if err := os.WriteFile(paths[i], []byte(content), 0o644); err != nil {
+			return nil, err
+		}
+	}
+	return paths, nil
+}
+
+func columnDefinition(d dialect.Name, field repository.FieldMeta) (string, error) {
+	sqlType := field.SQLType
+	if sqlType == "" {
+		var err error
+		if sqlType, err = columnType(d, field.Type); err != nil {
+			return "", fmt.Errorf("migrations: column %s: %w", field.Column, err)
+		}
+	}
+
+	definition := quoteIdent(d, field.Column) + " " + sqlType
+	if field.IsPK || field.IsNotNull {
+		definition += " NOT NULL"
+	}
+	if field.DefaultValue != "" {
+		definition += " DEFAULT " + field.DefaultValue
+	}
+	return definition, nil
+}
+
+// columnType maps the Go type names of repository.FieldMeta to column types.
+func columnType(d dialect.Name, goType string) (string, error) {
+	switch goType {
+	case "string":
+		if d == dialect.MySQL || d == dialect.MSSQL {
+			return "VARCHAR(255)", nil
+		}
+		return "VARCHAR", nil
+	case "bool":
+		if d == dialect.MSSQL {
+			return "BIT", nil
+		}
+		return "BOOLEAN", nil
+	case "int8", "int16", "uint8", "uint16":
+		return "SMALLINT", nil
+	case "int32", "uint32":
+		return "INTEGER", nil
+	case "int", "int64", "uint", "uint64":
+		return "BIGINT", nil
+	case "float32":
+		return "REAL", nil
+	case "float64":
+		switch d {
+		case dialect.MySQL:
+			return "DOUBLE", nil
+		case dialect.MSSQL:
+			return "FLOAT", nil
+		}
+		return "DOUBLE PRECISION", nil
+	case "time.Time":
+		switch d {
+		case dialect.PG:
+			return "TIMESTAMPTZ", nil
+		case dialect.MySQL:
+			return "DATETIME(6)", nil
+		case dialect.MSSQL:
+			return "DATETIME2", nil
+		}
+		return "TIMESTAMP", nil
+	case "uuid.UUID":
+		if d == dialect.PG {
+			return "UUID", nil
+		}
+		return "VARCHAR(36)", nil
+	case "array:uint8":
+		switch d {
+		case dialect.PG:
+			return "BYTEA", nil
+		case dialect.MSSQL:
+			return "VARBINARY(MAX)", nil
+		}
+		return "BLOB", nil
+	}
+
+	if strings.HasPrefix(goType, "array:") || strings.HasPrefix(goType, "map[") {
+		switch d {
+		case dialect.PG:
+			return "JSONB", nil
+		case dialect.MySQL:
+			return "JSON", nil
+		case dialect.MSSQL:
+			return "NVARCHAR(MAX)", nil
+		}
+		return "TEXT", nil
+	}
+	return "", fmt.Errorf("no column type for %s, set one with bun:\"type:...\"", goType)
+}
+
+func quoteIdent(d dialect.Name, ident string) string {
+	if d == dialect.MySQL {
+		return "`" + strings.ReplaceAll(ident, "`", "``") + "`"
+	}
+	return `"` + strings.ReplaceAll(ident, `"`, `""`) + `"`
+}
@@ -0,0 +1,147 @@
+package migrations
+
+import (
+	"context"
+	"database/sql"
+	"os"
+	"testing"
+	"time"
+
+	repository "github.com/goliatone/go-repository-bun"
+	"github.com/google/uuid"
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/uptrace/bun"
+	"github.com/uptrace/bun/dialect"
+	"github.com/uptrace/bun/dialect/sqlitedialect"
+	"github.com/uptrace/bun/migrate"
+)
+
+type migrationTestAuthor struct {
+	bun.BaseModel `bun:"table:migration_authors,alias:ma"`
+
+	ID        uuid.UUID `bun:"id,pk"`
+	Email     string    `bun:"email,notnull,unique"`
+	Score     float64   `bun:"score,type:decimal(10,2)"`
+	Active    bool      `bun:"active,notnull,default:true"`
+	CreatedAt time.Time `bun:"created_at,notnull"`
+	Posts     []string  `bun:"-"`
+}
+
+type migrationTestAuthorV2 struct {
+	bun.BaseModel `bun:"table:migration_authors,alias:ma"`
+
+	ID        uuid.UUID `bun:"id,pk"`
+	Email     string    `bun:"email,notnull,unique"`
+	Score     float64   `bun:"score,type:decimal(10,2)"`
+	Active    bool      `bun:"active,notnull,default:true"`
+	CreatedAt time.Time `bun:"created_at,notnull"`
+	Posts     []string  `bun:"-"`
+	Bio       string
+	Tags      []string `bun:"tags"`
+}
+
+func TestCreateTableMigration(t *testing.T) {
+	migration, err := CreateTableMigration(dialect.PG, repository.GenerateModelMeta(migrationTestAuthor{}))
+	require.NoError(t, err)
+
+	assert.Equal(t, "create_migration_authors", migration.Name)
+	assert.Equal(t, `CREATE TABLE "migration_authors" (
+	"id" UUID NOT NULL,
+	"email" VARCHAR NOT NULL,
+	"score" decimal(10,2),
+	"active" BOOLEAN NOT NULL DEFAULT true,
+	"created_at" TIMESTAMPTZ NOT NULL,
+	PRIMARY KEY ("id"),
+	UNIQUE ("email")
+);
+`, migration.Up)
+	assert.Equal(t, "DROP TABLE \"migration_authors\";\n", migration.Down)
+
+	migration, err = CreateTableMigration(dialect.MySQL, repository.GenerateModelMeta(migrationTestAuthor{}))
+	require.NoError(t, err)
+	assert.Contains(t, migration.Up, "`email` VARCHAR(255) NOT NULL")
+}
+
+func TestAddColumnsMigration(t *testing.T) {
+	previous := repository.GenerateModelMeta(migrationTestAuthor{})
+	current := repository.GenerateModelMeta(migrationTestAuthorV2{})
+
+	migration, err := AddColumnsMigration(dialect.PG, previous, current)
+	require.NoError(t, err)
+	assert.Equal(t, "add_bio_tags_to_migration_authors", migration.Name)
+	assert.Equal(t, "ALTER TABLE \"migration_authors\" ADD COLUMN \"bio\" VARCHAR;\n"+
+		"\n--bun:split\n\n"+
+		"ALTER TABLE \"migration_authors\" ADD COLUMN \"tags\" JSONB;\n", migration.Up)
+	assert.Equal(t, "ALTER TABLE \"migration_authors\" DROP COLUMN \"tags\";\n"+
+		"\n--bun:split\n\n"+
+		"ALTER TABLE \"migration_authors\" DROP COLUMN \"bio\";\n", migration.Down)
+
+	_, err = AddColumnsMigration(dialect.PG, current, current)
+	assert.ErrorIs(t, err, ErrNoChanges)
+}
+
+func TestMigratorUpDownStatus(t *testing.T) {
+	ctx := context.Background()
+	sqldb, err := sql.Open("sqlite3", ":memory:")
+	require.NoError(t, err)
+	sqldb.SetMaxOpenConns(1)
+	t.Cleanup(func() { _ = sqldb.Close() })
+	db := bun.NewDB(sqldb, sqlitedialect.New())
+
+	dir := t.TempDir()
+	create, err := CreateTableMigration(dialect.SQLite, repository.GenerateModelMeta(migrationTestAuthor{}))
+	require.NoError(t, err)
+	start := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	paths, err := create.WriteFiles(dir, start)
+	require.NoError(t, err)
+	assert.Equal(t, dir+"/20240102030405_create_migration_authors.up.sql", paths[0])
+
+	add, err := AddColumnsMigration(dialect.SQLite,
+		repository.GenerateModelMeta(migrationTestAuthor{}),
+		repository.GenerateModelMeta(migrationTestAuthorV2{}),
+	)
+	require.NoError(t, err)
+	_, err = add.WriteFiles(dir, start.Add(time.Hour))
+	require.NoError(t, err)
+
+	registered := migrate.NewMigrations()
+	require.NoError(t, registered.Discover(os.DirFS(dir)))
+	migrator := NewMigrator(db, registered)
+
+	statuses, err := migrator.Status(ctx)
+	require.NoError(t, err)
+	require.Len(t, statuses, 2)
+	assert.False(t, statuses[0].Applied)
+
+	group, err := migrator.Up(ctx)
+	require.NoError(t, err)
+	assert.Len(t, group.Migrations, 2)
+
+	_, err = db.NewInsert().Model(&migrationTestAuthorV2{
+		ID: uuid.New(), Email: "ada@example.com", CreatedAt: time.Now(), Bio: "first", Tags: []string{"math"},
+	}).Exec(ctx)
+	require.NoError(t, err)
+
+	statuses, err = migrator.Status(ctx)
+	require.NoError(t, err)
+	assert.True(t, statuses[0].Applied)
+	assert.True(t, statuses[1].Applied)
+	assert.Equal(t, statuses[0].GroupID, statuses[1].GroupID)
+
+	group, err = migrator.Up(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, group.Migrations)
+
+	_, err = migrator.Down(ctx)
+	require.NoError(t, err)
+	statuses, err = migrator.Status(ctx)
+	require.NoError(t, err)
+	assert.False(t, statuses[0].Applied)
+
+	var count int
+	err = db.NewRaw("SELECT COUNT(*) FROM sqlite_master WHERE name = ?", "migration_authors").Scan(ctx, &count)
+	require.NoError(t, err)
+	assert.Zero(t, count)
+}
@@ -0,0 +1,98 @@
+package migrations
+
+import (
+	"context"
+	"time"
+
+	"github.com/uptrace/bun"
+	"github.com/uptrace/bun/migrate"
+)
+
+// Migrator runs bun migrations with the bookkeeping Up, Down and Status need:
+// it creates the migration tables on first use and holds the migration lock
+// while migrating, so concurrent deploys do not apply the same group twice.
+type Migrator struct {
+	migrator *migrate.Migrator
+}
+
+// MigrationStatus is the state of a registered migration.
+type MigrationStatus struct {
+	Name    string
+	Comment string
+	Applied bool
+	// GroupID and MigratedAt are set for applied migrations.
+	GroupID    int64
+	MigratedAt time.Time
+}
+
+// NewMigrator returns a Migrator applying migrations to db, e.g. the SQL files
+// written by Migration.WriteFiles and loaded with migrations.Discover.
+func NewMigrator(db *bun.DB, migrations *migrate.Migrations, opts ...migrate.MigratorOption) *Migrator {
+	return &Migrator{migrator: migrate.NewMigrator(db, migrations, opts...)}
+}
+
+// Up applies every pending migration as a new group and returns it. The group
+// is empty when there was nothing to apply.
+func (m *Migrator) Up(ctx context.Context) (*migrate.MigrationGroup, error) {
+	var group *migrate.MigrationGroup
+	err := m.locked(ctx, func() error {
+		var err error
+		group, err = m.migrator.Migrate(ctx)
+		return err
+	})
+	return group, err
+}
+
+// Down rolls back the last applied group and returns it.
+func (m *Migrator) Down(ctx context.Context) (*migrate.MigrationGroup, error) {
+	var group *migrate.MigrationGroup
+	err := m.locked(ctx, func() error {
+		var err error
+		group, err = m.migrator.Rollback(ctx)
+		return err
+	})
+	return group, err
+}
+
+// Status lists the registered migrations in the order they apply.
+func (m *Migrator) Status(ctx context.Context) ([]MigrationStatus, error) {
+	if err := m.migrator.Init(ctx); err != nil {
+		return nil, err
+	}
+	migrations, err := m.migrator.MigrationsWithStatus(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	statuses := make([]MigrationStatus, len(migrations))
+	for i, migration := range migrations {
+		statuses[i] = MigrationStatus{
+			Name:       migration.Name,
+			Comment:    migration.Comment,
+			Applied:    migration.IsApplied(),
+			GroupID:    migration.GroupID,
+			MigratedAt: migration.MigratedAt,
+		}
+	}
+	return statuses, nil
+}
+
+// Migrator returns the wrapped migrate.Migrator.
+func (m *Migrator) Migrator() *migrate.Migrator {
+	return m.migrator
+}
+
+func (m *Migrator) locked(ctx context.Context, fn func() error) (err error) {
+	if err := m.migrator.Init(ctx); err != nil {
+		return err
+	}
+	if err := m.migrator.Lock(ctx); err != nil {
+		return err
+	}
+	defer func() {
+		if unlockErr := m.migrator.Unlock(ctx); err == nil {
+			err = unlockErr
+		}
+	}()
+	return fn()
+}
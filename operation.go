@@ -0,0 +1,123 @@
+package repository
+
+import (
+	"context"
+	stderrors "errors"
+	"fmt"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"strings"
+	"sync"
+
+	"github.com/goliatone/go-repository-bun/repositoryctx"
+	"github.com/uptrace/bun"
+)
+
+// OperationInfo describes the repository method that issued a query. Every
+// exported repository method stores it on the context before querying, so
+// query hooks registered on the bun.DB can read it with OperationFromContext.
+type OperationInfo = repositoryctx.OperationInfo
+
+// OperationFromContext returns the repository operation running the query
+// ctx belongs to, if any.
+func OperationFromContext(ctx context.Context) (OperationInfo, bool) {
+	return repositoryctx.Operation(ctx)
+}
+
+// packagePrefix prefixes the function names of this package in stack frames.
+var packagePrefix = reflect.TypeOf(repoConfig{}).PkgPath() + "."
+
+// withOperation attaches the operation to ctx. Methods delegating to other
+// methods of the same repository, e.g. DeleteMany to DeleteWhere, keep the
+// operation the caller started. With WithCircuitBreaker the returned context
+// fails every query while the breaker is open. WithEncryptedColumns and
+// WithIntegrityColumn decrypt and verify the records its queries scan.
+func (r *repo[T]) withOperation(ctx context.Context, operation string, criteriaCount int) context.Context {
+	if r.circuitBreaker != nil {
+		ctx = r.circuitBreaker.guard(ctx)
+	}
+	ctx = r.withEncryption(ctx)
+	ctx = r.withIntegrity(ctx)
+	entity := modelTypeName[T]()
+	if current, ok := repositoryctx.Operation(ctx); ok && current.Entity == entity {
+		return ctx
+	}
+	return repositoryctx.WithOperation(ctx, OperationInfo{
+		Entity:        entity,
+		Operation:     operation,
+		CriteriaCount: criteriaCount,
+		Caller:        operationCaller(),
+	})
+}
+
+// modelTypeName returns the struct name of T, as bun reports it in
+// schema.Table.TypeName.
+func modelTypeName[T any]() string {
+	typ := reflect.TypeFor[T]()
+	for typ.Kind() == reflect.Pointer {
+		typ = typ.Elem()
+	}
+	return typ.Name()
+}
+
+// operationCaller returns the dir/file:line of the first frame outside this
+// package. Tests of the package count as callers.
+func operationCaller() string {
+	var pcs [32]uintptr
+	n := runtime.Callers(3, pcs[:])
+	frames := runtime.CallersFrames(pcs[:n])
+	for {
+		frame, more := frames.Next()
+		if !strings.HasPrefix(frame.Function, packagePrefix) || strings.HasSuffix(frame.File, "_test.go") {
+			return fmt.Sprintf("%s/%s:%d", filepath.Base(filepath.Dir(frame.File)), filepath.Base(frame.File), frame.Line)
+		}
+		if !more {
+			return ""
+		}
+	}
+}
+
+// scanErrorsKey stores the scanErrors of the repository read running on ctx.
+type scanErrorsKey struct{}
+
+// scanErrors collects the errors query hooks hit on records a repository
+// read scans, e.g. a failed decryption, so the read can return them.
+type scanErrors struct {
+	mu   sync.Mutex
+	errs []error
+}
+
+// collectScanErrors returns ctx with a scanErrors collector, and a function
+// returning the joined errors collected so far.
+func collectScanErrors(ctx context.Context) (context.Context, func() error) {
+	collected := &scanErrors{}
+	return context.WithValue(ctx, scanErrorsKey{}, collected), func() error {
+		collected.mu.Lock()
+		defer collected.mu.Unlock()
+		return stderrors.Join(collected.errs...)
+	}
+}
+
+// reportScanError hands err to the repository read running on ctx, or to the
+// error handler of db for queries outside one.
+func reportScanError(ctx context.Context, db *bun.DB, hook bun.QueryHook, err error) {
+	collected, ok := ctx.Value(scanErrorsKey{}).(*scanErrors)
+	if !ok {
+		ReportQueryHookError(db, hook, err)
+		return
+	}
+	collected.mu.Lock()
+	collected.errs = append(collected.errs, err)
+	collected.mu.Unlock()
+}
+
+// scanRecords runs scan, e.g. q.Scan, and returns its mapped error, or else
+// the errors the query hooks reported for the scanned records.
+func (r *repo[T]) scanRecords(ctx context.Context, scan func(context.Context, ...any) error, dest ...any) error {
+	ctx, collected := collectScanErrors(ctx)
+	if err := scan(ctx, dest...); err != nil {
+		return r.mapError(err)
+	}
+	return collected()
+}
@@ -0,0 +1,65 @@
+package repository
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/uptrace/bun"
+	"github.com/uptrace/bun/dialect/sqlitedialect"
+)
+
+type operationRecorder struct {
+	mu         sync.Mutex
+	operations []OperationInfo
+}
+
+func (r *operationRecorder) BeforeQuery(ctx context.Context, _ *bun.QueryEvent) context.Context {
+	info, _ := OperationFromContext(ctx)
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.operations = append(r.operations, info)
+	return ctx
+}
+
+func (r *operationRecorder) AfterQuery(context.Context, *bun.QueryEvent) {}
+
+func TestOperationInfoReachesQueryHooks(t *testing.T) {
+	ctx := context.Background()
+	testDB := newDialectTestDB(t, sqlitedialect.New())
+	_, err := testDB.NewCreateTable().Model((*TestUser)(nil)).Exec(ctx)
+	require.NoError(t, err)
+
+	recorder := &operationRecorder{}
+	testDB.AddQueryHook(recorder)
+	repo := newTestUserRepository(testDB)
+
+	user, err := repo.Create(ctx, &TestUser{Name: "Alice", Email: "alice@example.com"})
+	require.NoError(t, err)
+	_, _, err = repo.List(ctx, SelectBy("name", "=", "Alice"), SelectColumns("id", "name"))
+	require.NoError(t, err)
+	require.NoError(t, repo.DeleteMany(ctx, DeleteBy("id", "=", user.ID.String())))
+	_, err = testDB.NewSelect().Model((*TestUser)(nil)).Count(ctx)
+	require.NoError(t, err)
+
+	require.NotEmpty(t, recorder.operations)
+	create := recorder.operations[0]
+	assert.Equal(t, "TestUser", create.Entity)
+	assert.Equal(t, "Create", create.Operation)
+	assert.Zero(t, create.CriteriaCount)
+	assert.Contains(t, create.Caller, "/operation_test.go:")
+
+	var methods []string
+	for _, info := range recorder.operations {
+		methods = append(methods, info.Operation)
+		if info.Operation == "List" {
+			assert.Equal(t, 2, info.CriteriaCount)
+		}
+	}
+	assert.Contains(t, methods, "List")
+	assert.Contains(t, methods, "DeleteMany")
+	assert.NotContains(t, methods, "DeleteWhere", "delegated calls keep the outer operation")
+	assert.Empty(t, methods[len(methods)-1], "queries outside the repository carry no operation")
+}
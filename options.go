@@ -7,6 +7,7 @@ import (
 	"reflect"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/uptrace/bun"
 )
@@ -20,8 +21,45 @@ type repoConfig struct {
 	defaultListLimit                int
 	defaultListOffset               int
 	allowFullTableDelete            bool
+	allowFullTableUpdate            bool
+	defaultOrder                    []string
+	defaultRelations                []string
+	listWindowCount                 bool
+	anonymizeBatchSize              int
+	constraintMapping               map[string]FieldErrorSpec
+	errorMappers                    []DatabaseErrorMapper
+	retentionPolicies               []RetentionPolicy
+	retentionProgress               RetentionProgressFunc
+	csvProfiles                     []CSVProfile
+	staleReadCache                  Cache
+	staleReadMaxAge                 time.Duration
 	recordLookupResolver            any
 	recordLookupResolverType        reflect.Type
+	atomicGetOrCreate               bool
+	readOnlyColumns                 []string
+	progressReporter                ProgressReporter
+	uniquePrechecks                 [][]string
+	preparedStatements              bool
+	eventPublishers                 []EventPublisher
+	eventErrorHandler               EventErrorHandler
+	outbox                          bool
+	parentColumn                    string
+	positionColumn                  string
+	slug                            *slugConfig
+	transitions                     *transitionConfig
+	encryption                      *encryptionConfig
+	integrity                       *integrityConfig
+	historySuffix                   string
+	circuitBreakerThreshold         int
+	circuitBreakerCooldown          time.Duration
+	filterableColumns               []string
+	sortableColumns                 []string
+	strictCriteria                  bool
+	softDeleteColumn                string
+	upsertLookupUniqueColumns       bool
+	listAllBatchSize                int
+	parallelCount                   bool
+	bulkCopier                      BulkCopier
 }
 
 // RecordLookupResolver resolves select criteria used to find an existing record
@@ -47,6 +85,36 @@ func WithRecordLookupResolver[T any](resolver RecordLookupResolver[T]) RepoOptio
 	}
 }
 
+// WithUpsertLookupUniqueColumns makes Upsert, GetOrCreate and the other
+// existing-record lookups also match on the single-column unique fields of the
+// model, as reported by GetModelFields, once ID and identifier lookups miss.
+// Columns are tried in model order, skipping those the record leaves empty,
+// before any WithRecordLookupResolver criteria. Fields in named unique groups
+// (bun:",unique:group") are composite keys and are not used.
+func WithUpsertLookupUniqueColumns() RepoOption {
+	return func(cfg *repoConfig) {
+		if cfg != nil {
+			cfg.upsertLookupUniqueColumns = true
+		}
+	}
+}
+
+// WithAtomicGetOrCreate makes GetOrCreate insert first, ignoring conflicts on
+// the identifier column, and reselect the existing record when the insert was
+// skipped, so concurrent calls never fail with duplicate key errors. The
+// identifier column needs a unique constraint. It applies on Postgres and
+// MySQL to records with an identifier value; SQLite, MSSQL and other records
+// keep the select-then-insert strategy. GetOrCreateWith runs onCreate before
+// the insert, so it may also run when the record turns out to exist.
+func WithAtomicGetOrCreate() RepoOption {
+	return func(cfg *repoConfig) {
+		if cfg == nil {
+			return
+		}
+		cfg.atomicGetOrCreate = true
+	}
+}
+
 // WithDefaultListPagination configures repository-level default pagination.
 // Use this during repository initialization.
 func WithDefaultListPagination(limit, offset int) RepoOption {
@@ -61,6 +129,79 @@ func WithDefaultListPagination(limit, offset int) RepoOption {
 	}
 }
 
+// WithDefaultOrder configures the ordering applied by List when none of the
+// provided criteria add an ORDER BY clause, making pagination deterministic.
+// Expressions use the OrderBy format ("created_at DESC", "id").
+// Invalid expressions are reported by Validate.
+func WithDefaultOrder(expr ...string) RepoOption {
+	return func(cfg *repoConfig) {
+		if cfg == nil {
+			return
+		}
+		cfg.defaultOrder = append([]string(nil), expr...)
+	}
+}
+
+// WithDefaultRelations configures relations that Get, GetByID, GetByIDs,
+// GetByIdentifier and List load automatically, e.g. "Company" or "Company.Owner".
+// Use WithoutRelations to skip them for a single call.
+// Unknown relations are reported by Validate.
+func WithDefaultRelations(relations ...string) RepoOption {
+	return func(cfg *repoConfig) {
+		if cfg == nil {
+			return
+		}
+		for _, relation := range relations {
+			relation = strings.TrimSpace(relation)
+			if relation == "" {
+				continue
+			}
+			cfg.defaultRelations = append(cfg.defaultRelations, relation)
+		}
+	}
+}
+
+// WithAnonymizeBatchSize sets how many rows Anonymize updates per statement.
+// Defaults to 500.
+func WithAnonymizeBatchSize(size int) RepoOption {
+	return func(cfg *repoConfig) {
+		if cfg == nil || size <= 0 {
+			return
+		}
+		cfg.anonymizeBatchSize = size
+	}
+}
+
+// WithListWindowCount makes List fetch the page and the total in a single
+// statement using COUNT(*) OVER() instead of a separate count query, so the
+// total always matches the returned page. It applies on Postgres, SQLite,
+// MSSQL and MySQL 8+; other drivers, lists loading has-many or many-to-many
+// relations, and empty pages keep the regular count query.
+func WithListWindowCount(enabled bool) RepoOption {
+	return func(cfg *repoConfig) {
+		if cfg == nil {
+			return
+		}
+		cfg.listWindowCount = enabled
+	}
+}
+
+// WithErrorMapper prepends mappers to the driver error mapping chain, e.g. to
+// map domain specific SQLSTATEs or errors raised by triggers. Mappers run in
+// the order given and return nil to fall through to the next mapper.
+func WithErrorMapper(mappers ...DatabaseErrorMapper) RepoOption {
+	return func(cfg *repoConfig) {
+		if cfg == nil {
+			return
+		}
+		for _, mapper := range mappers {
+			if mapper != nil {
+				cfg.errorMappers = append(cfg.errorMappers, mapper)
+			}
+		}
+	}
+}
+
 // WithAllowFullTableDelete enables DeleteWhere/DeleteMany calls without criteria.
 // Defaults to false for safety.
 func WithAllowFullTableDelete(enabled bool) RepoOption {
@@ -72,6 +213,17 @@ func WithAllowFullTableDelete(enabled bool) RepoOption {
 	}
 }
 
+// WithAllowFullTableUpdate enables bulk updates without criteria.
+// Defaults to false for safety.
+func WithAllowFullTableUpdate(enabled bool) RepoOption {
+	return func(cfg *repoConfig) {
+		if cfg == nil {
+			return
+		}
+		cfg.allowFullTableUpdate = enabled
+	}
+}
+
 // QueryHookKeyer allows hooks to provide a stable identity for deduplication.
 type QueryHookKeyer interface {
 	QueryHookKey() string
@@ -191,6 +343,20 @@ func getHookRegistryEntry(db *bun.DB) *hookRegistryEntry {
 	return nil
 }
 
+// ReportQueryHookError passes err to the error handler configured for db with
+// WithQueryHookErrorHandler, for options that fail to build their hook.
+func ReportQueryHookError(db *bun.DB, hook bun.QueryHook, err error) {
+	handler := QueryHookErrorHandler(LogQueryHookErrorHandler)
+	if entry := getHookRegistryEntry(db); entry != nil {
+		entry.mu.Lock()
+		if entry.handler != nil {
+			handler = entry.handler
+		}
+		entry.mu.Unlock()
+	}
+	handler(db, hook, err)
+}
+
 func setQueryHookErrorHandler(db *bun.DB, handler QueryHookErrorHandler) {
 	if db == nil {
 		return
@@ -0,0 +1,196 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/goliatone/go-errors"
+	"github.com/google/uuid"
+	"github.com/uptrace/bun"
+)
+
+// defaultPositionColumn is the position column of PositionReorderer when
+// WithPositionColumn is not configured.
+const defaultPositionColumn = "position"
+
+// WithPositionColumn sets the integer column holding the position of records
+// in sortable lists, used by PositionReorderer. Defaults to "position".
+// Unknown columns are reported by Validate.
+func WithPositionColumn(column string) RepoOption {
+	return func(cfg *repoConfig) {
+		if cfg == nil {
+			return
+		}
+		cfg.positionColumn = strings.TrimSpace(column)
+	}
+}
+
+// PositionReorderer is an optional capability for repositories of manually
+// ordered lists, e.g. kanban cards or menu items.
+type PositionReorderer interface {
+	Reorder(ctx context.Context, ids []uuid.UUID) error
+	ReorderTx(ctx context.Context, tx bun.IDB, ids []uuid.UUID) error
+	MoveBefore(ctx context.Context, id, beforeID uuid.UUID, criteria ...SelectCriteria) error
+	MoveBeforeTx(ctx context.Context, tx bun.IDB, id, beforeID uuid.UUID, criteria ...SelectCriteria) error
+	MoveAfter(ctx context.Context, id, afterID uuid.UUID, criteria ...SelectCriteria) error
+	MoveAfterTx(ctx context.Context, tx bun.IDB, id, afterID uuid.UUID, criteria ...SelectCriteria) error
+}
+
+// Reorder sets the position column of the records with ids to their index in
+// ids (0 based) with a single UPDATE. Records not listed keep their
+// position. Missing ids fail with a not found error and roll the update
+// back. Like UpdateWhere, no domain events are published.
+func (r *repo[T]) Reorder(ctx context.Context, ids []uuid.UUID) error {
+	return r.ReorderTx(ctx, r.db, ids)
+}
+
+func (r *repo[T]) ReorderTx(ctx context.Context, tx bun.IDB, ids []uuid.UUID) error {
+	ctx = r.withOperation(ctx, "Reorder", 0)
+	position, err := r.listPositionColumn()
+	if err != nil {
+		return err
+	}
+	if len(ids) == 0 {
+		return nil
+	}
+	seen := make(map[uuid.UUID]bool, len(ids))
+	for _, id := range ids {
+		if id == uuid.Nil || seen[id] {
+			return errors.NewValidation(
+				"repository: invalid reorder",
+				errors.FieldError{Field: "ids", Message: "ids must be unique and not nil"},
+			)
+		}
+		seen[id] = true
+	}
+
+	var expr strings.Builder
+	args := make([]any, 0, 2*len(ids)+1)
+	expr.WriteString("? = CASE ?TableAlias.id")
+	args = append(args, bun.Ident(position))
+	for i, id := range ids {
+		expr.WriteString(" WHEN ? THEN ?")
+		args = append(args, id, i)
+	}
+	expr.WriteString(" END")
+
+	return runInTx(ctx, tx, func(ctx context.Context, tx bun.IDB) error {
+		q := tx.NewUpdate().
+			Model(r.handlers.NewRecord()).
+			Set(expr.String(), args...).
+			Where("?TableAlias.id IN (?)", bun.In(ids))
+		q = r.applyUpdateScopes(ctx, q)
+		if err := r.applyUpdateCriteria(q, nil); err != nil {
+			return err
+		}
+		if err := r.recordHistory(ctx, tx, EventUpdate, ids...); err != nil {
+			return err
+		}
+
+		res, err := q.Exec(ctx)
+		if err != nil {
+			return r.mapError(err)
+		}
+		affected, err := rowsAffected(res)
+		if err != nil {
+			return err
+		}
+		if affected < int64(len(ids)) {
+			return NewRecordNotFound()
+		}
+		_, err = r.rehashIntegrity(ctx, tx, ids)
+		return err
+	})
+}
+
+// MoveBefore moves id right before beforeID in the list of records matched
+// by criteria, e.g. the cards of one column, and renumbers that list with
+// Reorder. The list is read in position order, ties broken by id.
+func (r *repo[T]) MoveBefore(ctx context.Context, id, beforeID uuid.UUID, criteria ...SelectCriteria) error {
+	return r.MoveBeforeTx(ctx, r.db, id, beforeID, criteria...)
+}
+
+func (r *repo[T]) MoveBeforeTx(ctx context.Context, tx bun.IDB, id, beforeID uuid.UUID, criteria ...SelectCriteria) error {
+	ctx = r.withOperation(ctx, "MoveBefore", len(criteria))
+	return r.moveRelative(ctx, tx, id, beforeID, 0, criteria)
+}
+
+// MoveAfter moves id right after afterID, like MoveBefore.
+func (r *repo[T]) MoveAfter(ctx context.Context, id, afterID uuid.UUID, criteria ...SelectCriteria) error {
+	return r.MoveAfterTx(ctx, r.db, id, afterID, criteria...)
+}
+
+func (r *repo[T]) MoveAfterTx(ctx context.Context, tx bun.IDB, id, afterID uuid.UUID, criteria ...SelectCriteria) error {
+	ctx = r.withOperation(ctx, "MoveAfter", len(criteria))
+	return r.moveRelative(ctx, tx, id, afterID, 1, criteria)
+}
+
+// moveRelative moves id to the index of targetID plus offset.
+func (r *repo[T]) moveRelative(ctx context.Context, tx bun.IDB, id, targetID uuid.UUID, offset int, criteria []SelectCriteria) error {
+	position, err := r.listPositionColumn()
+	if err != nil {
+		return err
+	}
+	if id == targetID {
+		return nil
+	}
+
+	return runInTx(ctx, tx, func(ctx context.Context, tx bun.IDB) error {
+		var ids []uuid.UUID
+		q := tx.NewSelect().
+			Model(r.handlers.NewRecord()).
+			ColumnExpr("?TableAlias.id")
+		q = r.applySelectScopes(ctx, q)
+		if err := r.applySelectCriteria(q, criteria); err != nil {
+			return err
+		}
+		q.OrderExpr("?TableAlias.? ASC, ?TableAlias.id ASC", bun.Ident(position))
+		if err := q.Scan(ctx, &ids); err != nil {
+			return r.mapError(err)
+		}
+
+		from := slices.Index(ids, id)
+		if from < 0 || !slices.Contains(ids, targetID) {
+			return NewRecordNotFound()
+		}
+		ids = slices.Delete(ids, from, from+1)
+		to := slices.Index(ids, targetID) + offset
+		ids = slices.Insert(ids, to, id)
+		return r.ReorderTx(ctx, tx, ids)
+	})
+}
+
+func (r *repo[T]) listPositionColumn() (string, error) {
+	column := r.positionColumn
+	if column == "" {
+		column = defaultPositionColumn
+	}
+	if table := r.modelTable(); table != nil {
+		if _, ok := table.FieldMap[column]; ok {
+			return column, nil
+		}
+	}
+	return "", errors.NewValidation(
+		"repository: unknown position column",
+		errors.FieldError{Field: "positionColumn", Message: fmt.Sprintf("column %q does not exist on %s; configure it with WithPositionColumn", column, r.TableName())},
+	)
+}
+
+func (r *repo[T]) validatePositionColumn() error {
+	if r.positionColumn == "" {
+		return nil
+	}
+	table := r.modelTable()
+	if table == nil {
+		return nil
+	}
+	if _, ok := table.FieldMap[r.positionColumn]; !ok {
+		return errors.NewValidation("repository configuration invalid", errors.FieldError{
+			Field:   "repoOptions.WithPositionColumn",
+			Message: fmt.Sprintf("unknown column %q on %s", r.positionColumn, table.Name),
+		})
+	}
+	return nil
+}
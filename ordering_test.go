@@ -0,0 +1,101 @@
+package repository
+
+import (
+	"context"
+	"testing"
+
+	goerrors "github.com/goliatone/go-errors"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/uptrace/bun"
+)
+
+type orderingTestCard struct {
+	bun.BaseModel `bun:"table:ordering_test_cards,alias:otc"`
+
+	ID     uuid.UUID `bun:"id,pk,notnull"`
+	Name   string    `bun:"name,notnull"`
+	Lane   string    `bun:"lane,notnull"`
+	SortAt int       `bun:"sort_at,notnull"`
+}
+
+func newOrderingTestRepository(t *testing.T) (Repository[*orderingTestCard], map[string]uuid.UUID) {
+	t.Helper()
+	ctx := context.Background()
+	repo := newTestRepository[*orderingTestCard](t, WithPositionColumn("sort_at"))
+	require.NoError(t, repo.(Validator).Validate())
+
+	ids := map[string]uuid.UUID{}
+	for i, name := range []string{"a", "b", "c", "d"} {
+		created, err := repo.Create(ctx, &orderingTestCard{Name: name, Lane: "todo", SortAt: i})
+		require.NoError(t, err)
+		ids[name] = created.ID
+	}
+	created, err := repo.Create(ctx, &orderingTestCard{Name: "x", Lane: "done"})
+	require.NoError(t, err)
+	ids["x"] = created.ID
+	return repo, ids
+}
+
+func orderedCardNames(t *testing.T, repo Repository[*orderingTestCard], lane string) []string {
+	t.Helper()
+	cards, _, err := repo.List(context.Background(), SelectBy("lane", "=", lane), SelectOrderAsc("sort_at"))
+	require.NoError(t, err)
+	names := make([]string, len(cards))
+	for i, card := range cards {
+		names[i] = card.Name
+	}
+	return names
+}
+
+func TestPositionReorderer_Reorder(t *testing.T) {
+	ctx := context.Background()
+	repo, ids := newOrderingTestRepository(t)
+	reorderer, ok := repo.(PositionReorderer)
+	require.True(t, ok)
+
+	require.NoError(t, reorderer.Reorder(ctx, []uuid.UUID{ids["d"], ids["b"], ids["a"], ids["c"]}))
+	assert.Equal(t, []string{"d", "b", "a", "c"}, orderedCardNames(t, repo, "todo"))
+
+	card, err := repo.GetByID(ctx, ids["a"].String())
+	require.NoError(t, err)
+	assert.Equal(t, 2, card.SortAt)
+
+	err = reorderer.Reorder(ctx, []uuid.UUID{ids["a"], uuid.New()})
+	assert.ErrorIs(t, err, ErrRecordNotFound)
+	assert.Equal(t, []string{"d", "b", "a", "c"}, orderedCardNames(t, repo, "todo"))
+
+	err = reorderer.Reorder(ctx, []uuid.UUID{ids["a"], ids["a"]})
+	assert.True(t, goerrors.IsValidation(err))
+}
+
+func TestPositionReorderer_MoveBeforeAfter(t *testing.T) {
+	ctx := context.Background()
+	repo, ids := newOrderingTestRepository(t)
+	reorderer := repo.(PositionReorderer)
+	todo := SelectBy("lane", "=", "todo")
+
+	require.NoError(t, reorderer.MoveBefore(ctx, ids["d"], ids["b"], todo))
+	assert.Equal(t, []string{"a", "d", "b", "c"}, orderedCardNames(t, repo, "todo"))
+
+	require.NoError(t, reorderer.MoveAfter(ctx, ids["a"], ids["c"], todo))
+	assert.Equal(t, []string{"d", "b", "c", "a"}, orderedCardNames(t, repo, "todo"))
+
+	require.NoError(t, reorderer.MoveBefore(ctx, ids["a"], ids["d"], todo))
+	assert.Equal(t, []string{"a", "d", "b", "c"}, orderedCardNames(t, repo, "todo"))
+
+	err := reorderer.MoveAfter(ctx, ids["x"], ids["a"], todo)
+	assert.ErrorIs(t, err, ErrRecordNotFound)
+}
+
+func TestPositionReorderer_RequiresPositionColumn(t *testing.T) {
+	repo := newTestUserRepository(db)
+	err := repo.(PositionReorderer).Reorder(context.Background(), []uuid.UUID{uuid.New()})
+	assert.True(t, goerrors.IsValidation(err))
+
+	repo = NewRepositoryWithConfig(db, testUserHandlers(), nil, WithPositionColumn("missing"))
+	err = repo.(Validator).Validate()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "repository configuration invalid")
+}
@@ -0,0 +1,356 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/uptrace/bun"
+	"github.com/uptrace/bun/dialect"
+)
+
+const (
+	defaultOutboxInterval    = time.Second
+	defaultOutboxBatchSize   = 100
+	defaultOutboxMaxAttempts = 10
+)
+
+// OutboxMessage is an Event stored in the outbox table by the transaction of
+// the write that produced it, waiting to be relayed by an OutboxDispatcher.
+type OutboxMessage struct {
+	bun.BaseModel `bun:"table:repository_outbox_messages,alias:rom"`
+
+	ID         uuid.UUID `bun:"id,pk,type:uuid" json:"id"`
+	Entity     string    `bun:"entity,notnull" json:"entity"`
+	Operation  string    `bun:"operation,notnull" json:"operation"`
+	RecordID   string    `bun:"record_id,notnull" json:"record_id"`
+	Columns    string    `bun:"columns,notnull" json:"columns"`
+	Payload    string    `bun:"payload,notnull" json:"payload"`
+	Actor      string    `bun:"actor" json:"actor,omitempty"`
+	OccurredAt time.Time `bun:"occurred_at,notnull" json:"occurred_at"`
+	Attempts   int       `bun:"attempts,notnull,default:0" json:"attempts"`
+	LastError  string    `bun:"last_error" json:"last_error,omitempty"`
+	// DispatchedAt is set once the message was relayed.
+	DispatchedAt *time.Time `bun:"dispatched_at" json:"dispatched_at,omitempty"`
+}
+
+// Event decodes the message back into the Event it stores. Record holds the
+// JSON encoding of the written record as a json.RawMessage.
+func (m OutboxMessage) Event() (Event, error) {
+	event := Event{
+		Entity:     m.Entity,
+		Operation:  EventOperation(m.Operation),
+		ID:         m.RecordID,
+		Actor:      m.Actor,
+		OccurredAt: m.OccurredAt,
+		Record:     json.RawMessage(m.Payload),
+	}
+	if m.Columns != "" {
+		if err := json.Unmarshal([]byte(m.Columns), &event.Columns); err != nil {
+			return Event{}, fmt.Errorf("repository: decode outbox columns: %w", err)
+		}
+	}
+	return event, nil
+}
+
+// CreateOutboxTable creates the table backing WithOutbox if it does not exist
+// yet.
+func CreateOutboxTable(ctx context.Context, db bun.IDB) error {
+	_, err := db.NewCreateTable().
+		Model((*OutboxMessage)(nil)).
+		IfNotExists().
+		Exec(ctx)
+	return err
+}
+
+// OutboxRepository stores events in the outbox table. It is a
+// TxEventPublisher, so registering it with WithEventPublisher (or WithOutbox)
+// stores the events of repository writes in the same transaction as the
+// write. The table must exist, see CreateOutboxTable.
+type OutboxRepository struct {
+	db bun.IDB
+}
+
+// NewOutboxRepository returns an outbox stored in db.
+func NewOutboxRepository(db bun.IDB) *OutboxRepository {
+	return &OutboxRepository{db: db}
+}
+
+// WithOutbox stores the events of every record written by the repository in
+// the outbox table of its database, in the transaction of the write. Relay
+// them with an OutboxDispatcher.
+func WithOutbox() RepoOption {
+	return func(cfg *repoConfig) {
+		if cfg == nil {
+			return
+		}
+		cfg.outbox = true
+	}
+}
+
+// Publish stores event outside of any transaction.
+func (o *OutboxRepository) Publish(ctx context.Context, event Event) error {
+	return o.PublishTx(ctx, o.db, event)
+}
+
+// PublishTx stores event using tx.
+func (o *OutboxRepository) PublishTx(ctx context.Context, tx bun.IDB, event Event) error {
+	message, err := newOutboxMessage(event)
+	if err != nil {
+		return err
+	}
+	if _, err := tx.NewInsert().Model(message).Exec(ctx); err != nil {
+		return fmt.Errorf("repository: store outbox message: %w", err)
+	}
+	return nil
+}
+
+func newOutboxMessage(event Event) (*OutboxMessage, error) {
+	columns, err := json.Marshal(event.Columns)
+	if err != nil {
+		return nil, fmt.Errorf("repository: encode outbox columns: %w", err)
+	}
+	payload, err := json.Marshal(event.Record)
+	if err != nil {
+		return nil, fmt.Errorf("repository: encode outbox payload: %w", err)
+	}
+	occurredAt := event.OccurredAt
+	if occurredAt.IsZero() {
+		occurredAt = time.Now().UTC()
+	}
+	return &OutboxMessage{
+		ID:         uuid.New(),
+		Entity:     event.Entity,
+		Operation:  string(event.Operation),
+		RecordID:   event.ID,
+		Columns:    string(columns),
+		Payload:    string(payload),
+		Actor:      event.Actor,
+		OccurredAt: occurredAt,
+	}, nil
+}
+
+// Pending lists up to limit messages not dispatched yet that failed fewer
+// than maxAttempts times, oldest first. A limit or maxAttempts <= 0 means no
+// limit.
+func (o *OutboxRepository) Pending(ctx context.Context, limit, maxAttempts int) ([]OutboxMessage, error) {
+	return o.PendingTx(ctx, o.db, limit, maxAttempts)
+}
+
+func (o *OutboxRepository) PendingTx(ctx context.Context, tx bun.IDB, limit, maxAttempts int) ([]OutboxMessage, error) {
+	messages := []OutboxMessage{}
+	q := tx.NewSelect().
+		Model(&messages).
+		Where("?TableAlias.dispatched_at IS NULL").
+		OrderExpr("?TableAlias.occurred_at ASC, ?TableAlias.id ASC")
+	if maxAttempts > 0 {
+		q = q.Where("?TableAlias.attempts < ?", maxAttempts)
+	}
+	if limit > 0 {
+		q = q.Limit(limit)
+	}
+	if tx.Dialect().Name() == dialect.PG && isTransaction(tx) {
+		// concurrent dispatchers skip the messages another one is relaying
+		q = q.For("UPDATE SKIP LOCKED")
+	}
+	if err := q.Scan(ctx); err != nil {
+		return nil, err
+	}
+	return messages, nil
+}
+
+// MarkDispatched records that the messages with ids were relayed.
+func (o *OutboxRepository) MarkDispatched(ctx context.Context, ids ...uuid.UUID) error {
+	return o.MarkDispatchedTx(ctx, o.db, ids...)
+}
+
+func (o *OutboxRepository) MarkDispatchedTx(ctx context.Context, tx bun.IDB, ids ...uuid.UUID) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	_, err := tx.NewUpdate().
+		Model((*OutboxMessage)(nil)).
+		Set("dispatched_at = ?", time.Now().UTC()).
+		Where("id IN (?)", bun.In(ids)).
+		Exec(ctx)
+	return err
+}
+
+// MarkFailed counts a failed relay attempt of the message with id.
+func (o *OutboxRepository) MarkFailed(ctx context.Context, id uuid.UUID, cause error) error {
+	return o.MarkFailedTx(ctx, o.db, id, cause)
+}
+
+func (o *OutboxRepository) MarkFailedTx(ctx context.Context, tx bun.IDB, id uuid.UUID, cause error) error {
+	message := ""
+	if cause != nil {
+		message = cause.Error()
+	}
+	_, err := tx.NewUpdate().
+		Model((*OutboxMessage)(nil)).
+		Set("attempts = attempts + 1").
+		Set("last_error = ?", message).
+		Where("id = ?", id).
+		Exec(ctx)
+	return err
+}
+
+// PurgeDispatched deletes the messages dispatched before cutoff and returns
+// how many were deleted.
+func (o *OutboxRepository) PurgeDispatched(ctx context.Context, cutoff time.Time) (int64, error) {
+	res, err := o.db.NewDelete().
+		Model((*OutboxMessage)(nil)).
+		Where("dispatched_at IS NOT NULL").
+		Where("dispatched_at < ?", cutoff).
+		Exec(ctx)
+	if err != nil {
+		return 0, err
+	}
+	return rowsAffected(res)
+}
+
+// OutboxDispatcherOption configures NewOutboxDispatcher.
+type OutboxDispatcherOption func(*OutboxDispatcher)
+
+// WithOutboxInterval sets how long Run waits between polls when the outbox
+// is drained. Defaults to 1s.
+func WithOutboxInterval(interval time.Duration) OutboxDispatcherOption {
+	return func(d *OutboxDispatcher) {
+		if interval > 0 {
+			d.interval = interval
+		}
+	}
+}
+
+// WithOutboxBatchSize caps the messages relayed per DispatchOnce. Defaults
+// to 100.
+func WithOutboxBatchSize(size int) OutboxDispatcherOption {
+	return func(d *OutboxDispatcher) {
+		if size > 0 {
+			d.batchSize = size
+		}
+	}
+}
+
+// WithOutboxMaxAttempts sets how many failed relays a message gets before it
+// is left in the outbox for inspection. Defaults to 10.
+func WithOutboxMaxAttempts(attempts int) OutboxDispatcherOption {
+	return func(d *OutboxDispatcher) {
+		if attempts > 0 {
+			d.maxAttempts = attempts
+		}
+	}
+}
+
+// OutboxDispatcher relays outbox messages to a publisher, at least once and,
+// per record, in occurrence order:
+//
+//	dispatcher := repository.NewOutboxDispatcher(repository.NewOutboxRepository(db), broker)
+//	go dispatcher.Run(ctx)
+//
+// A message whose publish fails stays pending and is retried on later polls;
+// until it is relayed, or exhausts its attempts, the later messages of the
+// same entity and record are held back. On PostgreSQL concurrent dispatchers
+// skip each other's messages, which gives up ordering across dispatchers; on
+// other databases, or when order matters, run a single dispatcher per outbox
+// table.
+type OutboxDispatcher struct {
+	outbox      *OutboxRepository
+	publisher   EventPublisher
+	interval    time.Duration
+	batchSize   int
+	maxAttempts int
+}
+
+// NewOutboxDispatcher returns a dispatcher relaying the messages of outbox to
+// publisher.
+func NewOutboxDispatcher(outbox *OutboxRepository, publisher EventPublisher, opts ...OutboxDispatcherOption) *OutboxDispatcher {
+	d := &OutboxDispatcher{
+		outbox:      outbox,
+		publisher:   publisher,
+		interval:    defaultOutboxInterval,
+		batchSize:   defaultOutboxBatchSize,
+		maxAttempts: defaultOutboxMaxAttempts,
+	}
+	for _, opt := range opts {
+		if opt != nil {
+			opt(d)
+		}
+	}
+	return d
+}
+
+// DispatchOnce relays one batch of pending messages and returns how many
+// were published. Publish errors are recorded on their message, and the
+// batch skips the later messages of its record; the returned error reports
+// database failures only.
+func (d *OutboxDispatcher) DispatchOnce(ctx context.Context) (int, error) {
+	published := 0
+	err := runInTx(ctx, d.outbox.db, func(ctx context.Context, tx bun.IDB) error {
+		messages, err := d.outbox.PendingTx(ctx, tx, d.batchSize, d.maxAttempts)
+		if err != nil {
+			return err
+		}
+
+		var dispatched []uuid.UUID
+		blocked := map[outboxRecordKey]struct{}{}
+		for _, message := range messages {
+			key := outboxRecordKey{entity: message.Entity, recordID: message.RecordID}
+			if _, ok := blocked[key]; ok {
+				continue
+			}
+			event, err := message.Event()
+			if err == nil {
+				err = d.publisher.Publish(ctx, event)
+			}
+			if err != nil {
+				if markErr := d.outbox.MarkFailedTx(ctx, tx, message.ID, err); markErr != nil {
+					return markErr
+				}
+				blocked[key] = struct{}{}
+				continue
+			}
+			dispatched = append(dispatched, message.ID)
+		}
+		published = len(dispatched)
+		return d.outbox.MarkDispatchedTx(ctx, tx, dispatched...)
+	})
+	if err != nil {
+		return 0, fmt.Errorf("repository: dispatch outbox: %w", err)
+	}
+	return published, nil
+}
+
+// outboxRecordKey identifies the record an outbox message is about.
+type outboxRecordKey struct {
+	entity   string
+	recordID string
+}
+
+// Run relays messages until ctx is done, polling every interval while the
+// outbox is drained. It returns ctx.Err() on shutdown, or the first database
+// error.
+func (d *OutboxDispatcher) Run(ctx context.Context) error {
+	for {
+		published, err := d.DispatchOnce(ctx)
+		if err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			return err
+		}
+		if published >= d.batchSize {
+			continue
+		}
+
+		timer := time.NewTimer(d.interval)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
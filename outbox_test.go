@@ -0,0 +1,166 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/uptrace/bun"
+)
+
+func setupOutbox(t *testing.T) *OutboxRepository {
+	t.Helper()
+	dropTestTable(t, (*OutboxMessage)(nil))
+	require.NoError(t, CreateOutboxTable(context.Background(), db))
+	return NewOutboxRepository(db)
+}
+
+func TestWithOutbox_StoresEventsWithWrites(t *testing.T) {
+	setupTestData(t)
+	outbox := setupOutbox(t)
+	ctx := context.Background()
+	repo := newTestUserRepositoryWithConfig(db, nil, WithOutbox())
+
+	user, err := repo.Create(ctx, &TestUser{ID: uuid.New(), Name: "Alice", Email: "alice@example.com"})
+	require.NoError(t, err)
+
+	rollback := errors.New("rollback")
+	err = RunInTx(ctx, db, nil, func(ctx context.Context, tx bun.Tx) error {
+		_, err := repo.CreateTx(ctx, tx, &TestUser{ID: uuid.New(), Name: "Bob", Email: "bob@example.com"})
+		require.NoError(t, err)
+		return rollback
+	})
+	assert.ErrorIs(t, err, rollback)
+
+	messages, err := outbox.Pending(ctx, 0, 0)
+	require.NoError(t, err)
+	require.Len(t, messages, 1, "rolled back writes leave no message")
+
+	event, err := messages[0].Event()
+	require.NoError(t, err)
+	assert.Equal(t, EventCreate, event.Operation)
+	assert.Equal(t, "test_users", event.Entity)
+	assert.Equal(t, user.ID.String(), event.ID)
+	assert.Contains(t, event.Columns, "name")
+
+	var stored TestUser
+	require.NoError(t, json.Unmarshal(event.Record.(json.RawMessage), &stored))
+	assert.Equal(t, "Alice", stored.Name)
+}
+
+func TestOutboxDispatcher_DispatchOnce(t *testing.T) {
+	outbox := setupOutbox(t)
+	ctx := context.Background()
+
+	for _, id := range []string{"1", "2", "3"} {
+		require.NoError(t, outbox.Publish(ctx, Event{Entity: "orders", Operation: EventCreate, ID: id}))
+	}
+
+	var relayed []string
+	failing := errors.New("broker down")
+	publisher := EventPublisherFunc(func(_ context.Context, event Event) error {
+		if event.ID == "2" {
+			return failing
+		}
+		relayed = append(relayed, event.ID)
+		return nil
+	})
+	dispatcher := NewOutboxDispatcher(outbox, publisher, WithOutboxMaxAttempts(2))
+
+	published, err := dispatcher.DispatchOnce(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 2, published)
+	assert.Equal(t, []string{"1", "3"}, relayed)
+
+	pending, err := outbox.Pending(ctx, 0, 0)
+	require.NoError(t, err)
+	require.Len(t, pending, 1)
+	assert.Equal(t, "2", pending[0].RecordID)
+	assert.Equal(t, 1, pending[0].Attempts)
+	assert.Equal(t, failing.Error(), pending[0].LastError)
+
+	_, err = dispatcher.DispatchOnce(ctx)
+	require.NoError(t, err)
+	published, err = dispatcher.DispatchOnce(ctx)
+	require.NoError(t, err)
+	assert.Zero(t, published, "messages past max attempts are not retried")
+
+	purged, err := outbox.PurgeDispatched(ctx, time.Now().Add(time.Minute))
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), purged)
+}
+
+func TestOutboxDispatcher_DispatchOnceKeepsRecordOrder(t *testing.T) {
+	outbox := setupOutbox(t)
+	ctx := context.Background()
+
+	now := time.Now().UTC()
+	for i, event := range []Event{
+		{Entity: "orders", Operation: EventCreate, ID: "a"},
+		{Entity: "orders", Operation: EventCreate, ID: "b"},
+		{Entity: "orders", Operation: EventUpdate, ID: "a"},
+	} {
+		event.OccurredAt = now.Add(time.Duration(i) * time.Millisecond)
+		require.NoError(t, outbox.Publish(ctx, event))
+	}
+
+	down := true
+	var relayed []string
+	publisher := EventPublisherFunc(func(_ context.Context, event Event) error {
+		if down && event.ID == "a" {
+			return errors.New("broker down")
+		}
+		relayed = append(relayed, event.ID+":"+string(event.Operation))
+		return nil
+	})
+	dispatcher := NewOutboxDispatcher(outbox, publisher)
+
+	published, err := dispatcher.DispatchOnce(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, published)
+	assert.Equal(t, []string{"b:create"}, relayed, "the update of a waits for its create")
+
+	pending, err := outbox.Pending(ctx, 0, 0)
+	require.NoError(t, err)
+	require.Len(t, pending, 2)
+	assert.Equal(t, 1, pending[0].Attempts)
+	assert.Zero(t, pending[1].Attempts, "held back messages are not attempted")
+
+	down = false
+	published, err = dispatcher.DispatchOnce(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 2, published)
+	assert.Equal(t, []string{"b:create", "a:create", "a:update"}, relayed)
+}
+
+func TestOutboxDispatcher_Run(t *testing.T) {
+	outbox := setupOutbox(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	require.NoError(t, outbox.Publish(ctx, Event{Entity: "orders", Operation: EventDelete, ID: "1"}))
+
+	relayed := make(chan Event, 1)
+	dispatcher := NewOutboxDispatcher(outbox, EventPublisherFunc(func(_ context.Context, event Event) error {
+		relayed <- event
+		return nil
+	}), WithOutboxInterval(time.Millisecond))
+
+	done := make(chan error, 1)
+	go func() { done <- dispatcher.Run(ctx) }()
+
+	select {
+	case event := <-relayed:
+		assert.Equal(t, EventDelete, event.Operation)
+	case <-time.After(time.Second):
+		t.Fatal("message was not relayed")
+	}
+
+	cancel()
+	assert.ErrorIs(t, <-done, context.Canceled)
+}
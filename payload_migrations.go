@@ -0,0 +1,142 @@
+package repository
+
+import (
+	stderrors "errors"
+	"fmt"
+	"maps"
+	"sort"
+	"strings"
+	"sync"
+)
+
+var (
+	// ErrPayloadMigrationExists is returned by RegisterPayloadMigration when a
+	// migration is already registered for the model version.
+	ErrPayloadMigrationExists = stderrors.New("repository: payload migration already registered")
+	// ErrPayloadVersionUnsupported is returned for payload versions newer than
+	// the latest registered migration.
+	ErrPayloadVersionUnsupported = stderrors.New("repository: payload version not supported")
+)
+
+// PayloadMigration upgrades a map payload from the previous model version,
+// e.g. by renaming or dropping keys. It receives a copy of the payload and
+// may modify and return it.
+type PayloadMigration func(payload map[string]any) map[string]any
+
+var payloadMigrations = struct {
+	mu       sync.RWMutex
+	byModel  map[string]map[int]PayloadMigration
+	versions map[string][]int
+}{
+	byModel:  make(map[string]map[int]PayloadMigration),
+	versions: make(map[string][]int),
+}
+
+// RegisterPayloadMigration registers the migration upgrading payloads of
+// model from version-1 to version, so older API clients keep working while
+// the model evolves. Payloads without migrations are version 1, so version
+// starts at 2:
+//
+//	RegisterPayloadMigration("user", 2, func(p map[string]any) map[string]any {
+//		if name, ok := p["full_name"]; ok {
+//			p["name"] = name
+//			delete(p, "full_name")
+//		}
+//		return p
+//	})
+//
+// Migrations apply to MapToRecord, ApplyMapPatch and UpdateCriteriaForMapPatch
+// calls given WithPayloadVersion.
+func RegisterPayloadMigration(model string, version int, migration PayloadMigration) error {
+	model = strings.TrimSpace(model)
+	if model == "" || version < 2 || migration == nil {
+		return fmt.Errorf("repository: payload migration requires a model, a version >= 2 and a function")
+	}
+
+	payloadMigrations.mu.Lock()
+	defer payloadMigrations.mu.Unlock()
+
+	migrations := payloadMigrations.byModel[model]
+	if migrations == nil {
+		migrations = make(map[int]PayloadMigration)
+		payloadMigrations.byModel[model] = migrations
+	}
+	if _, ok := migrations[version]; ok {
+		return fmt.Errorf("%w: %s v%d", ErrPayloadMigrationExists, model, version)
+	}
+	migrations[version] = migration
+
+	versions := append(payloadMigrations.versions[model], version)
+	sort.Ints(versions)
+	payloadMigrations.versions[model] = versions
+	return nil
+}
+
+// LatestPayloadVersion returns the current payload version of model: the
+// highest registered migration version, or 1 without migrations.
+func LatestPayloadVersion(model string) int {
+	payloadMigrations.mu.RLock()
+	defer payloadMigrations.mu.RUnlock()
+
+	versions := payloadMigrations.versions[strings.TrimSpace(model)]
+	if len(versions) == 0 {
+		return 1
+	}
+	return versions[len(versions)-1]
+}
+
+// MigratePayload upgrades payload of model from version to the latest
+// version, applying each registered migration in order on a copy of payload.
+// Versions below 1 are treated as already current.
+func MigratePayload(model string, version int, payload map[string]any) (map[string]any, error) {
+	model = strings.TrimSpace(model)
+	if version < 1 || payload == nil {
+		return payload, nil
+	}
+
+	payloadMigrations.mu.RLock()
+	versions := payloadMigrations.versions[model]
+	migrations := payloadMigrations.byModel[model]
+	latest := 1
+	if len(versions) > 0 {
+		latest = versions[len(versions)-1]
+	}
+	pending := make([]PayloadMigration, 0, len(versions))
+	for _, v := range versions {
+		if v > version {
+			pending = append(pending, migrations[v])
+		}
+	}
+	payloadMigrations.mu.RUnlock()
+
+	if version > latest {
+		return nil, fmt.Errorf("%w: %s v%d (latest v%d)", ErrPayloadVersionUnsupported, model, version, latest)
+	}
+	if len(pending) == 0 {
+		return payload, nil
+	}
+
+	migrated := maps.Clone(payload)
+	for _, migration := range pending {
+		if migrated = migration(migrated); migrated == nil {
+			migrated = map[string]any{}
+		}
+	}
+	return migrated, nil
+}
+
+// WithPayloadVersion upgrades payloads sent at version of model with the
+// migrations registered by RegisterPayloadMigration before they are mapped.
+func WithPayloadVersion(model string, version int) MapPatchOption {
+	return func(cfg *mapPatchConfig) {
+		cfg.payloadModel = strings.TrimSpace(model)
+		cfg.payloadVersion = version
+	}
+}
+
+func (cfg mapPatchConfig) migratePayload(patch map[string]any) (map[string]any, error) {
+	if cfg.payloadModel == "" {
+		return patch, nil
+	}
+	return MigratePayload(cfg.payloadModel, cfg.payloadVersion, patch)
+}
@@ -0,0 +1,79 @@
+package repository
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// registerMapHelperMigrations registers migrations under a unique model
+// name, since the registry is global, and returns it.
+func registerMapHelperMigrations(t *testing.T) string {
+	t.Helper()
+	model := "payload-test-" + uuid.NewString()
+	// v2 renamed full_name to name, v3 dropped legacy_flag.
+	require.NoError(t, RegisterPayloadMigration(model, 3, func(p map[string]any) map[string]any {
+		delete(p, "legacy_flag")
+		return p
+	}))
+	require.NoError(t, RegisterPayloadMigration(model, 2, func(p map[string]any) map[string]any {
+		if name, ok := p["full_name"]; ok {
+			p["name"] = name
+			delete(p, "full_name")
+		}
+		return p
+	}))
+	return model
+}
+
+func TestMigratePayload(t *testing.T) {
+	model := registerMapHelperMigrations(t)
+	assert.Equal(t, 3, LatestPayloadVersion(model))
+	assert.Equal(t, 1, LatestPayloadVersion("payload-test-unknown"))
+
+	payload := map[string]any{"full_name": "Alice", "legacy_flag": true}
+	migrated, err := MigratePayload(model, 1, payload)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]any{"name": "Alice"}, migrated)
+	assert.Contains(t, payload, "full_name", "the input payload is not modified")
+
+	migrated, err = MigratePayload(model, 2, map[string]any{"full_name": "Alice", "legacy_flag": true})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]any{"full_name": "Alice"}, migrated, "only newer migrations apply")
+
+	_, err = MigratePayload(model, 4, payload)
+	assert.ErrorIs(t, err, ErrPayloadVersionUnsupported)
+
+	err = RegisterPayloadMigration(model, 2, func(p map[string]any) map[string]any { return p })
+	assert.ErrorIs(t, err, ErrPayloadMigrationExists)
+	assert.Error(t, RegisterPayloadMigration(model, 1, func(p map[string]any) map[string]any { return p }))
+}
+
+func TestMapToRecord_WithPayloadVersion(t *testing.T) {
+	model := registerMapHelperMigrations(t)
+
+	record, err := MapToRecord[mapHelperModel](
+		map[string]any{"full_name": "Alice", "legacy_flag": true, "count": 2},
+		WithPayloadVersion(model, 1),
+	)
+	require.NoError(t, err)
+	assert.Equal(t, "Alice", record.Name)
+	assert.Equal(t, 2, record.Count)
+
+	_, err = MapToRecord[mapHelperModel](map[string]any{"full_name": "Alice"})
+	require.Error(t, err, "unversioned payloads are not migrated")
+
+	patched, columns, err := ApplyMapPatch(record, map[string]any{"full_name": "Bob"}, WithPayloadVersion(model, 1))
+	require.NoError(t, err)
+	assert.Equal(t, "Bob", patched.Name)
+	assert.Equal(t, []string{"name"}, columns)
+
+	criteria, err := UpdateCriteriaForMapPatch(map[string]any{"full_name": "Bob"}, WithPayloadVersion(model, 1))
+	require.NoError(t, err)
+	assert.Len(t, criteria, 2)
+
+	_, _, err = ApplyMapPatch(record, map[string]any{"name": "Bob"}, WithPayloadVersion(model, 9))
+	assert.ErrorIs(t, err, ErrPayloadVersionUnsupported)
+}
@@ -0,0 +1,127 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"sync"
+	"time"
+
+	"github.com/uptrace/bun"
+)
+
+// PoolConfig tunes the database/sql connection pool. Zero fields keep the
+// current setting.
+type PoolConfig struct {
+	MaxOpen     int
+	MaxIdle     int
+	MaxLifetime time.Duration
+	MaxIdleTime time.Duration
+}
+
+// ConfigurePool applies cfg to the connection pool of db.
+func ConfigurePool(db *bun.DB, cfg PoolConfig) {
+	if db == nil {
+		return
+	}
+	if cfg.MaxOpen > 0 {
+		db.SetMaxOpenConns(cfg.MaxOpen)
+	}
+	if cfg.MaxIdle > 0 {
+		db.SetMaxIdleConns(cfg.MaxIdle)
+	}
+	if cfg.MaxLifetime > 0 {
+		db.SetConnMaxLifetime(cfg.MaxLifetime)
+	}
+	if cfg.MaxIdleTime > 0 {
+		db.SetConnMaxIdleTime(cfg.MaxIdleTime)
+	}
+}
+
+// WithPoolConfig applies the pool limits to the bun.DB a repository is
+// created with, like ConfigurePool. Zero values keep the current setting.
+func WithPoolConfig(maxOpen, maxIdle int, maxLifetime time.Duration) Option {
+	return func(db *bun.DB) {
+		ConfigurePool(db, PoolConfig{MaxOpen: maxOpen, MaxIdle: maxIdle, MaxLifetime: maxLifetime})
+	}
+}
+
+// PoolStatsReader is an optional capability for repositories that expose the
+// live statistics of their connection pool.
+type PoolStatsReader interface {
+	PoolStats() sql.DBStats
+}
+
+// PoolStats returns the current statistics of the connection pool of the
+// repository DB.
+func (r *repo[T]) PoolStats() sql.DBStats {
+	if r.db == nil {
+		return sql.DBStats{}
+	}
+	return r.db.Stats()
+}
+
+// PoolSample is a snapshot of the connection pool taken by StartPoolSampler.
+type PoolSample struct {
+	Stats sql.DBStats
+	// WaitCount and WaitDuration cover the waits for a free connection since
+	// the previous sample; a growing value means the pool is saturated.
+	WaitCount    int64
+	WaitDuration time.Duration
+}
+
+// PoolStatsSink receives pool samples.
+type PoolStatsSink interface {
+	RecordPoolStats(ctx context.Context, sample PoolSample)
+}
+
+// PoolStatsSinkFunc adapts a function to PoolStatsSink.
+type PoolStatsSinkFunc func(ctx context.Context, sample PoolSample)
+
+func (f PoolStatsSinkFunc) RecordPoolStats(ctx context.Context, sample PoolSample) {
+	f(ctx, sample)
+}
+
+// StartPoolSampler reads the pool statistics of db every interval and reports
+// them to sinks, e.g. the exporters of the repositoryprom and repositoryotel
+// packages, until ctx is done or stop is called.
+func StartPoolSampler(ctx context.Context, db *bun.DB, interval time.Duration, sinks ...PoolStatsSink) (stop func()) {
+	ctx, cancel := context.WithCancel(ctx)
+	if db == nil || interval <= 0 || len(sinks) == 0 {
+		return cancel
+	}
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		last := db.Stats()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+
+			stats := db.Stats()
+			sample := PoolSample{
+				Stats:        stats,
+				WaitCount:    stats.WaitCount - last.WaitCount,
+				WaitDuration: stats.WaitDuration - last.WaitDuration,
+			}
+			last = stats
+			for _, sink := range sinks {
+				if sink != nil {
+					sink.RecordPoolStats(ctx, sample)
+				}
+			}
+		}
+	}()
+
+	return func() {
+		cancel()
+		wg.Wait()
+	}
+}
@@ -0,0 +1,58 @@
+package repository
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/uptrace/bun/dialect/sqlitedialect"
+)
+
+func TestConfigurePool(t *testing.T) {
+	testDB := newDialectTestDB(t, sqlitedialect.New())
+
+	ConfigurePool(testDB, PoolConfig{MaxOpen: 7, MaxIdle: 3, MaxLifetime: time.Minute, MaxIdleTime: time.Second})
+	assert.Equal(t, 7, testDB.Stats().MaxOpenConnections)
+
+	ConfigurePool(testDB, PoolConfig{})
+	assert.Equal(t, 7, testDB.Stats().MaxOpenConnections, "zero fields keep the current setting")
+}
+
+func TestWithPoolConfigAndPoolStats(t *testing.T) {
+	testDB := newDialectTestDB(t, sqlitedialect.New())
+	repo := newTestUserRepository(testDB, WithPoolConfig(9, 2, time.Minute))
+
+	reader, ok := repo.(PoolStatsReader)
+	require.True(t, ok)
+	assert.Equal(t, 9, reader.PoolStats().MaxOpenConnections)
+
+	require.NoError(t, testDB.PingContext(context.Background()))
+	assert.GreaterOrEqual(t, reader.PoolStats().OpenConnections, 1)
+}
+
+func TestStartPoolSampler(t *testing.T) {
+	ctx := context.Background()
+	testDB := newDialectTestDB(t, sqlitedialect.New())
+	ConfigurePool(testDB, PoolConfig{MaxOpen: 5})
+
+	samples := make(chan PoolSample, 16)
+	stop := StartPoolSampler(ctx, testDB, 5*time.Millisecond, nil,
+		PoolStatsSinkFunc(func(_ context.Context, sample PoolSample) {
+			select {
+			case samples <- sample:
+			default:
+			}
+		}),
+	)
+
+	select {
+	case sample := <-samples:
+		assert.Equal(t, 5, sample.Stats.MaxOpenConnections)
+		assert.GreaterOrEqual(t, sample.WaitCount, int64(0))
+	case <-time.After(time.Second):
+		t.Fatal("no pool sample reported")
+	}
+	stop()
+}
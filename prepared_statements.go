@@ -0,0 +1,262 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	stderrors "errors"
+	"strings"
+	"sync"
+
+	"github.com/uptrace/bun"
+)
+
+// maxPreparedStatements caps the statements cached per repository. Scopes
+// render their values into the SQL, so tenant scoped lookups get one
+// statement per tenant; past the cap the cache starts over.
+const maxPreparedStatements = 128
+
+// PreparedStatementCache is implemented by repositories configured with
+// WithPreparedStatements.
+type PreparedStatementCache interface {
+	// ClosePreparedStatements closes the cached statements, e.g. on
+	// shutdown. Later lookups prepare them again.
+	ClosePreparedStatements() error
+}
+
+// RecordExistenceChecker is an optional capability for repositories that can
+// check for a record without loading it.
+type RecordExistenceChecker interface {
+	ExistsByID(ctx context.Context, id string, criteria ...SelectCriteria) (bool, error)
+	ExistsByIDTx(ctx context.Context, tx bun.IDB, id string, criteria ...SelectCriteria) (bool, error)
+}
+
+// WithPreparedStatements caches prepared statements for the point lookups
+// GetByID, GetByIdentifier and ExistsByID, so hot lookups skip parsing and
+// planning on every call. Statements are used for calls without extra
+// criteria, outside transactions and without default relations; other calls
+// run as usual. Statements are keyed by their SQL, scopes included, and are
+// dropped when the *sql.DB of the bun.DB is replaced or a statement fails
+// with a broken connection.
+//
+// Prepared lookups bypass bun, so bun query hooks (query logging, metrics,
+// tracing) do not see them; encrypted columns are decrypted, integrity hashes
+// verified and outcomes reported to WithCircuitBreaker by the lookup itself.
+func WithPreparedStatements() RepoOption {
+	return func(cfg *repoConfig) {
+		if cfg == nil {
+			return
+		}
+		cfg.preparedStatements = true
+	}
+}
+
+type preparedStatementCache struct {
+	mu    sync.Mutex
+	sqldb *sql.DB
+	stmts map[string]*sql.Stmt
+}
+
+// statement returns the cached statement for query on sqldb, preparing it on
+// first use. A new sqldb invalidates every cached statement.
+func (c *preparedStatementCache) statement(ctx context.Context, sqldb *sql.DB, query string) (*sql.Stmt, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.sqldb != sqldb {
+		c.closeLocked()
+		c.sqldb = sqldb
+	}
+	if stmt, ok := c.stmts[query]; ok {
+		return stmt, nil
+	}
+	if len(c.stmts) >= maxPreparedStatements {
+		c.closeLocked()
+	}
+
+	stmt, err := sqldb.PrepareContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	if c.stmts == nil {
+		c.stmts = make(map[string]*sql.Stmt)
+	}
+	c.stmts[query] = stmt
+	return stmt, nil
+}
+
+// invalidate drops the statement of query.
+func (c *preparedStatementCache) invalidate(query string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if stmt, ok := c.stmts[query]; ok {
+		_ = stmt.Close()
+		delete(c.stmts, query)
+	}
+}
+
+func (c *preparedStatementCache) close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.closeLocked()
+}
+
+func (c *preparedStatementCache) closeLocked() error {
+	var errs []error
+	for query, stmt := range c.stmts {
+		if err := stmt.Close(); err != nil {
+			errs = append(errs, err)
+		}
+		delete(c.stmts, query)
+	}
+	return stderrors.Join(errs...)
+}
+
+func (r *repo[T]) ClosePreparedStatements() error {
+	if r.preparedStatements == nil {
+		return nil
+	}
+	return r.preparedStatements.close()
+}
+
+// canUsePreparedStatement reports whether a lookup may run through the
+// statement cache.
+func (r *repo[T]) canUsePreparedStatement(ctx context.Context, tx bun.IDB, criteria []SelectCriteria) bool {
+	if r.preparedStatements == nil || len(criteria) > 0 {
+		return false
+	}
+	if db, ok := tx.(*bun.DB); !ok || db != r.db || db.DB == nil {
+		return false
+	}
+	return len(r.defaultRelations) == 0 || defaultRelationsDisabled(ctx)
+}
+
+// preparedPlaceholder is the bind parameter of the driver.
+func (r *repo[T]) preparedPlaceholder() bun.Safe {
+	switch r.driver {
+	case "postgres":
+		return "$1"
+	case "mssql":
+		return "@p1"
+	default:
+		return "?"
+	}
+}
+
+// getByColumnPrepared loads the record whose column equals value through a
+// prepared statement. ok is false when the statement could not be used and
+// the caller should run the regular query.
+func (r *repo[T]) getByColumnPrepared(ctx context.Context, column string, value any) (record T, ok bool, err error) {
+	q := r.db.NewSelect().Model(r.handlers.NewRecord())
+	q = r.applySelectScopes(ctx, q)
+	if err := r.applySelectCriteria(q, nil); err != nil {
+		return record, false, nil
+	}
+	q = q.Where("?TableAlias.? = ?", bun.Ident(column), r.preparedPlaceholder()).Limit(1)
+	query := q.String()
+
+	stmt, err := r.preparedStatements.statement(ctx, r.db.DB, query)
+	if err != nil {
+		return record, false, nil
+	}
+	rows, err := stmt.QueryContext(ctx, value)
+	if err != nil {
+		if isStaleStatementError(err) {
+			r.preparedStatements.invalidate(query)
+			return record, false, nil
+		}
+		recordCircuitOutcome(ctx, err)
+		return record, true, r.mapError(err)
+	}
+	defer rows.Close()
+
+	if !rows.Next() {
+		err := rows.Err()
+		recordCircuitOutcome(ctx, err)
+		if err != nil {
+			return record, true, r.mapError(err)
+		}
+		return record, true, r.mapError(sql.ErrNoRows)
+	}
+	record = r.handlers.NewRecord()
+	if err := r.db.ScanRow(ctx, rows, record); err != nil {
+		recordCircuitOutcome(ctx, err)
+		var zero T
+		return zero, true, r.mapError(err)
+	}
+	recordCircuitOutcome(ctx, nil)
+	// The scan bypasses bun, so the encryption and integrity hooks never see
+	// the record.
+	if err := r.verifyRecords(record); err != nil {
+		var zero T
+		return zero, true, err
+	}
+	return record, true, nil
+}
+
+// existsPrepared is getByColumnPrepared for ExistsByID.
+func (r *repo[T]) existsPrepared(ctx context.Context, id string) (exists bool, ok bool, err error) {
+	q := r.db.NewSelect().Model(r.handlers.NewRecord()).ColumnExpr("1")
+	q = r.applySelectScopes(ctx, q)
+	if err := r.applySelectCriteria(q, nil); err != nil {
+		return false, false, nil
+	}
+	q = q.Where("?TableAlias.id = ?", r.preparedPlaceholder()).Limit(1)
+	query := q.String()
+
+	stmt, err := r.preparedStatements.statement(ctx, r.db.DB, query)
+	if err != nil {
+		return false, false, nil
+	}
+	var one int
+	err = stmt.QueryRowContext(ctx, id).Scan(&one)
+	switch {
+	case err == nil:
+		recordCircuitOutcome(ctx, nil)
+		return true, true, nil
+	case stderrors.Is(err, sql.ErrNoRows):
+		recordCircuitOutcome(ctx, nil)
+		return false, true, nil
+	case isStaleStatementError(err):
+		r.preparedStatements.invalidate(query)
+		return false, false, nil
+	default:
+		recordCircuitOutcome(ctx, err)
+		return false, true, r.mapError(err)
+	}
+}
+
+// ExistsByID reports whether a record with id matches criteria, without
+// loading it.
+func (r *repo[T]) ExistsByID(ctx context.Context, id string, criteria ...SelectCriteria) (bool, error) {
+	return r.ExistsByIDTx(ctx, r.db, id, criteria...)
+}
+
+func (r *repo[T]) ExistsByIDTx(ctx context.Context, tx bun.IDB, id string, criteria ...SelectCriteria) (bool, error) {
+	ctx = r.withOperation(ctx, "ExistsByID", len(criteria))
+	if r.canUsePreparedStatement(ctx, tx, criteria) {
+		if exists, ok, err := r.existsPrepared(ctx, id); ok {
+			return exists, err
+		}
+	}
+
+	q := tx.NewSelect().Model(r.handlers.NewRecord())
+	q = r.applySelectScopes(ctx, q)
+	if err := r.applySelectCriteria(q, append([]SelectCriteria{SelectByID(id)}, criteria...)); err != nil {
+		return false, err
+	}
+	exists, err := q.Exists(ctx)
+	if err != nil {
+		return false, r.mapError(err)
+	}
+	return exists, nil
+}
+
+// isStaleStatementError reports errors after which a cached statement should
+// be prepared again.
+func isStaleStatementError(err error) bool {
+	if stderrors.Is(err, driver.ErrBadConn) || stderrors.Is(err, sql.ErrConnDone) {
+		return true
+	}
+	return strings.Contains(err.Error(), "statement is closed")
+}
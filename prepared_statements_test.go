@@ -0,0 +1,119 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/uptrace/bun"
+	"github.com/uptrace/bun/dialect/sqlitedialect"
+)
+
+func newPreparedTestSQLDB(t *testing.T) *sql.DB {
+	t.Helper()
+	sqldb, err := sql.Open("sqlite3", ":memory:")
+	require.NoError(t, err)
+	sqldb.SetMaxOpenConns(1)
+	t.Cleanup(func() { _ = sqldb.Close() })
+	return sqldb
+}
+
+func createPreparedTestUser(t *testing.T, sqldb *sql.DB, email string) *TestUser {
+	t.Helper()
+	ctx := context.Background()
+	testDB := bun.NewDB(sqldb, sqlitedialect.New())
+	_, err := testDB.NewCreateTable().Model((*TestUser)(nil)).IfNotExists().Exec(ctx)
+	require.NoError(t, err)
+	user := &TestUser{ID: uuid.New(), Name: email, Email: email}
+	_, err = testDB.NewInsert().Model(user).Exec(ctx)
+	require.NoError(t, err)
+	return user
+}
+
+func TestWithPreparedStatements(t *testing.T) {
+	ctx := context.Background()
+	sqldb := newPreparedTestSQLDB(t)
+	testDB := bun.NewDB(sqldb, sqlitedialect.New())
+	alice := createPreparedTestUser(t, sqldb, "alice@example.com")
+
+	users := newTestUserRepositoryWithConfig(testDB, nil, WithPreparedStatements())
+	cache := users.(*repo[*TestUser]).preparedStatements
+	require.NotNil(t, cache)
+
+	for range 3 {
+		found, err := users.GetByID(ctx, alice.ID.String())
+		require.NoError(t, err)
+		assert.Equal(t, "alice@example.com", found.Email)
+	}
+	assert.Len(t, cache.stmts, 1, "statement is reused")
+
+	found, err := users.GetByIdentifier(ctx, "alice@example.com")
+	require.NoError(t, err)
+	assert.Equal(t, alice.ID, found.ID)
+
+	_, err = users.GetByID(ctx, uuid.NewString())
+	assert.True(t, IsRecordNotFound(err))
+
+	checker := users.(RecordExistenceChecker)
+	exists, err := checker.ExistsByID(ctx, alice.ID.String())
+	require.NoError(t, err)
+	assert.True(t, exists)
+	exists, err = checker.ExistsByID(ctx, uuid.NewString())
+	require.NoError(t, err)
+	assert.False(t, exists)
+	assert.Len(t, cache.stmts, 3)
+
+	// criteria and transactions use regular queries
+	_, err = users.GetByID(ctx, alice.ID.String(), SelectColumns("id", "email"))
+	require.NoError(t, err)
+	tx, err := testDB.BeginTx(ctx, nil)
+	require.NoError(t, err)
+	_, err = users.GetByIDTx(ctx, tx, alice.ID.String())
+	require.NoError(t, err)
+	require.NoError(t, tx.Rollback())
+	assert.Len(t, cache.stmts, 3)
+
+	// replacing the connection pool invalidates the statements
+	replacement := newPreparedTestSQLDB(t)
+	bob := createPreparedTestUser(t, replacement, "bob@example.com")
+	testDB.DB = replacement
+
+	found, err = users.GetByID(ctx, bob.ID.String())
+	require.NoError(t, err)
+	assert.Equal(t, "bob@example.com", found.Email)
+	assert.Len(t, cache.stmts, 1)
+	assert.Same(t, replacement, cache.sqldb)
+
+	require.NoError(t, users.(PreparedStatementCache).ClosePreparedStatements())
+	assert.Empty(t, cache.stmts)
+	_, err = users.GetByID(ctx, bob.ID.String())
+	require.NoError(t, err)
+}
+
+func TestWithPreparedStatements_Scopes(t *testing.T) {
+	ctx := context.Background()
+	sqldb := newPreparedTestSQLDB(t)
+	testDB := bun.NewDB(sqldb, sqlitedialect.New())
+	alice := createPreparedTestUser(t, sqldb, "alice@example.com")
+
+	repo := newTestUserRepositoryWithConfig(testDB, nil, WithPreparedStatements())
+	repo.RegisterScope("email", ScopeDefinition{
+		Select: func(ctx context.Context) []SelectCriteria {
+			value, ok := ScopeData(ctx, "email")
+			if !ok {
+				return nil
+			}
+			return []SelectCriteria{SelectBy("email", "=", value.(string))}
+		},
+	})
+
+	scoped := WithSelectScopes(ctx, "email")
+	_, err := repo.GetByID(WithScopeData(scoped, "email", "alice@example.com"), alice.ID.String())
+	require.NoError(t, err)
+
+	_, err = repo.GetByID(WithScopeData(scoped, "email", "bob@example.com"), alice.ID.String())
+	assert.True(t, IsRecordNotFound(err), "scope values are part of the statement")
+}
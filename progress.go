@@ -0,0 +1,131 @@
+package repository
+
+import (
+	"context"
+	"time"
+)
+
+// progressReportEvery is how many rows Import and SyncSet process between
+// two progress reports.
+const progressReportEvery = 100
+
+// Progress is a snapshot of a long running operation, e.g. an Import of a
+// large file or a RunRetention pass.
+type Progress struct {
+	// Operation is "import", "sync" or "retention".
+	Operation string
+	Table     string
+	// Processed counts the rows handled so far.
+	Processed int64
+	// Total is the number of rows to process, or 0 when it is not known
+	// upfront (RunRetention).
+	Total int64
+	// Batch is the 1-based number of the report.
+	Batch int
+	// BatchDuration is the time spent since the previous report.
+	BatchDuration time.Duration
+	Elapsed       time.Duration
+	// ETA estimates the remaining time from the average rate so far, or 0
+	// when Total is unknown.
+	ETA time.Duration
+	// Done is set on the last report of the operation.
+	Done bool
+}
+
+// ProgressReporter receives the progress of bulk operations, so operators
+// can watch large migrations instead of waiting on a silent call. Reports are
+// sent synchronously from the goroutine running the operation; slow reporters
+// slow the operation down.
+type ProgressReporter interface {
+	ReportProgress(ctx context.Context, progress Progress)
+}
+
+// ProgressReporterFunc adapts a function to ProgressReporter.
+type ProgressReporterFunc func(ctx context.Context, progress Progress)
+
+func (f ProgressReporterFunc) ReportProgress(ctx context.Context, progress Progress) {
+	f(ctx, progress)
+}
+
+// WithProgressReporter reports the progress of Import, ImportCSV, SyncSet and
+// RunRetention to reporter. WithImportProgress and SyncOptions.Progress
+// override it for a single call.
+func WithProgressReporter(reporter ProgressReporter) RepoOption {
+	return func(cfg *repoConfig) {
+		if cfg == nil {
+			return
+		}
+		cfg.progressReporter = reporter
+	}
+}
+
+// progressTracker builds the Progress reports of one operation. A tracker
+// without reporter does nothing.
+type progressTracker struct {
+	reporter  ProgressReporter
+	progress  Progress
+	pending   int64
+	started   time.Time
+	lastBatch time.Time
+}
+
+func newProgressTracker(reporter ProgressReporter, operation, table string, total int64) *progressTracker {
+	now := time.Now()
+	return &progressTracker{
+		reporter:  reporter,
+		progress:  Progress{Operation: operation, Table: table, Total: total},
+		started:   now,
+		lastBatch: now,
+	}
+}
+
+// add counts processed rows and reports once progressReportEvery rows are
+// pending.
+func (t *progressTracker) add(ctx context.Context, rows int64) {
+	if t.reporter == nil {
+		return
+	}
+	t.pending += rows
+	if t.pending >= progressReportEvery {
+		t.report(ctx, false)
+	}
+}
+
+// batch counts processed rows and reports right away.
+func (t *progressTracker) batch(ctx context.Context, rows int64) {
+	if t.reporter == nil {
+		return
+	}
+	t.pending += rows
+	t.report(ctx, false)
+}
+
+// grow raises the expected total once more work is discovered.
+func (t *progressTracker) grow(rows int64) {
+	t.progress.Total += rows
+}
+
+// done sends the final report.
+func (t *progressTracker) done(ctx context.Context) {
+	if t.reporter == nil {
+		return
+	}
+	t.report(ctx, true)
+}
+
+func (t *progressTracker) report(ctx context.Context, done bool) {
+	now := time.Now()
+	p := &t.progress
+	p.Processed += t.pending
+	p.Batch++
+	p.BatchDuration = now.Sub(t.lastBatch)
+	p.Elapsed = now.Sub(t.started)
+	p.Done = done
+	p.ETA = 0
+	if !done && p.Total > p.Processed && p.Processed > 0 {
+		p.ETA = time.Duration(float64(p.Elapsed) / float64(p.Processed) * float64(p.Total-p.Processed))
+	}
+	t.pending = 0
+	t.lastBatch = now
+	t.reporter.ReportProgress(ctx, *p)
+}
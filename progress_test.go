@@ -0,0 +1,135 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type progressRecorder struct {
+	mu      sync.Mutex
+	reports []Progress
+}
+
+func (r *progressRecorder) ReportProgress(_ context.Context, progress Progress) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.reports = append(r.reports, progress)
+}
+
+func (r *progressRecorder) last() Progress {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.reports[len(r.reports)-1]
+}
+
+func TestRepository_ImportProgress(t *testing.T) {
+	ctx := context.Background()
+	setupTestData(t)
+
+	repoProgress := &progressRecorder{}
+	repo := newTestUserRepositoryWithConfig(db, nil, WithProgressReporter(repoProgress))
+
+	rows := make([]map[string]any, 0, 250)
+	for i := range 250 {
+		rows = append(rows, map[string]any{"name": fmt.Sprintf("user-%d", i), "email": fmt.Sprintf("user-%d@example.com", i)})
+	}
+
+	importer := repo.(RecordImporter)
+	_, err := importer.Import(ctx, rows)
+	require.NoError(t, err)
+
+	require.Len(t, repoProgress.reports, 3)
+	first := repoProgress.reports[0]
+	assert.Equal(t, "import", first.Operation)
+	assert.Equal(t, "test_users", first.Table)
+	assert.Equal(t, int64(100), first.Processed)
+	assert.Equal(t, int64(250), first.Total)
+	assert.Equal(t, 1, first.Batch)
+	assert.False(t, first.Done)
+	assert.Equal(t, int64(200), repoProgress.reports[1].Processed)
+
+	last := repoProgress.last()
+	assert.Equal(t, int64(250), last.Processed)
+	assert.True(t, last.Done)
+	assert.Zero(t, last.ETA)
+	assert.GreaterOrEqual(t, last.Elapsed, first.Elapsed)
+
+	callProgress := &progressRecorder{}
+	_, err = importer.Import(ctx, rows[:10], WithImportConflictStrategy(ImportSkip), WithImportProgress(callProgress))
+	require.NoError(t, err)
+	require.Len(t, callProgress.reports, 1)
+	assert.Equal(t, int64(10), callProgress.last().Processed)
+	assert.Len(t, repoProgress.reports, 3, "call reporter replaces the repository one")
+}
+
+func TestRepository_SyncSetProgress(t *testing.T) {
+	ctx := context.Background()
+	setupTestData(t)
+	repo := newTestUserRepositoryWithConfig(db, nil, WithAllowFullTableDelete(true))
+
+	_, err := repo.CreateMany(ctx, []*TestUser{
+		{Name: "Alice", Email: "alice@example.com"},
+		{Name: "Bob", Email: "bob@example.com"},
+	})
+	require.NoError(t, err)
+
+	progress := &progressRecorder{}
+	_, err = repo.(SetSyncer[*TestUser]).SyncSet(ctx, []*TestUser{
+		{Name: "Alice Updated", Email: "alice@example.com"},
+		{Name: "Carol", Email: "carol@example.com"},
+		{Name: "Dave", Email: "dave@example.com"},
+	}, []string{"email"}, SyncOptions{IgnoreColumns: []string{"created_at", "updated_at"}, Progress: progress})
+	require.NoError(t, err)
+
+	require.Len(t, progress.reports, 2)
+	assert.Equal(t, Progress{Operation: "sync", Table: "test_users", Processed: 3, Total: 3, Batch: 1}, withoutTimings(progress.reports[0]))
+	assert.Equal(t, Progress{Operation: "sync", Table: "test_users", Processed: 4, Total: 4, Batch: 2, Done: true}, withoutTimings(progress.last()))
+}
+
+func TestRepository_RunRetentionProgress(t *testing.T) {
+	ctx := context.Background()
+	day := 24 * time.Hour
+
+	progress := &progressRecorder{}
+	policy := Retain(30*day, "created_at", SoftDelete)
+	policy.BatchSize = 2
+	repo := newRetentionTestEventRepository(t, WithRetention(policy), WithProgressReporter(progress))
+	seedRetentionTestEvents(t, repo, 40*day, 35*day, 31*day, day)
+
+	_, err := repo.(RetentionRunner).RunRetention(ctx)
+	require.NoError(t, err)
+
+	require.Len(t, progress.reports, 3)
+	assert.Equal(t, Progress{Operation: "retention", Table: "retention_test_events", Processed: 2, Batch: 1}, withoutTimings(progress.reports[0]))
+	assert.Equal(t, Progress{Operation: "retention", Table: "retention_test_events", Processed: 3, Batch: 2}, withoutTimings(progress.reports[1]))
+	assert.True(t, progress.last().Done)
+}
+
+func TestProgressTrackerETA(t *testing.T) {
+	ctx := context.Background()
+	progress := &progressRecorder{}
+	tracker := newProgressTracker(progress, "import", "items", 400)
+	tracker.started = tracker.started.Add(-time.Second)
+
+	tracker.batch(ctx, 100)
+	report := progress.last()
+	assert.InDelta(t, float64(3*time.Second), float64(report.ETA), float64(100*time.Millisecond))
+
+	tracker = newProgressTracker(nil, "import", "items", 10)
+	tracker.add(ctx, 200)
+	tracker.done(ctx)
+	assert.Len(t, progress.reports, 1, "trackers without reporter are silent")
+}
+
+func withoutTimings(p Progress) Progress {
+	p.BatchDuration = 0
+	p.Elapsed = 0
+	p.ETA = 0
+	return p
+}
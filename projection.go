@@ -0,0 +1,162 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+
+	"github.com/goliatone/go-errors"
+	"github.com/uptrace/bun"
+)
+
+// projectionSelector is implemented by repositories that can scan a subset
+// of their columns into another struct type.
+type projectionSelector interface {
+	selectProjection(ctx context.Context, tx bun.IDB, dest any, destType reflect.Type, list bool, criteria []SelectCriteria) (int, error)
+}
+
+// GetAs loads the first record matching criteria into the lightweight struct
+// D, selecting only the model columns that D declares. Fields are matched by
+// bun column name, like MapToRecord does, and fields without a model column
+// are left zero:
+//
+//	type UserSummary struct {
+//		ID    uuid.UUID `bun:"id"`
+//		Email string    `bun:"email"`
+//	}
+//
+//	summary, err := repository.GetAs[UserSummary](ctx, userRepo, repository.SelectByID(id))
+//
+// Scopes apply as for Get; default relations are not loaded. D may be a
+// struct or a pointer to one.
+func GetAs[D any, T any](ctx context.Context, repo Repository[T], criteria ...SelectCriteria) (D, error) {
+	return GetAsTx[D](ctx, repo, nil, criteria...)
+}
+
+// GetAsTx is the transactional variant of GetAs. A nil tx uses the
+// repository database.
+func GetAsTx[D any, T any](ctx context.Context, repo Repository[T], tx bun.IDB, criteria ...SelectCriteria) (D, error) {
+	var dest D
+	selector, destType, err := projectionTarget[D](repo)
+	if err != nil {
+		return dest, err
+	}
+
+	target := any(&dest)
+	if reflect.TypeFor[D]().Kind() == reflect.Pointer {
+		value := reflect.New(destType)
+		dest = value.Interface().(D)
+		target = dest
+	}
+
+	if _, err := selector.selectProjection(ctx, tx, target, destType, false, criteria); err != nil {
+		var zero D
+		return zero, err
+	}
+	return dest, nil
+}
+
+// ListAs lists the records matching criteria as D values, selecting only the
+// model columns that D declares, see GetAs. Pagination, default order and
+// scopes apply as for List, and the total count is returned alongside.
+func ListAs[D any, T any](ctx context.Context, repo Repository[T], criteria ...SelectCriteria) ([]D, int, error) {
+	return ListAsTx[D](ctx, repo, nil, criteria...)
+}
+
+// ListAsTx is the transactional variant of ListAs. A nil tx uses the
+// repository database.
+func ListAsTx[D any, T any](ctx context.Context, repo Repository[T], tx bun.IDB, criteria ...SelectCriteria) ([]D, int, error) {
+	selector, destType, err := projectionTarget[D](repo)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	dest := []D{}
+	total, err := selector.selectProjection(ctx, tx, &dest, destType, true, criteria)
+	if err != nil {
+		return nil, total, err
+	}
+	return dest, total, nil
+}
+
+func projectionTarget[D any, T any](repo Repository[T]) (projectionSelector, reflect.Type, error) {
+	selector, ok := any(repo).(projectionSelector)
+	if !ok {
+		return nil, nil, fmt.Errorf("repository: %T does not support projections", repo)
+	}
+
+	destType := reflect.TypeFor[D]()
+	if destType.Kind() == reflect.Pointer {
+		destType = destType.Elem()
+	}
+	if destType.Kind() != reflect.Struct {
+		return nil, nil, errors.NewValidation(
+			"repository: invalid projection type",
+			errors.FieldError{Field: "D", Message: fmt.Sprintf("%s is not a struct", destType)},
+		)
+	}
+	return selector, destType, nil
+}
+
+func (r *repo[T]) selectProjection(ctx context.Context, tx bun.IDB, dest any, destType reflect.Type, list bool, criteria []SelectCriteria) (int, error) {
+	if tx == nil {
+		tx = r.db
+	}
+
+	columns, err := r.projectionColumns(destType)
+	if err != nil {
+		return 0, err
+	}
+
+	// relations would join columns the projection has no fields for
+	ctx = WithoutRelations(ctx)
+
+	if list {
+		q, err := r.listQuery(ctx, tx, r.handlers.NewRecord(), criteria)
+		if err != nil {
+			return 0, err
+		}
+		ctx, collected := collectScanErrors(ctx)
+		total, err := q.Column(columns...).ScanAndCount(ctx, dest)
+		if err != nil {
+			return total, r.mapError(err)
+		}
+		return total, collected()
+	}
+
+	q := tx.NewSelect().Model(r.handlers.NewRecord())
+	q = r.applySelectScopes(ctx, q)
+	if err := r.applySelectCriteria(q, criteria); err != nil {
+		return 0, err
+	}
+	if err := r.scanRecords(ctx, q.Column(columns...).Limit(1).Scan, dest); err != nil {
+		return 0, err
+	}
+	return 1, nil
+}
+
+// projectionColumns returns the model columns declared by destType, resolved
+// through the map descriptor cache.
+func (r *repo[T]) projectionColumns(destType reflect.Type) ([]string, error) {
+	desc, err := getMapModelDescriptor(destType)
+	if err != nil {
+		return nil, err
+	}
+
+	table := r.modelTable()
+	columns := make([]string, 0, len(desc.fields))
+	for _, field := range desc.fields {
+		if table != nil && table.FieldMap[field.bunName] == nil {
+			continue
+		}
+		columns = append(columns, field.bunName)
+	}
+
+	if len(columns) == 0 {
+		return nil, errors.NewValidation(
+			"repository: invalid projection type",
+			errors.FieldError{Field: "D", Message: fmt.Sprintf("%s has no column of %s", destType, r.TableName())},
+		)
+	}
+	return columns, nil
+}
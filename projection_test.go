@@ -0,0 +1,86 @@
+package repository
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	goerrors "github.com/goliatone/go-errors"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type testUserSummary struct {
+	ID    uuid.UUID `bun:"id"`
+	Email string    `bun:"email"`
+	// Rank has no column on test_users and stays zero.
+	Rank int `bun:"rank"`
+}
+
+func TestGetAs(t *testing.T) {
+	ctx := context.Background()
+	setupTestData(t)
+
+	users := newTestUserRepository(db)
+	user, err := users.Create(ctx, &TestUser{Name: "Alice", Email: "alice@example.com"})
+	require.NoError(t, err)
+
+	columns, err := users.(*repo[*TestUser]).projectionColumns(reflect.TypeFor[testUserSummary]())
+	require.NoError(t, err)
+	assert.Equal(t, []string{"id", "email"}, columns)
+
+	summary, err := GetAs[testUserSummary](ctx, users, SelectByID(user.ID.String()))
+	require.NoError(t, err)
+	assert.Equal(t, testUserSummary{ID: user.ID, Email: "alice@example.com"}, summary)
+
+	pointer, err := GetAs[*testUserSummary](ctx, users, SelectBy("email", "=", "alice@example.com"))
+	require.NoError(t, err)
+	assert.Equal(t, user.ID, pointer.ID)
+
+	_, err = GetAs[testUserSummary](ctx, users, SelectByID(uuid.NewString()))
+	require.Error(t, err)
+	assert.True(t, IsRecordNotFound(err))
+}
+
+func TestListAs(t *testing.T) {
+	ctx := context.Background()
+	setupTestData(t)
+
+	repo := newTestUserRepository(db)
+	for _, email := range []string{"a@example.com", "b@example.com", "c@example.com"} {
+		_, err := repo.Create(ctx, &TestUser{Name: email, Email: email})
+		require.NoError(t, err)
+	}
+
+	summaries, total, err := ListAs[testUserSummary](ctx, repo, OrderBy("email DESC"), SelectPaginate(2, 0))
+	require.NoError(t, err)
+	assert.Equal(t, 3, total)
+	require.Len(t, summaries, 2)
+	assert.Equal(t, "c@example.com", summaries[0].Email)
+	assert.Equal(t, "b@example.com", summaries[1].Email)
+	assert.NotEqual(t, uuid.Nil, summaries[0].ID)
+
+	pointers, total, err := ListAsTx[*testUserSummary](ctx, repo, db, SelectBy("email", "=", "a@example.com"))
+	require.NoError(t, err)
+	assert.Equal(t, 1, total)
+	require.Len(t, pointers, 1)
+	assert.Equal(t, "a@example.com", pointers[0].Email)
+}
+
+func TestListAsInvalidProjection(t *testing.T) {
+	ctx := context.Background()
+	setupTestData(t)
+	repo := newTestUserRepository(db)
+
+	type unrelated struct {
+		Title string `bun:"title"`
+	}
+	_, _, err := ListAs[unrelated](ctx, repo)
+	require.Error(t, err)
+	assert.True(t, goerrors.IsValidation(err))
+
+	_, _, err = ListAs[string](ctx, repo)
+	require.Error(t, err)
+	assert.True(t, goerrors.IsValidation(err))
+}
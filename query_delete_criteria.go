@@ -23,7 +23,10 @@ func DeleteColumnIn[T any](column string, values []T) DeleteCriteria {
 	return func(q *bun.DeleteQuery) *bun.DeleteQuery {
 		col, ok := normalizeSQLIdentifier(column)
 		if !ok {
-			return q.Where("1=0")
+			return rejectDelete(q, criteriaInput{"column", column, ok})
+		}
+		if err := unknownColumnError(q, col); err != nil {
+			return q.Err(err)
 		}
 		if len(values) == 0 {
 			return q.Where("1=0")
@@ -34,11 +37,19 @@ func DeleteColumnIn[T any](column string, values []T) DeleteCriteria {
 
 // DeleteBy will delete by a given property
 func DeleteBy(column, operator, value string) DeleteCriteria {
+	return DeleteByValue(column, operator, value)
+}
+
+// DeleteByValue is DeleteBy binding value with its native type.
+func DeleteByValue[T any](column, operator string, value T) DeleteCriteria {
 	return func(q *bun.DeleteQuery) *bun.DeleteQuery {
 		col, colOK := normalizeSQLIdentifier(column)
 		op, opOK := normalizeComparisonOperator(operator)
 		if !colOK || !opOK {
-			return q.Where("1=0")
+			return rejectDelete(q, criteriaInput{"column", column, colOK}, criteriaInput{"operator", operator, opOK})
+		}
+		if err := unknownColumnError(q, col); err != nil {
+			return q.Err(err)
 		}
 		return q.Where(fmt.Sprintf("?TableAlias.%s %s ?", col, op), value)
 	}
@@ -50,7 +61,10 @@ func DeleteByTimetz(column, operator string, value time.Time) DeleteCriteria {
 		col, colOK := normalizeSQLIdentifier(column)
 		op, opOK := normalizeComparisonOperator(operator)
 		if !colOK || !opOK {
-			return q.Where("1=0")
+			return rejectDelete(q, criteriaInput{"column", column, colOK}, criteriaInput{"operator", operator, opOK})
+		}
+		if err := unknownColumnError(q, col); err != nil {
+			return q.Err(err)
 		}
 		ts := value.Format(time.RFC3339)
 		return q.Where(fmt.Sprintf("?TableAlias.%s %s ?", col, op), ts)
@@ -60,17 +74,14 @@ func DeleteByTimetz(column, operator string, value time.Time) DeleteCriteria {
 // DeleteForReal will set the force delete flag to really remove
 // items.
 func DeleteForReal() DeleteCriteria {
-	return func(q *bun.DeleteQuery) *bun.DeleteQuery {
-		return q.ForceDelete()
-	}
+	return deleteForReal
 }
 
 // WithSoftDelete forces the query to only target rows that have already been
-// soft deleted (i.e. where deleted_at IS NOT NULL). This is especially useful
+// soft deleted (i.e. where deleted_at IS NOT NULL, or the column set with
+// WithSoftDeleteColumn or the soft_delete tag). This is especially useful
 // when combined with DeleteForReal to permanently remove records that were
 // previously soft deleted.
 func WithSoftDelete() DeleteCriteria {
-	return func(q *bun.DeleteQuery) *bun.DeleteQuery {
-		return q.Where("?TableAlias.deleted_at IS NOT NULL")
-	}
+	return deleteSoftDeleted
 }
@@ -43,3 +43,13 @@ func TestDeleteBy_InvalidOperatorFailsClosed(t *testing.T) {
 	sql := query.String()
 	assert.True(t, strings.Contains(sql, "1=0") || strings.Contains(sql, "1 = 0"))
 }
+
+func TestDeleteByValue_BindsNativeTypes(t *testing.T) {
+	setupTestData(t)
+
+	query := db.NewDelete().
+		Model((*valueTestMember)(nil)).
+		Apply(DeleteByValue("active", "=", false))
+
+	assert.Contains(t, query.String(), `"u".active = FALSE`)
+}
@@ -0,0 +1,41 @@
+package repository
+
+import (
+	"database/sql"
+	stderrors "errors"
+
+	"github.com/uptrace/bun"
+)
+
+// QueryEventInfo describes a query seen by a bun query hook in repository
+// terms, for hooks exporting logs, traces or metrics.
+type QueryEventInfo struct {
+	// Entity is the Go type name of the model, empty for queries without one.
+	Entity    string
+	Operation string
+	Table     string
+	Driver    string
+	// ErrorCategory is the repository category of the query error, empty when
+	// the query succeeded. No rows is a regular outcome and has no category.
+	ErrorCategory string
+}
+
+// DescribeQueryEvent returns the entity, operation, table, driver and error
+// category of event.
+func DescribeQueryEvent(event *bun.QueryEvent) QueryEventInfo {
+	info := QueryEventInfo{
+		Operation: event.Operation(),
+		Driver:    DetectDriver(event.DB),
+	}
+	if model, ok := event.Model.(bun.TableModel); ok && model.Table() != nil {
+		info.Entity = model.Table().TypeName
+		info.Table = model.Table().Name
+	}
+	if info.Table == "" && event.IQuery != nil {
+		info.Table = event.IQuery.GetTableName()
+	}
+	if event.Err != nil && !stderrors.Is(event.Err, sql.ErrNoRows) {
+		info.ErrorCategory = string(errorCategory(MapDatabaseError(event.Err, info.Driver)))
+	}
+	return info
+}
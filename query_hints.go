@@ -0,0 +1,81 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/uptrace/bun"
+	"github.com/uptrace/bun/dialect"
+)
+
+var planHintPattern = regexp.MustCompile(`^[A-Za-z][A-Za-z0-9_]*\([A-Za-z0-9_ .,#+\-()]*\)$`)
+
+// SelectIndexHint pins the query to the given index using MySQL's
+// FORCE INDEX clause. The criteria is a no-op on other dialects and
+// when the index name is not a valid SQL identifier.
+func SelectIndexHint(index string) SelectCriteria {
+	return func(q *bun.SelectQuery) *bun.SelectQuery {
+		if q.Dialect().Name() != dialect.MySQL {
+			return q
+		}
+		safeIndex, ok := normalizeSQLIdentifier(index)
+		if !ok || strings.Contains(safeIndex, ".") {
+			return q
+		}
+		return q.ForceIndex(safeIndex)
+	}
+}
+
+// SelectPlanComment adds pg_hint_plan hints, e.g. "IndexScan(u idx_users_email)",
+// to a Postgres query. pg_hint_plan only reads a hint comment that opens with
+// "/*+" and is preceded by nothing but keywords, so the hint is rendered as the
+// head of the SELECT list, right after SELECT: apply it before any other column
+// criteria and without a query comment, which bun writes ahead of SELECT.
+// bun's Comment cannot carry the hint since it renders "/* +", which
+// pg_hint_plan ignores. The criteria is a no-op on other dialects and when any
+// hint is malformed; use PlanHintsAvailable to check the server loads
+// pg_hint_plan at all.
+func SelectPlanComment(hints ...string) SelectCriteria {
+	return func(q *bun.SelectQuery) *bun.SelectQuery {
+		if q.Dialect().Name() != dialect.PG {
+			return q
+		}
+		safeHints, ok := normalizePlanHints(hints)
+		if !ok {
+			return q
+		}
+		return q.ColumnExpr(fmt.Sprintf("/*+ %s */ ?TableColumns", strings.Join(safeHints, " ")))
+	}
+}
+
+// PlanHintsAvailable reports whether db reads SelectPlanComment hints, that
+// is whether it is Postgres with pg_hint_plan loaded and enabled, either
+// through shared_preload_libraries or a session LOAD.
+func PlanHintsAvailable(ctx context.Context, db bun.IDB) (bool, error) {
+	if db.Dialect().Name() != dialect.PG {
+		return false, nil
+	}
+	var setting sql.NullString
+	if err := db.NewRaw("SELECT current_setting('pg_hint_plan.enable_hint', true)").Scan(ctx, &setting); err != nil {
+		return false, err
+	}
+	return setting.Valid && strings.EqualFold(setting.String, "on"), nil
+}
+
+func normalizePlanHints(hints []string) ([]string, bool) {
+	safeHints := make([]string, 0, len(hints))
+	for _, hint := range hints {
+		hint = strings.TrimSpace(hint)
+		if hint == "" {
+			continue
+		}
+		if !planHintPattern.MatchString(hint) {
+			return nil, false
+		}
+		safeHints = append(safeHints, hint)
+	}
+	return safeHints, len(safeHints) > 0
+}
@@ -0,0 +1,54 @@
+package repository
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/uptrace/bun/dialect/mysqldialect"
+	"github.com/uptrace/bun/dialect/pgdialect"
+)
+
+func TestSelectIndexHint(t *testing.T) {
+	mysqlDB := newDialectTestDB(t, mysqldialect.New())
+
+	sql := mysqlDB.NewSelect().Model((*TestUser)(nil)).
+		Apply(SelectIndexHint("idx_users_email")).
+		String()
+	assert.Contains(t, sql, "FORCE INDEX (`idx_users_email`)")
+
+	sql = mysqlDB.NewSelect().Model((*TestUser)(nil)).
+		Apply(SelectIndexHint("idx`; DROP TABLE x")).
+		String()
+	assert.NotContains(t, sql, "FORCE INDEX")
+
+	sql = db.NewSelect().Model((*TestUser)(nil)).
+		Apply(SelectIndexHint("idx_users_email")).
+		String()
+	assert.NotContains(t, sql, "idx_users_email")
+}
+
+func TestSelectPlanComment(t *testing.T) {
+	pgDB := newDialectTestDB(t, pgdialect.New())
+
+	sql := pgDB.NewSelect().Model((*TestUser)(nil)).
+		Apply(SelectPlanComment("IndexScan(u idx_users_email)", "Set(enable_seqscan off)")).
+		String()
+	assert.True(t, strings.HasPrefix(sql, `SELECT /*+ IndexScan(u idx_users_email) Set(enable_seqscan off) */ "u"."id"`), sql)
+
+	sql = pgDB.NewSelect().Model((*TestUser)(nil)).
+		Apply(SelectPlanComment("IndexScan(u) */ DROP TABLE x; /*")).
+		String()
+	assert.NotContains(t, sql, "/*+")
+
+	sql = db.NewSelect().Model((*TestUser)(nil)).
+		Apply(SelectPlanComment("IndexScan(u idx_users_email)")).
+		String()
+	assert.NotContains(t, sql, "/*+")
+
+	available, err := PlanHintsAvailable(context.Background(), db)
+	require.NoError(t, err)
+	assert.False(t, available)
+}
@@ -35,6 +35,37 @@ func InsertOnConflictUpdate(cols ...string) InsertCriteria {
 	}
 }
 
+// InsertOnConflictUpdateWhere targets a partial unique index, e.g.
+// InsertOnConflictUpdateWhere([]string{"email"}, "deleted_at IS NULL") renders
+// ON CONFLICT (email) WHERE deleted_at IS NULL DO UPDATE. The predicate must
+// match the index predicate for Postgres (and SQLite) to infer the index.
+// An invalid column list or predicate fails the query with a criteria error.
+// The predicate is spliced into the statement as is, with only statement
+// separators and comments rejected, so it must never come from user input.
+//
+// It applies to Create and CreateMany. UpsertMany looks existing records up
+// instead and takes no conflict target; use the UpsertManyOnConflict
+// capability for a single statement upsert against a partial unique index.
+func InsertOnConflictUpdateWhere(cols []string, predicate string, args ...any) InsertCriteria {
+	return func(iq *bun.InsertQuery) *bun.InsertQuery {
+		safe := make([]string, 0, len(cols))
+		for _, col := range cols {
+			if normalized, ok := normalizeSQLIdentifier(col); ok {
+				safe = append(safe, normalized)
+			}
+		}
+		where, ok := normalizeSQLPredicate(predicate)
+		if len(safe) == 0 || len(safe) != len(cols) || !ok {
+			return iq.Err(NewCriteriaInvalidError("repository: invalid conflict target").
+				WithMetadata(map[string]any{
+					"columns":   cols,
+					"predicate": predicate,
+				}))
+		}
+		return iq.On(fmt.Sprintf("CONFLICT (%s) WHERE %s DO UPDATE", strings.Join(safe, ","), where), args...)
+	}
+}
+
 var insertReturnOrderByIDMarker InsertCriteria = func(iq *bun.InsertQuery) *bun.InsertQuery {
 	return iq
 }
@@ -0,0 +1,64 @@
+package repository
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/uptrace/bun/dialect/pgdialect"
+)
+
+func TestInsertOnConflictUpdateWhere_RendersConflictTarget(t *testing.T) {
+	pgDB := newDialectTestDB(t, pgdialect.New())
+
+	q := pgDB.NewInsert().Model(&TestUser{Name: "Alice", Email: "alice@example.com"})
+	q = InsertOnConflictUpdateWhere([]string{"email"}, "deleted_at IS NULL")(q)
+
+	sql := q.String()
+	assert.Contains(t, sql, `ON CONFLICT (email) WHERE deleted_at IS NULL DO UPDATE SET`)
+}
+
+func TestInsertOnConflictUpdateWhere_InvalidTarget(t *testing.T) {
+	setupTestData(t)
+	repo := newTestUserRepository(db)
+
+	_, err := repo.Create(context.Background(), &TestUser{Name: "Alice", Email: "alice@example.com"},
+		InsertOnConflictUpdateWhere([]string{"email"}, "1=1; DROP TABLE test_users"))
+	require.Error(t, err)
+	assert.True(t, IsCriteriaInvalid(err))
+
+	_, err = repo.Create(context.Background(), &TestUser{Name: "Alice", Email: "alice@example.com"},
+		InsertOnConflictUpdateWhere(nil, "deleted_at IS NULL"))
+	require.Error(t, err)
+	assert.True(t, IsCriteriaInvalid(err))
+}
+
+func TestInsertOnConflictUpdateWhere_PartialUniqueIndex(t *testing.T) {
+	setupTestData(t)
+	ctx := context.Background()
+	repo := newTestUserRepository(db)
+
+	_, err := db.ExecContext(ctx, `CREATE UNIQUE INDEX test_users_company_name_idx ON test_users (company_id, name) WHERE name <> ''`)
+	require.NoError(t, err)
+
+	companyID := uuid.New()
+	first, err := repo.Create(ctx, &TestUser{Name: "Alice", Email: "alice@example.com", CompanyID: companyID})
+	require.NoError(t, err)
+
+	upserted, err := repo.CreateMany(ctx, []*TestUser{
+		{Name: "Alice", Email: "alice@new.example.com", CompanyID: companyID},
+		{Name: "Bob", Email: "bob@example.com", CompanyID: companyID},
+	}, InsertOnConflictUpdateWhere([]string{"company_id", "name"}, "name <> ''"))
+	require.NoError(t, err)
+	require.Len(t, upserted, 2)
+
+	total, err := repo.Count(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 2, total)
+
+	alice, err := repo.GetByID(ctx, first.ID.String())
+	require.NoError(t, err)
+	assert.Equal(t, "alice@new.example.com", alice.Email)
+}
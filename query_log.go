@@ -0,0 +1,445 @@
+package repository
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/goliatone/go-repository-bun/repositoryctx"
+	"github.com/uptrace/bun"
+)
+
+// RedactedValue replaces literals of sensitive columns in logged SQL.
+const RedactedValue = "'[REDACTED]'"
+
+// QueryLogEntry is a query reported by the query log hook. Query has the
+// values of sensitive columns replaced by RedactedValue.
+type QueryLogEntry struct {
+	Entity    string
+	Operation string
+	Table     string
+	Query     string
+	Duration  time.Duration
+	// Slow is set when Duration reached the WithSlowQueryThreshold threshold.
+	Slow bool
+	Err  error
+	// Method and Caller come from the OperationInfo of the repository call
+	// that ran the query; they are empty for queries run outside one.
+	Method string
+	Caller string
+}
+
+// QueryLogFunc receives logged queries.
+type QueryLogFunc func(ctx context.Context, entry QueryLogEntry)
+
+// QueryLogOption configures WithQueryLogging.
+type QueryLogOption func(*queryLogHook)
+
+// WithSlowQueryThreshold only logs queries taking at least threshold, failed
+// queries and queries run with repositoryctx.WithDebug. Without it every
+// query is logged, as a debug hook.
+func WithSlowQueryThreshold(threshold time.Duration) QueryLogOption {
+	return func(h *queryLogHook) {
+		h.slowThreshold = threshold
+	}
+}
+
+// WithRedactedColumns masks the values compared with or assigned to columns,
+// matched case insensitively and regardless of table qualifier.
+func WithRedactedColumns(columns ...string) QueryLogOption {
+	return func(h *queryLogHook) {
+		h.redactor = h.redactor.with(columns...)
+	}
+}
+
+// WithRedactedConstraintFields masks the fields of a WithConstraintMapping
+// configuration, so columns already declared for error reporting, usually
+// unique ones such as email, are not leaked by logs either.
+func WithRedactedConstraintFields(mapping map[string]FieldErrorSpec) QueryLogOption {
+	return func(h *queryLogHook) {
+		columns := make([]string, 0, len(mapping))
+		for _, spec := range mapping {
+			columns = append(columns, spec.Field)
+		}
+		h.redactor = h.redactor.with(columns...)
+	}
+}
+
+// WithQueryLogging registers a query hook reporting queries to logger with
+// the values of sensitive columns masked, so logs are safe to ship to third
+// party providers:
+//
+//	repository.WithQueryLogging(logQuery,
+//		repository.WithSlowQueryThreshold(200*time.Millisecond),
+//		repository.WithRedactedColumns("email", "password_hash"),
+//	)
+//
+// Each call registers its own hook, so a debug logger and a slow query
+// logger can share a bun.DB. Reuse the returned Option across repositories
+// of the same bun.DB to register the hook once.
+func WithQueryLogging(logger QueryLogFunc, opts ...QueryLogOption) Option {
+	if logger == nil {
+		return func(*bun.DB) {}
+	}
+	hook := &queryLogHook{log: logger}
+	for _, opt := range opts {
+		if opt != nil {
+			opt(hook)
+		}
+	}
+	return func(db *bun.DB) {
+		registerQueryHooks(db, hook)
+	}
+}
+
+type queryLogHook struct {
+	log           QueryLogFunc
+	slowThreshold time.Duration
+	redactor      QueryRedactor
+}
+
+func (h *queryLogHook) BeforeQuery(ctx context.Context, _ *bun.QueryEvent) context.Context {
+	return ctx
+}
+
+func (h *queryLogHook) AfterQuery(ctx context.Context, event *bun.QueryEvent) {
+	duration := time.Since(event.StartTime)
+	slow := h.slowThreshold > 0 && duration >= h.slowThreshold
+	if h.slowThreshold > 0 && !slow && event.Err == nil && !repositoryctx.Debug(ctx) {
+		return
+	}
+
+	info := DescribeQueryEvent(event)
+	operation, _ := repositoryctx.Operation(ctx)
+	h.log(ctx, QueryLogEntry{
+		Entity:    info.Entity,
+		Operation: info.Operation,
+		Table:     info.Table,
+		Query:     h.redactor.Redact(event.Query),
+		Duration:  duration,
+		Slow:      slow,
+		Err:       event.Err,
+		Method:    operation.Operation,
+		Caller:    operation.Caller,
+	})
+}
+
+// QueryRedactor masks the literals bound to sensitive columns in formatted
+// SQL: comparisons (=, <>, LIKE, IN, BETWEEN, ...), SET assignments and
+// INSERT values. The output only depends on the query and the columns.
+type QueryRedactor struct {
+	columns map[string]struct{}
+}
+
+// NewQueryRedactor returns a redactor for columns.
+func NewQueryRedactor(columns ...string) QueryRedactor {
+	return QueryRedactor{}.with(columns...)
+}
+
+// Columns returns the sensitive columns, sorted.
+func (r QueryRedactor) Columns() []string {
+	columns := make([]string, 0, len(r.columns))
+	for column := range r.columns {
+		columns = append(columns, column)
+	}
+	sort.Strings(columns)
+	return columns
+}
+
+func (r QueryRedactor) with(columns ...string) QueryRedactor {
+	merged := make(map[string]struct{}, len(r.columns)+len(columns))
+	for column := range r.columns {
+		merged[column] = struct{}{}
+	}
+	for _, column := range columns {
+		if col, ok := normalizeSQLIdentifier(column); ok {
+			merged[strings.ToLower(lastIdentifierPart(col))] = struct{}{}
+		}
+	}
+	return QueryRedactor{columns: merged}
+}
+
+func (r QueryRedactor) sensitive(tok sqlToken) bool {
+	if tok.kind != sqlTokenIdent {
+		return false
+	}
+	_, ok := r.columns[strings.ToLower(tok.name())]
+	return ok
+}
+
+// Redact returns query with the values of sensitive columns masked.
+func (r QueryRedactor) Redact(query string) string {
+	if len(r.columns) == 0 || query == "" {
+		return query
+	}
+
+	tokens := tokenizeSQL(query)
+	redact := make([]bool, len(tokens))
+	r.redactComparisons(tokens, redact)
+	r.redactInsertValues(tokens, redact)
+
+	var b strings.Builder
+	b.Grow(len(query))
+	for i, tok := range tokens {
+		if redact[i] {
+			b.WriteString(RedactedValue)
+			continue
+		}
+		b.WriteString(tok.text)
+	}
+	return b.String()
+}
+
+func (r QueryRedactor) redactComparisons(tokens []sqlToken, redact []bool) {
+	for i := range tokens {
+		if !r.sensitive(tokens[i]) {
+			continue
+		}
+		// skip qualifiers such as "u"."email"
+		if next := nextSQLToken(tokens, i+1); next < len(tokens) && tokens[next].text == "." {
+			continue
+		}
+
+		op := nextSQLToken(tokens, i+1)
+		if op < len(tokens) && strings.EqualFold(tokens[op].text, "NOT") {
+			op = nextSQLToken(tokens, op+1)
+		}
+		if op >= len(tokens) {
+			continue
+		}
+
+		switch upper := strings.ToUpper(tokens[op].text); upper {
+		case "=", "<>", "!=", "<", ">", "<=", ">=", "LIKE", "ILIKE", "IN":
+			redactSQLValue(tokens, redact, nextSQLToken(tokens, op+1))
+		case "BETWEEN":
+			low := nextSQLToken(tokens, op+1)
+			end := redactSQLValue(tokens, redact, low)
+			if and := nextSQLToken(tokens, end); and < len(tokens) && strings.EqualFold(tokens[and].text, "AND") {
+				redactSQLValue(tokens, redact, nextSQLToken(tokens, and+1))
+			}
+		}
+	}
+}
+
+func (r QueryRedactor) redactInsertValues(tokens []sqlToken, redact []bool) {
+	for i := range tokens {
+		if !strings.EqualFold(tokens[i].text, "INTO") {
+			continue
+		}
+		// INTO table (columns) VALUES (...), (...)
+		open := i + 1
+		for open < len(tokens) && tokens[open].text != "(" && !strings.EqualFold(tokens[open].text, "VALUES") {
+			open++
+		}
+		if open >= len(tokens) || tokens[open].text != "(" {
+			continue
+		}
+
+		sensitive := []bool{}
+		pos := open + 1
+		for ; pos < len(tokens) && tokens[pos].text != ")"; pos++ {
+			if tokens[pos].kind != sqlTokenIdent {
+				continue
+			}
+			if next := nextSQLToken(tokens, pos+1); next < len(tokens) && tokens[next].text == "." {
+				continue
+			}
+			sensitive = append(sensitive, r.sensitive(tokens[pos]))
+		}
+
+		values := nextSQLToken(tokens, pos+1)
+		if values >= len(tokens) || !strings.EqualFold(tokens[values].text, "VALUES") {
+			continue
+		}
+		for row := nextSQLToken(tokens, values+1); row < len(tokens) && tokens[row].text == "("; {
+			row = redactSQLTuple(tokens, redact, row, sensitive)
+			if row >= len(tokens) || tokens[row].text != "," {
+				break
+			}
+			row = nextSQLToken(tokens, row+1)
+		}
+	}
+}
+
+// redactSQLTuple masks the values of the tuple opening at open whose position
+// is flagged in sensitive. It returns the first token after the tuple.
+func redactSQLTuple(tokens []sqlToken, redact []bool, open int, sensitive []bool) int {
+	depth := 0
+	index := 0
+	for i := open; i < len(tokens); i++ {
+		switch tokens[i].text {
+		case "(":
+			depth++
+			continue
+		case ")":
+			depth--
+			if depth == 0 {
+				return nextSQLToken(tokens, i+1)
+			}
+			continue
+		case ",":
+			if depth == 1 {
+				index++
+			}
+			continue
+		}
+		if index < len(sensitive) && sensitive[index] && tokens[i].literal() {
+			redact[i] = true
+		}
+	}
+	return len(tokens)
+}
+
+// redactSQLValue masks the literal at pos, or every literal of the
+// parenthesized list at pos, and returns the position after the value.
+func redactSQLValue(tokens []sqlToken, redact []bool, pos int) int {
+	if pos >= len(tokens) {
+		return pos
+	}
+	if tokens[pos].text == "(" {
+		depth := 0
+		for i := pos; i < len(tokens); i++ {
+			switch {
+			case tokens[i].text == "(":
+				depth++
+			case tokens[i].text == ")":
+				depth--
+				if depth == 0 {
+					return i + 1
+				}
+			case tokens[i].literal():
+				redact[i] = true
+			}
+		}
+		return len(tokens)
+	}
+	if tokens[pos].text == "-" {
+		if next := nextSQLToken(tokens, pos+1); next < len(tokens) && tokens[next].kind == sqlTokenNumber {
+			redact[pos] = true
+			for i := pos + 1; i < next; i++ {
+				tokens[i].text = ""
+			}
+			tokens[next].text = ""
+			return next + 1
+		}
+	}
+	if tokens[pos].literal() {
+		redact[pos] = true
+	}
+	return pos + 1
+}
+
+func nextSQLToken(tokens []sqlToken, from int) int {
+	for from < len(tokens) && tokens[from].kind == sqlTokenSpace {
+		from++
+	}
+	return from
+}
+
+func lastIdentifierPart(ident string) string {
+	if idx := strings.LastIndexByte(ident, '.'); idx >= 0 {
+		return ident[idx+1:]
+	}
+	return ident
+}
+
+type sqlTokenKind int
+
+const (
+	sqlTokenSpace sqlTokenKind = iota
+	sqlTokenIdent
+	sqlTokenString
+	sqlTokenNumber
+	sqlTokenPunct
+)
+
+type sqlToken struct {
+	kind sqlTokenKind
+	text string
+}
+
+func (t sqlToken) literal() bool {
+	return t.kind == sqlTokenString || t.kind == sqlTokenNumber
+}
+
+// name returns the identifier without quotes.
+func (t sqlToken) name() string {
+	if len(t.text) >= 2 {
+		switch t.text[0] {
+		case '"', '`', '[':
+			return t.text[1 : len(t.text)-1]
+		}
+	}
+	return t.text
+}
+
+func tokenizeSQL(query string) []sqlToken {
+	tokens := []sqlToken{}
+	for i := 0; i < len(query); {
+		c := query[i]
+		start := i
+		var kind sqlTokenKind
+		switch {
+		case unicode.IsSpace(rune(c)):
+			kind = sqlTokenSpace
+			for i < len(query) && unicode.IsSpace(rune(query[i])) {
+				i++
+			}
+		case c == '\'':
+			kind = sqlTokenString
+			i = scanSQLQuoted(query, i, '\'')
+		case c == '"' || c == '`':
+			kind = sqlTokenIdent
+			i = scanSQLQuoted(query, i, c)
+		case c == '[':
+			kind = sqlTokenIdent
+			i = scanSQLQuoted(query, i, ']')
+		case c >= '0' && c <= '9':
+			kind = sqlTokenNumber
+			for i < len(query) && (isSQLWordByte(query[i]) || query[i] == '.') {
+				i++
+			}
+		case isSQLWordByte(c):
+			kind = sqlTokenIdent
+			for i < len(query) && isSQLWordByte(query[i]) {
+				i++
+			}
+			// prefixed strings such as E'...', N'...' and X'...'
+			if i < len(query) && query[i] == '\'' && i-start == 1 {
+				kind = sqlTokenString
+				i = scanSQLQuoted(query, i, '\'')
+			}
+		default:
+			kind = sqlTokenPunct
+			i++
+			if i < len(query) && strings.Contains("<>!", string(c)) && strings.Contains("=>", string(query[i])) {
+				i++
+			}
+		}
+		tokens = append(tokens, sqlToken{kind: kind, text: query[start:i]})
+	}
+	return tokens
+}
+
+// scanSQLQuoted returns the position after the quoted section starting at
+// start. Doubled closing quotes are escapes.
+func scanSQLQuoted(query string, start int, closing byte) int {
+	for i := start + 1; i < len(query); i++ {
+		if query[i] != closing {
+			continue
+		}
+		if i+1 < len(query) && query[i+1] == closing {
+			i++
+			continue
+		}
+		return i + 1
+	}
+	return len(query)
+}
+
+func isSQLWordByte(c byte) bool {
+	return c == '_' || c == '$' || c >= 0x80 ||
+		(c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (c >= '0' && c <= '9')
+}
@@ -0,0 +1,158 @@
+package repository
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/goliatone/go-repository-bun/repositoryctx"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/uptrace/bun/dialect/sqlitedialect"
+)
+
+func TestQueryRedactor_Redact(t *testing.T) {
+	redactor := NewQueryRedactor("email", "u.ssn", "bad column")
+	assert.Equal(t, []string{"email", "ssn"}, redactor.Columns())
+
+	cases := []struct {
+		name     string
+		query    string
+		expected string
+	}{
+		{
+			name:     "where equality",
+			query:    `SELECT "u"."id" FROM "test_users" AS "u" WHERE ("u"."email" = 'a@example.com') AND ("u"."name" = 'Alice')`,
+			expected: `SELECT "u"."id" FROM "test_users" AS "u" WHERE ("u"."email" = '[REDACTED]') AND ("u"."name" = 'Alice')`,
+		},
+		{
+			name:     "in list and like",
+			query:    `SELECT * FROM users WHERE email IN ('a@x.io', 'b@x.io') OR EMAIL NOT LIKE '%@x.io' OR ssn BETWEEN 100 AND -200`,
+			expected: `SELECT * FROM users WHERE email IN ('[REDACTED]', '[REDACTED]') OR EMAIL NOT LIKE '[REDACTED]' OR ssn BETWEEN '[REDACTED]' AND '[REDACTED]'`,
+		},
+		{
+			name:     "escaped quotes",
+			query:    "UPDATE `users` SET `email` = 'o''brien@x.io', `name` = 'O''Brien' WHERE `id` = 1",
+			expected: "UPDATE `users` SET `email` = '[REDACTED]', `name` = 'O''Brien' WHERE `id` = 1",
+		},
+		{
+			name:     "insert values",
+			query:    `INSERT INTO "test_users" ("id", "name", "email") VALUES ('1', 'Alice', 'a@x.io'), ('2', lower('Bob'), 'b@x.io') RETURNING "id"`,
+			expected: `INSERT INTO "test_users" ("id", "name", "email") VALUES ('1', 'Alice', '[REDACTED]'), ('2', lower('Bob'), '[REDACTED]') RETURNING "id"`,
+		},
+		{
+			name:     "column references are kept",
+			query:    `INSERT INTO users (email) VALUES ('a@x.io') ON CONFLICT (email) DO UPDATE SET email = EXCLUDED.email`,
+			expected: `INSERT INTO users (email) VALUES ('[REDACTED]') ON CONFLICT (email) DO UPDATE SET email = EXCLUDED.email`,
+		},
+		{
+			name:     "mssql prefixed strings",
+			query:    `SELECT * FROM [users] WHERE [email] = N'a@x.io'`,
+			expected: `SELECT * FROM [users] WHERE [email] = '[REDACTED]'`,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, redactor.Redact(tc.query))
+			assert.Equal(t, tc.expected, redactor.Redact(tc.query), "output is deterministic")
+		})
+	}
+
+	assert.Equal(t, cases[0].query, NewQueryRedactor().Redact(cases[0].query))
+}
+
+type queryLogRecorder struct {
+	mu      sync.Mutex
+	entries []QueryLogEntry
+}
+
+func (r *queryLogRecorder) log(_ context.Context, entry QueryLogEntry) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.entries = append(r.entries, entry)
+}
+
+func TestWithQueryLogging(t *testing.T) {
+	ctx := context.Background()
+	testDB := newDialectTestDB(t, sqlitedialect.New())
+	_, err := testDB.NewCreateTable().Model((*TestUser)(nil)).Exec(ctx)
+	require.NoError(t, err)
+
+	recorder := &queryLogRecorder{}
+	repo := newTestUserRepository(testDB, WithQueryLogging(recorder.log,
+		WithRedactedConstraintFields(map[string]FieldErrorSpec{
+			"test_users.email": {Field: "email", Message: "email already taken"},
+		}),
+	))
+
+	_, err = repo.Create(ctx, &TestUser{Name: "Alice", Email: "alice@example.com"})
+	require.NoError(t, err)
+	_, err = repo.GetByIdentifier(ctx, "alice@example.com")
+	require.NoError(t, err)
+
+	require.Len(t, recorder.entries, 2)
+	for _, entry := range recorder.entries {
+		assert.NotContains(t, entry.Query, "alice@example.com")
+		assert.Contains(t, entry.Query, RedactedValue)
+		assert.Equal(t, "TestUser", entry.Entity)
+		assert.Equal(t, "test_users", entry.Table)
+		assert.False(t, entry.Slow)
+	}
+	assert.Equal(t, "INSERT", recorder.entries[0].Operation)
+	assert.Contains(t, recorder.entries[0].Query, "'Alice'")
+	assert.Equal(t, "Create", recorder.entries[0].Method)
+	assert.Equal(t, "GetByIdentifier", recorder.entries[1].Method)
+	assert.Contains(t, recorder.entries[1].Caller, "/query_log_test.go:")
+}
+
+func TestWithQueryLogging_SlowQueryThreshold(t *testing.T) {
+	ctx := context.Background()
+	testDB := newDialectTestDB(t, sqlitedialect.New())
+	_, err := testDB.NewCreateTable().Model((*TestUser)(nil)).Exec(ctx)
+	require.NoError(t, err)
+
+	recorder := &queryLogRecorder{}
+	repo := newTestUserRepository(testDB, WithQueryLogging(recorder.log,
+		WithSlowQueryThreshold(time.Hour),
+		WithRedactedColumns("email"),
+	))
+
+	_, err = repo.Create(ctx, &TestUser{Name: "Alice", Email: "alice@example.com"})
+	require.NoError(t, err)
+	assert.Empty(t, recorder.entries, "fast queries are not logged")
+
+	_, err = repo.Create(ctx, &TestUser{Name: "Alice", Email: "alice@example.com"})
+	require.Error(t, err)
+	require.Len(t, recorder.entries, 1, "failed queries are always logged")
+	assert.Error(t, recorder.entries[0].Err)
+	assert.NotContains(t, recorder.entries[0].Query, "alice@example.com")
+
+	_, err = repo.Count(repositoryctx.WithDebug(ctx))
+	require.NoError(t, err)
+	require.Len(t, recorder.entries, 2, "debug queries are always logged")
+	assert.Equal(t, "SELECT", recorder.entries[1].Operation)
+}
+
+func TestWithQueryLogging_SeveralLoggers(t *testing.T) {
+	ctx := context.Background()
+	testDB := newDialectTestDB(t, sqlitedialect.New())
+	_, err := testDB.NewCreateTable().Model((*TestUser)(nil)).Exec(ctx)
+	require.NoError(t, err)
+
+	debug := &queryLogRecorder{}
+	slow := &queryLogRecorder{}
+	debugLogging := WithQueryLogging(debug.log)
+	repo := newTestUserRepository(testDB, debugLogging, WithQueryLogging(slow.log, WithSlowQueryThreshold(time.Hour)))
+	// reusing the Option must not register the debug logger twice
+	repo = newTestUserRepository(testDB, debugLogging)
+
+	_, err = repo.Create(ctx, &TestUser{Name: "Alice", Email: "alice@example.com"})
+	require.NoError(t, err)
+	_, err = repo.Create(ctx, &TestUser{Name: "Alice", Email: "alice@example.com"})
+	require.Error(t, err)
+
+	assert.Len(t, debug.entries, 2)
+	assert.Len(t, slow.entries, 1, "the slow query logger still sees failed queries")
+}
@@ -1,9 +1,13 @@
 package repository
 
 import (
+	"fmt"
 	"regexp"
 	"strings"
 	"unicode"
+
+	"github.com/goliatone/go-errors"
+	"github.com/uptrace/bun"
 )
 
 var (
@@ -54,6 +58,23 @@ func normalizeSQLIdentifier(identifier string) (string, bool) {
 	return strings.Join(parts, "."), true
 }
 
+// normalizeSQLIdentifiers returns the valid identifiers among columns and
+// the invalid ones as criteria inputs.
+func normalizeSQLIdentifiers(columns []string) ([]string, []criteriaInput) {
+	var (
+		safe    = make([]string, 0, len(columns))
+		invalid []criteriaInput
+	)
+	for _, column := range columns {
+		if normalized, ok := normalizeSQLIdentifier(column); ok {
+			safe = append(safe, normalized)
+		} else {
+			invalid = append(invalid, criteriaInput{"column", column, false})
+		}
+	}
+	return safe, invalid
+}
+
 func normalizeComparisonOperator(operator string) (string, bool) {
 	operator = normalizeSQLOperator(operator)
 	if _, ok := comparisonOperators[operator]; !ok {
@@ -120,6 +141,21 @@ func normalizeSubquery(query string) (string, bool) {
 	return query, true
 }
 
+// normalizeSQLPredicate accepts a single boolean expression such as
+// "deleted_at IS NULL", rejecting statement separators and comments.
+func normalizeSQLPredicate(predicate string) (string, bool) {
+	predicate = strings.TrimSpace(predicate)
+	if predicate == "" {
+		return "", false
+	}
+
+	if strings.Contains(predicate, ";") || strings.Contains(predicate, "--") || strings.Contains(predicate, "/*") || strings.Contains(predicate, "*/") {
+		return "", false
+	}
+
+	return predicate, true
+}
+
 func normalizeJSONExpression(expr string) (string, bool) {
 	expr = strings.TrimSpace(expr)
 	if expr == "" {
@@ -142,3 +178,91 @@ func normalizeJSONExpression(expr string) (string, bool) {
 
 	return expr, true
 }
+
+// WithStrictCriteria makes criteria fail the query with a validation error on
+// invalid input, such as a column that is not a SQL identifier, an unknown
+// operator or a malformed JSON path, where they otherwise fail closed
+// silently: filters match nothing and OR, ORDER BY, DISTINCT and GROUP BY
+// criteria are dropped. Like WithFilterableColumns it only applies to the
+// criteria passed to the repository methods.
+func WithStrictCriteria() RepoOption {
+	return func(cfg *repoConfig) {
+		if cfg != nil {
+			cfg.strictCriteria = true
+		}
+	}
+}
+
+func strictCriteria(q criteriaQuery) bool {
+	policy := queryCriteriaPolicy(q)
+	return policy != nil && policy.strict
+}
+
+// criteriaInput is a criteria argument and whether it passed normalization.
+type criteriaInput struct {
+	field string
+	value string
+	ok    bool
+}
+
+func invalidCriteriaError(inputs []criteriaInput) error {
+	var fieldErrors errors.ValidationErrors
+	for _, input := range inputs {
+		if !input.ok {
+			fieldErrors = append(fieldErrors, errors.FieldError{
+				Field:   input.field,
+				Message: fmt.Sprintf("invalid %s %q", input.field, input.value),
+				Value:   input.value,
+			})
+		}
+	}
+	return errors.NewValidation("repository: invalid criteria", fieldErrors...)
+}
+
+func criteriaInputsValid(inputs []criteriaInput) bool {
+	for _, input := range inputs {
+		if !input.ok {
+			return false
+		}
+	}
+	return true
+}
+
+// rejectSelect fails q closed on invalid criteria input: it matches nothing,
+// or fails with a validation error in strict mode.
+func rejectSelect(q *bun.SelectQuery, inputs ...criteriaInput) *bun.SelectQuery {
+	if strictCriteria(q) {
+		return q.Err(invalidCriteriaError(inputs))
+	}
+	return q.Where("1=0")
+}
+
+// skipSelect leaves q as is on invalid input to criteria that only widen or
+// shape the result, failing it with a validation error in strict mode.
+func skipSelect(q *bun.SelectQuery, inputs ...criteriaInput) *bun.SelectQuery {
+	if !criteriaInputsValid(inputs) && strictCriteria(q) {
+		return q.Err(invalidCriteriaError(inputs))
+	}
+	return q
+}
+
+func rejectUpdate(q *bun.UpdateQuery, inputs ...criteriaInput) *bun.UpdateQuery {
+	if strictCriteria(q) {
+		return q.Err(invalidCriteriaError(inputs))
+	}
+	return q.Where("1=0")
+}
+
+func skipUpdate(q *bun.UpdateQuery, inputs ...criteriaInput) *bun.UpdateQuery {
+	if !criteriaInputsValid(inputs) && strictCriteria(q) {
+		return q.Err(invalidCriteriaError(inputs))
+	}
+	return q
+}
+
+func rejectDelete(q *bun.DeleteQuery, inputs ...criteriaInput) *bun.DeleteQuery {
+	if strictCriteria(q) {
+		return q.Err(invalidCriteriaError(inputs))
+	}
+	return q.Where("1=0")
+}
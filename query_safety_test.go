@@ -0,0 +1,85 @@
+package repository
+
+import (
+	"context"
+	"testing"
+
+	goerrors "github.com/goliatone/go-errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/uptrace/bun/dialect/sqlitedialect"
+)
+
+func TestStrictCriteria(t *testing.T) {
+	ctx := context.Background()
+	testDB := newDialectTestDB(t, sqlitedialect.New())
+	_, err := testDB.NewCreateTable().Model((*TestUser)(nil)).Exec(ctx)
+	require.NoError(t, err)
+
+	lenient := newTestUserRepository(testDB)
+	_, err = lenient.Create(ctx, &TestUser{Name: "Alice", Email: "alice@example.com"})
+	require.NoError(t, err)
+
+	invalid := []SelectCriteria{
+		SelectBy("name; DROP TABLE test_users", "=", "Alice"),
+		SelectBy("name", "=>", "Alice"),
+		SelectColumnCompare("name", "=", "email --"),
+		SelectILike("lower(name)", "a%"),
+		WhereJSONKeyEquals("name", "a..b", "x"),
+		WhereJSONContains("name @> '{}' OR 1=1", "x"),
+	}
+	for i, criteria := range invalid {
+		users, _, err := lenient.List(ctx, criteria)
+		require.NoError(t, err, i)
+		assert.Empty(t, users, "invalid criteria %d fail closed", i)
+	}
+
+	users, _, err := lenient.List(ctx, OrderBy("name; --"), SelectGroupBy("(id)"))
+	require.NoError(t, err)
+	assert.Len(t, users, 1, "invalid ORDER BY and GROUP BY are dropped")
+
+	users, _, err = lenient.List(ctx, OrderByJSONText("name", "sideways"))
+	require.NoError(t, err)
+	assert.Len(t, users, 1, "invalid JSON order direction falls back to ASC")
+	assert.Contains(t, testDB.NewSelect().Model((*TestUser)(nil)).Apply(OrderByJSONText("name", "sideways")).String(), `ORDER BY "name" ASC`)
+
+	affected, err := lenient.(BulkUpdater).UpdateWhere(ctx, UpdateSetColumn("name", "Bob"), UpdateSetColumn("name = 'x', email", "x"),
+		UpdateBy("email", "=", "alice@example.com"))
+	require.NoError(t, err)
+	assert.Zero(t, affected)
+
+	strict := newTestUserRepositoryWithConfig(testDB, nil, WithStrictCriteria())
+	for i, criteria := range invalid {
+		_, _, err := strict.List(ctx, criteria)
+		require.Error(t, err, i)
+		assert.True(t, goerrors.IsValidation(err), i)
+	}
+
+	_, _, err = strict.List(ctx, OrderBy("name; --"))
+	assert.True(t, goerrors.IsValidation(err))
+	_, _, err = strict.List(ctx, SelectGroupBy("(id)"))
+	assert.True(t, goerrors.IsValidation(err))
+	_, _, err = strict.List(ctx, OrderByJSONText("name", "sideways"))
+	assert.True(t, goerrors.IsValidation(err))
+	_, _, err = strict.List(ctx, GroupByDateTrunc("created_at; --", TimeBucketDay))
+	assert.True(t, goerrors.IsValidation(err))
+	_, err = strict.(BulkUpdater).UpdateWhere(ctx, UpdateBy("email", "LIKE ANY", "%"))
+	assert.True(t, goerrors.IsValidation(err))
+	err = strict.DeleteWhere(ctx, DeleteBy("1", "=", "1"))
+	assert.True(t, goerrors.IsValidation(err))
+
+	var validationErr *goerrors.Error
+	_, _, err = strict.List(ctx, SelectBy("bad column", "~", "x"))
+	require.True(t, goerrors.As(err, &validationErr))
+	require.Len(t, validationErr.ValidationErrors, 2)
+	assert.Equal(t, "column", validationErr.ValidationErrors[0].Field)
+	assert.Equal(t, "operator", validationErr.ValidationErrors[1].Field)
+
+	users, _, err = strict.List(ctx, SelectBy("name", "=", "Alice"), SelectColumnIn("id", []string{}), SelectOrderAsc("name"))
+	require.NoError(t, err, "valid criteria and empty inputs are not errors")
+	assert.Len(t, users, 1)
+
+	users, _, err = lenient.List(ctx, invalid...)
+	require.NoError(t, err, "strict mode is per repository")
+	assert.Empty(t, users)
+}
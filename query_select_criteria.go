@@ -5,6 +5,7 @@ import (
 	"strings"
 	"time"
 
+	"github.com/goliatone/go-errors"
 	"github.com/uptrace/bun"
 )
 
@@ -60,7 +61,11 @@ func SelectColumnCompare(col1, operator, col2 string) SelectCriteria {
 		right, rightOK := normalizeSQLIdentifier(col2)
 		op, opOK := normalizeComparisonOperator(operator)
 		if !leftOK || !rightOK || !opOK {
-			return sq.Where("1=0")
+			return rejectSelect(sq,
+				criteriaInput{"column", col1, leftOK},
+				criteriaInput{"operator", operator, opOK},
+				criteriaInput{"column", col2, rightOK},
+			)
 		}
 		return sq.Where(fmt.Sprintf("?TableAlias.%s %s ?TableAlias.%s", left, op, right))
 	}
@@ -108,14 +113,13 @@ func ExcludeColumns(columns ...string) SelectCriteria {
 // SelectBy will select by the given column where: column operator value
 // id = 23 or id <= 23
 func SelectBy(column, operator, value string) SelectCriteria {
-	return func(q *bun.SelectQuery) *bun.SelectQuery {
-		col, colOK := normalizeSQLIdentifier(column)
-		op, opOK := normalizeComparisonOperator(operator)
-		if !colOK || !opOK {
-			return q.Where("1=0")
-		}
-		return q.Where(fmt.Sprintf("?TableAlias.%s %s ?", col, op), value)
-	}
+	return SelectByValue(column, operator, value)
+}
+
+// SelectByValue is SelectBy binding value as is, so ints, bools, times and
+// UUIDs reach the driver with their native type instead of as strings.
+func SelectByValue[T any](column, operator string, value T) SelectCriteria {
+	return requireFilterable(column, selectCompare(column, operator, value))
 }
 
 // SelectByTimetz will take a time value and format for postgres
@@ -124,7 +128,7 @@ func SelectByTimetz(column, operator string, value time.Time) SelectCriteria {
 		col, colOK := normalizeSQLIdentifier(column)
 		op, opOK := normalizeComparisonOperator(operator)
 		if !colOK || !opOK {
-			return q.Where("1=0")
+			return rejectSelect(q, criteriaInput{"column", column, colOK}, criteriaInput{"operator", operator, opOK})
 		}
 		ts := value.Format(time.RFC3339)
 		return q.Where(fmt.Sprintf("?TableAlias.%s %s ?", col, op), ts)
@@ -140,7 +144,7 @@ func SelectMaybeByTimetz(column, operator string, value *time.Time) SelectCriter
 		col, colOK := normalizeSQLIdentifier(column)
 		op, opOK := normalizeComparisonOperator(operator)
 		if !colOK || !opOK {
-			return q.Where("1=0")
+			return rejectSelect(q, criteriaInput{"column", column, colOK}, criteriaInput{"operator", operator, opOK})
 		}
 		ts := value.Format(time.RFC3339)
 		return q.Where(fmt.Sprintf("?TableAlias.%s %s ?", col, op), ts)
@@ -149,25 +153,137 @@ func SelectMaybeByTimetz(column, operator string, value *time.Time) SelectCriter
 
 // SelectOrBy OR selector
 func SelectOrBy(column, operator, value string) SelectCriteria {
-	return func(q *bun.SelectQuery) *bun.SelectQuery {
+	return requireFilterable(column, func(q *bun.SelectQuery) *bun.SelectQuery {
 		col, colOK := normalizeSQLIdentifier(column)
 		op, opOK := normalizeComparisonOperator(operator)
 		if !colOK || !opOK {
-			return q
+			return skipSelect(q, criteriaInput{"column", column, colOK}, criteriaInput{"operator", operator, opOK})
 		}
 		return q.WhereOr(fmt.Sprintf("?TableAlias.%s %s ?", col, op), value)
+	})
+}
+
+// SelectAnd groups criteria as (c1) AND (c2) ..., each in its own
+// parentheses, so an OR inside one criterion stays within it. The group is
+// ANDed with the other criteria of the query.
+func SelectAnd(criteria ...SelectCriteria) SelectCriteria {
+	return selectGroup(" AND ", criteria)
+}
+
+// SelectOr groups criteria as (c1) OR (c2) ..., ANDed with the other criteria
+// of the query:
+//
+//	repo.List(ctx,
+//		SelectBy("tenant_id", "=", tenant),
+//		SelectOr(SelectBy("status", "=", "draft"), SelectAnd(
+//			SelectBy("status", "=", "published"),
+//			SelectBy("author_id", "=", userID),
+//		)),
+//	)
+//
+// selects WHERE tenant_id = ? AND (status = 'draft' OR (status = 'published'
+// AND author_id = ?)), where chaining SelectBy and SelectOrBy would OR the
+// tenant condition too.
+func SelectOr(criteria ...SelectCriteria) SelectCriteria {
+	return selectGroup(" OR ", criteria)
+}
+
+// SelectNot negates the conditions of criteria as one group.
+func SelectNot(criteria SelectCriteria) SelectCriteria {
+	return func(q *bun.SelectQuery) *bun.SelectQuery {
+		if criteria == nil {
+			return q
+		}
+		return q.WhereGroup(" AND ", func(q *bun.SelectQuery) *bun.SelectQuery {
+			// bun drops the separator of the first condition in a group, so the
+			// negated group needs a condition in front of it
+			return q.Where("1=1").WhereGroup(" AND NOT ", criteria)
+		})
+	}
+}
+
+func selectGroup(sep string, criteria []SelectCriteria) SelectCriteria {
+	return func(q *bun.SelectQuery) *bun.SelectQuery {
+		return q.WhereGroup(" AND ", func(q *bun.SelectQuery) *bun.SelectQuery {
+			for _, criterion := range criteria {
+				if criterion != nil {
+					q = q.WhereGroup(sep, criterion)
+				}
+			}
+			return q
+		})
 	}
 }
 
 // OrderBy expression
 func OrderBy(expression ...string) SelectCriteria {
 	return func(q *bun.SelectQuery) *bun.SelectQuery {
-		var safe []string
+		var (
+			safe    []string
+			invalid []criteriaInput
+		)
 		for _, expr := range expression {
 			if normalized, ok := normalizeOrderExpr(expr); ok {
 				safe = append(safe, normalized)
+			} else {
+				invalid = append(invalid, criteriaInput{"order", expr, false})
+			}
+		}
+		if len(invalid) > 0 && strictCriteria(q) {
+			return q.Err(invalidCriteriaError(invalid))
+		}
+		if len(safe) == 0 {
+			return q
+		}
+		return q.Order(safe...)
+	}
+}
+
+// OrderBySafe orders by expressions taken from untrusted input, e.g. a sort
+// query parameter. Each expression, or comma separated list of them, must be
+// a column optionally followed by a direction ("created_at DESC", "name ASC
+// NULLS LAST"). Where OrderBy drops invalid expressions, OrderBySafe fails the
+// query with a validation error naming them, so handlers can answer 400. So
+// it does for columns outside the model WithSortableColumns allowlist, and
+// with a CategoryCriteriaInvalid error for columns the model does not have.
+func OrderBySafe(expression ...string) SelectCriteria {
+	return func(q *bun.SelectQuery) *bun.SelectQuery {
+		var (
+			safe    []string
+			invalid errors.ValidationErrors
+		)
+		policy := queryCriteriaPolicy(q)
+		for _, expr := range expression {
+			for _, part := range strings.Split(expr, ",") {
+				if strings.TrimSpace(part) == "" {
+					continue
+				}
+				normalized, ok := normalizeOrderExpr(part)
+				if !ok {
+					invalid = append(invalid, errors.FieldError{
+						Field:   "order",
+						Message: fmt.Sprintf("invalid order expression %q", strings.TrimSpace(part)),
+						Value:   part,
+					})
+					continue
+				}
+				if policy != nil && !policyAllows(policy.sortable, sortColumn(normalized)) {
+					invalid = append(invalid, errors.FieldError{
+						Field:   "order",
+						Message: fmt.Sprintf("column %q is not sortable", sortColumn(normalized)),
+						Value:   part,
+					})
+					continue
+				}
+				if err := unknownColumnError(q, sortColumn(normalized)); err != nil {
+					return q.Err(err)
+				}
+				safe = append(safe, normalized)
 			}
 		}
+		if len(invalid) > 0 {
+			return q.Err(errors.NewValidation("repository: invalid order expression", invalid...))
+		}
 		if len(safe) == 0 {
 			return q
 		}
@@ -180,7 +296,7 @@ func SelectIsNull(column string) SelectCriteria {
 	return func(q *bun.SelectQuery) *bun.SelectQuery {
 		col, ok := normalizeSQLIdentifier(column)
 		if !ok {
-			return q.Where("1=0")
+			return rejectSelect(q, criteriaInput{"column", column, ok})
 		}
 		return q.Where(fmt.Sprintf("?TableAlias.%s IS NULL", col))
 	}
@@ -191,7 +307,7 @@ func SelectOrIsNull(column string) SelectCriteria {
 	return func(q *bun.SelectQuery) *bun.SelectQuery {
 		col, ok := normalizeSQLIdentifier(column)
 		if !ok {
-			return q
+			return skipSelect(q, criteriaInput{"column", column, ok})
 		}
 		return q.WhereOr(fmt.Sprintf("?TableAlias.%s IS NULL", col))
 	}
@@ -202,7 +318,7 @@ func SelectNotNull(column string) SelectCriteria {
 	return func(q *bun.SelectQuery) *bun.SelectQuery {
 		col, ok := normalizeSQLIdentifier(column)
 		if !ok {
-			return q.Where("1=0")
+			return rejectSelect(q, criteriaInput{"column", column, ok})
 		}
 		return q.Where(fmt.Sprintf("?TableAlias.%s IS NOT NULL", col))
 	}
@@ -213,7 +329,7 @@ func SelectOrNotNull(column string) SelectCriteria {
 	return func(q *bun.SelectQuery) *bun.SelectQuery {
 		col, ok := normalizeSQLIdentifier(column)
 		if !ok {
-			return q
+			return skipSelect(q, criteriaInput{"column", column, ok})
 		}
 		return q.WhereOr(fmt.Sprintf("?TableAlias.%s IS NOT NULL", col))
 	}
@@ -221,21 +337,17 @@ func SelectOrNotNull(column string) SelectCriteria {
 
 // SelectByID using ID
 func SelectByID(id string) SelectCriteria {
-	return SelectBy("id", "=", id)
+	return selectCompare("id", "=", id)
 }
 
 // SelectDeletedOnly will include deleted only
 func SelectDeletedOnly() SelectCriteria {
-	return func(q *bun.SelectQuery) *bun.SelectQuery {
-		return q.WhereDeleted()
-	}
+	return selectDeletedOnly
 }
 
 // SelectDeletedAlso will include deleted and non deleted
 func SelectDeletedAlso() SelectCriteria {
-	return func(q *bun.SelectQuery) *bun.SelectQuery {
-		return q.WhereAllWithDeleted()
-	}
+	return selectDeletedAlso
 }
 
 // SelectOrderDesc sort by column
@@ -243,7 +355,7 @@ func SelectOrderDesc(column string) SelectCriteria {
 	return func(q *bun.SelectQuery) *bun.SelectQuery {
 		col, ok := normalizeSQLIdentifier(column)
 		if !ok {
-			return q
+			return skipSelect(q, criteriaInput{"column", column, ok})
 		}
 		return q.Order(fmt.Sprintf("%s %s", col, "DESC"))
 	}
@@ -254,7 +366,7 @@ func SelectOrderAsc(column string) SelectCriteria {
 	return func(q *bun.SelectQuery) *bun.SelectQuery {
 		col, ok := normalizeSQLIdentifier(column)
 		if !ok {
-			return q
+			return skipSelect(q, criteriaInput{"column", column, ok})
 		}
 		return q.Order(fmt.Sprintf("%s %s", col, "ASC"))
 	}
@@ -269,7 +381,7 @@ func SelectColumnIn[T any](column string, slice []T) SelectCriteria {
 		}
 		col, ok := normalizeSQLIdentifier(column)
 		if !ok {
-			return q.Where("1=0")
+			return rejectSelect(q, criteriaInput{"column", column, ok})
 		}
 		return q.Where(fmt.Sprintf("?TableAlias.%s IN (?)", col), bun.In(slice))
 	}
@@ -284,7 +396,7 @@ func SelectColumnNotIn[T any](column string, slice []T) SelectCriteria {
 		}
 		col, ok := normalizeSQLIdentifier(column)
 		if !ok {
-			return q.Where("1=0")
+			return rejectSelect(q, criteriaInput{"column", column, ok})
 		}
 		return q.Where(fmt.Sprintf("?TableAlias.%s NOT IN (?)", col), bun.In(slice))
 	}
@@ -296,7 +408,7 @@ func SelectColumnInSubq(column string, query string, args ...any) SelectCriteria
 		col, colOK := normalizeSQLIdentifier(column)
 		subq, subqOK := normalizeSubquery(query)
 		if !colOK || !subqOK {
-			return q.Where("1=0")
+			return rejectSelect(q, criteriaInput{"column", column, colOK}, criteriaInput{"subquery", query, subqOK})
 		}
 		return q.Where(fmt.Sprintf("?TableAlias.%s IN (?)", col), bun.SafeQuery(subq, args...))
 	}
@@ -315,7 +427,7 @@ func SelectColumnNotInSubq(column string, query string, args ...any) SelectCrite
 	return func(q *bun.SelectQuery) *bun.SelectQuery {
 		col, colOK := normalizeSQLIdentifier(column)
 		if !colOK || !queryOK {
-			return q.Where("1=0")
+			return rejectSelect(q, criteriaInput{"column", column, colOK}, criteriaInput{"subquery", query, queryOK})
 		}
 		return q.Where(fmt.Sprintf("?TableAlias.%s NOT IN (?)", col), bun.SafeQuery(trimmed, args...))
 	}
@@ -327,11 +439,9 @@ func SelectDistinct(columns ...string) SelectCriteria {
 		if len(columns) == 0 {
 			return sq.Distinct()
 		}
-		safe := make([]string, 0, len(columns))
-		for _, column := range columns {
-			if normalized, ok := normalizeSQLIdentifier(column); ok {
-				safe = append(safe, normalized)
-			}
+		safe, invalid := normalizeSQLIdentifiers(columns)
+		if len(invalid) > 0 && strictCriteria(sq) {
+			return sq.Err(invalidCriteriaError(invalid))
 		}
 		if len(safe) == 0 {
 			return sq.Distinct()
@@ -342,11 +452,9 @@ func SelectDistinct(columns ...string) SelectCriteria {
 
 func SelectGroupBy(columns ...string) SelectCriteria {
 	return func(sq *bun.SelectQuery) *bun.SelectQuery {
-		safe := make([]string, 0, len(columns))
-		for _, column := range columns {
-			if normalized, ok := normalizeSQLIdentifier(column); ok {
-				safe = append(safe, normalized)
-			}
+		safe, invalid := normalizeSQLIdentifiers(columns)
+		if len(invalid) > 0 && strictCriteria(sq) {
+			return sq.Err(invalidCriteriaError(invalid))
 		}
 		if len(safe) == 0 {
 			return sq
@@ -365,7 +473,7 @@ func SelectBetween(column string, start, end any) SelectCriteria {
 	return func(sq *bun.SelectQuery) *bun.SelectQuery {
 		col, ok := normalizeSQLIdentifier(column)
 		if !ok {
-			return sq.Where("1=0")
+			return rejectSelect(sq, criteriaInput{"column", column, ok})
 		}
 		return sq.Where(fmt.Sprintf("?TableAlias.%s BETWEEN ? AND ?", col), start, end)
 	}
@@ -375,29 +483,83 @@ func SelectTimeRange(column string, start, end time.Time) SelectCriteria {
 	return func(sq *bun.SelectQuery) *bun.SelectQuery {
 		col, ok := normalizeSQLIdentifier(column)
 		if !ok {
-			return sq.Where("1=0")
+			return rejectSelect(sq, criteriaInput{"column", column, ok})
 		}
 		return sq.Where(fmt.Sprintf("?TableAlias.%s >= ? AND ?TableAlias.%s <= ?", col, col), start, end)
 	}
 }
 
+// SelectDateEquals matches column values on the calendar day of date, in the
+// location of date, as a half open range that can use an index on column.
+func SelectDateEquals(column string, date time.Time) SelectCriteria {
+	return func(sq *bun.SelectQuery) *bun.SelectQuery {
+		col, ok := normalizeSQLIdentifier(column)
+		if !ok {
+			return rejectSelect(sq, criteriaInput{"column", column, ok})
+		}
+		start := time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, date.Location())
+		return sq.Where(fmt.Sprintf("?TableAlias.%s >= ? AND ?TableAlias.%s < ?", col, col), start, start.AddDate(0, 0, 1))
+	}
+}
+
+// SelectInLastDuration matches column values within d of the time the query
+// is built, e.g. SelectInLastDuration("created_at", 24*time.Hour).
+func SelectInLastDuration(column string, d time.Duration) SelectCriteria {
+	return func(sq *bun.SelectQuery) *bun.SelectQuery {
+		col, ok := normalizeSQLIdentifier(column)
+		if !ok {
+			return rejectSelect(sq, criteriaInput{"column", column, ok})
+		}
+		return sq.Where(fmt.Sprintf("?TableAlias.%s >= ?", col), time.Now().Add(-d))
+	}
+}
+
+// GroupByDateTrunc selects column truncated to interval as
+// <column>_<interval>, e.g. created_at_day, and groups by it. Values are
+// labeled like CountByTimeBucket keys: ISO-8601 timestamps without zone
+// marking the start of each bucket. An invalid column is skipped, or fails
+// the query in strict mode. Postgres, SQLite and MySQL are supported; other
+// dialects fail the query with a validation error.
+func GroupByDateTrunc(column string, interval TimeBucket) SelectCriteria {
+	return func(sq *bun.SelectQuery) *bun.SelectQuery {
+		col, ok := normalizeSQLIdentifier(column)
+		if !ok {
+			return skipSelect(sq, criteriaInput{"column", column, ok})
+		}
+		name := sq.Dialect().Name()
+		expr, ok := timeBucketLabelExpr(name, "?TableAlias."+col, interval)
+		if !ok {
+			return sq.Err(errors.NewValidation(
+				"repository: unsupported time bucket",
+				errors.FieldError{
+					Field:   "interval",
+					Message: fmt.Sprintf("bucket %q is not supported for dialect %s", interval, name),
+				},
+			))
+		}
+		return sq.ColumnExpr(fmt.Sprintf("%s AS %s_%s", expr, col, interval)).GroupExpr(expr)
+	}
+}
+
 func SelectILike(column, pattern string) SelectCriteria {
 	return func(sq *bun.SelectQuery) *bun.SelectQuery {
 		col, ok := normalizeSQLIdentifier(column)
 		if !ok {
-			return sq.Where("1=0")
+			return rejectSelect(sq, criteriaInput{"column", column, ok})
 		}
 		return sq.Where(fmt.Sprintf("?TableAlias.%s ILIKE ?", col), pattern)
 	}
 }
 
+// SelectJSONContains matches rows whose JSON column contains jsonVal.
+// It renders @> on Postgres, JSON_CONTAINS on MySQL and an equivalent
+// json_extract/json_each predicate on SQLite.
 func SelectJSONContains(column string, jsonVal any) SelectCriteria {
 	return func(sq *bun.SelectQuery) *bun.SelectQuery {
 		col, ok := normalizeSQLIdentifier(column)
 		if !ok {
-			return sq.Where("1=0")
+			return rejectSelect(sq, criteriaInput{"column", column, ok})
 		}
-		// TODO: This is Postgres specific, how to generalize?
-		return sq.Where(fmt.Sprintf("?TableAlias.%s @> ?", col), jsonVal)
+		return jsonContainsCriteria(sq, col, jsonVal)
 	}
 }
@@ -1,13 +1,27 @@
 package repository
 
 import (
+	"context"
+	"fmt"
 	"strings"
 	"testing"
+	"time"
 
+	goerrors "github.com/goliatone/go-errors"
 	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/uptrace/bun"
 )
 
+type valueTestMember struct {
+	bun.BaseModel `bun:"table:value_test_members,alias:u"`
+
+	ID     int64 `bun:"id,pk,autoincrement"`
+	Age    int   `bun:"age"`
+	Active bool  `bun:"active"`
+}
+
 func TestSelectSubquery_DefaultAlias(t *testing.T) {
 	setupTestData(t)
 
@@ -73,3 +87,141 @@ func TestSelectColumnInSubq_InvalidSubqueryFailsClosed(t *testing.T) {
 	sql := query.String()
 	assert.True(t, strings.Contains(sql, "1=0") || strings.Contains(sql, "1 = 0"))
 }
+
+func TestSelectCriteriaGroups(t *testing.T) {
+	setupTestData(t)
+
+	query := db.NewSelect().
+		Model((*TestUser)(nil)).
+		Apply(
+			SelectBy("company_id", "=", "c1"),
+			SelectOr(
+				SelectBy("name", "=", "a"),
+				SelectAnd(SelectBy("name", "=", "b"), SelectOrBy("email", "=", "e")),
+			),
+			SelectNot(SelectBy("email", "=", "x")),
+		)
+
+	assert.Contains(t, query.String(), `WHERE ("u".company_id = 'c1') AND `+
+		`((("u".name = 'a')) OR (((("u".name = 'b')) AND (("u".email = 'e'))))) AND `+
+		`((1=1) AND NOT (("u".email = 'x')))`, "each grouped criterion is ANDed within SelectAnd")
+}
+
+func TestSelectCriteriaGroups_Results(t *testing.T) {
+	setupTestData(t)
+	ctx := context.Background()
+	repo := newTestUserRepository(db)
+
+	first, err := repo.Create(ctx, &TestUser{Name: "Alice", Email: "alice@example.com", CompanyID: uuid.New()})
+	require.NoError(t, err)
+	second, err := repo.Create(ctx, &TestUser{Name: "Bob", Email: "bob@example.com", CompanyID: uuid.New()})
+	require.NoError(t, err)
+
+	users, _, err := repo.List(ctx, SelectOr(
+		SelectBy("email", "=", first.Email),
+		SelectBy("email", "=", second.Email),
+	), SelectNot(SelectBy("id", "=", second.ID.String())))
+	require.NoError(t, err)
+	require.Len(t, users, 1)
+	assert.Equal(t, first.ID, users[0].ID)
+
+	_, total, err := repo.List(ctx)
+	require.NoError(t, err)
+	_, count, err := repo.List(ctx, SelectAnd(), SelectOr(), SelectNot(nil))
+	require.NoError(t, err)
+	assert.Equal(t, total, count, "empty groups add no condition")
+}
+
+func TestSelectByValue_BindsNativeTypes(t *testing.T) {
+	setupTestData(t)
+
+	query := db.NewSelect().
+		Model((*valueTestMember)(nil)).
+		Apply(SelectByValue("age", ">=", 30), SelectByValue("active", "=", true))
+	assert.Contains(t, query.String(), `("u".age >= 30) AND ("u".active = TRUE)`)
+
+	query = db.NewSelect().Model((*TestUser)(nil)).Apply(SelectByValue("age;--", "=", 1))
+	assert.Contains(t, query.String(), "1=0")
+
+	ctx := context.Background()
+	repo := newTestUserRepository(db)
+	created := time.Now().Add(-time.Hour)
+	user, err := repo.Create(ctx, &TestUser{Name: "Alice", Email: "alice@example.com", CompanyID: uuid.New(), CreatedAt: created})
+	require.NoError(t, err)
+
+	users, _, err := repo.List(ctx,
+		SelectByValue("company_id", "=", user.CompanyID),
+		SelectByValue("created_at", "<", time.Now()),
+	)
+	require.NoError(t, err)
+	require.Len(t, users, 1)
+	assert.Equal(t, user.ID, users[0].ID)
+}
+
+func TestSelectDateHelpers(t *testing.T) {
+	setupTestData(t)
+	ctx := context.Background()
+	repo := newTestUserRepository(db)
+
+	now := time.Now().UTC()
+	today := time.Date(now.Year(), now.Month(), now.Day(), 12, 0, 0, 0, time.UTC)
+	for i, at := range []time.Time{today, today.Add(-2 * time.Hour), today.AddDate(0, 0, -1), today.AddDate(0, 0, -10)} {
+		_, err := repo.Create(ctx, &TestUser{
+			Name: "user", Email: fmt.Sprintf("user%d@example.com", i), CompanyID: uuid.New(), CreatedAt: at,
+		})
+		require.NoError(t, err)
+	}
+
+	count, err := repo.Count(ctx, SelectDateEquals("created_at", today.Add(9*time.Hour)))
+	require.NoError(t, err)
+	assert.Equal(t, 2, count)
+	count, err = repo.Count(ctx, SelectDateEquals("created_at", today.AddDate(0, 0, -1)))
+	require.NoError(t, err)
+	assert.Equal(t, 1, count)
+
+	count, err = repo.Count(ctx, SelectInLastDuration("created_at", 72*time.Hour))
+	require.NoError(t, err)
+	assert.Equal(t, 3, count)
+
+	var buckets []struct {
+		Day   string `bun:"created_at_day"`
+		Count int    `bun:"total"`
+	}
+	err = db.NewSelect().Model((*TestUser)(nil)).
+		Apply(GroupByDateTrunc("created_at", TimeBucketDay), SelectColumnExpr("COUNT(*) AS total"), OrderBy("created_at_day ASC")).
+		Scan(ctx, &buckets)
+	require.NoError(t, err)
+	require.Len(t, buckets, 3)
+	assert.Equal(t, today.Format("2006-01-02T00:00:00"), buckets[2].Day)
+	assert.Equal(t, 2, buckets[2].Count)
+
+	unsupported := db.NewSelect().Model((*TestUser)(nil)).Apply(GroupByDateTrunc("created_at", "fortnight"))
+	assert.Error(t, unsupported.Scan(ctx, &buckets))
+	assert.NotContains(t, db.NewSelect().Model((*TestUser)(nil)).Apply(GroupByDateTrunc("bad;", TimeBucketDay)).String(), "GROUP BY")
+	assert.Contains(t, db.NewSelect().Model((*TestUser)(nil)).Apply(SelectDateEquals("bad;", today)).String(), "1=0")
+}
+
+func TestOrderBySafe(t *testing.T) {
+	setupTestData(t)
+	ctx := context.Background()
+	repo := newTestUserRepository(db)
+
+	for _, name := range []string{"Bob", "Alice"} {
+		_, err := repo.Create(ctx, &TestUser{Name: name, Email: name + "@example.com", CompanyID: uuid.New()})
+		require.NoError(t, err)
+	}
+
+	users, _, err := repo.List(ctx, OrderBySafe("name asc, email DESC"))
+	require.NoError(t, err)
+	require.Len(t, users, 2)
+	assert.Equal(t, "Alice", users[0].Name)
+
+	query := db.NewSelect().Model((*TestUser)(nil)).Apply(OrderBySafe(" ", "name DESC NULLS LAST"))
+	assert.Contains(t, query.String(), `ORDER BY "name" DESC NULLS LAST`)
+
+	for _, sort := range []string{"name; DROP TABLE test_users", "(SELECT 1)", "name DESC, id SIDEWAYS"} {
+		_, _, err = repo.List(ctx, OrderBySafe(sort))
+		require.Error(t, err, sort)
+		assert.True(t, goerrors.IsValidation(err), sort)
+	}
+}
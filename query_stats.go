@@ -0,0 +1,58 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/goliatone/go-repository-bun/repositoryctx"
+	"github.com/uptrace/bun"
+)
+
+// QueryStats sums the queries run with a context prepared by
+// WithStatsRecorder: how many, their cumulative duration and the rows
+// SELECT queries scanned.
+type QueryStats = repositoryctx.QueryStats
+
+// WithStatsRecorder returns a context collecting the QueryStats of every
+// query run with it through a repository bun.DB, e.g. for the lifetime of a
+// request, to spot N+1 patterns or enforce a query budget:
+//
+//	ctx := repository.WithStatsRecorder(r.Context())
+//	next.ServeHTTP(w, r.WithContext(ctx))
+//	if stats, _ := repository.StatsFromContext(ctx); stats.Queries > 50 {
+//		log.Printf("%s ran %d queries in %s", r.URL.Path, stats.Queries, stats.Duration)
+//	}
+func WithStatsRecorder(ctx context.Context) context.Context {
+	return repositoryctx.WithStatsRecorder(ctx)
+}
+
+// StatsFromContext returns the stats recorded so far on ctx, prepared with
+// WithStatsRecorder.
+func StatsFromContext(ctx context.Context) (QueryStats, bool) {
+	return repositoryctx.Stats(ctx)
+}
+
+// queryStatsHook feeds the stats recorder of the query context. Repositories
+// register it on their bun.DB; without a recorder it only costs a context
+// lookup per query.
+type queryStatsHook struct{}
+
+func (queryStatsHook) QueryHookKey() string {
+	return "query-stats"
+}
+
+func (queryStatsHook) BeforeQuery(ctx context.Context, _ *bun.QueryEvent) context.Context {
+	return ctx
+}
+
+func (queryStatsHook) AfterQuery(ctx context.Context, event *bun.QueryEvent) {
+	if _, ok := repositoryctx.Stats(ctx); !ok {
+		return
+	}
+
+	var rows int64
+	if event.Result != nil && event.Operation() == "SELECT" {
+		rows, _ = event.Result.RowsAffected()
+	}
+	repositoryctx.RecordQuery(ctx, time.Since(event.StartTime), rows)
+}
@@ -0,0 +1,49 @@
+package repository
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/uptrace/bun/dialect/sqlitedialect"
+)
+
+func TestStatsRecorder(t *testing.T) {
+	ctx := context.Background()
+	testDB := newDialectTestDB(t, sqlitedialect.New())
+	_, err := testDB.NewCreateTable().Model((*TestUser)(nil)).Exec(ctx)
+	require.NoError(t, err)
+	repo := newTestUserRepository(testDB)
+
+	_, err = repo.Create(ctx, &TestUser{Name: "Alice", Email: "alice@example.com"})
+	require.NoError(t, err)
+	_, ok := StatsFromContext(ctx)
+	assert.False(t, ok)
+
+	request := WithStatsRecorder(ctx)
+	_, err = repo.CreateMany(request, []*TestUser{
+		{Name: "Bob", Email: "bob@example.com"},
+		{Name: "Carol", Email: "carol@example.com"},
+	})
+	require.NoError(t, err)
+
+	stats, ok := StatsFromContext(request)
+	require.True(t, ok)
+	assert.Equal(t, 1, stats.Queries)
+	assert.Zero(t, stats.RowsScanned, "inserted rows are not scanned")
+
+	nested := WithStatsRecorder(request)
+	users, _, err := repo.List(nested)
+	require.NoError(t, err)
+	require.Len(t, users, 3)
+
+	stats, _ = StatsFromContext(nested)
+	assert.Equal(t, 2, stats.Queries, "List selects the page and counts")
+	assert.EqualValues(t, 3, stats.RowsScanned)
+	assert.Positive(t, stats.Duration)
+
+	stats, _ = StatsFromContext(request)
+	assert.Equal(t, 3, stats.Queries, "the outer recorder sees nested queries")
+	assert.EqualValues(t, 3, stats.RowsScanned)
+}
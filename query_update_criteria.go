@@ -21,11 +21,19 @@ func UpdateByPK(cols ...string) UpdateCriteria {
 // UpdateBy will select by the given column where: column operator value
 // id = 23 or id <= 23
 func UpdateBy(column, operator, value string) UpdateCriteria {
+	return UpdateByValue(column, operator, value)
+}
+
+// UpdateByValue is UpdateBy binding value with its native type.
+func UpdateByValue[T any](column, operator string, value T) UpdateCriteria {
 	return func(q *bun.UpdateQuery) *bun.UpdateQuery {
 		col, colOK := normalizeSQLIdentifier(column)
 		op, opOK := normalizeComparisonOperator(operator)
 		if !colOK || !opOK {
-			return q.Where("1=0")
+			return rejectUpdate(q, criteriaInput{"column", column, colOK}, criteriaInput{"operator", operator, opOK})
+		}
+		if err := unknownColumnError(q, col); err != nil {
+			return q.Err(err)
 		}
 		return q.Where(fmt.Sprintf("?TableAlias.%s %s ?", col, op), value)
 	}
@@ -37,7 +45,10 @@ func UpdateByTimetz(column, operator string, value time.Time) UpdateCriteria {
 		col, colOK := normalizeSQLIdentifier(column)
 		op, opOK := normalizeComparisonOperator(operator)
 		if !colOK || !opOK {
-			return q.Where("1=0")
+			return rejectUpdate(q, criteriaInput{"column", column, colOK}, criteriaInput{"operator", operator, opOK})
+		}
+		if err := unknownColumnError(q, col); err != nil {
+			return q.Err(err)
 		}
 		ts := value.Format(time.RFC3339)
 		return q.Where(fmt.Sprintf("?TableAlias.%s %s ?", col, op), ts)
@@ -53,7 +64,10 @@ func UpdateMaybeByTimetz(column, operator string, value *time.Time) UpdateCriter
 		col, colOK := normalizeSQLIdentifier(column)
 		op, opOK := normalizeComparisonOperator(operator)
 		if !colOK || !opOK {
-			return q.Where("1=0")
+			return rejectUpdate(q, criteriaInput{"column", column, colOK}, criteriaInput{"operator", operator, opOK})
+		}
+		if err := unknownColumnError(q, col); err != nil {
+			return q.Err(err)
 		}
 		ts := value.Format(time.RFC3339)
 		return q.Where(fmt.Sprintf("?TableAlias.%s %s ?", col, op), ts)
@@ -66,7 +80,10 @@ func UpdateOrBy(column, operator, value string) UpdateCriteria {
 		col, colOK := normalizeSQLIdentifier(column)
 		op, opOK := normalizeComparisonOperator(operator)
 		if !colOK || !opOK {
-			return q
+			return skipUpdate(q, criteriaInput{"column", column, colOK}, criteriaInput{"operator", operator, opOK})
+		}
+		if err := unknownColumnError(q, col); err != nil {
+			return q.Err(err)
 		}
 		return q.WhereOr(fmt.Sprintf("?TableAlias.%s %s ?", col, op), value)
 	}
@@ -77,7 +94,10 @@ func UpdateOrIsNull(column string) UpdateCriteria {
 	return func(q *bun.UpdateQuery) *bun.UpdateQuery {
 		col, ok := normalizeSQLIdentifier(column)
 		if !ok {
-			return q
+			return skipUpdate(q, criteriaInput{"column", column, ok})
+		}
+		if err := unknownColumnError(q, col); err != nil {
+			return q.Err(err)
 		}
 		return q.WhereOr(fmt.Sprintf("?TableAlias.%s IS NULL", col))
 	}
@@ -90,16 +110,12 @@ func UpdateByID(id string) UpdateCriteria {
 
 // UpdateDeletedOnly will include deleted only
 func UpdateDeletedOnly() UpdateCriteria {
-	return func(q *bun.UpdateQuery) *bun.UpdateQuery {
-		return q.WhereDeleted()
-	}
+	return updateDeletedOnly
 }
 
 // UpdateDeletedAlso will include deleted and non deleted
 func UpdateDeletedAlso() UpdateCriteria {
-	return func(q *bun.UpdateQuery) *bun.UpdateQuery {
-		return q.WhereAllWithDeleted()
-	}
+	return updateDeletedAlso
 }
 
 // UpdateColumns will select columns
@@ -119,7 +135,14 @@ func UpdateExcludeColumns(columns ...string) UpdateCriteria {
 // UpdateSetColumn will set the column to be updated
 func UpdateSetColumn(col string, val any) UpdateCriteria {
 	return func(q *bun.UpdateQuery) *bun.UpdateQuery {
-		return q.SetColumn(col, "?", val)
+		column, ok := normalizeSQLIdentifier(col)
+		if !ok {
+			return rejectUpdate(q, criteriaInput{"column", col, ok})
+		}
+		if err := unknownColumnError(q, column); err != nil {
+			return q.Err(err)
+		}
+		return q.SetColumn(column, "?", val)
 	}
 }
 
@@ -17,3 +17,17 @@ func TestUpdateBy_InvalidColumnFailsClosed(t *testing.T) {
 		t.Fatalf("expected fail-closed predicate, got SQL: %s", sql)
 	}
 }
+
+func TestUpdateByValue_BindsNativeTypes(t *testing.T) {
+	setupTestData(t)
+
+	query := db.NewUpdate().
+		Model(&valueTestMember{}).
+		Set("active = ?", false).
+		Apply(UpdateByValue("age", "<", 18))
+
+	sql := query.String()
+	if !strings.Contains(sql, `"u".age < 18`) {
+		t.Fatalf("expected unquoted int, got SQL: %s", sql)
+	}
+}
@@ -0,0 +1,160 @@
+package repository
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/goliatone/go-errors"
+	"github.com/uptrace/bun"
+)
+
+// ReadOnlyColumnsProvider is implemented by repositories configured with
+// WithReadOnlyColumns.
+type ReadOnlyColumnsProvider interface {
+	ReadOnlyColumns() []string
+}
+
+// WithReadOnlyColumns protects system managed columns, e.g. "created_at" or
+// "tenant_id": Update, UpdateMany and Upsert never write them, even when
+// listed in UpdateColumns, and UpdateByIDWithMapPatch treats them like
+// read-only fields (see WithPatchReadOnlyColumns). Inserts still set them.
+// Unknown columns are reported by Validate.
+func WithReadOnlyColumns(columns ...string) RepoOption {
+	return func(cfg *repoConfig) {
+		if cfg == nil {
+			return
+		}
+		for _, column := range columns {
+			if column = strings.TrimSpace(column); column != "" && !containsString(cfg.readOnlyColumns, column) {
+				cfg.readOnlyColumns = append(cfg.readOnlyColumns, column)
+			}
+		}
+	}
+}
+
+// ReadOnlyColumns returns the columns configured with WithReadOnlyColumns.
+func (r *repo[T]) ReadOnlyColumns() []string {
+	return copyStrings(r.readOnlyColumns)
+}
+
+// WithPatchReadOnlyColumns treats the given Bun columns as read-only fields,
+// handled according to WithPatchReadOnlyMode (rejected by default).
+func WithPatchReadOnlyColumns(columns ...string) MapPatchOption {
+	return func(cfg *mapPatchConfig) {
+		if cfg.readOnlyColumns == nil {
+			cfg.readOnlyColumns = make(map[string]struct{}, len(columns))
+		}
+		for _, column := range columns {
+			if column = strings.TrimSpace(column); column != "" {
+				cfg.readOnlyColumns[column] = struct{}{}
+			}
+		}
+	}
+}
+
+func (cfg mapPatchConfig) isReadOnlyColumn(column string) bool {
+	_, ok := cfg.readOnlyColumns[column]
+	return ok
+}
+
+// readOnlyPatchOptions returns the patch options protecting the read-only
+// columns of repo, if it has any.
+func readOnlyPatchOptions(repo any) []MapPatchOption {
+	provider, ok := repo.(ReadOnlyColumnsProvider)
+	if !ok {
+		return nil
+	}
+	if columns := provider.ReadOnlyColumns(); len(columns) > 0 {
+		return []MapPatchOption{WithPatchReadOnlyColumns(columns...)}
+	}
+	return nil
+}
+
+// excludeReadOnlyColumns removes the read-only columns from an update of the
+// model. It runs after the criteria, so explicit UpdateColumns lists are
+// filtered as well, and fails when nothing is left to update: bun would
+// otherwise fall back to updating every column.
+func (r *repo[T]) excludeReadOnlyColumns(q *bun.UpdateQuery) error {
+	if len(r.readOnlyColumns) == 0 {
+		return nil
+	}
+
+	selected := queryColumnNames(q)
+	var exclude []string
+	for _, column := range r.readOnlyColumns {
+		if r.modelField(column) == nil {
+			continue
+		}
+		if selected != nil {
+			if _, ok := selected[column]; !ok {
+				continue
+			}
+		}
+		exclude = append(exclude, column)
+	}
+	if len(exclude) == 0 {
+		return nil
+	}
+
+	q.ExcludeColumn(exclude...)
+	if selected != nil && len(queryColumnNames(q)) == 0 {
+		return errors.NewValidation(
+			"repository: update of read-only columns",
+			errors.FieldError{
+				Field:   "criteria",
+				Message: fmt.Sprintf("columns %s are read-only", strings.Join(exclude, ", ")),
+			},
+		)
+	}
+	return nil
+}
+
+// queryColumnNames returns the plain columns selected on q, or nil when the
+// query uses every model column.
+func queryColumnNames(q bun.Query) map[string]struct{} {
+	value := reflect.ValueOf(q)
+	if value.Kind() != reflect.Pointer || value.IsNil() {
+		return nil
+	}
+	list := value.Elem().FieldByName("columns")
+	if !list.IsValid() || list.Kind() != reflect.Slice || list.IsNil() {
+		return nil
+	}
+
+	names := make(map[string]struct{}, list.Len())
+	for i := 0; i < list.Len(); i++ {
+		item := list.Index(i)
+		if !item.FieldByName("Args").IsNil() {
+			continue
+		}
+		names[item.FieldByName("Query").String()] = struct{}{}
+	}
+	return names
+}
+
+func (r *repo[T]) validateReadOnlyColumns() error {
+	if len(r.readOnlyColumns) == 0 {
+		return nil
+	}
+
+	table := r.modelTable()
+	if table == nil {
+		return nil
+	}
+
+	var validationErrors errors.ValidationErrors
+	for _, column := range r.readOnlyColumns {
+		if _, ok := table.FieldMap[column]; !ok {
+			validationErrors = append(validationErrors, errors.FieldError{
+				Field:   "repoOptions.WithReadOnlyColumns",
+				Message: fmt.Sprintf("unknown column %q on %s", column, table.Name),
+			})
+		}
+	}
+
+	if len(validationErrors) > 0 {
+		return errors.NewValidation("repository configuration invalid", validationErrors...)
+	}
+	return nil
+}
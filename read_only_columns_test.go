@@ -0,0 +1,93 @@
+package repository
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRepository_WithReadOnlyColumns_Update(t *testing.T) {
+	setupTestData(t)
+	ctx := context.Background()
+	repo := newTestUserRepositoryWithConfig(db, nil, WithReadOnlyColumns("created_at", "company_id"))
+	require.NoError(t, repo.(Validator).Validate())
+	assert.Equal(t, []string{"created_at", "company_id"}, repo.(ReadOnlyColumnsProvider).ReadOnlyColumns())
+
+	companyID := uuid.New()
+	created, err := repo.Create(ctx, &TestUser{Name: "Alice", Email: "alice@example.com", CompanyID: companyID, CreatedAt: time.Now().Add(-time.Hour)})
+	require.NoError(t, err)
+	original, err := repo.GetByID(ctx, created.ID.String())
+	require.NoError(t, err)
+
+	update := *original
+	update.Name = "Alice Updated"
+	update.CompanyID = uuid.New()
+	update.CreatedAt = time.Now().Add(24 * time.Hour)
+	_, err = repo.Update(ctx, &update)
+	require.NoError(t, err)
+
+	stored, err := repo.GetByID(ctx, created.ID.String())
+	require.NoError(t, err)
+	assert.Equal(t, "Alice Updated", stored.Name)
+	assert.Equal(t, companyID, stored.CompanyID)
+	assert.True(t, original.CreatedAt.Equal(stored.CreatedAt))
+
+	update.Name = "Alice Again"
+	_, err = repo.Update(ctx, &update, UpdateColumns("name", "company_id"))
+	require.NoError(t, err)
+	stored, err = repo.GetByID(ctx, created.ID.String())
+	require.NoError(t, err)
+	assert.Equal(t, "Alice Again", stored.Name)
+	assert.Equal(t, companyID, stored.CompanyID, "explicit read-only columns are dropped")
+
+	_, err = repo.Update(ctx, &update, UpdateColumns("company_id"))
+	require.Error(t, err, "an update left without columns is rejected")
+
+	_, err = repo.UpdateMany(ctx, []*TestUser{&update})
+	require.NoError(t, err)
+	stored, err = repo.GetByID(ctx, created.ID.String())
+	require.NoError(t, err)
+	assert.Equal(t, companyID, stored.CompanyID)
+
+	upsert := &TestUser{Name: "Alice Upserted", Email: "alice@example.com", CompanyID: uuid.New(), CreatedAt: time.Now(), UpdatedAt: time.Now()}
+	_, err = repo.Upsert(ctx, upsert)
+	require.NoError(t, err)
+	stored, err = repo.GetByID(ctx, created.ID.String())
+	require.NoError(t, err)
+	assert.Equal(t, "Alice Upserted", stored.Name)
+	assert.Equal(t, companyID, stored.CompanyID)
+}
+
+func TestRepository_WithReadOnlyColumns_MapPatch(t *testing.T) {
+	setupTestData(t)
+	ctx := context.Background()
+	repo := newTestUserRepositoryWithConfig(db, nil, WithReadOnlyColumns("company_id"))
+
+	created, err := repo.Create(ctx, &TestUser{Name: "Alice", Email: "alice@example.com", CompanyID: uuid.New()})
+	require.NoError(t, err)
+
+	_, err = UpdateByIDWithMapPatch(ctx, repo, created.ID.String(), map[string]any{"company_id": uuid.New()}, nil)
+	assert.ErrorIs(t, err, ErrPatchReadOnlyField)
+
+	updated, err := UpdateByIDWithMapPatch(ctx, repo, created.ID.String(), map[string]any{"name": "Bob", "company_id": uuid.New()}, nil,
+		WithPatchReadOnlyMode(MapReadOnlySkip))
+	require.NoError(t, err)
+	assert.Equal(t, "Bob", updated.Name)
+	assert.Equal(t, created.CompanyID, updated.CompanyID)
+
+	_, _, err = ApplyMapPatch(created, map[string]any{"company_id": uuid.New()}, WithPatchReadOnlyColumns("company_id"))
+	assert.ErrorIs(t, err, ErrPatchReadOnlyField)
+	_, err = UpdateCriteriaForMapPatch(map[string]any{"company_id": uuid.New()}, WithPatchReadOnlyColumns("company_id"))
+	assert.ErrorIs(t, err, ErrPatchReadOnlyField)
+}
+
+func TestRepository_WithReadOnlyColumns_Validate(t *testing.T) {
+	repo := NewRepositoryWithConfig(db, testUserHandlers(), nil, WithReadOnlyColumns("missing"))
+	err := repo.(Validator).Validate()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "repository configuration invalid")
+}
@@ -0,0 +1,129 @@
+package repository
+
+import (
+	stderrors "errors"
+	"fmt"
+	"reflect"
+	"sync"
+
+	"github.com/uptrace/bun"
+)
+
+var (
+	// ErrModelNotRegistered is returned by Repo for model types never registered.
+	ErrModelNotRegistered = stderrors.New("repository: model not registered")
+	// ErrModelAlreadyRegistered is returned by Register for duplicate model types.
+	ErrModelAlreadyRegistered = stderrors.New("repository: model already registered")
+)
+
+// Registry builds and holds one repository per model type, all sharing the
+// same bun.DB, query hooks and default repo options:
+//
+//	registry := NewRegistry(db, []Option{WithQueryHooks(hook)}, WithDefaultListPagination(25, 0))
+//	Register(registry, userHandlers, WithDefaultRelations("Company"))
+//	users := MustRepo[*User](registry)
+type Registry struct {
+	db       *bun.DB
+	defaults []RepoOption
+
+	mu    sync.RWMutex
+	repos map[reflect.Type]any
+	order []reflect.Type
+}
+
+// NewRegistry applies dbOpts to db once and returns a registry whose
+// repositories get defaults before their own options.
+func NewRegistry(db *bun.DB, dbOpts []Option, defaults ...RepoOption) *Registry {
+	if db != nil {
+		for _, opt := range dbOpts {
+			if opt != nil {
+				opt(db)
+			}
+		}
+	}
+	return &Registry{
+		db:       db,
+		defaults: defaults,
+		repos:    make(map[reflect.Type]any),
+	}
+}
+
+// DB returns the bun.DB shared by the registry repositories.
+func (reg *Registry) DB() *bun.DB {
+	return reg.db
+}
+
+// Repositories returns the registered repositories in registration order,
+// e.g. to run optional capabilities such as RetentionRunner for every model.
+func (reg *Registry) Repositories() []any {
+	reg.mu.RLock()
+	defer reg.mu.RUnlock()
+
+	repos := make([]any, 0, len(reg.order))
+	for _, typ := range reg.order {
+		repos = append(repos, reg.repos[typ])
+	}
+	return repos
+}
+
+// Register creates and validates the repository for T and stores it in reg.
+// opts are applied after the registry defaults.
+func Register[T any](reg *Registry, handlers ModelHandlers[T], opts ...RepoOption) (Repository[T], error) {
+	if err := validateRepositoryConfig(reg.db, handlers); err != nil {
+		return nil, err
+	}
+
+	typ := reflect.TypeFor[T]()
+	reg.mu.Lock()
+	defer reg.mu.Unlock()
+
+	if _, ok := reg.repos[typ]; ok {
+		return nil, fmt.Errorf("%w: %s", ErrModelAlreadyRegistered, typ)
+	}
+
+	repoOpts := make([]RepoOption, 0, len(reg.defaults)+len(opts))
+	repoOpts = append(repoOpts, reg.defaults...)
+	repoOpts = append(repoOpts, opts...)
+
+	instance := NewRepositoryWithConfig(reg.db, handlers, nil, repoOpts...)
+	if validator, ok := instance.(Validator); ok {
+		if err := validator.Validate(); err != nil {
+			return nil, err
+		}
+	}
+
+	reg.repos[typ] = instance
+	reg.order = append(reg.order, typ)
+	return instance, nil
+}
+
+// MustRegister is like Register but panics on error.
+func MustRegister[T any](reg *Registry, handlers ModelHandlers[T], opts ...RepoOption) Repository[T] {
+	instance, err := Register(reg, handlers, opts...)
+	if err != nil {
+		panic(err)
+	}
+	return instance
+}
+
+// Repo returns the repository registered for T.
+func Repo[T any](reg *Registry) (Repository[T], error) {
+	typ := reflect.TypeFor[T]()
+	reg.mu.RLock()
+	defer reg.mu.RUnlock()
+
+	instance, ok := reg.repos[typ]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrModelNotRegistered, typ)
+	}
+	return instance.(Repository[T]), nil
+}
+
+// MustRepo is like Repo but panics when T is not registered.
+func MustRepo[T any](reg *Registry) Repository[T] {
+	instance, err := Repo[T](reg)
+	if err != nil {
+		panic(err)
+	}
+	return instance
+}
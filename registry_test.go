@@ -0,0 +1,68 @@
+package repository
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testUserHandlers() ModelHandlers[*TestUser] {
+	return ModelHandlers[*TestUser]{
+		NewRecord:          func() *TestUser { return &TestUser{} },
+		GetID:              func(record *TestUser) uuid.UUID { return record.ID },
+		SetID:              func(record *TestUser, id uuid.UUID) { record.ID = id },
+		GetIdentifier:      func() string { return "email" },
+		GetIdentifierValue: func(record *TestUser) string { return record.Email },
+	}
+}
+
+func TestRegistry_RegisterAndResolve(t *testing.T) {
+	setupTestData(t)
+	ctx := context.Background()
+
+	registry := NewRegistry(db, nil, WithDefaultListPagination(1, 0))
+	registered, err := Register(registry, testUserHandlers())
+	require.NoError(t, err)
+	MustRegister(registry, testModelHandlers[*TestCompany]())
+
+	users := MustRepo[*TestUser](registry)
+	assert.Same(t, registered, users)
+	assert.Len(t, registry.Repositories(), 2)
+	assert.Same(t, db, registry.DB())
+
+	_, err = users.CreateMany(ctx, []*TestUser{
+		{Name: "Alice", Email: "alice@example.com"},
+		{Name: "Bob", Email: "bob@example.com"},
+	})
+	require.NoError(t, err)
+
+	// registry defaults apply to every repository
+	page, total, err := users.List(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 2, total)
+	assert.Len(t, page, 1)
+}
+
+func TestRegistry_Errors(t *testing.T) {
+	registry := NewRegistry(db, nil)
+
+	_, err := Repo[*TestUser](registry)
+	require.ErrorIs(t, err, ErrModelNotRegistered)
+	assert.Panics(t, func() { MustRepo[*TestUser](registry) })
+
+	_, err = Register(registry, testUserHandlers())
+	require.NoError(t, err)
+	_, err = Register(registry, testUserHandlers())
+	require.ErrorIs(t, err, ErrModelAlreadyRegistered)
+
+	_, err = Register(registry, ModelHandlers[*TestCompany]{})
+	require.Error(t, err)
+
+	_, err = Register(registry, testModelHandlers[*relationTestUser](), WithDefaultRelations("Missing"))
+	require.Error(t, err)
+	_, err = Repo[*relationTestUser](registry)
+	require.ErrorIs(t, err, ErrModelNotRegistered)
+}
@@ -0,0 +1,149 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/goliatone/go-errors"
+	"github.com/goliatone/go-repository-bun/repositoryctx"
+	"github.com/uptrace/bun"
+)
+
+// WithoutRelations disables the relations configured via WithDefaultRelations
+// for calls made with the returned context.
+func WithoutRelations(ctx context.Context) context.Context {
+	return repositoryctx.WithoutRelations(ctx)
+}
+
+func defaultRelationsDisabled(ctx context.Context) bool {
+	return repositoryctx.RelationsDisabled(ctx)
+}
+
+func (r *repo[T]) applyDefaultRelations(ctx context.Context, q *bun.SelectQuery) *bun.SelectQuery {
+	if len(r.defaultRelations) == 0 || defaultRelationsDisabled(ctx) {
+		return q
+	}
+	for _, relation := range r.defaultRelations {
+		q = q.Relation(relation)
+	}
+	return q
+}
+
+func (r *repo[T]) validateDefaultRelations() error {
+	if len(r.defaultRelations) == 0 {
+		return nil
+	}
+
+	table := r.modelTable()
+	if table == nil {
+		return nil
+	}
+
+	var validationErrors errors.ValidationErrors
+	for _, relation := range r.defaultRelations {
+		current := table
+		for _, name := range strings.Split(relation, ".") {
+			rel, ok := current.Relations[name]
+			if !ok {
+				validationErrors = append(validationErrors, errors.FieldError{
+					Field:   "repoOptions.WithDefaultRelations",
+					Message: fmt.Sprintf("unknown relation %q on %s", relation, table.TypeName),
+				})
+				break
+			}
+			current = rel.JoinTable
+		}
+	}
+
+	if len(validationErrors) > 0 {
+		return errors.NewValidation("repository configuration invalid", validationErrors...)
+	}
+	return nil
+}
+
+type preloadConfig struct {
+	criteria    []SelectCriteria
+	order       []string
+	limit       int
+	columns     []string
+	withDeleted bool
+}
+
+// PreloadOption shapes the query used to load a relation.
+type PreloadOption func(*preloadConfig)
+
+// PreloadWhere filters the related records.
+func PreloadWhere(criteria ...SelectCriteria) PreloadOption {
+	return func(cfg *preloadConfig) {
+		cfg.criteria = append(cfg.criteria, criteria...)
+	}
+}
+
+// PreloadOrderBy orders the related records, e.g. PreloadOrderBy("created_at DESC").
+// Ordering only applies to has-many and many-to-many relations.
+func PreloadOrderBy(expr ...string) PreloadOption {
+	return func(cfg *preloadConfig) {
+		cfg.order = append(cfg.order, expr...)
+	}
+}
+
+// PreloadLimit caps the number of related records. Bun loads has-many and
+// many-to-many relations with a single query for all parents, so the limit
+// applies to that query and not to each parent record.
+func PreloadLimit(limit int) PreloadOption {
+	return func(cfg *preloadConfig) {
+		cfg.limit = limit
+	}
+}
+
+// PreloadColumns restricts the columns selected for the related records.
+// Join columns must be included for the records to be attached.
+func PreloadColumns(columns ...string) PreloadOption {
+	return func(cfg *preloadConfig) {
+		cfg.columns = append(cfg.columns, columns...)
+	}
+}
+
+// PreloadWithDeleted includes soft deleted related records.
+func PreloadWithDeleted() PreloadOption {
+	return func(cfg *preloadConfig) {
+		cfg.withDeleted = true
+	}
+}
+
+// Preload loads the relation at path, which may be nested ("Company.Owner").
+// Options apply to the last relation in the path.
+func Preload(path string, opts ...PreloadOption) SelectCriteria {
+	cfg := &preloadConfig{}
+	for _, opt := range opts {
+		if opt != nil {
+			opt(cfg)
+		}
+	}
+
+	return func(q *bun.SelectQuery) *bun.SelectQuery {
+		return q.Relation(strings.TrimSpace(path), cfg.apply)
+	}
+}
+
+func (cfg *preloadConfig) apply(q *bun.SelectQuery) *bun.SelectQuery {
+	if len(cfg.columns) > 0 {
+		q = SelectColumns(cfg.columns...)(q)
+	}
+	if cfg.withDeleted {
+		q = q.WhereAllWithDeleted()
+	}
+	for _, criteria := range cfg.criteria {
+		if criteria != nil {
+			q = criteria(q)
+		}
+	}
+	if len(cfg.order) > 0 {
+		q = OrderBy(cfg.order...)(q)
+	}
+	if cfg.limit > 0 {
+		q = q.Limit(cfg.limit)
+	}
+	return q
+}
@@ -0,0 +1,165 @@
+package repository
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/uptrace/bun"
+)
+
+type relationTestUser struct {
+	bun.BaseModel `bun:"table:test_users,alias:u"`
+
+	ID        uuid.UUID    `bun:"id,pk,notnull"`
+	Name      string       `bun:"name,notnull"`
+	Email     string       `bun:"email,notnull,unique"`
+	CompanyID uuid.UUID    `bun:"company_id,notnull"`
+	Company   *TestCompany `bun:"rel:belongs-to,join:company_id=id"`
+
+	CreatedAt time.Time `bun:"created_at,notnull"`
+	UpdatedAt time.Time `bun:"updated_at,notnull"`
+}
+
+func newRelationTestUserRepository(db *bun.DB, repoOpts ...RepoOption) Repository[*relationTestUser] {
+	handlers := ModelHandlers[*relationTestUser]{
+		NewRecord: func() *relationTestUser {
+			return &relationTestUser{}
+		},
+		GetID: func(record *relationTestUser) uuid.UUID {
+			return record.ID
+		},
+		SetID: func(record *relationTestUser, id uuid.UUID) {
+			record.ID = id
+		},
+		GetIdentifier: func() string {
+			return "email"
+		},
+		GetIdentifierValue: func(record *relationTestUser) string {
+			return record.Email
+		},
+	}
+	return NewRepositoryWithConfig[*relationTestUser](db, handlers, nil, repoOpts...)
+}
+
+func TestRepository_WithDefaultRelations(t *testing.T) {
+	setupTestData(t)
+	ctx := context.Background()
+
+	company, err := newTestCompanyRepository(db).Create(ctx, &TestCompany{Name: "Acme", Identifier: "acme"})
+	require.NoError(t, err)
+
+	repo := newRelationTestUserRepository(db, WithDefaultRelations("Company"))
+	require.NoError(t, repo.(Validator).Validate())
+
+	user, err := repo.Create(ctx, &relationTestUser{Name: "Alice", Email: "alice@example.com", CompanyID: company.ID})
+	require.NoError(t, err)
+
+	found, err := repo.GetByID(ctx, user.ID.String())
+	require.NoError(t, err)
+	require.NotNil(t, found.Company)
+	assert.Equal(t, "Acme", found.Company.Name)
+
+	found, err = repo.GetByIdentifier(ctx, "alice@example.com")
+	require.NoError(t, err)
+	require.NotNil(t, found.Company)
+
+	records, total, err := repo.List(ctx, SelectRelation("Company"))
+	require.NoError(t, err)
+	assert.Equal(t, 1, total)
+	require.Len(t, records, 1)
+	require.NotNil(t, records[0].Company)
+
+	found, err = repo.GetByID(WithoutRelations(ctx), user.ID.String())
+	require.NoError(t, err)
+	assert.Nil(t, found.Company)
+
+	records, _, err = repo.List(WithoutRelations(ctx))
+	require.NoError(t, err)
+	require.Len(t, records, 1)
+	assert.Nil(t, records[0].Company)
+}
+
+func TestRepository_WithDefaultRelations_InvalidRelationValidation(t *testing.T) {
+	validator, ok := newRelationTestUserRepository(db, WithDefaultRelations("Company.Owner")).(Validator)
+	require.True(t, ok)
+	require.Error(t, validator.Validate())
+
+	validator, ok = newRelationTestUserRepository(db, WithDefaultRelations("Missing")).(Validator)
+	require.True(t, ok)
+	err := validator.Validate()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "repository configuration invalid")
+}
+
+type preloadTestMember struct {
+	bun.BaseModel `bun:"table:preload_test_members,alias:pm"`
+
+	ID        uuid.UUID `bun:"id,pk,notnull"`
+	CompanyID uuid.UUID `bun:"company_id,notnull"`
+	UserID    uuid.UUID `bun:"user_id,notnull"`
+	Rank      int       `bun:"rank,notnull"`
+	User      *TestUser `bun:"rel:belongs-to,join:user_id=id"`
+	DeletedAt time.Time `bun:"deleted_at,soft_delete,nullzero"`
+}
+
+type preloadTestCompany struct {
+	bun.BaseModel `bun:"table:test_companies,alias:c"`
+
+	ID         uuid.UUID            `bun:"id,pk,notnull"`
+	Name       string               `bun:"name,notnull"`
+	Identifier string               `bun:"identifier,notnull"`
+	Members    []*preloadTestMember `bun:"rel:has-many,join:id=company_id"`
+
+	CreatedAt time.Time `bun:"created_at,notnull"`
+	UpdatedAt time.Time `bun:"updated_at,notnull"`
+}
+
+func TestPreload(t *testing.T) {
+	setupTestData(t)
+	ctx := context.Background()
+
+	recreateTestTable(t, (*preloadTestMember)(nil))
+
+	company, err := newTestCompanyRepository(db).Create(ctx, &TestCompany{Name: "Acme", Identifier: "acme"})
+	require.NoError(t, err)
+	user, err := newTestUserRepository(db).Create(ctx, &TestUser{Name: "Alice", Email: "alice@example.com", CompanyID: company.ID})
+	require.NoError(t, err)
+
+	members := []*preloadTestMember{
+		{ID: uuid.New(), CompanyID: company.ID, UserID: user.ID, Rank: 1},
+		{ID: uuid.New(), CompanyID: company.ID, UserID: user.ID, Rank: 2},
+		{ID: uuid.New(), CompanyID: company.ID, UserID: user.ID, Rank: 3, DeletedAt: time.Now()},
+	}
+	_, err = db.NewInsert().Model(&members).Exec(ctx)
+	require.NoError(t, err)
+
+	repo := NewRepositoryWithConfig(db, testModelHandlers[*preloadTestCompany](), nil)
+
+	found, err := repo.GetByID(ctx, company.ID.String(), Preload("Members", PreloadOrderBy("rank DESC")))
+	require.NoError(t, err)
+	require.Len(t, found.Members, 2)
+	assert.Equal(t, 2, found.Members[0].Rank)
+
+	found, err = repo.GetByID(ctx, company.ID.String(), Preload("Members", PreloadWithDeleted(), PreloadOrderBy("rank DESC"), PreloadLimit(1)))
+	require.NoError(t, err)
+	require.Len(t, found.Members, 1)
+	assert.Equal(t, 3, found.Members[0].Rank)
+
+	found, err = repo.GetByID(ctx, company.ID.String(), Preload("Members", PreloadWhere(SelectBy("rank", "=", "1"))))
+	require.NoError(t, err)
+	require.Len(t, found.Members, 1)
+	assert.Equal(t, 1, found.Members[0].Rank)
+
+	found, err = repo.GetByID(ctx, company.ID.String(), Preload("Members.User"))
+	require.NoError(t, err)
+	require.Len(t, found.Members, 2)
+	require.NotNil(t, found.Members[0].User)
+	assert.Equal(t, "Alice", found.Members[0].User.Name)
+
+	_, err = repo.GetByID(ctx, company.ID.String(), Preload("Missing"))
+	require.Error(t, err)
+}
@@ -9,11 +9,13 @@ import (
 	"sort"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/goliatone/go-errors"
 	"github.com/google/uuid"
 	"github.com/uptrace/bun"
 	"github.com/uptrace/bun/dialect"
+	"github.com/uptrace/bun/schema"
 )
 
 type SelectCriteria func(*bun.SelectQuery) *bun.SelectQuery
@@ -122,9 +124,64 @@ type repo[T any] struct {
 	scopeDefaults ScopeDefaults
 
 	allowFullTableDelete bool
+	allowFullTableUpdate bool
 
 	recordLookupResolver    RecordLookupResolver[T]
 	recordLookupResolverErr error
+	atomicGetOrCreate       bool
+
+	upsertLookupUniqueColumns bool
+
+	defaultOrder    []string
+	defaultOrderErr error
+
+	defaultRelations []string
+	listWindowCount  bool
+	parallelCount    bool
+	readOnlyColumns  []string
+	uniquePrechecks  [][]string
+
+	preparedStatements *preparedStatementCache
+	bulkCopier         BulkCopier
+
+	eventPublishers   []EventPublisher
+	eventErrorHandler EventErrorHandler
+
+	parentColumn   string
+	positionColumn string
+	slug           *slugConfig
+	transitions    *transitionConfig
+
+	encryptionConfig *encryptionConfig
+	encryption       *encryptedModel
+
+	integrityConfig *integrityConfig
+	integrity       *integrityModel
+
+	historySuffix string
+
+	circuitBreaker *circuitBreaker
+
+	filterableColumns []string
+	sortableColumns   []string
+	criteriaPolicy    *criteriaPolicy
+
+	softDeleteColumn string
+	softDelete       *schema.Field
+
+	anonymizeBatchSize int
+	listAllBatchSize   int
+	constraintMapping  map[string]FieldErrorSpec
+	errorMappers       []DatabaseErrorMapper
+
+	retentionPolicies []RetentionPolicy
+	retentionProgress RetentionProgressFunc
+	progressReporter  ProgressReporter
+
+	csvProfiles []CSVProfile
+
+	staleReadCache  Cache
+	staleReadMaxAge time.Duration
 
 	listDefaultsMu               sync.RWMutex
 	defaultListPaginationEnabled bool
@@ -147,6 +204,12 @@ type ModelHandlers[T any] struct {
 	// GetIdentifierValue returns the value for the identifier column.
 	// Return an empty string to indicate that the identifier is not available.
 	GetIdentifierValue func(T) string
+	// GetIdentifiers lists natural key columns (e.g. email, username, slug).
+	// GetByIdentifier tries them in order when ResolveIdentifier returns no
+	// options, and GetOrCreate/Upsert match an existing record on any of them,
+	// reading each column value from the record. A GetIdentifier column keeps
+	// using GetIdentifierValue.
+	GetIdentifiers func() []string
 	// ResolveIdentifier allows callers to customize how identifiers are resolved.
 	// It can inspect the provided identifier and return one or more IdentifierOptions
 	// to try (e.g., try email, username, and id for flexible lookups). Returning nil
@@ -165,17 +228,21 @@ const (
 	legacyDefaultListOffset = 0
 )
 
-func NewRepository[T any](db *bun.DB, handlers ModelHandlers[T]) Repository[T] {
-	return NewRepositoryWithOptions(db, handlers)
+// NewRepository builds a repository with the legacy list defaults (LIMIT 25
+// OFFSET 0) and repoOpts, e.g. WithRecordLookupResolver or
+// WithDefaultListPagination, which overrides the defaults.
+func NewRepository[T any](db *bun.DB, handlers ModelHandlers[T], repoOpts ...RepoOption) Repository[T] {
+	return NewRepositoryWithConfig(db, handlers, nil, legacyRepoOptions(repoOpts)...)
+}
+
+func legacyRepoOptions(repoOpts []RepoOption) []RepoOption {
+	opts := make([]RepoOption, 0, len(repoOpts)+1)
+	opts = append(opts, WithDefaultListPagination(legacyDefaultListLimit, legacyDefaultListOffset))
+	return append(opts, repoOpts...)
 }
 
 func NewRepositoryWithOptions[T any](db *bun.DB, handlers ModelHandlers[T], opts ...Option) Repository[T] {
-	return NewRepositoryWithConfig(
-		db,
-		handlers,
-		opts,
-		WithDefaultListPagination(legacyDefaultListLimit, legacyDefaultListOffset),
-	)
+	return NewRepositoryWithConfig(db, handlers, opts, legacyRepoOptions(nil)...)
 }
 
 func NewRepositoryWithConfig[T any](db *bun.DB, handlers ModelHandlers[T], dbOpts []Option, repoOpts ...RepoOption) Repository[T] {
@@ -197,14 +264,79 @@ func NewRepositoryWithConfig[T any](db *bun.DB, handlers ModelHandlers[T], dbOpt
 	}
 
 	recordLookupResolver, recordLookupResolverErr := resolveRecordLookupResolver[T](cfg)
+	defaultOrder, defaultOrderErr := resolveDefaultOrder(cfg)
 
 	instance := &repo[T]{
 		db:                      db,
 		handlers:                handlers,
 		driver:                  DetectDriver(db),
 		allowFullTableDelete:    cfg.allowFullTableDelete,
+		allowFullTableUpdate:    cfg.allowFullTableUpdate,
 		recordLookupResolver:    recordLookupResolver,
 		recordLookupResolverErr: recordLookupResolverErr,
+		atomicGetOrCreate:       cfg.atomicGetOrCreate,
+		defaultOrder:            defaultOrder,
+		defaultOrderErr:         defaultOrderErr,
+		defaultRelations:        cfg.defaultRelations,
+		listWindowCount:         cfg.listWindowCount,
+		parallelCount:           cfg.parallelCount,
+		bulkCopier:              cfg.bulkCopier,
+		readOnlyColumns:         cfg.readOnlyColumns,
+		uniquePrechecks:         cfg.uniquePrechecks,
+		anonymizeBatchSize:      cfg.anonymizeBatchSize,
+		listAllBatchSize:        cfg.listAllBatchSize,
+		constraintMapping:       cfg.constraintMapping,
+		errorMappers:            cfg.errorMappers,
+		retentionPolicies:       cfg.retentionPolicies,
+		retentionProgress:       cfg.retentionProgress,
+		progressReporter:        cfg.progressReporter,
+		csvProfiles:             cfg.csvProfiles,
+		staleReadCache:          cfg.staleReadCache,
+		staleReadMaxAge:         cfg.staleReadMaxAge,
+		eventPublishers:         cfg.eventPublishers,
+		eventErrorHandler:       cfg.eventErrorHandler,
+		parentColumn:            cfg.parentColumn,
+		positionColumn:          cfg.positionColumn,
+		slug:                    cfg.slug,
+		transitions:             cfg.transitions,
+		historySuffix:           cfg.historySuffix,
+		filterableColumns:       cfg.filterableColumns,
+		sortableColumns:         cfg.sortableColumns,
+		criteriaPolicy:          newCriteriaPolicy(cfg.filterableColumns, cfg.sortableColumns, cfg.strictCriteria),
+		softDeleteColumn:        cfg.softDeleteColumn,
+
+		upsertLookupUniqueColumns: cfg.upsertLookupUniqueColumns,
+	}
+
+	if db != nil {
+		registerQueryHooks(db, queryStatsHook{})
+	}
+
+	if cfg.softDeleteColumn != "" && db != nil {
+		instance.registerSoftDeleteColumn(cfg.softDeleteColumn)
+	}
+
+	if cfg.circuitBreakerThreshold > 0 && db != nil {
+		instance.circuitBreaker = newCircuitBreaker(cfg.circuitBreakerThreshold, cfg.circuitBreakerCooldown, instance.mapError)
+		registerQueryHooks(db, circuitBreakerHook{})
+	}
+
+	if cfg.preparedStatements {
+		instance.preparedStatements = &preparedStatementCache{}
+	}
+
+	if cfg.encryption != nil && db != nil {
+		instance.encryptionConfig = cfg.encryption
+		instance.registerEncryptedColumns(cfg.encryption)
+	}
+
+	if cfg.integrity != nil && db != nil {
+		instance.integrityConfig = cfg.integrity
+		instance.registerIntegrityColumn(cfg.integrity)
+	}
+
+	if cfg.outbox && db != nil {
+		instance.eventPublishers = append(instance.eventPublishers, NewOutboxRepository(db))
 	}
 
 	if cfg.defaultListPaginationConfigured {
@@ -214,12 +346,10 @@ func NewRepositoryWithConfig[T any](db *bun.DB, handlers ModelHandlers[T], dbOpt
 	return instance
 }
 
-func MustNewRepository[T any](db *bun.DB, handlers ModelHandlers[T]) Repository[T] {
-	if err := validateRepositoryConfig(db, handlers); err != nil {
-		panic(err)
-	}
-
-	return NewRepository(db, handlers)
+// MustNewRepository is NewRepository panicking on invalid configuration, as
+// MustNewRepositoryWithConfig.
+func MustNewRepository[T any](db *bun.DB, handlers ModelHandlers[T], repoOpts ...RepoOption) Repository[T] {
+	return MustNewRepositoryWithConfig(db, handlers, nil, legacyRepoOptions(repoOpts)...)
 }
 
 func MustNewRepositoryWithOptions[T any](db *bun.DB, handlers ModelHandlers[T], opts ...Option) Repository[T] {
@@ -251,7 +381,49 @@ func (r *repo[T]) Validate() error {
 	if r.recordLookupResolverErr != nil {
 		return r.recordLookupResolverErr
 	}
-	return nil
+	if r.defaultOrderErr != nil {
+		return r.defaultOrderErr
+	}
+	if err := r.validateDefaultRelations(); err != nil {
+		return err
+	}
+	if err := r.validateRetentionPolicies(); err != nil {
+		return err
+	}
+	if err := r.validateCSVProfiles(); err != nil {
+		return err
+	}
+	if err := r.validateReadOnlyColumns(); err != nil {
+		return err
+	}
+	if err := r.validateParentColumn(); err != nil {
+		return err
+	}
+	if err := r.validatePositionColumn(); err != nil {
+		return err
+	}
+	if err := r.validateSlug(); err != nil {
+		return err
+	}
+	if err := r.validateTransitions(); err != nil {
+		return err
+	}
+	if err := r.validateEncryptedColumns(); err != nil {
+		return err
+	}
+	if err := r.validateIntegrityColumn(); err != nil {
+		return err
+	}
+	if err := r.validateHistory(); err != nil {
+		return err
+	}
+	if err := r.validateColumnPolicy(); err != nil {
+		return err
+	}
+	if err := r.validateSoftDeleteColumn(); err != nil {
+		return err
+	}
+	return r.validateUniquePrechecks()
 }
 
 func (r *repo[T]) MustValidate() {
@@ -269,7 +441,13 @@ func (r *repo[T]) mapError(err error) error {
 		return err
 	}
 
-	return MapDatabaseError(err, r.driver)
+	for _, mapper := range r.errorMappers {
+		if mapped := mapper(err); mapped != nil {
+			return mapped
+		}
+	}
+
+	return r.mapConstraintError(err, MapDatabaseError(err, r.driver))
 }
 
 func (r *repo[T]) GetModelFields() []ModelField {
@@ -307,19 +485,64 @@ func (r *repo[T]) Raw(ctx context.Context, sql string, args ...any) ([]T, error)
 }
 
 func (r *repo[T]) RawTx(ctx context.Context, tx bun.IDB, sql string, args ...any) ([]T, error) {
+	ctx = r.withOperation(ctx, "Raw", 0)
 	records := []T{}
 
-	if err := tx.NewRaw(sql, args...).Scan(ctx, &records); err != nil {
-		return nil, r.mapError(err)
+	if err := r.scanRecords(ctx, tx.NewRaw(sql, args...).Scan, &records); err != nil {
+		return nil, err
 	}
 
 	return records, nil
 }
 
+// RawQuerier is an optional capability for repositories that run raw SQL
+// beyond the []T results of Raw.
+type RawQuerier interface {
+	RawScan(ctx context.Context, dest any, sql string, args ...any) error
+	RawScanTx(ctx context.Context, tx bun.IDB, dest any, sql string, args ...any) error
+	RawExec(ctx context.Context, sql string, args ...any) (sql.Result, error)
+	RawExecTx(ctx context.Context, tx bun.IDB, sql string, args ...any) (sql.Result, error)
+}
+
+// RawScan runs a raw query and scans the result into dest, which may be any
+// destination bun accepts: a struct or slice of structs for report rows, a
+// map, or scalars for counts. Several destinations scan the columns of a
+// single row. Errors go through the same mapping as every other method, so
+// no rows yields a not found error.
+func (r *repo[T]) RawScan(ctx context.Context, dest any, sql string, args ...any) error {
+	return r.RawScanTx(ctx, r.db, dest, sql, args...)
+}
+
+func (r *repo[T]) RawScanTx(ctx context.Context, tx bun.IDB, dest any, sql string, args ...any) error {
+	ctx = r.withOperation(ctx, "RawScan", 0)
+	if err := r.scanRecords(ctx, tx.NewRaw(sql, args...).Scan, dest); err != nil {
+		return err
+	}
+	return nil
+}
+
+// RawExec runs a raw statement that returns no rows, e.g. a bulk UPDATE or a
+// maintenance command, with the repository error mapping.
+func (r *repo[T]) RawExec(ctx context.Context, sql string, args ...any) (sql.Result, error) {
+	return r.RawExecTx(ctx, r.db, sql, args...)
+}
+
+func (r *repo[T]) RawExecTx(ctx context.Context, tx bun.IDB, sql string, args ...any) (sql.Result, error) {
+	ctx = r.withOperation(ctx, "RawExec", 0)
+	res, err := tx.ExecContext(ctx, sql, args...)
+	if err != nil {
+		return nil, r.mapError(err)
+	}
+	return res, nil
+}
+
 func (r *repo[T]) Handlers() ModelHandlers[T] {
 	return r.handlers
 }
 
+// DB returns the bun.DB the repository queries. Its DB field is the wrapped
+// *sql.DB, and the Tx field of a bun.Tx begun on it is a plain *sql.Tx, so
+// code not using bun can share the repository transactions.
 func (r *repo[T]) DB() *bun.DB {
 	return r.db
 }
@@ -397,18 +620,21 @@ func (r *repo[T]) Get(ctx context.Context, criteria ...SelectCriteria) (T, error
 }
 
 func (r *repo[T]) GetTx(ctx context.Context, tx bun.IDB, criteria ...SelectCriteria) (T, error) {
+	ctx = r.withOperation(ctx, "Get", len(criteria))
 	record := r.handlers.NewRecord()
 	q := tx.NewSelect().Model(record)
 
 	q = r.applySelectScopes(ctx, q)
+	q = r.applyDefaultRelations(ctx, q)
 
-	for _, c := range criteria {
-		q.Apply(c)
+	if err := r.applySelectCriteria(q, criteria); err != nil {
+		var zero T
+		return zero, err
 	}
 
-	if err := q.Limit(1).Scan(ctx); err != nil {
+	if err := r.scanRecords(ctx, q.Limit(1).Scan); err != nil {
 		var zero T
-		return zero, r.mapError(err)
+		return zero, err
 	}
 	return record, nil
 }
@@ -418,8 +644,157 @@ func (r *repo[T]) GetByID(ctx context.Context, id string, criteria ...SelectCrit
 }
 
 func (r *repo[T]) GetByIDTx(ctx context.Context, tx bun.IDB, id string, criteria ...SelectCriteria) (T, error) {
-	criteria = append([]SelectCriteria{SelectByID(id)}, criteria...)
-	return r.GetTx(ctx, tx, criteria...)
+	ctx = r.withOperation(ctx, "GetByID", len(criteria))
+	if r.canUsePreparedStatement(ctx, tx, criteria) {
+		if record, ok, err := r.getByColumnPrepared(ctx, "id", id); ok {
+			return r.staleReadFallback(ctx, tx, "id:"+id, criteria, record, err)
+		}
+	}
+	record, err := r.GetTx(ctx, tx, append([]SelectCriteria{SelectByID(id)}, criteria...)...)
+	return r.staleReadFallback(ctx, tx, "id:"+id, criteria, record, err)
+}
+
+// BatchGetter is an optional capability for repositories that load many
+// records by ID in a single query.
+type BatchGetter[T any] interface {
+	GetByIDs(ctx context.Context, ids []string, criteria ...SelectCriteria) ([]T, error)
+	GetByIDsTx(ctx context.Context, tx bun.IDB, ids []string, criteria ...SelectCriteria) ([]T, error)
+}
+
+// GetByIDs loads the records with the given IDs in a single query and returns
+// them in input order. Duplicate IDs are loaded once. When some IDs do not
+// match a record the found records are still returned together with a
+// *PartialResultError listing the missing IDs.
+func (r *repo[T]) GetByIDs(ctx context.Context, ids []string, criteria ...SelectCriteria) ([]T, error) {
+	return r.GetByIDsTx(ctx, r.db, ids, criteria...)
+}
+
+func (r *repo[T]) GetByIDsTx(ctx context.Context, tx bun.IDB, ids []string, criteria ...SelectCriteria) ([]T, error) {
+	ctx = r.withOperation(ctx, "GetByIDs", len(criteria))
+	order := make([]uuid.UUID, 0, len(ids))
+	seen := make(map[uuid.UUID]struct{}, len(ids))
+	for _, raw := range ids {
+		id, err := uuid.Parse(strings.TrimSpace(raw))
+		if err != nil {
+			continue
+		}
+		if _, dup := seen[id]; dup {
+			continue
+		}
+		seen[id] = struct{}{}
+		order = append(order, id)
+	}
+
+	records := []T{}
+	if len(order) > 0 {
+		q := tx.NewSelect().
+			Model(&records).
+			Where("?TableAlias.id IN (?)", bun.In(order))
+
+		q = r.applySelectScopes(ctx, q)
+		q = r.applyDefaultRelations(ctx, q)
+
+		if err := r.applySelectCriteria(q, criteria); err != nil {
+			return nil, err
+		}
+
+		if err := r.scanRecords(ctx, q.Scan); err != nil {
+			return nil, err
+		}
+	}
+
+	found := make(map[uuid.UUID]struct{}, len(records))
+	for _, record := range records {
+		found[r.handlers.GetID(record)] = struct{}{}
+	}
+
+	foundOrder := make([]uuid.UUID, 0, len(records))
+	for _, id := range order {
+		if _, ok := found[id]; ok {
+			foundOrder = append(foundOrder, id)
+		}
+	}
+
+	// Missing IDs are reported in input order, invalid IDs included.
+	var missing []string
+	reported := make(map[uuid.UUID]struct{})
+	for _, raw := range ids {
+		id, err := uuid.Parse(strings.TrimSpace(raw))
+		if err != nil {
+			missing = append(missing, raw)
+			continue
+		}
+		if _, ok := found[id]; ok {
+			continue
+		}
+		if _, dup := reported[id]; dup {
+			continue
+		}
+		reported[id] = struct{}{}
+		missing = append(missing, id.String())
+	}
+
+	if reordered, ok := reorderRecordsByID(records, foundOrder, r.handlers.GetID); ok {
+		records = reordered
+	}
+
+	if len(missing) > 0 {
+		return records, &PartialResultError{MissingIDs: missing}
+	}
+	return records, nil
+}
+
+// FieldGetter is an optional capability for repositories that look records
+// up by several columns at once, e.g. compound natural keys.
+type FieldGetter[T any] interface {
+	GetBy(ctx context.Context, fields map[string]any, criteria ...SelectCriteria) (T, error)
+	GetByTx(ctx context.Context, tx bun.IDB, fields map[string]any, criteria ...SelectCriteria) (T, error)
+}
+
+// GetBy loads the record whose columns equal every value in fields, e.g.
+// map[string]any{"tenant_id": tenantID, "slug": slug}. A nil value matches
+// NULL. Column names are validated against the model.
+func (r *repo[T]) GetBy(ctx context.Context, fields map[string]any, criteria ...SelectCriteria) (T, error) {
+	return r.GetByTx(ctx, r.db, fields, criteria...)
+}
+
+func (r *repo[T]) GetByTx(ctx context.Context, tx bun.IDB, fields map[string]any, criteria ...SelectCriteria) (T, error) {
+	ctx = r.withOperation(ctx, "GetBy", len(criteria))
+	var zero T
+	if len(fields) == 0 {
+		return zero, errors.NewValidation(
+			"repository: GetBy requires fields",
+			errors.FieldError{Field: "fields", Message: "at least one column is required"},
+		)
+	}
+
+	columns := make([]string, 0, len(fields))
+	for column := range fields {
+		columns = append(columns, column)
+	}
+	sort.Strings(columns)
+
+	table := r.modelTable()
+	predicates := make([]SelectCriteria, 0, len(columns))
+	for _, column := range columns {
+		col, ok := normalizeSQLIdentifier(column)
+		if !ok {
+			return zero, invalidColumnError("fields", column)
+		}
+		if err := unknownTableColumnError(table, col); err != nil {
+			return zero, err
+		}
+
+		value := fields[column]
+		predicates = append(predicates, func(q *bun.SelectQuery) *bun.SelectQuery {
+			if isNilValue(value) {
+				return q.Where(fmt.Sprintf("?TableAlias.%s IS NULL", col))
+			}
+			return q.Where(fmt.Sprintf("?TableAlias.%s = ?", col), value)
+		})
+	}
+
+	return r.GetTx(ctx, tx, append(predicates, criteria...)...)
 }
 
 func (r *repo[T]) List(ctx context.Context, criteria ...SelectCriteria) ([]T, int, error) {
@@ -427,29 +802,58 @@ func (r *repo[T]) List(ctx context.Context, criteria ...SelectCriteria) ([]T, in
 }
 
 func (r *repo[T]) ListTx(ctx context.Context, tx bun.IDB, criteria ...SelectCriteria) ([]T, int, error) {
+	ctx = r.withOperation(ctx, "List", len(criteria))
 	records := []T{}
 
+	q, err := r.listQuery(ctx, tx, &records, criteria)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	if r.useListWindowCount(q) {
+		return r.listWithWindowCount(ctx, tx, q, records, criteria)
+	}
+
+	ctx, collected := collectScanErrors(ctx)
+	var total int
+	if r.useParallelCount(tx) {
+		total, err = r.scanAndCountParallel(ctx, q)
+	} else {
+		total, err = q.ScanAndCount(ctx)
+	}
+	if err != nil {
+		return nil, total, r.mapError(err)
+	}
+	if err := collected(); err != nil {
+		return nil, total, err
+	}
+
+	return records, total, nil
+}
+
+// listQuery builds the select query run by List.
+func (r *repo[T]) listQuery(ctx context.Context, tx bun.IDB, model any, criteria []SelectCriteria) (*bun.SelectQuery, error) {
 	q := tx.NewSelect().
-		Model(&records)
+		Model(model)
 
 	if limit, offset, ok := r.defaultListPagination(); ok {
 		q.Limit(limit).Offset(offset)
 	}
 
 	q = r.applySelectScopes(ctx, q)
+	q = r.applyDefaultRelations(ctx, q)
 
-	for _, c := range criteria {
-		q.Apply(c)
+	if err := r.applySelectCriteria(q, criteria); err != nil {
+		return nil, err
 	}
 
-	var total int
-	var err error
-
-	if total, err = q.ScanAndCount(ctx); err != nil {
-		return nil, total, r.mapError(err)
+	if len(r.defaultOrder) > 0 && !selectHasOrder(q) {
+		for _, expr := range r.defaultOrder {
+			q.OrderExpr(expr)
+		}
 	}
 
-	return records, total, nil
+	return q, nil
 }
 
 func (r *repo[T]) Count(ctx context.Context, criteria ...SelectCriteria) (int, error) {
@@ -457,6 +861,7 @@ func (r *repo[T]) Count(ctx context.Context, criteria ...SelectCriteria) (int, e
 }
 
 func (r *repo[T]) CountTx(ctx context.Context, tx bun.IDB, criteria ...SelectCriteria) (int, error) {
+	ctx = r.withOperation(ctx, "Count", len(criteria))
 	record := r.handlers.NewRecord()
 
 	q := tx.NewSelect().
@@ -464,8 +869,8 @@ func (r *repo[T]) CountTx(ctx context.Context, tx bun.IDB, criteria ...SelectCri
 
 	q = r.applySelectScopes(ctx, q)
 
-	for _, c := range criteria {
-		q.Apply(c)
+	if err := r.applySelectCriteria(q, criteria); err != nil {
+		return 0, err
 	}
 
 	var total int
@@ -483,17 +888,59 @@ func (r *repo[T]) Create(ctx context.Context, record T, criteria ...InsertCriter
 }
 
 func (r *repo[T]) CreateTx(ctx context.Context, tx bun.IDB, record T, criteria ...InsertCriteria) (T, error) {
+	ctx = r.withOperation(ctx, "Create", len(criteria))
+	if key, ok := IdempotencyKeyFromContext(ctx); ok {
+		return r.createIdempotentTx(ctx, tx, key, record, criteria)
+	}
+	return r.createTx(ctx, tx, record, criteria)
+}
+
+func (r *repo[T]) createTx(ctx context.Context, tx bun.IDB, record T, criteria []InsertCriteria) (T, error) {
+	var created T
+	err := r.eventTx(ctx, tx, func(ctx context.Context, tx bun.IDB) error {
+		var err error
+		if created, err = r.insertRecord(ctx, tx, record, criteria); err != nil {
+			return err
+		}
+		return r.publishEvents(ctx, tx, EventCreate, r.createdColumns(), created)
+	})
+	if err != nil {
+		var zero T
+		return zero, err
+	}
+	return created, nil
+}
+
+func (r *repo[T]) insertRecord(ctx context.Context, tx bun.IDB, record T, criteria []InsertCriteria) (T, error) {
 	id := r.handlers.GetID(record)
 	if id == uuid.Nil {
 		newID := uuid.New()
 		r.handlers.SetID(record, newID)
 	}
+	if err := r.assignSlugs(ctx, tx, record); err != nil {
+		var zero T
+		return zero, err
+	}
+	if err := r.checkUnique(ctx, tx, record); err != nil {
+		var zero T
+		return zero, err
+	}
 	q := tx.NewInsert().Model(record)
 
 	q = r.applyInsertScopes(ctx, q)
 
-	for _, c := range criteria {
-		q.Apply(c)
+	if err := applyCriteria(q, criteria, r.criteriaPolicy); err != nil {
+		var zero T
+		return zero, err
+	}
+
+	if err := r.stampIntegrity(record); err != nil {
+		var zero T
+		return zero, err
+	}
+	if err := r.encryptRecords(record); err != nil {
+		var zero T
+		return zero, err
 	}
 
 	// TODO: what would be the proper way to getting the returned records from the insert?
@@ -502,6 +949,13 @@ func (r *repo[T]) CreateTx(ctx context.Context, tx bun.IDB, record T, criteria .
 		var zero T
 		return zero, r.mapError(err)
 	}
+	// criteria may turn the insert into an update of a conflicting row
+	if len(criteria) > 0 {
+		if err := r.rehashRecords(ctx, tx, record); err != nil {
+			var zero T
+			return zero, err
+		}
+	}
 	return record, nil
 }
 
@@ -510,6 +964,19 @@ func (r *repo[T]) CreateMany(ctx context.Context, records []T, criteria ...Inser
 }
 
 func (r *repo[T]) CreateManyTx(ctx context.Context, tx bun.IDB, records []T, criteria ...InsertCriteria) ([]T, error) {
+	ctx = r.withOperation(ctx, "CreateMany", len(criteria))
+	var created []T
+	err := r.eventTx(ctx, tx, func(ctx context.Context, tx bun.IDB) error {
+		var err error
+		if created, err = r.insertRecords(ctx, tx, records, criteria); err != nil {
+			return err
+		}
+		return r.publishEvents(ctx, tx, EventCreate, r.createdColumns(), created...)
+	})
+	return created, err
+}
+
+func (r *repo[T]) insertRecords(ctx context.Context, tx bun.IDB, records []T, criteria []InsertCriteria) ([]T, error) {
 	reorderByID, insertCriteria := splitInsertCriteriaForReturnOrder(criteria)
 	if len(records) == 0 {
 		return nil, nil
@@ -523,6 +990,13 @@ func (r *repo[T]) CreateManyTx(ctx context.Context, tx bun.IDB, records []T, cri
 		}
 	}
 
+	if err := r.assignSlugs(ctx, tx, records...); err != nil {
+		return records, err
+	}
+	if err := r.checkUniqueMany(ctx, tx, records); err != nil {
+		return records, err
+	}
+
 	var order []uuid.UUID
 	if reorderByID {
 		order = make([]uuid.UUID, len(records))
@@ -535,14 +1009,25 @@ func (r *repo[T]) CreateManyTx(ctx context.Context, tx bun.IDB, records []T, cri
 
 	q = r.applyInsertScopes(ctx, q)
 
-	for _, c := range insertCriteria {
-		q.Apply(c)
+	if err := applyCriteria(q, insertCriteria, r.criteriaPolicy); err != nil {
+		return records, err
+	}
+	if err := r.stampIntegrity(records...); err != nil {
+		return records, err
+	}
+	if err := r.encryptRecords(records...); err != nil {
+		return records, err
 	}
 
 	_, err := q.Returning("*").Exec(ctx)
 	if err != nil {
 		return records, r.mapError(fmt.Errorf("create many error: %w", err))
 	}
+	if len(insertCriteria) > 0 {
+		if err := r.rehashRecords(ctx, tx, records...); err != nil {
+			return records, err
+		}
+	}
 	if reorderByID {
 		if reordered, ok := reorderRecordsByID(records, order, r.handlers.GetID); ok {
 			return reordered, nil
@@ -667,9 +1152,39 @@ func (r *repo[T]) findExistingRecord(ctx context.Context, tx bun.IDB, record T)
 	if existing, found, err := r.findExistingByIdentifier(ctx, tx, record); found || err != nil {
 		return existing, found, err
 	}
+	if columns := r.lookupUniqueColumns(); len(columns) > 0 {
+		if existing, found, err := r.findExistingByNaturalKeys(ctx, tx, record, columns); found || err != nil {
+			return existing, found, err
+		}
+	}
 	return r.findExistingByResolver(ctx, tx, record)
 }
 
+// lookupUniqueColumns returns the single-column unique fields of the model
+// for WithUpsertLookupUniqueColumns, leaving out the primary key and the
+// identifier columns, which are looked up before.
+func (r *repo[T]) lookupUniqueColumns() []string {
+	if !r.upsertLookupUniqueColumns || r.modelTable() == nil {
+		return nil
+	}
+	skip := r.identifierColumns()
+	if r.handlers.GetIdentifier != nil {
+		skip = append(skip, strings.TrimSpace(r.handlers.GetIdentifier()))
+	}
+
+	var columns []string
+	for _, field := range GetModelFields(r.db, r.handlers.NewRecord()) {
+		if !field.IsUnique || field.IsPK || containsString(skip, field.Name) {
+			continue
+		}
+		if group, _ := r.modelField(field.Name).Tag.Option("unique"); group != "" {
+			continue
+		}
+		columns = append(columns, field.Name)
+	}
+	return columns
+}
+
 func (r *repo[T]) findExistingByID(ctx context.Context, tx bun.IDB, record T) (T, bool, error) {
 	var zero T
 	if r.handlers.GetID == nil {
@@ -685,6 +1200,9 @@ func (r *repo[T]) findExistingByID(ctx context.Context, tx bun.IDB, record T) (T
 
 func (r *repo[T]) findExistingByIdentifier(ctx context.Context, tx bun.IDB, record T) (T, bool, error) {
 	var zero T
+	if columns := r.identifierColumns(); len(columns) > 0 {
+		return r.findExistingByNaturalKeys(ctx, tx, record, columns)
+	}
 	if r.handlers.GetIdentifierValue == nil {
 		return zero, false, nil
 	}
@@ -696,6 +1214,46 @@ func (r *repo[T]) findExistingByIdentifier(ctx context.Context, tx bun.IDB, reco
 	return handleExistingLookup(existing, err)
 }
 
+// findExistingByNaturalKeys looks up record by each GetIdentifiers column in
+// order, skipping columns the record leaves empty.
+func (r *repo[T]) findExistingByNaturalKeys(ctx context.Context, tx bun.IDB, record T, columns []string) (T, bool, error) {
+	var zero T
+	for _, column := range columns {
+		column, _ = normalizeSQLIdentifier(column)
+		value, ok := r.naturalKeyValue(record, column)
+		if !ok {
+			continue
+		}
+		existing, err := r.getByColumnTx(ctx, tx, column, value, nil)
+		if existing, found, err := handleExistingLookup(existing, err); found || err != nil {
+			return existing, found, err
+		}
+	}
+	return zero, false, nil
+}
+
+// naturalKeyValue returns the value of the normalized column on record,
+// using GetIdentifierValue for the GetIdentifier column.
+func (r *repo[T]) naturalKeyValue(record T, column string) (any, bool) {
+	if r.handlers.GetIdentifier != nil && r.handlers.GetIdentifierValue != nil {
+		if identifier, ok := normalizeSQLIdentifier(strings.TrimSpace(r.handlers.GetIdentifier())); ok && identifier == column {
+			value := strings.TrimSpace(r.handlers.GetIdentifierValue(record))
+			return value, value != ""
+		}
+	}
+
+	field := r.modelField(column)
+	value := reflect.ValueOf(record)
+	if field == nil || value.Kind() != reflect.Pointer || value.IsNil() {
+		return nil, false
+	}
+	strct := value.Elem()
+	if field.HasZeroValue(strct) {
+		return nil, false
+	}
+	return field.Value(strct).Interface(), true
+}
+
 func (r *repo[T]) findExistingByResolver(ctx context.Context, tx bun.IDB, record T) (T, bool, error) {
 	var zero T
 	if r.recordLookupResolver == nil {
@@ -740,6 +1298,17 @@ func (r *repo[T]) GetOrCreate(ctx context.Context, record T) (T, error) {
 }
 
 func (r *repo[T]) GetOrCreateTx(ctx context.Context, tx bun.IDB, record T) (T, error) {
+	ctx = r.withOperation(ctx, "GetOrCreate", 0)
+	return r.getOrCreate(ctx, tx, record, nil)
+}
+
+// getOrCreate returns the existing record or creates it, calling onCreate, if
+// set, on the record about to be inserted.
+func (r *repo[T]) getOrCreate(ctx context.Context, tx bun.IDB, record T, onCreate func(T) T) (T, error) {
+	if column, ok := r.atomicGetOrCreateColumn(ctx, record); ok {
+		return r.getOrCreateAtomic(ctx, tx, column, record, onCreate)
+	}
+
 	existing, found, err := r.findExistingRecord(ctx, tx, record)
 	if err != nil {
 		var zero T
@@ -750,6 +1319,10 @@ func (r *repo[T]) GetOrCreateTx(ctx context.Context, tx bun.IDB, record T) (T, e
 		return existing, nil
 	}
 
+	if onCreate != nil {
+		record = onCreate(record)
+	}
+
 	created, err := r.CreateTx(ctx, tx, record)
 	if err != nil {
 		if existing, recovered, lookupErr := r.recoverDuplicateCreate(ctx, tx, record, err); recovered || lookupErr != nil {
@@ -813,6 +1386,12 @@ func (r *repo[T]) resolveIdentifierOptions(identifier string) []IdentifierOption
 		}
 	}
 
+	if len(options) == 0 {
+		for _, column := range r.identifierColumns() {
+			options = append(options, IdentifierOption{Column: column, Value: trimmed})
+		}
+	}
+
 	if len(options) == 0 {
 		options = append(options, r.defaultIdentifierOption(trimmed))
 	}
@@ -820,6 +1399,21 @@ func (r *repo[T]) resolveIdentifierOptions(identifier string) []IdentifierOption
 	return options
 }
 
+// identifierColumns returns the valid GetIdentifiers columns.
+func (r *repo[T]) identifierColumns() []string {
+	if r.handlers.GetIdentifiers == nil {
+		return nil
+	}
+	var columns []string
+	for _, column := range r.handlers.GetIdentifiers() {
+		column = strings.TrimSpace(column)
+		if _, ok := normalizeSQLIdentifier(column); ok && !containsString(columns, column) {
+			columns = append(columns, column)
+		}
+	}
+	return columns
+}
+
 func (r *repo[T]) defaultIdentifierOption(value string) IdentifierOption {
 	column := "id"
 	if r.handlers.GetIdentifier != nil {
@@ -839,6 +1433,12 @@ func (r *repo[T]) defaultIdentifierOption(value string) IdentifierOption {
 }
 
 func (r *repo[T]) GetByIdentifierTx(ctx context.Context, tx bun.IDB, identifier string, criteria ...SelectCriteria) (T, error) {
+	ctx = r.withOperation(ctx, "GetByIdentifier", len(criteria))
+	record, err := r.getByIdentifierTx(ctx, tx, identifier, criteria)
+	return r.staleReadFallback(ctx, tx, "identifier:"+identifier, criteria, record, err)
+}
+
+func (r *repo[T]) getByIdentifierTx(ctx context.Context, tx bun.IDB, identifier string, criteria []SelectCriteria) (T, error) {
 	var zero T
 	var lastErr error
 
@@ -853,23 +1453,13 @@ func (r *repo[T]) GetByIdentifierTx(ctx context.Context, tx bun.IDB, identifier
 			continue
 		}
 
-		record := r.handlers.NewRecord()
-
-		q := tx.NewSelect().Model(record)
-		q = r.applySelectScopes(ctx, q)
-
-		for _, c := range criteria {
-			q.Apply(c)
-		}
-
-		q = q.Where(fmt.Sprintf("?TableAlias.%s = ?", column), opt.Value).Limit(1)
-
-		if err := q.Scan(ctx); err != nil {
+		record, err := r.getByColumnTx(ctx, tx, column, opt.Value, criteria)
+		if err != nil {
 			if IsRecordNotFound(err) {
 				lastErr = err
 				continue
 			}
-			return zero, r.mapError(err)
+			return zero, err
 		}
 
 		return record, nil
@@ -882,17 +1472,81 @@ func (r *repo[T]) GetByIdentifierTx(ctx context.Context, tx bun.IDB, identifier
 	return zero, r.mapError(lastErr)
 }
 
+// getByColumnTx loads the first record whose normalized column equals value.
+func (r *repo[T]) getByColumnTx(ctx context.Context, tx bun.IDB, column string, value any, criteria []SelectCriteria) (T, error) {
+	if r.canUsePreparedStatement(ctx, tx, criteria) {
+		if record, ok, err := r.getByColumnPrepared(ctx, column, value); ok {
+			return record, err
+		}
+	}
+
+	var zero T
+	record := r.handlers.NewRecord()
+
+	q := tx.NewSelect().Model(record)
+	q = r.applySelectScopes(ctx, q)
+	q = r.applyDefaultRelations(ctx, q)
+
+	if err := r.applySelectCriteria(q, criteria); err != nil {
+		return zero, err
+	}
+
+	q = q.Where(fmt.Sprintf("?TableAlias.%s = ?", column), value).Limit(1)
+
+	if err := r.scanRecords(ctx, q.Scan); err != nil {
+		return zero, err
+	}
+
+	return record, nil
+}
+
 func (r *repo[T]) Update(ctx context.Context, record T, criteria ...UpdateCriteria) (T, error) {
 	return r.UpdateTx(ctx, r.db, record, criteria...)
 }
 
 func (r *repo[T]) UpdateTx(ctx context.Context, tx bun.IDB, record T, criteria ...UpdateCriteria) (T, error) {
+	ctx = r.withOperation(ctx, "Update", len(criteria))
+	var updated T
+	err := r.eventTx(ctx, tx, func(ctx context.Context, tx bun.IDB) error {
+		var columns []string
+		var err error
+		if updated, columns, err = r.updateRecord(ctx, tx, record, criteria); err != nil {
+			return err
+		}
+		return r.publishEvents(ctx, tx, EventUpdate, columns, updated)
+	})
+	if err != nil {
+		var zero T
+		return zero, err
+	}
+	return updated, nil
+}
+
+func (r *repo[T]) updateRecord(ctx context.Context, tx bun.IDB, record T, criteria []UpdateCriteria) (T, []string, error) {
 	q := tx.NewUpdate().Model(record)
 
 	q = r.applyUpdateScopes(ctx, q)
 
-	for _, c := range criteria {
-		q.Apply(c)
+	if err := r.applyUpdateCriteria(q, criteria); err != nil {
+		var zero T
+		return zero, nil, err
+	}
+	if err := r.excludeReadOnlyColumns(q); err != nil {
+		var zero T
+		return zero, nil, err
+	}
+	columns := r.updatedColumns(q)
+	if err := r.checkTransitions(ctx, tx, queryColumnNames(q), record); err != nil {
+		var zero T
+		return zero, nil, err
+	}
+	if err := r.recordHistory(ctx, tx, EventUpdate, r.handlers.GetID(record)); err != nil {
+		var zero T
+		return zero, nil, err
+	}
+	if err := r.encryptRecords(record); err != nil {
+		var zero T
+		return zero, nil, err
 	}
 	// TODO: WherePK will auto generate "ws"."id" = '44a3e9dc-0381-37a6-9652-99ea14057af5'
 	// so we can call it with model having the ID and we don't need criteria
@@ -900,15 +1554,19 @@ func (r *repo[T]) UpdateTx(ctx context.Context, tx bun.IDB, record T, criteria .
 
 	if err != nil {
 		var zero T
-		return zero, r.mapError(err)
+		return zero, nil, r.mapError(err)
 	}
 
 	if err = SQLExpectedCount(res, 1); err != nil {
 		var zero T
-		return zero, err
+		return zero, nil, err
+	}
+	if err := r.rehashRecords(ctx, tx, record); err != nil {
+		var zero T
+		return zero, nil, err
 	}
 
-	return record, nil
+	return record, columns, nil
 }
 
 func (r *repo[T]) UpdateMany(ctx context.Context, records []T, criteria ...UpdateCriteria) ([]T, error) {
@@ -916,9 +1574,26 @@ func (r *repo[T]) UpdateMany(ctx context.Context, records []T, criteria ...Updat
 }
 
 func (r *repo[T]) UpdateManyTx(ctx context.Context, tx bun.IDB, records []T, criteria ...UpdateCriteria) ([]T, error) {
+	ctx = r.withOperation(ctx, "UpdateMany", len(criteria))
+	var updated []T
+	err := r.eventTx(ctx, tx, func(ctx context.Context, tx bun.IDB) error {
+		var columns []string
+		var err error
+		if updated, columns, err = r.updateRecords(ctx, tx, records, criteria); err != nil {
+			return err
+		}
+		if len(updated) == 0 {
+			return nil
+		}
+		return r.publishEvents(ctx, tx, EventUpdate, columns, updated...)
+	})
+	return updated, err
+}
+
+func (r *repo[T]) updateRecords(ctx context.Context, tx bun.IDB, records []T, criteria []UpdateCriteria) ([]T, []string, error) {
 	reorderByID, updateCriteria := splitUpdateCriteriaForReturnOrder(criteria)
 	if len(records) == 0 {
-		return nil, nil
+		return nil, nil, nil
 	}
 
 	var order []uuid.UUID
@@ -933,8 +1608,25 @@ func (r *repo[T]) UpdateManyTx(ctx context.Context, tx bun.IDB, records []T, cri
 
 	q = r.applyUpdateScopes(ctx, q)
 
-	for _, c := range updateCriteria {
-		q.Apply(c)
+	if err := r.applyUpdateCriteria(q, updateCriteria); err != nil {
+		return records, nil, err
+	}
+	if err := r.excludeReadOnlyColumns(q); err != nil {
+		return records, nil, err
+	}
+	columns := r.updatedColumns(q)
+	if err := r.checkTransitions(ctx, tx, queryColumnNames(q), records...); err != nil {
+		return records, nil, err
+	}
+	ids := make([]uuid.UUID, len(records))
+	for i, record := range records {
+		ids[i] = r.handlers.GetID(record)
+	}
+	if err := r.recordHistory(ctx, tx, EventUpdate, ids...); err != nil {
+		return records, nil, err
+	}
+	if err := r.encryptRecords(records...); err != nil {
+		return records, nil, err
 	}
 
 	_, err := q.
@@ -944,16 +1636,87 @@ func (r *repo[T]) UpdateManyTx(ctx context.Context, tx bun.IDB, records []T, cri
 
 	if err != nil {
 		var zero []T
-		return zero, r.mapError(err)
+		return zero, nil, r.mapError(err)
+	}
+	if err := r.rehashRecords(ctx, tx, records...); err != nil {
+		return records, nil, err
 	}
 
 	if reorderByID {
 		if reordered, ok := reorderRecordsByID(records, order, r.handlers.GetID); ok {
-			return reordered, nil
+			return reordered, columns, nil
 		}
 	}
 
-	return records, nil
+	return records, columns, nil
+}
+
+// BulkUpdater is an optional capability for repositories that update rows by
+// criteria without loading them.
+type BulkUpdater interface {
+	UpdateWhere(ctx context.Context, criteria ...UpdateCriteria) (int64, error)
+	UpdateWhereTx(ctx context.Context, tx bun.IDB, criteria ...UpdateCriteria) (int64, error)
+}
+
+// UpdateWhere updates every row matched by criteria without loading them and
+// returns the number of affected rows. Values must be assigned with
+// UpdateSetColumn (or a raw processor calling Set). Calls without a WHERE
+// clause are blocked unless WithAllowFullTableUpdate(true) is configured.
+func (r *repo[T]) UpdateWhere(ctx context.Context, criteria ...UpdateCriteria) (int64, error) {
+	return r.UpdateWhereTx(ctx, r.db, criteria...)
+}
+
+func (r *repo[T]) UpdateWhereTx(ctx context.Context, tx bun.IDB, criteria ...UpdateCriteria) (int64, error) {
+	ctx = r.withOperation(ctx, "UpdateWhere", len(criteria))
+	hasSet, hasWhere, err := r.probeUpdateCriteria(tx, criteria)
+	if err != nil {
+		return 0, err
+	}
+	if !hasSet {
+		return 0, errors.NewValidation(
+			"repository: update without values",
+			errors.FieldError{
+				Field:   "criteria",
+				Message: "at least one column must be assigned, e.g. with UpdateSetColumn",
+			},
+		)
+	}
+	if !hasWhere && !r.allowFullTableUpdate {
+		return 0, fullTableOperationBlockedError("update", "WithAllowFullTableUpdate")
+	}
+
+	newQuery := func(tx bun.IDB) (*bun.UpdateQuery, error) {
+		q := tx.NewUpdate().Model(r.handlers.NewRecord())
+		if err := r.applyUpdateCriteria(q, criteria); err != nil {
+			return nil, err
+		}
+		if !hasWhere {
+			q = q.Where("1=1")
+		}
+		return r.applyUpdateScopes(ctx, q), nil
+	}
+
+	return r.execWithHistory(ctx, tx, EventUpdate,
+		func(ctx context.Context, tx bun.IDB, returning string, ids *[]uuid.UUID) error {
+			q, err := newQuery(tx)
+			if err != nil {
+				return err
+			}
+			_, err = q.Returning(returning).Exec(ctx, ids)
+			return err
+		},
+		func(ctx context.Context, tx bun.IDB, ids []uuid.UUID) (int64, error) {
+			q, err := newQuery(tx)
+			if err != nil {
+				return 0, err
+			}
+			if ids != nil {
+				query, args := r.whereInIDs(ids)
+				q.Where(query, args...)
+			}
+			return r.execUpdate(ctx, tx, q)
+		},
+	)
 }
 
 func (r *repo[T]) Upsert(ctx context.Context, record T, criteria ...UpdateCriteria) (T, error) {
@@ -961,6 +1724,7 @@ func (r *repo[T]) Upsert(ctx context.Context, record T, criteria ...UpdateCriter
 }
 
 func (r *repo[T]) UpsertTx(ctx context.Context, tx bun.IDB, record T, criteria ...UpdateCriteria) (T, error) {
+	ctx = r.withOperation(ctx, "Upsert", len(criteria))
 	existing, found, err := r.findExistingRecord(ctx, tx, record)
 	if err != nil {
 		var zero T
@@ -969,6 +1733,10 @@ func (r *repo[T]) UpsertTx(ctx context.Context, tx bun.IDB, record T, criteria .
 
 	if found {
 		r.handlers.SetID(record, r.handlers.GetID(existing))
+		if err := r.checkUnique(ctx, tx, record); err != nil {
+			var zero T
+			return zero, err
+		}
 		return r.UpdateTx(ctx, tx, record, criteria...)
 	}
 
@@ -980,6 +1748,7 @@ func (r *repo[T]) UpsertMany(ctx context.Context, records []T, criteria ...Updat
 }
 
 func (r *repo[T]) UpsertManyTx(ctx context.Context, tx bun.IDB, records []T, criteria ...UpdateCriteria) ([]T, error) {
+	ctx = r.withOperation(ctx, "UpsertMany", len(criteria))
 	var upsertedRecords []T
 
 	for _, record := range records {
@@ -990,6 +1759,9 @@ func (r *repo[T]) UpsertManyTx(ctx context.Context, tx bun.IDB, records []T, cri
 
 		if found {
 			r.handlers.SetID(record, r.handlers.GetID(existing))
+			if err := r.checkUnique(ctx, tx, record); err != nil {
+				return nil, err
+			}
 			updatedRecord, updateErr := r.UpdateTx(ctx, tx, record, criteria...)
 			if updateErr != nil {
 				return nil, r.mapError(updateErr)
@@ -1046,12 +1818,17 @@ func (r *repo[T]) Delete(ctx context.Context, record T) error {
 }
 
 func (r *repo[T]) DeleteTx(ctx context.Context, tx bun.IDB, record T) error {
-	q := tx.NewDelete().Model(record).WherePK()
-
-	q = r.applyDeleteScopes(ctx, q)
+	ctx = r.withOperation(ctx, "Delete", 0)
+	return r.eventTx(ctx, tx, func(ctx context.Context, tx bun.IDB) error {
+		if err := r.recordHistory(ctx, tx, EventDelete, r.handlers.GetID(record)); err != nil {
+			return err
+		}
 
-	_, err := q.Exec(ctx)
-	return r.mapError(err)
+		if _, err := r.execDelete(ctx, tx, record, nil); err != nil {
+			return r.mapError(err)
+		}
+		return r.publishEvents(ctx, tx, EventDelete, nil, record)
+	})
 }
 
 func (r *repo[T]) DeleteMany(ctx context.Context, criteria ...DeleteCriteria) error {
@@ -1059,6 +1836,7 @@ func (r *repo[T]) DeleteMany(ctx context.Context, criteria ...DeleteCriteria) er
 }
 
 func (r *repo[T]) DeleteManyTx(ctx context.Context, tx bun.IDB, criteria ...DeleteCriteria) error {
+	ctx = r.withOperation(ctx, "DeleteMany", len(criteria))
 	return r.DeleteWhereTx(ctx, tx, criteria...)
 }
 
@@ -1067,33 +1845,40 @@ func (r *repo[T]) DeleteWhere(ctx context.Context, criteria ...DeleteCriteria) e
 }
 
 func (r *repo[T]) DeleteWhereTx(ctx context.Context, tx bun.IDB, criteria ...DeleteCriteria) error {
-	if !r.allowFullTableDelete && !hasDeleteCriteria(criteria) {
-		return errors.NewValidation(
-			"repository: unsafe delete prevented",
-			errors.FieldError{
-				Field:   "criteria",
-				Message: "at least one delete criterion is required; use WithAllowFullTableDelete(true) to allow full-table deletes",
-			},
-		)
-	}
+	ctx = r.withOperation(ctx, "DeleteWhere", len(criteria))
+	_, err := r.DeleteWhereCountTx(ctx, tx, criteria...)
+	return err
+}
 
-	record := r.handlers.NewRecord()
-	q := tx.NewDelete().Model(record)
+// CountingDeleter is an optional capability for repositories that report how
+// many rows a delete by criteria removed.
+type CountingDeleter interface {
+	DeleteWhereCount(ctx context.Context, criteria ...DeleteCriteria) (int64, error)
+	DeleteWhereCountTx(ctx context.Context, tx bun.IDB, criteria ...DeleteCriteria) (int64, error)
+}
 
-	q = r.applyDeleteScopes(ctx, q)
+// DeleteWhereCount behaves like DeleteWhere and returns the number of deleted
+// (or soft deleted) rows.
+func (r *repo[T]) DeleteWhereCount(ctx context.Context, criteria ...DeleteCriteria) (int64, error) {
+	return r.DeleteWhereCountTx(ctx, r.db, criteria...)
+}
+
+func (r *repo[T]) DeleteWhereCountTx(ctx context.Context, tx bun.IDB, criteria ...DeleteCriteria) (int64, error) {
+	ctx = r.withOperation(ctx, "DeleteWhereCount", len(criteria))
+	if !r.allowFullTableDelete && !hasDeleteCriteria(criteria) {
+		return 0, fullTableOperationBlockedError("delete", "WithAllowFullTableDelete")
+	}
 
 	if r.allowFullTableDelete && !hasDeleteCriteria(criteria) {
-		q = q.Where("1=1")
+		criteria = []DeleteCriteria{deleteAll}
 	}
 
-	for _, c := range criteria {
-		if c == nil {
-			continue
-		}
-		q.Apply(c)
+	var zero T
+	affected, err := r.execDelete(ctx, tx, zero, criteria)
+	if err != nil {
+		return 0, r.mapError(err)
 	}
-	_, err := q.Exec(ctx)
-	return r.mapError(err)
+	return affected, nil
 }
 
 func (r *repo[T]) ForceDelete(ctx context.Context, record T) error {
@@ -1101,12 +1886,21 @@ func (r *repo[T]) ForceDelete(ctx context.Context, record T) error {
 }
 
 func (r *repo[T]) ForceDeleteTx(ctx context.Context, tx bun.IDB, record T) error {
-	q := tx.NewDelete().Model(record).WherePK().ForceDelete()
+	ctx = r.withOperation(ctx, "ForceDelete", 0)
+	return r.eventTx(ctx, tx, func(ctx context.Context, tx bun.IDB) error {
+		q := tx.NewDelete().Model(record).WherePK().ForceDelete()
 
-	q = r.applyDeleteScopes(ctx, q)
+		q = r.applyDeleteScopes(ctx, q)
 
-	_, err := q.Exec(ctx)
-	return r.mapError(err)
+		if err := r.recordHistory(ctx, tx, EventForceDelete, r.handlers.GetID(record)); err != nil {
+			return err
+		}
+
+		if _, err := q.Exec(ctx); err != nil {
+			return r.mapError(err)
+		}
+		return r.publishEvents(ctx, tx, EventForceDelete, nil, record)
+	})
 }
 
 func (r *repo[T]) TableName() string {
@@ -1232,6 +2026,11 @@ func DetectDriver(db *bun.DB) string {
 	}
 }
 
+// deleteAll matches every row, as bun refuses deletes without a WHERE clause.
+func deleteAll(q *bun.DeleteQuery) *bun.DeleteQuery {
+	return q.Where("1=1")
+}
+
 func hasDeleteCriteria(criteria []DeleteCriteria) bool {
 	for _, c := range criteria {
 		if c != nil {
@@ -1241,6 +2040,18 @@ func hasDeleteCriteria(criteria []DeleteCriteria) bool {
 	return false
 }
 
+func fullTableOperationBlockedError(operation, option string) error {
+	err := errors.NewValidation(
+		fmt.Sprintf("repository: unsafe %s prevented", operation),
+		errors.FieldError{
+			Field:   "criteria",
+			Message: fmt.Sprintf("at least one %s criterion is required; use %s(true) to allow full-table %ss", operation, option, operation),
+		},
+	)
+	err.Source = ErrFullTableOperationBlocked
+	return err
+}
+
 func resolveRecordLookupResolver[T any](cfg *repoConfig) (RecordLookupResolver[T], error) {
 	if cfg == nil || cfg.recordLookupResolver == nil {
 		return nil, nil
@@ -1271,6 +2082,132 @@ func resolveRecordLookupResolver[T any](cfg *repoConfig) (RecordLookupResolver[T
 	)
 }
 
+// resolveDefaultOrder normalizes WithDefaultOrder expressions into
+// table qualified ORDER BY expressions.
+func resolveDefaultOrder(cfg *repoConfig) ([]string, error) {
+	if cfg == nil || len(cfg.defaultOrder) == 0 {
+		return nil, nil
+	}
+
+	var order []string
+	var validationErrors errors.ValidationErrors
+	for _, expr := range cfg.defaultOrder {
+		normalized, ok := normalizeOrderExpr(expr)
+		if !ok {
+			validationErrors = append(validationErrors, errors.FieldError{
+				Field:   "repoOptions.WithDefaultOrder",
+				Message: fmt.Sprintf("invalid order expression %q", expr),
+			})
+			continue
+		}
+		if !strings.Contains(normalized, ".") {
+			normalized = "?TableAlias." + normalized
+		}
+		order = append(order, normalized)
+	}
+
+	if len(validationErrors) > 0 {
+		return order, errors.NewValidation("repository configuration invalid", validationErrors...)
+	}
+	return order, nil
+}
+
+type renderableQuery interface {
+	schema.QueryAppender
+	DB() *bun.DB
+}
+
+func renderQuery(q renderableQuery) (string, error) {
+	b, err := q.AppendQuery(q.DB().Formatter(), nil)
+	if err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
+
+// The helpers below find out whether criteria added clauses to a query. bun
+// does not expose its clause lists, so they render copies of the query that
+// only differ when a list is empty, following how bun renders the clause.
+
+// selectHasOrder reports whether an ORDER BY clause was added to q. bun
+// joins ORDER BY items with ", ", so one more item grows an ordered query by
+// the separator and the item only. If q cannot be rendered it is reported
+// as unordered and the default order is appended, where it acts as a
+// tie-breaker.
+func selectHasOrder(q *bun.SelectQuery) bool {
+	query, err := renderQuery(q.Clone())
+	if err != nil {
+		return false
+	}
+	ordered, err := renderQuery(q.Clone().OrderExpr("1"))
+	return err == nil && len(ordered)-len(query) == len(", 1")
+}
+
+// selectHasWhere reports whether a WHERE condition was added to q. bun drops
+// the separator of the first condition, so AND and OR conditions render alike
+// on a query without conditions. Soft delete conditions added by bun and
+// WherePK are not counted.
+func selectHasWhere(q *bun.SelectQuery) bool {
+	and, err := renderQuery(q.Clone().Where("1=1"))
+	if err != nil {
+		return true
+	}
+	or, err := renderQuery(q.Clone().WhereOr("1=1"))
+	return err != nil || and != or
+}
+
+// selectHasColumns reports whether columns were selected on q explicitly:
+// only then does dropping them change the columns rendered.
+func selectHasColumns(q *bun.SelectQuery) bool {
+	query, err := renderQuery(q.Clone().ColumnExpr("1"))
+	if err != nil {
+		return true
+	}
+	only, err := renderQuery(q.Clone().ExcludeColumn("*").ColumnExpr("1"))
+	return err != nil || query != only
+}
+
+// probeUpdateCriteria applies criteria to throwaway updates of the model and
+// reports whether they assign values and add a WHERE condition, rendered as
+// selectHasWhere does. Without assignments bun sets the model columns, so
+// restricting them changes the query.
+func (r *repo[T]) probeUpdateCriteria(tx bun.IDB, criteria []UpdateCriteria) (hasSet, hasWhere bool, err error) {
+	if r.softDelete != nil {
+		_, criteria = splitSoftDeleteCriteria(criteria, UpdateCriteria(updateDeletedOnly), UpdateCriteria(updateDeletedAlso))
+	}
+	render := func(probe func(q *bun.UpdateQuery) *bun.UpdateQuery) (string, error) {
+		q := tx.NewUpdate().Model(r.handlers.NewRecord())
+		if err := applyCriteria(q, criteria, r.criteriaPolicy); err != nil {
+			return "", err
+		}
+		return renderQuery(probe(q))
+	}
+
+	and, err := render(func(q *bun.UpdateQuery) *bun.UpdateQuery { return q.Where("1=1") })
+	if err != nil {
+		return false, false, err
+	}
+	or, err := render(func(q *bun.UpdateQuery) *bun.UpdateQuery { return q.WhereOr("1=1") })
+	if err != nil {
+		return false, false, err
+	}
+
+	if table := r.modelTable(); table != nil && len(table.Fields) > 1 {
+		column := table.Fields[0].Name
+		only, _ := render(func(q *bun.UpdateQuery) *bun.UpdateQuery { return q.Column(column).Where("1=1") })
+		others, _ := render(func(q *bun.UpdateQuery) *bun.UpdateQuery { return q.ExcludeColumn(column).Where("1=1") })
+		hasSet = only == others
+	}
+	return hasSet, and != or, nil
+}
+
+// querySoftDeletes reports whether bun adds a soft delete condition to the
+// WHERE clause of q.
+func querySoftDeletes(q bun.Query) bool {
+	model, ok := q.GetModel().(bun.TableModel)
+	return ok && model.Table().SoftDeleteField != nil
+}
+
 func validateRepositoryConfig[T any](db *bun.DB, handlers ModelHandlers[T]) error {
 	var validationErrors errors.ValidationErrors
 
@@ -1319,6 +2256,24 @@ func validateRepositoryConfig[T any](db *bun.DB, handlers ModelHandlers[T]) erro
 		}
 	}
 
+	if handlers.GetIdentifiers != nil {
+		columns := handlers.GetIdentifiers()
+		if len(columns) == 0 {
+			validationErrors = append(validationErrors, errors.FieldError{
+				Field:   "handlers.GetIdentifiers",
+				Message: "must return at least one column name",
+			})
+		}
+		for _, column := range columns {
+			if _, ok := normalizeSQLIdentifier(strings.TrimSpace(column)); !ok {
+				validationErrors = append(validationErrors, errors.FieldError{
+					Field:   "handlers.GetIdentifiers",
+					Message: fmt.Sprintf("invalid column name %q", column),
+				})
+			}
+		}
+	}
+
 	if len(validationErrors) > 0 {
 		return errors.NewValidation("repository configuration invalid", validationErrors...)
 	}
@@ -6,6 +6,8 @@ import (
 	stderrors "errors"
 	"fmt"
 	"os"
+	"reflect"
+	"strings"
 	"sync"
 	"testing"
 	"time"
@@ -99,6 +101,47 @@ func newTestUserRepositoryWithoutIdentifierWithConfig(db *bun.DB, dbOpts []Optio
 	return MustNewRepositoryWithConfig[*TestUser](db, handlers, dbOpts, repoOpts...)
 }
 
+// newTestRepository recreates the table of the model T, a pointer to a
+// struct with a UUID primary key, and returns a repository of it.
+func newTestRepository[T any](t *testing.T, repoOpts ...RepoOption) Repository[T] {
+	t.Helper()
+	var model T
+	recreateTestTable(t, model)
+	return NewRepositoryWithConfig[T](db, testModelHandlers[T](), nil, repoOpts...)
+}
+
+// testModelHandlers returns the ModelHandlers of the model T, reading and
+// writing its primary key through the bun table of T.
+func testModelHandlers[T any]() ModelHandlers[T] {
+	typ := reflect.TypeOf((*T)(nil)).Elem().Elem()
+	pk := db.Table(typ).PKs[0]
+	return ModelHandlers[T]{
+		NewRecord: func() T {
+			return reflect.New(typ).Interface().(T)
+		},
+		GetID: func(record T) uuid.UUID {
+			return pk.Value(reflect.ValueOf(record).Elem()).Interface().(uuid.UUID)
+		},
+		SetID: func(record T, id uuid.UUID) {
+			pk.Value(reflect.ValueOf(record).Elem()).Set(reflect.ValueOf(id))
+		},
+	}
+}
+
+// recreateTestTable drops the table of model, if any, and creates it again.
+func recreateTestTable(t *testing.T, model any) {
+	t.Helper()
+	dropTestTable(t, model)
+	_, err := db.NewCreateTable().Model(model).Exec(context.Background())
+	require.NoError(t, err)
+}
+
+func dropTestTable(t *testing.T, model any) {
+	t.Helper()
+	_, err := db.NewDropTable().Model(model).IfExists().Exec(context.Background())
+	require.NoError(t, err)
+}
+
 func newTestCompanyRepository(db *bun.DB) Repository[*TestCompany] {
 	handlers := ModelHandlers[*TestCompany]{
 		NewRecord: func() *TestCompany {
@@ -862,6 +905,13 @@ func TestRepository_DeleteWhere_WithoutCriteriaBlockedByDefault(t *testing.T) {
 	err = userRepo.DeleteWhere(ctx)
 	require.Error(t, err)
 	assert.Contains(t, err.Error(), "unsafe delete prevented")
+	assert.True(t, stderrors.Is(err, ErrFullTableOperationBlocked))
+	assert.True(t, IsFullTableOperationBlocked(err))
+	assert.True(t, goerrors.IsValidation(err))
+
+	err = userRepo.DeleteMany(ctx, nil)
+	require.Error(t, err)
+	assert.True(t, IsFullTableOperationBlocked(err))
 
 	remainingUsers, err := userRepo.Raw(ctx, "SELECT * FROM test_users")
 	require.NoError(t, err)
@@ -891,6 +941,124 @@ func TestRepository_DeleteWhere_WithoutCriteriaAllowedWhenConfigured(t *testing.
 	assert.Empty(t, remainingUsers)
 }
 
+func TestRepository_DeleteWhereCount(t *testing.T) {
+	setupTestData(t)
+
+	ctx := context.Background()
+	userRepo := newTestUserRepository(db)
+
+	companyID := uuid.New()
+	_, err := userRepo.CreateMany(ctx, []*TestUser{
+		{Name: "User One", Email: "user1@example.com", CompanyID: companyID},
+		{Name: "User Two", Email: "user2@example.com", CompanyID: companyID},
+		{Name: "User Three", Email: "user3@example.com", CompanyID: uuid.New()},
+	})
+	require.NoError(t, err)
+
+	deleted, err := userRepo.(CountingDeleter).DeleteWhereCount(ctx, DeleteBy("company_id", "=", companyID.String()))
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), deleted)
+
+	deleted, err = userRepo.(CountingDeleter).DeleteWhereCount(ctx, DeleteBy("company_id", "=", companyID.String()))
+	require.NoError(t, err)
+	assert.Equal(t, int64(0), deleted)
+
+	_, err = userRepo.(CountingDeleter).DeleteWhereCount(ctx)
+	assert.True(t, IsFullTableOperationBlocked(err))
+}
+
+func TestRepository_UpdateWhere(t *testing.T) {
+	setupTestData(t)
+
+	ctx := context.Background()
+	userRepo := newTestUserRepository(db)
+
+	companyID := uuid.New()
+	_, err := userRepo.CreateMany(ctx, []*TestUser{
+		{Name: "User One", Email: "user1@example.com", CompanyID: companyID},
+		{Name: "User Two", Email: "user2@example.com", CompanyID: companyID},
+		{Name: "User Three", Email: "user3@example.com", CompanyID: uuid.New()},
+	})
+	require.NoError(t, err)
+
+	affected, err := userRepo.(BulkUpdater).UpdateWhere(ctx,
+		UpdateSetColumn("name", "Renamed"),
+		UpdateBy("company_id", "=", companyID.String()),
+	)
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), affected)
+
+	renamed, err := userRepo.Count(ctx, SelectBy("name", "=", "Renamed"))
+	require.NoError(t, err)
+	assert.Equal(t, 2, renamed)
+
+	_, err = userRepo.(BulkUpdater).UpdateWhere(ctx, UpdateBy("company_id", "=", companyID.String()))
+	require.Error(t, err)
+	assert.True(t, goerrors.IsValidation(err))
+}
+
+func TestRepository_UpdateWhere_WithoutCriteriaBlockedByDefault(t *testing.T) {
+	setupTestData(t)
+
+	ctx := context.Background()
+	userRepo := newTestUserRepository(db)
+
+	_, err := userRepo.Create(ctx, &TestUser{Name: "User One", Email: "user1@example.com", CompanyID: uuid.New()})
+	require.NoError(t, err)
+
+	_, err = userRepo.(BulkUpdater).UpdateWhere(ctx, UpdateSetColumn("name", "Renamed"))
+	require.Error(t, err)
+	assert.True(t, IsFullTableOperationBlocked(err))
+	assert.Contains(t, err.Error(), "unsafe update prevented")
+
+	allowedRepo := newTestUserRepositoryWithConfig(db, nil, WithAllowFullTableUpdate(true))
+	affected, err := allowedRepo.(BulkUpdater).UpdateWhere(ctx, UpdateSetColumn("name", "Renamed"))
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), affected)
+}
+
+func TestRepository_UpdateWhere_ValuesDoNotCountAsWhere(t *testing.T) {
+	setupTestData(t)
+
+	ctx := context.Background()
+	userRepo := newTestUserRepository(db)
+
+	_, err := userRepo.(BulkUpdater).UpdateWhere(ctx, UpdateSetColumn("name", "x) WHERE (1=1"))
+	require.Error(t, err)
+	assert.True(t, IsFullTableOperationBlocked(err))
+}
+
+func TestQueryProbes(t *testing.T) {
+	q := db.NewSelect().Model((*TestUser)(nil))
+	assert.False(t, selectHasWhere(q))
+	assert.False(t, selectHasColumns(q))
+	assert.True(t, selectHasWhere(q.Clone().Apply(SelectBy("name", "=", "x"))))
+	assert.True(t, selectHasColumns(q.Clone().Apply(SelectColumns("id"))))
+	assert.False(t, selectHasOrder(q))
+	assert.True(t, selectHasOrder(q.Clone().Apply(OrderBy("name ASC"))))
+	assert.True(t, selectHasWhere(q.Clone().Apply(SelectOr(SelectBy("name", "=", "x")))))
+	assert.False(t, selectHasWhere(q.Clone().Apply(SelectColumns("id"), OrderBy("name ASC"))))
+
+	probe := newTestUserRepository(db).(*repo[*TestUser])
+	hasSet, hasWhere, err := probe.probeUpdateCriteria(db, nil)
+	require.NoError(t, err)
+	assert.False(t, hasSet)
+	assert.False(t, hasWhere)
+
+	hasSet, hasWhere, err = probe.probeUpdateCriteria(db, []UpdateCriteria{
+		UpdateSetColumn("name", "x"),
+		UpdateBy("name", "=", "y"),
+	})
+	require.NoError(t, err)
+	assert.True(t, hasSet)
+	assert.True(t, hasWhere)
+
+	hasSet, hasWhere, err = probe.probeUpdateCriteria(db, []UpdateCriteria{UpdateBy("name", "=", "y")})
+	require.NoError(t, err)
+	assert.False(t, hasSet)
+	assert.True(t, hasWhere)
+}
+
 func TestRepository_TransactionCommit(t *testing.T) {
 	setupTestData(t)
 
@@ -979,6 +1147,47 @@ func TestRepository_Raw(t *testing.T) {
 	}
 }
 
+func TestRepository_RawScanAndExec(t *testing.T) {
+	setupTestData(t)
+
+	ctx := context.Background()
+	userRepo := newTestUserRepository(db)
+	companyID := uuid.New()
+
+	for _, email := range []string{"a@example.com", "b@example.com", "c@example.com"} {
+		_, err := userRepo.Create(ctx, &TestUser{Name: "Raw", Email: email, CompanyID: companyID})
+		require.NoError(t, err)
+	}
+
+	querier, ok := userRepo.(RawQuerier)
+	require.True(t, ok)
+
+	var count int
+	require.NoError(t, querier.RawScan(ctx, &count, "SELECT COUNT(*) FROM test_users WHERE company_id = ?", companyID))
+	assert.Equal(t, 3, count)
+
+	type companyRow struct {
+		CompanyID uuid.UUID `bun:"company_id"`
+		Users     int       `bun:"users"`
+	}
+	var rows []companyRow
+	require.NoError(t, querier.RawScan(ctx, &rows, "SELECT company_id, COUNT(*) AS users FROM test_users GROUP BY company_id"))
+	assert.Equal(t, []companyRow{{CompanyID: companyID, Users: 3}}, rows)
+
+	res, err := querier.RawExec(ctx, "UPDATE test_users SET name = ? WHERE email <> ?", "Renamed", "a@example.com")
+	require.NoError(t, err)
+	affected, err := res.RowsAffected()
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), affected)
+
+	var name string
+	err = querier.RawScan(ctx, &name, "SELECT name FROM test_users WHERE email = ?", "missing@example.com")
+	assert.True(t, IsRecordNotFound(err))
+
+	_, err = querier.RawExec(ctx, "UPDATE test_users SET email = ?", "same@example.com")
+	assert.True(t, IsDuplicatedKey(err))
+}
+
 func TestRepository_Upsert(t *testing.T) {
 	setupTestData(t)
 
@@ -1173,6 +1382,71 @@ func TestRepository_Upsert_UsesRecordLookupResolverWhenIDAndIdentifierMissing(t
 	assert.Equal(t, payload.Email, upserted.Email)
 }
 
+func TestRepository_Upsert_UsesUniqueColumnsWhenIDAndIdentifierMissing(t *testing.T) {
+	setupTestData(t)
+
+	ctx := context.Background()
+	userRepo := newTestUserRepositoryWithoutIdentifierWithConfig(db, nil, WithUpsertLookupUniqueColumns())
+
+	existing, err := userRepo.Create(ctx, &TestUser{
+		Name:      "Unique User",
+		Email:     "unique@example.com",
+		CompanyID: uuid.New(),
+	})
+	require.NoError(t, err)
+
+	upserted, err := userRepo.Upsert(ctx, &TestUser{
+		Name:      "Unique User Renamed",
+		Email:     existing.Email,
+		CompanyID: existing.CompanyID,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, existing.ID, upserted.ID, "matched on the unique email column")
+	assert.Equal(t, "Unique User Renamed", upserted.Name)
+
+	inserted, err := userRepo.Upsert(ctx, &TestUser{Name: "Other", Email: "other@example.com", CompanyID: uuid.New()})
+	require.NoError(t, err)
+	assert.NotEqual(t, existing.ID, inserted.ID)
+
+	withoutOption := newTestUserRepositoryWithoutIdentifierWithConfig(db, nil)
+	_, err = withoutOption.Upsert(ctx, &TestUser{Name: "Duplicate", Email: existing.Email, CompanyID: uuid.New()})
+	require.Error(t, err, "without the option the insert hits the unique constraint")
+}
+
+func TestNewRepository_RepoOptions(t *testing.T) {
+	setupTestData(t)
+
+	ctx := context.Background()
+	handlers := ModelHandlers[*TestUser]{
+		NewRecord: func() *TestUser { return &TestUser{} },
+		GetID:     func(record *TestUser) uuid.UUID { return record.ID },
+		SetID:     func(record *TestUser, id uuid.UUID) { record.ID = id },
+	}
+	userRepo := MustNewRepository(db, handlers,
+		WithRecordLookupResolver(func(record *TestUser) []SelectCriteria {
+			return []SelectCriteria{SelectBy("name", "=", record.Name)}
+		}),
+		WithDefaultListPagination(1, 0),
+	)
+
+	existing, err := userRepo.Create(ctx, &TestUser{Name: "Resolved", Email: "resolved.old@example.com", CompanyID: uuid.New()})
+	require.NoError(t, err)
+	upserted, err := userRepo.Upsert(ctx, &TestUser{Name: "Resolved", Email: "resolved.new@example.com", CompanyID: existing.CompanyID})
+	require.NoError(t, err)
+	assert.Equal(t, existing.ID, upserted.ID)
+
+	_, err = userRepo.Create(ctx, &TestUser{Name: "Other", Email: "other@example.com", CompanyID: uuid.New()})
+	require.NoError(t, err)
+	users, total, err := userRepo.List(ctx)
+	require.NoError(t, err)
+	assert.Len(t, users, 1, "repo options override the legacy list defaults")
+	assert.Equal(t, 2, total)
+
+	assert.Panics(t, func() {
+		MustNewRepository(db, handlers, WithRecordLookupResolver(func(record *TestCompany) []SelectCriteria { return nil }))
+	})
+}
+
 func TestRepository_GetOrCreate_UsesRecordLookupResolver(t *testing.T) {
 	setupTestData(t)
 
@@ -1362,7 +1636,7 @@ func TestRepository_RecordLookupResolver_ErrorPropagation(t *testing.T) {
 	})
 	require.Error(t, err)
 	assert.False(t, IsRecordNotFound(err))
-	assert.True(t, goerrors.IsCategory(err, CategoryDatabase))
+	assert.True(t, goerrors.IsCategory(err, CategoryCriteriaInvalid))
 }
 
 func TestRepository_RecordLookupResolver_DeterministicSelection(t *testing.T) {
@@ -1629,7 +1903,7 @@ func TestRepository_RecordLookupResolver_RespectsSelectScopes(t *testing.T) {
 	_, err = userRepo.CreateTx(ctx, db, otherUser)
 	require.NoError(t, err)
 
-	updatedAt := time.Now()
+	updatedAt := time.Now().UTC().Truncate(time.Microsecond)
 	scopeCtx := WithScopeData(ctx, tenantScope, tenantCompany.ID)
 	upserted, err := userRepo.Upsert(scopeCtx, &TestUser{
 		Name:      tenantUser.Name,
@@ -1752,6 +2026,82 @@ func TestRepository_MustNewRepositoryWithConfig_RecordLookupResolverTypeMismatch
 	})
 }
 
+func TestRepository_GetBy(t *testing.T) {
+	setupTestData(t)
+	ctx := context.Background()
+	repo := newTestUserRepository(db)
+
+	companyA := uuid.New()
+	companyB := uuid.New()
+	_, err := repo.CreateMany(ctx, []*TestUser{
+		{Name: "Alice", Email: "alice@a.example.com", CompanyID: companyA},
+		{Name: "Alice", Email: "alice@b.example.com", CompanyID: companyB},
+	})
+	require.NoError(t, err)
+
+	user, err := repo.(FieldGetter[*TestUser]).GetBy(ctx, map[string]any{"company_id": companyB, "name": "Alice"})
+	require.NoError(t, err)
+	assert.Equal(t, "alice@b.example.com", user.Email)
+
+	_, err = repo.(FieldGetter[*TestUser]).GetBy(ctx, map[string]any{"company_id": companyA, "name": "Bob"})
+	assert.True(t, IsRecordNotFound(err))
+
+	_, err = repo.(FieldGetter[*TestUser]).GetBy(ctx, map[string]any{"name": "Alice"}, SelectBy("email", "=", "missing@example.com"))
+	assert.True(t, IsRecordNotFound(err))
+
+	_, err = repo.(FieldGetter[*TestUser]).GetBy(ctx, nil)
+	require.Error(t, err)
+
+	_, err = repo.(FieldGetter[*TestUser]).GetBy(ctx, map[string]any{"name;DROP TABLE test_users": "x"})
+	require.Error(t, err)
+
+	_, err = repo.(FieldGetter[*TestUser]).GetBy(ctx, map[string]any{"missing": "x"})
+	require.Error(t, err)
+	assert.True(t, IsCriteriaInvalid(err))
+}
+
+func TestRepository_GetByIDs(t *testing.T) {
+	setupTestData(t)
+
+	ctx := context.Background()
+	userRepo := newTestUserRepository(db)
+
+	users, err := userRepo.CreateMany(ctx, []*TestUser{
+		{Name: "User One", Email: "user1@example.com", CompanyID: uuid.New()},
+		{Name: "User Two", Email: "user2@example.com", CompanyID: uuid.New()},
+		{Name: "User Three", Email: "user3@example.com", CompanyID: uuid.New()},
+	})
+	require.NoError(t, err)
+
+	ids := []string{users[2].ID.String(), users[0].ID.String(), users[1].ID.String(), users[0].ID.String()}
+	records, err := userRepo.(BatchGetter[*TestUser]).GetByIDs(ctx, ids)
+	require.NoError(t, err)
+	require.Len(t, records, 3)
+	assert.Equal(t, "User Three", records[0].Name)
+	assert.Equal(t, "User One", records[1].Name)
+	assert.Equal(t, "User Two", records[2].Name)
+
+	missingID := uuid.New().String()
+	records, err = userRepo.(BatchGetter[*TestUser]).GetByIDs(ctx, []string{users[1].ID.String(), missingID, "not-a-uuid"})
+	require.Error(t, err)
+	require.Len(t, records, 1)
+	assert.Equal(t, "User Two", records[0].Name)
+	assert.True(t, IsPartialResult(err))
+
+	var partial *PartialResultError
+	require.True(t, stderrors.As(err, &partial))
+	assert.Equal(t, []string{missingID, "not-a-uuid"}, partial.MissingIDs)
+
+	otherID := uuid.New().String()
+	_, err = userRepo.(BatchGetter[*TestUser]).GetByIDs(ctx, []string{otherID, "bad", users[0].ID.String(), missingID, otherID})
+	require.True(t, stderrors.As(err, &partial))
+	assert.Equal(t, []string{otherID, "bad", missingID}, partial.MissingIDs, "missing IDs keep input order")
+
+	records, err = userRepo.(BatchGetter[*TestUser]).GetByIDs(ctx, nil)
+	require.NoError(t, err)
+	assert.Empty(t, records)
+}
+
 func TestRepository_List(t *testing.T) {
 	setupTestData(t)
 
@@ -1931,6 +2281,53 @@ func TestRepository_List_DefaultPaginationConfigured(t *testing.T) {
 	assert.Equal(t, 30, total, "ListTx total should remain full count")
 }
 
+func TestRepository_List_DefaultOrder(t *testing.T) {
+	setupTestData(t)
+
+	ctx := context.Background()
+	userRepo := newTestUserRepositoryWithConfig(db, nil, WithDefaultOrder("name DESC"))
+
+	now := time.Now()
+	for _, name := range []string{"Bravo", "Alpha", "Charlie"} {
+		_, err := userRepo.Create(ctx, &TestUser{
+			Name:      name,
+			Email:     strings.ToLower(name) + "@example.com",
+			CompanyID: uuid.New(),
+			CreatedAt: now,
+			UpdatedAt: now,
+		})
+		require.NoError(t, err)
+	}
+
+	users, _, err := userRepo.List(ctx)
+	require.NoError(t, err)
+	require.Len(t, users, 3)
+	assert.Equal(t, []string{"Charlie", "Bravo", "Alpha"}, []string{users[0].Name, users[1].Name, users[2].Name})
+
+	users, _, err = userRepo.List(ctx, OrderBy("name ASC"))
+	require.NoError(t, err)
+	require.Len(t, users, 3)
+	assert.Equal(t, "Alpha", users[0].Name, "Explicit ordering should replace the default order")
+
+	assert.False(t, selectHasOrder(db.NewSelect().Model((*TestUser)(nil))))
+	assert.True(t, selectHasOrder(db.NewSelect().Model((*TestUser)(nil)).Apply(OrderBy("name"))))
+}
+
+func TestRepository_WithDefaultOrder_InvalidExpressionValidation(t *testing.T) {
+	userRepo := NewRepositoryWithConfig[*TestUser](db, ModelHandlers[*TestUser]{
+		NewRecord: func() *TestUser { return &TestUser{} },
+		GetID:     func(record *TestUser) uuid.UUID { return record.ID },
+		SetID:     func(record *TestUser, id uuid.UUID) { record.ID = id },
+	}, nil, WithDefaultOrder("name; DROP TABLE test_users"))
+
+	validator, ok := userRepo.(Validator)
+	require.True(t, ok)
+
+	err := validator.Validate()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "invalid order expression")
+}
+
 func TestRepository_SetDefaultListPagination(t *testing.T) {
 	setupTestData(t)
 
@@ -0,0 +1,403 @@
+// Package repositoryctx holds the context values read by the repository
+// package: tenant, actor, transaction, commit hooks, debug flag, select
+// scopes, relation loading, idempotency keys, stale read tracking, the
+// running repository operation and query statistics. Keys are unexported
+// types, so values cannot collide with other packages, and every value has a
+// setter returning a derived context and a getter.
+//
+// The repository package exposes the same setters for convenience, e.g.
+// repository.WithScopes, which store their values through this package.
+package repositoryctx
+
+import (
+	"context"
+	"maps"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/uptrace/bun"
+)
+
+type (
+	tenantKey           struct{}
+	actorKey            struct{}
+	txKey               struct{}
+	debugKey            struct{}
+	scopesKey           struct{}
+	withoutRelationsKey struct{}
+	idempotencyKey      struct{}
+	staleReadKey        struct{}
+	commitHooksKey      struct{}
+	operationKey        struct{}
+	queryStatsKey       struct{}
+)
+
+// WithTenant returns a context carrying the tenant identifier. An empty
+// tenant leaves ctx unchanged.
+func WithTenant(ctx context.Context, tenant string) context.Context {
+	return withString(ctx, tenantKey{}, tenant)
+}
+
+// Tenant returns the tenant identifier stored in ctx, if any.
+func Tenant(ctx context.Context) (string, bool) {
+	return stringValue(ctx, tenantKey{})
+}
+
+// WithActor returns a context carrying the identifier of the user or service
+// performing the operation. An empty actor leaves ctx unchanged.
+func WithActor(ctx context.Context, actor string) context.Context {
+	return withString(ctx, actorKey{}, actor)
+}
+
+// Actor returns the actor identifier stored in ctx, if any.
+func Actor(ctx context.Context) (string, bool) {
+	return stringValue(ctx, actorKey{})
+}
+
+// WithTx returns a context carrying the transaction in progress, so code that
+// only receives a context can join it. repository.Aggregate sets it for its
+// steps. A nil tx leaves ctx unchanged.
+func WithTx(ctx context.Context, tx bun.IDB) context.Context {
+	if tx == nil {
+		return ctx
+	}
+	return context.WithValue(ctx, txKey{}, tx)
+}
+
+// Tx returns the transaction stored in ctx, if any.
+func Tx(ctx context.Context) (bun.IDB, bool) {
+	if ctx == nil {
+		return nil, false
+	}
+	tx, ok := ctx.Value(txKey{}).(bun.IDB)
+	return tx, ok
+}
+
+// TxOr returns the transaction stored in ctx, or db when there is none.
+func TxOr(ctx context.Context, db bun.IDB) bun.IDB {
+	if tx, ok := Tx(ctx); ok {
+		return tx
+	}
+	return db
+}
+
+// CommitHooks collects the functions to run once a transaction commits.
+type CommitHooks struct {
+	mu    sync.Mutex
+	hooks []func(context.Context)
+}
+
+// Run runs and clears the collected hooks in registration order.
+func (h *CommitHooks) Run(ctx context.Context) {
+	h.mu.Lock()
+	hooks := h.hooks
+	h.hooks = nil
+	h.mu.Unlock()
+
+	for _, hook := range hooks {
+		hook(ctx)
+	}
+}
+
+// Len returns the number of collected hooks.
+func (h *CommitHooks) Len() int {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return len(h.hooks)
+}
+
+// WithCommitHooks returns a context collecting commit hooks, see OnCommit,
+// with the collector. The owner of the transaction runs the hooks after a
+// successful commit and drops them on rollback. When ctx already collects
+// hooks it is returned unchanged with the existing collector and owned is
+// false: nested transactions defer to the outermost one.
+func WithCommitHooks(ctx context.Context) (_ context.Context, hooks *CommitHooks, owned bool) {
+	if hooks := commitHooks(ctx); hooks != nil {
+		return ctx, hooks, false
+	}
+	hooks = &CommitHooks{}
+	return context.WithValue(ctx, commitHooksKey{}, hooks), hooks, true
+}
+
+// OnCommit registers hook to run after the transaction of ctx commits. It
+// reports false, without registering, when ctx does not collect commit
+// hooks.
+func OnCommit(ctx context.Context, hook func(context.Context)) bool {
+	hooks := commitHooks(ctx)
+	if hooks == nil || hook == nil {
+		return false
+	}
+	hooks.mu.Lock()
+	hooks.hooks = append(hooks.hooks, hook)
+	hooks.mu.Unlock()
+	return true
+}
+
+// CollectsCommitHooks reports whether OnCommit hooks registered with ctx
+// will run, that is whether ctx belongs to a transaction whose owner runs
+// them after the commit.
+func CollectsCommitHooks(ctx context.Context) bool {
+	return commitHooks(ctx) != nil
+}
+
+func commitHooks(ctx context.Context) *CommitHooks {
+	if ctx == nil {
+		return nil
+	}
+	hooks, _ := ctx.Value(commitHooksKey{}).(*CommitHooks)
+	return hooks
+}
+
+// WithDebug returns a context that flags its queries for debugging. The
+// repository query logger reports flagged queries regardless of its slow
+// query threshold.
+func WithDebug(ctx context.Context) context.Context {
+	return context.WithValue(ctx, debugKey{}, true)
+}
+
+// Debug reports whether ctx was prepared with WithDebug.
+func Debug(ctx context.Context) bool {
+	return boolValue(ctx, debugKey{})
+}
+
+// OperationInfo describes the repository method that issued a query. The
+// repository attaches it to the context before querying, so query hooks and
+// loggers can tell which call produced the SQL they see.
+type OperationInfo struct {
+	// Entity is the type name of the repository model, e.g. "User".
+	Entity string `json:"entity"`
+	// Operation is the repository method, e.g. "GetByID" or "UpdateMany".
+	Operation string `json:"operation"`
+	// CriteriaCount is the number of criteria passed to the method.
+	CriteriaCount int `json:"criteria_count"`
+	// Caller is the file:line of the first frame outside the repository.
+	Caller string `json:"caller,omitempty"`
+}
+
+// WithOperation returns a context carrying info.
+func WithOperation(ctx context.Context, info OperationInfo) context.Context {
+	return context.WithValue(ctx, operationKey{}, info)
+}
+
+// Operation returns the repository operation stored in ctx, if any.
+func Operation(ctx context.Context) (OperationInfo, bool) {
+	if ctx == nil {
+		return OperationInfo{}, false
+	}
+	info, ok := ctx.Value(operationKey{}).(OperationInfo)
+	return info, ok
+}
+
+// Scopes is the scope selection carried by a context. Names are applied on
+// top of the repository scope defaults unless SkipDefaults is set.
+type Scopes struct {
+	SkipDefaults bool           `json:"skip_defaults,omitempty"`
+	All          []string       `json:"all,omitempty"`
+	Select       []string       `json:"select,omitempty"`
+	Update       []string       `json:"update,omitempty"`
+	Insert       []string       `json:"insert,omitempty"`
+	Delete       []string       `json:"delete,omitempty"`
+	Data         map[string]any `json:"data,omitempty"`
+}
+
+// Clone returns a deep copy of s; Data values are copied by reference.
+func (s Scopes) Clone() Scopes {
+	s.All = slices.Clone(s.All)
+	s.Select = slices.Clone(s.Select)
+	s.Update = slices.Clone(s.Update)
+	s.Insert = slices.Clone(s.Insert)
+	s.Delete = slices.Clone(s.Delete)
+	s.Data = maps.Clone(s.Data)
+	return s
+}
+
+// WithScopes returns a context carrying a copy of scopes, replacing any
+// scope selection already stored in ctx.
+func WithScopes(ctx context.Context, scopes Scopes) context.Context {
+	return context.WithValue(ctx, scopesKey{}, scopes.Clone())
+}
+
+// ScopesFrom returns a copy of the scope selection stored in ctx, if any.
+func ScopesFrom(ctx context.Context) (Scopes, bool) {
+	if ctx == nil {
+		return Scopes{}, false
+	}
+	scopes, ok := ctx.Value(scopesKey{}).(Scopes)
+	if !ok {
+		return Scopes{}, false
+	}
+	return scopes.Clone(), true
+}
+
+// WithoutRelations returns a context that skips the relations a repository
+// loads by default.
+func WithoutRelations(ctx context.Context) context.Context {
+	return context.WithValue(ctx, withoutRelationsKey{}, true)
+}
+
+// RelationsDisabled reports whether ctx was prepared with WithoutRelations.
+func RelationsDisabled(ctx context.Context) bool {
+	return boolValue(ctx, withoutRelationsKey{})
+}
+
+// WithIdempotencyKey returns a context carrying an idempotency key for
+// Create calls. Surrounding whitespace is trimmed and an empty key leaves
+// ctx unchanged.
+func WithIdempotencyKey(ctx context.Context, key string) context.Context {
+	return withString(ctx, idempotencyKey{}, key)
+}
+
+// IdempotencyKey returns the idempotency key stored in ctx, if any.
+func IdempotencyKey(ctx context.Context) (string, bool) {
+	return stringValue(ctx, idempotencyKey{})
+}
+
+// WithStaleReadTracking returns a context that records whether a read made
+// with it was served from a stale read cache, see MarkStaleRead.
+func WithStaleReadTracking(ctx context.Context) context.Context {
+	return context.WithValue(ctx, staleReadKey{}, new(atomic.Bool))
+}
+
+// MarkStaleRead flags ctx as having served a stale read. It is a no-op
+// unless ctx was prepared with WithStaleReadTracking.
+func MarkStaleRead(ctx context.Context) {
+	if flag := staleReadFlag(ctx); flag != nil {
+		flag.Store(true)
+	}
+}
+
+// IsStaleRead reports whether MarkStaleRead was called on ctx.
+func IsStaleRead(ctx context.Context) bool {
+	flag := staleReadFlag(ctx)
+	return flag != nil && flag.Load()
+}
+
+// QueryStats sums the queries run with a context prepared by
+// WithStatsRecorder.
+type QueryStats struct {
+	Queries  int           `json:"queries"`
+	Duration time.Duration `json:"duration"`
+	// RowsScanned counts the rows read by SELECT queries.
+	RowsScanned int64 `json:"rows_scanned"`
+}
+
+type queryStatsRecorder struct {
+	mu     sync.Mutex
+	stats  QueryStats
+	parent *queryStatsRecorder
+}
+
+// WithStatsRecorder returns a context collecting QueryStats for the queries
+// run with it, see RecordQuery. Recording into a context that already
+// collects stats starts a new, nested count; the outer one still sees every
+// query.
+func WithStatsRecorder(ctx context.Context) context.Context {
+	return context.WithValue(ctx, queryStatsKey{}, &queryStatsRecorder{parent: statsRecorder(ctx)})
+}
+
+// RecordQuery adds a query to the stats of ctx. It is a no-op unless ctx was
+// prepared with WithStatsRecorder.
+func RecordQuery(ctx context.Context, duration time.Duration, rowsScanned int64) {
+	for recorder := statsRecorder(ctx); recorder != nil; recorder = recorder.parent {
+		recorder.mu.Lock()
+		recorder.stats.Queries++
+		recorder.stats.Duration += duration
+		recorder.stats.RowsScanned += rowsScanned
+		recorder.mu.Unlock()
+	}
+}
+
+// Stats returns the stats recorded so far on ctx.
+func Stats(ctx context.Context) (QueryStats, bool) {
+	recorder := statsRecorder(ctx)
+	if recorder == nil {
+		return QueryStats{}, false
+	}
+	recorder.mu.Lock()
+	defer recorder.mu.Unlock()
+	return recorder.stats, true
+}
+
+// DescribeContext returns the repository values stored in ctx keyed by name,
+// for debug logging. Unset values are omitted and the transaction is only
+// reported as present.
+func DescribeContext(ctx context.Context) map[string]any {
+	out := map[string]any{}
+	if tenant, ok := Tenant(ctx); ok {
+		out["tenant"] = tenant
+	}
+	if actor, ok := Actor(ctx); ok {
+		out["actor"] = actor
+	}
+	if _, ok := Tx(ctx); ok {
+		out["tx"] = true
+	}
+	if hooks := commitHooks(ctx); hooks != nil {
+		out["commit_hooks"] = hooks.Len()
+	}
+	if Debug(ctx) {
+		out["debug"] = true
+	}
+	if scopes, ok := ScopesFrom(ctx); ok {
+		out["scopes"] = scopes
+	}
+	if RelationsDisabled(ctx) {
+		out["without_relations"] = true
+	}
+	if key, ok := IdempotencyKey(ctx); ok {
+		out["idempotency_key"] = key
+	}
+	if flag := staleReadFlag(ctx); flag != nil {
+		out["stale_read"] = flag.Load()
+	}
+	if info, ok := Operation(ctx); ok {
+		out["operation"] = info
+	}
+	if stats, ok := Stats(ctx); ok {
+		out["query_stats"] = stats
+	}
+	return out
+}
+
+func statsRecorder(ctx context.Context) *queryStatsRecorder {
+	if ctx == nil {
+		return nil
+	}
+	recorder, _ := ctx.Value(queryStatsKey{}).(*queryStatsRecorder)
+	return recorder
+}
+
+func staleReadFlag(ctx context.Context) *atomic.Bool {
+	if ctx == nil {
+		return nil
+	}
+	flag, _ := ctx.Value(staleReadKey{}).(*atomic.Bool)
+	return flag
+}
+
+func withString(ctx context.Context, key any, value string) context.Context {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return ctx
+	}
+	return context.WithValue(ctx, key, value)
+}
+
+func stringValue(ctx context.Context, key any) (string, bool) {
+	if ctx == nil {
+		return "", false
+	}
+	value, ok := ctx.Value(key).(string)
+	return value, ok && value != ""
+}
+
+func boolValue(ctx context.Context, key any) bool {
+	if ctx == nil {
+		return false
+	}
+	value, _ := ctx.Value(key).(bool)
+	return value
+}
@@ -0,0 +1,135 @@
+package repositoryctx
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/uptrace/bun"
+	"github.com/uptrace/bun/dialect/pgdialect"
+)
+
+func TestContextValues(t *testing.T) {
+	ctx := context.Background()
+	assert.Empty(t, DescribeContext(ctx))
+
+	_, ok := Tenant(ctx)
+	assert.False(t, ok)
+	assert.Equal(t, ctx, WithTenant(ctx, "  "), "empty values leave ctx unchanged")
+
+	db := bun.NewDB(&sql.DB{}, pgdialect.New())
+	ctx = WithTenant(ctx, " acme ")
+	ctx = WithActor(ctx, "user-1")
+	ctx = WithTx(ctx, db)
+	ctx = WithDebug(ctx)
+	ctx = WithoutRelations(ctx)
+	ctx = WithIdempotencyKey(ctx, "key-1")
+	operation := OperationInfo{Entity: "User", Operation: "List", CriteriaCount: 2}
+	ctx = WithOperation(ctx, operation)
+
+	tenant, ok := Tenant(ctx)
+	assert.True(t, ok)
+	assert.Equal(t, "acme", tenant)
+	actor, _ := Actor(ctx)
+	assert.Equal(t, "user-1", actor)
+	tx, ok := Tx(ctx)
+	assert.True(t, ok)
+	assert.Same(t, db, tx)
+	assert.Same(t, db, TxOr(ctx, nil))
+	assert.Nil(t, TxOr(context.Background(), nil))
+	assert.True(t, Debug(ctx))
+	assert.True(t, RelationsDisabled(ctx))
+	key, _ := IdempotencyKey(ctx)
+	assert.Equal(t, "key-1", key)
+	info, ok := Operation(ctx)
+	assert.True(t, ok)
+	assert.Equal(t, operation, info)
+
+	assert.Equal(t, map[string]any{
+		"tenant":            "acme",
+		"actor":             "user-1",
+		"tx":                true,
+		"debug":             true,
+		"without_relations": true,
+		"idempotency_key":   "key-1",
+		"operation":         operation,
+	}, DescribeContext(ctx))
+}
+
+func TestScopes(t *testing.T) {
+	ctx := context.Background()
+	_, ok := ScopesFrom(ctx)
+	assert.False(t, ok)
+
+	scopes := Scopes{Select: []string{"tenant"}, Data: map[string]any{"tenant": "acme"}}
+	ctx = WithScopes(ctx, scopes)
+	scopes.Select[0] = "mutated"
+	scopes.Data["tenant"] = "mutated"
+
+	stored, ok := ScopesFrom(ctx)
+	assert.True(t, ok)
+	assert.Equal(t, []string{"tenant"}, stored.Select)
+	assert.Equal(t, "acme", stored.Data["tenant"])
+
+	stored.Select[0] = "mutated"
+	again, _ := ScopesFrom(ctx)
+	assert.Equal(t, []string{"tenant"}, again.Select, "getters return copies")
+	assert.Equal(t, again, DescribeContext(ctx)["scopes"])
+}
+
+func TestStaleReadTracking(t *testing.T) {
+	ctx := context.Background()
+	MarkStaleRead(ctx)
+	assert.False(t, IsStaleRead(ctx))
+
+	ctx = WithStaleReadTracking(ctx)
+	assert.Equal(t, false, DescribeContext(ctx)["stale_read"])
+	MarkStaleRead(ctx)
+	assert.True(t, IsStaleRead(ctx))
+	assert.Equal(t, true, DescribeContext(ctx)["stale_read"])
+}
+
+func TestCommitHooks(t *testing.T) {
+	ctx := context.Background()
+	assert.False(t, OnCommit(ctx, func(context.Context) {}), "no collector")
+
+	ctx, hooks, owned := WithCommitHooks(ctx)
+	assert.True(t, owned)
+
+	nested, nestedHooks, owned := WithCommitHooks(ctx)
+	assert.False(t, owned, "nested transactions share the outermost hooks")
+	assert.Same(t, hooks, nestedHooks)
+	assert.Equal(t, ctx, nested)
+
+	var calls []string
+	assert.True(t, OnCommit(ctx, func(context.Context) { calls = append(calls, "first") }))
+	assert.True(t, OnCommit(nested, func(context.Context) { calls = append(calls, "second") }))
+	assert.Equal(t, 2, DescribeContext(ctx)["commit_hooks"])
+
+	hooks.Run(ctx)
+	assert.Equal(t, []string{"first", "second"}, calls)
+	assert.Equal(t, 0, hooks.Len(), "hooks run once")
+	hooks.Run(ctx)
+	assert.Len(t, calls, 2)
+}
+
+func TestQueryStats(t *testing.T) {
+	ctx := context.Background()
+	RecordQuery(ctx, time.Second, 1)
+	_, ok := Stats(ctx)
+	assert.False(t, ok)
+
+	outer := WithStatsRecorder(ctx)
+	RecordQuery(outer, time.Millisecond, 2)
+	inner := WithStatsRecorder(outer)
+	RecordQuery(inner, time.Millisecond, 3)
+
+	stats, ok := Stats(inner)
+	assert.True(t, ok)
+	assert.Equal(t, QueryStats{Queries: 1, Duration: time.Millisecond, RowsScanned: 3}, stats)
+	stats, _ = Stats(outer)
+	assert.Equal(t, QueryStats{Queries: 2, Duration: 2 * time.Millisecond, RowsScanned: 5}, stats)
+	assert.Equal(t, stats, DescribeContext(outer)["query_stats"])
+}
@@ -0,0 +1,224 @@
+// Package repositoryotel exports repository queries and connection pool
+// statistics to OpenTelemetry. It is built on the query hooks and pool
+// sampler of the repository package, which does not depend on OpenTelemetry.
+package repositoryotel
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	repository "github.com/goliatone/go-repository-bun"
+	"github.com/goliatone/go-repository-bun/repositoryctx"
+	"github.com/uptrace/bun"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const instrumentationName = "github.com/goliatone/go-repository-bun"
+
+// WithTracing registers a query hook that wraps every query run through the
+// bun.DB in a span carrying the entity, operation, table, rows affected and
+// error category. The SQL text is not recorded since it contains bound values.
+// The hook is registered once per provider and bun.DB.
+func WithTracing(provider trace.TracerProvider) repository.Option {
+	return func(db *bun.DB) {
+		if provider == nil {
+			return
+		}
+		repository.WithQueryHooks(&tracingQueryHook{
+			tracer: provider.Tracer(instrumentationName),
+			key:    providerKey(provider),
+		})(db)
+	}
+}
+
+// WithMetrics registers a query hook that records the repository.operations
+// counter and the repository.operation.duration histogram (seconds) for every
+// query run through the bun.DB, labeled by entity, operation, table and
+// error category. The hook is registered once per provider and bun.DB.
+func WithMetrics(provider metric.MeterProvider) repository.Option {
+	return func(db *bun.DB) {
+		if provider == nil {
+			return
+		}
+		hook, err := newMetricsQueryHook(provider.Meter(instrumentationName))
+		if err != nil {
+			repository.ReportQueryHookError(db, hook, err)
+			return
+		}
+		hook.key = providerKey(provider)
+		repository.WithQueryHooks(hook)(db)
+	}
+}
+
+// providerKey identifies a provider, so registering the same provider twice
+// does not duplicate spans or metrics while distinct providers each get a
+// hook.
+func providerKey(provider any) string {
+	return fmt.Sprintf("%T:%p", provider, provider)
+}
+
+func eventAttributes(info repository.QueryEventInfo) []attribute.KeyValue {
+	return []attribute.KeyValue{
+		attribute.String("db.system", info.Driver),
+		attribute.String("db.operation.name", info.Operation),
+		attribute.String("db.collection.name", info.Table),
+		attribute.String("repository.entity", info.Entity),
+	}
+}
+
+type tracingQueryHook struct {
+	tracer trace.Tracer
+	key    string
+}
+
+// spanKey holds the span started by a hook, since the context reaching
+// AfterQuery carries the innermost span when several hooks trace a query.
+type spanKey struct {
+	hook *tracingQueryHook
+}
+
+func (h *tracingQueryHook) QueryHookKey() string {
+	return h.key
+}
+
+func (h *tracingQueryHook) BeforeQuery(ctx context.Context, event *bun.QueryEvent) context.Context {
+	info := repository.DescribeQueryEvent(event)
+	name := info.Operation
+	if info.Table != "" {
+		name += " " + info.Table
+	}
+	attrs := eventAttributes(info)
+	if operation, ok := repositoryctx.Operation(ctx); ok {
+		attrs = append(attrs,
+			attribute.String("repository.method", operation.Operation),
+			attribute.Int("repository.criteria_count", operation.CriteriaCount),
+			attribute.String("repository.caller", operation.Caller),
+		)
+	}
+	ctx, span := h.tracer.Start(ctx, name,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(attrs...),
+	)
+	return context.WithValue(ctx, spanKey{h}, span)
+}
+
+func (h *tracingQueryHook) AfterQuery(ctx context.Context, event *bun.QueryEvent) {
+	span, ok := ctx.Value(spanKey{h}).(trace.Span)
+	if !ok || !span.IsRecording() {
+		return
+	}
+	defer span.End()
+
+	if event.Result != nil {
+		if rows, err := event.Result.RowsAffected(); err == nil {
+			span.SetAttributes(attribute.Int64("repository.rows_affected", rows))
+		}
+	}
+
+	if category := repository.DescribeQueryEvent(event).ErrorCategory; category != "" {
+		span.SetAttributes(attribute.String("repository.error_category", category))
+		span.RecordError(event.Err)
+		span.SetStatus(codes.Error, event.Err.Error())
+	}
+}
+
+type metricsQueryHook struct {
+	operations metric.Int64Counter
+	duration   metric.Float64Histogram
+	key        string
+}
+
+func newMetricsQueryHook(meter metric.Meter) (*metricsQueryHook, error) {
+	operations, err := meter.Int64Counter("repository.operations",
+		metric.WithDescription("Number of repository queries"),
+	)
+	if err != nil {
+		return nil, err
+	}
+	duration, err := meter.Float64Histogram("repository.operation.duration",
+		metric.WithDescription("Duration of repository queries"),
+		metric.WithUnit("s"),
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &metricsQueryHook{operations: operations, duration: duration}, nil
+}
+
+func (h *metricsQueryHook) QueryHookKey() string {
+	return h.key
+}
+
+func (h *metricsQueryHook) BeforeQuery(ctx context.Context, _ *bun.QueryEvent) context.Context {
+	return ctx
+}
+
+func (h *metricsQueryHook) AfterQuery(ctx context.Context, event *bun.QueryEvent) {
+	info := repository.DescribeQueryEvent(event)
+	attrs := append(eventAttributes(info),
+		attribute.String("repository.error_category", info.ErrorCategory),
+	)
+	set := metric.WithAttributes(attrs...)
+
+	h.operations.Add(ctx, 1, set)
+	h.duration.Record(ctx, time.Since(event.StartTime).Seconds(), set)
+}
+
+type poolMetricsSink struct {
+	connections  metric.Int64Gauge
+	maxOpen      metric.Int64Gauge
+	waits        metric.Int64Counter
+	waitDuration metric.Float64Counter
+}
+
+// NewPoolMetricsSink returns a repository.PoolStatsSink for
+// repository.StartPoolSampler recording the repository.pool.connections gauge
+// (by state), repository.pool.max_open gauge, repository.pool.waits counter
+// and repository.pool.wait.duration counter (seconds).
+func NewPoolMetricsSink(provider metric.MeterProvider) (repository.PoolStatsSink, error) {
+	meter := provider.Meter(instrumentationName)
+	connections, err := meter.Int64Gauge("repository.pool.connections",
+		metric.WithDescription("Connections in the pool by state"),
+	)
+	if err != nil {
+		return nil, err
+	}
+	maxOpen, err := meter.Int64Gauge("repository.pool.max_open",
+		metric.WithDescription("Maximum number of open connections"),
+	)
+	if err != nil {
+		return nil, err
+	}
+	waits, err := meter.Int64Counter("repository.pool.waits",
+		metric.WithDescription("Number of waits for a free connection"),
+	)
+	if err != nil {
+		return nil, err
+	}
+	waitDuration, err := meter.Float64Counter("repository.pool.wait.duration",
+		metric.WithDescription("Time spent waiting for a free connection"),
+		metric.WithUnit("s"),
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &poolMetricsSink{
+		connections:  connections,
+		maxOpen:      maxOpen,
+		waits:        waits,
+		waitDuration: waitDuration,
+	}, nil
+}
+
+func (s *poolMetricsSink) RecordPoolStats(ctx context.Context, sample repository.PoolSample) {
+	stats := sample.Stats
+	s.connections.Record(ctx, int64(stats.InUse), metric.WithAttributes(attribute.String("state", "in_use")))
+	s.connections.Record(ctx, int64(stats.Idle), metric.WithAttributes(attribute.String("state", "idle")))
+	s.maxOpen.Record(ctx, int64(stats.MaxOpenConnections))
+	s.waits.Add(ctx, sample.WaitCount)
+	s.waitDuration.Add(ctx, sample.WaitDuration.Seconds())
+}
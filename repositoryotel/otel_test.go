@@ -0,0 +1,177 @@
+package repositoryotel
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	repository "github.com/goliatone/go-repository-bun"
+	"github.com/google/uuid"
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/uptrace/bun"
+	"github.com/uptrace/bun/dialect/sqlitedialect"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+type TestUser struct {
+	bun.BaseModel `bun:"table:test_users,alias:u"`
+
+	ID    uuid.UUID `bun:"id,pk,notnull"`
+	Name  string    `bun:"name,notnull"`
+	Email string    `bun:"email,notnull,unique"`
+}
+
+func newTestDB(t *testing.T) *bun.DB {
+	t.Helper()
+	sqldb, err := sql.Open("sqlite3", ":memory:")
+	require.NoError(t, err)
+	sqldb.SetMaxOpenConns(1)
+	t.Cleanup(func() {
+		require.NoError(t, sqldb.Close())
+	})
+	db := bun.NewDB(sqldb, sqlitedialect.New())
+	_, err = db.NewCreateTable().Model((*TestUser)(nil)).Exec(context.Background())
+	require.NoError(t, err)
+	return db
+}
+
+func newTestRepository(db *bun.DB, opts ...repository.Option) repository.Repository[*TestUser] {
+	return repository.NewRepositoryWithOptions(db, repository.ModelHandlers[*TestUser]{
+		NewRecord: func() *TestUser { return &TestUser{} },
+		GetID:     func(record *TestUser) uuid.UUID { return record.ID },
+		SetID:     func(record *TestUser, id uuid.UUID) { record.ID = id },
+	}, opts...)
+}
+
+func TestWithTracing_RecordsQuerySpans(t *testing.T) {
+	ctx := context.Background()
+	testDB := newTestDB(t)
+
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+
+	repo := newTestRepository(testDB, WithTracing(provider))
+	// registering twice must not duplicate spans
+	repo = newTestRepository(testDB, WithTracing(provider))
+
+	_, err := repo.Create(ctx, &TestUser{Name: "Alice", Email: "alice@example.com"})
+	require.NoError(t, err)
+	_, err = repo.GetByID(ctx, uuid.NewString())
+	require.Error(t, err)
+	_, err = repo.Get(ctx, repository.SelectRawProcessor(func(q *bun.SelectQuery) *bun.SelectQuery {
+		return q.Where("?TableAlias.missing_column = ?", "x")
+	}))
+	require.Error(t, err)
+
+	spans := recorder.Ended()
+	require.Len(t, spans, 3)
+
+	insert := spans[0]
+	assert.Equal(t, "INSERT test_users", insert.Name())
+	assert.Contains(t, insert.Attributes(), attribute.String("repository.entity", "TestUser"))
+	assert.Contains(t, insert.Attributes(), attribute.Int64("repository.rows_affected", 1))
+	assert.Contains(t, insert.Attributes(), attribute.String("db.system", "sqlite"))
+	assert.Contains(t, insert.Attributes(), attribute.String("repository.method", "Create"))
+
+	notFound := spans[1]
+	assert.Equal(t, "SELECT test_users", notFound.Name())
+	assert.Equal(t, codes.Unset, notFound.Status().Code)
+
+	invalid := spans[2]
+	assert.Equal(t, codes.Error, invalid.Status().Code)
+	assert.Contains(t, invalid.Attributes(), attribute.String("repository.error_category", string(repository.CategoryCriteriaInvalid)))
+}
+
+func TestWithTracing_SeveralProviders(t *testing.T) {
+	ctx := context.Background()
+	testDB := newTestDB(t)
+
+	first := tracetest.NewSpanRecorder()
+	second := tracetest.NewSpanRecorder()
+	repo := newTestRepository(testDB,
+		WithTracing(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(first))),
+		WithTracing(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(second))),
+	)
+
+	_, err := repo.Create(ctx, &TestUser{Name: "Alice", Email: "alice@example.com"})
+	require.NoError(t, err)
+
+	assert.Len(t, first.Ended(), 1)
+	assert.Len(t, second.Ended(), 1)
+}
+
+func TestWithMetrics_RecordsOperations(t *testing.T) {
+	ctx := context.Background()
+	testDB := newTestDB(t)
+
+	reader := sdkmetric.NewManualReader()
+	provider := sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader))
+
+	repo := newTestRepository(testDB, WithMetrics(provider))
+	_, err := repo.Create(ctx, &TestUser{Name: "Alice", Email: "alice@example.com"})
+	require.NoError(t, err)
+	_, _, err = repo.List(ctx)
+	require.NoError(t, err)
+
+	var data metricdata.ResourceMetrics
+	require.NoError(t, reader.Collect(ctx, &data))
+	require.Len(t, data.ScopeMetrics, 1)
+
+	metrics := make(map[string]metricdata.Metrics)
+	for _, m := range data.ScopeMetrics[0].Metrics {
+		metrics[m.Name] = m
+	}
+
+	counter, ok := metrics["repository.operations"].Data.(metricdata.Sum[int64])
+	require.True(t, ok)
+	var total int64
+	for _, point := range counter.DataPoints {
+		entity, _ := point.Attributes.Value("repository.entity")
+		assert.Equal(t, "TestUser", entity.AsString())
+		total += point.Value
+	}
+	// insert, select and count
+	assert.Equal(t, int64(3), total)
+
+	histogram, ok := metrics["repository.operation.duration"].Data.(metricdata.Histogram[float64])
+	require.True(t, ok)
+	assert.NotEmpty(t, histogram.DataPoints)
+}
+
+func TestNewPoolMetricsSink(t *testing.T) {
+	ctx := context.Background()
+	testDB := newTestDB(t)
+
+	reader := sdkmetric.NewManualReader()
+	sink, err := NewPoolMetricsSink(sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader)))
+	require.NoError(t, err)
+
+	stop := repository.StartPoolSampler(ctx, testDB, 5*time.Millisecond, sink)
+	require.Eventually(t, func() bool {
+		var data metricdata.ResourceMetrics
+		require.NoError(t, reader.Collect(ctx, &data))
+		return len(data.ScopeMetrics) == 1 && len(data.ScopeMetrics[0].Metrics) == 4
+	}, time.Second, 5*time.Millisecond)
+	stop()
+
+	var data metricdata.ResourceMetrics
+	require.NoError(t, reader.Collect(ctx, &data))
+	names := []string{}
+	for _, m := range data.ScopeMetrics[0].Metrics {
+		names = append(names, m.Name)
+	}
+	assert.ElementsMatch(t, []string{
+		"repository.pool.connections",
+		"repository.pool.max_open",
+		"repository.pool.waits",
+		"repository.pool.wait.duration",
+	}, names)
+}
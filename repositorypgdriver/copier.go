@@ -0,0 +1,118 @@
+// Package repositorypgdriver streams repository BulkLoad rows with COPY FROM
+// on connections of bun's pgdriver. It is a package of its own so the
+// repository package does not link pgdriver into applications using lib/pq.
+package repositorypgdriver
+
+import (
+	"bufio"
+	"context"
+	"database/sql/driver"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	repository "github.com/goliatone/go-repository-bun"
+	"github.com/uptrace/bun"
+	"github.com/uptrace/bun/driver/pgdriver"
+)
+
+// Copier is a repository.BulkCopier that sends rows with pgdriver.CopyFrom,
+// in the PostgreSQL text format.
+type Copier struct{}
+
+var _ repository.BulkCopier = Copier{}
+
+// NewCopier returns a Copier.
+func NewCopier() Copier {
+	return Copier{}
+}
+
+// WithBulkCopy makes BulkLoad stream rows with a Copier. The bun.DB must use
+// pgdriver.
+func WithBulkCopy() repository.RepoOption {
+	return repository.WithBulkCopier(NewCopier())
+}
+
+// CopyFrom streams rows to query on conn. Rows are encoded while the
+// database reads them, so loads are not buffered in memory.
+func (Copier) CopyFrom(ctx context.Context, conn bun.Conn, query string, rows [][]any) error {
+	pr, pw := io.Pipe()
+	go func() {
+		pw.CloseWithError(writeRows(pw, rows))
+	}()
+	_, err := pgdriver.CopyFrom(ctx, conn, pr, query)
+	// unblocks the writer when the copy stopped reading early
+	_ = pr.Close()
+	return err
+}
+
+// writeRows writes rows to w in the COPY text format: tab separated columns,
+// one row per line and \N for NULL.
+func writeRows(w io.Writer, rows [][]any) error {
+	bw := bufio.NewWriter(w)
+	var line []byte
+	for _, row := range rows {
+		line = line[:0]
+		for i, value := range row {
+			if i > 0 {
+				line = append(line, '\t')
+			}
+			var err error
+			if line, err = appendValue(line, value); err != nil {
+				return err
+			}
+		}
+		line = append(line, '\n')
+		if _, err := bw.Write(line); err != nil {
+			return err
+		}
+	}
+	return bw.Flush()
+}
+
+func appendValue(b []byte, value any) ([]byte, error) {
+	v, err := driver.DefaultParameterConverter.ConvertValue(value)
+	if err != nil {
+		return nil, fmt.Errorf("repositorypgdriver: encode %T: %w", value, err)
+	}
+	switch v := v.(type) {
+	case nil:
+		return append(b, `\N`...), nil
+	case int64:
+		return strconv.AppendInt(b, v, 10), nil
+	case float64:
+		switch {
+		case math.IsInf(v, 1):
+			return append(b, "Infinity"...), nil
+		case math.IsInf(v, -1):
+			return append(b, "-Infinity"...), nil
+		}
+		return strconv.AppendFloat(b, v, 'g', -1, 64), nil
+	case bool:
+		if v {
+			return append(b, 't'), nil
+		}
+		return append(b, 'f'), nil
+	case []byte:
+		// bytea hex format, with the backslash escaped
+		b = append(b, `\\x`...)
+		return hex.AppendEncode(b, v), nil
+	case string:
+		return appendText(b, v), nil
+	case time.Time:
+		return append(b, v.Format("2006-01-02 15:04:05.999999999Z07:00")...), nil
+	default:
+		return nil, fmt.Errorf("repositorypgdriver: unsupported value %T", value)
+	}
+}
+
+// textEscaper escapes the characters the COPY text format reserves.
+var textEscaper = strings.NewReplacer(`\`, `\\`, "\t", `\t`, "\n", `\n`, "\r", `\r`)
+
+func appendText(b []byte, s string) []byte {
+	return append(b, textEscaper.Replace(s)...)
+}
@@ -0,0 +1,94 @@
+package repositorypgdriver
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"math"
+	"os"
+	"testing"
+	"time"
+
+	repository "github.com/goliatone/go-repository-bun"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/uptrace/bun"
+	"github.com/uptrace/bun/dialect/pgdialect"
+	"github.com/uptrace/bun/driver/pgdriver"
+)
+
+func TestWriteRows(t *testing.T) {
+	id := uuid.MustParse("6f1c2a4e-0d7b-4d55-8b8e-5f0a1c9d2e3b")
+	at := time.Date(2024, 5, 1, 12, 30, 0, 1000, time.UTC)
+
+	var buf bytes.Buffer
+	require.NoError(t, writeRows(&buf, [][]any{
+		{id, "tab\there", nil, int64(42), true},
+		{"back\\slash\nnew line", []byte{0xde, 0xad}, 1.5, math.Inf(-1), at},
+	}))
+
+	assert.Equal(t,
+		"6f1c2a4e-0d7b-4d55-8b8e-5f0a1c9d2e3b\ttab\\there\t\\N\t42\tt\n"+
+			"back\\\\slash\\nnew line\t\\\\xdead\t1.5\t-Infinity\t2024-05-01 12:30:00.000001Z\n",
+		buf.String())
+}
+
+func TestWriteRows_UnsupportedValue(t *testing.T) {
+	err := writeRows(&bytes.Buffer{}, [][]any{{struct{}{}}})
+	assert.Error(t, err)
+}
+
+type copyItem struct {
+	bun.BaseModel `bun:"table:repositorypgdriver_copy_items,alias:rci"`
+
+	ID        uuid.UUID `bun:"id,pk,type:uuid"`
+	Name      string    `bun:"name,notnull"`
+	Notes     string    `bun:"notes"`
+	CreatedAt time.Time `bun:"created_at,nullzero,notnull,default:current_timestamp"`
+}
+
+// TestCopier_BulkLoad loads rows with pgdriver COPY into the PostgreSQL
+// database in REPOSITORY_TEST_POSTGRES_DSN.
+func TestCopier_BulkLoad(t *testing.T) {
+	dsn := os.Getenv("REPOSITORY_TEST_POSTGRES_DSN")
+	if dsn == "" {
+		t.Skip("REPOSITORY_TEST_POSTGRES_DSN is not set")
+	}
+	ctx := context.Background()
+
+	pgDB := bun.NewDB(sql.OpenDB(pgdriver.NewConnector(pgdriver.WithDSN(dsn))), pgdialect.New())
+	t.Cleanup(func() { _ = pgDB.Close() })
+
+	_, err := pgDB.NewDropTable().Model((*copyItem)(nil)).IfExists().Exec(ctx)
+	require.NoError(t, err)
+	_, err = pgDB.NewCreateTable().Model((*copyItem)(nil)).Exec(ctx)
+	require.NoError(t, err)
+
+	items := repository.NewRepositoryWithConfig(pgDB, repository.ModelHandlers[*copyItem]{
+		NewRecord: func() *copyItem { return &copyItem{} },
+		GetID:     func(record *copyItem) uuid.UUID { return record.ID },
+		SetID:     func(record *copyItem, id uuid.UUID) { record.ID = id },
+	}, nil, WithBulkCopy())
+
+	loader := items.(repository.BulkLoader[*copyItem])
+	loaded, err := loader.BulkLoad(ctx, []*copyItem{
+		{Name: "a", Notes: "line\none\ttab"},
+		{Name: "b", CreatedAt: time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)},
+	})
+	require.NoError(t, err)
+	assert.EqualValues(t, 2, loaded)
+
+	stored, total, err := items.List(ctx, repository.OrderBy("name ASC"))
+	require.NoError(t, err)
+	require.Equal(t, 2, total)
+	assert.Equal(t, "line\none\ttab", stored[0].Notes)
+	assert.False(t, stored[0].CreatedAt.IsZero(), "created_at gets its default")
+	assert.True(t, stored[1].CreatedAt.Equal(time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)))
+
+	_, err = loader.BulkLoad(ctx, []*copyItem{{ID: stored[0].ID, Name: "dup"}})
+	assert.True(t, repository.IsDuplicatedKey(err))
+	count, err := items.Count(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 2, count, "a failed copy is rolled back")
+}
@@ -0,0 +1,156 @@
+// Package repositoryprom exports repository queries and connection pool
+// statistics as Prometheus metrics. It is built on the query hooks and pool
+// sampler of the repository package, which does not depend on Prometheus.
+package repositoryprom
+
+import (
+	"context"
+	stderrors "errors"
+	"fmt"
+	"time"
+
+	repository "github.com/goliatone/go-repository-bun"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/uptrace/bun"
+)
+
+// MetricsHook is a bun query hook that exports Prometheus metrics for every
+// query run through the bun.DB:
+//
+//   - repository_operations_total{entity,operation,table}
+//   - repository_operation_errors_total{entity,operation,table,category}
+//   - repository_operation_duration_seconds{entity,operation,table}
+//
+// Register it with repository.WithQueryHooks. It is also a
+// repository.PoolStatsSink for repository.StartPoolSampler, exporting:
+//
+//   - repository_pool_connections{state}
+//   - repository_pool_max_open_connections
+//   - repository_pool_waits_total
+//   - repository_pool_wait_duration_seconds_total
+type MetricsHook struct {
+	operations *prometheus.CounterVec
+	errors     *prometheus.CounterVec
+	duration   *prometheus.HistogramVec
+
+	poolConnections  *prometheus.GaugeVec
+	poolMaxOpen      prometheus.Gauge
+	poolWaits        prometheus.Counter
+	poolWaitDuration prometheus.Counter
+}
+
+// NewMetricsHook creates a MetricsHook and registers its collectors with
+// registerer, or prometheus.DefaultRegisterer when nil. Collectors that are
+// already registered are reused, so several bun.DB instances can share them.
+func NewMetricsHook(registerer prometheus.Registerer) (*MetricsHook, error) {
+	if registerer == nil {
+		registerer = prometheus.DefaultRegisterer
+	}
+
+	labels := []string{"entity", "operation", "table"}
+	operations, err := registerCollector(registerer, prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "repository_operations_total",
+		Help: "Number of repository queries.",
+	}, labels))
+	if err != nil {
+		return nil, err
+	}
+	errorsTotal, err := registerCollector(registerer, prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "repository_operation_errors_total",
+		Help: "Number of failed repository queries by error category.",
+	}, append(labels, "category")))
+	if err != nil {
+		return nil, err
+	}
+	duration, err := registerCollector(registerer, prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "repository_operation_duration_seconds",
+		Help:    "Duration of repository queries.",
+		Buckets: prometheus.DefBuckets,
+	}, labels))
+	if err != nil {
+		return nil, err
+	}
+
+	hook := &MetricsHook{operations: operations, errors: errorsTotal, duration: duration}
+	if err := hook.registerPoolCollectors(registerer); err != nil {
+		return nil, err
+	}
+	return hook, nil
+}
+
+func (h *MetricsHook) registerPoolCollectors(registerer prometheus.Registerer) error {
+	var err error
+	if h.poolConnections, err = registerCollector(registerer, prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "repository_pool_connections",
+		Help: "Connections in the pool by state.",
+	}, []string{"state"})); err != nil {
+		return err
+	}
+	if h.poolMaxOpen, err = registerCollector(registerer, prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "repository_pool_max_open_connections",
+		Help: "Maximum number of open connections.",
+	})); err != nil {
+		return err
+	}
+	if h.poolWaits, err = registerCollector(registerer, prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "repository_pool_waits_total",
+		Help: "Number of waits for a free connection.",
+	})); err != nil {
+		return err
+	}
+	h.poolWaitDuration, err = registerCollector(registerer, prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "repository_pool_wait_duration_seconds_total",
+		Help: "Time spent waiting for a free connection.",
+	}))
+	return err
+}
+
+func registerCollector[C prometheus.Collector](registerer prometheus.Registerer, collector C) (C, error) {
+	if err := registerer.Register(collector); err != nil {
+		var registered prometheus.AlreadyRegisteredError
+		if stderrors.As(err, &registered) {
+			if existing, ok := registered.ExistingCollector.(C); ok {
+				return existing, nil
+			}
+		}
+		return collector, err
+	}
+	return collector, nil
+}
+
+// QueryHookKey identifies the hook by its collectors, so hooks sharing the
+// collectors of one registerer are registered once per bun.DB while hooks of
+// distinct registerers each record queries.
+func (h *MetricsHook) QueryHookKey() string {
+	return fmt.Sprintf("%p", h.operations)
+}
+
+func (h *MetricsHook) BeforeQuery(ctx context.Context, _ *bun.QueryEvent) context.Context {
+	return ctx
+}
+
+func (h *MetricsHook) AfterQuery(_ context.Context, event *bun.QueryEvent) {
+	info := repository.DescribeQueryEvent(event)
+	labels := prometheus.Labels{
+		"entity":    info.Entity,
+		"operation": info.Operation,
+		"table":     info.Table,
+	}
+
+	h.operations.With(labels).Inc()
+	h.duration.With(labels).Observe(time.Since(event.StartTime).Seconds())
+
+	if info.ErrorCategory != "" {
+		labels["category"] = info.ErrorCategory
+		h.errors.With(labels).Inc()
+	}
+}
+
+func (h *MetricsHook) RecordPoolStats(_ context.Context, sample repository.PoolSample) {
+	stats := sample.Stats
+	h.poolConnections.WithLabelValues("in_use").Set(float64(stats.InUse))
+	h.poolConnections.WithLabelValues("idle").Set(float64(stats.Idle))
+	h.poolMaxOpen.Set(float64(stats.MaxOpenConnections))
+	h.poolWaits.Add(float64(sample.WaitCount))
+	h.poolWaitDuration.Add(sample.WaitDuration.Seconds())
+}
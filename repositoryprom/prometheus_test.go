@@ -0,0 +1,124 @@
+package repositoryprom
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	repository "github.com/goliatone/go-repository-bun"
+	"github.com/google/uuid"
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/uptrace/bun"
+	"github.com/uptrace/bun/dialect/sqlitedialect"
+)
+
+type TestUser struct {
+	bun.BaseModel `bun:"table:test_users,alias:u"`
+
+	ID    uuid.UUID `bun:"id,pk,notnull"`
+	Name  string    `bun:"name,notnull"`
+	Email string    `bun:"email,notnull,unique"`
+}
+
+func newTestDB(t *testing.T) *bun.DB {
+	t.Helper()
+	sqldb, err := sql.Open("sqlite3", ":memory:")
+	require.NoError(t, err)
+	sqldb.SetMaxOpenConns(1)
+	t.Cleanup(func() {
+		require.NoError(t, sqldb.Close())
+	})
+	db := bun.NewDB(sqldb, sqlitedialect.New())
+	_, err = db.NewCreateTable().Model((*TestUser)(nil)).Exec(context.Background())
+	require.NoError(t, err)
+	return db
+}
+
+func TestNewMetricsHook_RecordsOperations(t *testing.T) {
+	ctx := context.Background()
+	testDB := newTestDB(t)
+
+	registry := prometheus.NewRegistry()
+	hook, err := NewMetricsHook(registry)
+	require.NoError(t, err)
+
+	repo := repository.NewRepositoryWithOptions(testDB, repository.ModelHandlers[*TestUser]{
+		NewRecord: func() *TestUser { return &TestUser{} },
+		GetID:     func(record *TestUser) uuid.UUID { return record.ID },
+		SetID:     func(record *TestUser, id uuid.UUID) { record.ID = id },
+	}, repository.WithQueryHooks(hook))
+	_, err = repo.Create(ctx, &TestUser{Name: "Alice", Email: "alice@example.com"})
+	require.NoError(t, err)
+	_, err = repo.Create(ctx, &TestUser{Name: "Alice", Email: "alice@example.com"})
+	require.Error(t, err)
+	_, _, err = repo.List(ctx)
+	require.NoError(t, err)
+
+	assert.InDelta(t, 2, testutil.ToFloat64(hook.operations.WithLabelValues("TestUser", "INSERT", "test_users")), 0)
+	// List runs the select and the count query
+	assert.InDelta(t, 2, testutil.ToFloat64(hook.operations.WithLabelValues("TestUser", "SELECT", "test_users")), 0)
+	assert.InDelta(t, 1, testutil.ToFloat64(
+		hook.errors.WithLabelValues("TestUser", "INSERT", "test_users", string(repository.CategoryDatabaseDuplicate)),
+	), 0)
+	assert.Equal(t, 1, testutil.CollectAndCount(hook.errors))
+	assert.Positive(t, testutil.CollectAndCount(hook.duration))
+}
+
+func TestNewMetricsHook_ReusesRegisteredCollectors(t *testing.T) {
+	registry := prometheus.NewRegistry()
+
+	first, err := NewMetricsHook(registry)
+	require.NoError(t, err)
+	second, err := NewMetricsHook(registry)
+	require.NoError(t, err)
+
+	assert.Same(t, first.operations, second.operations)
+	assert.Same(t, first.duration, second.duration)
+}
+
+func TestMetricsHook_SeveralRegisterers(t *testing.T) {
+	ctx := context.Background()
+	testDB := newTestDB(t)
+
+	shared := prometheus.NewRegistry()
+	first, err := NewMetricsHook(shared)
+	require.NoError(t, err)
+	// a second hook on the same registerer shares the collectors of the first
+	same, err := NewMetricsHook(shared)
+	require.NoError(t, err)
+	other, err := NewMetricsHook(prometheus.NewRegistry())
+	require.NoError(t, err)
+
+	repo := repository.NewRepositoryWithOptions(testDB, repository.ModelHandlers[*TestUser]{
+		NewRecord: func() *TestUser { return &TestUser{} },
+		GetID:     func(record *TestUser) uuid.UUID { return record.ID },
+		SetID:     func(record *TestUser, id uuid.UUID) { record.ID = id },
+	}, repository.WithQueryHooks(first, same, other))
+	_, err = repo.Create(ctx, &TestUser{Name: "Alice", Email: "alice@example.com"})
+	require.NoError(t, err)
+
+	assert.InDelta(t, 1, testutil.ToFloat64(first.operations.WithLabelValues("TestUser", "INSERT", "test_users")), 0)
+	assert.InDelta(t, 1, testutil.ToFloat64(other.operations.WithLabelValues("TestUser", "INSERT", "test_users")), 0)
+}
+
+func TestMetricsHook_RecordPoolStats(t *testing.T) {
+	ctx := context.Background()
+	testDB := newTestDB(t)
+	repository.ConfigurePool(testDB, repository.PoolConfig{MaxOpen: 5})
+
+	hook, err := NewMetricsHook(prometheus.NewRegistry())
+	require.NoError(t, err)
+
+	stop := repository.StartPoolSampler(ctx, testDB, 5*time.Millisecond, hook)
+	require.Eventually(t, func() bool {
+		return testutil.ToFloat64(hook.poolMaxOpen) == 5
+	}, time.Second, 5*time.Millisecond)
+	stop()
+
+	assert.Equal(t, 2, testutil.CollectAndCount(hook.poolConnections))
+}
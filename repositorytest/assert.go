@@ -0,0 +1,170 @@
+package repositorytest
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	stderrors "errors"
+	"reflect"
+	"sort"
+	"strings"
+
+	repository "github.com/goliatone/go-repository-bun"
+	"github.com/uptrace/bun"
+	"github.com/uptrace/bun/dialect/pgdialect"
+)
+
+// TestingT is the subset of testing.TB used by the assertion helpers.
+type TestingT interface {
+	Helper()
+	Errorf(format string, args ...any)
+}
+
+// AssertCalled fails unless method, or its Tx variant, was called.
+func (m *MockRepository[T]) AssertCalled(t TestingT, method string) bool {
+	t.Helper()
+	if len(m.CallsTo(method)) == 0 {
+		t.Errorf("repositorytest: expected a call to %s, got %s", method, m.methods())
+		return false
+	}
+	return true
+}
+
+// AssertNotCalled fails if method, or its Tx variant, was called.
+func (m *MockRepository[T]) AssertNotCalled(t TestingT, method string) bool {
+	t.Helper()
+	if calls := m.CallsTo(method); len(calls) > 0 {
+		t.Errorf("repositorytest: expected no call to %s, got %d", method, len(calls))
+		return false
+	}
+	return true
+}
+
+// AssertCreated fails unless a record equal to expected was passed to
+// Create, CreateMany or their Tx variants.
+func (m *MockRepository[T]) AssertCreated(t TestingT, expected T) bool {
+	t.Helper()
+	for _, record := range m.createdRecords() {
+		if reflect.DeepEqual(record, expected) {
+			return true
+		}
+	}
+	t.Errorf("repositorytest: expected record to be created: %+v", expected)
+	return false
+}
+
+// Created returns the records passed to Create, CreateMany and their Tx
+// variants, in call order.
+func (m *MockRepository[T]) Created() []T {
+	return m.createdRecords()
+}
+
+func (m *MockRepository[T]) createdRecords() []T {
+	records := []T{}
+	for _, call := range m.Calls() {
+		switch call.Method {
+		case "Create", "CreateTx":
+			records = append(records, call.Args[0].(T))
+		case "CreateMany", "CreateManyTx":
+			records = append(records, call.Args[0].([]T)...)
+		}
+	}
+	return records
+}
+
+// AssertUpdatedColumns fails unless an Update, UpdateMany or UpdateWhere
+// call (or Tx variant) restricted or assigned exactly columns, e.g. with
+// repository.UpdateColumns or repository.UpdateSetColumn. Order is ignored.
+func (m *MockRepository[T]) AssertUpdatedColumns(t TestingT, columns ...string) bool {
+	t.Helper()
+	expected := sortedColumns(columns)
+	seen := []string{}
+	for _, call := range m.Calls() {
+		criteria, ok := updateCriteria(call)
+		if !ok {
+			continue
+		}
+		got := sortedColumns(UpdatedColumns(criteria...))
+		if reflect.DeepEqual(got, expected) {
+			return true
+		}
+		seen = append(seen, call.Method+"["+strings.Join(got, ",")+"]")
+	}
+	t.Errorf("repositorytest: expected an update of columns [%s], got %v", strings.Join(expected, ","), seen)
+	return false
+}
+
+func updateCriteria(call Call) ([]repository.UpdateCriteria, bool) {
+	switch call.Method {
+	case "Update", "UpdateTx", "UpdateMany", "UpdateManyTx":
+		criteria, ok := call.Args[1].([]repository.UpdateCriteria)
+		return criteria, ok
+	case "UpdateWhere", "UpdateWhereTx":
+		criteria, ok := call.Args[0].([]repository.UpdateCriteria)
+		return criteria, ok
+	default:
+		return nil, false
+	}
+}
+
+// UpdatedColumns returns the columns restricted (UpdateColumns) or assigned
+// (UpdateSetColumn) by criteria, in order.
+func UpdatedColumns(criteria ...repository.UpdateCriteria) []string {
+	q := criteriaDB.NewUpdate()
+	for _, c := range criteria {
+		if c != nil {
+			q = c(q)
+		}
+	}
+
+	columns := []string{}
+	for _, field := range []string{"columns", "set"} {
+		list := reflect.ValueOf(q).Elem().FieldByName(field)
+		if !list.IsValid() || list.Kind() != reflect.Slice {
+			continue
+		}
+		for i := 0; i < list.Len(); i++ {
+			query := list.Index(i).FieldByName("Query").String()
+			if field == "set" {
+				query, _, _ = strings.Cut(query, "=")
+			}
+			columns = append(columns, strings.Trim(strings.TrimSpace(query), `"`))
+		}
+	}
+	return columns
+}
+
+func (m *MockRepository[T]) methods() []string {
+	methods := []string{}
+	for _, call := range m.Calls() {
+		methods = append(methods, call.Method)
+	}
+	return methods
+}
+
+func sortedColumns(columns []string) []string {
+	sorted := append([]string{}, columns...)
+	sort.Strings(sorted)
+	return sorted
+}
+
+// criteriaDB builds queries to inspect criteria; it never connects.
+var criteriaDB = bun.NewDB(sql.OpenDB(noopConnector{}), pgdialect.New())
+
+var errNoConnection = stderrors.New("repositorytest: no database connection")
+
+type noopConnector struct{}
+
+func (noopConnector) Connect(context.Context) (driver.Conn, error) {
+	return nil, errNoConnection
+}
+
+func (noopConnector) Driver() driver.Driver {
+	return noopDriver{}
+}
+
+type noopDriver struct{}
+
+func (noopDriver) Open(string) (driver.Conn, error) {
+	return nil, errNoConnection
+}
@@ -0,0 +1,526 @@
+// Package repositorytest provides test doubles for repository.Repository.
+package repositorytest
+
+import (
+	"context"
+	"sync"
+
+	repository "github.com/goliatone/go-repository-bun"
+	"github.com/uptrace/bun"
+)
+
+var (
+	_ repository.Repository[any]  = (*MockRepository[any])(nil)
+	_ repository.BatchGetter[any] = (*MockRepository[any])(nil)
+	_ repository.FieldGetter[any] = (*MockRepository[any])(nil)
+	_ repository.GroupCounter     = (*MockRepository[any])(nil)
+	_ repository.BulkUpdater      = (*MockRepository[any])(nil)
+	_ repository.CountingDeleter  = (*MockRepository[any])(nil)
+	_ repository.SetSyncer[any]   = (*MockRepository[any])(nil)
+)
+
+// Call is a recorded repository call. Args holds the arguments after the
+// context and transaction, e.g. the record and criteria of CreateTx.
+type Call struct {
+	Method string
+	Tx     bun.IDB
+	Args   []any
+}
+
+// MockRepository implements repository.Repository[T], and the BatchGetter,
+// FieldGetter, GroupCounter, BulkUpdater, CountingDeleter and SetSyncer
+// capabilities, recording every call.
+// Returns are stubbed per method with the *Func fields; the Tx variant of a
+// method uses the same stub, its transaction is available in Call.Tx. Without
+// a stub, methods receiving records return them unchanged and every other
+// method returns zero values:
+//
+//	repo := &repositorytest.MockRepository[*User]{
+//		GetByIDFunc: func(ctx context.Context, id string, _ ...repository.SelectCriteria) (*User, error) {
+//			return &User{ID: uuid.MustParse(id)}, nil
+//		},
+//	}
+//	svc := NewUserService(repo)
+//	...
+//	repo.AssertUpdatedColumns(t, "name", "updated_at")
+type MockRepository[T any] struct {
+	RawFunc               func(ctx context.Context, sql string, args ...any) ([]T, error)
+	GetFunc               func(ctx context.Context, criteria ...repository.SelectCriteria) (T, error)
+	GetByIDFunc           func(ctx context.Context, id string, criteria ...repository.SelectCriteria) (T, error)
+	GetByIDsFunc          func(ctx context.Context, ids []string, criteria ...repository.SelectCriteria) ([]T, error)
+	GetByFunc             func(ctx context.Context, fields map[string]any, criteria ...repository.SelectCriteria) (T, error)
+	ListFunc              func(ctx context.Context, criteria ...repository.SelectCriteria) ([]T, int, error)
+	CountFunc             func(ctx context.Context, criteria ...repository.SelectCriteria) (int, error)
+	CountByFunc           func(ctx context.Context, column string, criteria ...repository.SelectCriteria) (map[string]int, error)
+	CountByTimeBucketFunc func(ctx context.Context, column string, bucket repository.TimeBucket, criteria ...repository.SelectCriteria) (map[string]int, error)
+	CreateFunc            func(ctx context.Context, record T, criteria ...repository.InsertCriteria) (T, error)
+	CreateManyFunc        func(ctx context.Context, records []T, criteria ...repository.InsertCriteria) ([]T, error)
+	GetOrCreateFunc       func(ctx context.Context, record T) (T, error)
+	GetByIdentifierFunc   func(ctx context.Context, identifier string, criteria ...repository.SelectCriteria) (T, error)
+	UpdateFunc            func(ctx context.Context, record T, criteria ...repository.UpdateCriteria) (T, error)
+	UpdateManyFunc        func(ctx context.Context, records []T, criteria ...repository.UpdateCriteria) ([]T, error)
+	UpdateWhereFunc       func(ctx context.Context, criteria ...repository.UpdateCriteria) (int64, error)
+	UpsertFunc            func(ctx context.Context, record T, criteria ...repository.UpdateCriteria) (T, error)
+	UpsertManyFunc        func(ctx context.Context, records []T, criteria ...repository.UpdateCriteria) ([]T, error)
+	DeleteFunc            func(ctx context.Context, record T) error
+	DeleteManyFunc        func(ctx context.Context, criteria ...repository.DeleteCriteria) error
+	DeleteWhereFunc       func(ctx context.Context, criteria ...repository.DeleteCriteria) error
+	DeleteWhereCountFunc  func(ctx context.Context, criteria ...repository.DeleteCriteria) (int64, error)
+	ForceDeleteFunc       func(ctx context.Context, record T) error
+	SyncSetFunc           func(ctx context.Context, desired []T, matchColumns []string, opts repository.SyncOptions) (repository.SyncReport, error)
+
+	// ModelHandlers is returned by Handlers.
+	ModelHandlers repository.ModelHandlers[T]
+
+	mu            sync.Mutex
+	calls         []Call
+	scopes        map[string]repository.ScopeDefinition
+	scopeDefaults repository.ScopeDefaults
+}
+
+func (m *MockRepository[T]) record(method string, tx bun.IDB, args ...any) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.calls = append(m.calls, Call{Method: method, Tx: tx, Args: args})
+}
+
+// Calls returns the recorded calls in order.
+func (m *MockRepository[T]) Calls() []Call {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]Call(nil), m.calls...)
+}
+
+// CallsTo returns the recorded calls to method and its Tx variant.
+func (m *MockRepository[T]) CallsTo(method string) []Call {
+	calls := []Call{}
+	for _, call := range m.Calls() {
+		if call.Method == method || call.Method == method+"Tx" {
+			calls = append(calls, call)
+		}
+	}
+	return calls
+}
+
+// Reset clears the recorded calls. Stubs are kept.
+func (m *MockRepository[T]) Reset() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.calls = nil
+}
+
+func (m *MockRepository[T]) Raw(ctx context.Context, sql string, args ...any) ([]T, error) {
+	return m.raw(ctx, "Raw", nil, sql, args)
+}
+
+func (m *MockRepository[T]) RawTx(ctx context.Context, tx bun.IDB, sql string, args ...any) ([]T, error) {
+	return m.raw(ctx, "RawTx", tx, sql, args)
+}
+
+func (m *MockRepository[T]) raw(ctx context.Context, method string, tx bun.IDB, sql string, args []any) ([]T, error) {
+	m.record(method, tx, sql, args)
+	if m.RawFunc != nil {
+		return m.RawFunc(ctx, sql, args...)
+	}
+	return nil, nil
+}
+
+func (m *MockRepository[T]) Get(ctx context.Context, criteria ...repository.SelectCriteria) (T, error) {
+	return m.get(ctx, "Get", nil, criteria)
+}
+
+func (m *MockRepository[T]) GetTx(ctx context.Context, tx bun.IDB, criteria ...repository.SelectCriteria) (T, error) {
+	return m.get(ctx, "GetTx", tx, criteria)
+}
+
+func (m *MockRepository[T]) get(ctx context.Context, method string, tx bun.IDB, criteria []repository.SelectCriteria) (T, error) {
+	m.record(method, tx, criteria)
+	if m.GetFunc != nil {
+		return m.GetFunc(ctx, criteria...)
+	}
+	var zero T
+	return zero, nil
+}
+
+func (m *MockRepository[T]) GetByID(ctx context.Context, id string, criteria ...repository.SelectCriteria) (T, error) {
+	return m.getByID(ctx, "GetByID", nil, id, criteria)
+}
+
+func (m *MockRepository[T]) GetByIDTx(ctx context.Context, tx bun.IDB, id string, criteria ...repository.SelectCriteria) (T, error) {
+	return m.getByID(ctx, "GetByIDTx", tx, id, criteria)
+}
+
+func (m *MockRepository[T]) getByID(ctx context.Context, method string, tx bun.IDB, id string, criteria []repository.SelectCriteria) (T, error) {
+	m.record(method, tx, id, criteria)
+	if m.GetByIDFunc != nil {
+		return m.GetByIDFunc(ctx, id, criteria...)
+	}
+	var zero T
+	return zero, nil
+}
+
+func (m *MockRepository[T]) GetByIDs(ctx context.Context, ids []string, criteria ...repository.SelectCriteria) ([]T, error) {
+	return m.getByIDs(ctx, "GetByIDs", nil, ids, criteria)
+}
+
+func (m *MockRepository[T]) GetByIDsTx(ctx context.Context, tx bun.IDB, ids []string, criteria ...repository.SelectCriteria) ([]T, error) {
+	return m.getByIDs(ctx, "GetByIDsTx", tx, ids, criteria)
+}
+
+func (m *MockRepository[T]) getByIDs(ctx context.Context, method string, tx bun.IDB, ids []string, criteria []repository.SelectCriteria) ([]T, error) {
+	m.record(method, tx, ids, criteria)
+	if m.GetByIDsFunc != nil {
+		return m.GetByIDsFunc(ctx, ids, criteria...)
+	}
+	return nil, nil
+}
+
+func (m *MockRepository[T]) GetBy(ctx context.Context, fields map[string]any, criteria ...repository.SelectCriteria) (T, error) {
+	return m.getBy(ctx, "GetBy", nil, fields, criteria)
+}
+
+func (m *MockRepository[T]) GetByTx(ctx context.Context, tx bun.IDB, fields map[string]any, criteria ...repository.SelectCriteria) (T, error) {
+	return m.getBy(ctx, "GetByTx", tx, fields, criteria)
+}
+
+func (m *MockRepository[T]) getBy(ctx context.Context, method string, tx bun.IDB, fields map[string]any, criteria []repository.SelectCriteria) (T, error) {
+	m.record(method, tx, fields, criteria)
+	if m.GetByFunc != nil {
+		return m.GetByFunc(ctx, fields, criteria...)
+	}
+	var zero T
+	return zero, nil
+}
+
+func (m *MockRepository[T]) List(ctx context.Context, criteria ...repository.SelectCriteria) ([]T, int, error) {
+	return m.list(ctx, "List", nil, criteria)
+}
+
+func (m *MockRepository[T]) ListTx(ctx context.Context, tx bun.IDB, criteria ...repository.SelectCriteria) ([]T, int, error) {
+	return m.list(ctx, "ListTx", tx, criteria)
+}
+
+func (m *MockRepository[T]) list(ctx context.Context, method string, tx bun.IDB, criteria []repository.SelectCriteria) ([]T, int, error) {
+	m.record(method, tx, criteria)
+	if m.ListFunc != nil {
+		return m.ListFunc(ctx, criteria...)
+	}
+	return nil, 0, nil
+}
+
+func (m *MockRepository[T]) Count(ctx context.Context, criteria ...repository.SelectCriteria) (int, error) {
+	return m.count(ctx, "Count", nil, criteria)
+}
+
+func (m *MockRepository[T]) CountTx(ctx context.Context, tx bun.IDB, criteria ...repository.SelectCriteria) (int, error) {
+	return m.count(ctx, "CountTx", tx, criteria)
+}
+
+func (m *MockRepository[T]) count(ctx context.Context, method string, tx bun.IDB, criteria []repository.SelectCriteria) (int, error) {
+	m.record(method, tx, criteria)
+	if m.CountFunc != nil {
+		return m.CountFunc(ctx, criteria...)
+	}
+	return 0, nil
+}
+
+func (m *MockRepository[T]) CountBy(ctx context.Context, column string, criteria ...repository.SelectCriteria) (map[string]int, error) {
+	return m.countBy(ctx, "CountBy", nil, column, criteria)
+}
+
+func (m *MockRepository[T]) CountByTx(ctx context.Context, tx bun.IDB, column string, criteria ...repository.SelectCriteria) (map[string]int, error) {
+	return m.countBy(ctx, "CountByTx", tx, column, criteria)
+}
+
+func (m *MockRepository[T]) countBy(ctx context.Context, method string, tx bun.IDB, column string, criteria []repository.SelectCriteria) (map[string]int, error) {
+	m.record(method, tx, column, criteria)
+	if m.CountByFunc != nil {
+		return m.CountByFunc(ctx, column, criteria...)
+	}
+	return map[string]int{}, nil
+}
+
+func (m *MockRepository[T]) CountByTimeBucket(ctx context.Context, column string, bucket repository.TimeBucket, criteria ...repository.SelectCriteria) (map[string]int, error) {
+	return m.countByTimeBucket(ctx, "CountByTimeBucket", nil, column, bucket, criteria)
+}
+
+func (m *MockRepository[T]) CountByTimeBucketTx(ctx context.Context, tx bun.IDB, column string, bucket repository.TimeBucket, criteria ...repository.SelectCriteria) (map[string]int, error) {
+	return m.countByTimeBucket(ctx, "CountByTimeBucketTx", tx, column, bucket, criteria)
+}
+
+func (m *MockRepository[T]) countByTimeBucket(ctx context.Context, method string, tx bun.IDB, column string, bucket repository.TimeBucket, criteria []repository.SelectCriteria) (map[string]int, error) {
+	m.record(method, tx, column, bucket, criteria)
+	if m.CountByTimeBucketFunc != nil {
+		return m.CountByTimeBucketFunc(ctx, column, bucket, criteria...)
+	}
+	return map[string]int{}, nil
+}
+
+func (m *MockRepository[T]) Create(ctx context.Context, record T, criteria ...repository.InsertCriteria) (T, error) {
+	return m.create(ctx, "Create", nil, record, criteria)
+}
+
+func (m *MockRepository[T]) CreateTx(ctx context.Context, tx bun.IDB, record T, criteria ...repository.InsertCriteria) (T, error) {
+	return m.create(ctx, "CreateTx", tx, record, criteria)
+}
+
+func (m *MockRepository[T]) create(ctx context.Context, method string, tx bun.IDB, record T, criteria []repository.InsertCriteria) (T, error) {
+	m.record(method, tx, record, criteria)
+	if m.CreateFunc != nil {
+		return m.CreateFunc(ctx, record, criteria...)
+	}
+	return record, nil
+}
+
+func (m *MockRepository[T]) CreateMany(ctx context.Context, records []T, criteria ...repository.InsertCriteria) ([]T, error) {
+	return m.createMany(ctx, "CreateMany", nil, records, criteria)
+}
+
+func (m *MockRepository[T]) CreateManyTx(ctx context.Context, tx bun.IDB, records []T, criteria ...repository.InsertCriteria) ([]T, error) {
+	return m.createMany(ctx, "CreateManyTx", tx, records, criteria)
+}
+
+func (m *MockRepository[T]) createMany(ctx context.Context, method string, tx bun.IDB, records []T, criteria []repository.InsertCriteria) ([]T, error) {
+	m.record(method, tx, records, criteria)
+	if m.CreateManyFunc != nil {
+		return m.CreateManyFunc(ctx, records, criteria...)
+	}
+	return records, nil
+}
+
+func (m *MockRepository[T]) GetOrCreate(ctx context.Context, record T) (T, error) {
+	return m.getOrCreate(ctx, "GetOrCreate", nil, record)
+}
+
+func (m *MockRepository[T]) GetOrCreateTx(ctx context.Context, tx bun.IDB, record T) (T, error) {
+	return m.getOrCreate(ctx, "GetOrCreateTx", tx, record)
+}
+
+func (m *MockRepository[T]) getOrCreate(ctx context.Context, method string, tx bun.IDB, record T) (T, error) {
+	m.record(method, tx, record)
+	if m.GetOrCreateFunc != nil {
+		return m.GetOrCreateFunc(ctx, record)
+	}
+	return record, nil
+}
+
+func (m *MockRepository[T]) GetByIdentifier(ctx context.Context, identifier string, criteria ...repository.SelectCriteria) (T, error) {
+	return m.getByIdentifier(ctx, "GetByIdentifier", nil, identifier, criteria)
+}
+
+func (m *MockRepository[T]) GetByIdentifierTx(ctx context.Context, tx bun.IDB, identifier string, criteria ...repository.SelectCriteria) (T, error) {
+	return m.getByIdentifier(ctx, "GetByIdentifierTx", tx, identifier, criteria)
+}
+
+func (m *MockRepository[T]) getByIdentifier(ctx context.Context, method string, tx bun.IDB, identifier string, criteria []repository.SelectCriteria) (T, error) {
+	m.record(method, tx, identifier, criteria)
+	if m.GetByIdentifierFunc != nil {
+		return m.GetByIdentifierFunc(ctx, identifier, criteria...)
+	}
+	var zero T
+	return zero, nil
+}
+
+func (m *MockRepository[T]) Update(ctx context.Context, record T, criteria ...repository.UpdateCriteria) (T, error) {
+	return m.update(ctx, "Update", nil, record, criteria)
+}
+
+func (m *MockRepository[T]) UpdateTx(ctx context.Context, tx bun.IDB, record T, criteria ...repository.UpdateCriteria) (T, error) {
+	return m.update(ctx, "UpdateTx", tx, record, criteria)
+}
+
+func (m *MockRepository[T]) update(ctx context.Context, method string, tx bun.IDB, record T, criteria []repository.UpdateCriteria) (T, error) {
+	m.record(method, tx, record, criteria)
+	if m.UpdateFunc != nil {
+		return m.UpdateFunc(ctx, record, criteria...)
+	}
+	return record, nil
+}
+
+func (m *MockRepository[T]) UpdateMany(ctx context.Context, records []T, criteria ...repository.UpdateCriteria) ([]T, error) {
+	return m.updateMany(ctx, "UpdateMany", nil, records, criteria)
+}
+
+func (m *MockRepository[T]) UpdateManyTx(ctx context.Context, tx bun.IDB, records []T, criteria ...repository.UpdateCriteria) ([]T, error) {
+	return m.updateMany(ctx, "UpdateManyTx", tx, records, criteria)
+}
+
+func (m *MockRepository[T]) updateMany(ctx context.Context, method string, tx bun.IDB, records []T, criteria []repository.UpdateCriteria) ([]T, error) {
+	m.record(method, tx, records, criteria)
+	if m.UpdateManyFunc != nil {
+		return m.UpdateManyFunc(ctx, records, criteria...)
+	}
+	return records, nil
+}
+
+func (m *MockRepository[T]) UpdateWhere(ctx context.Context, criteria ...repository.UpdateCriteria) (int64, error) {
+	return m.updateWhere(ctx, "UpdateWhere", nil, criteria)
+}
+
+func (m *MockRepository[T]) UpdateWhereTx(ctx context.Context, tx bun.IDB, criteria ...repository.UpdateCriteria) (int64, error) {
+	return m.updateWhere(ctx, "UpdateWhereTx", tx, criteria)
+}
+
+func (m *MockRepository[T]) updateWhere(ctx context.Context, method string, tx bun.IDB, criteria []repository.UpdateCriteria) (int64, error) {
+	m.record(method, tx, criteria)
+	if m.UpdateWhereFunc != nil {
+		return m.UpdateWhereFunc(ctx, criteria...)
+	}
+	return 0, nil
+}
+
+func (m *MockRepository[T]) Upsert(ctx context.Context, record T, criteria ...repository.UpdateCriteria) (T, error) {
+	return m.upsert(ctx, "Upsert", nil, record, criteria)
+}
+
+func (m *MockRepository[T]) UpsertTx(ctx context.Context, tx bun.IDB, record T, criteria ...repository.UpdateCriteria) (T, error) {
+	return m.upsert(ctx, "UpsertTx", tx, record, criteria)
+}
+
+func (m *MockRepository[T]) upsert(ctx context.Context, method string, tx bun.IDB, record T, criteria []repository.UpdateCriteria) (T, error) {
+	m.record(method, tx, record, criteria)
+	if m.UpsertFunc != nil {
+		return m.UpsertFunc(ctx, record, criteria...)
+	}
+	return record, nil
+}
+
+func (m *MockRepository[T]) UpsertMany(ctx context.Context, records []T, criteria ...repository.UpdateCriteria) ([]T, error) {
+	return m.upsertMany(ctx, "UpsertMany", nil, records, criteria)
+}
+
+func (m *MockRepository[T]) UpsertManyTx(ctx context.Context, tx bun.IDB, records []T, criteria ...repository.UpdateCriteria) ([]T, error) {
+	return m.upsertMany(ctx, "UpsertManyTx", tx, records, criteria)
+}
+
+func (m *MockRepository[T]) upsertMany(ctx context.Context, method string, tx bun.IDB, records []T, criteria []repository.UpdateCriteria) ([]T, error) {
+	m.record(method, tx, records, criteria)
+	if m.UpsertManyFunc != nil {
+		return m.UpsertManyFunc(ctx, records, criteria...)
+	}
+	return records, nil
+}
+
+func (m *MockRepository[T]) Delete(ctx context.Context, record T) error {
+	return m.delete(ctx, "Delete", nil, record)
+}
+
+func (m *MockRepository[T]) DeleteTx(ctx context.Context, tx bun.IDB, record T) error {
+	return m.delete(ctx, "DeleteTx", tx, record)
+}
+
+func (m *MockRepository[T]) delete(ctx context.Context, method string, tx bun.IDB, record T) error {
+	m.record(method, tx, record)
+	if m.DeleteFunc != nil {
+		return m.DeleteFunc(ctx, record)
+	}
+	return nil
+}
+
+func (m *MockRepository[T]) DeleteMany(ctx context.Context, criteria ...repository.DeleteCriteria) error {
+	return m.deleteMany(ctx, "DeleteMany", nil, criteria)
+}
+
+func (m *MockRepository[T]) DeleteManyTx(ctx context.Context, tx bun.IDB, criteria ...repository.DeleteCriteria) error {
+	return m.deleteMany(ctx, "DeleteManyTx", tx, criteria)
+}
+
+func (m *MockRepository[T]) deleteMany(ctx context.Context, method string, tx bun.IDB, criteria []repository.DeleteCriteria) error {
+	m.record(method, tx, criteria)
+	if m.DeleteManyFunc != nil {
+		return m.DeleteManyFunc(ctx, criteria...)
+	}
+	return nil
+}
+
+func (m *MockRepository[T]) DeleteWhere(ctx context.Context, criteria ...repository.DeleteCriteria) error {
+	return m.deleteWhere(ctx, "DeleteWhere", nil, criteria)
+}
+
+func (m *MockRepository[T]) DeleteWhereTx(ctx context.Context, tx bun.IDB, criteria ...repository.DeleteCriteria) error {
+	return m.deleteWhere(ctx, "DeleteWhereTx", tx, criteria)
+}
+
+func (m *MockRepository[T]) deleteWhere(ctx context.Context, method string, tx bun.IDB, criteria []repository.DeleteCriteria) error {
+	m.record(method, tx, criteria)
+	if m.DeleteWhereFunc != nil {
+		return m.DeleteWhereFunc(ctx, criteria...)
+	}
+	return nil
+}
+
+func (m *MockRepository[T]) DeleteWhereCount(ctx context.Context, criteria ...repository.DeleteCriteria) (int64, error) {
+	return m.deleteWhereCount(ctx, "DeleteWhereCount", nil, criteria)
+}
+
+func (m *MockRepository[T]) DeleteWhereCountTx(ctx context.Context, tx bun.IDB, criteria ...repository.DeleteCriteria) (int64, error) {
+	return m.deleteWhereCount(ctx, "DeleteWhereCountTx", tx, criteria)
+}
+
+func (m *MockRepository[T]) deleteWhereCount(ctx context.Context, method string, tx bun.IDB, criteria []repository.DeleteCriteria) (int64, error) {
+	m.record(method, tx, criteria)
+	if m.DeleteWhereCountFunc != nil {
+		return m.DeleteWhereCountFunc(ctx, criteria...)
+	}
+	return 0, nil
+}
+
+func (m *MockRepository[T]) ForceDelete(ctx context.Context, record T) error {
+	return m.forceDelete(ctx, "ForceDelete", nil, record)
+}
+
+func (m *MockRepository[T]) ForceDeleteTx(ctx context.Context, tx bun.IDB, record T) error {
+	return m.forceDelete(ctx, "ForceDeleteTx", tx, record)
+}
+
+func (m *MockRepository[T]) forceDelete(ctx context.Context, method string, tx bun.IDB, record T) error {
+	m.record(method, tx, record)
+	if m.ForceDeleteFunc != nil {
+		return m.ForceDeleteFunc(ctx, record)
+	}
+	return nil
+}
+
+func (m *MockRepository[T]) SyncSet(ctx context.Context, desired []T, matchColumns []string, opts repository.SyncOptions) (repository.SyncReport, error) {
+	return m.syncSet(ctx, "SyncSet", nil, desired, matchColumns, opts)
+}
+
+func (m *MockRepository[T]) SyncSetTx(ctx context.Context, tx bun.IDB, desired []T, matchColumns []string, opts repository.SyncOptions) (repository.SyncReport, error) {
+	return m.syncSet(ctx, "SyncSetTx", tx, desired, matchColumns, opts)
+}
+
+func (m *MockRepository[T]) syncSet(ctx context.Context, method string, tx bun.IDB, desired []T, matchColumns []string, opts repository.SyncOptions) (repository.SyncReport, error) {
+	m.record(method, tx, desired, matchColumns, opts)
+	if m.SyncSetFunc != nil {
+		return m.SyncSetFunc(ctx, desired, matchColumns, opts)
+	}
+	return repository.SyncReport{}, nil
+}
+
+func (m *MockRepository[T]) Handlers() repository.ModelHandlers[T] {
+	return m.ModelHandlers
+}
+
+func (m *MockRepository[T]) RegisterScope(name string, scope repository.ScopeDefinition) {
+	m.record("RegisterScope", nil, name, scope)
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.scopes == nil {
+		m.scopes = make(map[string]repository.ScopeDefinition)
+	}
+	m.scopes[name] = scope
+}
+
+func (m *MockRepository[T]) SetScopeDefaults(defaults repository.ScopeDefaults) error {
+	m.record("SetScopeDefaults", nil, defaults)
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.scopeDefaults = defaults
+	return nil
+}
+
+func (m *MockRepository[T]) GetScopeDefaults() repository.ScopeDefaults {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.scopeDefaults
+}
@@ -0,0 +1,104 @@
+package repositorytest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	repository "github.com/goliatone/go-repository-bun"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type user struct {
+	ID   string
+	Name string
+}
+
+type recordingT struct {
+	errors []string
+}
+
+func (r *recordingT) Helper() {}
+
+func (r *recordingT) Errorf(format string, args ...any) {
+	r.errors = append(r.errors, fmt.Sprintf(format, args...))
+}
+
+func TestMockRepository_Stubs(t *testing.T) {
+	ctx := context.Background()
+	errNotFound := errors.New("not found")
+
+	mock := &MockRepository[*user]{
+		GetByIDFunc: func(_ context.Context, id string, _ ...repository.SelectCriteria) (*user, error) {
+			if id == "1" {
+				return &user{ID: "1", Name: "Alice"}, nil
+			}
+			return nil, errNotFound
+		},
+	}
+	var repo repository.Repository[*user] = mock
+
+	found, err := repo.GetByID(ctx, "1")
+	require.NoError(t, err)
+	assert.Equal(t, "Alice", found.Name)
+
+	_, err = repo.GetByIDTx(ctx, nil, "2")
+	assert.ErrorIs(t, err, errNotFound, "Tx variants share the stub")
+
+	record := &user{ID: "3"}
+	created, err := repo.Create(ctx, record)
+	require.NoError(t, err)
+	assert.Same(t, record, created, "unstubbed methods echo the record")
+
+	list, total, err := repo.List(ctx)
+	require.NoError(t, err)
+	assert.Nil(t, list)
+	assert.Zero(t, total)
+
+	calls := mock.Calls()
+	require.Len(t, calls, 4)
+	assert.Equal(t, "GetByIDTx", calls[1].Method)
+	assert.Equal(t, "2", calls[1].Args[0])
+	assert.Len(t, mock.CallsTo("GetByID"), 2)
+
+	mock.Reset()
+	assert.Empty(t, mock.Calls())
+}
+
+func TestMockRepository_Assertions(t *testing.T) {
+	ctx := context.Background()
+	mock := &MockRepository[*user]{}
+
+	_, _ = mock.CreateMany(ctx, []*user{{ID: "1", Name: "Alice"}, {ID: "2", Name: "Bob"}})
+	_, _ = mock.UpdateTx(ctx, nil, &user{ID: "1"}, repository.UpdateColumns("name", "updated_at"))
+	_, _ = mock.UpdateWhere(ctx,
+		repository.UpdateSetColumn("status", "archived"),
+		repository.UpdateBy("status", "=", "inactive"),
+	)
+
+	assert.True(t, mock.AssertCreated(t, &user{ID: "2", Name: "Bob"}))
+	assert.True(t, mock.AssertUpdatedColumns(t, "updated_at", "name"))
+	assert.True(t, mock.AssertUpdatedColumns(t, "status"))
+	assert.True(t, mock.AssertCalled(t, "Update"))
+	assert.True(t, mock.AssertNotCalled(t, "Delete"))
+	assert.Len(t, mock.Created(), 2)
+
+	failing := &recordingT{}
+	assert.False(t, mock.AssertCreated(failing, &user{ID: "9"}))
+	assert.False(t, mock.AssertUpdatedColumns(failing, "email"))
+	assert.False(t, mock.AssertCalled(failing, "Delete"))
+	assert.False(t, mock.AssertNotCalled(failing, "CreateMany"))
+	require.Len(t, failing.errors, 4)
+	assert.Contains(t, failing.errors[1], "UpdateTx[name,updated_at]")
+}
+
+func TestMockRepository_Scopes(t *testing.T) {
+	mock := &MockRepository[*user]{}
+	mock.RegisterScope("tenant", repository.ScopeDefinition{})
+	defaults := repository.ScopeDefaults{}
+	require.NoError(t, mock.SetScopeDefaults(defaults))
+	assert.Equal(t, defaults, mock.GetScopeDefaults())
+	assert.True(t, mock.AssertCalled(t, "RegisterScope"))
+}
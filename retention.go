@@ -0,0 +1,225 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/goliatone/go-errors"
+	"github.com/uptrace/bun"
+)
+
+const defaultRetentionBatchSize = 1000
+
+// RetentionAction is what RunRetention does with expired rows.
+type RetentionAction int
+
+const (
+	// SoftDelete marks expired rows as deleted; the model needs a soft_delete column.
+	SoftDelete RetentionAction = iota
+	// HardDelete removes expired rows, soft deleted ones included.
+	HardDelete
+)
+
+func (a RetentionAction) String() string {
+	switch a {
+	case SoftDelete:
+		return "soft_delete"
+	case HardDelete:
+		return "hard_delete"
+	default:
+		return fmt.Sprintf("RetentionAction(%d)", int(a))
+	}
+}
+
+// RetentionPolicy expires rows whose Column is older than MaxAge.
+type RetentionPolicy struct {
+	MaxAge time.Duration
+	Column string
+	Action RetentionAction
+	// BatchSize caps the rows deleted per statement. Defaults to 1000.
+	BatchSize int
+}
+
+// Retain returns a policy expiring rows whose column is older than maxAge:
+//
+//	WithRetention(Retain(90*24*time.Hour, "created_at", SoftDelete))
+func Retain(maxAge time.Duration, column string, action RetentionAction) RetentionPolicy {
+	return RetentionPolicy{MaxAge: maxAge, Column: column, Action: action}
+}
+
+// RetentionProgress is reported after every RunRetention batch.
+type RetentionProgress struct {
+	Table  string
+	Policy RetentionPolicy
+	Batch  int
+	// Rows is the number of rows expired so far by Policy.
+	Rows int64
+}
+
+// RetentionProgressFunc receives RunRetention progress.
+type RetentionProgressFunc func(RetentionProgress)
+
+// RetentionResult summarizes a policy run.
+type RetentionResult struct {
+	Policy  RetentionPolicy
+	Rows    int64
+	Batches int
+}
+
+// RetentionRunner is an optional capability for repositories configured with
+// WithRetention.
+type RetentionRunner interface {
+	RunRetention(ctx context.Context) ([]RetentionResult, error)
+}
+
+// WithRetention registers retention policies executed by RunRetention.
+// Invalid policies are reported by Validate.
+func WithRetention(policies ...RetentionPolicy) RepoOption {
+	return func(cfg *repoConfig) {
+		if cfg == nil {
+			return
+		}
+		cfg.retentionPolicies = append(cfg.retentionPolicies, policies...)
+	}
+}
+
+// WithRetentionProgress sets a callback invoked after every RunRetention batch.
+// Batches are also reported to the WithProgressReporter reporter.
+func WithRetentionProgress(fn RetentionProgressFunc) RepoOption {
+	return func(cfg *repoConfig) {
+		if cfg == nil {
+			return
+		}
+		cfg.retentionProgress = fn
+	}
+}
+
+// RunRetention executes the configured retention policies in order. Expired
+// rows are deleted in batches, each committed on its own, so long running
+// jobs do not hold locks on the whole table. Repository scopes apply as for
+// any other delete.
+func (r *repo[T]) RunRetention(ctx context.Context) ([]RetentionResult, error) {
+	if err := r.validateRetentionPolicies(); err != nil {
+		return nil, err
+	}
+
+	results := make([]RetentionResult, 0, len(r.retentionPolicies))
+	for _, policy := range r.retentionPolicies {
+		result, err := r.runRetentionPolicy(ctx, policy)
+		results = append(results, result)
+		if err != nil {
+			return results, err
+		}
+	}
+	return results, nil
+}
+
+func (r *repo[T]) runRetentionPolicy(ctx context.Context, policy RetentionPolicy) (RetentionResult, error) {
+	result := RetentionResult{Policy: policy}
+	batchSize := policy.BatchSize
+	if batchSize <= 0 {
+		batchSize = defaultRetentionBatchSize
+	}
+	cutoff := time.Now().Add(-policy.MaxAge)
+	column, _ := normalizeSQLIdentifier(policy.Column)
+
+	progress := newProgressTracker(r.progressReporter, "retention", r.TableName(), 0)
+	defer progress.done(ctx)
+
+	for {
+		ids := []string{}
+		q := r.db.NewSelect().
+			Model(r.handlers.NewRecord()).
+			Column("id").
+			Where("?TableAlias.? < ?", bun.Ident(column), cutoff).
+			OrderExpr("?TableAlias.? ASC", bun.Ident(column)).
+			Limit(batchSize)
+		if policy.Action == HardDelete && querySoftDeletes(q) {
+			q = q.WhereAllWithDeleted()
+		}
+		q = r.applySelectScopes(ctx, q)
+		if policy.Action == SoftDelete {
+			if err := r.applySelectCriteria(q, nil); err != nil {
+				return result, err
+			}
+		}
+		if err := q.Scan(ctx, &ids); err != nil {
+			return result, r.mapError(err)
+		}
+		if len(ids) == 0 {
+			return result, nil
+		}
+
+		criteria := []DeleteCriteria{DeleteColumnIn("id", ids)}
+		if policy.Action == HardDelete {
+			criteria = append(criteria, DeleteForReal())
+		}
+		var zero T
+		affected, err := r.execDelete(ctx, r.db, zero, criteria)
+		if err != nil {
+			return result, r.mapError(err)
+		}
+
+		result.Rows += affected
+		result.Batches++
+		progress.batch(ctx, affected)
+		if r.retentionProgress != nil {
+			r.retentionProgress(RetentionProgress{
+				Table:  r.TableName(),
+				Policy: policy,
+				Batch:  result.Batches,
+				Rows:   result.Rows,
+			})
+		}
+
+		if len(ids) < batchSize || affected == 0 {
+			return result, nil
+		}
+	}
+}
+
+func (r *repo[T]) validateRetentionPolicies() error {
+	if len(r.retentionPolicies) == 0 {
+		return nil
+	}
+
+	table := r.modelTable()
+	var validationErrors errors.ValidationErrors
+	for _, policy := range r.retentionPolicies {
+		column, ok := normalizeSQLIdentifier(policy.Column)
+		switch {
+		case !ok:
+			validationErrors = append(validationErrors, errors.FieldError{
+				Field:   "repoOptions.WithRetention",
+				Message: fmt.Sprintf("column %q is not a valid SQL identifier", policy.Column),
+			})
+		case policy.MaxAge <= 0:
+			validationErrors = append(validationErrors, errors.FieldError{
+				Field:   "repoOptions.WithRetention",
+				Message: fmt.Sprintf("max age for %q must be positive", column),
+			})
+		case policy.Action != SoftDelete && policy.Action != HardDelete:
+			validationErrors = append(validationErrors, errors.FieldError{
+				Field:   "repoOptions.WithRetention",
+				Message: fmt.Sprintf("unknown action %s for %q", policy.Action, column),
+			})
+		case table == nil:
+		case table.FieldMap[column] == nil:
+			validationErrors = append(validationErrors, errors.FieldError{
+				Field:   "repoOptions.WithRetention",
+				Message: fmt.Sprintf("column %q does not exist on %s", column, table.Name),
+			})
+		case policy.Action == SoftDelete && !r.hasSoftDelete():
+			validationErrors = append(validationErrors, errors.FieldError{
+				Field:   "repoOptions.WithRetention",
+				Message: fmt.Sprintf("%s has no soft delete column, use HardDelete", table.TypeName),
+			})
+		}
+	}
+
+	if len(validationErrors) > 0 {
+		return errors.NewValidation("repository configuration invalid", validationErrors...)
+	}
+	return nil
+}
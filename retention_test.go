@@ -0,0 +1,118 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	goerrors "github.com/goliatone/go-errors"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/uptrace/bun"
+)
+
+type retentionTestEvent struct {
+	bun.BaseModel `bun:"table:retention_test_events,alias:rte"`
+
+	ID        uuid.UUID `bun:"id,pk,notnull"`
+	Name      string    `bun:"name,notnull"`
+	CreatedAt time.Time `bun:"created_at,notnull"`
+	DeletedAt time.Time `bun:"deleted_at,soft_delete,nullzero"`
+}
+
+func newRetentionTestEventRepository(t *testing.T, opts ...RepoOption) Repository[*retentionTestEvent] {
+	t.Helper()
+	return newTestRepository[*retentionTestEvent](t, opts...)
+}
+
+func seedRetentionTestEvents(t *testing.T, repo Repository[*retentionTestEvent], ages ...time.Duration) {
+	t.Helper()
+	now := time.Now()
+	for i, age := range ages {
+		_, err := repo.Create(context.Background(), &retentionTestEvent{
+			ID:        uuid.New(),
+			Name:      fmt.Sprintf("event-%d", i),
+			CreatedAt: now.Add(-age),
+		})
+		require.NoError(t, err)
+	}
+}
+
+func TestRepository_RunRetention_SoftDelete(t *testing.T) {
+	ctx := context.Background()
+	day := 24 * time.Hour
+
+	var progress []RetentionProgress
+	policy := Retain(30*day, "created_at", SoftDelete)
+	policy.BatchSize = 2
+	repo := newRetentionTestEventRepository(t,
+		WithRetention(policy),
+		WithRetentionProgress(func(p RetentionProgress) { progress = append(progress, p) }),
+	)
+	seedRetentionTestEvents(t, repo, 40*day, 35*day, 31*day, day)
+
+	runner, ok := repo.(RetentionRunner)
+	require.True(t, ok)
+	results, err := runner.RunRetention(ctx)
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+	assert.Equal(t, int64(3), results[0].Rows)
+	assert.Equal(t, 2, results[0].Batches)
+
+	require.Len(t, progress, 2)
+	assert.Equal(t, "retention_test_events", progress[1].Table)
+	assert.Equal(t, int64(3), progress[1].Rows)
+
+	remaining, total, err := repo.List(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, total)
+	assert.Equal(t, "event-3", remaining[0].Name)
+
+	trashed, err := db.NewSelect().Model((*retentionTestEvent)(nil)).WhereDeleted().Count(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 3, trashed)
+}
+
+func TestRepository_RunRetention_HardDeleteIncludesTrashed(t *testing.T) {
+	ctx := context.Background()
+	day := 24 * time.Hour
+
+	repo := newRetentionTestEventRepository(t, WithRetention(Retain(30*day, "created_at", HardDelete)))
+	seedRetentionTestEvents(t, repo, 40*day, day)
+
+	expired, _, err := repo.List(ctx, OrderBy("created_at ASC"))
+	require.NoError(t, err)
+	require.NoError(t, repo.Delete(ctx, expired[0]))
+
+	results, err := repo.(RetentionRunner).RunRetention(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), results[0].Rows)
+
+	all, err := db.NewSelect().Model((*retentionTestEvent)(nil)).WhereAllWithDeleted().Count(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, all)
+}
+
+func TestWithRetention_Validate(t *testing.T) {
+	tests := []struct {
+		name   string
+		policy RetentionPolicy
+	}{
+		{name: "unknown column", policy: Retain(time.Hour, "expires_at", HardDelete)},
+		{name: "non positive age", policy: Retain(0, "created_at", HardDelete)},
+		{name: "soft delete without column", policy: Retain(time.Hour, "created_at", SoftDelete)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := NewRepositoryWithConfig(db, testUserHandlers(), nil, WithRetention(tt.policy))
+			validator, ok := repo.(Validator)
+			require.True(t, ok)
+			err := validator.Validate()
+			require.Error(t, err)
+			assert.True(t, goerrors.IsValidation(err))
+		})
+	}
+}
@@ -0,0 +1,304 @@
+package repository
+
+import (
+	"container/heap"
+	"context"
+	"database/sql"
+	"fmt"
+	"math"
+	"math/rand/v2"
+
+	"github.com/goliatone/go-errors"
+	"github.com/google/uuid"
+	"github.com/uptrace/bun"
+	"github.com/uptrace/bun/dialect"
+)
+
+// RecordSampler is an optional capability for repositories that can return
+// random subsets of their rows.
+type RecordSampler[T any] interface {
+	Sample(ctx context.Context, n int, criteria ...SelectCriteria) ([]T, error)
+	SampleTx(ctx context.Context, tx bun.IDB, n int, criteria ...SelectCriteria) ([]T, error)
+	SampleWeighted(ctx context.Context, n int, weightColumn string, criteria ...SelectCriteria) ([]T, error)
+	SampleWeightedTx(ctx context.Context, tx bun.IDB, n int, weightColumn string, criteria ...SelectCriteria) ([]T, error)
+}
+
+// sampleTableSampleMinRows is the estimated table size from which Sample
+// uses TABLESAMPLE on Postgres; smaller tables are cheap to sort.
+const sampleTableSampleMinRows = 10000
+
+// sampleOversampling is how many more rows than requested TABLESAMPLE aims
+// for, so that criteria filtering rarely leaves the sample short.
+const sampleOversampling = 4
+
+var randomOrderFunctions = map[dialect.Name]string{
+	dialect.PG:     "RANDOM()",
+	dialect.SQLite: "RANDOM()",
+	dialect.MySQL:  "RAND()",
+	dialect.MSSQL:  "NEWID()",
+}
+
+// SelectRandomOrder orders rows randomly with the random function of the
+// dialect: RANDOM() on Postgres and SQLite, RAND() on MySQL and NEWID() on
+// SQL Server. It sorts every matching row, so prefer Sample on large tables.
+func SelectRandomOrder() SelectCriteria {
+	return func(q *bun.SelectQuery) *bun.SelectQuery {
+		if random, ok := randomOrderFunctions[q.Dialect().Name()]; ok {
+			return q.OrderExpr(random)
+		}
+		return q
+	}
+}
+
+// Sample returns up to n random records matching criteria, in random order.
+// Large Postgres tables are read through TABLESAMPLE BERNOULLI, sized from
+// the planner estimate of the rows matching criteria, instead of sorting the
+// whole table; when that comes up short the query is retried with
+// ORDER BY RANDOM(). Other drivers always sort randomly.
+func (r *repo[T]) Sample(ctx context.Context, n int, criteria ...SelectCriteria) ([]T, error) {
+	return r.SampleTx(ctx, r.db, n, criteria...)
+}
+
+func (r *repo[T]) SampleTx(ctx context.Context, tx bun.IDB, n int, criteria ...SelectCriteria) ([]T, error) {
+	ctx = r.withOperation(ctx, "Sample", len(criteria))
+	if n <= 0 {
+		return []T{}, nil
+	}
+	if _, ok := randomOrderFunctions[tx.Dialect().Name()]; !ok {
+		return nil, unsupportedDriverError("Sample", r.driver)
+	}
+
+	if percent, ok := r.tableSamplePercent(ctx, tx, n, criteria); ok {
+		records, err := r.sampleQuery(ctx, tx, n, percent, criteria)
+		if err != nil || len(records) == n {
+			return records, err
+		}
+	}
+	return r.sampleQuery(ctx, tx, n, 0, criteria)
+}
+
+func (r *repo[T]) sampleQuery(ctx context.Context, tx bun.IDB, n int, percent float64, criteria []SelectCriteria) ([]T, error) {
+	records := []T{}
+	q := tx.NewSelect().Model(&records)
+	if percent > 0 {
+		q.ModelTableExpr("?TableName AS ?TableAlias TABLESAMPLE BERNOULLI (?)", percent)
+	}
+
+	q = r.applySelectScopes(ctx, q)
+	q = r.applyDefaultRelations(ctx, q)
+	if err := r.applySelectCriteria(q, criteria); err != nil {
+		return nil, err
+	}
+
+	if err := r.scanRecords(ctx, q.Apply(SelectRandomOrder()).Limit(n).Scan); err != nil {
+		return nil, err
+	}
+	return records, nil
+}
+
+// tableSamplePercent returns the TABLESAMPLE percentage expected to yield
+// enough rows matching criteria for a sample of n, when the table is large
+// enough to benefit. It is sized from the EXPLAIN row estimate of the
+// filtered query, so a selective filter samples a larger share of the table
+// or, once that reaches the whole table, skips TABLESAMPLE altogether.
+func (r *repo[T]) tableSamplePercent(ctx context.Context, tx bun.IDB, n int, criteria []SelectCriteria) (float64, bool) {
+	if r.driver != "postgres" {
+		return 0, false
+	}
+
+	var estimate float64
+	err := tx.NewRaw("SELECT reltuples FROM pg_class WHERE oid = to_regclass(?)", r.TableName()).
+		Scan(ctx, &estimate)
+	if err != nil || estimate < sampleTableSampleMinRows {
+		return 0, false
+	}
+
+	q := tx.NewSelect().Model(r.handlers.NewRecord())
+	q = r.applySelectScopes(ctx, q)
+	if err := r.applySelectCriteria(q, criteria); err != nil {
+		return 0, false
+	}
+	var plan []byte
+	if err := tx.NewRaw("EXPLAIN (FORMAT JSON) ?", q).Scan(ctx, &plan); err != nil {
+		return 0, false
+	}
+	matching, ok := postgresPlanRows(plan)
+	if !ok || matching <= 0 {
+		return 0, false
+	}
+
+	percent := 100 * float64(n*sampleOversampling) / min(matching, estimate)
+	if percent >= 100 {
+		return 0, false
+	}
+	return percent, true
+}
+
+// SampleWeighted returns up to n records matching criteria, picked without
+// replacement with a probability proportional to weightColumn. Rows with a
+// NULL, zero or negative weight are never picked. Weights are streamed and
+// sampled with a Reservoir, so it reads every matching row once on any
+// driver, and records come back from the heaviest sampling key down.
+func (r *repo[T]) SampleWeighted(ctx context.Context, n int, weightColumn string, criteria ...SelectCriteria) ([]T, error) {
+	return r.SampleWeightedTx(ctx, r.db, n, weightColumn, criteria...)
+}
+
+func (r *repo[T]) SampleWeightedTx(ctx context.Context, tx bun.IDB, n int, weightColumn string, criteria ...SelectCriteria) ([]T, error) {
+	ctx = r.withOperation(ctx, "SampleWeighted", len(criteria))
+	col, ok := normalizeSQLIdentifier(weightColumn)
+	if !ok {
+		return nil, invalidColumnError("weightColumn", weightColumn)
+	}
+	if table := r.modelTable(); table != nil {
+		if _, ok := table.FieldMap[col]; !ok {
+			return nil, errors.NewValidation(
+				"repository: unknown column",
+				errors.FieldError{Field: "weightColumn", Message: fmt.Sprintf("column %q does not exist on %s", col, table.Name)},
+			)
+		}
+	}
+	if n <= 0 {
+		return []T{}, nil
+	}
+
+	q := tx.NewSelect().
+		Model(r.handlers.NewRecord()).
+		ColumnExpr("?TableAlias.id").
+		ColumnExpr("?TableAlias.?", bun.Ident(col))
+	q = r.applySelectScopes(ctx, q)
+	if err := r.applySelectCriteria(q, criteria); err != nil {
+		return nil, err
+	}
+
+	rows, err := q.Rows(ctx)
+	if err != nil {
+		return nil, r.mapError(err)
+	}
+	defer rows.Close()
+
+	reservoir := NewReservoir[uuid.UUID](n)
+	for rows.Next() {
+		var id uuid.UUID
+		var weight sql.NullFloat64
+		if err := rows.Scan(&id, &weight); err != nil {
+			return nil, r.mapError(err)
+		}
+		if weight.Valid {
+			reservoir.AddWeighted(id, weight.Float64)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, r.mapError(err)
+	}
+
+	return r.recordsInOrder(ctx, tx, reservoir.Items(), criteria)
+}
+
+// recordsInOrder loads the records with ids, in the order of ids. Records
+// deleted in the meantime are skipped.
+func (r *repo[T]) recordsInOrder(ctx context.Context, tx bun.IDB, ids []uuid.UUID, criteria []SelectCriteria) ([]T, error) {
+	if len(ids) == 0 {
+		return []T{}, nil
+	}
+
+	records := []T{}
+	q := tx.NewSelect().
+		Model(&records).
+		Where("?TableAlias.id IN (?)", bun.In(ids))
+	q = r.applySelectScopes(ctx, q)
+	q = r.applyDefaultRelations(ctx, q)
+	if err := r.applySelectCriteria(q, criteria); err != nil {
+		return nil, err
+	}
+	if err := r.scanRecords(ctx, q.Scan); err != nil {
+		return nil, err
+	}
+
+	byID := make(map[uuid.UUID]T, len(records))
+	for _, record := range records {
+		byID[r.handlers.GetID(record)] = record
+	}
+	ordered := make([]T, 0, len(records))
+	for _, id := range ids {
+		if record, ok := byID[id]; ok {
+			ordered = append(ordered, record)
+		}
+	}
+	return ordered, nil
+}
+
+// Reservoir keeps a random sample of at most n items from a stream of
+// unknown length in O(n) memory, using the Efraimidis-Spirakis algorithm:
+// each item gets the key log(u)/weight for a uniform u, and the n largest
+// keys are kept. Items added with Add share the same weight, which makes
+// the sample uniform.
+type Reservoir[T any] struct {
+	size    int
+	entries reservoirHeap[T]
+}
+
+// NewReservoir returns an empty Reservoir holding up to n items.
+func NewReservoir[T any](n int) *Reservoir[T] {
+	return &Reservoir[T]{size: max(n, 0)}
+}
+
+// Add offers item with weight 1.
+func (r *Reservoir[T]) Add(item T) {
+	r.AddWeighted(item, 1)
+}
+
+// AddWeighted offers item with weight. Items with a zero, negative or NaN
+// weight are ignored.
+func (r *Reservoir[T]) AddWeighted(item T, weight float64) {
+	if r.size == 0 || !(weight > 0) || math.IsInf(weight, 1) {
+		return
+	}
+	key := math.Log(1-rand.Float64()) / weight
+	if len(r.entries) < r.size {
+		heap.Push(&r.entries, reservoirEntry[T]{key: key, item: item})
+		return
+	}
+	if key > r.entries[0].key {
+		r.entries[0] = reservoirEntry[T]{key: key, item: item}
+		heap.Fix(&r.entries, 0)
+	}
+}
+
+// Len returns the number of items currently sampled.
+func (r *Reservoir[T]) Len() int {
+	return len(r.entries)
+}
+
+// Items returns the sampled items, from the largest key down.
+func (r *Reservoir[T]) Items() []T {
+	sorted := make(reservoirHeap[T], len(r.entries))
+	copy(sorted, r.entries)
+	items := make([]T, len(sorted))
+	for i := len(items) - 1; i >= 0; i-- {
+		items[i] = heap.Pop(&sorted).(reservoirEntry[T]).item
+	}
+	return items
+}
+
+type reservoirEntry[T any] struct {
+	key  float64
+	item T
+}
+
+// reservoirHeap is a min-heap on key, so the weakest entry is replaced first.
+type reservoirHeap[T any] []reservoirEntry[T]
+
+func (h reservoirHeap[T]) Len() int           { return len(h) }
+func (h reservoirHeap[T]) Less(i, j int) bool { return h[i].key < h[j].key }
+func (h reservoirHeap[T]) Swap(i, j int)      { h[i], h[j] = h[j], h[i] }
+
+func (h *reservoirHeap[T]) Push(x any) {
+	*h = append(*h, x.(reservoirEntry[T]))
+}
+
+func (h *reservoirHeap[T]) Pop() any {
+	old := *h
+	entry := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return entry
+}
@@ -0,0 +1,155 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/uptrace/bun"
+	"github.com/uptrace/bun/dialect/mysqldialect"
+	"github.com/uptrace/bun/dialect/pgdialect"
+)
+
+type sampleTestItem struct {
+	bun.BaseModel `bun:"table:sample_test_items,alias:sti"`
+
+	ID     uuid.UUID `bun:"id,pk,notnull"`
+	Name   string    `bun:"name,notnull"`
+	Group  string    `bun:"grp,notnull"`
+	Weight *float64  `bun:"weight"`
+}
+
+func newSampleTestItemRepository(t *testing.T, items ...*sampleTestItem) Repository[*sampleTestItem] {
+	t.Helper()
+	repo := newTestRepository[*sampleTestItem](t)
+	if len(items) > 0 {
+		_, err := repo.CreateMany(context.Background(), items)
+		require.NoError(t, err)
+	}
+	return repo
+}
+
+func sampleWeight(value float64) *float64 {
+	return &value
+}
+
+func TestRepository_Sample(t *testing.T) {
+	ctx := context.Background()
+	items := make([]*sampleTestItem, 0, 20)
+	for i := range 20 {
+		group := "even"
+		if i%2 == 1 {
+			group = "odd"
+		}
+		items = append(items, &sampleTestItem{Name: fmt.Sprintf("item-%d", i), Group: group})
+	}
+	repo := newSampleTestItemRepository(t, items...)
+
+	sampler, ok := repo.(RecordSampler[*sampleTestItem])
+	require.True(t, ok)
+
+	sample, err := sampler.Sample(ctx, 5)
+	require.NoError(t, err)
+	require.Len(t, sample, 5)
+	seen := map[uuid.UUID]bool{}
+	for _, item := range sample {
+		assert.False(t, seen[item.ID], "samples have no duplicates")
+		seen[item.ID] = true
+	}
+
+	sample, err = sampler.Sample(ctx, 50, SelectBy("grp", "=", "odd"))
+	require.NoError(t, err)
+	require.Len(t, sample, 10)
+	for _, item := range sample {
+		assert.Equal(t, "odd", item.Group)
+	}
+
+	sample, err = sampler.Sample(ctx, 0)
+	require.NoError(t, err)
+	assert.Empty(t, sample)
+}
+
+func TestSelectRandomOrder(t *testing.T) {
+	sql := db.NewSelect().Model((*TestUser)(nil)).Apply(SelectRandomOrder()).String()
+	assert.Contains(t, sql, "ORDER BY RANDOM()")
+
+	pgDB := newDialectTestDB(t, pgdialect.New())
+	sql = pgDB.NewSelect().Model((*TestUser)(nil)).Apply(SelectRandomOrder()).String()
+	assert.Contains(t, sql, "ORDER BY RANDOM()")
+
+	mysqlDB := newDialectTestDB(t, mysqldialect.New())
+	sql = mysqlDB.NewSelect().Model((*TestUser)(nil)).Apply(SelectRandomOrder()).String()
+	assert.Contains(t, sql, "ORDER BY RAND()")
+
+	setupTestData(t)
+	repo := newTestUserRepository(db)
+	seedExportUsers(t, repo, 3)
+	users, _, err := repo.List(context.Background(), SelectRandomOrder(), SelectPaginate(2, 0))
+	require.NoError(t, err)
+	assert.Len(t, users, 2)
+}
+
+func TestRepository_SampleWeighted(t *testing.T) {
+	ctx := context.Background()
+	repo := newSampleTestItemRepository(t,
+		&sampleTestItem{Name: "heavy", Group: "a", Weight: sampleWeight(1000)},
+		&sampleTestItem{Name: "light", Group: "a", Weight: sampleWeight(1)},
+		&sampleTestItem{Name: "zero", Group: "a", Weight: sampleWeight(0)},
+		&sampleTestItem{Name: "null", Group: "a"},
+		&sampleTestItem{Name: "other", Group: "b", Weight: sampleWeight(1000)},
+	)
+	sampler := repo.(RecordSampler[*sampleTestItem])
+
+	sample, err := sampler.SampleWeighted(ctx, 5, "weight", SelectBy("grp", "=", "a"))
+	require.NoError(t, err)
+	require.Len(t, sample, 2, "zero and NULL weights are never picked")
+	names := []string{sample[0].Name, sample[1].Name}
+	assert.ElementsMatch(t, []string{"heavy", "light"}, names)
+
+	heavyFirst := 0
+	for range 50 {
+		sample, err = sampler.SampleWeighted(ctx, 1, "weight", SelectBy("grp", "=", "a"))
+		require.NoError(t, err)
+		require.Len(t, sample, 1)
+		if sample[0].Name == "heavy" {
+			heavyFirst++
+		}
+	}
+	assert.Greater(t, heavyFirst, 40)
+
+	_, err = sampler.SampleWeighted(ctx, 1, "missing")
+	require.Error(t, err)
+	_, err = sampler.SampleWeighted(ctx, 1, "weight; DROP")
+	require.Error(t, err)
+}
+
+func TestReservoir(t *testing.T) {
+	reservoir := NewReservoir[int](3)
+	for i := range 100 {
+		reservoir.Add(i)
+	}
+	assert.Equal(t, 3, reservoir.Len())
+	items := reservoir.Items()
+	require.Len(t, items, 3)
+	assert.NotEqual(t, items[0], items[1])
+
+	reservoir = NewReservoir[int](10)
+	reservoir.Add(1)
+	reservoir.AddWeighted(2, 0)
+	reservoir.AddWeighted(3, -1)
+	assert.Equal(t, []int{1}, reservoir.Items())
+
+	counts := map[string]int{}
+	for range 1000 {
+		reservoir := NewReservoir[string](1)
+		reservoir.AddWeighted("a", 1)
+		reservoir.AddWeighted("b", 3)
+		counts[reservoir.Items()[0]]++
+	}
+	assert.InDelta(t, 750, counts["b"], 100)
+
+	assert.Empty(t, NewReservoir[int](0).Items())
+}
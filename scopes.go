@@ -3,28 +3,14 @@ package repository
 import (
 	"context"
 	"fmt"
-	"maps"
 	"slices"
 	"strings"
 
+	"github.com/goliatone/go-repository-bun/repositoryctx"
 	"github.com/google/uuid"
 	"github.com/uptrace/bun"
 )
 
-type scopeContextKey struct{}
-
-type scopeContextConfig struct {
-	useDefaults bool
-
-	all     []string
-	selects []string
-	updates []string
-	inserts []string
-	deletes []string
-
-	data map[string]any
-}
-
 // ScopeOperation identifies the repository operation type used when applying scopes.
 type ScopeOperation int
 
@@ -49,68 +35,62 @@ func (s ScopeState) IsZero() bool {
 }
 
 func WithScopes(ctx context.Context, names ...string) context.Context {
-	return withScopeNames(ctx, names, func(cfg *scopeContextConfig) *[]string {
-		return &cfg.all
+	return withScopeNames(ctx, names, func(scopes *repositoryctx.Scopes) *[]string {
+		return &scopes.All
 	})
 }
 
 func WithSelectScopes(ctx context.Context, names ...string) context.Context {
-	return withScopeNames(ctx, names, func(cfg *scopeContextConfig) *[]string {
-		return &cfg.selects
+	return withScopeNames(ctx, names, func(scopes *repositoryctx.Scopes) *[]string {
+		return &scopes.Select
 	})
 }
 
 func WithUpdateScopes(ctx context.Context, names ...string) context.Context {
-	return withScopeNames(ctx, names, func(cfg *scopeContextConfig) *[]string {
-		return &cfg.updates
+	return withScopeNames(ctx, names, func(scopes *repositoryctx.Scopes) *[]string {
+		return &scopes.Update
 	})
 }
 
 func WithInsertScopes(ctx context.Context, names ...string) context.Context {
-	return withScopeNames(ctx, names, func(cfg *scopeContextConfig) *[]string {
-		return &cfg.inserts
+	return withScopeNames(ctx, names, func(scopes *repositoryctx.Scopes) *[]string {
+		return &scopes.Insert
 	})
 }
 
 func WithDeleteScopes(ctx context.Context, names ...string) context.Context {
-	return withScopeNames(ctx, names, func(cfg *scopeContextConfig) *[]string {
-		return &cfg.deletes
+	return withScopeNames(ctx, names, func(scopes *repositoryctx.Scopes) *[]string {
+		return &scopes.Delete
 	})
 }
 
 func WithScopeData(ctx context.Context, name string, data any) context.Context {
-	cfg := cloneScopeContextConfig(scopeConfigFromContext(ctx))
-	if cfg.data == nil {
-		cfg.data = make(map[string]any)
+	scopes, _ := repositoryctx.ScopesFrom(ctx)
+	if scopes.Data == nil {
+		scopes.Data = make(map[string]any)
 	}
-	cfg.data[strings.TrimSpace(name)] = data
-	return context.WithValue(ctx, scopeContextKey{}, cfg)
+	scopes.Data[strings.TrimSpace(name)] = data
+	return repositoryctx.WithScopes(ctx, scopes)
 }
 
 func ScopeData(ctx context.Context, name string) (any, bool) {
-	cfg := scopeConfigFromContext(ctx)
-	if cfg == nil || cfg.data == nil {
-		return nil, false
-	}
-	val, ok := cfg.data[strings.TrimSpace(name)]
+	scopes, _ := repositoryctx.ScopesFrom(ctx)
+	val, ok := scopes.Data[strings.TrimSpace(name)]
 	return val, ok
 }
 
 func ScopeDataSnapshot(ctx context.Context) map[string]any {
-	cfg := scopeConfigFromContext(ctx)
-	if cfg == nil || cfg.data == nil {
+	scopes, _ := repositoryctx.ScopesFrom(ctx)
+	if scopes.Data == nil {
 		return nil
 	}
-
-	snapshot := make(map[string]any, len(cfg.data))
-	maps.Copy(snapshot, cfg.data)
-	return snapshot
+	return scopes.Data
 }
 
 func WithoutDefaultScopes(ctx context.Context) context.Context {
-	cfg := cloneScopeContextConfig(scopeConfigFromContext(ctx))
-	cfg.useDefaults = false
-	return context.WithValue(ctx, scopeContextKey{}, cfg)
+	scopes, _ := repositoryctx.ScopesFrom(ctx)
+	scopes.SkipDefaults = true
+	return repositoryctx.WithScopes(ctx, scopes)
 }
 
 func ResolveScopeState(ctx context.Context, defaults ScopeDefaults, op ScopeOperation) ScopeState {
@@ -154,75 +134,41 @@ func CloneScopeDefaults(defaults ScopeDefaults) ScopeDefaults {
 	}
 }
 
-func scopeConfigFromContext(ctx context.Context) *scopeContextConfig {
-	if ctx == nil {
-		return nil
-	}
-	if cfg, ok := ctx.Value(scopeContextKey{}).(*scopeContextConfig); ok {
-		return cfg
-	}
-	return nil
-}
-
 func scopeNamesForOperation(ctx context.Context, op ScopeOperation) ([]string, bool) {
-	cfg := scopeConfigFromContext(ctx)
-	if cfg == nil {
+	scopes, ok := repositoryctx.ScopesFrom(ctx)
+	if !ok {
 		return nil, true
 	}
 
 	var selection []string
 	switch op {
 	case ScopeOperationSelect:
-		selection = cfg.selects
+		selection = scopes.Select
 	case ScopeOperationUpdate:
-		selection = cfg.updates
+		selection = scopes.Update
 	case ScopeOperationInsert:
-		selection = cfg.inserts
+		selection = scopes.Insert
 	case ScopeOperationDelete:
-		selection = cfg.deletes
+		selection = scopes.Delete
 	default:
 		selection = nil
 	}
 
-	combined := append([]string{}, cfg.all...)
+	combined := append([]string{}, scopes.All...)
 	if len(selection) > 0 {
 		combined = append(combined, selection...)
 	}
 
-	return combined, cfg.useDefaults
-}
-
-func cloneScopeContextConfig(cfg *scopeContextConfig) *scopeContextConfig {
-	if cfg == nil {
-		return &scopeContextConfig{
-			useDefaults: true,
-		}
-	}
-
-	cloned := &scopeContextConfig{
-		useDefaults: cfg.useDefaults,
-		all:         copyStrings(cfg.all),
-		selects:     copyStrings(cfg.selects),
-		updates:     copyStrings(cfg.updates),
-		inserts:     copyStrings(cfg.inserts),
-		deletes:     copyStrings(cfg.deletes),
-	}
-
-	if cfg.data != nil {
-		cloned.data = make(map[string]any, len(cfg.data))
-		maps.Copy(cloned.data, cfg.data)
-	}
-
-	return cloned
+	return combined, !scopes.SkipDefaults
 }
 
-func withScopeNames(ctx context.Context, names []string, target func(*scopeContextConfig) *[]string) context.Context {
+func withScopeNames(ctx context.Context, names []string, target func(*repositoryctx.Scopes) *[]string) context.Context {
 	if len(names) == 0 {
 		return ctx
 	}
 
-	cfg := cloneScopeContextConfig(scopeConfigFromContext(ctx))
-	dest := target(cfg)
+	scopes, _ := repositoryctx.ScopesFrom(ctx)
+	dest := target(&scopes)
 
 	for _, name := range names {
 		if trimmed := strings.TrimSpace(name); trimmed != "" && !containsString(*dest, trimmed) {
@@ -230,7 +176,7 @@ func withScopeNames(ctx context.Context, names []string, target func(*scopeConte
 		}
 	}
 
-	return context.WithValue(ctx, scopeContextKey{}, cfg)
+	return repositoryctx.WithScopes(ctx, scopes)
 }
 
 func containsString(list []string, value string) bool {
@@ -299,7 +245,7 @@ func scopeByField(scopeName, field string, required bool) ScopeDefinition {
 	return ScopeDefinition{
 		Select: func(ctx context.Context) []SelectCriteria {
 			if value, ok := scopeFieldValue(ctx, scopeName); ok {
-				return []SelectCriteria{SelectBy(field, "=", value)}
+				return []SelectCriteria{selectCompare(field, "=", value)}
 			}
 			if required {
 				return noMatchSelectCriteria()
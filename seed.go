@@ -0,0 +1,262 @@
+package repository
+
+import (
+	"context"
+	stderrors "errors"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+)
+
+var (
+	// ErrSeedNotFound is returned for unknown seed names and dependencies.
+	ErrSeedNotFound = stderrors.New("repository: seed not found")
+	// ErrSeedAlreadyRegistered is returned by RegisterSeed for duplicate names.
+	ErrSeedAlreadyRegistered = stderrors.New("repository: seed already registered")
+	// ErrSeedCycle is returned when seed dependencies form a cycle.
+	ErrSeedCycle = stderrors.New("repository: seed dependency cycle")
+	// ErrDestructiveSeedBlocked is returned when a run includes a destructive
+	// seed and the seeder was not built with WithDestructiveSeeds(true).
+	ErrDestructiveSeedBlocked = stderrors.New("repository: destructive seed not allowed")
+)
+
+// SeedFunc populates data through the repositories of reg.
+type SeedFunc func(ctx context.Context, reg *Registry) error
+
+// SeedOption configures a seed registered with RegisterSeed.
+type SeedOption func(*seed)
+
+// SeedDependsOn runs the named seeds before this one.
+func SeedDependsOn(names ...string) SeedOption {
+	return func(s *seed) {
+		for _, name := range names {
+			if name = strings.TrimSpace(name); name != "" {
+				s.dependsOn = append(s.dependsOn, name)
+			}
+		}
+	}
+}
+
+// SeedEnvironments restricts the seed to the given environments, matched
+// case insensitively against WithSeedEnvironment. In other environments it is
+// skipped, while its dependencies still run.
+func SeedEnvironments(environments ...string) SeedOption {
+	return func(s *seed) {
+		for _, env := range environments {
+			if env = strings.TrimSpace(env); env != "" {
+				s.environments = append(s.environments, strings.ToLower(env))
+			}
+		}
+	}
+}
+
+// SeedDestructive marks a seed that deletes or overwrites data. Runs that
+// include it fail with ErrDestructiveSeedBlocked unless the seeder was built
+// with WithDestructiveSeeds(true).
+func SeedDestructive() SeedOption {
+	return func(s *seed) {
+		s.destructive = true
+	}
+}
+
+// SeederOption configures NewSeeder.
+type SeederOption func(*Seeder)
+
+// WithSeedEnvironment sets the environment matched by SeedEnvironments,
+// e.g. os.Getenv("APP_ENV").
+func WithSeedEnvironment(environment string) SeederOption {
+	return func(s *Seeder) {
+		s.environment = strings.ToLower(strings.TrimSpace(environment))
+	}
+}
+
+// WithDestructiveSeeds allows seeds marked with SeedDestructive to run.
+// Defaults to false for safety.
+func WithDestructiveSeeds(allowed bool) SeederOption {
+	return func(s *Seeder) {
+		s.allowDestructive = allowed
+	}
+}
+
+// SeedResult reports one seed of a Seeder run.
+type SeedResult struct {
+	Name string
+	// Skipped is set when the seed does not apply to the seeder environment.
+	Skipped bool
+}
+
+type seed struct {
+	name         string
+	run          SeedFunc
+	dependsOn    []string
+	environments []string
+	destructive  bool
+}
+
+// Seeder runs named data seeds through the repositories of a Registry, in
+// dependency order:
+//
+//	seeder := NewSeeder(registry, WithSeedEnvironment(os.Getenv("APP_ENV")))
+//	seeder.RegisterSeed("companies", seedCompanies)
+//	seeder.RegisterSeed("users", seedUsers, SeedDependsOn("companies"), SeedEnvironments("dev", "staging"))
+//	results, err := seeder.Run(ctx)
+//
+// Seeds should be idempotent, e.g. by creating records with SeedRecords, so
+// runs can be repeated.
+type Seeder struct {
+	registry         *Registry
+	environment      string
+	allowDestructive bool
+
+	mu    sync.Mutex
+	seeds map[string]*seed
+	order []string
+}
+
+// NewSeeder returns a seeder running seeds against reg.
+func NewSeeder(reg *Registry, opts ...SeederOption) *Seeder {
+	s := &Seeder{
+		registry: reg,
+		seeds:    make(map[string]*seed),
+	}
+	for _, opt := range opts {
+		if opt != nil {
+			opt(s)
+		}
+	}
+	return s
+}
+
+// RegisterSeed adds a seed under name. Dependencies may be registered later
+// and are resolved by Run.
+func (s *Seeder) RegisterSeed(name string, fn SeedFunc, opts ...SeedOption) error {
+	name = strings.TrimSpace(name)
+	if name == "" || fn == nil {
+		return fmt.Errorf("repository: seed requires a name and a function")
+	}
+
+	entry := &seed{name: name, run: fn}
+	for _, opt := range opts {
+		if opt != nil {
+			opt(entry)
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.seeds[name]; ok {
+		return fmt.Errorf("%w: %s", ErrSeedAlreadyRegistered, name)
+	}
+	s.seeds[name] = entry
+	s.order = append(s.order, name)
+	return nil
+}
+
+// Run executes the named seeds and their dependencies, or every registered
+// seed when names is empty, each after its dependencies and otherwise in
+// registration order. Unknown names, dependency cycles and blocked
+// destructive seeds are reported before any seed runs. Run stops at the
+// first failing seed and returns the results of the seeds already run.
+func (s *Seeder) Run(ctx context.Context, names ...string) ([]SeedResult, error) {
+	plan, err := s.plan(names)
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]SeedResult, 0, len(plan))
+	for _, entry := range plan {
+		if !s.appliesTo(entry) {
+			results = append(results, SeedResult{Name: entry.name, Skipped: true})
+			continue
+		}
+		if err := entry.run(ctx, s.registry); err != nil {
+			return results, fmt.Errorf("repository: seed %s: %w", entry.name, err)
+		}
+		results = append(results, SeedResult{Name: entry.name})
+	}
+	return results, nil
+}
+
+// plan resolves names into seeds sorted by dependencies and checks guards.
+func (s *Seeder) plan(names []string) ([]*seed, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if len(names) == 0 {
+		names = s.order
+	}
+
+	const (
+		visiting = 1
+		visited  = 2
+	)
+	state := make(map[string]int, len(s.seeds))
+	plan := make([]*seed, 0, len(s.seeds))
+
+	var visit func(name string, path []string) error
+	visit = func(name string, path []string) error {
+		entry, ok := s.seeds[name]
+		if !ok {
+			if len(path) > 0 {
+				return fmt.Errorf("%w: %s (required by %s)", ErrSeedNotFound, name, path[len(path)-1])
+			}
+			return fmt.Errorf("%w: %s", ErrSeedNotFound, name)
+		}
+		switch state[name] {
+		case visited:
+			return nil
+		case visiting:
+			return fmt.Errorf("%w: %s", ErrSeedCycle, strings.Join(append(path, name), " -> "))
+		}
+
+		state[name] = visiting
+		for _, dep := range entry.dependsOn {
+			if err := visit(dep, append(slices.Clip(path), name)); err != nil {
+				return err
+			}
+		}
+		state[name] = visited
+		plan = append(plan, entry)
+		return nil
+	}
+
+	for _, name := range names {
+		if err := visit(strings.TrimSpace(name), nil); err != nil {
+			return nil, err
+		}
+	}
+
+	if !s.allowDestructive {
+		for _, entry := range plan {
+			if entry.destructive && s.appliesTo(entry) {
+				return nil, fmt.Errorf("%w: %s", ErrDestructiveSeedBlocked, entry.name)
+			}
+		}
+	}
+	return plan, nil
+}
+
+func (s *Seeder) appliesTo(entry *seed) bool {
+	return len(entry.environments) == 0 || slices.Contains(entry.environments, s.environment)
+}
+
+// SeedRecords creates records through the repository registered for T with
+// GetOrCreate, so seeds can be run repeatedly without duplicating rows.
+// Records are matched by ID, identifier or WithRecordLookupResolver.
+func SeedRecords[T any](ctx context.Context, reg *Registry, records ...T) ([]T, error) {
+	repo, err := Repo[T](reg)
+	if err != nil {
+		return nil, err
+	}
+
+	seeded := make([]T, 0, len(records))
+	for _, record := range records {
+		result, err := repo.GetOrCreate(ctx, record)
+		if err != nil {
+			return seeded, err
+		}
+		seeded = append(seeded, result)
+	}
+	return seeded, nil
+}
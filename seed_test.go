@@ -0,0 +1,92 @@
+package repository
+
+import (
+	"context"
+	stderrors "errors"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSeeder_RunsInDependencyOrder(t *testing.T) {
+	setupTestData(t)
+	ctx := context.Background()
+
+	registry := NewRegistry(db, nil)
+	MustRegister(registry, testUserHandlers())
+	MustRegister(registry, ModelHandlers[*TestCompany]{
+		NewRecord:          func() *TestCompany { return &TestCompany{} },
+		GetID:              func(record *TestCompany) uuid.UUID { return record.ID },
+		SetID:              func(record *TestCompany, id uuid.UUID) { record.ID = id },
+		GetIdentifier:      func() string { return "identifier" },
+		GetIdentifierValue: func(record *TestCompany) string { return record.Identifier },
+	})
+
+	seeder := NewSeeder(registry, WithSeedEnvironment("Production"))
+	var ran []string
+	require.NoError(t, seeder.RegisterSeed("users", func(ctx context.Context, reg *Registry) error {
+		ran = append(ran, "users")
+		company, err := MustRepo[*TestCompany](reg).GetByIdentifier(ctx, "acme")
+		if err != nil {
+			return err
+		}
+		_, err = SeedRecords(ctx, reg, &TestUser{Name: "Alice", Email: "alice@example.com", CompanyID: company.ID})
+		return err
+	}, SeedDependsOn("companies")))
+	require.NoError(t, seeder.RegisterSeed("companies", func(ctx context.Context, reg *Registry) error {
+		ran = append(ran, "companies")
+		_, err := SeedRecords(ctx, reg, &TestCompany{Name: "Acme", Identifier: "acme"})
+		return err
+	}))
+	require.NoError(t, seeder.RegisterSeed("demo", func(context.Context, *Registry) error {
+		ran = append(ran, "demo")
+		return nil
+	}, SeedEnvironments("dev", "staging")))
+
+	results, err := seeder.Run(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"companies", "users"}, ran)
+	assert.Equal(t, []SeedResult{{Name: "companies"}, {Name: "users"}, {Name: "demo", Skipped: true}}, results)
+
+	_, err = seeder.Run(ctx, "users")
+	require.NoError(t, err, "seeds are idempotent")
+	total, err := MustRepo[*TestUser](registry).Count(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, total)
+
+	assert.ErrorIs(t, seeder.RegisterSeed("users", func(context.Context, *Registry) error { return nil }), ErrSeedAlreadyRegistered)
+}
+
+func TestSeeder_Guards(t *testing.T) {
+	ctx := context.Background()
+	noop := func(context.Context, *Registry) error { return nil }
+
+	seeder := NewSeeder(NewRegistry(db, nil), WithSeedEnvironment("dev"))
+	require.NoError(t, seeder.RegisterSeed("reset", noop, SeedDestructive()))
+	require.NoError(t, seeder.RegisterSeed("a", noop, SeedDependsOn("b")))
+	require.NoError(t, seeder.RegisterSeed("b", noop, SeedDependsOn("a")))
+	require.NoError(t, seeder.RegisterSeed("orphan", noop, SeedDependsOn("missing")))
+	require.NoError(t, seeder.RegisterSeed("prod-reset", noop, SeedDestructive(), SeedEnvironments("prod")))
+
+	_, err := seeder.Run(ctx, "reset")
+	assert.ErrorIs(t, err, ErrDestructiveSeedBlocked)
+	_, err = seeder.Run(ctx, "prod-reset")
+	assert.NoError(t, err, "destructive seeds of other environments are skipped")
+	_, err = seeder.Run(ctx, "a")
+	assert.ErrorIs(t, err, ErrSeedCycle)
+	assert.Contains(t, err.Error(), "a -> b -> a")
+	_, err = seeder.Run(ctx, "orphan")
+	assert.ErrorIs(t, err, ErrSeedNotFound)
+	_, err = seeder.Run(ctx, "unknown")
+	assert.ErrorIs(t, err, ErrSeedNotFound)
+
+	allowed := NewSeeder(NewRegistry(db, nil), WithDestructiveSeeds(true))
+	boom := stderrors.New("boom")
+	require.NoError(t, allowed.RegisterSeed("reset", noop, SeedDestructive()))
+	require.NoError(t, allowed.RegisterSeed("fail", func(context.Context, *Registry) error { return boom }, SeedDependsOn("reset")))
+	results, err := allowed.Run(ctx)
+	assert.ErrorIs(t, err, boom)
+	assert.Equal(t, []SeedResult{{Name: "reset"}}, results)
+}
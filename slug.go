@@ -0,0 +1,173 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/goliatone/go-errors"
+	"github.com/uptrace/bun"
+)
+
+type slugConfig struct {
+	source string
+	column string
+}
+
+// WithSlug generates a URL-safe slug from sourceColumn into slugColumn when
+// Create, CreateMany, GetOrCreate or CreateIgnoreDuplicate insert a record
+// with an empty slug, e.g. WithSlug("title", "slug")
+// turns "Hello, World!" into "hello-world". Slugs already taken, including
+// by soft deleted rows, get a numeric suffix: "hello-world-2", "-3" and so
+// on. Both columns must be string fields; unknown columns are reported by
+// Validate. Keep a unique index on slugColumn, concurrent creates can still
+// pick the same slug.
+func WithSlug(sourceColumn, slugColumn string) RepoOption {
+	return func(cfg *repoConfig) {
+		if cfg == nil {
+			return
+		}
+		cfg.slug = &slugConfig{
+			source: strings.TrimSpace(sourceColumn),
+			column: strings.TrimSpace(slugColumn),
+		}
+	}
+}
+
+var slugFolds = map[rune]string{
+	'à': "a", 'á': "a", 'â': "a", 'ã': "a", 'ä': "a", 'å': "a", 'æ': "ae",
+	'ç': "c", 'è': "e", 'é': "e", 'ê': "e", 'ë': "e", 'ì': "i", 'í': "i",
+	'î': "i", 'ï': "i", 'ñ': "n", 'ò': "o", 'ó': "o", 'ô': "o", 'õ': "o",
+	'ö': "o", 'ø': "o", 'ß': "ss", 'ù': "u", 'ú': "u", 'û': "u", 'ü': "u",
+	'ý': "y", 'ÿ': "y", 'œ': "oe",
+}
+
+// Slugify returns the URL-safe slug of s: lower case ASCII letters and
+// digits separated by single dashes. Common Latin accents are folded ("é"
+// becomes "e") and other characters act as separators.
+func Slugify(s string) string {
+	var b strings.Builder
+	dash := false
+	for _, c := range strings.ToLower(s) {
+		var part string
+		switch {
+		case c < unicode.MaxASCII && (unicode.IsLetter(c) || unicode.IsDigit(c)):
+			part = string(c)
+		case slugFolds[c] != "":
+			part = slugFolds[c]
+		default:
+			dash = b.Len() > 0
+			continue
+		}
+		if dash {
+			b.WriteByte('-')
+			dash = false
+		}
+		b.WriteString(part)
+	}
+	return b.String()
+}
+
+// assignSlugs fills the empty slugs of records, unique among the stored rows
+// and the records themselves.
+func (r *repo[T]) assignSlugs(ctx context.Context, tx bun.IDB, records ...T) error {
+	if r.slug == nil {
+		return nil
+	}
+	table := r.modelTable()
+	if table == nil {
+		return nil
+	}
+	source, okSource := table.FieldMap[r.slug.source]
+	column, okColumn := table.FieldMap[r.slug.column]
+	if !okSource || !okColumn {
+		return r.validateSlug()
+	}
+
+	taken := map[string]bool{}
+	for _, record := range records {
+		value := reflect.Indirect(reflect.ValueOf(record))
+		slugValue := column.Value(value)
+		if slugValue.Kind() != reflect.String || slugValue.String() != "" {
+			continue
+		}
+		sourceValue := source.Value(value)
+		if sourceValue.Kind() != reflect.String {
+			continue
+		}
+		base := Slugify(sourceValue.String())
+		if base == "" {
+			continue
+		}
+
+		slug, err := r.uniqueSlug(ctx, tx, base, taken)
+		if err != nil {
+			return err
+		}
+		taken[slug] = true
+		slugValue.SetString(slug)
+	}
+	return nil
+}
+
+// uniqueSlug returns base, or base with the lowest free numeric suffix.
+func (r *repo[T]) uniqueSlug(ctx context.Context, tx bun.IDB, base string, taken map[string]bool) (string, error) {
+	var existing []string
+	q := tx.NewSelect().
+		Model(r.handlers.NewRecord()).
+		ColumnExpr("?TableAlias.?", bun.Ident(r.slug.column)).
+		WhereGroup(" AND ", func(q *bun.SelectQuery) *bun.SelectQuery {
+			return q.Where("?TableAlias.? = ?", bun.Ident(r.slug.column), base).
+				WhereOr("?TableAlias.? LIKE ?", bun.Ident(r.slug.column), base+"-%")
+		})
+	if querySoftDeletes(q) {
+		q = q.WhereAllWithDeleted()
+	}
+	if err := q.Scan(ctx, &existing); err != nil {
+		return "", r.mapError(err)
+	}
+	for _, slug := range existing {
+		taken[slug] = true
+	}
+
+	slug := base
+	for n := 2; taken[slug]; n++ {
+		slug = base + "-" + strconv.Itoa(n)
+	}
+	return slug, nil
+}
+
+func (r *repo[T]) validateSlug() error {
+	if r.slug == nil {
+		return nil
+	}
+	table := r.modelTable()
+	if table == nil {
+		return nil
+	}
+
+	var validationErrors errors.ValidationErrors
+	for _, column := range []string{r.slug.source, r.slug.column} {
+		field, ok := table.FieldMap[column]
+		switch {
+		case !ok:
+			validationErrors = append(validationErrors, errors.FieldError{
+				Field:   "repoOptions.WithSlug",
+				Message: fmt.Sprintf("unknown column %q on %s", column, table.Name),
+			})
+		case field.IndirectType.Kind() != reflect.String:
+			validationErrors = append(validationErrors, errors.FieldError{
+				Field:   "repoOptions.WithSlug",
+				Message: fmt.Sprintf("column %q on %s is not a string", column, table.Name),
+			})
+		}
+	}
+
+	if len(validationErrors) > 0 {
+		return errors.NewValidation("repository configuration invalid", validationErrors...)
+	}
+	return nil
+}
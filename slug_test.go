@@ -0,0 +1,76 @@
+package repository
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/uptrace/bun"
+)
+
+type slugTestPost struct {
+	bun.BaseModel `bun:"table:slug_test_posts,alias:stp"`
+
+	ID    uuid.UUID `bun:"id,pk,notnull"`
+	Title string    `bun:"title,notnull"`
+	Slug  string    `bun:"slug,notnull,unique"`
+}
+
+func newSlugTestRepository(t *testing.T, opts ...RepoOption) Repository[*slugTestPost] {
+	t.Helper()
+	return newTestRepository[*slugTestPost](t, opts...)
+}
+
+func TestSlugify(t *testing.T) {
+	cases := map[string]string{
+		"Hello, World!":          "hello-world",
+		"  Crème brûlée  recipe": "creme-brulee-recipe",
+		"Straße 42":              "strasse-42",
+		"---":                    "",
+		"Go 1.23 — release":      "go-1-23-release",
+	}
+	for input, expected := range cases {
+		assert.Equal(t, expected, Slugify(input), input)
+	}
+}
+
+func TestRepository_WithSlug(t *testing.T) {
+	ctx := context.Background()
+	repo := newSlugTestRepository(t, WithSlug("title", "slug"))
+	require.NoError(t, repo.(Validator).Validate())
+
+	first, err := repo.Create(ctx, &slugTestPost{Title: "Hello World"})
+	require.NoError(t, err)
+	assert.Equal(t, "hello-world", first.Slug)
+
+	second, err := repo.Create(ctx, &slugTestPost{Title: "Hello, world!"})
+	require.NoError(t, err)
+	assert.Equal(t, "hello-world-2", second.Slug)
+
+	custom, err := repo.Create(ctx, &slugTestPost{Title: "Hello World", Slug: "custom"})
+	require.NoError(t, err)
+	assert.Equal(t, "custom", custom.Slug)
+
+	many, err := repo.CreateMany(ctx, []*slugTestPost{
+		{Title: "Hello World"},
+		{Title: "hello world"},
+		{Title: "Other"},
+	})
+	require.NoError(t, err)
+	slugs := []string{many[0].Slug, many[1].Slug, many[2].Slug}
+	assert.ElementsMatch(t, []string{"hello-world-3", "hello-world-4", "other"}, slugs)
+}
+
+func TestRepository_WithSlug_Validate(t *testing.T) {
+	repo := newSlugTestRepository(t, WithSlug("missing", "slug"))
+	err := repo.(Validator).Validate()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "repository configuration invalid")
+
+	userRepo := NewRepositoryWithConfig(db, testUserHandlers(), nil, WithSlug("created_at", "name"))
+	err = userRepo.(Validator).Validate()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "is not a string")
+}
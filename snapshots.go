@@ -0,0 +1,185 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+	"time"
+
+	"github.com/goliatone/go-errors"
+	"github.com/uptrace/bun"
+)
+
+// VersionID identifies a record snapshot in the record versions table.
+type VersionID int64
+
+// RecordVersion stores a RecordToMap payload of a record, keyed by the
+// repository table and record ID. Encrypted columns keep their ciphertext.
+type RecordVersion struct {
+	bun.BaseModel `bun:"table:repository_record_versions,alias:rrv"`
+
+	ID        VersionID `bun:"id,pk,autoincrement" json:"id"`
+	Entity    string    `bun:"entity,notnull" json:"entity"`
+	RecordID  string    `bun:"record_id,notnull" json:"record_id"`
+	Payload   string    `bun:"payload,notnull" json:"payload"`
+	CreatedAt time.Time `bun:"created_at,notnull" json:"created_at"`
+}
+
+// CreateRecordVersionTable creates the table backing RecordSnapshotter if it
+// does not exist yet. One table serves every repository of the database.
+func CreateRecordVersionTable(ctx context.Context, db bun.IDB) error {
+	_, err := db.NewCreateTable().
+		Model((*RecordVersion)(nil)).
+		IfNotExists().
+		Exec(ctx)
+	return err
+}
+
+// RecordSnapshotter stores snapshots of single records and rolls records back
+// to them, as a lightweight undo that needs neither WithHistory nor a table
+// per model. The versions table must exist, see CreateRecordVersionTable.
+type RecordSnapshotter[T any] interface {
+	Snapshot(ctx context.Context, id string) (VersionID, error)
+	SnapshotTx(ctx context.Context, tx bun.IDB, id string) (VersionID, error)
+	RollbackTo(ctx context.Context, id string, version VersionID) (T, error)
+	RollbackToTx(ctx context.Context, tx bun.IDB, id string, version VersionID) (T, error)
+}
+
+// NewRecordSnapshotter returns the RecordSnapshotter of repository, created
+// by this package. The Snapshot and Restore methods of the repository itself
+// back up whole tables, see TableSnapshotter.
+func NewRecordSnapshotter[T any](repository Repository[T]) (RecordSnapshotter[T], error) {
+	r, ok := repository.(*repo[T])
+	if !ok {
+		return nil, errors.New("repository: record snapshots require a repository created by this package", errors.CategoryBadInput)
+	}
+	return recordSnapshotter[T]{repo: r}, nil
+}
+
+type recordSnapshotter[T any] struct {
+	repo *repo[T]
+}
+
+// Snapshot stores the current state of the record with id and returns the
+// version that RollbackTo accepts. Versions are unique across records.
+func (s recordSnapshotter[T]) Snapshot(ctx context.Context, id string) (VersionID, error) {
+	return s.SnapshotTx(ctx, s.repo.db, id)
+}
+
+func (s recordSnapshotter[T]) SnapshotTx(ctx context.Context, tx bun.IDB, id string) (VersionID, error) {
+	r := s.repo
+	ctx = r.withOperation(ctx, "Snapshot", 0)
+	record, err := r.GetByIDTx(WithoutRelations(ctx), tx, id)
+	if err != nil {
+		return 0, err
+	}
+	if err := r.encryptRecords(record); err != nil {
+		return 0, err
+	}
+
+	payload, err := RecordToMap(record, WithProjectionSchema(r.db))
+	if err != nil {
+		return 0, err
+	}
+	encoded, err := json.Marshal(payload)
+	if err != nil {
+		return 0, errors.Wrap(err, errors.CategoryInternal, "repository: encode record snapshot")
+	}
+
+	version := &RecordVersion{
+		Entity:    r.TableName(),
+		RecordID:  r.handlers.GetID(record).String(),
+		Payload:   string(encoded),
+		CreatedAt: time.Now().UTC(),
+	}
+	// the insert reports the generated ID, so concurrent snapshots never see
+	// each other's version
+	if _, err := tx.NewInsert().Model(version).Exec(ctx); err != nil {
+		return 0, r.mapError(err)
+	}
+	return version.ID, nil
+}
+
+// RollbackTo restores the record with id to the state stored in version.
+// Columns added after the snapshot keep their current value; primary keys and
+// read-only columns are never written. With WithHistory the replaced row is
+// recorded like any other update.
+func (s recordSnapshotter[T]) RollbackTo(ctx context.Context, id string, version VersionID) (T, error) {
+	return s.RollbackToTx(ctx, s.repo.db, id, version)
+}
+
+func (s recordSnapshotter[T]) RollbackToTx(ctx context.Context, tx bun.IDB, id string, version VersionID) (T, error) {
+	r := s.repo
+	ctx = r.withOperation(ctx, "RollbackTo", 0)
+	var result T
+	err := runInTx(ctx, tx, func(ctx context.Context, tx bun.IDB) error {
+		current, err := r.GetByIDTx(WithoutRelations(ctx), tx, id)
+		if err != nil {
+			return err
+		}
+
+		snapshot := new(RecordVersion)
+		err = tx.NewSelect().
+			Model(snapshot).
+			Where("?TableAlias.id = ?", version).
+			Where("?TableAlias.entity = ?", r.TableName()).
+			Where("?TableAlias.record_id = ?", r.handlers.GetID(current).String()).
+			Scan(ctx)
+		if err != nil {
+			return r.mapError(err)
+		}
+
+		payload, err := r.snapshotPatch(current, snapshot.Payload)
+		if err != nil {
+			return err
+		}
+		patched, columns, err := ApplyMapPatch(current, payload,
+			WithPatchSchema(r.db),
+			WithPatchIgnoreUnknown(true),
+			WithPatchReadOnlyMode(MapReadOnlySkip),
+		)
+		if err != nil {
+			return err
+		}
+		if r.encryption != nil {
+			if err := r.encryption.decrypt(reflect.Indirect(reflect.ValueOf(patched))); err != nil {
+				return err
+			}
+		}
+		if len(columns) == 0 {
+			result = patched
+			return nil
+		}
+
+		result, err = r.UpdateTx(ctx, tx, patched, UpdateColumns(columns...))
+		return err
+	})
+	if err != nil {
+		var zero T
+		return zero, err
+	}
+	return result, nil
+}
+
+// snapshotPatch decodes a snapshot payload without its primary key columns.
+func (r *repo[T]) snapshotPatch(record T, encoded string) (map[string]any, error) {
+	payload := map[string]any{}
+	if err := json.Unmarshal([]byte(encoded), &payload); err != nil {
+		return nil, errors.Wrap(err, errors.CategoryInternal, "repository: decode record snapshot")
+	}
+
+	typ := reflect.TypeOf(record)
+	for typ.Kind() == reflect.Pointer {
+		typ = typ.Elem()
+	}
+	desc, err := resolveMapModelDescriptor(r.db, typ)
+	if err != nil {
+		return nil, err
+	}
+	for _, field := range desc.fields {
+		if field.isPrimary {
+			delete(payload, field.bunName)
+		}
+	}
+	return payload, nil
+}
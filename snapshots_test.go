@@ -0,0 +1,145 @@
+package repository
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func setupRecordVersions(t *testing.T) {
+	t.Helper()
+	dropTestTable(t, (*RecordVersion)(nil))
+	require.NoError(t, CreateRecordVersionTable(context.Background(), db))
+}
+
+func newTestRecordSnapshotter[T any](t *testing.T, repo Repository[T]) RecordSnapshotter[T] {
+	t.Helper()
+	setupRecordVersions(t)
+	snapshotter, err := NewRecordSnapshotter(repo)
+	require.NoError(t, err)
+	return snapshotter
+}
+
+func TestRepository_SnapshotAndRollbackTo(t *testing.T) {
+	setupTestData(t)
+	ctx := context.Background()
+	repo := newTestUserRepository(db)
+	snapshotter := newTestRecordSnapshotter(t, repo)
+
+	companyID := uuid.New()
+	user, err := repo.Create(ctx, &TestUser{Name: "Alice", Email: "alice@example.com", CompanyID: companyID})
+	require.NoError(t, err)
+	other, err := repo.Create(ctx, &TestUser{Name: "Bob", Email: "bob@example.com"})
+	require.NoError(t, err)
+
+	version, err := snapshotter.Snapshot(ctx, user.ID.String())
+	require.NoError(t, err)
+	otherVersion, err := snapshotter.Snapshot(ctx, other.ID.String())
+	require.NoError(t, err)
+	assert.NotEqual(t, version, otherVersion, "versions are unique across records")
+
+	user.Name = "Mallory"
+	user.Email = "mallory@example.com"
+	user.CompanyID = uuid.New()
+	_, err = repo.Update(ctx, user)
+	require.NoError(t, err)
+
+	restored, err := snapshotter.RollbackTo(ctx, user.ID.String(), version)
+	require.NoError(t, err)
+	assert.Equal(t, user.ID, restored.ID)
+	assert.Equal(t, "Alice", restored.Name)
+	assert.Equal(t, "alice@example.com", restored.Email)
+	assert.Equal(t, companyID, restored.CompanyID)
+
+	reloaded, err := repo.GetByID(ctx, user.ID.String())
+	require.NoError(t, err)
+	assert.Equal(t, "Alice", reloaded.Name)
+
+	var stored []RecordVersion
+	require.NoError(t, db.NewSelect().Model(&stored).Order("id").Scan(ctx))
+	require.Len(t, stored, 2)
+	assert.Equal(t, version, stored[0].ID)
+	assert.Equal(t, "test_users", stored[0].Entity)
+	assert.Equal(t, user.ID.String(), stored[0].RecordID)
+	assert.Contains(t, stored[0].Payload, `"name":"Alice"`)
+}
+
+func TestRepository_RollbackTo_UnknownVersion(t *testing.T) {
+	setupTestData(t)
+	ctx := context.Background()
+	repo := newTestUserRepository(db)
+	snapshotter := newTestRecordSnapshotter(t, repo)
+
+	alice, err := repo.Create(ctx, &TestUser{Name: "Alice", Email: "alice@example.com"})
+	require.NoError(t, err)
+	bob, err := repo.Create(ctx, &TestUser{Name: "Bob", Email: "bob@example.com"})
+	require.NoError(t, err)
+
+	version, err := snapshotter.Snapshot(ctx, alice.ID.String())
+	require.NoError(t, err)
+
+	_, err = snapshotter.RollbackTo(ctx, bob.ID.String(), version)
+	assert.True(t, IsRecordNotFound(err))
+
+	_, err = snapshotter.RollbackTo(ctx, alice.ID.String(), version+1)
+	assert.True(t, IsRecordNotFound(err))
+
+	_, err = snapshotter.Snapshot(ctx, uuid.NewString())
+	assert.True(t, IsRecordNotFound(err))
+}
+
+func TestRepository_RollbackTo_RecordsHistory(t *testing.T) {
+	ctx := context.Background()
+	repo, history := newHistoryTestRepository(t)
+	snapshotter := newTestRecordSnapshotter(t, repo)
+
+	user, err := repo.Create(ctx, &TestUser{Name: "Alice", Email: "alice@example.com"})
+	require.NoError(t, err)
+	version, err := snapshotter.Snapshot(ctx, user.ID.String())
+	require.NoError(t, err)
+
+	versions, err := history.History(ctx, user.ID.String())
+	require.NoError(t, err)
+	assert.Empty(t, versions, "snapshots do not write history")
+
+	user.Name = "Mallory"
+	_, err = repo.Update(ctx, user)
+	require.NoError(t, err)
+	_, err = snapshotter.RollbackTo(ctx, user.ID.String(), version)
+	require.NoError(t, err)
+
+	versions, err = history.History(ctx, user.ID.String())
+	require.NoError(t, err)
+	require.Len(t, versions, 2, "the update and the rollback")
+	assert.Equal(t, "Mallory", versions[1].Record.Name)
+}
+
+func TestRepository_Snapshot_KeepsCiphertext(t *testing.T) {
+	ctx := context.Background()
+	repo := newEncryptionTestRepository(t, WithEncryptedColumns(newTestCipher(t), "ssn"))
+	snapshotter := newTestRecordSnapshotter(t, repo)
+
+	patient, err := repo.Create(ctx, &encryptionTestPatient{Name: "Ada", SSN: "123-45-6789"})
+	require.NoError(t, err)
+	version, err := snapshotter.Snapshot(ctx, patient.ID.String())
+	require.NoError(t, err)
+
+	stored := new(RecordVersion)
+	require.NoError(t, db.NewSelect().Model(stored).Where("id = ?", version).Scan(ctx))
+	assert.NotContains(t, stored.Payload, "123-45-6789")
+	assert.Contains(t, stored.Payload, encryptedValuePrefix)
+
+	patient.SSN = "987-65-4321"
+	_, err = repo.Update(ctx, patient)
+	require.NoError(t, err)
+	restored, err := snapshotter.RollbackTo(ctx, patient.ID.String(), version)
+	require.NoError(t, err)
+	assert.Equal(t, "123-45-6789", restored.SSN)
+
+	reloaded, err := repo.GetByID(ctx, patient.ID.String())
+	require.NoError(t, err)
+	assert.Equal(t, "123-45-6789", reloaded.SSN)
+}
@@ -0,0 +1,433 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/goliatone/go-errors"
+	"github.com/google/uuid"
+	"github.com/uptrace/bun"
+	"github.com/uptrace/bun/schema"
+)
+
+// WithSoftDeleteColumn soft deletes rows through column, e.g. "archived_at"
+// on legacy tables, instead of the field tagged soft_delete, if any. The
+// column must be a time.Time, *time.Time or sql.NullTime field. Deletes of
+// the repository then set it, its selects and updates skip rows where it is
+// set, and SelectDeletedOnly, SelectDeletedAlso, UpdateDeletedOnly,
+// UpdateDeletedAlso, WithSoftDelete, DeleteForReal and CountTrashed use it,
+// as with a bun:",soft_delete" tag. Live rows hold NULL, or the zero time for
+// non nullzero time.Time fields, like bun soft deletes.
+//
+// The column is handled by the repository only: bun table metadata is left
+// untouched, so other queries of the model on the bun.DB see every row.
+// Deletes run as an UPDATE with the WHERE clause of the delete; delete
+// criteria adding other clauses, such as With or TableExpr, are rejected.
+func WithSoftDeleteColumn(column string) RepoOption {
+	return func(cfg *repoConfig) {
+		if cfg != nil {
+			cfg.softDeleteColumn = strings.TrimSpace(column)
+		}
+	}
+}
+
+var nullTimeType = reflect.TypeFor[sql.NullTime]()
+
+func softDeleteFieldSupported(field *schema.Field) bool {
+	return field.IndirectType == timeType || field.StructField.Type == nullTimeType
+}
+
+// registerSoftDeleteColumn resolves the column set with WithSoftDeleteColumn.
+// A column bun already soft deletes through needs no handling.
+func (r *repo[T]) registerSoftDeleteColumn(column string) {
+	table := r.modelTable()
+	if table == nil {
+		return
+	}
+	field, ok := table.FieldMap[column]
+	if !ok || !softDeleteFieldSupported(field) || table.SoftDeleteField == field {
+		return
+	}
+	r.softDelete = field
+}
+
+func (r *repo[T]) validateSoftDeleteColumn() error {
+	if r.softDeleteColumn == "" {
+		return nil
+	}
+	table := r.modelTable()
+	if table == nil {
+		return nil
+	}
+
+	message := ""
+	if field, ok := table.FieldMap[r.softDeleteColumn]; !ok {
+		message = fmt.Sprintf("unknown column %q on %s", r.softDeleteColumn, table.Name)
+	} else if !softDeleteFieldSupported(field) {
+		message = fmt.Sprintf("column %q must be a time.Time, *time.Time or sql.NullTime field", r.softDeleteColumn)
+	}
+	if message == "" {
+		return nil
+	}
+	return errors.NewValidation("repository configuration invalid", errors.FieldError{
+		Field:   "repoOptions.WithSoftDeleteColumn",
+		Message: message,
+	})
+}
+
+// hasSoftDelete reports whether records are soft deleted, by bun or through
+// WithSoftDeleteColumn.
+func (r *repo[T]) hasSoftDelete() bool {
+	if r.softDelete != nil {
+		return true
+	}
+	table := r.modelTable()
+	return table != nil && table.SoftDeleteField != nil
+}
+
+// softDeleteColumn returns the soft delete column of the query model,
+// deleted_at for models without one.
+func softDeleteColumn(q criteriaQuery) string {
+	if model, ok := q.GetModel().(bun.TableModel); ok && model.Table() != nil && model.Table().SoftDeleteField != nil {
+		return model.Table().SoftDeleteField.Name
+	}
+	return "deleted_at"
+}
+
+// softDeleteMode selects rows by their soft delete column.
+type softDeleteMode int
+
+const (
+	softDeleteLive softDeleteMode = iota
+	softDeleteTrashed
+	softDeleteAll
+)
+
+// The criteria below are returned by the public helpers, so repositories
+// with WithSoftDeleteColumn can recognize them and select rows themselves.
+
+func selectDeletedOnly(q *bun.SelectQuery) *bun.SelectQuery {
+	return q.WhereDeleted()
+}
+
+func selectDeletedAlso(q *bun.SelectQuery) *bun.SelectQuery {
+	return q.WhereAllWithDeleted()
+}
+
+func updateDeletedOnly(q *bun.UpdateQuery) *bun.UpdateQuery {
+	return q.WhereDeleted()
+}
+
+func updateDeletedAlso(q *bun.UpdateQuery) *bun.UpdateQuery {
+	return q.WhereAllWithDeleted()
+}
+
+func deleteForReal(q *bun.DeleteQuery) *bun.DeleteQuery {
+	return q.ForceDelete()
+}
+
+func deleteSoftDeleted(q *bun.DeleteQuery) *bun.DeleteQuery {
+	return q.Where("?TableAlias.? IS NOT NULL", bun.Ident(softDeleteColumn(q)))
+}
+
+// splitCriteria removes the criteria matching marker from criteria and
+// reports whether any did.
+func splitCriteria[C any](criteria []C, marker C) (bool, []C) {
+	markerPtr := reflect.ValueOf(marker).Pointer()
+	found := false
+	filtered := criteria[:0:0]
+	for _, c := range criteria {
+		if value := reflect.ValueOf(c); value.IsValid() && !value.IsNil() && value.Pointer() == markerPtr {
+			found = true
+			continue
+		}
+		filtered = append(filtered, c)
+	}
+	if !found {
+		return false, criteria
+	}
+	return true, filtered
+}
+
+// splitSoftDeleteCriteria removes the trashed and all criteria from criteria
+// and returns the mode they select; the last one wins.
+func splitSoftDeleteCriteria[C any](criteria []C, trashed, all C) (softDeleteMode, []C) {
+	mode := softDeleteLive
+	trashedPtr := reflect.ValueOf(trashed).Pointer()
+	allPtr := reflect.ValueOf(all).Pointer()
+	for _, c := range criteria {
+		if value := reflect.ValueOf(c); value.IsValid() && !value.IsNil() {
+			switch value.Pointer() {
+			case trashedPtr:
+				mode = softDeleteTrashed
+			case allPtr:
+				mode = softDeleteAll
+			}
+		}
+	}
+	_, criteria = splitCriteria(criteria, trashed)
+	_, criteria = splitCriteria(criteria, all)
+	return mode, criteria
+}
+
+// softDeleteCondition returns the condition selecting rows of mode through
+// the WithSoftDeleteColumn column.
+func (r *repo[T]) softDeleteCondition(mode softDeleteMode) (string, []any, bool) {
+	column := bun.Ident(r.softDelete.Name)
+	nullable := r.softDelete.IsPtr || r.softDelete.NullZero || r.softDelete.StructField.Type == nullTimeType
+	switch {
+	case mode == softDeleteLive && nullable:
+		return "?TableAlias.? IS NULL", []any{column}, true
+	case mode == softDeleteLive:
+		return "?TableAlias.? = ?", []any{column, time.Time{}}, true
+	case mode == softDeleteTrashed && nullable:
+		return "?TableAlias.? IS NOT NULL", []any{column}, true
+	case mode == softDeleteTrashed:
+		return "?TableAlias.? != ?", []any{column, time.Time{}}, true
+	default:
+		return "", nil, false
+	}
+}
+
+// applySelectCriteria applies criteria to q, a select of the model, and
+// skips soft deleted rows like bun does for the soft_delete tag.
+func (r *repo[T]) applySelectCriteria(q *bun.SelectQuery, criteria []SelectCriteria) error {
+	if r.softDelete != nil {
+		var mode softDeleteMode
+		mode, criteria = splitSoftDeleteCriteria(criteria, SelectCriteria(selectDeletedOnly), SelectCriteria(selectDeletedAlso))
+		if query, args, ok := r.softDeleteCondition(mode); ok {
+			q.Where(query, args...)
+		}
+	}
+	return applyCriteria(q, criteria, r.criteriaPolicy)
+}
+
+// applyUpdateCriteria is applySelectCriteria for updates of the model.
+func (r *repo[T]) applyUpdateCriteria(q *bun.UpdateQuery, criteria []UpdateCriteria) error {
+	if r.softDelete != nil {
+		var mode softDeleteMode
+		mode, criteria = splitSoftDeleteCriteria(criteria, UpdateCriteria(updateDeletedOnly), UpdateCriteria(updateDeletedAlso))
+		if query, args, ok := r.softDeleteCondition(mode); ok {
+			q.Where(query, args...)
+		}
+	}
+	return applyCriteria(q, criteria, r.criteriaPolicy)
+}
+
+// deleteQuery is a delete of the model. Deletes through the
+// WithSoftDeleteColumn column run update instead, an UPDATE stamping the
+// column with the WHERE clause of the delete.
+type deleteQuery struct {
+	*bun.DeleteQuery
+	update *bun.UpdateQuery
+}
+
+// newDeleteQuery builds the delete of record on tx, or of the rows matched by
+// criteria when record is nil, with the delete scopes of ctx.
+func (r *repo[T]) newDeleteQuery(ctx context.Context, tx bun.IDB, record T, criteria []DeleteCriteria) (*deleteQuery, error) {
+	hasRecord := !isNilRecord(record)
+	model := record
+	if !hasRecord {
+		model = r.handlers.NewRecord()
+	}
+	q := &deleteQuery{DeleteQuery: tx.NewDelete().Model(model)}
+	if r.softDelete == nil {
+		if hasRecord {
+			q.WherePK()
+		}
+		q.DeleteQuery = r.applyDeleteScopes(ctx, q.DeleteQuery)
+		if err := applyCriteria(q.DeleteQuery, criteria, r.criteriaPolicy); err != nil {
+			return nil, err
+		}
+		return q, nil
+	}
+
+	force, criteria := splitCriteria(criteria, DeleteCriteria(deleteForReal))
+	trashed, criteria := splitCriteria(criteria, DeleteCriteria(deleteSoftDeleted))
+	if force {
+		q.ForceDelete()
+	} else {
+		live, args, _ := r.softDeleteCondition(softDeleteLive)
+		q.Where(live, args...)
+	}
+
+	if hasRecord {
+		if err := r.deleteWherePK(q.DeleteQuery, record); err != nil {
+			return nil, err
+		}
+	}
+	if trashed {
+		query, args, _ := r.softDeleteCondition(softDeleteTrashed)
+		q.Where(query, args...)
+	}
+	q.DeleteQuery = r.applyDeleteScopes(ctx, q.DeleteQuery)
+	if err := applyCriteria(q.DeleteQuery, criteria, r.criteriaPolicy); err != nil {
+		return nil, err
+	}
+	if force {
+		return q, nil
+	}
+
+	update, err := r.softDeleteUpdate(tx, q.DeleteQuery)
+	if err != nil {
+		return nil, err
+	}
+	q.update = update
+	return q, nil
+}
+
+// softDeleteUpdate converts q, a delete of the model, into an UPDATE of the
+// model with the same WHERE clause. bun does not expose the clause, so it is
+// cut from a render of q; deletes with clauses before it, such as WITH or
+// USING, cannot be converted and are rejected.
+func (r *repo[T]) softDeleteUpdate(tx bun.IDB, q *bun.DeleteQuery) (*bun.UpdateQuery, error) {
+	// force both renders so models with a soft_delete tag render a DELETE
+	query, err := renderQuery(q.ForceDelete())
+	if err != nil {
+		return nil, err
+	}
+	bare, err := renderQuery(tx.NewDelete().Model(r.handlers.NewRecord()).ForceDelete().Where("1=1"))
+	if err != nil {
+		return nil, err
+	}
+	prefix, _, _ := strings.Cut(bare, " WHERE ")
+	where, ok := strings.CutPrefix(query, prefix+" WHERE ")
+	if !ok {
+		return nil, errSoftDeleteUnsupported()
+	}
+	return tx.NewUpdate().Model(r.handlers.NewRecord()).Where("?", bun.Safe(where)), nil
+}
+
+// where adds a WHERE condition to the write q runs.
+func (q *deleteQuery) where(query string, args ...any) {
+	if q.update != nil {
+		q.update.Where(query, args...)
+		return
+	}
+	q.DeleteQuery.Where(query, args...)
+}
+
+// returning runs the write of q scanning the returning expression into dest.
+func (q *deleteQuery) returning(ctx context.Context, returning string, dest any) error {
+	if q.update != nil {
+		_, err := q.update.Returning(returning).Exec(ctx, dest)
+		return err
+	}
+	_, err := q.DeleteQuery.Returning(returning).Exec(ctx, dest)
+	return err
+}
+
+// isNilRecord reports whether record is the zero value of T, a nil pointer
+// for models passed by pointer.
+func isNilRecord[T any](record T) bool {
+	value := reflect.ValueOf(record)
+	return !value.IsValid() || (value.Kind() == reflect.Pointer && value.IsNil())
+}
+
+// deleteWherePK matches the primary key of record.
+func (r *repo[T]) deleteWherePK(q *bun.DeleteQuery, record T) error {
+	table := r.modelTable()
+	if table == nil || len(table.PKs) == 0 {
+		return errSoftDeleteUnsupported()
+	}
+	value := reflect.Indirect(reflect.ValueOf(record))
+	for _, pk := range table.PKs {
+		q.Where("?TableAlias.? = ?", bun.Ident(pk.Name), pk.Value(value).Interface())
+	}
+	return nil
+}
+
+// execDelete deletes record, or the rows matched by criteria when record is
+// nil, on tx and returns the number of affected rows. Soft deletes through
+// the WithSoftDeleteColumn column stamp the live rows matched, and record as
+// well when given. With an integrity column the rows soft deleted are
+// rehashed, and with WithHistory the rows deleted by criteria are copied into
+// the history table, in the same transaction.
+func (r *repo[T]) execDelete(ctx context.Context, tx bun.IDB, record T, criteria []DeleteCriteria) (int64, error) {
+	if !isNilRecord(record) {
+		q, err := r.newDeleteQuery(ctx, tx, record, nil)
+		if err != nil {
+			return 0, err
+		}
+		return r.execDeleteQuery(ctx, tx, q, record)
+	}
+
+	op := EventDelete
+	if force, _ := splitCriteria(criteria, DeleteCriteria(deleteForReal)); force {
+		op = EventForceDelete
+	}
+	return r.execWithHistory(ctx, tx, op,
+		func(ctx context.Context, tx bun.IDB, returning string, ids *[]uuid.UUID) error {
+			q, err := r.newDeleteQuery(ctx, tx, record, criteria)
+			if err != nil {
+				return err
+			}
+			return q.returning(ctx, returning, ids)
+		},
+		func(ctx context.Context, tx bun.IDB, ids []uuid.UUID) (int64, error) {
+			q, err := r.newDeleteQuery(ctx, tx, record, criteria)
+			if err != nil {
+				return 0, err
+			}
+			if ids != nil {
+				query, args := r.whereInIDs(ids)
+				q.where(query, args...)
+			}
+			return r.execDeleteQuery(ctx, tx, q, record)
+		},
+	)
+}
+
+func (r *repo[T]) execDeleteQuery(ctx context.Context, tx bun.IDB, q *deleteQuery, record T) (int64, error) {
+	if q.update == nil {
+		if r.integrity == nil || !querySoftDeletes(q.DeleteQuery) {
+			res, err := q.Exec(ctx)
+			if err != nil {
+				return 0, err
+			}
+			return rowsAffected(res)
+		}
+		// bun soft deletes run as an UPDATE unless forced
+		query, err := renderQuery(q.DeleteQuery)
+		if err != nil {
+			return 0, err
+		}
+		if !strings.HasPrefix(query, "UPDATE ") {
+			res, err := q.Exec(ctx)
+			if err != nil {
+				return 0, err
+			}
+			return rowsAffected(res)
+		}
+		return r.execRehashed(ctx, tx, func(ctx context.Context, tx bun.IDB, returning string, ids *[]uuid.UUID) error {
+			_, err := q.Conn(tx).Returning(returning).Exec(ctx, ids)
+			return err
+		})
+	}
+
+	now := time.Now()
+	affected, err := r.execUpdate(ctx, tx, q.update.Set("? = ?", bun.Ident(r.softDelete.Name), now))
+	if err != nil {
+		return 0, err
+	}
+	if !isNilRecord(record) {
+		if err := r.softDelete.ScanValue(reflect.Indirect(reflect.ValueOf(record)), now); err != nil {
+			return 0, err
+		}
+	}
+	return affected, nil
+}
+
+func errSoftDeleteUnsupported() error {
+	return errors.NewValidation(
+		"repository: unsupported soft delete",
+		errors.FieldError{
+			Field:   "criteria",
+			Message: "soft deletes through WithSoftDeleteColumn only support delete criteria adding WHERE conditions",
+		},
+	)
+}
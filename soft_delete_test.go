@@ -0,0 +1,122 @@
+package repository
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/uptrace/bun"
+	"github.com/uptrace/bun/dialect/sqlitedialect"
+)
+
+type softDeleteTestDocument struct {
+	bun.BaseModel `bun:"table:soft_delete_test_documents,alias:sdd"`
+
+	ID         uuid.UUID `bun:"id,pk,notnull"`
+	Name       string    `bun:"name,notnull"`
+	ArchivedAt time.Time `bun:"archived_at"`
+}
+
+func TestWithSoftDeleteColumn(t *testing.T) {
+	ctx := context.Background()
+	testDB := newDialectTestDB(t, sqlitedialect.New())
+	repo := NewRepositoryWithConfig(testDB, testModelHandlers[*softDeleteTestDocument](), nil, WithSoftDeleteColumn(" archived_at "))
+	require.NoError(t, repo.(Validator).Validate())
+	_, err := testDB.NewCreateTable().Model((*softDeleteTestDocument)(nil)).Exec(ctx)
+	require.NoError(t, err)
+
+	var docs []*softDeleteTestDocument
+	for _, name := range []string{"draft", "report", "memo"} {
+		doc, err := repo.Create(ctx, &softDeleteTestDocument{Name: name})
+		require.NoError(t, err)
+		docs = append(docs, doc)
+	}
+	require.NoError(t, repo.Delete(ctx, docs[0]))
+	assert.False(t, docs[0].ArchivedAt.IsZero(), "delete stamps the record")
+	require.NoError(t, repo.DeleteWhere(ctx, DeleteBy("name", "=", "report")))
+
+	var archivedAt *time.Time
+	require.NoError(t, testDB.NewSelect().Table("soft_delete_test_documents").
+		Column("archived_at").Where("name = ?", "draft").Scan(ctx, &archivedAt))
+	require.NotNil(t, archivedAt, "rows are archived, not deleted")
+
+	live, total, err := repo.List(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, total)
+	assert.Equal(t, "memo", live[0].Name)
+	_, err = repo.GetByID(ctx, docs[0].ID.String())
+	assert.True(t, IsRecordNotFound(err), "archived rows are hidden from lookups")
+
+	table := testDB.Dialect().Tables().Get(reflect.TypeFor[softDeleteTestDocument]())
+	assert.Nil(t, table.SoftDeleteField, "bun table metadata is left untouched")
+	rows, err := testDB.NewSelect().Model((*softDeleteTestDocument)(nil)).Count(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 3, rows, "plain bun queries see archived rows")
+
+	counter := repo.(TrashedCounter)
+	trashed, err := counter.CountTrashed(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 2, trashed)
+	archived, _, err := repo.List(ctx, SelectDeletedOnly())
+	require.NoError(t, err)
+	assert.Len(t, archived, 2)
+
+	purged, err := repo.(CountingDeleter).DeleteWhereCount(ctx, DeleteForReal(), WithSoftDelete())
+	require.NoError(t, err)
+	assert.EqualValues(t, 2, purged)
+	count, err := counter.CountWithTrashed(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, count)
+}
+
+func TestWithSoftDeleteColumn_Validate(t *testing.T) {
+	testDB := newDialectTestDB(t, sqlitedialect.New())
+	for _, column := range []string{"missing", "name"} {
+		repo := NewRepositoryWithConfig(testDB, testModelHandlers[*softDeleteTestDocument](), nil, WithSoftDeleteColumn(column))
+		err := repo.(Validator).Validate()
+		require.Error(t, err, column)
+		assert.Contains(t, err.Error(), "repository configuration invalid")
+	}
+}
+
+func TestWithSoftDeleteColumn_Criteria(t *testing.T) {
+	ctx := context.Background()
+	testDB := newDialectTestDB(t, sqlitedialect.New())
+	repo := NewRepositoryWithConfig(testDB, testModelHandlers[*softDeleteTestDocument](), nil, WithSoftDeleteColumn("archived_at"))
+	_, err := testDB.NewCreateTable().Model((*softDeleteTestDocument)(nil)).Exec(ctx)
+	require.NoError(t, err)
+
+	for _, name := range []string{"draft", "report", "memo"} {
+		_, err := repo.Create(ctx, &softDeleteTestDocument{Name: name})
+		require.NoError(t, err)
+	}
+
+	archived, err := repo.(CountingDeleter).DeleteWhereCount(ctx, DeleteColumnIn("name", []string{"draft", "report"}), DeleteBy("name", "!=", "report"))
+	require.NoError(t, err)
+	assert.EqualValues(t, 1, archived)
+
+	archived, err = repo.(CountingDeleter).DeleteWhereCount(ctx, func(q *bun.DeleteQuery) *bun.DeleteQuery {
+		return q.Where("name = ?", "memo")
+	})
+	require.NoError(t, err, "raw conditions move to the UPDATE")
+	assert.EqualValues(t, 1, archived)
+
+	_, err = repo.(CountingDeleter).DeleteWhereCount(ctx, func(q *bun.DeleteQuery) *bun.DeleteQuery {
+		return q.With("names", testDB.NewSelect().ColumnExpr("'report' AS name")).
+			Where("name IN (SELECT name FROM names)")
+	})
+	require.Error(t, err, "a WITH clause cannot be moved to the UPDATE")
+	assert.Contains(t, err.Error(), "unsupported soft delete")
+
+	live, total, err := repo.List(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, total)
+	assert.Equal(t, "report", live[0].Name)
+	trashed, err := repo.(TrashedCounter).CountTrashed(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 2, trashed)
+}
@@ -0,0 +1,30 @@
+package repository
+
+import (
+	"database/sql"
+
+	"github.com/uptrace/bun"
+	"github.com/uptrace/bun/schema"
+)
+
+// NewRepositoryFromSQL builds a repository over a plain *sql.DB, e.g. one
+// opened for sqlx or the pgx stdlib driver, creating the bun.DB internally
+// with dialect. Repositories built this way implement DBProvider: pass their
+// DB to NewRepositoryWithConfig to build more repositories on the same
+// bun.DB, and its query hooks, instead of wrapping sqldb again.
+func NewRepositoryFromSQL[T any](sqldb *sql.DB, dialect schema.Dialect, handlers ModelHandlers[T], dbOpts []Option, repoOpts ...RepoOption) Repository[T] {
+	return NewRepositoryWithConfig(newBunDB(sqldb, dialect), handlers, dbOpts, repoOpts...)
+}
+
+// MustNewRepositoryFromSQL is NewRepositoryFromSQL panicking on invalid
+// configuration, as MustNewRepositoryWithConfig.
+func MustNewRepositoryFromSQL[T any](sqldb *sql.DB, dialect schema.Dialect, handlers ModelHandlers[T], dbOpts []Option, repoOpts ...RepoOption) Repository[T] {
+	return MustNewRepositoryWithConfig(newBunDB(sqldb, dialect), handlers, dbOpts, repoOpts...)
+}
+
+func newBunDB(sqldb *sql.DB, dialect schema.Dialect) *bun.DB {
+	if sqldb == nil || dialect == nil {
+		return nil
+	}
+	return bun.NewDB(sqldb, dialect)
+}
@@ -0,0 +1,50 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/uptrace/bun/dialect/sqlitedialect"
+)
+
+func TestNewRepositoryFromSQL(t *testing.T) {
+	ctx := context.Background()
+	sqldb, err := sql.Open("sqlite3", ":memory:")
+	require.NoError(t, err)
+	sqldb.SetMaxOpenConns(1)
+	t.Cleanup(func() { _ = sqldb.Close() })
+
+	repo := MustNewRepositoryFromSQL(sqldb, sqlitedialect.New(), testUserHandlers(), nil)
+	bunDB := repo.(DBProvider).DB()
+	require.NotNil(t, bunDB)
+	assert.Same(t, sqldb, bunDB.DB)
+	_, err = bunDB.NewCreateTable().Model((*TestUser)(nil)).Exec(ctx)
+	require.NoError(t, err)
+
+	tx, err := bunDB.BeginTx(ctx, nil)
+	require.NoError(t, err)
+	// plain database/sql code writing in the repository transaction
+	_, err = tx.Tx.ExecContext(ctx,
+		"INSERT INTO test_users (id, name, email, company_id, created_at, updated_at) VALUES (?, ?, ?, ?, ?, ?)",
+		uuid.NewString(), "Alice", "alice@example.com", uuid.NewString(), time.Now(), time.Now())
+	require.NoError(t, err)
+	_, err = repo.CreateTx(ctx, tx, &TestUser{Name: "Bob", Email: "bob@example.com"})
+	require.NoError(t, err)
+	count, err := repo.CountTx(ctx, tx)
+	require.NoError(t, err)
+	assert.Equal(t, 2, count)
+	require.NoError(t, tx.Rollback())
+
+	count, err = repo.Count(ctx)
+	require.NoError(t, err)
+	assert.Zero(t, count, "both writes rolled back together")
+
+	assert.Panics(t, func() {
+		MustNewRepositoryFromSQL(nil, sqlitedialect.New(), testUserHandlers(), nil)
+	})
+}
@@ -0,0 +1,171 @@
+package repository
+
+import (
+	"container/list"
+	"context"
+	"reflect"
+	"sync"
+	"time"
+
+	"github.com/goliatone/go-repository-bun/repositoryctx"
+	"github.com/uptrace/bun"
+)
+
+// Cache stores records served by WithStaleReadFallback. Values are the
+// repository records themselves, so distributed caches need an adapter that
+// keeps them in process or encodes them.
+type Cache interface {
+	Get(ctx context.Context, key string) (any, bool)
+	Set(ctx context.Context, key string, value any)
+}
+
+// DefaultMemoryCacheSize is the capacity of a MemoryCache created with a
+// non-positive size.
+const DefaultMemoryCacheSize = 10000
+
+// MemoryCache is an in-process Cache holding a bounded number of records,
+// evicting the least recently used one when full.
+type MemoryCache struct {
+	mu      sync.Mutex
+	size    int
+	order   *list.List
+	entries map[string]*list.Element
+}
+
+type memoryCacheEntry struct {
+	key   string
+	value any
+}
+
+// NewMemoryCache returns an empty MemoryCache holding at most size records,
+// or DefaultMemoryCacheSize when size is not positive.
+func NewMemoryCache(size int) *MemoryCache {
+	if size <= 0 {
+		size = DefaultMemoryCacheSize
+	}
+	return &MemoryCache{
+		size:    size,
+		order:   list.New(),
+		entries: make(map[string]*list.Element),
+	}
+}
+
+func (c *MemoryCache) Get(_ context.Context, key string) (any, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	element, ok := c.entries[key]
+	if !ok {
+		return nil, false
+	}
+	c.order.MoveToFront(element)
+	return element.Value.(*memoryCacheEntry).value, true
+}
+
+func (c *MemoryCache) Set(_ context.Context, key string, value any) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if element, ok := c.entries[key]; ok {
+		element.Value.(*memoryCacheEntry).value = value
+		c.order.MoveToFront(element)
+		return
+	}
+	c.entries[key] = c.order.PushFront(&memoryCacheEntry{key: key, value: value})
+	for c.order.Len() > c.size {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*memoryCacheEntry).key)
+	}
+}
+
+// Len returns the number of cached records.
+func (c *MemoryCache) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.order.Len()
+}
+
+// WithStaleReadFallback keeps the last copy of records read by GetByID and
+// GetByIdentifier in cache. When such a read later fails with a connection
+// error, a copy younger than maxStale is returned instead and the read is
+// flagged, see WithStaleReadTracking. Reads with extra criteria, active select
+// scopes or inside a transaction are neither cached nor served stale, so
+// tenant scoped data never leaks across scopes.
+func WithStaleReadFallback(cache Cache, maxStale time.Duration) RepoOption {
+	return func(cfg *repoConfig) {
+		if cfg == nil || cache == nil || maxStale <= 0 {
+			return
+		}
+		cfg.staleReadCache = cache
+		cfg.staleReadMaxAge = maxStale
+	}
+}
+
+// WithStaleReadTracking returns a context that records whether a read made
+// with it was served from the WithStaleReadFallback cache:
+//
+//	ctx = repository.WithStaleReadTracking(ctx)
+//	user, err := users.GetByID(ctx, id)
+//	if repository.IsStaleRead(ctx) {
+//		w.Header().Set("Warning", `110 - "Response is Stale"`)
+//	}
+func WithStaleReadTracking(ctx context.Context) context.Context {
+	return repositoryctx.WithStaleReadTracking(ctx)
+}
+
+// IsStaleRead reports whether a read made with ctx, prepared with
+// WithStaleReadTracking, returned a stale cached copy.
+func IsStaleRead(ctx context.Context) bool {
+	return repositoryctx.IsStaleRead(ctx)
+}
+
+type staleReadEntry struct {
+	value    any
+	storedAt time.Time
+}
+
+// staleReadFallback caches the result of a successful read under key, or
+// serves the cached copy when the read failed with a connection error.
+func (r *repo[T]) staleReadFallback(ctx context.Context, tx bun.IDB, key string, criteria []SelectCriteria, record T, err error) (T, error) {
+	if r.staleReadCache == nil || len(criteria) > 0 || isTransaction(tx) ||
+		defaultRelationsDisabled(ctx) || len(r.resolveSelectScopes(ctx)) > 0 {
+		return record, err
+	}
+	key = r.TableName() + ":" + key
+
+	if err == nil {
+		r.staleReadCache.Set(ctx, key, staleReadEntry{value: cloneRecord(record), storedAt: time.Now()})
+		return record, nil
+	}
+	if !IsConnectionError(err) {
+		return record, err
+	}
+
+	cached, ok := r.staleReadCache.Get(ctx, key)
+	if !ok {
+		return record, err
+	}
+	entry, ok := cached.(staleReadEntry)
+	if !ok || time.Since(entry.storedAt) > r.staleReadMaxAge {
+		return record, err
+	}
+	stale, ok := entry.value.(T)
+	if !ok {
+		return record, err
+	}
+
+	repositoryctx.MarkStaleRead(ctx)
+	return cloneRecord(stale), nil
+}
+
+// cloneRecord returns a shallow copy of pointer records, so callers cannot
+// mutate cached copies.
+func cloneRecord[T any](record T) T {
+	value := reflect.ValueOf(record)
+	if value.Kind() != reflect.Pointer || value.IsNil() {
+		return record
+	}
+	clone := reflect.New(value.Elem().Type())
+	clone.Elem().Set(value.Elem())
+	out, _ := clone.Interface().(T)
+	return out
+}
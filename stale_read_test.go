@@ -0,0 +1,97 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/uptrace/bun"
+	"github.com/uptrace/bun/dialect/sqlitedialect"
+)
+
+// mapClosedDatabase reports a closed pool as a connection error, standing in
+// for a database outage.
+func mapClosedDatabase(err error) error {
+	if strings.Contains(err.Error(), "database is closed") {
+		return newRetryableDatabaseConnectionError("Database unavailable")
+	}
+	return nil
+}
+
+func TestRepository_WithStaleReadFallback(t *testing.T) {
+	ctx := context.Background()
+	sqldb, err := sql.Open("sqlite3", ":memory:")
+	require.NoError(t, err)
+	sqldb.SetMaxOpenConns(1)
+	testDB := bun.NewDB(sqldb, sqlitedialect.New())
+	_, err = testDB.NewCreateTable().Model((*TestUser)(nil)).Exec(ctx)
+	require.NoError(t, err)
+
+	cache := NewMemoryCache(0)
+	repo := newTestUserRepositoryWithConfig(testDB, nil,
+		WithStaleReadFallback(cache, time.Minute),
+		WithErrorMapper(mapClosedDatabase),
+	)
+	user, err := repo.Create(ctx, &TestUser{Name: "Alice", Email: "alice@example.com"})
+	require.NoError(t, err)
+
+	fresh, err := repo.GetByID(ctx, user.ID.String())
+	require.NoError(t, err)
+	_, err = repo.GetByIdentifier(ctx, "alice@example.com")
+	require.NoError(t, err)
+
+	require.NoError(t, sqldb.Close())
+
+	tracked := WithStaleReadTracking(ctx)
+	assert.False(t, IsStaleRead(tracked))
+	stale, err := repo.GetByID(tracked, user.ID.String())
+	require.NoError(t, err)
+	assert.True(t, IsStaleRead(tracked))
+	assert.Equal(t, fresh, stale)
+	assert.NotSame(t, fresh, stale, "callers get a copy of the cached record")
+
+	stale.Name = "Mutated"
+	again, err := repo.GetByIdentifier(ctx, "alice@example.com")
+	require.NoError(t, err)
+	assert.Equal(t, "Alice", again.Name)
+
+	_, err = repo.GetByID(ctx, user.ID.String(), SelectColumns("id"))
+	assert.True(t, IsConnectionError(err), "reads with criteria are not served stale")
+
+	_, err = repo.GetByIdentifier(ctx, "bob@example.com")
+	assert.True(t, IsConnectionError(err), "uncached reads fail")
+
+	expired := newTestUserRepositoryWithConfig(testDB, nil,
+		WithStaleReadFallback(cache, time.Nanosecond),
+		WithErrorMapper(mapClosedDatabase),
+	)
+	_, err = expired.GetByID(ctx, user.ID.String())
+	assert.True(t, IsConnectionError(err), "copies older than maxStale are not served")
+}
+
+func TestMemoryCache_EvictsLeastRecentlyUsed(t *testing.T) {
+	ctx := context.Background()
+	cache := NewMemoryCache(2)
+
+	cache.Set(ctx, "a", 1)
+	cache.Set(ctx, "b", 2)
+	_, ok := cache.Get(ctx, "a")
+	require.True(t, ok)
+	cache.Set(ctx, "c", 3)
+
+	assert.Equal(t, 2, cache.Len())
+	_, ok = cache.Get(ctx, "b")
+	assert.False(t, ok, "the least recently used record is evicted")
+	value, ok := cache.Get(ctx, "a")
+	require.True(t, ok)
+	assert.Equal(t, 1, value)
+
+	cache.Set(ctx, "a", 4)
+	value, _ = cache.Get(ctx, "a")
+	assert.Equal(t, 4, value)
+	assert.Equal(t, 2, cache.Len())
+}
@@ -0,0 +1,300 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/goliatone/go-errors"
+	"github.com/uptrace/bun"
+	"github.com/uptrace/bun/schema"
+)
+
+// SyncOptions configures SyncSet.
+type SyncOptions struct {
+	// Criteria limits the existing rows that make up the set, e.g. the rows
+	// that belong to a single parent record.
+	Criteria []SelectCriteria
+	// CompareColumns restricts change detection to the listed columns.
+	// Defaults to every column except primary keys, match columns, the soft
+	// delete column, created_at and updated_at, and columns the database
+	// fills in (nullzero or default:), which desired records usually leave
+	// zero. List those here to sync them.
+	CompareColumns []string
+	// IgnoreColumns are skipped during change detection (e.g. created_at).
+	IgnoreColumns []string
+	// KeepExtraneous leaves existing rows that are not part of desired untouched.
+	KeepExtraneous bool
+	// ForceDelete permanently removes extraneous rows of soft delete models.
+	ForceDelete bool
+	// Progress receives the progress of the sync, in place of the repository
+	// WithProgressReporter.
+	Progress ProgressReporter
+}
+
+// SyncReport summarizes the changes applied by SyncSet.
+type SyncReport struct {
+	Inserted  int `json:"inserted"`
+	Updated   int `json:"updated"`
+	Deleted   int `json:"deleted"`
+	Unchanged int `json:"unchanged"`
+}
+
+// SetSyncer is an optional capability for repositories that reconcile a
+// set of rows, e.g. the children of a parent record, with a desired set.
+type SetSyncer[T any] interface {
+	SyncSet(ctx context.Context, desired []T, matchColumns []string, opts SyncOptions) (SyncReport, error)
+	SyncSetTx(ctx context.Context, tx bun.IDB, desired []T, matchColumns []string, opts SyncOptions) (SyncReport, error)
+}
+
+// SyncSet reconciles the rows selected by opts.Criteria with desired.
+// Records are matched on matchColumns: unmatched desired records are inserted,
+// matched records with changed columns are updated (desired records receive the
+// ID of the row they matched), and existing rows missing from desired are
+// deleted, which is a soft delete for models that support it.
+// All changes are applied in a single transaction.
+//
+// Syncing without criteria while deleting extraneous rows affects the whole
+// table and is blocked unless WithAllowFullTableDelete(true) is configured.
+func (r *repo[T]) SyncSet(ctx context.Context, desired []T, matchColumns []string, opts SyncOptions) (SyncReport, error) {
+	return r.SyncSetTx(ctx, r.db, desired, matchColumns, opts)
+}
+
+func (r *repo[T]) SyncSetTx(ctx context.Context, tx bun.IDB, desired []T, matchColumns []string, opts SyncOptions) (SyncReport, error) {
+	ctx = r.withOperation(ctx, "SyncSet", 0)
+	var report SyncReport
+
+	table := r.modelTable()
+	if table == nil {
+		return report, errors.New("repository: model table is not available", errors.CategoryInternal)
+	}
+
+	matchFields, err := syncFields(table, "match_columns", matchColumns)
+	if err != nil {
+		return report, err
+	}
+	if len(matchFields) == 0 {
+		return report, errors.NewValidation(
+			"repository: sync requires match columns",
+			errors.FieldError{Field: "match_columns", Message: "at least one match column is required"},
+		)
+	}
+
+	compareFields, err := syncCompareFields(table, matchFields, opts, r.softDelete)
+	if err != nil {
+		return report, err
+	}
+
+	if !opts.KeepExtraneous && !r.allowFullTableDelete && !hasSelectCriteria(opts.Criteria) {
+		return report, fullTableOperationBlockedError("sync", "WithAllowFullTableDelete")
+	}
+
+	err = runInTx(ctx, tx, func(ctx context.Context, tx bun.IDB) error {
+		report, err = r.syncSet(ctx, tx, desired, matchFields, compareFields, opts)
+		return err
+	})
+	if err != nil {
+		return SyncReport{}, err
+	}
+
+	return report, nil
+}
+
+func (r *repo[T]) syncSet(ctx context.Context, tx bun.IDB, desired []T, matchFields, compareFields []*schema.Field, opts SyncOptions) (SyncReport, error) {
+	var report SyncReport
+
+	existing, err := r.loadSyncSet(ctx, tx, opts.Criteria)
+	if err != nil {
+		return report, err
+	}
+
+	reporter := opts.Progress
+	if reporter == nil {
+		reporter = r.progressReporter
+	}
+	progress := newProgressTracker(reporter, "sync", r.TableName(), int64(len(desired)))
+	defer progress.done(ctx)
+
+	index := make(map[string]T, len(existing))
+	for _, record := range existing {
+		index[syncKey(record, matchFields)] = record
+	}
+
+	var toCreate []T
+	seen := make(map[string]struct{}, len(desired))
+	for _, record := range desired {
+		key := syncKey(record, matchFields)
+		if _, dup := seen[key]; dup {
+			return report, errors.NewValidation(
+				"repository: duplicate record in sync set",
+				errors.FieldError{Field: "desired", Message: fmt.Sprintf("more than one record matches key %q", key)},
+			)
+		}
+		seen[key] = struct{}{}
+
+		current, ok := index[key]
+		if !ok {
+			toCreate = append(toCreate, record)
+			continue
+		}
+		delete(index, key)
+
+		r.handlers.SetID(record, r.handlers.GetID(current))
+		changed := copyChangedFields(current, record, compareFields)
+		if len(changed) == 0 {
+			report.Unchanged++
+			progress.add(ctx, 1)
+			continue
+		}
+		if _, err := r.UpdateTx(ctx, tx, current, UpdateColumns(changed...)); err != nil {
+			return report, err
+		}
+		report.Updated++
+		progress.add(ctx, 1)
+	}
+
+	if len(toCreate) > 0 {
+		if _, err := r.CreateManyTx(ctx, tx, toCreate); err != nil {
+			return report, err
+		}
+		report.Inserted = len(toCreate)
+		progress.batch(ctx, int64(len(toCreate)))
+	}
+
+	if opts.KeepExtraneous || len(index) == 0 {
+		return report, nil
+	}
+	progress.grow(int64(len(index)))
+
+	ids := make([]string, 0, len(index))
+	for _, record := range index {
+		ids = append(ids, r.handlers.GetID(record).String())
+	}
+	criteria := []DeleteCriteria{DeleteByIDs(ids)}
+	if opts.ForceDelete {
+		criteria = append(criteria, DeleteForReal())
+	}
+	deleted, err := r.DeleteWhereCountTx(ctx, tx, criteria...)
+	if err != nil {
+		return report, err
+	}
+	report.Deleted = int(deleted)
+	progress.add(ctx, int64(len(index)))
+
+	return report, nil
+}
+
+func (r *repo[T]) loadSyncSet(ctx context.Context, tx bun.IDB, criteria []SelectCriteria) ([]T, error) {
+	records := []T{}
+	q := tx.NewSelect().Model(&records)
+
+	q = r.applySelectScopes(ctx, q)
+
+	if err := r.applySelectCriteria(q, criteria); err != nil {
+		return nil, err
+	}
+
+	if err := r.scanRecords(ctx, q.Scan); err != nil {
+		return nil, err
+	}
+	return records, nil
+}
+
+func syncFields(table *schema.Table, field string, columns []string) ([]*schema.Field, error) {
+	fields := make([]*schema.Field, 0, len(columns))
+	for _, column := range columns {
+		col, ok := normalizeSQLIdentifier(column)
+		if !ok {
+			return nil, invalidColumnError(field, column)
+		}
+		f, ok := table.FieldMap[col]
+		if !ok {
+			return nil, errors.NewValidation(
+				"repository: unknown column",
+				errors.FieldError{Field: field, Message: fmt.Sprintf("column %q does not exist on %s", col, table.Name)},
+			)
+		}
+		fields = append(fields, f)
+	}
+	return fields, nil
+}
+
+// syncAuditColumns are the timestamps Sync leaves to the repository by
+// default.
+var syncAuditColumns = []string{"created_at", "updated_at"}
+
+// syncCompareFields returns the fields compared by Sync. softDelete is the
+// WithSoftDeleteColumn field, if any, skipped like the soft_delete one.
+func syncCompareFields(table *schema.Table, matchFields []*schema.Field, opts SyncOptions, softDelete *schema.Field) ([]*schema.Field, error) {
+	if len(opts.CompareColumns) > 0 {
+		return syncFields(table, "compare_columns", opts.CompareColumns)
+	}
+
+	fields := make([]*schema.Field, 0, len(table.Fields))
+	for _, f := range table.Fields {
+		if f.IsPK || f == table.SoftDeleteField || f == softDelete || slices.Contains(matchFields, f) {
+			continue
+		}
+		if f.NullZero || f.SQLDefault != "" || slices.Contains(syncAuditColumns, f.Name) {
+			continue
+		}
+		if slices.Contains(opts.IgnoreColumns, f.Name) {
+			continue
+		}
+		fields = append(fields, f)
+	}
+	return fields, nil
+}
+
+func syncKey[T any](record T, fields []*schema.Field) string {
+	strct := reflect.Indirect(reflect.ValueOf(record))
+	parts := make([]string, len(fields))
+	for i, f := range fields {
+		parts[i] = fmt.Sprint(f.Value(strct).Interface())
+	}
+	return strings.Join(parts, "\x1f")
+}
+
+// copyChangedFields copies the fields that differ from desired onto current
+// and returns the names of the copied columns.
+func copyChangedFields[T any](current, desired T, fields []*schema.Field) []string {
+	currentValue := reflect.Indirect(reflect.ValueOf(current))
+	desiredValue := reflect.Indirect(reflect.ValueOf(desired))
+
+	var changed []string
+	for _, f := range fields {
+		cv := f.Value(currentValue)
+		dv := f.Value(desiredValue)
+		if syncValuesEqual(cv, dv) {
+			continue
+		}
+		cv.Set(dv)
+		changed = append(changed, f.Name)
+	}
+	return changed
+}
+
+func syncValuesEqual(a, b reflect.Value) bool {
+	if a.Kind() == reflect.Pointer {
+		if a.IsNil() || b.IsNil() {
+			return a.IsNil() == b.IsNil()
+		}
+		return syncValuesEqual(a.Elem(), b.Elem())
+	}
+	if at, ok := a.Interface().(time.Time); ok {
+		return at.Equal(b.Interface().(time.Time))
+	}
+	return reflect.DeepEqual(a.Interface(), b.Interface())
+}
+
+func hasSelectCriteria(criteria []SelectCriteria) bool {
+	for _, c := range criteria {
+		if c != nil {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,115 @@
+package repository
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRepository_SyncSet(t *testing.T) {
+	setupTestData(t)
+	ctx := context.Background()
+	repo := newTestUserRepository(db)
+
+	companyID := uuid.New()
+	otherCompanyID := uuid.New()
+	_, err := repo.CreateMany(ctx, []*TestUser{
+		{Name: "Keep", Email: "keep@example.com", CompanyID: companyID},
+		{Name: "Old Name", Email: "rename@example.com", CompanyID: companyID},
+		{Name: "Gone", Email: "gone@example.com", CompanyID: companyID},
+		{Name: "Other", Email: "other@example.com", CompanyID: otherCompanyID},
+	})
+	require.NoError(t, err)
+
+	desired := []*TestUser{
+		{Name: "Keep", Email: "keep@example.com", CompanyID: companyID},
+		{Name: "New Name", Email: "rename@example.com", CompanyID: companyID},
+		{Name: "Fresh", Email: "fresh@example.com", CompanyID: companyID},
+	}
+
+	report, err := repo.(SetSyncer[*TestUser]).SyncSet(ctx, desired, []string{"email"}, SyncOptions{
+		Criteria:      []SelectCriteria{SelectBy("company_id", "=", companyID.String())},
+		IgnoreColumns: []string{"created_at", "updated_at"},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, SyncReport{Inserted: 1, Updated: 1, Deleted: 1, Unchanged: 1}, report)
+	assert.NotEqual(t, uuid.Nil, desired[1].ID)
+
+	renamed, err := repo.GetByIdentifier(ctx, "rename@example.com")
+	require.NoError(t, err)
+	assert.Equal(t, "New Name", renamed.Name)
+	assert.Equal(t, renamed.ID, desired[1].ID)
+
+	_, err = repo.GetByIdentifier(ctx, "gone@example.com")
+	assert.True(t, IsRecordNotFound(err))
+
+	_, err = repo.GetByIdentifier(ctx, "other@example.com")
+	require.NoError(t, err)
+
+	total, err := repo.Count(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 4, total)
+}
+
+func TestRepository_SyncSet_KeepsAuditColumns(t *testing.T) {
+	setupTestData(t)
+	ctx := context.Background()
+	repo := newTestUserRepository(db)
+
+	companyID := uuid.New()
+	createdAt := time.Now().Add(-time.Hour).UTC().Truncate(time.Second)
+	created, err := repo.Create(ctx, &TestUser{Name: "Keep", Email: "keep@example.com", CompanyID: companyID, CreatedAt: createdAt})
+	require.NoError(t, err)
+
+	desired := []*TestUser{{Name: "Keep", Email: "keep@example.com", CompanyID: companyID}}
+	report, err := repo.(SetSyncer[*TestUser]).SyncSet(ctx, desired, []string{"email"}, SyncOptions{
+		Criteria: []SelectCriteria{SelectBy("company_id", "=", companyID.String())},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, SyncReport{Unchanged: 1}, report, "a zero CreatedAt is not a change")
+
+	stored, err := repo.GetByID(ctx, created.ID.String())
+	require.NoError(t, err)
+	assert.True(t, createdAt.Equal(stored.CreatedAt))
+
+	desired = []*TestUser{{Name: "Renamed", Email: "keep@example.com", CompanyID: companyID}}
+	report, err = repo.(SetSyncer[*TestUser]).SyncSet(ctx, desired, []string{"email"}, SyncOptions{
+		Criteria: []SelectCriteria{SelectBy("company_id", "=", companyID.String())},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, SyncReport{Updated: 1}, report)
+
+	stored, err = repo.GetByID(ctx, created.ID.String())
+	require.NoError(t, err)
+	assert.Equal(t, "Renamed", stored.Name)
+	assert.True(t, createdAt.Equal(stored.CreatedAt))
+}
+
+func TestRepository_SyncSet_Validation(t *testing.T) {
+	setupTestData(t)
+	ctx := context.Background()
+	repo := newTestUserRepository(db)
+
+	_, err := repo.(SetSyncer[*TestUser]).SyncSet(ctx, nil, nil, SyncOptions{KeepExtraneous: true})
+	require.Error(t, err)
+
+	_, err = repo.(SetSyncer[*TestUser]).SyncSet(ctx, nil, []string{"missing"}, SyncOptions{KeepExtraneous: true})
+	require.Error(t, err)
+
+	_, err = repo.(SetSyncer[*TestUser]).SyncSet(ctx, nil, []string{"email"}, SyncOptions{})
+	assert.True(t, IsFullTableOperationBlocked(err))
+
+	_, err = repo.(SetSyncer[*TestUser]).SyncSet(ctx, []*TestUser{
+		{Name: "A", Email: "dup@example.com"},
+		{Name: "B", Email: "dup@example.com"},
+	}, []string{"email"}, SyncOptions{KeepExtraneous: true})
+	require.Error(t, err)
+
+	total, err := repo.Count(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 0, total)
+}
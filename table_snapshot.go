@@ -0,0 +1,180 @@
+package repository
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	stderrors "errors"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/uptrace/bun"
+)
+
+// TableSnapshotFormat identifies the header line of Snapshot files.
+const TableSnapshotFormat = "go-repository-bun/table-snapshot"
+
+const defaultTableSnapshotChunkSize = 1000
+
+// ErrTableSnapshotMismatch is returned by Restore for files that are not
+// table snapshots of the repository table.
+var ErrTableSnapshotMismatch = stderrors.New("repository: table snapshot does not match repository")
+
+// TableSnapshotHeader is the first line of a Snapshot file.
+type TableSnapshotHeader struct {
+	Format string `json:"format"`
+	Table  string `json:"table"`
+	// SchemaVersion is the payload version of the rows, see
+	// RegisterPayloadMigration. Migrations registered under the table name
+	// upgrade older snapshots on restore.
+	SchemaVersion int       `json:"schema_version"`
+	Columns       []string  `json:"columns"`
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+// TableSnapshotter is an optional capability for repositories that can back
+// up rows to a file and restore them, e.g. to move a tenant between
+// environments.
+type TableSnapshotter interface {
+	Snapshot(ctx context.Context, w io.Writer, criteria ...SelectCriteria) (int, error)
+	SnapshotTx(ctx context.Context, tx bun.IDB, w io.Writer, criteria ...SelectCriteria) (int, error)
+	Restore(ctx context.Context, r io.Reader, opts ...ImportOption) (ImportReport, error)
+	RestoreTx(ctx context.Context, tx bun.IDB, r io.Reader, opts ...ImportOption) (ImportReport, error)
+}
+
+// Snapshot writes the rows matched by criteria to w as NDJSON: a
+// TableSnapshotHeader line followed by one RecordToMap object (Bun column
+// keys) per row, and returns the number of rows written. Rows are read in
+// primary key order with keyset pagination, so criteria should only filter.
+func (r *repo[T]) Snapshot(ctx context.Context, w io.Writer, criteria ...SelectCriteria) (int, error) {
+	return r.SnapshotTx(ctx, r.db, w, criteria...)
+}
+
+func (r *repo[T]) SnapshotTx(ctx context.Context, tx bun.IDB, w io.Writer, criteria ...SelectCriteria) (int, error) {
+	ctx = r.withOperation(ctx, "Snapshot", len(criteria))
+	table := r.modelTable()
+	if table == nil || len(table.PKs) == 0 {
+		return 0, fmt.Errorf("repository: table snapshot requires a model with a primary key")
+	}
+
+	header := TableSnapshotHeader{
+		Format:        TableSnapshotFormat,
+		Table:         r.TableName(),
+		SchemaVersion: LatestPayloadVersion(r.TableName()),
+		CreatedAt:     time.Now().UTC(),
+	}
+	for _, field := range table.Fields {
+		header.Columns = append(header.Columns, field.Name)
+	}
+
+	bw := bufio.NewWriter(w)
+	encoder := json.NewEncoder(bw)
+	if err := encoder.Encode(header); err != nil {
+		return 0, err
+	}
+
+	written := 0
+	var after []any
+	for {
+		records := []T{}
+		q := tx.NewSelect().Model(&records)
+		q = r.applySelectScopes(ctx, q)
+		if err := r.applySelectCriteria(q, criteria); err != nil {
+			return written, err
+		}
+		keys := make([]string, len(table.PKs))
+		for i, pk := range table.PKs {
+			keys[i] = "?TableAlias." + string(pk.SQLName)
+			q.OrderExpr("?TableAlias.? ASC", bun.Ident(pk.Name))
+		}
+		if after != nil {
+			placeholders := strings.TrimSuffix(strings.Repeat("?, ", len(after)), ", ")
+			if len(after) == 1 {
+				q.Where(keys[0]+" > ?", after...)
+			} else {
+				q.Where("("+strings.Join(keys, ", ")+") > ("+placeholders+")", after...)
+			}
+		}
+		if err := r.scanRecords(ctx, q.Limit(defaultTableSnapshotChunkSize).Scan); err != nil {
+			return written, err
+		}
+
+		for _, record := range records {
+			row, err := RecordToMap(record,
+				WithProjectionSchema(r.db),
+				WithProjectionJSONCompatibleValues(),
+				WithProjectionRedaction(false),
+			)
+			if err != nil {
+				return written, err
+			}
+			if err := encoder.Encode(row); err != nil {
+				return written, err
+			}
+			written++
+		}
+		if len(records) < defaultTableSnapshotChunkSize {
+			return written, bw.Flush()
+		}
+
+		last := reflect.Indirect(reflect.ValueOf(records[len(records)-1]))
+		after = make([]any, len(table.PKs))
+		for i, pk := range table.PKs {
+			after[i] = pk.Value(last).Interface()
+		}
+	}
+}
+
+// Restore re-inserts the rows of a Snapshot file like Import,
+// upgrading rows written at an older schema version with the payload
+// migrations of the table first. Rows whose primary key or identifier
+// already exists are skipped, so restores are idempotent;
+// WithImportConflictStrategy(ImportOverwrite) replaces them instead. Columns
+// unknown to the current model are ignored and rows are inserted in batches
+// of 500 unless WithImportBatchSize says otherwise.
+func (r *repo[T]) Restore(ctx context.Context, reader io.Reader, opts ...ImportOption) (ImportReport, error) {
+	return r.RestoreTx(ctx, r.db, reader, opts...)
+}
+
+func (r *repo[T]) RestoreTx(ctx context.Context, tx bun.IDB, reader io.Reader, opts ...ImportOption) (ImportReport, error) {
+	ctx = r.withOperation(ctx, "Restore", 0)
+	br := bufio.NewReader(reader)
+	line, err := br.ReadBytes('\n')
+	if err != nil && err != io.EOF {
+		return ImportReport{}, err
+	}
+	var header TableSnapshotHeader
+	if err := json.Unmarshal(bytes.TrimSpace(line), &header); err != nil || header.Format != TableSnapshotFormat {
+		return ImportReport{}, fmt.Errorf("%w: missing snapshot header", ErrTableSnapshotMismatch)
+	}
+	if header.Table != r.TableName() {
+		return ImportReport{}, fmt.Errorf("%w: snapshot of %q restored into %q", ErrTableSnapshotMismatch, header.Table, r.TableName())
+	}
+	if latest := LatestPayloadVersion(header.Table); header.SchemaVersion > latest {
+		return ImportReport{}, fmt.Errorf("%w: %s v%d (latest v%d)", ErrPayloadVersionUnsupported, header.Table, header.SchemaVersion, latest)
+	}
+
+	defaults := []ImportOption{
+		WithImportConflictStrategy(ImportSkip),
+		WithImportBatchSize(defaultImportStreamBatchSize),
+		WithImportPatchOptions(
+			WithPatchSchema(r.db),
+			WithPatchIgnoreUnknown(true),
+			WithPayloadVersion(header.Table, header.SchemaVersion),
+		),
+	}
+	cfg := newImportConfig(append(defaults, opts...))
+
+	rows := ndjsonImportSource(br)
+	next := func() (importInput, bool, error) {
+		input, ok, err := rows()
+		// the header is line 1
+		input.line++
+		return input, ok, err
+	}
+	return r.importRows(ctx, tx, next, 0, cfg)
+}
@@ -0,0 +1,98 @@
+package repository
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRepository_Snapshot_Restore(t *testing.T) {
+	setupTestData(t)
+	ctx := context.Background()
+	repo := newTestUserRepository(db)
+	seedExportUsers(t, repo, 5)
+
+	snapshotter, ok := repo.(TableSnapshotter)
+	require.True(t, ok)
+
+	var out bytes.Buffer
+	written, err := snapshotter.Snapshot(ctx, &out, SelectBy("email", "<>", "user-4@example.com"))
+	require.NoError(t, err)
+	assert.Equal(t, 4, written)
+
+	scanner := bufio.NewScanner(bytes.NewReader(out.Bytes()))
+	require.True(t, scanner.Scan())
+	var header TableSnapshotHeader
+	require.NoError(t, json.Unmarshal(scanner.Bytes(), &header))
+	assert.Equal(t, TableSnapshotFormat, header.Format)
+	assert.Equal(t, "test_users", header.Table)
+	assert.Contains(t, header.Columns, "email")
+
+	_, err = db.NewDelete().Model((*TestUser)(nil)).Where("email IN (?, ?)", "user-0@example.com", "user-1@example.com").Exec(ctx)
+	require.NoError(t, err)
+
+	report, err := snapshotter.Restore(ctx, bytes.NewReader(out.Bytes()))
+	require.NoError(t, err)
+	assert.Equal(t, 4, report.Rows)
+	assert.Equal(t, 2, report.Inserted)
+	assert.Equal(t, 2, report.Skipped)
+
+	restored, err := repo.GetByIdentifier(ctx, "user-0@example.com")
+	require.NoError(t, err)
+	assert.Equal(t, "user-0", restored.Name)
+
+	report, err = snapshotter.Restore(ctx, bytes.NewReader(out.Bytes()))
+	require.NoError(t, err)
+	assert.Equal(t, 4, report.Skipped)
+
+	count, err := repo.Count(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 5, count)
+}
+
+func TestRepository_RestoreTable_MigratesOlderSnapshots(t *testing.T) {
+	setupTestData(t)
+	ctx := context.Background()
+	repo := newTestUserRepository(db)
+
+	if LatestPayloadVersion("test_users") < 2 {
+		require.NoError(t, RegisterPayloadMigration("test_users", 2, func(p map[string]any) map[string]any {
+			if name, ok := p["full_name"]; ok {
+				p["name"] = name
+				delete(p, "full_name")
+			}
+			return p
+		}))
+	}
+
+	input := strings.Join([]string{
+		`{"format":"go-repository-bun/table-snapshot","table":"test_users","schema_version":1}`,
+		`{"full_name":"Legacy","email":"legacy@example.com","dropped_column":true}`,
+	}, "\n")
+
+	report, err := repo.(TableSnapshotter).Restore(ctx, strings.NewReader(input))
+	require.NoError(t, err)
+	assert.Equal(t, 1, report.Inserted)
+
+	restored, err := repo.GetByIdentifier(ctx, "legacy@example.com")
+	require.NoError(t, err)
+	assert.Equal(t, "Legacy", restored.Name)
+}
+
+func TestRepository_RestoreTable_RejectsOtherTables(t *testing.T) {
+	setupTestData(t)
+	repo := newTestUserRepository(db)
+	snapshotter := repo.(TableSnapshotter)
+
+	_, err := snapshotter.Restore(context.Background(), strings.NewReader(`{"format":"go-repository-bun/table-snapshot","table":"companies"}`))
+	assert.ErrorIs(t, err, ErrTableSnapshotMismatch)
+
+	_, err = snapshotter.Restore(context.Background(), strings.NewReader(`{"name":"no header"}`))
+	assert.ErrorIs(t, err, ErrTableSnapshotMismatch)
+}
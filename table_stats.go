@@ -0,0 +1,102 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/uptrace/bun"
+)
+
+// TableStats reports the approximate size of a repository table. Values the
+// database does not expose are left zero (sizes) or nil (timestamps).
+type TableStats struct {
+	Table string `json:"table"`
+	// Rows is the planner estimate on Postgres, MySQL and MSSQL and an exact
+	// count on SQLite.
+	Rows       int64      `json:"rows"`
+	TotalBytes int64      `json:"total_bytes"`
+	TableBytes int64      `json:"table_bytes"`
+	IndexBytes int64      `json:"index_bytes"`
+	LastVacuum *time.Time `json:"last_vacuum,omitempty"`
+	// LastAnalyze is the last time planner statistics were refreshed.
+	LastAnalyze *time.Time `json:"last_analyze,omitempty"`
+}
+
+// TableStatsReader is an optional capability for repositories that can report
+// statistics about their table, e.g. for capacity dashboards.
+type TableStatsReader interface {
+	TableStats(ctx context.Context) (TableStats, error)
+}
+
+const postgresTableStatsQuery = `SELECT
+	GREATEST(c.reltuples, 0)::bigint,
+	pg_total_relation_size(c.oid),
+	pg_relation_size(c.oid),
+	pg_indexes_size(c.oid),
+	GREATEST(s.last_vacuum, s.last_autovacuum),
+	GREATEST(s.last_analyze, s.last_autoanalyze)
+FROM pg_class c
+LEFT JOIN pg_stat_all_tables s ON s.relid = c.oid
+WHERE c.oid = to_regclass(?)`
+
+const mysqlTableStatsQuery = `SELECT
+	COALESCE(TABLE_ROWS, 0),
+	COALESCE(DATA_LENGTH, 0) + COALESCE(INDEX_LENGTH, 0),
+	COALESCE(DATA_LENGTH, 0),
+	COALESCE(INDEX_LENGTH, 0)
+FROM information_schema.TABLES
+WHERE TABLE_SCHEMA = DATABASE() AND TABLE_NAME = ?`
+
+const mssqlTableStatsQuery = `SELECT
+	COALESCE(SUM(CASE WHEN ps.index_id < 2 THEN ps.row_count ELSE 0 END), 0),
+	COALESCE(SUM(ps.reserved_page_count), 0) * 8192,
+	COALESCE(SUM(CASE WHEN ps.index_id < 2 THEN ps.reserved_page_count ELSE 0 END), 0) * 8192,
+	(SELECT MAX(STATS_DATE(st.object_id, st.stats_id)) FROM sys.stats st WHERE st.object_id = OBJECT_ID(?))
+FROM sys.dm_db_partition_stats ps
+WHERE ps.object_id = OBJECT_ID(?)`
+
+// TableStats returns row count and size statistics for the repository table.
+// SQLite does not track table sizes, so only Rows is reported there.
+func (r *repo[T]) TableStats(ctx context.Context) (TableStats, error) {
+	table := r.TableName()
+	stats := TableStats{Table: table}
+
+	var err error
+	switch r.driver {
+	case "postgres":
+		var lastVacuum, lastAnalyze sql.NullTime
+		err = r.db.NewRaw(postgresTableStatsQuery, table).Scan(ctx,
+			&stats.Rows, &stats.TotalBytes, &stats.TableBytes, &stats.IndexBytes, &lastVacuum, &lastAnalyze,
+		)
+		stats.LastVacuum = nullTimePtr(lastVacuum)
+		stats.LastAnalyze = nullTimePtr(lastAnalyze)
+	case "mysql":
+		err = r.db.NewRaw(mysqlTableStatsQuery, table).Scan(ctx,
+			&stats.Rows, &stats.TotalBytes, &stats.TableBytes, &stats.IndexBytes,
+		)
+	case "mssql":
+		var lastAnalyze sql.NullTime
+		err = r.db.NewRaw(mssqlTableStatsQuery, table, table).Scan(ctx,
+			&stats.Rows, &stats.TotalBytes, &stats.TableBytes, &lastAnalyze,
+		)
+		stats.IndexBytes = stats.TotalBytes - stats.TableBytes
+		stats.LastAnalyze = nullTimePtr(lastAnalyze)
+	case "sqlite":
+		err = r.db.NewRaw("SELECT COUNT(*) FROM ?", bun.Ident(table)).Scan(ctx, &stats.Rows)
+	default:
+		return stats, unsupportedDriverError("TableStats", r.driver)
+	}
+	if err != nil {
+		return TableStats{}, r.mapError(err)
+	}
+	return stats, nil
+}
+
+func nullTimePtr(value sql.NullTime) *time.Time {
+	if !value.Valid {
+		return nil
+	}
+	t := value.Time
+	return &t
+}
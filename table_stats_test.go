@@ -0,0 +1,31 @@
+package repository
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRepository_TableStats(t *testing.T) {
+	setupTestData(t)
+	ctx := context.Background()
+
+	repo := newTestUserRepository(db)
+	_, err := repo.CreateMany(ctx, []*TestUser{
+		{Name: "Alice", Email: "alice@example.com"},
+		{Name: "Bob", Email: "bob@example.com"},
+	})
+	require.NoError(t, err)
+
+	reader, ok := repo.(TableStatsReader)
+	require.True(t, ok)
+
+	stats, err := reader.TableStats(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, "test_users", stats.Table)
+	assert.Equal(t, int64(2), stats.Rows)
+	assert.Zero(t, stats.TotalBytes)
+	assert.Nil(t, stats.LastAnalyze)
+}
@@ -0,0 +1,48 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/goliatone/go-errors"
+	"github.com/uptrace/bun"
+)
+
+// RecordToucher is an optional capability for repositories that can refresh a
+// timestamp column on many rows at once.
+type RecordToucher interface {
+	TouchWhere(ctx context.Context, column string, criteria ...UpdateCriteria) (int64, error)
+	TouchWhereTx(ctx context.Context, tx bun.IDB, column string, criteria ...UpdateCriteria) (int64, error)
+}
+
+// TouchWhere sets column to the current time for every row matched by
+// criteria in a single UPDATE and returns the number of affected rows, e.g.
+// to invalidate cached expiry for a tenant:
+//
+//	repo.(RecordToucher).TouchWhere(ctx, "updated_at", UpdateBy("tenant_id", "=", tenantID))
+//
+// Like UpdateWhere, calls without a WHERE clause are blocked unless
+// WithAllowFullTableUpdate(true) is configured.
+func (r *repo[T]) TouchWhere(ctx context.Context, column string, criteria ...UpdateCriteria) (int64, error) {
+	return r.TouchWhereTx(ctx, r.db, column, criteria...)
+}
+
+func (r *repo[T]) TouchWhereTx(ctx context.Context, tx bun.IDB, column string, criteria ...UpdateCriteria) (int64, error) {
+	ctx = r.withOperation(ctx, "TouchWhere", len(criteria))
+	col, ok := normalizeSQLIdentifier(column)
+	if !ok {
+		return 0, invalidColumnError("column", column)
+	}
+	if table := r.modelTable(); table != nil {
+		if _, ok := table.FieldMap[col]; !ok {
+			return 0, errors.NewValidation(
+				"repository: unknown column",
+				errors.FieldError{Field: "column", Message: fmt.Sprintf("column %q does not exist on %s", col, table.Name)},
+			)
+		}
+	}
+
+	touch := UpdateSetColumn(col, time.Now().UTC())
+	return r.UpdateWhereTx(ctx, tx, append([]UpdateCriteria{touch}, criteria...)...)
+}
@@ -0,0 +1,54 @@
+package repository
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	goerrors "github.com/goliatone/go-errors"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRepository_TouchWhere(t *testing.T) {
+	setupTestData(t)
+	ctx := context.Background()
+
+	repo := newTestUserRepository(db)
+	toucher, ok := repo.(RecordToucher)
+	require.True(t, ok)
+
+	past := time.Now().Add(-48 * time.Hour).UTC()
+	acme, other := uuid.New(), uuid.New()
+	for _, user := range []*TestUser{
+		{Name: "Alice", Email: "alice@example.com", CompanyID: acme, CreatedAt: past, UpdatedAt: past},
+		{Name: "Bob", Email: "bob@example.com", CompanyID: acme, CreatedAt: past, UpdatedAt: past},
+		{Name: "Carol", Email: "carol@example.com", CompanyID: other, CreatedAt: past, UpdatedAt: past},
+	} {
+		_, err := repo.Create(ctx, user)
+		require.NoError(t, err)
+	}
+
+	before := time.Now().Add(-time.Minute)
+	affected, err := toucher.TouchWhere(ctx, "updated_at", UpdateBy("company_id", "=", acme.String()))
+	require.NoError(t, err)
+	assert.EqualValues(t, 2, affected)
+
+	users, _, err := repo.List(ctx, OrderBy("name ASC"))
+	require.NoError(t, err)
+	require.Len(t, users, 3)
+	assert.True(t, users[0].UpdatedAt.After(before))
+	assert.True(t, users[1].UpdatedAt.After(before))
+	assert.True(t, users[2].UpdatedAt.Before(before), "rows outside the criteria are untouched")
+	assert.Equal(t, "Carol", users[2].Name)
+
+	_, err = toucher.TouchWhere(ctx, "updated_at")
+	assert.True(t, IsFullTableOperationBlocked(err))
+
+	_, err = toucher.TouchWhere(ctx, "expires_at", UpdateBy("company_id", "=", acme.String()))
+	assert.True(t, goerrors.IsValidation(err))
+
+	_, err = toucher.TouchWhere(ctx, "updated_at; DROP", UpdateBy("company_id", "=", acme.String()))
+	assert.True(t, goerrors.IsValidation(err))
+}
@@ -0,0 +1,172 @@
+package repository
+
+import (
+	"context"
+	stderrors "errors"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/goliatone/go-errors"
+	"github.com/google/uuid"
+	"github.com/uptrace/bun"
+)
+
+// ErrInvalidTransition matches, with errors.Is, updates rejected by
+// WithTransitions.
+var ErrInvalidTransition = stderrors.New("repository: invalid state transition")
+
+const invalidTransitionTextCode = "INVALID_TRANSITION"
+
+// InvalidTransitionError describes an update rejected by WithTransitions. It
+// is the source of the returned validation error, so errors.As finds it.
+type InvalidTransitionError struct {
+	Column string
+	From   string
+	To     string
+}
+
+func (e *InvalidTransitionError) Error() string {
+	return fmt.Sprintf("repository: %s cannot transition from %q to %q", e.Column, e.From, e.To)
+}
+
+// Is reports whether target is ErrInvalidTransition.
+func (e *InvalidTransitionError) Is(target error) bool {
+	return target == ErrInvalidTransition
+}
+
+type transitionConfig struct {
+	column  string
+	allowed map[string][]string
+}
+
+// WithTransitions guards a status column with a transition graph: allowed
+// maps each state to the states it may move to. Update, UpdateMany, Upsert
+// and UpdateByIDWithMapPatch calls that change the column to a state not
+// allowed from the stored one fail with a 409 validation error wrapping an
+// *InvalidTransitionError (errors.Is(err, ErrInvalidTransition)), before any
+// row is written. Inserts may use any state and updates leaving the column
+// untouched are not checked. Statements that do not load records, such as
+// UpdateWhere or UpsertOnConflict, are not guarded either. The column must
+// be a string field; unknown columns are reported by Validate.
+func WithTransitions(column string, allowed map[string][]string) RepoOption {
+	return func(cfg *repoConfig) {
+		if cfg == nil {
+			return
+		}
+		graph := make(map[string][]string, len(allowed))
+		for from, to := range allowed {
+			graph[from] = copyStrings(to)
+		}
+		cfg.transitions = &transitionConfig{
+			column:  strings.TrimSpace(column),
+			allowed: graph,
+		}
+	}
+}
+
+func (c *transitionConfig) permits(from, to string) bool {
+	return from == to || containsString(c.allowed[from], to)
+}
+
+// checkTransitions validates the state of records against the stored rows
+// when the update writes the guarded column. columns are the updated
+// columns, nil for every column.
+func (r *repo[T]) checkTransitions(ctx context.Context, tx bun.IDB, columns map[string]struct{}, records ...T) error {
+	if r.transitions == nil || len(records) == 0 {
+		return nil
+	}
+	column := r.transitions.column
+	if columns != nil {
+		if _, ok := columns[column]; !ok {
+			return nil
+		}
+	}
+	table := r.modelTable()
+	if table == nil {
+		return nil
+	}
+	field, ok := table.FieldMap[column]
+	if !ok {
+		return r.validateTransitions()
+	}
+
+	ids := make([]uuid.UUID, 0, len(records))
+	for _, record := range records {
+		ids = append(ids, r.handlers.GetID(record))
+	}
+	var rows []struct {
+		ID    uuid.UUID `bun:"id"`
+		State string    `bun:"state"`
+	}
+	q := tx.NewSelect().
+		Model(r.handlers.NewRecord()).
+		ColumnExpr("?TableAlias.id").
+		ColumnExpr("?TableAlias.? AS state", bun.Ident(column)).
+		Where("?TableAlias.id IN (?)", bun.In(ids))
+	if querySoftDeletes(q) {
+		q = q.WhereAllWithDeleted()
+	}
+	if err := q.Scan(ctx, &rows); err != nil {
+		return r.mapError(err)
+	}
+	stored := make(map[uuid.UUID]string, len(rows))
+	for _, row := range rows {
+		stored[row.ID] = row.State
+	}
+
+	for _, record := range records {
+		from, found := stored[r.handlers.GetID(record)]
+		if !found {
+			// the update itself reports missing records
+			continue
+		}
+		value := field.Value(reflect.Indirect(reflect.ValueOf(record)))
+		to := ""
+		if value = reflect.Indirect(value); value.IsValid() {
+			to = value.String()
+		}
+		if !r.transitions.permits(from, to) {
+			return invalidTransitionError(column, from, to)
+		}
+	}
+	return nil
+}
+
+func invalidTransitionError(column, from, to string) error {
+	err := errors.NewValidation(
+		"repository: invalid state transition",
+		errors.FieldError{Field: column, Message: fmt.Sprintf("cannot transition from %q to %q", from, to)},
+	).
+		WithCode(errors.CodeConflict).
+		WithTextCode(invalidTransitionTextCode).
+		WithMetadata(map[string]any{"column": column, "from": from, "to": to})
+	err.Source = &InvalidTransitionError{Column: column, From: from, To: to}
+	return err
+}
+
+func (r *repo[T]) validateTransitions() error {
+	if r.transitions == nil {
+		return nil
+	}
+	table := r.modelTable()
+	if table == nil {
+		return nil
+	}
+
+	column := r.transitions.column
+	field, ok := table.FieldMap[column]
+	var message string
+	switch {
+	case !ok:
+		message = fmt.Sprintf("unknown column %q on %s", column, table.Name)
+	case field.IndirectType.Kind() != reflect.String:
+		message = fmt.Sprintf("column %q on %s is not a string", column, table.Name)
+	default:
+		return nil
+	}
+	return errors.NewValidation("repository configuration invalid", errors.FieldError{
+		Field:   "repoOptions.WithTransitions",
+		Message: message,
+	})
+}
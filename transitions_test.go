@@ -0,0 +1,102 @@
+package repository
+
+import (
+	"context"
+	"testing"
+
+	goerrors "github.com/goliatone/go-errors"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/uptrace/bun"
+)
+
+type transitionTestOrder struct {
+	bun.BaseModel `bun:"table:transition_test_orders,alias:tto"`
+
+	ID     uuid.UUID `bun:"id,pk,notnull"`
+	Status string    `bun:"status,notnull"`
+	Note   string    `bun:"note"`
+}
+
+func newTransitionTestRepository(t *testing.T) Repository[*transitionTestOrder] {
+	t.Helper()
+	repo := newTestRepository[*transitionTestOrder](t, WithTransitions("status", map[string][]string{
+		"draft":   {"pending", "cancelled"},
+		"pending": {"paid", "cancelled"},
+		"paid":    {"shipped"},
+	}))
+	require.NoError(t, repo.(Validator).Validate())
+	return repo
+}
+
+func TestRepository_WithTransitions_Update(t *testing.T) {
+	ctx := context.Background()
+	repo := newTransitionTestRepository(t)
+
+	order, err := repo.Create(ctx, &transitionTestOrder{Status: "draft"})
+	require.NoError(t, err)
+
+	order.Status = "pending"
+	order, err = repo.Update(ctx, order)
+	require.NoError(t, err)
+
+	order.Status = "shipped"
+	_, err = repo.Update(ctx, order)
+	require.Error(t, err)
+	assert.ErrorIs(t, err, ErrInvalidTransition)
+	assert.True(t, goerrors.IsValidation(err))
+
+	var transitionErr *InvalidTransitionError
+	require.ErrorAs(t, err, &transitionErr)
+	assert.Equal(t, "status", transitionErr.Column)
+	assert.Equal(t, "pending", transitionErr.From)
+	assert.Equal(t, "shipped", transitionErr.To)
+
+	stored, err := repo.GetByID(ctx, order.ID.String())
+	require.NoError(t, err)
+	assert.Equal(t, "pending", stored.Status)
+
+	// updates leaving the column out are not checked
+	order.Note = "gift wrap"
+	_, err = repo.Update(ctx, order, UpdateColumns("note"))
+	require.NoError(t, err)
+
+	// unchanged states are always allowed
+	stored.Note = "fragile"
+	_, err = repo.Update(ctx, stored)
+	require.NoError(t, err)
+}
+
+func TestRepository_WithTransitions_MapPatchAndUpdateMany(t *testing.T) {
+	ctx := context.Background()
+	repo := newTransitionTestRepository(t)
+
+	first, err := repo.Create(ctx, &transitionTestOrder{Status: "pending"})
+	require.NoError(t, err)
+	second, err := repo.Create(ctx, &transitionTestOrder{Status: "draft"})
+	require.NoError(t, err)
+
+	_, err = UpdateByIDWithMapPatch(ctx, repo, first.ID.String(), map[string]any{"status": "draft"}, nil)
+	assert.ErrorIs(t, err, ErrInvalidTransition)
+
+	patched, err := UpdateByIDWithMapPatch(ctx, repo, first.ID.String(), map[string]any{"status": "paid"}, nil)
+	require.NoError(t, err)
+	assert.Equal(t, "paid", patched.Status)
+
+	first.Status = "shipped"
+	second.Status = "paid"
+	_, err = repo.UpdateMany(ctx, []*transitionTestOrder{first, second})
+	assert.ErrorIs(t, err, ErrInvalidTransition)
+
+	stored, err := repo.GetByID(ctx, first.ID.String())
+	require.NoError(t, err)
+	assert.Equal(t, "paid", stored.Status)
+}
+
+func TestRepository_WithTransitions_Validate(t *testing.T) {
+	repo := NewRepositoryWithConfig(db, testUserHandlers(), nil, WithTransitions("missing", nil))
+	err := repo.(Validator).Validate()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "repository configuration invalid")
+}
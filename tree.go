@@ -0,0 +1,260 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+
+	"github.com/goliatone/go-errors"
+	"github.com/google/uuid"
+	"github.com/uptrace/bun"
+)
+
+// defaultParentColumn is the parent column of TreeRepository when
+// WithParentColumn is not configured.
+const defaultParentColumn = "parent_id"
+
+// maxTreeDepth bounds the recursive tree queries, so cycles in corrupt data
+// cannot make them run forever.
+const maxTreeDepth = 1000
+
+// WithParentColumn sets the column holding the parent ID of adjacency-list
+// models, used by TreeRepository. Defaults to "parent_id". Unknown columns are
+// reported by Validate.
+func WithParentColumn(column string) RepoOption {
+	return func(cfg *repoConfig) {
+		if cfg == nil {
+			return
+		}
+		cfg.parentColumn = strings.TrimSpace(column)
+	}
+}
+
+// TreeRepository is an optional capability for repositories of adjacency-list
+// models, where each row references its parent and roots have a NULL parent.
+type TreeRepository[T any] interface {
+	GetChildren(ctx context.Context, id uuid.UUID, criteria ...SelectCriteria) ([]T, error)
+	GetChildrenTx(ctx context.Context, tx bun.IDB, id uuid.UUID, criteria ...SelectCriteria) ([]T, error)
+	GetDescendants(ctx context.Context, id uuid.UUID, criteria ...SelectCriteria) ([]T, error)
+	GetDescendantsTx(ctx context.Context, tx bun.IDB, id uuid.UUID, criteria ...SelectCriteria) ([]T, error)
+	GetAncestors(ctx context.Context, id uuid.UUID, criteria ...SelectCriteria) ([]T, error)
+	GetAncestorsTx(ctx context.Context, tx bun.IDB, id uuid.UUID, criteria ...SelectCriteria) ([]T, error)
+	MoveSubtree(ctx context.Context, id, newParentID uuid.UUID) (T, error)
+	MoveSubtreeTx(ctx context.Context, tx bun.IDB, id, newParentID uuid.UUID) (T, error)
+}
+
+// GetChildren returns the direct children of id, in the default order.
+func (r *repo[T]) GetChildren(ctx context.Context, id uuid.UUID, criteria ...SelectCriteria) ([]T, error) {
+	return r.GetChildrenTx(ctx, r.db, id, criteria...)
+}
+
+func (r *repo[T]) GetChildrenTx(ctx context.Context, tx bun.IDB, id uuid.UUID, criteria ...SelectCriteria) ([]T, error) {
+	ctx = r.withOperation(ctx, "GetChildren", len(criteria))
+	parent, err := r.treeParentColumn()
+	if err != nil {
+		return nil, err
+	}
+
+	records := []T{}
+	q := tx.NewSelect().
+		Model(&records).
+		Where("?TableAlias.? = ?", bun.Ident(parent), id)
+	q = r.applySelectScopes(ctx, q)
+	q = r.applyDefaultRelations(ctx, q)
+	if err := r.applySelectCriteria(q, criteria); err != nil {
+		return nil, err
+	}
+	if !selectHasOrder(q) {
+		for _, expr := range r.defaultOrder {
+			q.OrderExpr(expr)
+		}
+	}
+	if err := r.scanRecords(ctx, q.Scan); err != nil {
+		return nil, err
+	}
+	return records, nil
+}
+
+// GetDescendants returns every node below id with a recursive CTE, breadth
+// first: children, then grandchildren and so on. Criteria and scopes filter
+// the returned records, not the traversal.
+func (r *repo[T]) GetDescendants(ctx context.Context, id uuid.UUID, criteria ...SelectCriteria) ([]T, error) {
+	return r.GetDescendantsTx(ctx, r.db, id, criteria...)
+}
+
+func (r *repo[T]) GetDescendantsTx(ctx context.Context, tx bun.IDB, id uuid.UUID, criteria ...SelectCriteria) ([]T, error) {
+	ctx = r.withOperation(ctx, "GetDescendants", len(criteria))
+	parent, err := r.treeParentColumn()
+	if err != nil {
+		return nil, err
+	}
+
+	ids, err := r.treeNodeIDs(ctx, tx, `
+WITH RECURSIVE tree_nodes (id, depth) AS (
+	SELECT n.id, 1 FROM ? AS n WHERE n.? = ?
+	UNION ALL
+	SELECT n.id, tree_nodes.depth + 1 FROM ? AS n
+	JOIN tree_nodes ON n.? = tree_nodes.id
+	WHERE tree_nodes.depth < ?
+)
+SELECT id, depth FROM tree_nodes ORDER BY depth`,
+		bun.Ident(r.TableName()), bun.Ident(parent), id,
+		bun.Ident(r.TableName()), bun.Ident(parent), maxTreeDepth,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return r.recordsInOrder(ctx, tx, ids, criteria)
+}
+
+// GetAncestors returns the ancestors of id from its parent up to the root.
+// Criteria and scopes filter the returned records, not the traversal.
+func (r *repo[T]) GetAncestors(ctx context.Context, id uuid.UUID, criteria ...SelectCriteria) ([]T, error) {
+	return r.GetAncestorsTx(ctx, r.db, id, criteria...)
+}
+
+func (r *repo[T]) GetAncestorsTx(ctx context.Context, tx bun.IDB, id uuid.UUID, criteria ...SelectCriteria) ([]T, error) {
+	ctx = r.withOperation(ctx, "GetAncestors", len(criteria))
+	ids, err := r.ancestorIDs(ctx, tx, id)
+	if err != nil {
+		return nil, err
+	}
+	return r.recordsInOrder(ctx, tx, ids, criteria)
+}
+
+// MoveSubtree makes newParentID the parent of id, carrying its descendants
+// along, and returns the updated node. uuid.Nil moves the node to the root.
+// Moving a node under itself or one of its descendants fails with a
+// validation error. The parent column is written with Update, so domain
+// events are published.
+func (r *repo[T]) MoveSubtree(ctx context.Context, id, newParentID uuid.UUID) (T, error) {
+	return r.MoveSubtreeTx(ctx, r.db, id, newParentID)
+}
+
+func (r *repo[T]) MoveSubtreeTx(ctx context.Context, tx bun.IDB, id, newParentID uuid.UUID) (T, error) {
+	ctx = r.withOperation(ctx, "MoveSubtree", 0)
+	var moved T
+	parent, err := r.treeParentColumn()
+	if err != nil {
+		return moved, err
+	}
+
+	err = runInTx(ctx, tx, func(ctx context.Context, tx bun.IDB) error {
+		record, err := r.GetByIDTx(ctx, tx, id.String())
+		if err != nil {
+			return err
+		}
+
+		var src any
+		if newParentID != uuid.Nil {
+			if _, err := r.GetByIDTx(ctx, tx, newParentID.String()); err != nil {
+				return err
+			}
+			ancestors, err := r.ancestorIDs(ctx, tx, newParentID)
+			if err != nil {
+				return err
+			}
+			if newParentID == id || slices.Contains(ancestors, id) {
+				return errors.NewValidation(
+					"repository: invalid tree move",
+					errors.FieldError{Field: "newParentID", Message: "a node cannot be moved under itself or one of its descendants"},
+				)
+			}
+			src = newParentID.String()
+		}
+
+		field := r.modelTable().FieldMap[parent]
+		structValue := reflect.Indirect(reflect.ValueOf(record))
+		if src == nil {
+			fv := field.Value(structValue)
+			fv.Set(reflect.Zero(fv.Type()))
+		} else if err := field.ScanValue(structValue, src); err != nil {
+			return fmt.Errorf("repository: set %s: %w", parent, err)
+		}
+		moved, err = r.UpdateTx(ctx, tx, record, UpdateColumns(parent))
+		return err
+	})
+	return moved, err
+}
+
+func (r *repo[T]) ancestorIDs(ctx context.Context, tx bun.IDB, id uuid.UUID) ([]uuid.UUID, error) {
+	parent, err := r.treeParentColumn()
+	if err != nil {
+		return nil, err
+	}
+
+	return r.treeNodeIDs(ctx, tx, `
+WITH RECURSIVE tree_nodes (id, parent_ref, depth) AS (
+	SELECT p.id, p.?, 1 FROM ? AS p
+	JOIN ? AS c ON p.id = c.?
+	WHERE c.id = ?
+	UNION ALL
+	SELECT p.id, p.?, tree_nodes.depth + 1 FROM ? AS p
+	JOIN tree_nodes ON p.id = tree_nodes.parent_ref
+	WHERE tree_nodes.depth < ?
+)
+SELECT id, depth FROM tree_nodes ORDER BY depth`,
+		bun.Ident(parent), bun.Ident(r.TableName()),
+		bun.Ident(r.TableName()), bun.Ident(parent),
+		id,
+		bun.Ident(parent), bun.Ident(r.TableName()),
+		maxTreeDepth,
+	)
+}
+
+// treeNodeIDs runs a tree query returning id and depth columns and returns
+// the distinct ids in depth order.
+func (r *repo[T]) treeNodeIDs(ctx context.Context, tx bun.IDB, query string, args ...any) ([]uuid.UUID, error) {
+	var nodes []struct {
+		ID    uuid.UUID `bun:"id"`
+		Depth int       `bun:"depth"`
+	}
+	if err := tx.NewRaw(query, args...).Scan(ctx, &nodes); err != nil {
+		return nil, r.mapError(err)
+	}
+
+	ids := make([]uuid.UUID, 0, len(nodes))
+	seen := make(map[uuid.UUID]bool, len(nodes))
+	for _, node := range nodes {
+		if !seen[node.ID] {
+			seen[node.ID] = true
+			ids = append(ids, node.ID)
+		}
+	}
+	return ids, nil
+}
+
+func (r *repo[T]) treeParentColumn() (string, error) {
+	column := r.parentColumn
+	if column == "" {
+		column = defaultParentColumn
+	}
+	if table := r.modelTable(); table != nil {
+		if _, ok := table.FieldMap[column]; ok {
+			return column, nil
+		}
+	}
+	return "", errors.NewValidation(
+		"repository: unknown parent column",
+		errors.FieldError{Field: "parentColumn", Message: fmt.Sprintf("column %q does not exist on %s; configure it with WithParentColumn", column, r.TableName())},
+	)
+}
+
+func (r *repo[T]) validateParentColumn() error {
+	if r.parentColumn == "" {
+		return nil
+	}
+	table := r.modelTable()
+	if table == nil {
+		return nil
+	}
+	if _, ok := table.FieldMap[r.parentColumn]; !ok {
+		return errors.NewValidation("repository configuration invalid", errors.FieldError{
+			Field:   "repoOptions.WithParentColumn",
+			Message: fmt.Sprintf("unknown column %q on %s", r.parentColumn, table.Name),
+		})
+	}
+	return nil
+}
@@ -0,0 +1,128 @@
+package repository
+
+import (
+	"context"
+	"testing"
+
+	goerrors "github.com/goliatone/go-errors"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/uptrace/bun"
+)
+
+type treeTestNode struct {
+	bun.BaseModel `bun:"table:tree_test_nodes,alias:ttn"`
+
+	ID       uuid.UUID  `bun:"id,pk,notnull"`
+	Name     string     `bun:"name,notnull"`
+	ParentID *uuid.UUID `bun:"parent_node_id"`
+}
+
+// newTreeTestRepository creates the tree
+//
+//	root
+//	├── a
+//	│   ├── a1
+//	│   │   └── a1x
+//	│   └── a2
+//	└── b
+func newTreeTestRepository(t *testing.T) (Repository[*treeTestNode], map[string]uuid.UUID) {
+	t.Helper()
+	ctx := context.Background()
+	repo := newTestRepository[*treeTestNode](t, WithParentColumn("parent_node_id"), WithDefaultOrder("name ASC"))
+	require.NoError(t, repo.(Validator).Validate())
+
+	ids := map[string]uuid.UUID{}
+	for _, node := range []struct{ name, parent string }{
+		{"root", ""}, {"a", "root"}, {"b", "root"}, {"a1", "a"}, {"a2", "a"}, {"a1x", "a1"},
+	} {
+		record := &treeTestNode{Name: node.name}
+		if node.parent != "" {
+			parentID := ids[node.parent]
+			record.ParentID = &parentID
+		}
+		created, err := repo.Create(ctx, record)
+		require.NoError(t, err)
+		ids[node.name] = created.ID
+	}
+	return repo, ids
+}
+
+func treeNodeNames(nodes []*treeTestNode) []string {
+	names := make([]string, len(nodes))
+	for i, node := range nodes {
+		names[i] = node.Name
+	}
+	return names
+}
+
+func TestTreeRepository_Queries(t *testing.T) {
+	ctx := context.Background()
+	repo, ids := newTreeTestRepository(t)
+	tree, ok := repo.(TreeRepository[*treeTestNode])
+	require.True(t, ok)
+
+	children, err := tree.GetChildren(ctx, ids["a"])
+	require.NoError(t, err)
+	assert.Equal(t, []string{"a1", "a2"}, treeNodeNames(children))
+
+	descendants, err := tree.GetDescendants(ctx, ids["root"])
+	require.NoError(t, err)
+	names := treeNodeNames(descendants)
+	require.Len(t, names, 5)
+	assert.ElementsMatch(t, []string{"a", "b"}, names[:2])
+	assert.ElementsMatch(t, []string{"a1", "a2"}, names[2:4])
+	assert.Equal(t, "a1x", names[4])
+
+	descendants, err = tree.GetDescendants(ctx, ids["a"], SelectBy("name", "<>", "a2"))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"a1", "a1x"}, treeNodeNames(descendants))
+
+	ancestors, err := tree.GetAncestors(ctx, ids["a1x"])
+	require.NoError(t, err)
+	assert.Equal(t, []string{"a1", "a", "root"}, treeNodeNames(ancestors))
+
+	ancestors, err = tree.GetAncestors(ctx, ids["root"])
+	require.NoError(t, err)
+	assert.Empty(t, ancestors)
+}
+
+func TestTreeRepository_MoveSubtree(t *testing.T) {
+	ctx := context.Background()
+	repo, ids := newTreeTestRepository(t)
+	tree := repo.(TreeRepository[*treeTestNode])
+
+	moved, err := tree.MoveSubtree(ctx, ids["a1"], ids["b"])
+	require.NoError(t, err)
+	require.NotNil(t, moved.ParentID)
+	assert.Equal(t, ids["b"], *moved.ParentID)
+
+	ancestors, err := tree.GetAncestors(ctx, ids["a1x"])
+	require.NoError(t, err)
+	assert.Equal(t, []string{"a1", "b", "root"}, treeNodeNames(ancestors))
+
+	_, err = tree.MoveSubtree(ctx, ids["a"], ids["a"])
+	assert.True(t, goerrors.IsValidation(err))
+	_, err = tree.MoveSubtree(ctx, ids["root"], ids["a1x"])
+	assert.True(t, goerrors.IsValidation(err))
+
+	moved, err = tree.MoveSubtree(ctx, ids["a"], uuid.Nil)
+	require.NoError(t, err)
+	assert.Nil(t, moved.ParentID)
+
+	roots, _, err := repo.List(ctx, SelectIsNull("parent_node_id"))
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{"root", "a"}, treeNodeNames(roots))
+}
+
+func TestTreeRepository_RequiresParentColumn(t *testing.T) {
+	repo := newTestUserRepository(db)
+	_, err := repo.(TreeRepository[*TestUser]).GetChildren(context.Background(), uuid.New())
+	assert.True(t, goerrors.IsValidation(err))
+
+	repo = NewRepositoryWithConfig(db, testUserHandlers(), nil, WithParentColumn("missing_id"))
+	err = repo.(Validator).Validate()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "repository configuration invalid")
+}
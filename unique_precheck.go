@@ -0,0 +1,194 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/goliatone/go-errors"
+	"github.com/google/uuid"
+	"github.com/uptrace/bun"
+)
+
+// uniqueViolationTextCode marks the errors returned by the unique pre-checks.
+const uniqueViolationTextCode = "UNIQUE_VIOLATION"
+
+// WithUniquePrecheck declares unique column sets checked before Create,
+// CreateMany, Upsert and UpsertMany write a record:
+//
+//	WithUniquePrecheck([]string{"email"}, []string{"tenant_id", "slug"})
+//
+// Each set runs an existence query inside the transaction of the call, soft
+// deleted rows included, and a taken set fails the call with a validation
+// error (code 409) on the last column of the set, e.g. `slug: is already
+// taken for tenant_id`. Sets with a NULL value are not checked. The checks
+// make errors precise on every dialect but do not replace the unique
+// constraint: concurrent writes can still pass them. Unknown columns are
+// reported by Validate.
+func WithUniquePrecheck(columns ...[]string) RepoOption {
+	return func(cfg *repoConfig) {
+		if cfg == nil {
+			return
+		}
+		for _, set := range columns {
+			normalized := make([]string, 0, len(set))
+			for _, column := range set {
+				if column = strings.TrimSpace(column); column != "" {
+					normalized = append(normalized, column)
+				}
+			}
+			if len(normalized) > 0 {
+				cfg.uniquePrechecks = append(cfg.uniquePrechecks, normalized)
+			}
+		}
+	}
+}
+
+// checkUnique runs the unique pre-checks for record, ignoring the row of
+// record itself.
+func (r *repo[T]) checkUnique(ctx context.Context, tx bun.IDB, record T) error {
+	if len(r.uniquePrechecks) == 0 {
+		return nil
+	}
+
+	var validationErrors errors.ValidationErrors
+	var taken [][]string
+	for _, columns := range r.uniquePrechecks {
+		values, ok := r.uniqueValues(record, columns)
+		if !ok {
+			continue
+		}
+
+		q := tx.NewSelect().Model(r.handlers.NewRecord())
+		if querySoftDeletes(q) {
+			q = q.WhereAllWithDeleted()
+		}
+		for i, column := range columns {
+			q = q.Where("?TableAlias.? = ?", bun.Ident(column), values[i])
+		}
+		if id := r.handlers.GetID(record); id != uuid.Nil {
+			q = q.Where("?TableAlias.id <> ?", id)
+		}
+
+		exists, err := q.Exists(ctx)
+		if err != nil {
+			return r.mapError(err)
+		}
+		if exists {
+			validationErrors = append(validationErrors, uniqueFieldError(columns))
+			taken = append(taken, columns)
+		}
+	}
+
+	if len(validationErrors) == 0 {
+		return nil
+	}
+	return uniqueViolationError(validationErrors, taken)
+}
+
+// checkUniqueMany runs the unique pre-checks for records, reporting sets that
+// repeat within records as well.
+func (r *repo[T]) checkUniqueMany(ctx context.Context, tx bun.IDB, records []T) error {
+	if len(r.uniquePrechecks) == 0 {
+		return nil
+	}
+
+	for _, columns := range r.uniquePrechecks {
+		seen := make(map[string]struct{}, len(records))
+		for _, record := range records {
+			values, ok := r.uniqueValues(record, columns)
+			if !ok {
+				continue
+			}
+			key := fmt.Sprint(values...)
+			if _, dup := seen[key]; dup {
+				return uniqueViolationError(errors.ValidationErrors{uniqueFieldError(columns)}, [][]string{columns})
+			}
+			seen[key] = struct{}{}
+		}
+	}
+
+	for _, record := range records {
+		if err := r.checkUnique(ctx, tx, record); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// uniqueValues returns the values of columns on record, or false when one of
+// them is stored as NULL.
+func (r *repo[T]) uniqueValues(record T, columns []string) ([]any, bool) {
+	strct := reflect.Indirect(reflect.ValueOf(record))
+	if strct.Kind() != reflect.Struct {
+		return nil, false
+	}
+
+	values := make([]any, len(columns))
+	for i, column := range columns {
+		field := r.modelField(column)
+		if field == nil {
+			return nil, false
+		}
+		value := field.Value(strct)
+		if (field.IsPtr && value.IsNil()) || (field.NullZero && field.IsZero(value)) {
+			return nil, false
+		}
+		values[i] = value.Interface()
+	}
+	return values, true
+}
+
+func uniqueFieldError(columns []string) errors.FieldError {
+	message := "is already taken"
+	if len(columns) > 1 {
+		message += " for " + strings.Join(columns[:len(columns)-1], ", ")
+	}
+	return errors.FieldError{Field: columns[len(columns)-1], Message: message}
+}
+
+func uniqueViolationError(validationErrors errors.ValidationErrors, taken [][]string) error {
+	return errors.NewValidation("repository: unique values already taken", validationErrors...).
+		WithCode(errors.CodeConflict).
+		WithTextCode(uniqueViolationTextCode).
+		WithMetadata(map[string]any{"columns": taken})
+}
+
+// isUniqueConflict reports whether err is a duplicate key error or a failed
+// unique pre-check.
+func isUniqueConflict(err error) bool {
+	if IsDuplicatedKey(err) {
+		return true
+	}
+	var e *errors.Error
+	return errors.As(err, &e) && e.TextCode == uniqueViolationTextCode
+}
+
+func (r *repo[T]) validateUniquePrechecks() error {
+	if len(r.uniquePrechecks) == 0 {
+		return nil
+	}
+
+	table := r.modelTable()
+	if table == nil {
+		return nil
+	}
+
+	var validationErrors errors.ValidationErrors
+	for _, columns := range r.uniquePrechecks {
+		for _, column := range columns {
+			if _, ok := table.FieldMap[column]; !ok {
+				validationErrors = append(validationErrors, errors.FieldError{
+					Field:   "repoOptions.WithUniquePrecheck",
+					Message: fmt.Sprintf("unknown column %q on %s", column, table.Name),
+				})
+			}
+		}
+	}
+
+	if len(validationErrors) > 0 {
+		return errors.NewValidation("repository configuration invalid", validationErrors...)
+	}
+	return nil
+}
@@ -0,0 +1,113 @@
+package repository
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	goerrors "github.com/goliatone/go-errors"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWithUniquePrecheck_Create(t *testing.T) {
+	ctx := context.Background()
+	setupTestData(t)
+	companyID := uuid.New()
+
+	repo := newTestUserRepositoryWithConfig(db, nil,
+		WithUniquePrecheck([]string{"email"}, []string{"company_id", "name"}),
+	)
+	_, err := repo.Create(ctx, &TestUser{Name: "Alice", Email: "alice@example.com", CompanyID: companyID})
+	require.NoError(t, err)
+
+	_, err = repo.Create(ctx, &TestUser{Name: "Alice", Email: "alice@example.com", CompanyID: companyID})
+	require.Error(t, err)
+
+	var validation *goerrors.Error
+	require.ErrorAs(t, err, &validation)
+	assert.True(t, goerrors.IsValidation(err))
+	assert.Equal(t, goerrors.CodeConflict, validation.Code)
+	assert.Equal(t, "UNIQUE_VIOLATION", validation.TextCode)
+	assert.Equal(t, map[string]string{
+		"email": "is already taken",
+		"name":  "is already taken for company_id",
+	}, validation.ValidationMap())
+
+	_, err = repo.Create(ctx, &TestUser{Name: "Alice", Email: "alice2@example.com", CompanyID: uuid.New()})
+	require.NoError(t, err, "same name in another company")
+
+	_, err = repo.CreateMany(ctx, []*TestUser{
+		{Name: "Bob", Email: "bob@example.com", CompanyID: companyID},
+		{Name: "Bobby", Email: "bob@example.com", CompanyID: companyID},
+	})
+	require.Error(t, err)
+	require.ErrorAs(t, err, &validation)
+	assert.Equal(t, map[string]string{"email": "is already taken"}, validation.ValidationMap())
+
+	total, err := repo.Count(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 2, total)
+}
+
+func TestWithUniquePrecheck_Upsert(t *testing.T) {
+	ctx := context.Background()
+	setupTestData(t)
+	companyID := uuid.New()
+
+	repo := newTestUserRepositoryWithConfig(db, nil, WithUniquePrecheck([]string{"company_id", "name"}))
+	alice, err := repo.Create(ctx, &TestUser{Name: "Alice", Email: "alice@example.com", CompanyID: companyID})
+	require.NoError(t, err)
+	_, err = repo.Create(ctx, &TestUser{Name: "Bob", Email: "bob@example.com", CompanyID: companyID})
+	require.NoError(t, err)
+
+	updated, err := repo.Upsert(ctx, &TestUser{Name: "Alice", Email: "alice@example.com", CompanyID: companyID, CreatedAt: time.Now()})
+	require.NoError(t, err, "a record does not conflict with itself")
+	assert.Equal(t, alice.ID, updated.ID)
+
+	_, err = repo.Upsert(ctx, &TestUser{Name: "Bob", Email: "alice@example.com", CompanyID: companyID})
+	require.Error(t, err)
+	assert.True(t, goerrors.IsValidation(err))
+
+	_, err = repo.UpsertMany(ctx, []*TestUser{{Name: "Bob", Email: "carol@example.com", CompanyID: companyID}})
+	require.Error(t, err)
+	assert.True(t, goerrors.IsValidation(err))
+}
+
+func TestWithUniquePrecheck_SoftDeletedRows(t *testing.T) {
+	ctx := context.Background()
+	repo := newRetentionTestEventRepository(t, WithUniquePrecheck([]string{"name"}))
+
+	event, err := repo.Create(ctx, &retentionTestEvent{Name: "launch", CreatedAt: time.Now()})
+	require.NoError(t, err)
+	require.NoError(t, repo.Delete(ctx, event))
+
+	_, err = repo.Create(ctx, &retentionTestEvent{Name: "launch", CreatedAt: time.Now()})
+	require.Error(t, err, "soft deleted rows still hold unique values")
+	assert.True(t, isUniqueConflict(err))
+}
+
+func TestWithUniquePrecheck_ImportSkip(t *testing.T) {
+	ctx := context.Background()
+	setupTestData(t)
+
+	repo := newTestUserRepositoryWithConfig(db, nil, WithUniquePrecheck([]string{"email"}))
+	_, err := repo.Create(ctx, &TestUser{Name: "Alice", Email: "alice@example.com"})
+	require.NoError(t, err)
+
+	report, err := repo.(RecordImporter).Import(ctx, []map[string]any{
+		{"name": "Alice Again", "email": "alice@example.com"},
+	}, WithImportConflictStrategy(ImportSkip))
+	require.NoError(t, err)
+	assert.Equal(t, ImportReport{Rows: 1, Skipped: 1}, report)
+}
+
+func TestWithUniquePrecheck_Validate(t *testing.T) {
+	repo := NewRepositoryWithConfig(db, testUserHandlers(), nil, WithUniquePrecheck([]string{"email", "missing"}))
+
+	err := repo.(Validator).Validate()
+	require.Error(t, err)
+	assert.True(t, goerrors.IsValidation(err))
+	assert.Contains(t, err.Error(), "missing")
+}
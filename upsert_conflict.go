@@ -0,0 +1,269 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/goliatone/go-errors"
+	"github.com/google/uuid"
+	"github.com/uptrace/bun"
+	"github.com/uptrace/bun/schema"
+)
+
+// EventUpsert is the operation of events published by UpsertOnConflict,
+// which cannot tell whether the statement inserted or updated the row.
+const EventUpsert EventOperation = "upsert"
+
+// ConflictUpserter is an optional capability for repositories that can
+// upsert records with a single INSERT ... ON CONFLICT statement.
+type ConflictUpserter[T any] interface {
+	UpsertOnConflict(ctx context.Context, record T, conflictColumns []string, updateColumns []string) (T, error)
+	UpsertOnConflictTx(ctx context.Context, tx bun.IDB, record T, conflictColumns []string, updateColumns []string) (T, error)
+	UpsertManyOnConflict(ctx context.Context, records []T, target ConflictTarget, updateColumns []string) ([]T, error)
+	UpsertManyOnConflictTx(ctx context.Context, tx bun.IDB, records []T, target ConflictTarget, updateColumns []string) ([]T, error)
+}
+
+// ConflictTarget is the ON CONFLICT target of UpsertManyOnConflict: the
+// columns of a unique index and, for a partial unique index, its predicate.
+type ConflictTarget struct {
+	// Columns default to the primary key.
+	Columns []string
+	// Where is the predicate of a partial unique index, e.g.
+	// "deleted_at IS NULL", with ? placeholders bound to Args. It must match
+	// the index predicate for Postgres and SQLite to infer the index. It is
+	// spliced into the statement as is, with only statement separators and
+	// comments rejected, so it must never come from user input.
+	Where string
+	Args  []any
+}
+
+// UpsertOnConflict inserts record, or updates updateColumns of the row
+// conflicting with it on conflictColumns, in a single statement:
+//
+//	repo.(ConflictUpserter[*Reading]).UpsertOnConflict(ctx, reading,
+//		[]string{"device_id", "taken_at"}, []string{"value", "updated_at"})
+//
+// conflictColumns default to the primary key and must match a unique index.
+// updateColumns default to every column except the primary key, the conflict
+// columns and read-only columns; read-only columns are never updated. The
+// returned record holds the stored row, including the ID of a row that
+// already existed. MySQL ignores the conflict target and updates on any
+// unique key conflict.
+func (r *repo[T]) UpsertOnConflict(ctx context.Context, record T, conflictColumns []string, updateColumns []string) (T, error) {
+	return r.UpsertOnConflictTx(ctx, r.db, record, conflictColumns, updateColumns)
+}
+
+func (r *repo[T]) UpsertOnConflictTx(ctx context.Context, tx bun.IDB, record T, conflictColumns []string, updateColumns []string) (T, error) {
+	ctx = r.withOperation(ctx, "UpsertOnConflict", 0)
+	var zero T
+	result, err := r.upsertOnConflict(ctx, tx, []T{record}, ConflictTarget{Columns: conflictColumns}, updateColumns)
+	if err != nil {
+		return zero, err
+	}
+	return result[0], nil
+}
+
+// UpsertManyOnConflict upserts records like UpsertOnConflict, in a single
+// statement, against target, which may name a partial unique index:
+//
+//	repo.(ConflictUpserter[*User]).UpsertManyOnConflict(ctx, users,
+//		repository.ConflictTarget{Columns: []string{"email"}, Where: "deleted_at IS NULL"}, nil)
+//
+// Records are returned in input order holding the stored rows. MySQL, which
+// has no partial indexes, ignores the target.
+func (r *repo[T]) UpsertManyOnConflict(ctx context.Context, records []T, target ConflictTarget, updateColumns []string) ([]T, error) {
+	return r.UpsertManyOnConflictTx(ctx, r.db, records, target, updateColumns)
+}
+
+func (r *repo[T]) UpsertManyOnConflictTx(ctx context.Context, tx bun.IDB, records []T, target ConflictTarget, updateColumns []string) ([]T, error) {
+	ctx = r.withOperation(ctx, "UpsertManyOnConflict", 0)
+	if len(records) == 0 {
+		return []T{}, nil
+	}
+	return r.upsertOnConflict(ctx, tx, records, target, updateColumns)
+}
+
+func (r *repo[T]) upsertOnConflict(ctx context.Context, tx bun.IDB, records []T, target ConflictTarget, updateColumns []string) ([]T, error) {
+	table := r.modelTable()
+	if table == nil {
+		return nil, fmt.Errorf("repository: upsert on conflict: unknown model table")
+	}
+
+	conflict, err := upsertColumns(table, "conflictColumns", target.Columns)
+	if err != nil {
+		return nil, err
+	}
+	if len(conflict) == 0 {
+		for _, field := range table.PKs {
+			conflict = append(conflict, field.Name)
+		}
+	}
+	where, predicate := "", ""
+	if strings.TrimSpace(target.Where) != "" {
+		var ok bool
+		predicate, ok = normalizeSQLPredicate(target.Where)
+		if !ok {
+			return nil, errors.NewValidation(
+				"repository: invalid conflict target",
+				errors.FieldError{Field: "where", Message: fmt.Sprintf("invalid predicate %q", target.Where)},
+			)
+		}
+		where = " WHERE " + predicate
+	}
+	update, err := upsertColumns(table, "updateColumns", updateColumns)
+	if err != nil {
+		return nil, err
+	}
+	if len(update) == 0 {
+		for _, field := range table.DataFields {
+			if !containsString(conflict, field.Name) {
+				update = append(update, field.Name)
+			}
+		}
+	}
+	writable := update[:0]
+	for _, column := range update {
+		if !containsString(r.readOnlyColumns, column) {
+			writable = append(writable, column)
+		}
+	}
+	update = writable
+	if r.integrity != nil && !containsString(update, r.integrity.field.Name) && len(update) > 0 {
+		update = append(update, r.integrity.field.Name)
+	}
+	if len(update) == 0 {
+		return nil, errors.NewValidation(
+			"repository: nothing to update on conflict",
+			errors.FieldError{Field: "updateColumns", Message: "no writable column left to update"},
+		)
+	}
+
+	for _, record := range records {
+		if r.handlers.GetID(record) == uuid.Nil {
+			r.handlers.SetID(record, uuid.New())
+		}
+	}
+
+	var result []T
+	err = r.eventTx(ctx, tx, func(ctx context.Context, tx bun.IDB) error {
+		q := tx.NewInsert().Model(&records)
+		q = r.applyInsertScopes(ctx, q)
+
+		mysql := r.driver == "mysql"
+		if mysql {
+			q = q.On("DUPLICATE KEY UPDATE")
+		} else {
+			q = q.On(fmt.Sprintf("CONFLICT (%s)%s DO UPDATE", strings.Join(conflict, ", "), where), target.Args...)
+		}
+		for _, column := range update {
+			if mysql {
+				q = q.Set("? = VALUES(?)", bun.Ident(column), bun.Ident(column))
+			} else {
+				q = q.Set("? = EXCLUDED.?", bun.Ident(column), bun.Ident(column))
+			}
+		}
+		if err := r.stampIntegrity(records...); err != nil {
+			return err
+		}
+		if err := r.encryptRecords(records...); err != nil {
+			return err
+		}
+		if err := r.recordConflictHistory(ctx, tx, records, table, conflict, predicate, target.Args); err != nil {
+			return err
+		}
+
+		if mysql {
+			if _, err := q.Exec(ctx); err != nil {
+				return r.mapError(err)
+			}
+			result = make([]T, 0, len(records))
+			for _, record := range records {
+				stored, err := r.reloadByColumns(ctx, tx, record, table, conflict)
+				if err != nil {
+					return err
+				}
+				result = append(result, stored)
+			}
+		} else {
+			if _, err := q.Returning("*").Exec(ctx); err != nil {
+				return r.mapError(err)
+			}
+			result = records
+		}
+		if err := r.rehashRecords(ctx, tx, result...); err != nil {
+			return err
+		}
+		return r.publishEvents(ctx, tx, EventUpsert, update, result...)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// recordConflictHistory copies the rows records conflict with on columns,
+// among the rows matching predicate when given, into the history table
+// before the upsert updates them. On MySQL, which updates on any unique key
+// conflict, only conflicts on columns are recorded.
+func (r *repo[T]) recordConflictHistory(ctx context.Context, tx bun.IDB, records []T, table *schema.Table, columns []string, predicate string, args []any) error {
+	source := r.newHistorySource(tx)
+	if source == nil {
+		return nil
+	}
+	if querySoftDeletes(source) {
+		source = source.WhereAllWithDeleted()
+	}
+	source = source.WhereGroup(" AND ", func(q *bun.SelectQuery) *bun.SelectQuery {
+		for _, record := range records {
+			value := reflect.Indirect(reflect.ValueOf(record))
+			q = q.WhereGroup(" OR ", func(q *bun.SelectQuery) *bun.SelectQuery {
+				for _, column := range columns {
+					q = q.Where("?TableAlias.? = ?", bun.Ident(column), table.FieldMap[column].Value(value).Interface())
+				}
+				return q
+			})
+		}
+		return q
+	})
+	if predicate != "" {
+		source = source.Where(predicate, args...)
+	}
+	return r.recordHistoryOf(ctx, tx, EventUpdate, source)
+}
+
+// upsertColumns validates that columns are columns of table.
+func upsertColumns(table *schema.Table, field string, columns []string) ([]string, error) {
+	safe := make([]string, 0, len(columns))
+	for _, column := range columns {
+		normalized, ok := normalizeSQLIdentifier(column)
+		if !ok {
+			return nil, invalidColumnError(field, column)
+		}
+		if _, ok := table.FieldMap[normalized]; !ok {
+			return nil, errors.NewValidation(
+				"repository: unknown column",
+				errors.FieldError{Field: field, Message: fmt.Sprintf("column %q does not exist on %s", normalized, table.Name)},
+			)
+		}
+		safe = append(safe, normalized)
+	}
+	return safe, nil
+}
+
+// reloadByColumns selects the row matching the values of columns in record.
+func (r *repo[T]) reloadByColumns(ctx context.Context, tx bun.IDB, record T, table *schema.Table, columns []string) (T, error) {
+	value := reflect.ValueOf(record)
+	for value.Kind() == reflect.Pointer {
+		value = value.Elem()
+	}
+	criteria := make([]SelectCriteria, 0, len(columns))
+	for _, column := range columns {
+		fieldValue := table.FieldMap[column].Value(value).Interface()
+		criteria = append(criteria, func(q *bun.SelectQuery) *bun.SelectQuery {
+			return q.Where("?TableAlias.? = ?", bun.Ident(column), fieldValue)
+		})
+	}
+	return r.GetTx(WithoutRelations(ctx), tx, criteria...)
+}
@@ -0,0 +1,111 @@
+package repository
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	goerrors "github.com/goliatone/go-errors"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRepository_UpsertOnConflict(t *testing.T) {
+	setupTestData(t)
+	ctx := context.Background()
+
+	publisher := &recordingPublisher{}
+	repo := newTestUserRepositoryWithConfig(db, nil, WithEventPublisher(publisher))
+	upserter, ok := repo.(ConflictUpserter[*TestUser])
+	require.True(t, ok)
+
+	companyID := uuid.New()
+	created, err := upserter.UpsertOnConflict(ctx,
+		&TestUser{Name: "Alice", Email: "alice@example.com", CompanyID: companyID},
+		[]string{"email"}, []string{"name"})
+	require.NoError(t, err)
+	require.NotEqual(t, uuid.Nil, created.ID)
+
+	updated, err := upserter.UpsertOnConflict(ctx,
+		&TestUser{Name: "Alicia", Email: "alice@example.com", CompanyID: uuid.New()},
+		[]string{"email"}, []string{"name"})
+	require.NoError(t, err)
+	assert.Equal(t, created.ID, updated.ID, "the existing row is returned")
+	assert.Equal(t, "Alicia", updated.Name)
+	assert.Equal(t, companyID, updated.CompanyID, "columns outside updateColumns are kept")
+
+	count, err := repo.Count(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, count)
+
+	events := publisher.recorded()
+	require.Len(t, events, 2)
+	assert.Equal(t, EventUpsert, events[1].Operation)
+	assert.Equal(t, []string{"name"}, events[1].Columns)
+}
+
+func TestRepository_UpsertOnConflictDefaults(t *testing.T) {
+	setupTestData(t)
+	ctx := context.Background()
+
+	repo := newTestUserRepositoryWithConfig(db, nil, WithReadOnlyColumns("created_at"))
+	upserter := repo.(ConflictUpserter[*TestUser])
+
+	createdAt := time.Now().Add(-time.Hour).UTC()
+	user, err := upserter.UpsertOnConflict(ctx, &TestUser{Name: "Bob", Email: "bob@example.com", CreatedAt: createdAt}, nil, nil)
+	require.NoError(t, err)
+
+	replay := &TestUser{ID: user.ID, Name: "Robert", Email: "robert@example.com", CreatedAt: time.Now().UTC()}
+	replayed, err := upserter.UpsertOnConflict(ctx, replay, nil, nil)
+	require.NoError(t, err)
+	assert.Equal(t, "Robert", replayed.Name)
+	assert.Equal(t, "robert@example.com", replayed.Email)
+	assert.WithinDuration(t, createdAt, replayed.CreatedAt, time.Millisecond, "read-only columns are not updated")
+
+	_, err = upserter.UpsertOnConflict(ctx, replay, []string{"missing"}, nil)
+	assert.True(t, goerrors.IsValidation(err))
+
+	_, err = upserter.UpsertOnConflict(ctx, replay, nil, []string{"name;drop"})
+	assert.True(t, goerrors.IsValidation(err))
+}
+
+func TestRepository_UpsertManyOnConflict_PartialUniqueIndex(t *testing.T) {
+	setupTestData(t)
+	ctx := context.Background()
+
+	publisher := &recordingPublisher{}
+	repo := newTestUserRepositoryWithConfig(db, nil, WithEventPublisher(publisher))
+	upserter := repo.(ConflictUpserter[*TestUser])
+
+	_, err := db.ExecContext(ctx, `CREATE UNIQUE INDEX test_users_company_name_idx ON test_users (company_id, name) WHERE name <> ''`)
+	require.NoError(t, err)
+
+	companyID := uuid.New()
+	first, err := repo.Create(ctx, &TestUser{Name: "Alice", Email: "alice@example.com", CompanyID: companyID})
+	require.NoError(t, err)
+
+	target := ConflictTarget{Columns: []string{"company_id", "name"}, Where: "name <> ?", Args: []any{""}}
+	upserted, err := upserter.UpsertManyOnConflict(ctx, []*TestUser{
+		{Name: "Bob", Email: "bob@example.com", CompanyID: companyID},
+		{Name: "Alice", Email: "alice@new.example.com", CompanyID: companyID},
+	}, target, []string{"email"})
+	require.NoError(t, err)
+	require.Len(t, upserted, 2)
+	assert.Equal(t, "Bob", upserted[0].Name)
+	assert.Equal(t, first.ID, upserted[1].ID, "the existing row is returned in input order")
+	assert.Equal(t, "alice@new.example.com", upserted[1].Email)
+
+	count, err := repo.Count(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 2, count)
+	assert.Len(t, publisher.recorded(), 3)
+
+	_, err = upserter.UpsertManyOnConflict(ctx, []*TestUser{{Name: "Carol", Email: "carol@example.com"}},
+		ConflictTarget{Columns: []string{"email"}, Where: "1=1; DROP TABLE test_users"}, nil)
+	assert.True(t, goerrors.IsValidation(err))
+
+	none, err := upserter.UpsertManyOnConflict(ctx, nil, target, nil)
+	require.NoError(t, err)
+	assert.Empty(t, none)
+}
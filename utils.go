@@ -4,6 +4,7 @@ import (
 	"database/sql"
 	stderrors "errors"
 	"fmt"
+	"strings"
 
 	"github.com/goliatone/go-errors"
 )
@@ -11,6 +12,16 @@ import (
 // ErrRecordNotFound is a sentinel error that enables errors.Is(err, ErrRecordNotFound) checks.
 var ErrRecordNotFound = stderrors.New("repository: record not found")
 
+// ErrFullTableOperationBlocked is returned (wrapped in a validation error) when a
+// bulk delete or update without criteria is attempted and full-table operations
+// have not been explicitly allowed.
+var ErrFullTableOperationBlocked = stderrors.New("repository: full-table operation blocked")
+
+// ErrEventCommitUnobservable is returned (wrapped in an operation error) by
+// writes that would publish events from inside a transaction whose commit the
+// repository cannot observe, i.e. one not started with RunInTx.
+var ErrEventCommitUnobservable = stderrors.New("repository: event publishing requires RunInTx")
+
 func SQLExpectedCount(res sql.Result, expected int64) error {
 	total, err := res.RowsAffected()
 	if err != nil {
@@ -31,6 +42,30 @@ func SQLExpectedCount(res sql.Result, expected int64) error {
 	return nil
 }
 
+// PartialResultError is returned alongside the records that were found when a
+// batch lookup such as GetByIDs could not find every requested ID.
+type PartialResultError struct {
+	MissingIDs []string
+}
+
+func (e *PartialResultError) Error() string {
+	return fmt.Sprintf("repository: %d record(s) not found: %s", len(e.MissingIDs), strings.Join(e.MissingIDs, ", "))
+}
+
+// IsPartialResult reports whether err is a *PartialResultError.
+func IsPartialResult(err error) bool {
+	var partial *PartialResultError
+	return stderrors.As(err, &partial)
+}
+
+func rowsAffected(res sql.Result) (int64, error) {
+	total, err := res.RowsAffected()
+	if err != nil {
+		return 0, errors.Wrap(err, CategoryDatabase, "Failed to get rows affected count")
+	}
+	return total, nil
+}
+
 // And add a category checker:
 func IsSQLExpectedCountViolation(err error) bool {
 	return errors.IsCategory(err, CategoryDatabaseExpectedCount)
@@ -62,3 +97,9 @@ func IsRecordNotFound(err error) bool {
 
 	return false
 }
+
+// IsFullTableOperationBlocked reports whether err was caused by the full-table
+// delete/update guard.
+func IsFullTableOperationBlocked(err error) bool {
+	return stderrors.Is(err, ErrFullTableOperationBlocked)
+}